@@ -0,0 +1,125 @@
+package bukudb
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+)
+
+// aliasesTable holds further URLs of bookmarks, like mirrors of the same
+// docs, by bookmark ID. It only exists once an alias has been added.
+const aliasesTable = "robuku_aliases"
+
+const aliasesSchema = `CREATE TABLE IF NOT EXISTS ` + aliasesTable + ` (
+    id INTEGER NOT NULL,
+    url TEXT NOT NULL,
+    PRIMARY KEY (id, url)
+);`
+
+// ErrAliasIsURL is returned by AddAlias for the URL of the bookmark itself.
+var ErrAliasIsURL = errors.New("the alias is the bookmark's URL")
+
+// AddAlias adds url as another URL of the bookmark with the given ID,
+// creating the aliases table if needed. Adding an alias twice keeps one.
+func (db *BukuDB) AddAlias(id uint16, url string) error {
+	db.mu.Lock()
+	defer db.mu.Unlock()
+
+	url = strings.TrimSpace(url)
+	if url == "" {
+		return errors.New("no alias URL given")
+	}
+	if _, err := db.syncLocked(); err != nil {
+		return err
+	}
+	if id < 1 || int(id) > db.len {
+		return fmt.Errorf("id %d out of range (1-%d)", id, db.len)
+	}
+	var primary string
+	if err := db.conn.QueryRow(`SELECT URL FROM bookmarks WHERE id = ?`, id).Scan(&primary); err != nil {
+		return fmt.Errorf("failed to look up bookmark: %w", err)
+	}
+	if url == primary {
+		return ErrAliasIsURL
+	}
+
+	if _, err := db.conn.Exec(aliasesSchema); err != nil {
+		return fmt.Errorf("failed to create aliases table: %w", err)
+	}
+	_, err := db.conn.Exec(`INSERT OR IGNORE INTO `+aliasesTable+` (id, url) VALUES (?, ?)`, id, url)
+	if err != nil {
+		return fmt.Errorf("failed to add alias: %w", err)
+	}
+	return nil
+}
+
+// RemoveAlias removes the alias url of the bookmark with the given ID, it
+// is not an error if there is no such alias.
+func (db *BukuDB) RemoveAlias(id uint16, url string) error {
+	db.mu.Lock()
+	defer db.mu.Unlock()
+
+	ok, err := hasTable(db.conn, aliasesTable)
+	if err != nil || !ok {
+		return err
+	}
+	if _, err := db.conn.Exec(`DELETE FROM `+aliasesTable+` WHERE id = ? AND url = ?`, id, url); err != nil {
+		return fmt.Errorf("failed to remove alias: %w", err)
+	}
+	return nil
+}
+
+// GetAliases returns the aliases of the bookmark with the given ID in the
+// order they were added.
+func (db *BukuDB) GetAliases(id uint16) ([]string, error) {
+	db.mu.Lock()
+	defer db.mu.Unlock()
+
+	ok, err := hasTable(db.conn, aliasesTable)
+	if err != nil || !ok {
+		return nil, err
+	}
+	rows, err := db.conn.Query(`SELECT url FROM `+aliasesTable+` WHERE id = ? ORDER BY rowid`, id)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query aliases: %w", err)
+	}
+	defer rows.Close()
+
+	var aliases []string
+	for rows.Next() {
+		var url string
+		if err := rows.Scan(&url); err != nil {
+			return nil, fmt.Errorf("failed to scan alias: %w", err)
+		}
+		aliases = append(aliases, url)
+	}
+	return aliases, rows.Err()
+}
+
+// GetAllAliases returns the aliases of every bookmark having any by
+// bookmark ID, it is empty if no alias was ever added.
+func (db *BukuDB) GetAllAliases() (map[uint16][]string, error) {
+	db.mu.Lock()
+	defer db.mu.Unlock()
+
+	aliases := make(map[uint16][]string)
+	ok, err := hasTable(db.conn, aliasesTable)
+	if err != nil || !ok {
+		return aliases, err
+	}
+	rows, err := db.conn.Query(`SELECT id, url FROM ` + aliasesTable + ` ORDER BY id, rowid`)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query aliases: %w", err)
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var id uint16
+		var url string
+		if err := rows.Scan(&id, &url); err != nil {
+			return nil, fmt.Errorf("failed to scan alias: %w", err)
+		}
+		aliases[id] = append(aliases[id], url)
+	}
+	return aliases, rows.Err()
+}
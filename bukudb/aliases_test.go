@@ -0,0 +1,103 @@
+package bukudb
+
+import (
+	"errors"
+	"maps"
+	"slices"
+	"testing"
+)
+
+func Test_Aliases(t *testing.T) {
+	createTestDb(t)
+	db, err := NewBukuDB(sqlTestDbPath)
+	defer cleanUpTestDB(t, db)
+
+	if err != nil {
+		t.Fatalf("expected no error on NewBukuDB(), got '%v'", err)
+	}
+
+	// no table yet
+	if aliases, err := db.GetAliases(1); err != nil || aliases != nil {
+		t.Errorf("expected no aliases, got %q and '%v'", aliases, err)
+	}
+	if all, err := db.GetAllAliases(); err != nil || len(all) != 0 {
+		t.Errorf("expected no aliases, got %v and '%v'", all, err)
+	}
+	if err := db.RemoveAlias(1, "https://a.org"); err != nil {
+		t.Errorf("expected no error removing without a table, got '%v'", err)
+	}
+
+	for _, url := range []string{"https://a.org", " https://a.net ", "https://a.org"} {
+		if err := db.AddAlias(1, url); err != nil {
+			t.Fatalf("expected no error on AddAlias(), got '%v'", err)
+		}
+	}
+	if err := db.AddAlias(3, "https://c.org"); err != nil {
+		t.Fatal(err)
+	}
+	if aliases, _ := db.GetAliases(1); !slices.Equal(aliases, []string{"https://a.org", "https://a.net"}) {
+		t.Errorf("expected aliases in the order added without duplicates, got %q", aliases)
+	}
+
+	if err := db.AddAlias(1, "https://www.a.com"); !errors.Is(err, ErrAliasIsURL) {
+		t.Errorf("expected ErrAliasIsURL, got '%v'", err)
+	}
+	if err := db.AddAlias(1, "  "); err == nil {
+		t.Errorf("expected error for an empty alias")
+	}
+	if err := db.AddAlias(9, "https://x.org"); err == nil {
+		t.Errorf("expected error for an ID out of range")
+	}
+
+	if err := db.RemoveAlias(1, "https://a.org"); err != nil {
+		t.Fatalf("expected no error on RemoveAlias(), got '%v'", err)
+	}
+	all, err := db.GetAllAliases()
+	if err != nil {
+		t.Fatal(err)
+	}
+	expected := map[uint16][]string{1: {"https://a.net"}, 3: {"https://c.org"}}
+	if !maps.EqualFunc(all, expected, slices.Equal) {
+		t.Errorf("expected aliases %v, got %v", expected, all)
+	}
+}
+
+func Test_Aliases_FollowRemove(t *testing.T) {
+	createTestDb(t)
+	db, err := NewBukuDB(sqlTestDbPath)
+	defer cleanUpTestDB(t, db)
+
+	if err != nil {
+		t.Fatalf("expected no error on NewBukuDB(), got '%v'", err)
+	}
+	for id, url := range map[uint16]string{1: "https://a.org", 2: "https://b.org", 3: "https://c.org", 4: "https://d.org"} {
+		if err := db.AddAlias(id, url); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := db.AddAlias(4, "https://d.net"); err != nil {
+		t.Fatal(err)
+	}
+
+	// the aliases of the removed bookmark go, the later ones move down
+	if err := db.Remove(2); err != nil {
+		t.Fatalf("expected no error on Remove(), got '%v'", err)
+	}
+	all, _ := db.GetAllAliases()
+	expected := map[uint16][]string{1: {"https://a.org"}, 2: {"https://c.org"}, 3: {"https://d.org", "https://d.net"}}
+	if !maps.EqualFunc(all, expected, slices.Equal) {
+		t.Errorf("expected aliases %v after Remove(), got %v", expected, all)
+	}
+
+	if err := db.BulkRemove([]uint16{1, 2}); err != nil {
+		t.Fatalf("expected no error on BulkRemove(), got '%v'", err)
+	}
+	all, _ = db.GetAllAliases()
+	expected = map[uint16][]string{1: {"https://d.org", "https://d.net"}}
+	if !maps.EqualFunc(all, expected, slices.Equal) {
+		t.Errorf("expected aliases %v after BulkRemove(), got %v", expected, all)
+	}
+	if b, _ := db.Get(1); b.URL != "https://www.d.com" {
+		t.Errorf("expected the aliases to stay with https://www.d.com, got '%s'", b.URL)
+	}
+}
@@ -1,32 +1,63 @@
 // bukudb, for use with https://github.com/jarun/Buku
+//
+// Other tools may build on the exported API, which keeps its signatures and
+// behavior: Bookmark, DBInterface and its implementations BukuDB and
+// DryRunDB, NewBukuDB with its Options, and the errors ErrDuplicateURL,
+// ErrChangedOnDisk, DuplicateURLError, LimitError, and NotBukuDBError.
+//
+// Like buku, bookmarks are numbered from 1 to Len without gaps. IDs are 16
+// bit and at most MaxBookmarks are kept unless WithMaxBookmarks raises it.
+// Remove renumbers the bookmarks after the removed one, so IDs held across a
+// Remove must be looked up again, e.g. by URL.
 package bukudb
 
 import (
+	"context"
 	"database/sql"
 	"fmt"
+	"os"
 	"runtime"
 	"slices"
 	"sort"
 	"strings"
 	"sync"
+	"time"
 
 	_ "github.com/mattn/go-sqlite3"
 )
 
-/* buku database schema
-bookmarks (
+// schema is the buku database schema
+const schema = `CREATE TABLE IF NOT EXISTS bookmarks (
     id INTEGER PRIMARY KEY,
     URL TEXT NOT NULL UNIQUE,
     metadata TEXT DEFAULT '',
     tags TEXT DEFAULT ',',
     desc TEXT DEFAULT '',
     flags INTEGER DEFAULT 0
-);
-*/
+);`
 
-// MaxBookmarks defines the maximum number of bookmarks that can be stored.
+// MaxBookmarks defines the default maximum number of bookmarks that can be
+// stored, see WithMaxBookmarks.
 const MaxBookmarks = 1000
 
+// LimitError is returned when a bookmark is added to a full database.
+type LimitError struct {
+	// Count is the number of bookmarks in the database.
+	Count int
+
+	// Max is the maximum number of bookmarks.
+	Max int
+}
+
+func (e *LimitError) Error() string {
+	return fmt.Sprintf("maximum number of bookmarks reached (%d of %d)", e.Count, e.Max)
+}
+
+var (
+	_ DBInterface = (*BukuDB)(nil)
+	_ DBInterface = (*DryRunDB)(nil)
+)
+
 // Bookmark represents a single bookmark entry.
 type Bookmark struct {
 	// ID is the unique identifier for the bookmark.
@@ -43,58 +74,300 @@ type Bookmark struct {
 
 	// Comment or description of the bookmark.
 	Comment string
+
+	// Created is when the bookmark was added, nil if unknown.
+	Created *time.Time
+}
+
+// TagCount is the number of bookmarks carrying a tag.
+type TagCount struct {
+	Tag   string
+	Count int
 }
 
+// DomainCount is the number of bookmarks pointing to a domain.
+type DomainCount struct {
+	Domain string
+	Count  int
+}
+
+// Stats holds aggregated information about the bookmarks in db.
+type Stats struct {
+	// Total number of bookmarks.
+	Total int
+
+	// Tags is the number of distinct tags.
+	Tags int
+
+	// TopTags are the most used tags, most used first.
+	TopTags []TagCount
+
+	// Untagged is the number of bookmarks without tags.
+	Untagged int
+
+	// Untitled is the number of bookmarks without a title.
+	Untitled int
+
+	// Insecure is the number of bookmarks with a plain http URL.
+	Insecure int
+
+	// TopDomains are the most bookmarked domains, most bookmarked first.
+	TopDomains []DomainCount
+
+	// Visited is the number of bookmarks opened at least once.
+	Visited int
+
+	// FileSize of the database file in bytes.
+	FileSize int64
+}
+
+const (
+	statsTopTags    = 10
+	statsTopDomains = 5
+)
+
+// statsTagsCTE splits the comma delimited tags column into one row per tag,
+// trimmed like parseTagsColumn trims them.
+const statsTagsCTE = `WITH RECURSIVE split(id, tag, rest) AS (
+	SELECT id, '', trim(tags, ',') || ',' FROM bookmarks WHERE tags IS NOT NULL
+	UNION ALL
+	SELECT id, trim(substr(rest, 1, instr(rest, ',') - 1)), substr(rest, instr(rest, ',') + 1)
+	FROM split WHERE rest != ''
+) `
+
+// statsDomainsQuery counts bookmarks per host, ignoring scheme and "www.".
+const statsDomainsQuery = `WITH hosts AS (
+	SELECT CASE WHEN instr(URL, '://') > 0
+		THEN substr(URL, instr(URL, '://') + 3) ELSE URL END AS h
+	FROM bookmarks
+), domains AS (
+	SELECT CASE WHEN instr(h, '/') > 0 THEN substr(h, 1, instr(h, '/') - 1) ELSE h END AS d
+	FROM hosts
+)
+SELECT CASE WHEN d LIKE 'www.%' THEN substr(d, 5) ELSE d END AS domain, COUNT(*) AS n
+FROM domains WHERE d != '' GROUP BY domain ORDER BY n DESC, domain LIMIT ?`
+
+// DBInterface is the database robuku works with, implemented by BukuDB and
+// DryRunDB.
 type DBInterface interface {
 	Close() error
 	Len() int
+	Stats() (Stats, error)
+	Tags() ([]TagCount, error)
 	GetAll() ([]Bookmark, error)
+	GetAllContext(ctx context.Context) ([]Bookmark, error)
+	GetAllByCreated() ([]Bookmark, error)
+	GetRecent(n int, exclude []string) ([]Bookmark, error)
+	GetByIDs(ids []uint16) ([]Bookmark, error)
+	SearchRanked(query string) ([]Bookmark, error)
+	SearchRankedContext(ctx context.Context, query string) ([]Bookmark, error)
+	SearchByTags(include, exclude []string, matchAll bool) ([]Bookmark, error)
+	SearchComments(query string) ([]Bookmark, error)
 	Get(id uint16) (Bookmark, error)
 	Add(bookmark Bookmark) error
 	UpdateTitle(id uint16, title string) error
 	UpdateURL(id uint16, url string) error
 	UpdateComment(id uint16, comment string) error
+	AppendComment(id uint16, note string) error
+	Update(id uint16, b Bookmark, fields FieldMask) error
 	AddTags(id uint16, tags []string) error
 	RemoveTags(id uint16, tags []string) error
 	ClearTags(id uint16) error
 	Remove(id uint16) error
+	BulkRemove(ids []uint16) error
+	GetLinkStatuses() (map[uint16]LinkStatus, error)
+	RecordVisit(id uint16, at time.Time) error
+	GetVisits(id uint16) (Visits, error)
+	ResetVisits(id uint16) error
+	AddAlias(id uint16, url string) error
+	RemoveAlias(id uint16, url string) error
+	GetAliases(id uint16) ([]string, error)
+	GetAllAliases() (map[uint16][]string, error)
+	TrashList() ([]TrashedBookmark, error)
+	Restore(trashID int64) (uint16, error)
+	EmptyTrash() error
+	CheckBookmark(id uint16, url string) error
 }
 
 // BukuDB represents a connection to the buku SQLite database.
 type BukuDB struct {
 	dbPath string
-	conn   *sql.DB
-	mu     *sync.Mutex
-	len    int
+	conn   *loggedDB
+
+	// mu is taken exactly once by every exported method, the unexported
+	// *Locked methods expect it to be held.
+	mu sync.Mutex
+
+	len int
+	max int
+	fts bool
+
+	// hasMeta is true once robuku_meta exists, setup once setupLocked
+	// created and synced the robuku tables.
+	hasMeta bool
+	setup   bool
+
+	// fetchTitle fetches the titles of ImportText, nil if disabled.
+	fetchTitle TitleFetcher
+
+	// updates are the prepared statements of updateField.
+	updates map[field]*loggedStmt
+
+	// stat and stamp detect other programs changing the database file
+	// before db writes to it.
+	stat  stater
+	stamp fileStamp
 }
 
-// NewBukuDB initializes and returns a new BukuDB instance.
-func NewBukuDB(dbPath string) (*BukuDB, error) {
-	mu := sync.Mutex{}
-	mu.Lock()
-	defer mu.Unlock()
+// NotBukuDBError is returned by NewBukuDB when the database at Path has no
+// bookmarks table.
+type NotBukuDBError struct {
+	Path string
+}
 
-	conn, err := sql.Open("sqlite3", dbPath)
+func (e *NotBukuDBError) Error() string {
+	return fmt.Sprintf("%s is not a buku database (it has no bookmarks table)", e.Path)
+}
+
+// NewBukuDB initializes and returns a new BukuDB instance, opts tune the
+// SQLite connection and default to SQLite's own defaults.
+func NewBukuDB(dbPath string, opts ...Option) (*BukuDB, error) {
+	var o options
+	for _, opt := range opts {
+		if err := opt(&o); err != nil {
+			return nil, fmt.Errorf("invalid database option: %w", err)
+		}
+	}
+
+	sqlConn, err := sql.Open("sqlite3", o.dsn(dbPath))
 	if err != nil {
 		return nil, fmt.Errorf("failed to open database: %w", err)
 	}
+	conn := newLoggedDB(sqlConn, o.queryLogger)
+	conn.SetMaxOpenConns(o.maxOpenConns)
+
+	ok, err := hasBookmarksTable(conn)
+	if err != nil {
+		conn.Close()
+		return nil, fmt.Errorf("failed to check database schema: %w", err)
+	}
+	if !ok {
+		conn.Close()
+		return nil, &NotBukuDBError{Path: dbPath}
+	}
+
+	maxBookmarks := MaxBookmarks
+	if o.maxBookmarks > 0 {
+		maxBookmarks = o.maxBookmarks
+	}
 
-	l, err := getMaxBookmarkID(conn)
+	l, err := getMaxBookmarkID(conn, maxBookmarks)
 	if err != nil {
+		conn.Close()
 		return nil, fmt.Errorf("failed to get database length: %w", err)
 	}
 
+	hasMeta, err := hasTable(conn, "robuku_meta")
+	if err != nil {
+		conn.Close()
+		return nil, fmt.Errorf("failed to check meta table: %w", err)
+	}
+
+	var fts bool
+	if o.fts {
+		if fts, err = setupFTS(conn); err != nil {
+			conn.Close()
+			return nil, err
+		}
+	}
+
+	updates, err := prepareUpdates(conn)
+	if err != nil {
+		conn.Close()
+		return nil, err
+	}
+
+	var stat stater = osStater{}
+	stamp, _ := stat.stamp(dbPath)
+
 	return &BukuDB{
-		dbPath: dbPath,
-		conn:   conn,
-		mu:     &mu,
-		len:    l,
+		dbPath:  dbPath,
+		conn:    conn,
+		len:     l,
+		max:     maxBookmarks,
+		fts:     fts,
+		hasMeta: hasMeta,
+		updates: updates,
+
+		fetchTitle: o.fetchTitle,
+		stat:       stat,
+		stamp:      stamp,
 	}, nil
 }
 
-// Close closes the database connection.
+// InitSchema creates the buku bookmarks table in the database at dbPath if
+// it does not exist yet.
+func InitSchema(dbPath string) error {
+	conn, err := sql.Open("sqlite3", dbPath)
+	if err != nil {
+		return fmt.Errorf("failed to open database: %w", err)
+	}
+	defer conn.Close()
+
+	if _, err := conn.Exec(schema); err != nil {
+		return fmt.Errorf("failed to create bookmarks table: %w", err)
+	}
+	return nil
+}
+
+// CopyDB writes a consistent copy of the database at src to dst, including
+// the changes still in its write-ahead log. dst must not exist yet.
+func CopyDB(src, dst string) error {
+	if _, err := os.Stat(src); err != nil {
+		return fmt.Errorf("failed to open database: %w", err)
+	}
+	conn, err := sql.Open("sqlite3", "file:"+src+"?mode=ro")
+	if err != nil {
+		return fmt.Errorf("failed to open database: %w", err)
+	}
+	defer conn.Close()
+
+	if _, err := conn.Exec("VACUUM INTO ?;", dst); err != nil {
+		return fmt.Errorf("failed to copy database: %w", err)
+	}
+	return nil
+}
+
+// Close flushes pending writes and closes the database connection.
 func (db *BukuDB) Close() error {
-	return db.conn.Close()
+	flushErr := db.Flush()
+	for _, stmt := range db.updates {
+		stmt.Close()
+	}
+	if err := db.conn.Close(); err != nil {
+		return fmt.Errorf("failed to close database: %w", err)
+	}
+	return flushErr
+}
+
+// Flush checkpoints the write-ahead log into the database file when the
+// database is in WAL journal mode, otherwise it does nothing.
+func (db *BukuDB) Flush() error {
+	db.mu.Lock()
+	defer db.mu.Unlock()
+
+	var mode string
+	if err := db.conn.QueryRow("PRAGMA journal_mode;").Scan(&mode); err != nil {
+		return fmt.Errorf("failed to get journal mode: %w", err)
+	}
+	if !strings.EqualFold(mode, "wal") {
+		return nil
+	}
+
+	if _, err := db.conn.Exec("PRAGMA wal_checkpoint(TRUNCATE);"); err != nil {
+		return fmt.Errorf("failed to checkpoint database: %w", err)
+	}
+	return nil
 }
 
 // Len returns the number of bookmarks in db.
@@ -104,46 +377,158 @@ func (db *BukuDB) Len() int {
 	return db.len
 }
 
+// Stats returns aggregated information about the bookmarks in db.
+func (db *BukuDB) Stats() (Stats, error) {
+	db.mu.Lock()
+	defer db.mu.Unlock()
+
+	var s Stats
+	row := db.conn.QueryRow(`SELECT COUNT(*),
+		COUNT(CASE WHEN tags IS NULL OR trim(tags, ',') = '' THEN 1 END),
+		COUNT(CASE WHEN metadata IS NULL OR metadata = '' THEN 1 END),
+		COUNT(CASE WHEN URL LIKE 'http://%' THEN 1 END)
+		FROM bookmarks`)
+	if err := row.Scan(&s.Total, &s.Untagged, &s.Untitled, &s.Insecure); err != nil {
+		return Stats{}, fmt.Errorf("failed to count bookmarks: %w", err)
+	}
+
+	row = db.conn.QueryRow(statsTagsCTE + `SELECT COUNT(DISTINCT tag) FROM split WHERE tag != ''`)
+	if err := row.Scan(&s.Tags); err != nil {
+		return Stats{}, fmt.Errorf("failed to count tags: %w", err)
+	}
+
+	topTags, err := queryTopTags(db.conn, statsTopTags)
+	if err != nil {
+		return Stats{}, err
+	}
+	s.TopTags = topTags
+
+	topDomains, err := queryTopDomains(db.conn, statsTopDomains)
+	if err != nil {
+		return Stats{}, err
+	}
+	s.TopDomains = topDomains
+
+	if s.Visited, err = countVisited(db.conn); err != nil {
+		return Stats{}, err
+	}
+
+	fi, err := os.Stat(db.dbPath)
+	if err != nil {
+		return Stats{}, fmt.Errorf("failed to get database file size: %w", err)
+	}
+	s.FileSize = fi.Size()
+
+	return s, nil
+}
+
 // GetAll returns a all bookmarks in db.
 func (db *BukuDB) GetAll() ([]Bookmark, error) {
+	return db.GetAllContext(context.Background())
+}
+
+// GetAllContext is like GetAll, the queries are interrupted when ctx is done.
+func (db *BukuDB) GetAllContext(ctx context.Context) ([]Bookmark, error) {
+	db.mu.Lock()
+	defer db.mu.Unlock()
+	return loadBookmarks(ctx, db.conn, db.source(), db.len)
+}
+
+// ForEach calls fn for every bookmark in db in ID order, scanning them one
+// at a time from a single query so they are never all in memory. It stops
+// at and returns the first error of fn, which must not use db.
+func (db *BukuDB) ForEach(fn func(Bookmark) error) error {
 	db.mu.Lock()
 	defer db.mu.Unlock()
-	return loadBookmarks(db.conn, db.len)
+
+	rows, err := db.conn.Query("SELECT " + bookmarkColumns + " FROM " + db.source() +
+		" ORDER BY bookmarks.id")
+	if err != nil {
+		return fmt.Errorf("failed to query bookmarks: %w", err)
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		b, err := scanBookmark(rows)
+		if err != nil {
+			return err
+		}
+		if err := fn(b); err != nil {
+			return err
+		}
+	}
+	return rows.Err()
 }
 
 // Get returns a bookmark by ID.
 func (db *BukuDB) Get(id uint16) (Bookmark, error) {
 	db.mu.Lock()
 	defer db.mu.Unlock()
+	return db.getLocked(id)
+}
 
+// getLocked is Get with db.mu held.
+func (db *BukuDB) getLocked(id uint16) (Bookmark, error) {
 	if id < 1 || int(id) > db.len {
 		return Bookmark{}, fmt.Errorf("bookmark id %d out of range (1-%d)", id, db.len)
 	}
 
-	var b Bookmark
-	var tagsString string
-	row := db.conn.QueryRow("SELECT id, URL, metadata, tags, desc FROM bookmarks WHERE id = ?", id)
-	if err := row.Scan(&b.ID, &b.URL, &b.Title, &tagsString, &b.Comment); err != nil {
-		return Bookmark{}, fmt.Errorf("failed to scan bookmark: %w", err)
+	row := db.conn.QueryRow(
+		"SELECT "+bookmarkColumns+" FROM "+db.source()+" WHERE bookmarks.id = ?", id)
+	return scanBookmark(row)
+}
+
+// GetByIDs returns the bookmarks with the given IDs in the same order, IDs
+// without a bookmark are skipped.
+func (db *BukuDB) GetByIDs(ids []uint16) ([]Bookmark, error) {
+	if len(ids) == 0 {
+		return []Bookmark{}, nil
 	}
 
-	if tagsString != "," {
-		b.Tags = strings.Split(tagsString, ",")
-		b.Tags = filter(b.Tags, func(t string) bool { return t != "" })
+	db.mu.Lock()
+	defer db.mu.Unlock()
+
+	args := make([]any, len(ids))
+	for i, id := range ids {
+		args[i] = id
+	}
+	placeholders := strings.TrimSuffix(strings.Repeat("?,", len(ids)), ",")
+	bookmarks, err := queryBookmarks(context.Background(), db.conn, "SELECT "+bookmarkColumns+
+		" FROM "+db.source()+" WHERE bookmarks.id IN ("+placeholders+")", args...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query bookmarks: %w", err)
 	}
 
-	return b, nil
+	byID := make(map[uint16]Bookmark, len(bookmarks))
+	for _, b := range bookmarks {
+		byID[b.ID] = b
+	}
+	ordered := make([]Bookmark, 0, len(bookmarks))
+	for _, id := range ids {
+		if b, ok := byID[id]; ok {
+			ordered = append(ordered, b)
+			delete(byID, id)
+		}
+	}
+	return ordered, nil
 }
 
-// Add inserts a new bookmark into the database.
+// Add inserts a new bookmark into the database. A URL that is already
+// bookmarked returns a *DuplicateURLError.
 func (db *BukuDB) Add(bookmark Bookmark) error {
 	db.mu.Lock()
 	defer db.mu.Unlock()
 
-	bookmark.ID = uint16(db.len + 1)
-	if bookmark.ID > uint16(MaxBookmarks) {
-		return fmt.Errorf("maximum number of bookmarks (%d) reached", MaxBookmarks)
+	if _, err := db.syncLocked(); err != nil {
+		return err
+	}
+	if db.len >= db.max {
+		return &LimitError{Count: db.len, Max: db.max}
 	}
+	if err := db.setupLocked(); err != nil {
+		return err
+	}
+	bookmark.ID = uint16(db.len + 1)
 
 	query := `INSERT INTO bookmarks (id, URL, metadata, tags, desc, flags) VALUES (?, ?, ?, ?, ?, ?)`
 	_, err := db.conn.Exec(
@@ -151,133 +536,423 @@ func (db *BukuDB) Add(bookmark Bookmark) error {
 		bookmark.ID,
 		bookmark.URL,
 		bookmark.Title,
-		strings.Join(bookmark.Tags, ","),
+		formatTags(bookmark.Tags),
 		bookmark.Comment,
 		0,
 	)
+	if dup := duplicateURL(db.conn, err, bookmark.URL); dup != nil {
+		return dup
+	}
 	if err != nil {
 		return fmt.Errorf("failed to insert bookmark: %w", err)
 	}
 
+	_, err = db.conn.Exec(`INSERT OR REPLACE INTO robuku_meta (id, created_at) VALUES (?, ?)`,
+		bookmark.ID, time.Now().Unix())
+	if err != nil {
+		return fmt.Errorf("failed to insert bookmark meta: %w", err)
+	}
+
 	db.len = int(bookmark.ID)
 	return nil
 }
 
 // UpdateTitle updates the title of the bookmark with the given ID.
 func (db *BukuDB) UpdateTitle(id uint16, title string) error {
-	return db.updateField(id, "metadata", title)
+	return db.updateField(id, fieldTitle, title)
 }
 
-// UpdateURL updates the URL of the bookmark with the given ID.
+// UpdateURL updates the URL of the bookmark with the given ID, the URL of
+// another bookmark returns a *DuplicateURLError.
 func (db *BukuDB) UpdateURL(id uint16, url string) error {
-	return db.updateField(id, "URL", url)
+	return db.updateField(id, fieldURL, url)
 }
 
 // UpdateComment updates the comment of the bookmark with the given ID.
 func (db *BukuDB) UpdateComment(id uint16, comment string) error {
-	return db.updateField(id, "desc", comment)
+	return db.updateField(id, fieldComment, comment)
+}
+
+// AppendComment adds note as a new line to the comment of the bookmark with
+// the given ID, in a single statement so concurrent edits are not lost.
+func (db *BukuDB) AppendComment(id uint16, note string) error {
+	db.mu.Lock()
+	defer db.mu.Unlock()
+
+	if _, err := db.syncLocked(); err != nil {
+		return err
+	}
+	if id < 1 || int(id) > db.len {
+		return fmt.Errorf("id %d out of range (1-%d)", id, db.len)
+	}
+	_, err := db.conn.Exec(`UPDATE bookmarks SET desc = CASE WHEN IFNULL(desc, '') = '' THEN ?
+		ELSE desc || char(10) || ? END WHERE id = ?`, note, note, id)
+	if err != nil {
+		return fmt.Errorf("failed to append to comment: %w", err)
+	}
+	return nil
 }
 
 // AddTags adds tags to the bookmark with the given ID.
 func (db *BukuDB) AddTags(id uint16, tags []string) error {
-	b, err := db.Get(id)
+	db.mu.Lock()
+	defer db.mu.Unlock()
+
+	if _, err := db.syncLocked(); err != nil {
+		return err
+	}
+	b, err := db.getLocked(id)
 	if err != nil {
 		return err
 	}
 
-	tags = filter(tags, func(t string) bool { return !slices.Contains(b.Tags, t) })
 	b.Tags = append(b.Tags, tags...)
 
-	sort.Slice(b.Tags, func(i, j int) bool {
+	sort.SliceStable(b.Tags, func(i, j int) bool {
 		return strings.ToLower(b.Tags[i]) < strings.ToLower(b.Tags[j])
 	})
 
-	tagsStr := "," + strings.Join(b.Tags, ",") + ","
-	return db.updateField(id, "tags", tagsStr)
+	return db.updateFieldLocked(id, fieldTags, formatTags(b.Tags))
 }
 
-// RemoveTags removes tags from the bookmark with the given ID.
+// RemoveTags removes tags from the bookmark with the given ID, compared
+// case-insensitively.
 func (db *BukuDB) RemoveTags(id uint16, tags []string) error {
-	b, err := db.Get(id)
+	db.mu.Lock()
+	defer db.mu.Unlock()
+
+	if _, err := db.syncLocked(); err != nil {
+		return err
+	}
+	b, err := db.getLocked(id)
 	if err != nil {
 		return err
 	}
 
-	b.Tags = filter(b.Tags, func(t string) bool { return !slices.Contains(tags, t) })
-	tagsStr := "," + strings.Join(b.Tags, ",") + ","
-	return db.updateField(id, "tags", tagsStr)
+	b.Tags = filter(b.Tags, func(t string) bool {
+		return !slices.ContainsFunc(tags, func(r string) bool { return strings.EqualFold(t, r) })
+	})
+	return db.updateFieldLocked(id, fieldTags, formatTags(b.Tags))
 }
 
 // ClearTags removes all tags from the bookmark with the given ID.
 func (db *BukuDB) ClearTags(id uint16) error {
-	return db.updateField(id, "tags", ",")
+	return db.updateField(id, fieldTags, ",")
+}
+
+// FieldMask selects the fields of a bookmark Update writes.
+type FieldMask uint8
+
+const (
+	MaskTitle FieldMask = 1 << iota
+	MaskURL
+	MaskComment
+	MaskTags
+
+	MaskAll = MaskTitle | MaskURL | MaskComment | MaskTags
+)
+
+// maskColumns are the columns of each field of a FieldMask, in the order
+// they are set.
+var maskColumns = []struct {
+	mask   FieldMask
+	column string
+}{
+	{MaskTitle, "metadata"},
+	{MaskURL, "URL"},
+	{MaskComment, "desc"},
+	{MaskTags, "tags"},
+}
+
+// Update sets the fields of the bookmark with the given ID selected by
+// fields to those of b in one transaction, so either all or none of them
+// change. The ID of b is ignored. The URL of another bookmark returns a
+// *DuplicateURLError.
+func (db *BukuDB) Update(id uint16, b Bookmark, fields FieldMask) error {
+	if fields&^MaskAll != 0 {
+		return fmt.Errorf("unknown fields %#b", fields&^MaskAll)
+	}
+	if fields == 0 {
+		return nil
+	}
+
+	db.mu.Lock()
+	defer db.mu.Unlock()
+
+	if _, err := db.syncLocked(); err != nil {
+		return err
+	}
+	if id < 1 || int(id) > db.len {
+		return fmt.Errorf("id %d out of range (1-%d)", id, db.len)
+	}
+
+	values := map[FieldMask]string{
+		MaskTitle:   b.Title,
+		MaskURL:     b.URL,
+		MaskComment: b.Comment,
+		MaskTags:    formatTags(b.Tags),
+	}
+	var sets []string
+	var args []any
+	for _, c := range maskColumns {
+		if fields&c.mask != 0 {
+			sets = append(sets, c.column+" = ?")
+			args = append(args, values[c.mask])
+		}
+	}
+
+	tx, err := db.conn.Begin()
+	if err != nil {
+		return fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	query := `UPDATE bookmarks SET ` + strings.Join(sets, ", ") + ` WHERE id = ?`
+	if _, err := tx.Exec(query, append(args, id)...); err != nil {
+		if dup := duplicateURL(tx, err, b.URL); dup != nil {
+			return dup
+		}
+		return fmt.Errorf("failed to update bookmark: %w", err)
+	}
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("failed to commit update: %w", err)
+	}
+	return nil
 }
 
-// Remove removes a bookmark from the database.
+// optionalIDTables are the robuku tables keyed by bookmark ID that are only
+// created once needed, their rows follow the bookmarks on Remove.
+var optionalIDTables = []string{linkStatusTable, visitsTable, aliasesTable}
+
+// Remove removes a bookmark from the database and keeps a copy in the
+// trash, in one transaction.
 func (db *BukuDB) Remove(id uint16) error {
 	db.mu.Lock()
 	defer db.mu.Unlock()
 
-	index := int(id - 1)
+	if _, err := db.syncLocked(); err != nil {
+		return err
+	}
+	return db.removeLocked([]uint16{id})
+}
 
-	if id < 1 || index >= db.len {
-		return fmt.Errorf("id %d out of range (1-%d)", id, db.len)
+// BulkRemove removes the bookmarks with the given IDs like Remove, in one
+// transaction. If any ID is out of range none is removed. The IDs refer to
+// the bookmarks before the call, the renumbering of each removal is
+// accounted for.
+func (db *BukuDB) BulkRemove(ids []uint16) error {
+	db.mu.Lock()
+	defer db.mu.Unlock()
+
+	if _, err := db.syncLocked(); err != nil {
+		return err
+	}
+	return db.removeLocked(ids)
+}
+
+// removeLocked is BulkRemove with db.mu held.
+func (db *BukuDB) removeLocked(ids []uint16) error {
+	for _, id := range ids {
+		if id < 1 || int(id) > db.len {
+			return fmt.Errorf("id %d out of range (1-%d)", id, db.len)
+		}
+	}
+	if err := db.setupLocked(); err != nil {
+		return err
 	}
 
-	query := `DELETE FROM bookmarks WHERE id = ?`
-	_, err := db.conn.Exec(query, id)
+	tx, err := db.conn.Begin()
 	if err != nil {
+		return fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	var sideTables []string
+	for _, table := range optionalIDTables {
+		ok, err := hasTable(tx, table)
+		if err != nil {
+			return fmt.Errorf("failed to check %s table: %w", table, err)
+		}
+		if ok {
+			sideTables = append(sideTables, table)
+		}
+	}
+
+	// removing the highest ID first keeps the lower ones in place
+	sorted := slices.Clone(ids)
+	slices.Sort(sorted)
+	sorted = slices.Compact(sorted)
+	n := db.len
+	now := time.Now().Unix()
+	for _, id := range slices.Backward(sorted) {
+		if err := removeBookmark(tx, id, n, sideTables, now); err != nil {
+			return err
+		}
+		n--
+	}
+
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("failed to commit removal: %w", err)
+	}
+	db.len = n
+	return nil
+}
+
+// removeBookmark moves the bookmark with the given ID of n to the trash and
+// renumbers the ones after it, in bookmarks, robuku_meta and sideTables.
+func removeBookmark(tx *loggedTx, id uint16, n int, sideTables []string, now int64) error {
+	if _, err := tx.Exec(trashInsert, now, id); err != nil {
+		return fmt.Errorf("failed to move bookmark to trash: %w", err)
+	}
+
+	if _, err := tx.Exec(`DELETE FROM bookmarks WHERE id = ?`, id); err != nil {
 		return fmt.Errorf("failed to delete bookmark: %w", err)
 	}
+	if _, err := tx.Exec(`DELETE FROM robuku_meta WHERE id = ?`, id); err != nil {
+		return fmt.Errorf("failed to delete bookmark meta: %w", err)
+	}
+	for _, table := range sideTables {
+		if _, err := tx.Exec(`DELETE FROM `+table+` WHERE id = ?`, id); err != nil {
+			return fmt.Errorf("failed to delete bookmark from %s: %w", table, err)
+		}
+	}
 
-	for i := index + 1; i < db.len; i++ {
+	for i := int(id); i < n; i++ {
 		updateQuery := `UPDATE bookmarks SET id = ? WHERE id = ?`
-		_, err := db.conn.Exec(updateQuery, i, i+1)
-		if err != nil {
+		if _, err := tx.Exec(updateQuery, i, i+1); err != nil {
 			return fmt.Errorf("failed to update bookmark id: %w", err)
 		}
+		metaQuery := `UPDATE robuku_meta SET id = ? WHERE id = ?`
+		if _, err := tx.Exec(metaQuery, i, i+1); err != nil {
+			return fmt.Errorf("failed to update bookmark meta id: %w", err)
+		}
+		for _, table := range sideTables {
+			sideQuery := `UPDATE ` + table + ` SET id = ? WHERE id = ?`
+			if _, err := tx.Exec(sideQuery, i, i+1); err != nil {
+				return fmt.Errorf("failed to update %s id: %w", table, err)
+			}
+		}
 	}
-
-	db.len -= 1
 	return nil
 }
 
-// updateField updates a specific field in the database and in-memory bookmark.
-func (db *BukuDB) updateField(id uint16, field, value string) error {
+// field is a bookmark column updateField can write.
+type field int
+
+const (
+	fieldTitle field = iota
+	fieldURL
+	fieldComment
+	fieldTags
+)
+
+// updateQueries are the statements updating each field, the column names
+// are never taken from input.
+var updateQueries = map[field]string{
+	fieldTitle:   `UPDATE bookmarks SET metadata = ? WHERE id = ?`,
+	fieldURL:     `UPDATE bookmarks SET URL = ? WHERE id = ?`,
+	fieldComment: `UPDATE bookmarks SET desc = ? WHERE id = ?`,
+	fieldTags:    `UPDATE bookmarks SET tags = ? WHERE id = ?`,
+}
+
+func (f field) String() string {
+	switch f {
+	case fieldTitle:
+		return "title"
+	case fieldURL:
+		return "URL"
+	case fieldComment:
+		return "comment"
+	case fieldTags:
+		return "tags"
+	}
+	return fmt.Sprintf("field(%d)", int(f))
+}
+
+// prepareUpdates prepares the statements of updateQueries.
+func prepareUpdates(conn *loggedDB) (map[field]*loggedStmt, error) {
+	updates := make(map[field]*loggedStmt, len(updateQueries))
+	for f, query := range updateQueries {
+		stmt, err := conn.Prepare(query)
+		if err != nil {
+			for _, s := range updates {
+				s.Close()
+			}
+			return nil, fmt.Errorf("failed to prepare %s update: %w", f, err)
+		}
+		updates[f] = stmt
+	}
+	return updates, nil
+}
+
+// updateField sets f of the bookmark with the given ID to value.
+func (db *BukuDB) updateField(id uint16, f field, value string) error {
 	db.mu.Lock()
 	defer db.mu.Unlock()
+	return db.updateFieldLocked(id, f, value)
+}
 
+// updateFieldLocked is updateField with db.mu held.
+func (db *BukuDB) updateFieldLocked(id uint16, f field, value string) error {
+	stmt, ok := db.updates[f]
+	if !ok {
+		return fmt.Errorf("unknown field %s", f)
+	}
+	if _, err := db.syncLocked(); err != nil {
+		return err
+	}
 	if id < 1 || int(id) > db.len {
 		return fmt.Errorf("id %d out of range (1-%d)", id, db.len)
 	}
 
-	query := fmt.Sprintf("UPDATE bookmarks SET %s = ? WHERE id = ?", field)
-	_, err := db.conn.Exec(query, value, id)
-	if err != nil {
-		return fmt.Errorf("failed to update field %s: %w", field, err)
+	if _, err := stmt.Exec(value, id); err != nil {
+		if f == fieldURL {
+			if dup := duplicateURL(db.conn, err, value); dup != nil {
+				return dup
+			}
+		}
+		return fmt.Errorf("failed to update field %s: %w", f, err)
 	}
-
 	return nil
 }
 
 // Utility functions
 
-// getMaxBookmarkID retrieves the maximum ID from the bookmarks table.
-func getMaxBookmarkID(conn *sql.DB) (int, error) {
+// hasBookmarksTable reports whether the buku bookmarks table exists.
+func hasBookmarksTable(conn *loggedDB) (bool, error) {
+	return hasTable(conn, "bookmarks")
+}
+
+// hasTable reports whether the table name exists.
+func hasTable(conn rowQuerier, name string) (bool, error) {
+	var n int
+	err := conn.QueryRow(
+		"SELECT COUNT(*) FROM sqlite_master WHERE type = 'table' AND name = ?", name).Scan(&n)
+	if err != nil {
+		return false, err
+	}
+	return n > 0, nil
+}
+
+// getMaxBookmarkID retrieves the maximum ID from the bookmarks table, at
+// most maxBookmarks.
+func getMaxBookmarkID(conn *loggedDB, maxBookmarks int) (int, error) {
 	var maxID int
-	err := conn.QueryRow("SELECT MAX(id) FROM bookmarks;").Scan(&maxID)
+	err := conn.QueryRow("SELECT COALESCE(MAX(id), 0) FROM bookmarks;").Scan(&maxID)
 	if err != nil {
 		return 0, fmt.Errorf("failed to get max ID from bookmarks: %w", err)
 	}
 
-	if maxID > MaxBookmarks {
-		maxID = MaxBookmarks
+	if maxID > maxBookmarks {
+		maxID = maxBookmarks
 	}
 	return maxID, nil
 }
 
-// loadBookmarks loads all bookmarks from the database up to maxID.
-func loadBookmarks(conn *sql.DB, maxID int) ([]Bookmark, error) {
+// loadBookmarks loads all bookmarks from the database up to maxID, selecting
+// them from source.
+func loadBookmarks(ctx context.Context, conn *loggedDB, source string, maxID int) ([]Bookmark, error) {
+	// mu guards bookmarksMap and processErr
 	mu := sync.Mutex{}
 	bookmarksMap := make(map[uint16]Bookmark)
 	var wg sync.WaitGroup
@@ -295,8 +970,10 @@ func loadBookmarks(conn *sql.DB, maxID int) ([]Bookmark, error) {
 
 		go func(start, end int) {
 			defer wg.Done()
-			if err := processBookmarkRange(conn, start, end, bookmarksMap, &mu); err != nil {
+			if err := processBookmarkRange(ctx, conn, source, start, end, bookmarksMap, &mu); err != nil {
+				mu.Lock()
 				processErr = fmt.Errorf("error processing bookmarks range: %w", err)
+				mu.Unlock()
 			}
 		}(start, end)
 	}
@@ -320,33 +997,80 @@ func loadBookmarks(conn *sql.DB, maxID int) ([]Bookmark, error) {
 }
 
 // processBookmarkRange loads a range of bookmarks into the bookmarksMap.
-func processBookmarkRange(conn *sql.DB, start, end int,
+func processBookmarkRange(ctx context.Context, conn *loggedDB, source string, start, end int,
 	bookmarksMap map[uint16]Bookmark, mu *sync.Mutex) error {
-	rows, err := conn.Query("SELECT id, URL, metadata, tags, desc, flags FROM bookmarks WHERE id BETWEEN ? AND ?", start, end)
+	bookmarks, err := queryBookmarks(ctx, conn, "SELECT "+bookmarkColumns+" FROM "+source+
+		" WHERE bookmarks.id BETWEEN ? AND ?", start, end)
 	if err != nil {
 		return fmt.Errorf("failed to query bookmarks in range (%d-%d): %w", start, end, err)
 	}
+
+	mu.Lock()
+	for _, b := range bookmarks {
+		bookmarksMap[b.ID] = b
+	}
+	mu.Unlock()
+
+	return nil
+}
+
+// queryTopTags returns the n most used tags.
+func queryTopTags(conn *loggedDB, n int) ([]TagCount, error) {
+	rows, err := conn.Query(statsTagsCTE+`SELECT tag, COUNT(DISTINCT id) AS n
+		FROM split WHERE tag != '' GROUP BY tag ORDER BY n DESC, tag LIMIT ?`, n)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query top tags: %w", err)
+	}
 	defer rows.Close()
 
+	var tags []TagCount
 	for rows.Next() {
-		var b Bookmark
-		var tagsString string
-		var flags int // Ignored for now
-
-		if err := rows.Scan(&b.ID, &b.URL, &b.Title, &tagsString, &b.Comment, &flags); err != nil {
-			return fmt.Errorf("failed to scan bookmark: %w", err)
+		var tc TagCount
+		if err := rows.Scan(&tc.Tag, &tc.Count); err != nil {
+			return nil, fmt.Errorf("failed to scan tag count: %w", err)
 		}
+		tags = append(tags, tc)
+	}
+	return tags, rows.Err()
+}
 
-		if len(tagsString) >= 2 {
-			b.Tags = strings.Split(tagsString[1:len(tagsString)-1], ",")
-		}
+// queryTopDomains returns the n most bookmarked domains.
+func queryTopDomains(conn *loggedDB, n int) ([]DomainCount, error) {
+	rows, err := conn.Query(statsDomainsQuery, n)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query top domains: %w", err)
+	}
+	defer rows.Close()
 
-		mu.Lock()
-		bookmarksMap[b.ID] = b
-		mu.Unlock()
+	var domains []DomainCount
+	for rows.Next() {
+		var dc DomainCount
+		if err := rows.Scan(&dc.Domain, &dc.Count); err != nil {
+			return nil, fmt.Errorf("failed to scan domain count: %w", err)
+		}
+		domains = append(domains, dc)
 	}
+	return domains, rows.Err()
+}
 
-	return rows.Err()
+// formatTags returns tags in buku's ",tag1,tag2," column format, empty and
+// case-insensitively duplicated tags are dropped so the column never
+// contains ",,".
+func formatTags(tags []string) string {
+	var b strings.Builder
+	b.WriteString(",")
+	seen := make(map[string]bool, len(tags))
+	for _, t := range tags {
+		t = strings.TrimSpace(t)
+		key := strings.ToLower(t)
+		if t == "" || seen[key] {
+			continue
+		}
+		seen[key] = true
+		b.WriteString(t)
+		b.WriteString(",")
+	}
+	return b.String()
 }
 
 func filter(slice []string, predicate func(string) bool) []string {
@@ -1,8 +1,15 @@
 package bukudb
 
 import (
+	"context"
 	"database/sql"
+	"errors"
+	"fmt"
 	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"sync"
 	"testing"
 
 	_ "github.com/mattn/go-sqlite3"
@@ -112,6 +119,99 @@ func Test_Add_And_Remove(t *testing.T) {
 	}
 }
 
+func Test_BulkRemove(t *testing.T) {
+	createTestDb(t)
+	db, err := NewBukuDB(sqlTestDbPath)
+	defer cleanUpTestDB(t, db)
+
+	if err != nil {
+		t.Fatalf("expected no error on NewBukuDB(), got '%v'", err)
+	}
+
+	if err := db.BulkRemove([]uint16{1, 9}); err == nil {
+		t.Errorf("expected an out of range error on BulkRemove()")
+	}
+	if db.Len() != 4 {
+		t.Fatalf("expected no bookmark to be removed, got length %d", db.Len())
+	}
+
+	// the IDs are those before the call, in any order
+	if err := db.BulkRemove([]uint16{3, 1, 3}); err != nil {
+		t.Fatalf("expected no error on BulkRemove(), got '%v'", err)
+	}
+	all, err := db.GetAll()
+	if err != nil {
+		t.Fatalf("expected no error on GetAll(), got '%v'", err)
+	}
+	var urls []string
+	for _, b := range all {
+		urls = append(urls, b.URL)
+	}
+	if expected := []string{"https://www.b.com", "https://www.d.com"}; !slices.Equal(urls, expected) {
+		t.Errorf("expected urls %v, got %v", expected, urls)
+	}
+
+	trashed, err := db.TrashList()
+	if err != nil || len(trashed) != 2 {
+		t.Errorf("expected 2 bookmarks in the trash, got %d (%v)", len(trashed), err)
+	}
+}
+
+func Test_BulkRemove_Rollback(t *testing.T) {
+	createTestDb(t)
+	db, err := NewBukuDB(sqlTestDbPath)
+	defer cleanUpTestDB(t, db)
+
+	if err != nil {
+		t.Fatalf("expected no error on NewBukuDB(), got '%v'", err)
+	}
+
+	// the removal of bookmark 1 fails after bookmark 3 was removed
+	if _, err := db.conn.Exec(`CREATE TRIGGER fail_remove BEFORE DELETE ON bookmarks
+		WHEN old.id = 1 BEGIN SELECT RAISE(ABORT, 'no'); END`); err != nil {
+		t.Fatal(err)
+	}
+	if err := db.BulkRemove([]uint16{1, 3}); err == nil {
+		t.Fatalf("expected an error on BulkRemove()")
+	}
+
+	if db.Len() != 4 {
+		t.Errorf("expected length 4, got %d", db.Len())
+	}
+	if all, _ := db.GetAll(); len(all) != 4 || all[2].URL != "https://www.c.com" {
+		t.Errorf("expected the bookmarks to be unchanged, got %v", all)
+	}
+	if trashed, _ := db.TrashList(); len(trashed) != 0 {
+		t.Errorf("expected an empty trash, got %v", trashed)
+	}
+}
+
+func Test_Add_Limit(t *testing.T) {
+	createTestDb(t)
+	db, err := NewBukuDB(sqlTestDbPath, WithMaxBookmarks(5))
+	defer cleanUpTestDB(t, db)
+
+	if err != nil {
+		t.Fatalf("expected no error on NewBukuDB(), got '%v'", err)
+	}
+
+	if err := db.Add(Bookmark{URL: "https://www.new.com"}); err != nil {
+		t.Fatalf("expected no error on Add() below the limit, got '%v'", err)
+	}
+	err = db.Add(Bookmark{URL: "https://www.full.com"})
+	var le *LimitError
+	if !errors.As(err, &le) || le.Count != 5 || le.Max != 5 {
+		t.Fatalf("expected limit error for 5 of 5, got '%v'", err)
+	}
+	expected := "maximum number of bookmarks reached (5 of 5)"
+	if err.Error() != expected {
+		t.Errorf("expected error '%s', got '%s'", expected, err)
+	}
+	if db.Len() != 5 {
+		t.Errorf("expected bookmarks length = 5, got %d", db.Len())
+	}
+}
+
 func Test_UpdateTitle(t *testing.T) {
 	createTestDb(t)
 	db, err := NewBukuDB(sqlTestDbPath)
@@ -139,6 +239,85 @@ func Test_UpdateTitle(t *testing.T) {
 	}
 }
 
+func Test_Update(t *testing.T) {
+	createTestDb(t)
+	db, err := NewBukuDB(sqlTestDbPath)
+	defer cleanUpTestDB(t, db)
+
+	if err != nil {
+		t.Fatalf("expected no error on NewBukuDB(), got '%v'", err)
+	}
+
+	changes := Bookmark{URL: "https://www.new.com", Title: "new title",
+		Tags: []string{"new"}, Comment: "new comment"}
+
+	tests := []struct {
+		name     string
+		id       uint16
+		fields   FieldMask
+		expected Bookmark
+	}{
+		{"none", 1, 0, Bookmark{ID: 1, URL: "https://www.a.com", Title: "metadata (title) a",
+			Tags: []string{"a", "tag2", "tag3"}, Comment: "desc (comment) a"}},
+		{"title and comment", 1, MaskTitle | MaskComment, Bookmark{ID: 1, URL: "https://www.a.com",
+			Title: "new title", Tags: []string{"a", "tag2", "tag3"}, Comment: "new comment"}},
+		{"tags", 2, MaskTags, Bookmark{ID: 2, URL: "https://www.b.com", Title: "metadata (title) b",
+			Tags: []string{"new"}}},
+		{"all", 3, MaskAll, Bookmark{ID: 3, URL: "https://www.new.com", Title: "new title",
+			Tags: []string{"new"}, Comment: "new comment"}},
+	}
+
+	for _, tt := range tests {
+		if err := db.Update(tt.id, changes, tt.fields); err != nil {
+			t.Fatalf("%s: expected no error on Update(), got '%v'", tt.name, err)
+		}
+		actual, err := db.Get(tt.id)
+		if err != nil {
+			t.Fatalf("%s: expected ID '%d' to cause no err, got %v", tt.name, tt.id, err)
+		}
+		if !isMatchingBookmark(t, tt.expected, actual) {
+			t.Errorf("%s: expected bookmark '%v', got '%v'", tt.name, tt.expected, actual)
+		}
+	}
+
+	if err := db.Update(9, changes, MaskTitle); err == nil {
+		t.Errorf("expected error on Update() of an unknown id, got nil")
+	}
+	if err := db.Update(1, changes, MaskAll+1); err == nil {
+		t.Errorf("expected error on Update() with unknown fields, got nil")
+	}
+}
+
+func Test_Update_DuplicateURL(t *testing.T) {
+	createTestDb(t)
+	db, err := NewBukuDB(sqlTestDbPath)
+	defer cleanUpTestDB(t, db)
+
+	if err != nil {
+		t.Fatalf("expected no error on NewBukuDB(), got '%v'", err)
+	}
+
+	expected, err := db.Get(1)
+	if err != nil {
+		t.Fatalf("expected ID '1' to cause no err, got %v", err)
+	}
+
+	changes := Bookmark{URL: "https://www.b.com", Title: "new title", Comment: "new comment"}
+	err = db.Update(1, changes, MaskTitle|MaskURL|MaskComment)
+	var dup *DuplicateURLError
+	if !errors.As(err, &dup) || dup.ID != 2 {
+		t.Fatalf("expected a duplicate url error for #2 on Update(), got '%v'", err)
+	}
+
+	actual, err := db.Get(1)
+	if err != nil {
+		t.Fatalf("expected ID '1' to cause no err, got %v", err)
+	}
+	if !isMatchingBookmark(t, expected, actual) {
+		t.Errorf("expected bookmark '%v' to be unchanged, got '%v'", expected, actual)
+	}
+}
+
 func Test_UpdateURL(t *testing.T) {
 	createTestDb(t)
 	db, err := NewBukuDB(sqlTestDbPath)
@@ -193,6 +372,42 @@ func Test_UpdateComment(t *testing.T) {
 	}
 }
 
+func Test_AppendComment(t *testing.T) {
+	createTestDb(t)
+	db, err := NewBukuDB(sqlTestDbPath)
+	defer cleanUpTestDB(t, db)
+
+	if err != nil {
+		t.Fatalf("expected no error on NewBukuDB(), got '%v'", err)
+	}
+
+	if err := db.UpdateComment(2, "old comment"); err != nil {
+		t.Fatal(err)
+	}
+	if err := db.AppendComment(2, "2024-06-01: rechecked"); err != nil {
+		t.Fatalf("expected no error on AppendComment(), got '%v'", err)
+	}
+	actual, _ := db.Get(2)
+	if expected := "old comment\n2024-06-01: rechecked"; actual.Comment != expected {
+		t.Errorf("expected comment %q, got %q", expected, actual.Comment)
+	}
+
+	// an empty comment becomes the note
+	if err := db.UpdateComment(2, ""); err != nil {
+		t.Fatal(err)
+	}
+	if err := db.AppendComment(2, "first"); err != nil {
+		t.Fatalf("expected no error on AppendComment(), got '%v'", err)
+	}
+	if actual, _ := db.Get(2); actual.Comment != "first" {
+		t.Errorf("expected comment 'first', got %q", actual.Comment)
+	}
+
+	if err := db.AppendComment(42, "note"); err == nil {
+		t.Error("expected error for unknown id, got nil")
+	}
+}
+
 func Test_AddTags(t *testing.T) {
 	createTestDb(t)
 	db, err := NewBukuDB(sqlTestDbPath)
@@ -232,7 +447,7 @@ func Test_RemoveTags(t *testing.T) {
 	expected := Bookmark{ID: 2, URL: "https://www.b.com", Title: "metadata (title) b",
 		Tags: []string{"b"}}
 
-	err = db.RemoveTags(expected.ID, []string{"tag2", "tag3"})
+	err = db.RemoveTags(expected.ID, []string{"TAG2", "tag3"})
 	if err != nil {
 		t.Fatalf("expected no error on RemoveTags(), got '%v'", err)
 	}
@@ -274,6 +489,192 @@ func Test_ClearTags(t *testing.T) {
 	}
 }
 
+func Test_Stats(t *testing.T) {
+	createTestDb(t)
+	db, err := NewBukuDB(sqlTestDbPath)
+	defer cleanUpTestDB(t, db)
+
+	if err != nil {
+		t.Fatalf("expected no error on NewBukuDB(), got '%v'", err)
+	}
+
+	s, err := db.Stats()
+	if err != nil {
+		t.Fatalf("expected no error on Stats(), got '%v'", err)
+	}
+
+	if s.Total != 4 {
+		t.Errorf("expected total '4', got '%d'", s.Total)
+	}
+	if s.Tags != 4 {
+		t.Errorf("expected tags '4', got '%d'", s.Tags)
+	}
+	if s.Untagged != 2 {
+		t.Errorf("expected untagged '2', got '%d'", s.Untagged)
+	}
+	if s.Untitled != 1 {
+		t.Errorf("expected untitled '1', got '%d'", s.Untitled)
+	}
+	if s.Insecure != 0 {
+		t.Errorf("expected insecure '0', got '%d'", s.Insecure)
+	}
+	if s.FileSize <= 0 {
+		t.Errorf("expected file size > 0, got '%d'", s.FileSize)
+	}
+
+	expectedTags := []TagCount{{"tag2", 2}, {"tag3", 2}, {"a", 1}, {"b", 1}}
+	if len(s.TopTags) != len(expectedTags) {
+		t.Fatalf("expected top tags '%v', got '%v'", expectedTags, s.TopTags)
+	}
+	for i, tc := range expectedTags {
+		if s.TopTags[i] != tc {
+			t.Errorf("expected top tag '%v' at index %d, got '%v'", tc, i, s.TopTags[i])
+		}
+	}
+
+	expectedDomains := []DomainCount{{"a.com", 1}, {"b.com", 1}, {"c.com", 1}, {"d.com", 1}}
+	if len(s.TopDomains) != len(expectedDomains) {
+		t.Fatalf("expected top domains '%v', got '%v'", expectedDomains, s.TopDomains)
+	}
+	for i, dc := range expectedDomains {
+		if s.TopDomains[i] != dc {
+			t.Errorf("expected top domain '%v' at index %d, got '%v'", dc, i, s.TopDomains[i])
+		}
+	}
+}
+
+func Test_NewBukuDB_NotBukuDB(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "newsboat-cache.db")
+	conn, err := sql.Open("sqlite3", path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := conn.Exec("CREATE TABLE rss_item (id INTEGER PRIMARY KEY, url TEXT)"); err != nil {
+		t.Fatal(err)
+	}
+	conn.Close()
+
+	_, err = NewBukuDB(path)
+	var notBukuDBErr *NotBukuDBError
+	if !errors.As(err, &notBukuDBErr) {
+		t.Fatalf("expected NotBukuDBError on NewBukuDB(), got '%v'", err)
+	}
+	if notBukuDBErr.Path != path {
+		t.Errorf("expected error path '%s', got '%s'", path, notBukuDBErr.Path)
+	}
+}
+
+func Test_InitSchema(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "empty.db")
+
+	if err := InitSchema(path); err != nil {
+		t.Fatalf("expected no error on InitSchema(), got '%v'", err)
+	}
+
+	db, err := NewBukuDB(path)
+	if err != nil {
+		t.Fatalf("expected no error on NewBukuDB(), got '%v'", err)
+	}
+	defer db.Close()
+
+	if db.Len() != 0 {
+		t.Errorf("expected bookmarks length '0', got '%d'", db.Len())
+	}
+
+	if err := db.Add(Bookmark{URL: "https://www.a.com"}); err != nil {
+		t.Fatalf("expected no error on Add(), got '%v'", err)
+	}
+	if db.Len() != 1 {
+		t.Errorf("expected bookmarks length '1', got '%d'", db.Len())
+	}
+
+	// initializing an existing buku database keeps its bookmarks
+	if err := InitSchema(path); err != nil {
+		t.Fatalf("expected no error on InitSchema(), got '%v'", err)
+	}
+	if _, err := db.Get(1); err != nil {
+		t.Errorf("expected ID 1 to cause no err, got %v", err)
+	}
+}
+
+func Test_CopyDB(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "bookmarks.db")
+	if err := InitSchema(path); err != nil {
+		t.Fatal(err)
+	}
+
+	db, err := NewBukuDB(path, WithJournalMode("WAL"))
+	if err != nil {
+		t.Fatalf("expected no error on NewBukuDB(), got '%v'", err)
+	}
+	defer db.Close()
+	// the bookmark is still in the write-ahead log while db is open
+	if err := db.Add(Bookmark{URL: "https://www.a.com"}); err != nil {
+		t.Fatalf("expected no error on Add(), got '%v'", err)
+	}
+
+	copyPath := filepath.Join(dir, "copy.db")
+	if err := CopyDB(path, copyPath); err != nil {
+		t.Fatalf("expected no error on CopyDB(), got '%v'", err)
+	}
+	dbCopy, err := NewBukuDB(copyPath)
+	if err != nil {
+		t.Fatalf("expected no error on NewBukuDB(), got '%v'", err)
+	}
+	defer dbCopy.Close()
+	if dbCopy.Len() != 1 {
+		t.Errorf("expected the copy to hold '1' bookmark, got '%d'", dbCopy.Len())
+	}
+
+	if err := CopyDB(filepath.Join(dir, "missing.db"), filepath.Join(dir, "other.db")); err == nil {
+		t.Error("expected an error copying a missing database")
+	}
+	if _, err := os.Stat(filepath.Join(dir, "missing.db")); !os.IsNotExist(err) {
+		t.Errorf("expected the missing database not to be created, got '%v'", err)
+	}
+}
+
+func Test_Close_Flush(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "bookmarks.db")
+	if err := InitSchema(path); err != nil {
+		t.Fatal(err)
+	}
+
+	db, err := NewBukuDB(path)
+	if err != nil {
+		t.Fatalf("expected no error on NewBukuDB(), got '%v'", err)
+	}
+	if _, err := db.conn.Exec("PRAGMA journal_mode=WAL;"); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := db.Add(Bookmark{URL: "https://www.a.com", Title: "a"}); err != nil {
+		t.Fatalf("expected no error on Add(), got '%v'", err)
+	}
+	if err := db.Close(); err != nil {
+		t.Fatalf("expected no error on Close(), got '%v'", err)
+	}
+
+	if fi, err := os.Stat(path + "-wal"); err == nil && fi.Size() != 0 {
+		t.Errorf("expected write-ahead log to be checkpointed, has size '%d'", fi.Size())
+	}
+
+	db, err = NewBukuDB(path)
+	if err != nil {
+		t.Fatalf("expected no error on NewBukuDB(), got '%v'", err)
+	}
+	defer db.Close()
+
+	b, err := db.Get(1)
+	if err != nil {
+		t.Fatalf("expected ID 1 to cause no err, got %v", err)
+	}
+	if b.Title != "a" {
+		t.Errorf("expected bookmark title 'a', got '%s'", b.Title)
+	}
+}
+
 func createTestDb(t *testing.T) {
 	t.Helper()
 
@@ -406,3 +807,224 @@ func isMatchingBookmark(t *testing.T, expected, actual Bookmark) bool {
 
 	return match
 }
+
+func Test_formatTags(t *testing.T) {
+	tests := []struct {
+		tags     []string
+		expected string
+	}{
+		{nil, ","},
+		{[]string{"", " "}, ","},
+		{[]string{"a", "", "b", ""}, ",a,b,"},
+		{[]string{" a ", "A", "b", "B"}, ",a,b,"},
+	}
+	for _, test := range tests {
+		if actual := formatTags(test.tags); actual != test.expected {
+			t.Errorf("tags %q: expected '%s', got '%s'", test.tags, test.expected, actual)
+		}
+	}
+}
+
+func Test_AddTags_NoEmptyTags(t *testing.T) {
+	createTestDb(t)
+	db, err := NewBukuDB(sqlTestDbPath)
+	defer cleanUpTestDB(t, db)
+
+	if err != nil {
+		t.Fatalf("expected no error on NewBukuDB(), got '%v'", err)
+	}
+
+	if err := db.AddTags(3, []string{"", "Tag4", "tag4", " "}); err != nil {
+		t.Fatalf("expected no error on AddTags(), got '%v'", err)
+	}
+	if err := db.Add(Bookmark{URL: "https://www.e.com", Tags: []string{"", "e", ""}}); err != nil {
+		t.Fatalf("expected no error on Add(), got '%v'", err)
+	}
+
+	for id, expected := range map[int]string{3: ",Tag4,", 5: ",e,"} {
+		var tags string
+		if err := db.conn.QueryRow("SELECT tags FROM bookmarks WHERE id = ?", id).Scan(&tags); err != nil {
+			t.Fatal(err)
+		}
+		if tags != expected {
+			t.Errorf("expected tags column '%s', got '%s'", expected, tags)
+		}
+	}
+}
+
+func Test_GetAllContext_Cancelled(t *testing.T) {
+	createTestDb(t)
+	db, err := NewBukuDB(sqlTestDbPath)
+	defer cleanUpTestDB(t, db)
+
+	if err != nil {
+		t.Fatalf("expected no error on NewBukuDB(), got '%v'", err)
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	if _, err := db.GetAllContext(ctx); !errors.Is(err, context.Canceled) {
+		t.Errorf("expected error '%v' on GetAllContext(), got '%v'", context.Canceled, err)
+	}
+	if _, err := db.SearchRankedContext(ctx, "a"); !errors.Is(err, context.Canceled) {
+		t.Errorf("expected error '%v' on SearchRankedContext(), got '%v'", context.Canceled, err)
+	}
+}
+
+func Test_GetByIDs(t *testing.T) {
+	createTestDb(t)
+	db, err := NewBukuDB(sqlTestDbPath)
+	defer cleanUpTestDB(t, db)
+
+	if err != nil {
+		t.Fatalf("expected no error on NewBukuDB(), got '%v'", err)
+	}
+
+	bookmarks, err := db.GetByIDs([]uint16{3, 1, 42, 3})
+	if err != nil {
+		t.Fatalf("expected no error on GetByIDs(), got '%v'", err)
+	}
+	if len(bookmarks) != 2 || bookmarks[0].ID != 3 || bookmarks[1].ID != 1 {
+		t.Errorf("expected bookmarks [3 1], got '%v'", bookmarks)
+	}
+	if bookmarks[1].URL != "https://www.a.com" || len(bookmarks[1].Tags) != 3 {
+		t.Errorf("expected bookmark 1 to be loaded, got '%v'", bookmarks[1])
+	}
+
+	if bookmarks, err := db.GetByIDs(nil); err != nil || len(bookmarks) != 0 {
+		t.Errorf("expected no bookmarks and no error, got '%v' and '%v'", bookmarks, err)
+	}
+}
+
+func Test_ForEach(t *testing.T) {
+	createTestDb(t)
+	db, err := NewBukuDB(sqlTestDbPath)
+	defer cleanUpTestDB(t, db)
+
+	if err != nil {
+		t.Fatalf("expected no error on NewBukuDB(), got '%v'", err)
+	}
+
+	all, _ := db.GetAll()
+	var visited []Bookmark
+	if err := db.ForEach(func(b Bookmark) error {
+		visited = append(visited, b)
+		return nil
+	}); err != nil {
+		t.Fatalf("expected no error on ForEach(), got '%v'", err)
+	}
+	if len(visited) != len(all) {
+		t.Fatalf("expected %d bookmarks, got %d", len(all), len(visited))
+	}
+	for i := range all {
+		if !isMatchingBookmark(t, all[i], visited[i]) {
+			t.Errorf("expected bookmark %v, got %v", all[i], visited[i])
+		}
+	}
+
+	// the first error of fn stops the iteration
+	stop := errors.New("stop")
+	calls := 0
+	err = db.ForEach(func(Bookmark) error {
+		calls++
+		return stop
+	})
+	if err != stop || calls != 1 {
+		t.Errorf("expected error '%v' after 1 call, got '%v' after %d", stop, err, calls)
+	}
+}
+
+func Test_BukuDB_Concurrent(t *testing.T) {
+	createTestDb(t)
+	db, err := NewBukuDB(sqlTestDbPath)
+	defer cleanUpTestDB(t, db)
+
+	if err != nil {
+		t.Fatalf("expected no error on NewBukuDB(), got '%v'", err)
+	}
+
+	const workers, rounds = 8, 20
+	before := db.Len()
+	var wg sync.WaitGroup
+	errs := make(chan error, workers*rounds)
+	for w := range workers {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			tag := fmt.Sprintf("w%d", w)
+			for i := range rounds {
+				if err := db.AddTags(1, []string{tag, "tmp"}); err != nil {
+					errs <- err
+				}
+				if err := db.RemoveTags(1, []string{"tmp"}); err != nil {
+					errs <- err
+				}
+				if err := db.UpdateTitle(2, fmt.Sprintf("%s-%d", tag, i)); err != nil {
+					errs <- err
+				}
+				if _, err := db.Get(uint16(i%before + 1)); err != nil {
+					errs <- err
+				}
+				if _, err := db.GetAll(); err != nil {
+					errs <- err
+				}
+				if i%5 == 0 {
+					if err := db.Add(Bookmark{URL: fmt.Sprintf("https://%s-%d.com", tag, i)}); err != nil {
+						errs <- err
+					}
+				}
+				db.Len()
+			}
+		}()
+	}
+	wg.Wait()
+	close(errs)
+	for err := range errs {
+		t.Errorf("expected no error, got '%v'", err)
+	}
+
+	// no tag added by AddTags was lost to a concurrent RemoveTags
+	b, _ := db.Get(1)
+	for w := range workers {
+		if tag := fmt.Sprintf("w%d", w); !slices.Contains(b.Tags, tag) {
+			t.Errorf("expected tag '%s' on bookmark 1, got %v", tag, b.Tags)
+		}
+	}
+	if slices.Contains(b.Tags, "tmp") {
+		t.Errorf("expected tag 'tmp' to be removed, got %v", b.Tags)
+	}
+
+	expected := before + workers*rounds/5
+	all, _ := db.GetAll()
+	if db.Len() != expected || len(all) != expected {
+		t.Errorf("expected %d bookmarks, got Len() %d and %d loaded", expected, db.Len(), len(all))
+	}
+}
+
+func Test_updateField_InvalidField(t *testing.T) {
+	createTestDb(t)
+	db, err := NewBukuDB(sqlTestDbPath)
+	defer cleanUpTestDB(t, db)
+
+	if err != nil {
+		t.Fatalf("expected no error on NewBukuDB(), got '%v'", err)
+	}
+
+	before, _ := db.Get(1)
+	err = db.updateField(1, field(42), "https://www.e.com")
+	if err == nil || !strings.Contains(err.Error(), "unknown field") {
+		t.Errorf("expected unknown field error, got '%v'", err)
+	}
+	after, _ := db.Get(1)
+	if !isMatchingBookmark(t, before, after) {
+		t.Errorf("expected bookmark to be unchanged")
+	}
+
+	// every field has a statement
+	for f := fieldTitle; f <= fieldTags; f++ {
+		if err := db.updateField(1, f, ","); err != nil {
+			t.Errorf("expected no error updating %s, got '%v'", f, err)
+		}
+	}
+}
@@ -0,0 +1,47 @@
+package bukudb
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+)
+
+// ConflictPolicy decides what an import does with a bookmark whose URL is
+// already in the database.
+type ConflictPolicy string
+
+const (
+	// ConflictSkip keeps the existing bookmark as it is, the default.
+	ConflictSkip ConflictPolicy = "skip"
+	// ConflictOverwrite replaces the title, comment, and tags of the
+	// existing bookmark with the imported ones.
+	ConflictOverwrite ConflictPolicy = "overwrite"
+	// ConflictMergeTags adds the imported tags to the existing bookmark and
+	// keeps its title and comment.
+	ConflictMergeTags ConflictPolicy = "merge-tags"
+)
+
+// ParseConflictPolicy returns the policy named s, "" is ConflictSkip.
+func ParseConflictPolicy(s string) (ConflictPolicy, error) {
+	switch p := ConflictPolicy(strings.ToLower(strings.TrimSpace(s))); p {
+	case "":
+		return ConflictSkip, nil
+	case ConflictSkip, ConflictOverwrite, ConflictMergeTags:
+		return p, nil
+	}
+	return "", fmt.Errorf("unknown conflict policy '%s', expected %s, %s or %s",
+		s, ConflictSkip, ConflictOverwrite, ConflictMergeTags)
+}
+
+// mergeTags returns the tags column of a bookmark with the tags column
+// existing and the added tags, sorted like AddTags sorts them.
+func mergeTags(existing string, added []string) string {
+	tags := parseTagsColumn(existing)
+	for _, t := range added {
+		tags = append(tags, strings.TrimSpace(t))
+	}
+	sort.SliceStable(tags, func(i, j int) bool {
+		return strings.ToLower(tags[i]) < strings.ToLower(tags[j])
+	})
+	return formatTags(tags)
+}
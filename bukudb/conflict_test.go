@@ -0,0 +1,41 @@
+package bukudb
+
+import "testing"
+
+func Test_ParseConflictPolicy(t *testing.T) {
+	tests := []struct {
+		value    string
+		expected ConflictPolicy
+		err      bool
+	}{
+		{"", ConflictSkip, false},
+		{"skip", ConflictSkip, false},
+		{" Overwrite ", ConflictOverwrite, false},
+		{"merge-tags", ConflictMergeTags, false},
+		{"merge", "", true},
+	}
+	for _, tt := range tests {
+		got, err := ParseConflictPolicy(tt.value)
+		if got != tt.expected || (err != nil) != tt.err {
+			t.Errorf("value '%s': expected '%s' and error %v, got '%s' and '%v'",
+				tt.value, tt.expected, tt.err, got, err)
+		}
+	}
+}
+
+func Test_mergeTags(t *testing.T) {
+	tests := []struct {
+		existing string
+		added    []string
+		expected string
+	}{
+		{",", nil, ","},
+		{",b,", []string{"a", "B", "c"}, ",a,b,c,"},
+		{",go,web,", []string{"Go", " rust "}, ",go,rust,web,"},
+	}
+	for _, tt := range tests {
+		if got := mergeTags(tt.existing, tt.added); got != tt.expected {
+			t.Errorf("existing '%s' with %v: expected '%s', got '%s'", tt.existing, tt.added, tt.expected, got)
+		}
+	}
+}
@@ -0,0 +1,170 @@
+package bukudb
+
+import (
+	"fmt"
+	"strings"
+	"time"
+	"unicode/utf8"
+)
+
+// dryRunURLMaxLen is the maximum length of URLs in dry run reports.
+const dryRunURLMaxLen = 60
+
+// DryRunDB wraps a DBInterface, reads are passed through while writes only
+// describe what they would have done to report and never reach the inner
+// database.
+type DryRunDB struct {
+	DBInterface
+	report func(msg string)
+	prefix string
+}
+
+// NewDryRunDB returns a DryRunDB reading from inner and reporting writes to
+// report.
+func NewDryRunDB(inner DBInterface, report func(msg string)) *DryRunDB {
+	return &DryRunDB{DBInterface: inner, report: report, prefix: "DRY RUN: "}
+}
+
+// NewReadOnlyDB returns a DryRunDB reading from inner and reporting the
+// refused writes to report.
+func NewReadOnlyDB(inner DBInterface, report func(msg string)) *DryRunDB {
+	return &DryRunDB{DBInterface: inner, report: report, prefix: "READ ONLY: "}
+}
+
+// Add reports the bookmark that would be added.
+func (db *DryRunDB) Add(bookmark Bookmark) error {
+	db.reportf("would add %s", shortURL(bookmark.URL))
+	return nil
+}
+
+// UpdateTitle reports the title that would be set.
+func (db *DryRunDB) UpdateTitle(id uint16, title string) error {
+	db.reportf("would set the title of %s to '%s'", db.describe(id), title)
+	return nil
+}
+
+// UpdateURL reports the URL that would be set.
+func (db *DryRunDB) UpdateURL(id uint16, url string) error {
+	db.reportf("would set the URL of %s to %s", db.describe(id), shortURL(url))
+	return nil
+}
+
+// UpdateComment reports the comment change.
+func (db *DryRunDB) UpdateComment(id uint16, comment string) error {
+	db.reportf("would change the comment of %s", db.describe(id))
+	return nil
+}
+
+// AppendComment reports the note that would be appended.
+func (db *DryRunDB) AppendComment(id uint16, note string) error {
+	db.reportf("would append a note to the comment of %s", db.describe(id))
+	return nil
+}
+
+// Update reports the fields that would be set.
+func (db *DryRunDB) Update(id uint16, b Bookmark, fields FieldMask) error {
+	var names []string
+	for _, f := range []struct {
+		mask FieldMask
+		name string
+	}{{MaskTitle, "title"}, {MaskURL, "URL"}, {MaskComment, "comment"}, {MaskTags, "tags"}} {
+		if fields&f.mask != 0 {
+			names = append(names, f.name)
+		}
+	}
+	db.reportf("would update the %s of %s", strings.Join(names, ", "), db.describe(id))
+	return nil
+}
+
+// RecordVisit reports the visit that would be counted.
+func (db *DryRunDB) RecordVisit(id uint16, at time.Time) error {
+	db.reportf("would count a visit of %s", db.describe(id))
+	return nil
+}
+
+// AddAlias reports the alias that would be added.
+func (db *DryRunDB) AddAlias(id uint16, url string) error {
+	db.reportf("would add alias %s to %s", url, db.describe(id))
+	return nil
+}
+
+// RemoveAlias reports the alias that would be removed.
+func (db *DryRunDB) RemoveAlias(id uint16, url string) error {
+	db.reportf("would remove alias %s from %s", url, db.describe(id))
+	return nil
+}
+
+// ResetVisits reports the visits that would be reset.
+func (db *DryRunDB) ResetVisits(id uint16) error {
+	if id == 0 {
+		db.reportf("would reset the open counts of all bookmarks")
+	} else {
+		db.reportf("would reset the open count of %s", db.describe(id))
+	}
+	return nil
+}
+
+// AddTags reports the tags that would be added.
+func (db *DryRunDB) AddTags(id uint16, tags []string) error {
+	db.reportf("would add tags %s to %s", strings.Join(tags, ", "), db.describe(id))
+	return nil
+}
+
+// RemoveTags reports the tags that would be removed.
+func (db *DryRunDB) RemoveTags(id uint16, tags []string) error {
+	db.reportf("would remove tags %s from %s", strings.Join(tags, ", "), db.describe(id))
+	return nil
+}
+
+// ClearTags reports the tags that would be cleared.
+func (db *DryRunDB) ClearTags(id uint16) error {
+	db.reportf("would clear the tags of %s", db.describe(id))
+	return nil
+}
+
+// Remove reports the bookmark that would be deleted.
+func (db *DryRunDB) Remove(id uint16) error {
+	db.reportf("would delete %s", db.describe(id))
+	return nil
+}
+
+// BulkRemove reports the bookmarks that would be deleted.
+func (db *DryRunDB) BulkRemove(ids []uint16) error {
+	for _, id := range ids {
+		db.reportf("would delete %s", db.describe(id))
+	}
+	return nil
+}
+
+// Restore reports the bookmark that would be restored.
+func (db *DryRunDB) Restore(trashID int64) (uint16, error) {
+	db.reportf("would restore bookmark %d from the trash", trashID)
+	return 0, nil
+}
+
+// EmptyTrash reports that the trash would be emptied.
+func (db *DryRunDB) EmptyTrash() error {
+	db.reportf("would empty the trash")
+	return nil
+}
+
+func (db *DryRunDB) reportf(format string, a ...any) {
+	db.report(db.prefix + fmt.Sprintf(format, a...))
+}
+
+// describe returns the id and the URL of the bookmark with id.
+func (db *DryRunDB) describe(id uint16) string {
+	s := fmt.Sprintf("#%d", id)
+	if b, err := db.Get(id); err == nil {
+		s += " " + shortURL(b.URL)
+	}
+	return s
+}
+
+// shortURL truncates url to dryRunURLMaxLen characters.
+func shortURL(url string) string {
+	if utf8.RuneCountInString(url) <= dryRunURLMaxLen {
+		return url
+	}
+	return string([]rune(url)[:dryRunURLMaxLen-1]) + "…"
+}
@@ -0,0 +1,143 @@
+package bukudb
+
+import (
+	"strings"
+	"testing"
+	"time"
+)
+
+// writeCountingDB counts the writes that reach a BukuDB.
+type writeCountingDB struct {
+	*BukuDB
+	writes int
+}
+
+func (db *writeCountingDB) Add(Bookmark) error                 { db.writes++; return nil }
+func (db *writeCountingDB) UpdateTitle(uint16, string) error   { db.writes++; return nil }
+func (db *writeCountingDB) UpdateURL(uint16, string) error     { db.writes++; return nil }
+func (db *writeCountingDB) UpdateComment(uint16, string) error { db.writes++; return nil }
+func (db *writeCountingDB) AppendComment(uint16, string) error { db.writes++; return nil }
+func (db *writeCountingDB) AddTags(uint16, []string) error     { db.writes++; return nil }
+func (db *writeCountingDB) Update(uint16, Bookmark, FieldMask) error {
+	db.writes++
+	return nil
+}
+func (db *writeCountingDB) RemoveTags(uint16, []string) error   { db.writes++; return nil }
+func (db *writeCountingDB) ClearTags(uint16) error              { db.writes++; return nil }
+func (db *writeCountingDB) Remove(uint16) error                 { db.writes++; return nil }
+func (db *writeCountingDB) BulkRemove([]uint16) error           { db.writes++; return nil }
+func (db *writeCountingDB) Restore(int64) (uint16, error)       { db.writes++; return 0, nil }
+func (db *writeCountingDB) EmptyTrash() error                   { db.writes++; return nil }
+func (db *writeCountingDB) RecordVisit(uint16, time.Time) error { db.writes++; return nil }
+func (db *writeCountingDB) ResetVisits(uint16) error            { db.writes++; return nil }
+func (db *writeCountingDB) AddAlias(uint16, string) error       { db.writes++; return nil }
+func (db *writeCountingDB) RemoveAlias(uint16, string) error    { db.writes++; return nil }
+
+func Test_DryRunDB(t *testing.T) {
+	createTestDb(t)
+	bdb, err := NewBukuDB(sqlTestDbPath)
+	defer cleanUpTestDB(t, bdb)
+
+	if err != nil {
+		t.Fatalf("expected no error on NewBukuDB(), got '%v'", err)
+	}
+
+	inner := &writeCountingDB{BukuDB: bdb}
+	var reports []string
+	db := NewDryRunDB(inner, func(msg string) { reports = append(reports, msg) })
+
+	long := "https://www.e.com/" + strings.Repeat("a", 100)
+	writes := []struct {
+		write    func() error
+		expected string
+	}{
+		{func() error { return db.Add(Bookmark{URL: long}) },
+			"DRY RUN: would add " + long[:59] + "…"},
+		{func() error { return db.UpdateTitle(1, "new") },
+			"DRY RUN: would set the title of #1 https://www.a.com to 'new'"},
+		{func() error { return db.UpdateURL(2, "https://www.e.com") },
+			"DRY RUN: would set the URL of #2 https://www.b.com to https://www.e.com"},
+		{func() error { return db.UpdateComment(3, "comment") },
+			"DRY RUN: would change the comment of #3 https://www.c.com"},
+		{func() error { return db.AppendComment(3, "note") },
+			"DRY RUN: would append a note to the comment of #3 https://www.c.com"},
+		{func() error { return db.Update(2, Bookmark{}, MaskTitle|MaskTags) },
+			"DRY RUN: would update the title, tags of #2 https://www.b.com"},
+		{func() error { return db.AddTags(1, []string{"x", "y"}) },
+			"DRY RUN: would add tags x, y to #1 https://www.a.com"},
+		{func() error { return db.RemoveTags(1, []string{"a"}) },
+			"DRY RUN: would remove tags a from #1 https://www.a.com"},
+		{func() error { return db.ClearTags(2) },
+			"DRY RUN: would clear the tags of #2 https://www.b.com"},
+		{func() error { return db.Remove(4) },
+			"DRY RUN: would delete #4 https://www.d.com"},
+		{func() error { return db.Remove(42) },
+			"DRY RUN: would delete #42"},
+		{func() error { return db.BulkRemove([]uint16{3}) },
+			"DRY RUN: would delete #3 https://www.c.com"},
+		{func() error { _, err := db.Restore(7); return err },
+			"DRY RUN: would restore bookmark 7 from the trash"},
+		{func() error { return db.EmptyTrash() },
+			"DRY RUN: would empty the trash"},
+		{func() error { return db.RecordVisit(1, time.Now()) },
+			"DRY RUN: would count a visit of #1 https://www.a.com"},
+		{func() error { return db.ResetVisits(2) },
+			"DRY RUN: would reset the open count of #2 https://www.b.com"},
+		{func() error { return db.ResetVisits(0) },
+			"DRY RUN: would reset the open counts of all bookmarks"},
+		{func() error { return db.AddAlias(1, "https://a.org") },
+			"DRY RUN: would add alias https://a.org to #1 https://www.a.com"},
+		{func() error { return db.RemoveAlias(1, "https://a.org") },
+			"DRY RUN: would remove alias https://a.org from #1 https://www.a.com"},
+	}
+
+	for i, w := range writes {
+		if err := w.write(); err != nil {
+			t.Errorf("expected no error on write %d, got '%v'", i, err)
+		}
+		if len(reports) != i+1 || reports[i] != w.expected {
+			t.Errorf("expected report '%s', got '%v'", w.expected, reports)
+		}
+	}
+
+	if inner.writes != 0 {
+		t.Errorf("expected no writes to reach the inner db, got %d", inner.writes)
+	}
+
+	// reads are passed through
+	if db.Len() != 4 {
+		t.Errorf("expected length 4, got %d", db.Len())
+	}
+	bookmarks, err := db.GetAll()
+	if err != nil || len(bookmarks) != 4 {
+		t.Errorf("expected 4 bookmarks, got %d and error '%v'", len(bookmarks), err)
+	}
+	if b, _ := db.Get(1); b.Title != "metadata (title) a" || len(b.Tags) != 3 {
+		t.Errorf("expected bookmark 1 to be unchanged, got '%v'", b)
+	}
+}
+
+func Test_NewReadOnlyDB(t *testing.T) {
+	createTestDb(t)
+	bdb, err := NewBukuDB(sqlTestDbPath)
+	defer cleanUpTestDB(t, bdb)
+
+	if err != nil {
+		t.Fatalf("expected no error on NewBukuDB(), got '%v'", err)
+	}
+
+	inner := &writeCountingDB{BukuDB: bdb}
+	var reports []string
+	db := NewReadOnlyDB(inner, func(msg string) { reports = append(reports, msg) })
+
+	if err := db.Remove(4); err != nil {
+		t.Errorf("expected no error on Remove(), got '%v'", err)
+	}
+	expected := "READ ONLY: would delete #4 https://www.d.com"
+	if len(reports) != 1 || reports[0] != expected {
+		t.Errorf("expected report '%s', got '%v'", expected, reports)
+	}
+	if inner.writes != 0 || db.Len() != 4 {
+		t.Errorf("expected no writes to reach the inner db, got %d writes and length %d", inner.writes, db.Len())
+	}
+}
@@ -0,0 +1,53 @@
+package bukudb
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+
+	"github.com/mattn/go-sqlite3"
+)
+
+// ErrDuplicateURL is matched by the error of an update giving a bookmark the
+// URL of another one.
+var ErrDuplicateURL = errors.New("url already bookmarked")
+
+// DuplicateURLError is returned when an update would give a bookmark the URL
+// of another one, the URL column is unique.
+type DuplicateURLError struct {
+	URL string
+
+	// ID of the bookmark that has the URL, 0 if it could not be found.
+	ID uint16
+}
+
+func (e *DuplicateURLError) Error() string {
+	if e.ID == 0 {
+		return fmt.Sprintf("%s is already bookmarked", e.URL)
+	}
+	return fmt.Sprintf("%s is already bookmarked as #%d", e.URL, e.ID)
+}
+
+// Is makes errors.Is(err, ErrDuplicateURL) true.
+func (e *DuplicateURLError) Is(target error) bool {
+	return target == ErrDuplicateURL
+}
+
+// rowQuerier is implemented by *sql.DB, *loggedDB, and *loggedTx.
+type rowQuerier interface {
+	QueryRow(query string, args ...any) *sql.Row
+}
+
+// duplicateURL returns a DuplicateURLError if err is the unique constraint
+// of the URL column failing for url, otherwise nil.
+func duplicateURL(q rowQuerier, err error, url string) error {
+	var sqliteErr sqlite3.Error
+	if !errors.As(err, &sqliteErr) || sqliteErr.ExtendedCode != sqlite3.ErrConstraintUnique {
+		return nil
+	}
+	var id uint16
+	if err := q.QueryRow(`SELECT id FROM bookmarks WHERE URL = ?`, url).Scan(&id); err != nil {
+		id = 0
+	}
+	return &DuplicateURLError{URL: url, ID: id}
+}
@@ -0,0 +1,57 @@
+package bukudb
+
+import (
+	"errors"
+	"testing"
+)
+
+func Test_UpdateURL_Duplicate(t *testing.T) {
+	createTestDb(t)
+	db, err := NewBukuDB(sqlTestDbPath)
+	defer cleanUpTestDB(t, db)
+
+	if err != nil {
+		t.Fatalf("expected no error on NewBukuDB(), got '%v'", err)
+	}
+
+	err = db.UpdateURL(1, "https://www.c.com")
+	if !errors.Is(err, ErrDuplicateURL) {
+		t.Fatalf("expected ErrDuplicateURL on UpdateURL(), got '%v'", err)
+	}
+	var dup *DuplicateURLError
+	if !errors.As(err, &dup) || dup.ID != 3 || dup.URL != "https://www.c.com" {
+		t.Errorf("expected the url to belong to #3, got %+v", dup)
+	}
+	if expected := "https://www.c.com is already bookmarked as #3"; err.Error() != expected {
+		t.Errorf("expected error '%s', got '%s'", expected, err)
+	}
+
+	if b, _ := db.Get(1); b.URL != "https://www.a.com" {
+		t.Errorf("expected url to be unchanged, got '%s'", b.URL)
+	}
+
+	// other errors are not mapped
+	if err := db.UpdateURL(9, "https://www.c.com"); errors.Is(err, ErrDuplicateURL) {
+		t.Errorf("expected an out of range error, got '%v'", err)
+	}
+}
+
+func Test_Add_Duplicate(t *testing.T) {
+	createTestDb(t)
+	db, err := NewBukuDB(sqlTestDbPath)
+	defer cleanUpTestDB(t, db)
+
+	if err != nil {
+		t.Fatalf("expected no error on NewBukuDB(), got '%v'", err)
+	}
+
+	before := db.Len()
+	err = db.Add(Bookmark{URL: "https://www.c.com", Title: "again"})
+	var dup *DuplicateURLError
+	if !errors.As(err, &dup) || dup.ID != 3 {
+		t.Fatalf("expected a duplicate url error for #3 on Add(), got '%v'", err)
+	}
+	if db.Len() != before {
+		t.Errorf("expected %d bookmarks, got %d", before, db.Len())
+	}
+}
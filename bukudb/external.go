@@ -0,0 +1,96 @@
+package bukudb
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+	"os"
+	"time"
+)
+
+// ErrChangedOnDisk is returned by CheckBookmark when another program, like a
+// sync tool, changed the bookmark since it was read.
+var ErrChangedOnDisk = errors.New("bookmark changed on disk, please retry")
+
+// fileStamp identifies a version of the database file.
+type fileStamp struct {
+	modTime time.Time
+	size    int64
+}
+
+// stater returns the fileStamp of the file at path, tests replace it.
+type stater interface {
+	stamp(path string) (fileStamp, error)
+}
+
+// osStater stats the file system.
+type osStater struct{}
+
+func (osStater) stamp(path string) (fileStamp, error) {
+	fi, err := os.Stat(path)
+	if err != nil {
+		return fileStamp{}, err
+	}
+	return fileStamp{modTime: fi.ModTime(), size: fi.Size()}, nil
+}
+
+// Refresh re-reads what db caches about the database file, for when another
+// program replaced or changed it.
+func (db *BukuDB) Refresh() error {
+	db.mu.Lock()
+	defer db.mu.Unlock()
+	return db.refreshLocked()
+}
+
+// refreshLocked is Refresh with db.mu held.
+func (db *BukuDB) refreshLocked() error {
+	l, err := getMaxBookmarkID(db.conn, db.max)
+	if err != nil {
+		return fmt.Errorf("failed to refresh database: %w", err)
+	}
+	db.len = l
+	// another program may have created it
+	if !db.hasMeta {
+		if db.hasMeta, err = hasTable(db.conn, "robuku_meta"); err != nil {
+			return fmt.Errorf("failed to refresh database: %w", err)
+		}
+	}
+	// a file that can't be stat'ed, e.g. it was just moved away, is
+	// compared again before the next write
+	db.stamp, _ = db.stat.stamp(db.dbPath)
+	return nil
+}
+
+// syncLocked refreshes db if the database file changed since it was opened
+// or last refreshed, and reports whether it did. db's own writes count as
+// changes too, refreshing after them is cheap.
+func (db *BukuDB) syncLocked() (bool, error) {
+	s, err := db.stat.stamp(db.dbPath)
+	if err != nil || s == db.stamp {
+		return false, nil
+	}
+	return true, db.refreshLocked()
+}
+
+// CheckBookmark returns ErrChangedOnDisk if the database file changed since
+// db last read it and the bookmark with the given ID is gone or no longer
+// has url, so a write meant for it would change another bookmark. An empty
+// url only checks that the bookmark still exists.
+func (db *BukuDB) CheckBookmark(id uint16, url string) error {
+	db.mu.Lock()
+	defer db.mu.Unlock()
+
+	changed, err := db.syncLocked()
+	if err != nil || !changed {
+		return err
+	}
+	var current string
+	err = db.conn.QueryRow(`SELECT URL FROM bookmarks WHERE id = ?`, id).Scan(&current)
+	if errors.Is(err, sql.ErrNoRows) || err == nil && url != "" && current != url {
+		return ErrChangedOnDisk
+	}
+	if err != nil {
+		return fmt.Errorf("failed to check bookmark: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,112 @@
+package bukudb
+
+import (
+	"database/sql"
+	"errors"
+	"testing"
+	"time"
+)
+
+// fakeStater returns s, or err if set, for any path.
+type fakeStater struct {
+	s   fileStamp
+	err error
+}
+
+func (f *fakeStater) stamp(string) (fileStamp, error) {
+	return f.s, f.err
+}
+
+// touch makes f report another version of the file.
+func (f *fakeStater) touch() {
+	f.s.modTime = f.s.modTime.Add(time.Second)
+}
+
+// externalExec runs query on the test database without db, like a sync
+// tool would.
+func externalExec(t *testing.T, query string, args ...any) {
+	t.Helper()
+	conn, err := sql.Open("sqlite3", sqlTestDbPath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer conn.Close()
+	if _, err := conn.Exec(query, args...); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func Test_ExternalChange(t *testing.T) {
+	createTestDb(t)
+	db, err := NewBukuDB(sqlTestDbPath)
+	defer cleanUpTestDB(t, db)
+
+	if err != nil {
+		t.Fatalf("expected no error on NewBukuDB(), got '%v'", err)
+	}
+	stat := &fakeStater{}
+	db.stat = stat
+
+	if err := db.CheckBookmark(2, "https://www.b.com"); err != nil {
+		t.Errorf("expected no error on an unchanged file, got '%v'", err)
+	}
+
+	// an unnoticed insert would give Add a taken ID
+	externalExec(t, `INSERT INTO bookmarks (id, URL) VALUES (5, 'https://www.e.com')`)
+	stat.touch()
+	if err := db.Add(Bookmark{URL: "https://www.f.com"}); err != nil {
+		t.Fatalf("expected no error on Add(), got '%v'", err)
+	}
+	if b, _ := db.Get(6); b.URL != "https://www.f.com" {
+		t.Errorf("expected the bookmark to be added as #6, got '%s'", b.URL)
+	}
+
+	externalExec(t, `UPDATE bookmarks SET URL = 'https://www.x.com' WHERE id = 2`)
+	stat.touch()
+	if err := db.CheckBookmark(2, "https://www.b.com"); !errors.Is(err, ErrChangedOnDisk) {
+		t.Errorf("expected ErrChangedOnDisk for a changed url, got '%v'", err)
+	}
+
+	externalExec(t, `DELETE FROM bookmarks WHERE id = 3`)
+	stat.touch()
+	if err := db.CheckBookmark(3, ""); !errors.Is(err, ErrChangedOnDisk) {
+		t.Errorf("expected ErrChangedOnDisk for a removed bookmark, got '%v'", err)
+	}
+
+	stat.touch()
+	if err := db.CheckBookmark(1, "https://www.a.com"); err != nil {
+		t.Errorf("expected no error for an unchanged bookmark, got '%v'", err)
+	}
+	if err := db.CheckBookmark(4, ""); err != nil {
+		t.Errorf("expected no error without a url, got '%v'", err)
+	}
+
+	// a file that can't be stat'ed is taken as unchanged
+	externalExec(t, `UPDATE bookmarks SET URL = 'https://www.y.com' WHERE id = 1`)
+	stat.touch()
+	stat.err = errors.New("stat failed")
+	if err := db.CheckBookmark(1, "https://www.a.com"); err != nil {
+		t.Errorf("expected no error when stat fails, got '%v'", err)
+	}
+}
+
+func Test_Refresh(t *testing.T) {
+	createTestDb(t)
+	db, err := NewBukuDB(sqlTestDbPath)
+	defer cleanUpTestDB(t, db)
+
+	if err != nil {
+		t.Fatalf("expected no error on NewBukuDB(), got '%v'", err)
+	}
+
+	externalExec(t, `INSERT INTO bookmarks (id, URL) VALUES (5, 'https://www.e.com')`)
+	if err := db.Refresh(); err != nil {
+		t.Fatalf("expected no error on Refresh(), got '%v'", err)
+	}
+	if db.Len() != 5 {
+		t.Errorf("expected length 5, got %d", db.Len())
+	}
+	if db.stamp == (fileStamp{}) {
+		t.Errorf("expected the stamp of the file to be recorded")
+	}
+}
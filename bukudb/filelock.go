@@ -0,0 +1,44 @@
+package bukudb
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"syscall"
+	"time"
+)
+
+// ErrLocked is returned when another robuku instance holds the write lock
+// for longer than the wait.
+var ErrLocked = errors.New("another robuku instance is writing, try again")
+
+// lockRetryInterval is how often a lock held by another process is tried
+// again.
+const lockRetryInterval = 20 * time.Millisecond
+
+// lockFile takes an exclusive advisory lock on the file at path, creating
+// it if needed, and waits up to wait while another process holds it. The
+// returned unlock releases the lock.
+func lockFile(path string, wait time.Duration) (unlock func(), err error) {
+	f, err := os.OpenFile(path, os.O_RDWR|os.O_CREATE, 0o600)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open lock file: %w", err)
+	}
+	deadline := time.Now().Add(wait)
+	for {
+		err := syscall.Flock(int(f.Fd()), syscall.LOCK_EX|syscall.LOCK_NB)
+		if err == nil {
+			// closing the file releases the lock
+			return func() { f.Close() }, nil
+		}
+		if !errors.Is(err, syscall.EWOULDBLOCK) && !errors.Is(err, syscall.EINTR) {
+			f.Close()
+			return nil, fmt.Errorf("failed to lock %s: %w", path, err)
+		}
+		if !time.Now().Before(deadline) {
+			f.Close()
+			return nil, ErrLocked
+		}
+		time.Sleep(min(lockRetryInterval, time.Until(deadline)))
+	}
+}
@@ -0,0 +1,102 @@
+package bukudb
+
+import (
+	"bufio"
+	"errors"
+	"io"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func Test_lockFile(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "robuku.lock")
+
+	unlock, err := lockFile(path, 0)
+	if err != nil {
+		t.Fatalf("expected no error on lockFile(), got '%v'", err)
+	}
+
+	// a second lock fails after the wait
+	errs := make(chan error)
+	go func() {
+		_, err := lockFile(path, 50*time.Millisecond)
+		errs <- err
+	}()
+	if err := <-errs; !errors.Is(err, ErrLocked) {
+		t.Errorf("expected ErrLocked, got '%v'", err)
+	}
+
+	// and succeeds when the lock is released within the wait
+	go func() {
+		unlock, err := lockFile(path, 2*time.Second)
+		if err == nil {
+			unlock()
+		}
+		errs <- err
+	}()
+	time.Sleep(50 * time.Millisecond)
+	unlock()
+	if err := <-errs; err != nil {
+		t.Errorf("expected no error once unlocked, got '%v'", err)
+	}
+}
+
+func Test_lockFile_badPath(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "missing", "robuku.lock")
+	if _, err := lockFile(path, 0); err == nil || errors.Is(err, ErrLocked) {
+		t.Errorf("expected error opening lock file, got '%v'", err)
+	}
+}
+
+// lockHelperEnvVar makes the test binary hold the lock in its path until
+// stdin is closed, standing in for another robuku instance.
+const lockHelperEnvVar = "ROBUKU_TEST_LOCK_HELPER"
+
+func Test_lockFile_process(t *testing.T) {
+	if path := os.Getenv(lockHelperEnvVar); path != "" {
+		unlock, err := lockFile(path, 0)
+		if err != nil {
+			os.Exit(1)
+		}
+		os.Stdout.WriteString("locked\n")
+		io.Copy(io.Discard, os.Stdin)
+		unlock()
+		os.Exit(0)
+	}
+
+	path := filepath.Join(t.TempDir(), "robuku.lock")
+	cmd := exec.Command(os.Args[0], "-test.run=^Test_lockFile_process$")
+	cmd.Env = append(os.Environ(), lockHelperEnvVar+"="+path)
+	stdin, err := cmd.StdinPipe()
+	if err != nil {
+		t.Fatal(err)
+	}
+	stdout, err := cmd.StdoutPipe()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := cmd.Start(); err != nil {
+		t.Fatal(err)
+	}
+	if line, _ := bufio.NewReader(stdout).ReadString('\n'); line != "locked\n" {
+		t.Fatalf("expected helper to hold the lock, got '%s'", line)
+	}
+
+	if _, err := lockFile(path, 50*time.Millisecond); !errors.Is(err, ErrLocked) {
+		t.Errorf("expected ErrLocked while another process holds the lock, got '%v'", err)
+	}
+
+	stdin.Close()
+	if err := cmd.Wait(); err != nil {
+		t.Fatalf("expected helper to exit cleanly, got '%v'", err)
+	}
+	unlock, err := lockFile(path, 0)
+	if err != nil {
+		t.Errorf("expected no error after the process exited, got '%v'", err)
+	} else {
+		unlock()
+	}
+}
@@ -0,0 +1,74 @@
+package bukudb
+
+import (
+	"fmt"
+	"strings"
+)
+
+// parseTagsColumn returns the tags of a tags column. buku writes them as
+// ",tag1,tag2,", other programs may leave out the outer commas or pad the
+// tags with spaces, which are read the same. It returns nil for no tags.
+func parseTagsColumn(column string) []string {
+	var tags []string
+	for _, t := range strings.Split(column, ",") {
+		if t = strings.TrimSpace(t); t != "" {
+			tags = append(tags, t)
+		}
+	}
+	return tags
+}
+
+// TagFix is a tags column rewritten to buku's format.
+type TagFix struct {
+	ID  uint16
+	Old string
+	New string
+}
+
+// FixTags rewrites every tags column not in buku's ",tag1,tag2," format,
+// like "tag1,tag2" or ", tag1 ,,", in one transaction. With dryRun nothing
+// is written. It returns the columns that were, or would be, rewritten in
+// ID order.
+func (db *BukuDB) FixTags(dryRun bool) ([]TagFix, error) {
+	db.mu.Lock()
+	defer db.mu.Unlock()
+
+	tx, err := db.conn.Begin()
+	if err != nil {
+		return nil, fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	rows, err := tx.Query(`SELECT id, tags FROM bookmarks WHERE tags IS NOT NULL ORDER BY id`)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query tags: %w", err)
+	}
+	var fixes []TagFix
+	for rows.Next() {
+		var fix TagFix
+		if err := rows.Scan(&fix.ID, &fix.Old); err != nil {
+			rows.Close()
+			return nil, fmt.Errorf("failed to scan tags: %w", err)
+		}
+		if fix.New = formatTags(parseTagsColumn(fix.Old)); fix.New != fix.Old {
+			fixes = append(fixes, fix)
+		}
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("failed to query tags: %w", err)
+	}
+	if dryRun {
+		return fixes, nil
+	}
+
+	for _, fix := range fixes {
+		if _, err := tx.Exec(`UPDATE bookmarks SET tags = ? WHERE id = ?`, fix.New, fix.ID); err != nil {
+			return nil, fmt.Errorf("failed to fix tags of bookmark %d: %w", fix.ID, err)
+		}
+	}
+	if err := tx.Commit(); err != nil {
+		return nil, fmt.Errorf("failed to commit tag fixes: %w", err)
+	}
+	return fixes, nil
+}
@@ -0,0 +1,139 @@
+package bukudb
+
+import (
+	"database/sql"
+	"fmt"
+	"path/filepath"
+	"slices"
+	"testing"
+)
+
+func Test_parseTagsColumn(t *testing.T) {
+	tests := []struct {
+		column   string
+		expected []string
+	}{
+		{",a,b,", []string{"a", "b"}},
+		{"a,b", []string{"a", "b"}},
+		{"a,b,", []string{"a", "b"}},
+		{",a,b", []string{"a", "b"}},
+		{" a , b ", []string{"a", "b"}},
+		{", a,,b ,", []string{"a", "b"}},
+		{"a", []string{"a"}},
+		{",two words,", []string{"two words"}},
+		{",", nil},
+		{",,", nil},
+		{"", nil},
+		{" , ", nil},
+	}
+	for _, tt := range tests {
+		if actual := parseTagsColumn(tt.column); !slices.Equal(actual, tt.expected) {
+			t.Errorf("expected tags %q of column %q, got %q", tt.expected, tt.column, actual)
+		}
+	}
+}
+
+// newTagsTestDB returns a database with a bookmark per tags column.
+func newTagsTestDB(t *testing.T, columns []string) *BukuDB {
+	t.Helper()
+
+	path := filepath.Join(t.TempDir(), "bookmarks.db")
+	if err := InitSchema(path); err != nil {
+		t.Fatal(err)
+	}
+	conn, err := sql.Open("sqlite3", path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer conn.Close()
+	for i, c := range columns {
+		_, err := conn.Exec(`INSERT INTO bookmarks (id, URL, tags) VALUES (?, ?, ?)`,
+			i+1, fmt.Sprintf("https://%d.com", i+1), c)
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	db, err := NewBukuDB(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { db.Close() })
+	return db
+}
+
+func Test_NonCanonicalTags_Read(t *testing.T) {
+	db := newTagsTestDB(t, []string{"go,video", " go ,blog,", ",rust,"})
+
+	b, err := db.Get(1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !slices.Equal(b.Tags, []string{"go", "video"}) {
+		t.Errorf("expected tags [go video], got %q", b.Tags)
+	}
+
+	found, err := db.SearchByTags([]string{"go"}, nil, true)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(found) != 1 || found[0].ID != 1 {
+		t.Errorf("expected the first tag of a column without outer commas to match, got %v", found)
+	}
+	if found, err = db.SearchByTags([]string{"video"}, nil, true); err != nil || len(found) != 1 {
+		t.Errorf("expected the last tag of a column without outer commas to match, got %v, %v", found, err)
+	}
+
+	tags, err := db.Tags()
+	if err != nil {
+		t.Fatal(err)
+	}
+	expected := []TagCount{{"blog", 1}, {"go", 2}, {"rust", 1}, {"video", 1}}
+	if !slices.Equal(tags, expected) {
+		t.Errorf("expected tag counts %v, got %v", expected, tags)
+	}
+}
+
+func Test_FixTags(t *testing.T) {
+	db := newTagsTestDB(t, []string{",a,b,", "a,b", " a , b ", ",a,,A,", ",", ""})
+	expected := []TagFix{
+		{ID: 2, Old: "a,b", New: ",a,b,"},
+		{ID: 3, Old: " a , b ", New: ",a,b,"},
+		{ID: 4, Old: ",a,,A,", New: ",a,"},
+		{ID: 6, Old: "", New: ","},
+	}
+	column := func(id int) string {
+		var tags string
+		if err := db.conn.QueryRow(`SELECT tags FROM bookmarks WHERE id = ?`, id).Scan(&tags); err != nil {
+			t.Fatal(err)
+		}
+		return tags
+	}
+
+	fixes, err := db.FixTags(true)
+	if err != nil {
+		t.Fatalf("expected no error on FixTags(true), got '%v'", err)
+	}
+	if !slices.Equal(fixes, expected) {
+		t.Errorf("expected fixes %v, got %v", expected, fixes)
+	}
+	if actual := column(2); actual != "a,b" {
+		t.Errorf("expected a dry run to keep 'a,b', got '%s'", actual)
+	}
+
+	if fixes, err = db.FixTags(false); err != nil {
+		t.Fatalf("expected no error on FixTags(false), got '%v'", err)
+	}
+	if !slices.Equal(fixes, expected) {
+		t.Errorf("expected fixes %v, got %v", expected, fixes)
+	}
+	for _, fix := range expected {
+		if actual := column(int(fix.ID)); actual != fix.New {
+			t.Errorf("expected tags '%s' of bookmark %d, got '%s'", fix.New, fix.ID, actual)
+		}
+	}
+
+	if fixes, err = db.FixTags(false); err != nil || len(fixes) != 0 {
+		t.Errorf("expected nothing left to fix, got %v, %v", fixes, err)
+	}
+}
@@ -0,0 +1,148 @@
+package bukudb
+
+import (
+	"database/sql"
+	"fmt"
+	"log"
+	"strings"
+	"unicode"
+)
+
+// The full text index lives in its own robuku_fts table and robuku_fts_*
+// triggers, buku ignores objects it does not know about so the database
+// stays usable by buku. The triggers however need FTS5 in every SQLite that
+// writes to bookmarks, including the one buku runs on (Python's sqlite3
+// ships with FTS5 on all common platforms). Use DropFTS to remove the index
+// again.
+//
+// robuku_fts is contentless, the bookmark columns are only indexed, not
+// stored a second time, and its rowid is the bookmark id.
+const ftsSchema = `
+CREATE VIRTUAL TABLE IF NOT EXISTS robuku_fts USING fts5(
+    metadata, url, tags, desc, content=''
+);
+CREATE TRIGGER IF NOT EXISTS robuku_fts_insert AFTER INSERT ON bookmarks BEGIN
+    INSERT INTO robuku_fts(rowid, metadata, url, tags, desc)
+    VALUES (new.id, coalesce(new.metadata, ''), new.URL, coalesce(new.tags, ''), coalesce(new.desc, ''));
+END;
+CREATE TRIGGER IF NOT EXISTS robuku_fts_delete AFTER DELETE ON bookmarks BEGIN
+    INSERT INTO robuku_fts(robuku_fts, rowid, metadata, url, tags, desc)
+    VALUES ('delete', old.id, coalesce(old.metadata, ''), old.URL, coalesce(old.tags, ''), coalesce(old.desc, ''));
+END;
+CREATE TRIGGER IF NOT EXISTS robuku_fts_update AFTER UPDATE ON bookmarks BEGIN
+    INSERT INTO robuku_fts(robuku_fts, rowid, metadata, url, tags, desc)
+    VALUES ('delete', old.id, coalesce(old.metadata, ''), old.URL, coalesce(old.tags, ''), coalesce(old.desc, ''));
+    INSERT INTO robuku_fts(rowid, metadata, url, tags, desc)
+    VALUES (new.id, coalesce(new.metadata, ''), new.URL, coalesce(new.tags, ''), coalesce(new.desc, ''));
+END;`
+
+// ftsPopulate indexes the bookmarks of a newly created robuku_fts table.
+const ftsPopulate = `INSERT INTO robuku_fts(rowid, metadata, url, tags, desc)
+SELECT id, coalesce(metadata, ''), URL, coalesce(tags, ''), coalesce(desc, '')
+FROM bookmarks`
+
+// ftsDrop removes the full text index and its triggers.
+const ftsDrop = `
+DROP TRIGGER IF EXISTS robuku_fts_insert;
+DROP TRIGGER IF EXISTS robuku_fts_delete;
+DROP TRIGGER IF EXISTS robuku_fts_update;
+DROP TABLE IF EXISTS robuku_fts;`
+
+// bm25 column weights of robuku_fts, in column order, matching the ranking
+// of the LIKE search.
+var ftsWeights = fmt.Sprintf("%d, %d, %d, %d", rankTitle, rankURL, rankTags, rankComment)
+
+// setupFTS creates and populates robuku_fts if it is missing, it returns
+// false without an error if the SQLite driver was built without FTS5.
+func setupFTS(conn *loggedDB) (bool, error) {
+	if ok, err := hasFTS5(conn); err != nil || !ok {
+		return false, err
+	}
+
+	var exists bool
+	row := conn.QueryRow(`SELECT COUNT(*) > 0 FROM sqlite_master
+		WHERE type = 'table' AND name = 'robuku_fts'`)
+	if err := row.Scan(&exists); err != nil {
+		return false, fmt.Errorf("failed to check full text index: %w", err)
+	}
+
+	tx, err := conn.Begin()
+	if err != nil {
+		return false, fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	if _, err := tx.Exec(ftsSchema); err != nil {
+		return false, fmt.Errorf("failed to create full text index: %w", err)
+	}
+	if !exists {
+		if _, err := tx.Exec(ftsPopulate); err != nil {
+			return false, fmt.Errorf("failed to populate full text index: %w", err)
+		}
+	}
+
+	if err := tx.Commit(); err != nil {
+		return false, fmt.Errorf("failed to commit full text index: %w", err)
+	}
+	return true, nil
+}
+
+// DropFTS removes the robuku full text index and its triggers from the
+// database at dbPath.
+func DropFTS(dbPath string) error {
+	conn, err := sql.Open("sqlite3", dbPath)
+	if err != nil {
+		return fmt.Errorf("failed to open database: %w", err)
+	}
+	defer conn.Close()
+
+	if _, err := conn.Exec(ftsDrop); err != nil {
+		return fmt.Errorf("failed to drop full text index: %w", err)
+	}
+	return nil
+}
+
+// hasFTS5 reports whether the SQLite driver was compiled with FTS5, which
+// go-sqlite3 only does with the sqlite_fts5 build tag.
+func hasFTS5(conn *loggedDB) (bool, error) {
+	var ok bool
+	row := conn.QueryRow("SELECT sqlite_compileoption_used('ENABLE_FTS5');")
+	if err := row.Scan(&ok); err != nil {
+		return false, fmt.Errorf("failed to check for FTS5: %w", err)
+	}
+	if !ok {
+		log.Println("ERROR full text search needs FTS5, falling back to LIKE search")
+	}
+	return ok, nil
+}
+
+// buildFTSQuery returns the FTS5 query matching bookmarks that contain all
+// terms as prefixes, or false if a term has no letters or digits and can
+// not be matched through the index.
+func buildFTSQuery(terms []string) (string, bool) {
+	phrases := make([]string, 0, len(terms))
+	for _, term := range terms {
+		if !strings.ContainsFunc(term, func(r rune) bool {
+			return unicode.IsLetter(r) || unicode.IsDigit(r)
+		}) {
+			return "", false
+		}
+		phrases = append(phrases, `"`+strings.ReplaceAll(term, `"`, `""`)+`"*`)
+	}
+	return strings.Join(phrases, " "), true
+}
+
+// buildFTSSearchQuery returns the SQL and its bound arguments for a full
+// text search of terms ranked by bm25, selecting from source.
+func buildFTSSearchQuery(terms []string, source string) (string, []any, bool) {
+	match, ok := buildFTSQuery(terms)
+	if !ok {
+		return "", nil, false
+	}
+
+	query := `SELECT ` + bookmarkColumns + `
+		FROM ` + source + ` JOIN robuku_fts ON robuku_fts.rowid = bookmarks.id
+		WHERE robuku_fts MATCH ?
+		ORDER BY bm25(robuku_fts, ` + ftsWeights + `), bookmarks.id`
+	return query, []any{match}, true
+}
@@ -0,0 +1,196 @@
+package bukudb
+
+import (
+	"database/sql"
+	"path/filepath"
+	"testing"
+)
+
+func newFTSTestDB(t *testing.T) (*BukuDB, string) {
+	path := filepath.Join(t.TempDir(), "bookmarks.db")
+	if err := InitSchema(path); err != nil {
+		t.Fatal(err)
+	}
+
+	db, err := NewBukuDB(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, b := range []Bookmark{
+		{URL: "https://golang.org", Title: "The Go Programming Language"},
+		{URL: "https://rust-lang.org", Title: "Rust", Tags: []string{"lang"}},
+	} {
+		if err := db.Add(b); err != nil {
+			t.Fatal(err)
+		}
+	}
+	db.Close()
+
+	db, err = NewBukuDB(path, WithFTS(true))
+	if err != nil {
+		t.Fatalf("expected no error on NewBukuDB() with FTS, got '%v'", err)
+	}
+	t.Cleanup(func() { db.Close() })
+
+	if ok, _ := hasFTS5(db.conn); !ok {
+		t.Skip("SQLite driver built without FTS5, run tests with -tags sqlite_fts5")
+	}
+	return db, path
+}
+
+func countObjects(t *testing.T, conn rowQuerier, typ, pattern string) int {
+	var n int
+	row := conn.QueryRow("SELECT COUNT(*) FROM sqlite_master WHERE type = ? AND name LIKE ?",
+		typ, pattern)
+	if err := row.Scan(&n); err != nil {
+		t.Fatal(err)
+	}
+	return n
+}
+
+func Test_FTS_Create(t *testing.T) {
+	db, _ := newFTSTestDB(t)
+
+	if !db.fts {
+		t.Error("expected full text search to be enabled")
+	}
+	if n := countObjects(t, db.conn, "trigger", "robuku_fts_%"); n != 3 {
+		t.Errorf("expected 3 robuku_fts triggers, got %d", n)
+	}
+
+	// existing bookmarks are indexed on creation
+	if ids := searchIDs(t, db, "programming"); len(ids) != 1 || ids[0] != 1 {
+		t.Errorf("expected [1], got %v", ids)
+	}
+	// title prefix matches rank above tag and URL matches
+	if ids := searchIDs(t, db, "lang"); len(ids) != 2 || ids[0] != 1 {
+		t.Errorf("expected [1 2], got %v", ids)
+	}
+
+	// reopening does not index the bookmarks a second time
+	db2, err := NewBukuDB(db.dbPath, WithFTS(true))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer db2.Close()
+	var n int
+	if err := db2.conn.QueryRow("SELECT COUNT(*) FROM robuku_fts WHERE robuku_fts MATCH 'programming'").Scan(&n); err != nil {
+		t.Fatal(err)
+	}
+	if n != 1 {
+		t.Errorf("expected 1 indexed row, got %d", n)
+	}
+}
+
+func Test_FTS_Sync(t *testing.T) {
+	db, _ := newFTSTestDB(t)
+
+	if err := db.Add(Bookmark{URL: "https://zig.org", Title: "Zig"}); err != nil {
+		t.Fatal(err)
+	}
+	if ids := searchIDs(t, db, "zig"); len(ids) != 1 || ids[0] != 3 {
+		t.Errorf("after insert: expected [3], got %v", ids)
+	}
+
+	if err := db.UpdateTitle(3, "Ziglang"); err != nil {
+		t.Fatal(err)
+	}
+	if ids := searchIDs(t, db, "ziglang"); len(ids) != 1 || ids[0] != 3 {
+		t.Errorf("after update: expected [3], got %v", ids)
+	}
+
+	if err := db.AddTags(1, []string{"gopher"}); err != nil {
+		t.Fatal(err)
+	}
+	if ids := searchIDs(t, db, "gopher"); len(ids) != 1 || ids[0] != 1 {
+		t.Errorf("after tag update: expected [1], got %v", ids)
+	}
+
+	// removing renumbers the following bookmarks
+	if err := db.Remove(1); err != nil {
+		t.Fatal(err)
+	}
+	if ids := searchIDs(t, db, "gopher"); len(ids) != 0 {
+		t.Errorf("after delete: expected [], got %v", ids)
+	}
+	if ids := searchIDs(t, db, "ziglang"); len(ids) != 1 || ids[0] != 2 {
+		t.Errorf("after delete: expected [2], got %v", ids)
+	}
+}
+
+func Test_FTS_PunctuationFallback(t *testing.T) {
+	db, _ := newFTSTestDB(t)
+
+	if ids := searchIDs(t, db, "-"); len(ids) != 1 || ids[0] != 2 {
+		t.Errorf("expected [2], got %v", ids)
+	}
+}
+
+func Test_DropFTS(t *testing.T) {
+	db, path := newFTSTestDB(t)
+	db.Close()
+
+	if err := DropFTS(path); err != nil {
+		t.Fatalf("expected no error on DropFTS(), got '%v'", err)
+	}
+
+	conn, err := sql.Open("sqlite3", path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer conn.Close()
+	if n := countObjects(t, conn, "table", "robuku_fts%"); n != 0 {
+		t.Errorf("expected no robuku_fts tables, got %d", n)
+	}
+	if n := countObjects(t, conn, "trigger", "robuku_fts%"); n != 0 {
+		t.Errorf("expected no robuku_fts objects, got %d", n)
+	}
+}
+
+func Test_FTS_Unavailable(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "bookmarks.db")
+	if err := InitSchema(path); err != nil {
+		t.Fatal(err)
+	}
+	db, err := NewBukuDB(path, WithFTS(true))
+	if err != nil {
+		t.Fatalf("expected no error on NewBukuDB() with FTS, got '%v'", err)
+	}
+	defer db.Close()
+
+	if ok, _ := hasFTS5(db.conn); ok {
+		t.Skip("SQLite driver built with FTS5")
+	}
+
+	if db.fts {
+		t.Error("expected full text search to be disabled")
+	}
+	if n := countObjects(t, db.conn, "table", "robuku_fts%"); n != 0 {
+		t.Errorf("expected no robuku_fts objects, got %d", n)
+	}
+	if err := db.Add(Bookmark{URL: "https://golang.org", Title: "Go"}); err != nil {
+		t.Fatal(err)
+	}
+	if ids := searchIDs(t, db, "go"); len(ids) != 1 {
+		t.Errorf("expected LIKE search to find 1 bookmark, got %v", ids)
+	}
+}
+
+func Test_buildFTSQuery(t *testing.T) {
+	tests := []struct {
+		terms    []string
+		expected string
+		ok       bool
+	}{
+		{[]string{"go", "lang"}, `"go"* "lang"*`, true},
+		{[]string{`say"hi`}, `"say""hi"*`, true},
+		{[]string{"go", "%"}, "", false},
+	}
+	for _, test := range tests {
+		query, ok := buildFTSQuery(test.terms)
+		if query != test.expected || ok != test.ok {
+			t.Errorf("terms %v: expected '%s' %v, got '%s' %v",
+				test.terms, test.expected, test.ok, query, ok)
+		}
+	}
+}
@@ -0,0 +1,193 @@
+package bukudb
+
+import (
+	"bufio"
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"regexp"
+	"strings"
+	"time"
+)
+
+// TitleFetcher returns the title of the page at url.
+type TitleFetcher func(ctx context.Context, url string) (string, error)
+
+var (
+	// schemeRegex matches a URL starting with a scheme, like "https://".
+	schemeRegex = regexp.MustCompile(`^[a-zA-Z][a-zA-Z0-9+.-]*://\S`)
+
+	// bareDomainRegex matches a URL starting with a domain, like
+	// "example.com/page".
+	bareDomainRegex = regexp.MustCompile(`^[a-zA-Z0-9-]+(\.[a-zA-Z0-9-]+)*\.[a-zA-Z]{2,}(:\d+)?([/?#]|$)`)
+)
+
+// parseTextLine returns the URL and trailing "# comment" of a line of a URL
+// list. Lines that are blank, comments, or not a URL are not ok. Bare
+// domains get an https:// scheme.
+func parseTextLine(line string) (url, comment string, ok bool) {
+	line = strings.TrimSpace(line)
+	// a "#" only starts a comment after whitespace, URLs may contain one
+	if i := strings.Index(line, " #"); i >= 0 {
+		line, comment = line[:i], strings.TrimSpace(line[i+2:])
+	} else if i := strings.Index(line, "\t#"); i >= 0 {
+		line, comment = line[:i], strings.TrimSpace(line[i+2:])
+	}
+	url = strings.TrimSpace(line)
+	if url == "" || strings.ContainsAny(url, " \t") {
+		return "", "", false
+	}
+
+	switch {
+	case schemeRegex.MatchString(url):
+		return url, comment, true
+	case bareDomainRegex.MatchString(url):
+		return "https://" + url, comment, true
+	default:
+		return "", "", false
+	}
+}
+
+// parseText returns the bookmarks of the URL list read from r, tagged with
+// tags.
+func parseText(r io.Reader, tags []string) ([]Bookmark, error) {
+	var bookmarks []Bookmark
+	scanner := bufio.NewScanner(r)
+	for scanner.Scan() {
+		url, comment, ok := parseTextLine(scanner.Text())
+		if ok {
+			bookmarks = append(bookmarks, Bookmark{URL: url, Comment: comment, Tags: tags})
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("failed to read URL list: %w", err)
+	}
+	return bookmarks, nil
+}
+
+// ImportText adds the URLs read from r, one per line with an optional
+// trailing "# comment", tagged with defaultTags. Blank lines and lines that
+// are not a URL are skipped, URLs already in the database are handled by
+// policy. Titles are fetched if enabled with WithTitleFetcher. All bookmarks
+// are imported in one transaction, it returns how many were added and how
+// many existing ones were updated.
+func (db *BukuDB) ImportText(r io.Reader, defaultTags []string, policy ConflictPolicy) (added, updated int, err error) {
+	bookmarks, err := parseText(r, defaultTags)
+	if err != nil {
+		return 0, 0, err
+	}
+	// titles are fetched before the transaction, which would otherwise
+	// lock the database while waiting for the network
+	if db.fetchTitle != nil {
+		if err := db.fetchTitles(bookmarks, policy == ConflictOverwrite); err != nil {
+			return 0, 0, err
+		}
+	}
+
+	db.mu.Lock()
+	defer db.mu.Unlock()
+
+	if _, err := db.syncLocked(); err != nil {
+		return 0, 0, err
+	}
+	if err := db.setupLocked(); err != nil {
+		return 0, 0, err
+	}
+	tx, err := db.conn.Begin()
+	if err != nil {
+		return 0, 0, fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	id := db.len
+	now := time.Now().Unix()
+	for _, b := range bookmarks {
+		var existingID uint16
+		var existingTags string
+		err := tx.QueryRow(`SELECT id, tags FROM bookmarks WHERE URL = ?`, b.URL).
+			Scan(&existingID, &existingTags)
+		if err != nil && !errors.Is(err, sql.ErrNoRows) {
+			return 0, 0, fmt.Errorf("failed to look up bookmark: %w", err)
+		}
+		if err == nil {
+			ok, err := resolveConflict(tx, policy, existingID, existingTags, b)
+			if err != nil {
+				return 0, 0, err
+			}
+			if ok {
+				updated++
+			}
+			continue
+		}
+		if id >= db.max {
+			return 0, 0, &LimitError{Count: id, Max: db.max}
+		}
+		id++
+
+		_, err = tx.Exec(`INSERT INTO bookmarks (id, URL, metadata, tags, desc, flags)
+			VALUES (?, ?, ?, ?, ?, 0)`, id, b.URL, b.Title, formatTags(b.Tags), b.Comment)
+		if err != nil {
+			return 0, 0, fmt.Errorf("failed to insert bookmark: %w", err)
+		}
+		_, err = tx.Exec(`INSERT OR REPLACE INTO robuku_meta (id, created_at) VALUES (?, ?)`, id, now)
+		if err != nil {
+			return 0, 0, fmt.Errorf("failed to insert bookmark meta: %w", err)
+		}
+	}
+
+	if err := tx.Commit(); err != nil {
+		return 0, 0, fmt.Errorf("failed to commit text import: %w", err)
+	}
+	added = id - db.len
+	db.len = id
+	return added, updated, nil
+}
+
+// resolveConflict applies policy to the bookmark with id and the tags
+// column existingTags, which has the URL of the imported bookmark b. It
+// reports whether the existing bookmark was updated.
+func resolveConflict(tx *loggedTx, policy ConflictPolicy, id uint16, existingTags string, b Bookmark) (bool, error) {
+	var err error
+	switch policy {
+	case ConflictOverwrite:
+		_, err = tx.Exec(`UPDATE bookmarks SET metadata = ?, desc = ?, tags = ? WHERE id = ?`,
+			b.Title, b.Comment, formatTags(b.Tags), id)
+	case ConflictMergeTags:
+		_, err = tx.Exec(`UPDATE bookmarks SET tags = ? WHERE id = ?`, mergeTags(existingTags, b.Tags), id)
+	default:
+		return false, nil
+	}
+	if err != nil {
+		return false, fmt.Errorf("failed to update bookmark: %w", err)
+	}
+	return true, nil
+}
+
+// fetchTitles sets the titles of the bookmarks not in the database yet, or
+// of all bookmarks if existing too, failed fetches are logged and leave the
+// title empty.
+func (db *BukuDB) fetchTitles(bookmarks []Bookmark, existing bool) error {
+	for i, b := range bookmarks {
+		db.mu.Lock()
+		var n int
+		err := db.conn.QueryRow(`SELECT COUNT(*) FROM bookmarks WHERE URL = ?`, b.URL).Scan(&n)
+		db.mu.Unlock()
+		if err != nil {
+			return fmt.Errorf("failed to look up bookmark: %w", err)
+		}
+		if n > 0 && !existing {
+			continue
+		}
+
+		title, err := db.fetchTitle(context.Background(), b.URL)
+		if err != nil {
+			log.Println("ERROR", fmt.Errorf("failed to fetch title of %s: %w", b.URL, err))
+			continue
+		}
+		bookmarks[i].Title = title
+	}
+	return nil
+}
@@ -0,0 +1,177 @@
+package bukudb
+
+import (
+	"context"
+	"errors"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func Test_parseTextLine(t *testing.T) {
+	tests := []struct {
+		line, url, comment string
+		ok                 bool
+	}{
+		{"https://a.com", "https://a.com", "", true},
+		{"  https://a.com/page?q=1  ", "https://a.com/page?q=1", "", true},
+		{"https://a.com/#section", "https://a.com/#section", "", true},
+		{"https://a.com # read later", "https://a.com", "read later", true},
+		{"https://a.com/#top\t# tab comment", "https://a.com/#top", "tab comment", true},
+		{"ftp://files.example.org/x", "ftp://files.example.org/x", "", true},
+		{"example.com", "https://example.com", "", true},
+		{"go.dev/doc # docs", "https://go.dev/doc", "docs", true},
+		{"localhost:8080/x", "", "", false},
+		{"", "", "", false},
+		{"   ", "", "", false},
+		{"# just a comment", "", "", false},
+		{"some notes about a.com", "", "", false},
+		{"not-a-url", "", "", false},
+		{"https://", "", "", false},
+	}
+	for _, tt := range tests {
+		url, comment, ok := parseTextLine(tt.line)
+		if url != tt.url || comment != tt.comment || ok != tt.ok {
+			t.Errorf("line %q: expected %q %q %v, got %q %q %v",
+				tt.line, tt.url, tt.comment, tt.ok, url, comment, ok)
+		}
+	}
+}
+
+func newImportTestDB(t *testing.T, opts ...Option) *BukuDB {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), "bookmarks.db")
+	if err := InitSchema(path); err != nil {
+		t.Fatal(err)
+	}
+	db, err := NewBukuDB(path, opts...)
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { db.Close() })
+	return db
+}
+
+func Test_ImportText(t *testing.T) {
+	fetched := make(map[string]bool)
+	fetch := func(ctx context.Context, url string) (string, error) {
+		fetched[url] = true
+		if url == "https://broken.com" {
+			return "", errors.New("connection refused")
+		}
+		return "title of " + url, nil
+	}
+	db := newImportTestDB(t, WithTitleFetcher(fetch))
+	if err := db.Add(Bookmark{URL: "https://old.com"}); err != nil {
+		t.Fatal(err)
+	}
+
+	input := `
+# reading list
+https://a.com # from the newsletter
+b.com
+https://old.com
+https://a.com
+https://broken.com
+not a url
+`
+	n, updated, err := db.ImportText(strings.NewReader(input), []string{"inbox"}, ConflictSkip)
+	if err != nil {
+		t.Fatalf("expected no error on ImportText(), got '%v'", err)
+	}
+	if n != 3 || updated != 0 || db.Len() != 4 {
+		t.Fatalf("expected 3 added, 0 updated and 4 bookmarks, got %d, %d and %d", n, updated, db.Len())
+	}
+	if fetched["https://old.com"] {
+		t.Errorf("expected no title fetch for a bookmarked URL")
+	}
+
+	expected := []Bookmark{
+		{ID: 2, URL: "https://a.com", Title: "title of https://a.com", Comment: "from the newsletter",
+			Tags: []string{"inbox"}},
+		{ID: 3, URL: "https://b.com", Title: "title of https://b.com", Tags: []string{"inbox"}},
+		{ID: 4, URL: "https://broken.com", Tags: []string{"inbox"}},
+	}
+	for _, e := range expected {
+		b, err := db.Get(e.ID)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if b.URL != e.URL || b.Title != e.Title || b.Comment != e.Comment ||
+			strings.Join(b.Tags, ",") != strings.Join(e.Tags, ",") || b.Created == nil {
+			t.Errorf("expected %+v, got %+v", e, b)
+		}
+	}
+}
+
+func Test_ImportText_Limit(t *testing.T) {
+	db := newImportTestDB(t, WithMaxBookmarks(1))
+
+	_, _, err := db.ImportText(strings.NewReader("https://a.com\nhttps://b.com\n"), nil, ConflictSkip)
+	var le *LimitError
+	if !errors.As(err, &le) {
+		t.Fatalf("expected limit error, got '%v'", err)
+	}
+	// nothing is added when the transaction fails
+	if db.Len() != 0 {
+		t.Errorf("expected no bookmarks, got %d", db.Len())
+	}
+}
+
+func Test_ImportText_ConflictPolicies(t *testing.T) {
+	existing := Bookmark{URL: "https://old.com", Title: "old title", Comment: "old comment",
+		Tags: []string{"keep", "shared"}}
+	input := "https://old.com # new comment\nhttps://new.com\n"
+
+	tests := []struct {
+		policy   ConflictPolicy
+		updated  int
+		expected Bookmark
+	}{
+		{ConflictSkip, 0, existing},
+		{ConflictOverwrite, 1, Bookmark{URL: "https://old.com", Comment: "new comment",
+			Tags: []string{"inbox", "shared"}}},
+		{ConflictMergeTags, 1, Bookmark{URL: "https://old.com", Title: "old title",
+			Comment: "old comment", Tags: []string{"inbox", "keep", "shared"}}},
+	}
+	for _, tt := range tests {
+		db := newImportTestDB(t)
+		if err := db.Add(existing); err != nil {
+			t.Fatal(err)
+		}
+
+		added, updated, err := db.ImportText(strings.NewReader(input), []string{"inbox", "shared"}, tt.policy)
+		if err != nil {
+			t.Fatalf("%s: expected no error on ImportText(), got '%v'", tt.policy, err)
+		}
+		if added != 1 || updated != tt.updated {
+			t.Errorf("%s: expected 1 added and %d updated, got %d and %d", tt.policy, tt.updated, added, updated)
+		}
+
+		b, err := db.Get(1)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if b.Title != tt.expected.Title || b.Comment != tt.expected.Comment ||
+			strings.Join(b.Tags, ",") != strings.Join(tt.expected.Tags, ",") {
+			t.Errorf("%s: expected %+v, got %+v", tt.policy, tt.expected, b)
+		}
+	}
+}
+
+func Test_ImportText_ConflictRollback(t *testing.T) {
+	db := newImportTestDB(t, WithMaxBookmarks(1))
+	if err := db.Add(Bookmark{URL: "https://old.com", Title: "old title"}); err != nil {
+		t.Fatal(err)
+	}
+
+	_, _, err := db.ImportText(strings.NewReader("https://old.com\nhttps://new.com\n"), nil, ConflictOverwrite)
+	var le *LimitError
+	if !errors.As(err, &le) {
+		t.Fatalf("expected limit error, got '%v'", err)
+	}
+	// the overwrite is rolled back with the failed transaction
+	if b, _ := db.Get(1); b.Title != "old title" {
+		t.Errorf("expected title 'old title', got '%s'", b.Title)
+	}
+}
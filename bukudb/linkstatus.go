@@ -0,0 +1,81 @@
+package bukudb
+
+import (
+	"database/sql"
+	"fmt"
+	"time"
+)
+
+// linkStatusTable holds the results of the dead link checker by bookmark ID,
+// it only exists once the checker has run.
+const linkStatusTable = "robuku_link_status"
+
+const linkStatusSchema = `CREATE TABLE IF NOT EXISTS ` + linkStatusTable + ` (
+    id INTEGER PRIMARY KEY,
+    dead INTEGER NOT NULL,
+    status_code INTEGER,
+    checked_at INTEGER NOT NULL
+);`
+
+// LinkStatus is the result of checking whether a bookmark URL still works.
+type LinkStatus struct {
+	// Dead is true if the URL could not be reached or answered with an error.
+	Dead bool
+
+	// StatusCode is the HTTP status the URL answered with, 0 if none.
+	StatusCode int
+
+	// Checked is when the URL was checked.
+	Checked time.Time
+}
+
+// GetLinkStatuses returns the known link statuses by bookmark ID, it is
+// empty if links have never been checked.
+func (db *BukuDB) GetLinkStatuses() (map[uint16]LinkStatus, error) {
+	db.mu.Lock()
+	defer db.mu.Unlock()
+
+	statuses := make(map[uint16]LinkStatus)
+	ok, err := hasTable(db.conn, linkStatusTable)
+	if err != nil || !ok {
+		return statuses, err
+	}
+
+	rows, err := db.conn.Query(`SELECT id, dead, status_code, checked_at FROM ` + linkStatusTable)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query link statuses: %w", err)
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var id uint16
+		var s LinkStatus
+		var code sql.NullInt64
+		var checked int64
+		if err := rows.Scan(&id, &s.Dead, &code, &checked); err != nil {
+			return nil, fmt.Errorf("failed to scan link status: %w", err)
+		}
+		s.StatusCode = int(code.Int64)
+		s.Checked = time.Unix(checked, 0)
+		statuses[id] = s
+	}
+	return statuses, rows.Err()
+}
+
+// SetLinkStatus stores the link status of the bookmark with the given ID,
+// creating the link status table if needed.
+func (db *BukuDB) SetLinkStatus(id uint16, s LinkStatus) error {
+	db.mu.Lock()
+	defer db.mu.Unlock()
+
+	if _, err := db.conn.Exec(linkStatusSchema); err != nil {
+		return fmt.Errorf("failed to create link status table: %w", err)
+	}
+	_, err := db.conn.Exec(`INSERT OR REPLACE INTO `+linkStatusTable+
+		` (id, dead, status_code, checked_at) VALUES (?, ?, ?, ?)`,
+		id, s.Dead, s.StatusCode, s.Checked.Unix())
+	if err != nil {
+		return fmt.Errorf("failed to store link status: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,66 @@
+package bukudb
+
+import (
+	"testing"
+	"time"
+)
+
+func Test_GetLinkStatuses(t *testing.T) {
+	createTestDb(t)
+	db, err := NewBukuDB(sqlTestDbPath)
+	defer cleanUpTestDB(t, db)
+
+	if err != nil {
+		t.Fatalf("expected no error on NewBukuDB(), got '%v'", err)
+	}
+
+	// links never checked
+	statuses, err := db.GetLinkStatuses()
+	if err != nil || len(statuses) != 0 {
+		t.Fatalf("expected no statuses, got %v and error '%v'", statuses, err)
+	}
+	if ok, _ := hasTable(db.conn, linkStatusTable); ok {
+		t.Errorf("expected GetLinkStatuses() to not create the table")
+	}
+
+	checked := time.Unix(1700000000, 0)
+	if err := db.SetLinkStatus(2, LinkStatus{Dead: true, StatusCode: 404, Checked: checked}); err != nil {
+		t.Fatal(err)
+	}
+	if err := db.SetLinkStatus(3, LinkStatus{Checked: checked}); err != nil {
+		t.Fatal(err)
+	}
+
+	statuses, err = db.GetLinkStatuses()
+	if err != nil {
+		t.Fatalf("expected no error on GetLinkStatuses(), got '%v'", err)
+	}
+	expected := map[uint16]LinkStatus{
+		2: {Dead: true, StatusCode: 404, Checked: checked},
+		3: {Checked: checked},
+	}
+	if len(statuses) != len(expected) {
+		t.Fatalf("expected %v, got %v", expected, statuses)
+	}
+	for id, s := range expected {
+		if got := statuses[id]; got.Dead != s.Dead || got.StatusCode != s.StatusCode || !got.Checked.Equal(s.Checked) {
+			t.Errorf("expected status %v for %d, got %v", s, id, got)
+		}
+	}
+
+	// statuses follow their bookmarks when IDs shift
+	if err := db.Remove(1); err != nil {
+		t.Fatal(err)
+	}
+	statuses, _ = db.GetLinkStatuses()
+	if !statuses[1].Dead || statuses[2].Dead || len(statuses) != 2 {
+		t.Errorf("expected dead #1 and alive #2 after remove, got %v", statuses)
+	}
+	if err := db.Remove(1); err != nil {
+		t.Fatal(err)
+	}
+	statuses, _ = db.GetLinkStatuses()
+	if len(statuses) != 1 || statuses[1].Dead {
+		t.Errorf("expected alive #1 after remove, got %v", statuses)
+	}
+}
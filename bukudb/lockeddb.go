@@ -0,0 +1,131 @@
+package bukudb
+
+import "time"
+
+// DefaultLockWait is how long a write waits for another robuku instance to
+// finish writing.
+const DefaultLockWait = time.Second
+
+// LockedDB wraps a DBInterface, writes hold an advisory lock on a file so
+// overlapping robuku instances don't write, and renumber IDs, at the same
+// time. Reads are passed through and never wait for the lock.
+type LockedDB struct {
+	DBInterface
+	path string
+	wait time.Duration
+}
+
+// NewLockedDB returns a LockedDB writing to inner while holding the lock on
+// the file at path, waiting up to wait for it before failing with
+// ErrLocked.
+func NewLockedDB(inner DBInterface, path string, wait time.Duration) *LockedDB {
+	return &LockedDB{DBInterface: inner, path: path, wait: wait}
+}
+
+// Add adds the bookmark holding the lock.
+func (db *LockedDB) Add(bookmark Bookmark) error {
+	return db.locked(db.wait, func() error { return db.DBInterface.Add(bookmark) })
+}
+
+// UpdateTitle sets the title holding the lock.
+func (db *LockedDB) UpdateTitle(id uint16, title string) error {
+	return db.locked(db.wait, func() error { return db.DBInterface.UpdateTitle(id, title) })
+}
+
+// UpdateURL sets the URL holding the lock.
+func (db *LockedDB) UpdateURL(id uint16, url string) error {
+	return db.locked(db.wait, func() error { return db.DBInterface.UpdateURL(id, url) })
+}
+
+// UpdateComment sets the comment holding the lock.
+func (db *LockedDB) UpdateComment(id uint16, comment string) error {
+	return db.locked(db.wait, func() error { return db.DBInterface.UpdateComment(id, comment) })
+}
+
+// AppendComment appends the note holding the lock.
+func (db *LockedDB) AppendComment(id uint16, note string) error {
+	return db.locked(db.wait, func() error { return db.DBInterface.AppendComment(id, note) })
+}
+
+// Update sets the fields holding the lock.
+func (db *LockedDB) Update(id uint16, b Bookmark, fields FieldMask) error {
+	return db.locked(db.wait, func() error { return db.DBInterface.Update(id, b, fields) })
+}
+
+// RecordVisit counts the visit if the lock is free, opening a bookmark never
+// waits for it.
+func (db *LockedDB) RecordVisit(id uint16, at time.Time) error {
+	return db.locked(0, func() error { return db.DBInterface.RecordVisit(id, at) })
+}
+
+// ResetVisits resets the open counts holding the lock.
+func (db *LockedDB) ResetVisits(id uint16) error {
+	return db.locked(db.wait, func() error { return db.DBInterface.ResetVisits(id) })
+}
+
+// AddAlias adds the alias holding the lock.
+func (db *LockedDB) AddAlias(id uint16, url string) error {
+	return db.locked(db.wait, func() error { return db.DBInterface.AddAlias(id, url) })
+}
+
+// RemoveAlias removes the alias holding the lock.
+func (db *LockedDB) RemoveAlias(id uint16, url string) error {
+	return db.locked(db.wait, func() error { return db.DBInterface.RemoveAlias(id, url) })
+}
+
+// AddTags adds the tags holding the lock.
+func (db *LockedDB) AddTags(id uint16, tags []string) error {
+	return db.locked(db.wait, func() error { return db.DBInterface.AddTags(id, tags) })
+}
+
+// RemoveTags removes the tags holding the lock.
+func (db *LockedDB) RemoveTags(id uint16, tags []string) error {
+	return db.locked(db.wait, func() error { return db.DBInterface.RemoveTags(id, tags) })
+}
+
+// ClearTags clears the tags holding the lock.
+func (db *LockedDB) ClearTags(id uint16) error {
+	return db.locked(db.wait, func() error { return db.DBInterface.ClearTags(id) })
+}
+
+// Remove deletes the bookmark holding the lock.
+func (db *LockedDB) Remove(id uint16) error {
+	return db.locked(db.wait, func() error { return db.DBInterface.Remove(id) })
+}
+
+// BulkRemove deletes the bookmarks holding the lock.
+func (db *LockedDB) BulkRemove(ids []uint16) error {
+	return db.locked(db.wait, func() error { return db.DBInterface.BulkRemove(ids) })
+}
+
+// Restore restores the bookmark from the trash holding the lock.
+func (db *LockedDB) Restore(trashID int64) (uint16, error) {
+	var id uint16
+	err := db.locked(db.wait, func() error {
+		var err error
+		id, err = db.DBInterface.Restore(trashID)
+		return err
+	})
+	return id, err
+}
+
+// EmptyTrash empties the trash holding the lock.
+func (db *LockedDB) EmptyTrash() error {
+	return db.locked(db.wait, db.DBInterface.EmptyTrash)
+}
+
+// Locked runs write holding the lock, for writes of the inner database
+// DBInterface has no method for.
+func (db *LockedDB) Locked(write func() error) error {
+	return db.locked(db.wait, write)
+}
+
+// locked runs write holding the lock, waiting up to wait for it.
+func (db *LockedDB) locked(wait time.Duration, write func() error) error {
+	unlock, err := lockFile(db.path, wait)
+	if err != nil {
+		return err
+	}
+	defer unlock()
+	return write()
+}
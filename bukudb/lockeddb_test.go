@@ -0,0 +1,62 @@
+package bukudb
+
+import (
+	"errors"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func Test_LockedDB(t *testing.T) {
+	createTestDb(t)
+	bdb, err := NewBukuDB(sqlTestDbPath)
+	defer cleanUpTestDB(t, bdb)
+
+	if err != nil {
+		t.Fatalf("expected no error on NewBukuDB(), got '%v'", err)
+	}
+
+	path := filepath.Join(t.TempDir(), "robuku.lock")
+	inner := &writeCountingDB{BukuDB: bdb}
+	db := NewLockedDB(inner, path, 50*time.Millisecond)
+
+	if err := db.UpdateTitle(1, "new"); err != nil || inner.writes != 1 {
+		t.Errorf("expected the write to pass, got %d writes and error '%v'", inner.writes, err)
+	}
+
+	// another instance is writing
+	unlock, err := lockFile(path, 0)
+	if err != nil {
+		t.Fatalf("expected no error on lockFile(), got '%v'", err)
+	}
+	if err := db.Remove(1); !errors.Is(err, ErrLocked) {
+		t.Errorf("expected ErrLocked on Remove(), got '%v'", err)
+	}
+	if _, err := db.Restore(1); !errors.Is(err, ErrLocked) {
+		t.Errorf("expected ErrLocked on Restore(), got '%v'", err)
+	}
+	if err := db.AddAlias(1, "https://a.org"); !errors.Is(err, ErrLocked) {
+		t.Errorf("expected ErrLocked on AddAlias(), got '%v'", err)
+	}
+	start := time.Now()
+	if err := db.RecordVisit(1, start); !errors.Is(err, ErrLocked) || time.Since(start) > 40*time.Millisecond {
+		t.Errorf("expected RecordVisit() to fail without waiting, got '%v' after %s", err, time.Since(start))
+	}
+	if inner.writes != 1 {
+		t.Errorf("expected no writes while locked, got %d", inner.writes)
+	}
+
+	// reads don't wait
+	start = time.Now()
+	if b, err := db.Get(1); err != nil || b.ID != 1 || time.Since(start) > 40*time.Millisecond {
+		t.Errorf("expected Get() to pass without waiting, got '%v' after %s", err, time.Since(start))
+	}
+	if db.Len() != 4 {
+		t.Errorf("expected length 4, got %d", db.Len())
+	}
+
+	unlock()
+	if err := db.Remove(1); err != nil || inner.writes != 2 {
+		t.Errorf("expected the write to pass once unlocked, got %d writes and error '%v'", inner.writes, err)
+	}
+}
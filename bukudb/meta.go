@@ -0,0 +1,161 @@
+package bukudb
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"strings"
+	"time"
+)
+
+// metaSchema creates robuku_meta, which holds data buku has no column for.
+// buku ignores tables it does not know about, but bookmarks it adds have no
+// row until robuku opens the database again, and since buku renumbers
+// bookmarks differently on delete, created_at may get mixed up for
+// bookmarks deleted with buku.
+const metaSchema = `CREATE TABLE IF NOT EXISTS robuku_meta (
+    id INTEGER PRIMARY KEY,
+    created_at INTEGER
+);`
+
+// metaSync backfills rows for bookmarks without one, with an unknown
+// creation time, and removes rows of bookmarks that no longer exist.
+const metaSync = `
+INSERT OR IGNORE INTO robuku_meta (id, created_at) SELECT id, NULL FROM bookmarks;
+DELETE FROM robuku_meta WHERE id NOT IN (SELECT id FROM bookmarks);`
+
+// bookmarkColumns are the columns scanned by scanBookmark, from bookmarks
+// joined with robuku_meta.
+const bookmarkColumns = `bookmarks.id, bookmarks.URL, bookmarks.metadata,
+	bookmarks.tags, bookmarks.desc, robuku_meta.created_at`
+
+// bookmarkSource is the table expression bookmarkColumns are selected from.
+const bookmarkSource = `bookmarks LEFT JOIN robuku_meta ON robuku_meta.id = bookmarks.id`
+
+// noMetaSource is bookmarkSource for a database without robuku_meta, every
+// creation time is unknown then.
+const noMetaSource = `bookmarks LEFT JOIN (SELECT NULL AS id, NULL AS created_at WHERE 0)
+	AS robuku_meta ON robuku_meta.id = bookmarks.id`
+
+// setupMeta creates and syncs robuku_meta.
+func setupMeta(conn *loggedDB) error {
+	if _, err := conn.Exec(metaSchema); err != nil {
+		return fmt.Errorf("failed to create meta table: %w", err)
+	}
+	if _, err := conn.Exec(metaSync); err != nil {
+		return fmt.Errorf("failed to sync meta table: %w", err)
+	}
+	return nil
+}
+
+// setupLocked creates and syncs robuku_meta and robuku_trash before the
+// first write of db, so only opening the database, like for a dry run or
+// in read-only mode, leaves it as it is.
+func (db *BukuDB) setupLocked() error {
+	if db.setup {
+		return nil
+	}
+	if err := setupMeta(db.conn); err != nil {
+		return err
+	}
+	if err := setupTrash(db.conn); err != nil {
+		return err
+	}
+	db.setup = true
+	db.hasMeta = true
+	return nil
+}
+
+// source returns the table expression bookmarkColumns are selected from,
+// which depends on whether robuku_meta exists yet.
+func (db *BukuDB) source() string {
+	if db.hasMeta {
+		return bookmarkSource
+	}
+	return noMetaSource
+}
+
+// GetAllByCreated returns all bookmarks, the most recently added first.
+// Bookmarks with an unknown creation time come last, ordered by ID.
+func (db *BukuDB) GetAllByCreated() ([]Bookmark, error) {
+	db.mu.Lock()
+	defer db.mu.Unlock()
+
+	rows, err := db.conn.Query(`SELECT ` + bookmarkColumns + ` FROM ` + db.source() + `
+		ORDER BY robuku_meta.created_at DESC NULLS LAST, bookmarks.id`)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query bookmarks: %w", err)
+	}
+	return scanBookmarks(rows)
+}
+
+// GetRecent returns the n most recently added bookmarks not carrying any
+// tag of exclude, newest first. Bookmarks with an unknown creation time
+// follow, highest ID first.
+func (db *BukuDB) GetRecent(n int, exclude []string) ([]Bookmark, error) {
+	db.mu.Lock()
+	defer db.mu.Unlock()
+
+	where := []string{"1"}
+	var args []any
+	for _, tag := range exclude {
+		match, patterns := tagCondition(tag)
+		where = append(where, "NOT "+match)
+		args = append(args, patterns...)
+	}
+	args = append(args, n)
+
+	bookmarks, err := queryBookmarks(context.Background(), db.conn, `SELECT `+bookmarkColumns+
+		` FROM `+db.source()+` WHERE `+strings.Join(where, " AND ")+`
+		ORDER BY robuku_meta.created_at DESC NULLS LAST, bookmarks.id DESC LIMIT ?`, args...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query recent bookmarks: %w", err)
+	}
+	return bookmarks, nil
+}
+
+// scanner is implemented by *sql.Row and *sql.Rows.
+type scanner interface {
+	Scan(dest ...any) error
+}
+
+// scanBookmark scans a row of bookmarkColumns.
+func scanBookmark(s scanner) (Bookmark, error) {
+	var b Bookmark
+	var tagsString string
+	var created sql.NullInt64
+	if err := s.Scan(&b.ID, &b.URL, &b.Title, &tagsString, &b.Comment, &created); err != nil {
+		return Bookmark{}, fmt.Errorf("failed to scan bookmark: %w", err)
+	}
+
+	b.Tags = parseTagsColumn(tagsString)
+	if created.Valid {
+		t := time.Unix(created.Int64, 0)
+		b.Created = &t
+	}
+	return b, nil
+}
+
+// scanBookmarks scans and closes rows of bookmarkColumns.
+func scanBookmarks(rows *sql.Rows) ([]Bookmark, error) {
+	defer rows.Close()
+
+	var bookmarks []Bookmark
+	for rows.Next() {
+		b, err := scanBookmark(rows)
+		if err != nil {
+			return nil, err
+		}
+		bookmarks = append(bookmarks, b)
+	}
+	return bookmarks, rows.Err()
+}
+
+// queryBookmarks runs query with args and scans the resulting bookmarks.
+func queryBookmarks(ctx context.Context, conn *loggedDB, query string, args ...any) ([]Bookmark, error) {
+	rows, err := conn.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, err
+	}
+	return scanBookmarks(rows)
+}
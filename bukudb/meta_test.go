@@ -0,0 +1,250 @@
+package bukudb
+
+import (
+	"slices"
+	"testing"
+	"time"
+)
+
+func metaIDs(t *testing.T, db *BukuDB) map[int]bool {
+	rows, err := db.conn.Query("SELECT id FROM robuku_meta")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer rows.Close()
+
+	ids := make(map[int]bool)
+	for rows.Next() {
+		var id int
+		if err := rows.Scan(&id); err != nil {
+			t.Fatal(err)
+		}
+		ids[id] = true
+	}
+	return ids
+}
+
+func Test_Meta_Backfill(t *testing.T) {
+	createTestDb(t)
+	db, err := NewBukuDB(sqlTestDbPath)
+	defer cleanUpTestDB(t, db)
+
+	if err != nil {
+		t.Fatalf("expected no error on NewBukuDB(), got '%v'", err)
+	}
+
+	// opening leaves the database as it is
+	for _, table := range []string{"robuku_meta", "robuku_trash"} {
+		if ok, err := hasTable(db.conn, table); err != nil || ok {
+			t.Errorf("expected no %s table before the first write, got %v, '%v'", table, ok, err)
+		}
+	}
+	checkUnknownCreated := func() {
+		t.Helper()
+		bookmarks, err := db.GetAll()
+		if err != nil {
+			t.Fatal(err)
+		}
+		for _, b := range bookmarks[:4] {
+			if b.Created != nil {
+				t.Errorf("expected bookmark %d to have unknown creation time, got '%v'", b.ID, b.Created)
+			}
+		}
+	}
+	checkUnknownCreated()
+	if trashed, err := db.TrashList(); err != nil || len(trashed) != 0 {
+		t.Errorf("expected an empty trash, got %v, '%v'", trashed, err)
+	}
+
+	if err := db.Add(Bookmark{URL: "https://www.e.com"}); err != nil {
+		t.Fatal(err)
+	}
+	if ids := metaIDs(t, db); len(ids) != 5 {
+		t.Errorf("expected 5 meta rows, got %d", len(ids))
+	}
+	checkUnknownCreated()
+}
+
+func Test_Meta_AddRemove(t *testing.T) {
+	createTestDb(t)
+	db, err := NewBukuDB(sqlTestDbPath)
+	defer cleanUpTestDB(t, db)
+
+	if err != nil {
+		t.Fatalf("expected no error on NewBukuDB(), got '%v'", err)
+	}
+
+	before := time.Now().Add(-time.Second)
+	if err := db.Add(Bookmark{URL: "https://www.e.com"}); err != nil {
+		t.Fatal(err)
+	}
+	if err := db.Add(Bookmark{URL: "https://www.f.com"}); err != nil {
+		t.Fatal(err)
+	}
+
+	b, err := db.Get(5)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if b.Created == nil || b.Created.Before(before) || b.Created.After(time.Now()) {
+		t.Errorf("expected bookmark created time to be now, got '%v'", b.Created)
+	}
+
+	// removing renumbers the meta rows with the bookmarks
+	if err := db.Remove(2); err != nil {
+		t.Fatal(err)
+	}
+	if ids := metaIDs(t, db); len(ids) != 5 || ids[6] {
+		t.Errorf("expected meta ids 1-5, got %v", ids)
+	}
+	for id, url := range map[uint16]string{4: "https://www.e.com", 5: "https://www.f.com"} {
+		b, err := db.Get(id)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if b.URL != url || b.Created == nil {
+			t.Errorf("expected bookmark %d '%s' with created time, got '%s' '%v'",
+				id, url, b.URL, b.Created)
+		}
+	}
+	if b, _ := db.Get(3); b.Created != nil {
+		t.Errorf("expected bookmark 3 to have unknown creation time, got '%v'", b.Created)
+	}
+
+	// removing the last bookmark removes its meta row
+	if err := db.Remove(5); err != nil {
+		t.Fatal(err)
+	}
+	if ids := metaIDs(t, db); len(ids) != 4 || ids[5] {
+		t.Errorf("expected meta ids 1-4, got %v", ids)
+	}
+}
+
+func Test_Meta_SyncOnWrite(t *testing.T) {
+	createTestDb(t)
+	db, err := NewBukuDB(sqlTestDbPath)
+	defer cleanUpTestDB(t, db)
+
+	if err != nil {
+		t.Fatalf("expected no error on NewBukuDB(), got '%v'", err)
+	}
+	if err := db.EmptyTrash(); err != nil {
+		t.Fatal(err)
+	}
+
+	// a bookmark added and one deleted by buku
+	if _, err := db.conn.Exec(`INSERT INTO bookmarks (id, URL) VALUES (5, 'https://www.e.com')`); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := db.conn.Exec(`DELETE FROM bookmarks WHERE id = 1`); err != nil {
+		t.Fatal(err)
+	}
+	db.Close()
+
+	db, err = NewBukuDB(sqlTestDbPath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if ids := metaIDs(t, db); len(ids) != 4 || !ids[1] || ids[5] {
+		t.Errorf("expected meta ids 1-4 before the first write, got %v", ids)
+	}
+	if err := db.EmptyTrash(); err != nil {
+		t.Fatal(err)
+	}
+	if ids := metaIDs(t, db); len(ids) != 4 || ids[1] || !ids[5] {
+		t.Errorf("expected meta ids 2-5, got %v", ids)
+	}
+}
+
+func Test_GetAllByCreated(t *testing.T) {
+	createTestDb(t)
+	db, err := NewBukuDB(sqlTestDbPath)
+	defer cleanUpTestDB(t, db)
+
+	if err != nil {
+		t.Fatalf("expected no error on NewBukuDB(), got '%v'", err)
+	}
+
+	if err := db.Add(Bookmark{URL: "https://www.e.com"}); err != nil {
+		t.Fatal(err)
+	}
+	if err := db.Add(Bookmark{URL: "https://www.f.com"}); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := db.conn.Exec(`UPDATE robuku_meta SET created_at = 100 WHERE id = 5`); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := db.conn.Exec(`UPDATE robuku_meta SET created_at = 50 WHERE id = 2`); err != nil {
+		t.Fatal(err)
+	}
+
+	bookmarks, err := db.GetAllByCreated()
+	if err != nil {
+		t.Fatalf("expected no error on GetAllByCreated(), got '%v'", err)
+	}
+
+	expected := []uint16{6, 5, 2, 1, 3, 4}
+	if len(bookmarks) != len(expected) {
+		t.Fatalf("expected %d bookmarks, got %d", len(expected), len(bookmarks))
+	}
+	for i, b := range bookmarks {
+		if b.ID != expected[i] {
+			t.Errorf("expected bookmark %d at index %d, got %d", expected[i], i, b.ID)
+		}
+	}
+	if bookmarks[1].Created == nil || bookmarks[1].Created.Unix() != 100 {
+		t.Errorf("expected created time 100, got '%v'", bookmarks[1].Created)
+	}
+}
+
+func Test_GetRecent(t *testing.T) {
+	createTestDb(t)
+	db, err := NewBukuDB(sqlTestDbPath)
+	defer cleanUpTestDB(t, db)
+
+	if err != nil {
+		t.Fatalf("expected no error on NewBukuDB(), got '%v'", err)
+	}
+
+	if err := db.Add(Bookmark{URL: "https://www.e.com", Tags: []string{"nsfw"}}); err != nil {
+		t.Fatal(err)
+	}
+	if err := db.Add(Bookmark{URL: "https://www.f.com"}); err != nil {
+		t.Fatal(err)
+	}
+	for id, created := range map[int]int{5: 300, 6: 200, 2: 100} {
+		if _, err := db.conn.Exec(`UPDATE robuku_meta SET created_at = ? WHERE id = ?`, created, id); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if _, err := db.conn.Exec(`UPDATE robuku_meta SET created_at = NULL WHERE id != 5 AND id != 6 AND id != 2`); err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		n        int
+		exclude  []string
+		expected []uint16
+	}{
+		{10, nil, []uint16{5, 6, 2, 4, 3, 1}},
+		{6, nil, []uint16{5, 6, 2, 4, 3, 1}},
+		{5, nil, []uint16{5, 6, 2, 4, 3}},
+		{3, nil, []uint16{5, 6, 2}},
+		{1, nil, []uint16{5}},
+		{0, nil, nil},
+		{3, []string{"nsfw"}, []uint16{6, 2, 4}},
+	}
+	for _, tt := range tests {
+		bookmarks, err := db.GetRecent(tt.n, tt.exclude)
+		if err != nil {
+			t.Fatalf("expected no error on GetRecent(), got '%v'", err)
+		}
+		var ids []uint16
+		for _, b := range bookmarks {
+			ids = append(ids, b.ID)
+		}
+		if !slices.Equal(ids, tt.expected) {
+			t.Errorf("expected ids %v for %d excluding %q, got %v", tt.expected, tt.n, tt.exclude, ids)
+		}
+	}
+}
@@ -0,0 +1,189 @@
+package bukudb
+
+import (
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"io"
+	"time"
+)
+
+// metaExportVersion is the version of the ExportMeta format.
+const metaExportVersion = 1
+
+// metaExport is the data of robuku's side tables, bookmarks are keyed by URL
+// since IDs change when bookmarks are deleted or imported again.
+type metaExport struct {
+	Version   int                       `json:"version"`
+	Bookmarks map[string]bookmarkExport `json:"bookmarks"`
+	Trash     []trashExport             `json:"trash"`
+}
+
+type bookmarkExport struct {
+	CreatedAt  *int64            `json:"created_at,omitempty"`
+	LinkStatus *linkStatusExport `json:"link_status,omitempty"`
+}
+
+type linkStatusExport struct {
+	Dead       bool  `json:"dead"`
+	StatusCode int   `json:"status_code,omitempty"`
+	CheckedAt  int64 `json:"checked_at"`
+}
+
+type trashExport struct {
+	URL       string   `json:"url"`
+	Title     string   `json:"title,omitempty"`
+	Tags      []string `json:"tags,omitempty"`
+	Comment   string   `json:"comment,omitempty"`
+	CreatedAt *int64   `json:"created_at,omitempty"`
+	DeletedAt int64    `json:"deleted_at"`
+}
+
+// ExportMeta writes the data robuku keeps besides buku's bookmarks table,
+// creation times, link statuses, and the trash, as JSON to w.
+func (db *BukuDB) ExportMeta(w io.Writer) error {
+	bookmarks, err := db.GetAll()
+	if err != nil {
+		return err
+	}
+	statuses, err := db.GetLinkStatuses()
+	if err != nil {
+		return err
+	}
+	trashed, err := db.TrashList()
+	if err != nil {
+		return err
+	}
+
+	export := metaExport{
+		Version:   metaExportVersion,
+		Bookmarks: make(map[string]bookmarkExport),
+		Trash:     make([]trashExport, 0, len(trashed)),
+	}
+	for _, b := range bookmarks {
+		e := bookmarkExport{CreatedAt: unixOrNil(b.Created)}
+		if s, ok := statuses[b.ID]; ok {
+			e.LinkStatus = &linkStatusExport{
+				Dead: s.Dead, StatusCode: s.StatusCode, CheckedAt: s.Checked.Unix()}
+		}
+		if e != (bookmarkExport{}) {
+			export.Bookmarks[b.URL] = e
+		}
+	}
+	for _, t := range trashed {
+		export.Trash = append(export.Trash, trashExport{URL: t.URL, Title: t.Title,
+			Tags: t.Tags, Comment: t.Comment, CreatedAt: unixOrNil(t.Created),
+			DeletedAt: t.Deleted.Unix()})
+	}
+
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+	if err := enc.Encode(export); err != nil {
+		return fmt.Errorf("failed to write meta export: %w", err)
+	}
+	return nil
+}
+
+// ImportMeta reads JSON written by ExportMeta from r and restores it for the
+// bookmarks with the same URL, others are skipped. Trashed bookmarks already
+// in the trash are not added again.
+func (db *BukuDB) ImportMeta(r io.Reader) error {
+	var export metaExport
+	if err := json.NewDecoder(r).Decode(&export); err != nil {
+		return fmt.Errorf("failed to read meta export: %w", err)
+	}
+	if export.Version != metaExportVersion {
+		return fmt.Errorf("unsupported meta export version %d", export.Version)
+	}
+
+	db.mu.Lock()
+	defer db.mu.Unlock()
+
+	if err := db.setupLocked(); err != nil {
+		return err
+	}
+	tx, err := db.conn.Begin()
+	if err != nil {
+		return fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	if err := importBookmarkMeta(tx, export.Bookmarks); err != nil {
+		return err
+	}
+	if err := importTrash(tx, export.Trash); err != nil {
+		return err
+	}
+
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("failed to commit meta import: %w", err)
+	}
+	return nil
+}
+
+// importBookmarkMeta restores creation times and link statuses by URL.
+func importBookmarkMeta(tx *loggedTx, bookmarks map[string]bookmarkExport) error {
+	for url, e := range bookmarks {
+		var id int
+		err := tx.QueryRow(`SELECT id FROM bookmarks WHERE URL = ?`, url).Scan(&id)
+		if err == sql.ErrNoRows {
+			continue
+		}
+		if err != nil {
+			return fmt.Errorf("failed to look up bookmark: %w", err)
+		}
+
+		if e.CreatedAt != nil {
+			_, err := tx.Exec(`INSERT OR REPLACE INTO robuku_meta (id, created_at) VALUES (?, ?)`,
+				id, *e.CreatedAt)
+			if err != nil {
+				return fmt.Errorf("failed to import creation time: %w", err)
+			}
+		}
+		if s := e.LinkStatus; s != nil {
+			if _, err := tx.Exec(linkStatusSchema); err != nil {
+				return fmt.Errorf("failed to create link status table: %w", err)
+			}
+			_, err := tx.Exec(`INSERT OR REPLACE INTO `+linkStatusTable+
+				` (id, dead, status_code, checked_at) VALUES (?, ?, ?, ?)`,
+				id, s.Dead, s.StatusCode, s.CheckedAt)
+			if err != nil {
+				return fmt.Errorf("failed to import link status: %w", err)
+			}
+		}
+	}
+	return nil
+}
+
+// importTrash adds the trashed bookmarks not already in the trash.
+func importTrash(tx *loggedTx, trash []trashExport) error {
+	for _, t := range trash {
+		var n int
+		err := tx.QueryRow(`SELECT COUNT(*) FROM robuku_trash WHERE URL = ? AND deleted_at = ?`,
+			t.URL, t.DeletedAt).Scan(&n)
+		if err != nil {
+			return fmt.Errorf("failed to look up trashed bookmark: %w", err)
+		}
+		if n > 0 {
+			continue
+		}
+
+		_, err = tx.Exec(`INSERT INTO robuku_trash
+			(URL, metadata, tags, desc, flags, created_at, deleted_at)
+			VALUES (?, ?, ?, ?, 0, ?, ?)`,
+			t.URL, t.Title, formatTags(t.Tags), t.Comment, t.CreatedAt, t.DeletedAt)
+		if err != nil {
+			return fmt.Errorf("failed to import trashed bookmark: %w", err)
+		}
+	}
+	return nil
+}
+
+// unixOrNil returns the Unix time of t, or nil if t is nil.
+func unixOrNil(t *time.Time) *int64 {
+	if t == nil {
+		return nil
+	}
+	u := t.Unix()
+	return &u
+}
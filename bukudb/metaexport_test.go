@@ -0,0 +1,106 @@
+package bukudb
+
+import (
+	"bytes"
+	"database/sql"
+	"strings"
+	"testing"
+	"time"
+)
+
+func Test_ExportMeta_ImportMeta(t *testing.T) {
+	createTestDb(t)
+	db, err := NewBukuDB(sqlTestDbPath)
+	if err != nil {
+		t.Fatalf("expected no error on NewBukuDB(), got '%v'", err)
+	}
+
+	// the first write creates robuku_meta
+	if err := db.Remove(4); err != nil {
+		t.Fatal(err)
+	}
+	created := time.Unix(1600000000, 0)
+	if _, err := db.conn.Exec(`UPDATE robuku_meta SET created_at = ? WHERE id IN (1, 3)`,
+		created.Unix()); err != nil {
+		t.Fatal(err)
+	}
+	checked := time.Unix(1700000000, 0)
+	if err := db.SetLinkStatus(2, LinkStatus{Dead: true, StatusCode: 404, Checked: checked}); err != nil {
+		t.Fatal(err)
+	}
+
+	var buf bytes.Buffer
+	if err := db.ExportMeta(&buf); err != nil {
+		t.Fatalf("expected no error on ExportMeta(), got '%v'", err)
+	}
+	export := buf.String()
+	cleanUpTestDB(t, db)
+
+	// the main table imported again in another order, without robuku's tables
+	createTestDb(t)
+	conn, err := sql.Open("sqlite3", sqlTestDbPath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	_, err = conn.Exec(`DELETE FROM bookmarks;
+		INSERT INTO bookmarks (id, URL, metadata) VALUES
+			(1, 'https://www.c.com', 'c'), (2, 'https://www.b.com', 'b'), (3, 'https://www.a.com', 'a');`)
+	conn.Close()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	db, err = NewBukuDB(sqlTestDbPath)
+	defer cleanUpTestDB(t, db)
+	if err != nil {
+		t.Fatalf("expected no error on NewBukuDB(), got '%v'", err)
+	}
+
+	// importing twice does not duplicate the trash
+	for range 2 {
+		if err := db.ImportMeta(strings.NewReader(export)); err != nil {
+			t.Fatalf("expected no error on ImportMeta(), got '%v'", err)
+		}
+	}
+
+	for id, expected := range map[uint16]*time.Time{1: &created, 2: nil, 3: &created} {
+		b, err := db.Get(id)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if (expected == nil) != (b.Created == nil) || (expected != nil && !b.Created.Equal(*expected)) {
+			t.Errorf("expected creation time %v for %s, got %v", expected, b.URL, b.Created)
+		}
+	}
+
+	statuses, err := db.GetLinkStatuses()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if s := statuses[2]; len(statuses) != 1 || !s.Dead || s.StatusCode != 404 || !s.Checked.Equal(checked) {
+		t.Errorf("expected only b.com to be dead, got %v", statuses)
+	}
+
+	trashed, err := db.TrashList()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(trashed) != 1 || trashed[0].URL != "https://www.d.com" {
+		t.Errorf("expected d.com in the trash, got %v", trashed)
+	}
+}
+
+func Test_ImportMeta_Invalid(t *testing.T) {
+	createTestDb(t)
+	db, err := NewBukuDB(sqlTestDbPath)
+	defer cleanUpTestDB(t, db)
+	if err != nil {
+		t.Fatalf("expected no error on NewBukuDB(), got '%v'", err)
+	}
+
+	for _, input := range []string{"", "not json", `{"version": 2}`} {
+		if err := db.ImportMeta(strings.NewReader(input)); err == nil {
+			t.Errorf("expected error for '%s', got nil", input)
+		}
+	}
+}
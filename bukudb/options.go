@@ -0,0 +1,131 @@
+package bukudb
+
+import (
+	"fmt"
+	"math"
+	"net/url"
+	"strings"
+	"time"
+)
+
+// Option configures the SQLite connection opened by NewBukuDB.
+type Option func(*options) error
+
+type options struct {
+	busyTimeout  time.Duration
+	journalMode  string
+	foreignKeys  *bool
+	maxOpenConns int
+	fts          bool
+	queryLogger  QueryLogger
+	maxBookmarks int
+	fetchTitle   TitleFetcher
+}
+
+// journalModes are the SQLite journal modes accepted by WithJournalMode.
+var journalModes = []string{"DELETE", "TRUNCATE", "PERSIST", "MEMORY", "WAL", "OFF"}
+
+// WithBusyTimeout sets how long a locked database is retried before failing.
+func WithBusyTimeout(d time.Duration) Option {
+	return func(o *options) error {
+		if d < 0 {
+			return fmt.Errorf("invalid busy timeout %s", d)
+		}
+		o.busyTimeout = d
+		return nil
+	}
+}
+
+// WithJournalMode sets the SQLite journal mode, e.g. "WAL" or "DELETE".
+func WithJournalMode(mode string) Option {
+	return func(o *options) error {
+		mode = strings.ToUpper(mode)
+		for _, m := range journalModes {
+			if mode == m {
+				o.journalMode = mode
+				return nil
+			}
+		}
+		return fmt.Errorf("invalid journal mode '%s', expected one of %s",
+			mode, strings.Join(journalModes, ", "))
+	}
+}
+
+// WithForeignKeys enables or disables foreign key constraint enforcement.
+func WithForeignKeys(enabled bool) Option {
+	return func(o *options) error {
+		o.foreignKeys = &enabled
+		return nil
+	}
+}
+
+// WithMaxOpenConns sets the maximum number of open connections, 0 means
+// unlimited.
+func WithMaxOpenConns(n int) Option {
+	return func(o *options) error {
+		if n < 0 {
+			return fmt.Errorf("invalid max open connections %d", n)
+		}
+		o.maxOpenConns = n
+		return nil
+	}
+}
+
+// WithMaxBookmarks sets the maximum number of bookmarks, MaxBookmarks if not
+// set. Bookmark IDs are 16 bit, so it is at most math.MaxUint16.
+func WithMaxBookmarks(n int) Option {
+	return func(o *options) error {
+		if n < 1 || n > math.MaxUint16 {
+			return fmt.Errorf("invalid max bookmarks %d, expected 1-%d", n, math.MaxUint16)
+		}
+		o.maxBookmarks = n
+		return nil
+	}
+}
+
+// WithFTS enables the robuku_fts full text index, which is created if
+// missing and used by SearchRanked. It is ignored with a logged error if the
+// SQLite driver was built without FTS5.
+func WithFTS(enabled bool) Option {
+	return func(o *options) error {
+		o.fts = enabled
+		return nil
+	}
+}
+
+// WithQueryLogger reports every statement run on the database to logger.
+func WithQueryLogger(logger QueryLogger) Option {
+	return func(o *options) error {
+		o.queryLogger = logger
+		return nil
+	}
+}
+
+// WithTitleFetcher sets how ImportText fetches the titles of imported pages,
+// titles are not fetched if not set.
+func WithTitleFetcher(fetch TitleFetcher) Option {
+	return func(o *options) error {
+		o.fetchTitle = fetch
+		return nil
+	}
+}
+
+// dsn returns the data source name for dbPath, the pragmas are passed as
+// go-sqlite3 DSN parameters so they apply to every pooled connection.
+func (o options) dsn(dbPath string) string {
+	params := url.Values{}
+	if o.busyTimeout > 0 {
+		params.Set("_busy_timeout", fmt.Sprint(o.busyTimeout.Milliseconds()))
+	}
+	if o.journalMode != "" {
+		params.Set("_journal_mode", o.journalMode)
+	}
+	if o.foreignKeys != nil {
+		params.Set("_foreign_keys", fmt.Sprint(*o.foreignKeys))
+	}
+
+	if len(params) == 0 {
+		return dbPath
+	}
+	return dbPath + "?" + params.Encode()
+}
@@ -0,0 +1,106 @@
+package bukudb
+
+import (
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func Test_Options(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "bookmarks.db")
+	if err := InitSchema(path); err != nil {
+		t.Fatal(err)
+	}
+
+	db, err := NewBukuDB(path,
+		WithBusyTimeout(2500*time.Millisecond),
+		WithJournalMode("wal"),
+		WithForeignKeys(true),
+		WithMaxOpenConns(3),
+	)
+	if err != nil {
+		t.Fatalf("expected no error on NewBukuDB(), got '%v'", err)
+	}
+	defer db.Close()
+
+	var busyTimeout int
+	if err := db.conn.QueryRow("PRAGMA busy_timeout;").Scan(&busyTimeout); err != nil {
+		t.Fatal(err)
+	}
+	if busyTimeout != 2500 {
+		t.Errorf("expected busy_timeout '2500', got '%d'", busyTimeout)
+	}
+
+	var journalMode string
+	if err := db.conn.QueryRow("PRAGMA journal_mode;").Scan(&journalMode); err != nil {
+		t.Fatal(err)
+	}
+	if journalMode != "wal" {
+		t.Errorf("expected journal_mode 'wal', got '%s'", journalMode)
+	}
+
+	var foreignKeys int
+	if err := db.conn.QueryRow("PRAGMA foreign_keys;").Scan(&foreignKeys); err != nil {
+		t.Fatal(err)
+	}
+	if foreignKeys != 1 {
+		t.Errorf("expected foreign_keys '1', got '%d'", foreignKeys)
+	}
+
+	if n := db.conn.Stats().MaxOpenConnections; n != 3 {
+		t.Errorf("expected max open connections '3', got '%d'", n)
+	}
+}
+
+func Test_Options_Defaults(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "bookmarks.db")
+	if err := InitSchema(path); err != nil {
+		t.Fatal(err)
+	}
+
+	db, err := NewBukuDB(path)
+	if err != nil {
+		t.Fatalf("expected no error on NewBukuDB(), got '%v'", err)
+	}
+	defer db.Close()
+
+	var journalMode string
+	if err := db.conn.QueryRow("PRAGMA journal_mode;").Scan(&journalMode); err != nil {
+		t.Fatal(err)
+	}
+	if journalMode != "delete" {
+		t.Errorf("expected journal_mode 'delete', got '%s'", journalMode)
+	}
+
+	var foreignKeys int
+	if err := db.conn.QueryRow("PRAGMA foreign_keys;").Scan(&foreignKeys); err != nil {
+		t.Fatal(err)
+	}
+	if foreignKeys != 0 {
+		t.Errorf("expected foreign_keys '0', got '%d'", foreignKeys)
+	}
+
+	if n := db.conn.Stats().MaxOpenConnections; n != 0 {
+		t.Errorf("expected max open connections '0', got '%d'", n)
+	}
+}
+
+func Test_Options_Invalid(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "bookmarks.db")
+	if err := InitSchema(path); err != nil {
+		t.Fatal(err)
+	}
+
+	invalid := []Option{
+		WithBusyTimeout(-time.Second),
+		WithJournalMode("fast"),
+		WithMaxOpenConns(-1),
+		WithMaxBookmarks(0),
+		WithMaxBookmarks(65536),
+	}
+	for _, opt := range invalid {
+		if _, err := NewBukuDB(path, opt); err == nil {
+			t.Error("expected error on NewBukuDB() with invalid option, got nil")
+		}
+	}
+}
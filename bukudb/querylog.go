@@ -0,0 +1,157 @@
+package bukudb
+
+import (
+	"context"
+	"database/sql"
+	"time"
+)
+
+// QueryLogger receives every statement run on the database, how long it
+// took, and the error it returned.
+type QueryLogger func(query string, d time.Duration, err error)
+
+// queryLog times statements and reports them to logger, a nil logger
+// reports nothing.
+type queryLog struct {
+	logger QueryLogger
+	now    func() time.Time
+}
+
+// start returns the start time of a statement, zero without a logger.
+func (l queryLog) start() time.Time {
+	if l.logger == nil {
+		return time.Time{}
+	}
+	return l.now()
+}
+
+// done reports query, started at start, to the logger.
+func (l queryLog) done(query string, start time.Time, err error) {
+	if l.logger != nil {
+		l.logger(query, l.now().Sub(start), err)
+	}
+}
+
+// sqlDB are the methods of *sql.DB a loggedDB passes on, listed so no
+// statement can get past the logger through a method it does not wrap.
+type sqlDB interface {
+	Exec(query string, args ...any) (sql.Result, error)
+	QueryContext(ctx context.Context, query string, args ...any) (*sql.Rows, error)
+	QueryRow(query string, args ...any) *sql.Row
+	Begin() (*sql.Tx, error)
+	Prepare(query string) (*sql.Stmt, error)
+	SetMaxOpenConns(n int)
+	Stats() sql.DBStats
+	Close() error
+}
+
+// sqlTx are the methods of *sql.Tx a loggedTx passes on.
+type sqlTx interface {
+	Exec(query string, args ...any) (sql.Result, error)
+	Query(query string, args ...any) (*sql.Rows, error)
+	QueryRow(query string, args ...any) *sql.Row
+	Commit() error
+	Rollback() error
+}
+
+// sqlStmt are the methods of *sql.Stmt a loggedStmt passes on.
+type sqlStmt interface {
+	Exec(args ...any) (sql.Result, error)
+	Close() error
+}
+
+// loggedDB is a database connection reporting its statements to a
+// QueryLogger, including those of its transactions and prepared statements.
+type loggedDB struct {
+	sqlDB
+	queryLog
+}
+
+// newLoggedDB wraps conn, logger may be nil.
+func newLoggedDB(conn *sql.DB, logger QueryLogger) *loggedDB {
+	return &loggedDB{sqlDB: conn, queryLog: queryLog{logger: logger, now: time.Now}}
+}
+
+func (c *loggedDB) Exec(query string, args ...any) (sql.Result, error) {
+	start := c.start()
+	res, err := c.sqlDB.Exec(query, args...)
+	c.done(query, start, err)
+	return res, err
+}
+
+func (c *loggedDB) Query(query string, args ...any) (*sql.Rows, error) {
+	return c.QueryContext(context.Background(), query, args...)
+}
+
+func (c *loggedDB) QueryContext(ctx context.Context, query string, args ...any) (*sql.Rows, error) {
+	start := c.start()
+	rows, err := c.sqlDB.QueryContext(ctx, query, args...)
+	c.done(query, start, err)
+	return rows, err
+}
+
+func (c *loggedDB) QueryRow(query string, args ...any) *sql.Row {
+	start := c.start()
+	row := c.sqlDB.QueryRow(query, args...)
+	c.done(query, start, row.Err())
+	return row
+}
+
+func (c *loggedDB) Begin() (*loggedTx, error) {
+	tx, err := c.sqlDB.Begin()
+	if err != nil {
+		return nil, err
+	}
+	return &loggedTx{sqlTx: tx, queryLog: c.queryLog}, nil
+}
+
+func (c *loggedDB) Prepare(query string) (*loggedStmt, error) {
+	start := c.start()
+	stmt, err := c.sqlDB.Prepare(query)
+	c.done(query, start, err)
+	if err != nil {
+		return nil, err
+	}
+	return &loggedStmt{sqlStmt: stmt, query: query, queryLog: c.queryLog}, nil
+}
+
+// loggedTx is a transaction of a loggedDB.
+type loggedTx struct {
+	sqlTx
+	queryLog
+}
+
+func (tx *loggedTx) Exec(query string, args ...any) (sql.Result, error) {
+	start := tx.start()
+	res, err := tx.sqlTx.Exec(query, args...)
+	tx.done(query, start, err)
+	return res, err
+}
+
+func (tx *loggedTx) Query(query string, args ...any) (*sql.Rows, error) {
+	start := tx.start()
+	rows, err := tx.sqlTx.Query(query, args...)
+	tx.done(query, start, err)
+	return rows, err
+}
+
+func (tx *loggedTx) QueryRow(query string, args ...any) *sql.Row {
+	start := tx.start()
+	row := tx.sqlTx.QueryRow(query, args...)
+	tx.done(query, start, row.Err())
+	return row
+}
+
+// loggedStmt is a prepared statement of a loggedDB.
+type loggedStmt struct {
+	sqlStmt
+	query string
+	queryLog
+}
+
+func (s *loggedStmt) Exec(args ...any) (sql.Result, error) {
+	start := s.start()
+	res, err := s.sqlStmt.Exec(args...)
+	s.done(s.query, start, err)
+	return res, err
+}
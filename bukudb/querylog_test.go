@@ -0,0 +1,113 @@
+package bukudb
+
+import (
+	"database/sql"
+	"testing"
+	"time"
+)
+
+// loggedQuery is a statement reported to a QueryLogger.
+type loggedQuery struct {
+	query string
+	d     time.Duration
+	err   error
+}
+
+func Test_loggedDB(t *testing.T) {
+	sqlConn, err := sql.Open("sqlite3", ":memory:")
+	if err != nil {
+		t.Fatal(err)
+	}
+	var logged []loggedQuery
+	conn := newLoggedDB(sqlConn, func(query string, d time.Duration, err error) {
+		logged = append(logged, loggedQuery{query, d, err})
+	})
+	defer conn.Close()
+
+	// the fake clock is read when a statement starts and when it is done,
+	// statement i takes durations[i]
+	durations := []time.Duration{1, 20, 300, 4, 50, 600}
+	var calls int
+	conn.now = func() time.Time {
+		calls++
+		if calls%2 == 1 {
+			return time.Unix(0, 0)
+		}
+		return time.Unix(0, 0).Add(durations[calls/2-1] * time.Millisecond)
+	}
+
+	conn.SetMaxOpenConns(1)
+	if _, err := conn.Exec(`CREATE TABLE t (v TEXT)`); err != nil {
+		t.Fatal(err)
+	}
+	stmt, err := conn.Prepare(`INSERT INTO t (v) VALUES (?)`)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := stmt.Exec("a"); err != nil {
+		t.Fatal(err)
+	}
+	stmt.Close()
+	tx, err := conn.Begin()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := tx.Exec(`INSERT INTO t (v) VALUES ('b')`); err != nil {
+		t.Fatal(err)
+	}
+	tx.Commit()
+	var n int
+	if err := conn.QueryRow(`SELECT COUNT(*) FROM t`).Scan(&n); err != nil || n != 2 {
+		t.Fatalf("expected 2 rows, got %d and error %v", n, err)
+	}
+	rows, err := conn.Query(`SELECT missing FROM t`)
+	if err == nil {
+		rows.Close()
+	}
+
+	expected := []string{
+		`CREATE TABLE t (v TEXT)`,
+		`INSERT INTO t (v) VALUES (?)`,
+		`INSERT INTO t (v) VALUES (?)`,
+		`INSERT INTO t (v) VALUES ('b')`,
+		`SELECT COUNT(*) FROM t`,
+		`SELECT missing FROM t`,
+	}
+	if len(logged) != len(expected) {
+		t.Fatalf("expected %d logged statements, got %v", len(expected), logged)
+	}
+	for i, q := range logged {
+		if q.query != expected[i] {
+			t.Errorf("expected query '%s', got '%s'", expected[i], q.query)
+		}
+		if d := durations[i] * time.Millisecond; q.d != d {
+			t.Errorf("query '%s': expected duration %s, got %s", q.query, d, q.d)
+		}
+	}
+	if logged[len(logged)-1].err == nil {
+		t.Errorf("expected the failed query to be logged with its error")
+	}
+}
+
+func Test_WithQueryLogger(t *testing.T) {
+	createTestDb(t)
+	var queries []string
+	db, err := NewBukuDB(sqlTestDbPath, WithQueryLogger(func(query string, d time.Duration, err error) {
+		queries = append(queries, query)
+	}))
+	defer cleanUpTestDB(t, db)
+	if err != nil {
+		t.Fatalf("expected no error on NewBukuDB(), got '%v'", err)
+	}
+
+	before := len(queries)
+	if before == 0 {
+		t.Errorf("expected the setup statements to be logged")
+	}
+	if err := db.UpdateTitle(1, "new title"); err != nil {
+		t.Fatal(err)
+	}
+	if len(queries) != before+1 || queries[before] != updateQueries[fieldTitle] {
+		t.Errorf("expected the title update to be logged, got %v", queries[before:])
+	}
+}
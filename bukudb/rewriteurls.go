@@ -0,0 +1,109 @@
+package bukudb
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+)
+
+// URLRewrite is a URL rewritten by URLRewrites.
+type URLRewrite struct {
+	ID  uint16
+	Old string
+	New string
+
+	// Duplicate is the ID of the bookmark that already has New, the rewrite
+	// is skipped then. It is 0 for a rewrite that was made.
+	Duplicate uint16
+}
+
+// rewriteURL returns url with match replaced by replace, ok is false when
+// url doesn't have match. With prefixOnly only a match at the start counts.
+func rewriteURL(url, match, replace string, prefixOnly bool) (string, bool) {
+	if prefixOnly {
+		rest, ok := strings.CutPrefix(url, match)
+		if !ok {
+			return url, false
+		}
+		return replace + rest, true
+	}
+	return strings.ReplaceAll(url, match, replace), strings.Contains(url, match)
+}
+
+// RewriteURLs replaces match by replace in every URL starting with match, or
+// containing it unless prefixOnly, in one transaction. A rewrite to the URL
+// of another bookmark is skipped, see URLRewrites for which. It returns the
+// number of URLs rewritten.
+func (db *BukuDB) RewriteURLs(match, replace string, prefixOnly bool) (int, error) {
+	rewrites, err := db.URLRewrites(match, replace, prefixOnly, false)
+	if err != nil {
+		return 0, err
+	}
+	n := 0
+	for _, r := range rewrites {
+		if r.Duplicate == 0 {
+			n++
+		}
+	}
+	return n, nil
+}
+
+// URLRewrites is RewriteURLs returning every URL that was, or with dryRun
+// would be, rewritten in ID order, the skipped ones with Duplicate set.
+// With dryRun the rewrites are rolled back, so the skipped ones are the same.
+func (db *BukuDB) URLRewrites(match, replace string, prefixOnly, dryRun bool) ([]URLRewrite, error) {
+	if match == "" {
+		return nil, errors.New("no url to match")
+	}
+
+	db.mu.Lock()
+	defer db.mu.Unlock()
+
+	tx, err := db.conn.Begin()
+	if err != nil {
+		return nil, fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	rows, err := tx.Query(`SELECT id, URL FROM bookmarks ORDER BY id`)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query urls: %w", err)
+	}
+	var rewrites []URLRewrite
+	for rows.Next() {
+		var r URLRewrite
+		if err := rows.Scan(&r.ID, &r.Old); err != nil {
+			rows.Close()
+			return nil, fmt.Errorf("failed to scan url: %w", err)
+		}
+		var ok bool
+		if r.New, ok = rewriteURL(r.Old, match, replace, prefixOnly); ok && r.New != r.Old {
+			rewrites = append(rewrites, r)
+		}
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("failed to query urls: %w", err)
+	}
+
+	for i, r := range rewrites {
+		_, err := tx.Exec(`UPDATE bookmarks SET URL = ? WHERE id = ?`, r.New, r.ID)
+		if err == nil {
+			continue
+		}
+		// a failed statement is undone on its own, the transaction goes on
+		var dup *DuplicateURLError
+		if errors.As(duplicateURL(tx, err, r.New), &dup) {
+			rewrites[i].Duplicate = dup.ID
+			continue
+		}
+		return nil, fmt.Errorf("failed to rewrite url of bookmark %d: %w", r.ID, err)
+	}
+	if dryRun {
+		return rewrites, nil
+	}
+	if err := tx.Commit(); err != nil {
+		return nil, fmt.Errorf("failed to commit url rewrites: %w", err)
+	}
+	return rewrites, nil
+}
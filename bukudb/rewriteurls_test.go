@@ -0,0 +1,114 @@
+package bukudb
+
+import (
+	"path/filepath"
+	"testing"
+)
+
+func Test_rewriteURL(t *testing.T) {
+	tests := []struct {
+		url        string
+		prefixOnly bool
+		expected   string
+		ok         bool
+	}{
+		{"https://old.org/a", true, "https://new.org/a", true},
+		{"https://www.old.org/a", true, "https://www.old.org/a", false},
+		{"https://www.old.org/a", false, "https://www.new.org/a", true},
+		{"https://a.com/?u=old.org&v=old.org", false, "https://a.com/?u=new.org&v=new.org", true},
+		{"https://a.com", false, "https://a.com", false},
+	}
+	for _, tt := range tests {
+		match, replace := "https://old.org", "https://new.org"
+		if !tt.prefixOnly {
+			match, replace = "old.org", "new.org"
+		}
+		actual, ok := rewriteURL(tt.url, match, replace, tt.prefixOnly)
+		if actual != tt.expected || ok != tt.ok {
+			t.Errorf("expected '%s', %t for '%s', got '%s', %t", tt.expected, tt.ok, tt.url, actual, ok)
+		}
+	}
+}
+
+// newRewriteTestDB returns a database with bookmarks on old.org, one of
+// which is also on new.org.
+func newRewriteTestDB(t *testing.T) *BukuDB {
+	t.Helper()
+
+	path := filepath.Join(t.TempDir(), "bookmarks.db")
+	if err := InitSchema(path); err != nil {
+		t.Fatal(err)
+	}
+	db, err := NewBukuDB(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { db.Close() })
+	for _, url := range []string{
+		"https://old.org/a", "https://old.org/b", "https://new.org/b", "https://a.com/?u=https://old.org",
+	} {
+		if err := db.Add(Bookmark{URL: url}); err != nil {
+			t.Fatal(err)
+		}
+	}
+	return db
+}
+
+func checkURLs(t *testing.T, db *BukuDB, expected ...string) {
+	t.Helper()
+	for i, url := range expected {
+		if b, _ := db.Get(uint16(i + 1)); b.URL != url {
+			t.Errorf("expected url '%s' of #%d, got '%s'", url, i+1, b.URL)
+		}
+	}
+}
+
+func Test_URLRewrites(t *testing.T) {
+	db := newRewriteTestDB(t)
+
+	rewrites, err := db.URLRewrites("https://old.org", "https://new.org", true, true)
+	if err != nil {
+		t.Fatal(err)
+	}
+	expected := []URLRewrite{
+		{ID: 1, Old: "https://old.org/a", New: "https://new.org/a"},
+		{ID: 2, Old: "https://old.org/b", New: "https://new.org/b", Duplicate: 3},
+	}
+	if len(rewrites) != len(expected) {
+		t.Fatalf("expected rewrites %+v, got %+v", expected, rewrites)
+	}
+	for i := range expected {
+		if rewrites[i] != expected[i] {
+			t.Errorf("expected rewrite %+v, got %+v", expected[i], rewrites[i])
+		}
+	}
+	// the dry run is rolled back
+	checkURLs(t, db, "https://old.org/a", "https://old.org/b")
+
+	if _, err := db.URLRewrites("", "https://new.org", true, true); err == nil {
+		t.Errorf("expected an error for an empty match")
+	}
+}
+
+func Test_RewriteURLs(t *testing.T) {
+	db := newRewriteTestDB(t)
+
+	n, err := db.RewriteURLs("https://old.org", "https://new.org", true)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if n != 1 {
+		t.Errorf("expected 1 url rewritten, got %d", n)
+	}
+	// the collision is skipped, the query string isn't a prefix
+	checkURLs(t, db, "https://new.org/a", "https://old.org/b", "https://new.org/b", "https://a.com/?u=https://old.org")
+
+	n, err = db.RewriteURLs("old.org", "docs.org", false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if n != 2 {
+		t.Errorf("expected 2 urls rewritten, got %d", n)
+	}
+	checkURLs(t, db, "https://new.org/a", "https://docs.org/b", "https://new.org/b", "https://a.com/?u=https://docs.org")
+}
@@ -0,0 +1,182 @@
+package bukudb
+
+import (
+	"context"
+	"fmt"
+	"strings"
+)
+
+// likeEscaper escapes the LIKE wildcards so search terms match literally.
+var likeEscaper = strings.NewReplacer(`\`, `\\`, `%`, `\%`, `_`, `\_`)
+
+// Search rank weights, a term found in a higher ranked field adds its weight
+// once, so title matches sort above tag, URL, and comment only matches.
+const (
+	rankTitle   = 8
+	rankTags    = 4
+	rankURL     = 2
+	rankComment = 1
+)
+
+// SearchRanked returns the bookmarks containing every whitespace separated
+// term of query in their title, URL, tags, or comment, ignoring case. The
+// results are ordered by rank, then by ID. An empty query returns all
+// bookmarks. The full text index is used when enabled with WithFTS, which
+// matches terms as word prefixes instead of substrings.
+func (db *BukuDB) SearchRanked(query string) ([]Bookmark, error) {
+	return db.SearchRankedContext(context.Background(), query)
+}
+
+// SearchRankedContext is like SearchRanked, the query is interrupted when
+// ctx is done.
+func (db *BukuDB) SearchRankedContext(ctx context.Context, query string) ([]Bookmark, error) {
+	terms := strings.Fields(query)
+	if len(terms) == 0 {
+		return db.GetAllContext(ctx)
+	}
+
+	db.mu.Lock()
+	defer db.mu.Unlock()
+
+	sqlQuery, args, ok := "", []any(nil), false
+	if db.fts {
+		sqlQuery, args, ok = buildFTSSearchQuery(terms, db.source())
+	}
+	if !ok {
+		sqlQuery, args = buildSearchQuery(terms, db.source())
+	}
+
+	bookmarks, err := queryBookmarks(ctx, db.conn, sqlQuery, args...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to search bookmarks: %w", err)
+	}
+	return bookmarks, nil
+}
+
+// buildSearchQuery returns the SQL and its bound arguments for terms,
+// selecting from source. The terms are only ever passed as arguments.
+func buildSearchQuery(terms []string, source string) (string, []any) {
+	var rank, where []string
+	var rankArgs, whereArgs []any
+
+	for _, term := range terms {
+		pattern := "%" + likeEscaper.Replace(term) + "%"
+
+		rank = append(rank, fmt.Sprintf(`CASE WHEN metadata LIKE ? ESCAPE '\' THEN %d
+			WHEN tags LIKE ? ESCAPE '\' THEN %d
+			WHEN URL LIKE ? ESCAPE '\' THEN %d
+			ELSE %d END`, rankTitle, rankTags, rankURL, rankComment))
+		rankArgs = append(rankArgs, pattern, pattern, pattern)
+
+		where = append(where, `(metadata LIKE ? ESCAPE '\' OR URL LIKE ? ESCAPE '\'
+			OR tags LIKE ? ESCAPE '\' OR desc LIKE ? ESCAPE '\')`)
+		whereArgs = append(whereArgs, pattern, pattern, pattern, pattern)
+	}
+
+	query := `SELECT ` + bookmarkColumns + ` FROM ` + source + `
+		WHERE ` + strings.Join(where, " AND ") + `
+		ORDER BY (` + strings.Join(rank, " + ") + `) DESC, bookmarks.id`
+
+	return query, append(whereArgs, rankArgs...)
+}
+
+// SearchComments returns the bookmarks whose comment contains every word of
+// query, ignoring case, ordered by ID. Titles, URLs, and tags are not
+// searched.
+func (db *BukuDB) SearchComments(query string) ([]Bookmark, error) {
+	terms := strings.Fields(query)
+	if len(terms) == 0 {
+		return nil, nil
+	}
+
+	db.mu.Lock()
+	defer db.mu.Unlock()
+
+	where := make([]string, 0, len(terms))
+	args := make([]any, 0, len(terms))
+	for _, term := range terms {
+		where = append(where, `IFNULL(desc, '') LIKE ? ESCAPE '\'`)
+		args = append(args, "%"+likeEscaper.Replace(term)+"%")
+	}
+	bookmarks, err := queryBookmarks(context.Background(), db.conn, `SELECT `+bookmarkColumns+
+		` FROM `+db.source()+` WHERE `+strings.Join(where, " AND ")+` ORDER BY bookmarks.id`, args...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to search comments: %w", err)
+	}
+	return bookmarks, nil
+}
+
+// SearchByTags returns the bookmarks carrying every tag of include, or any
+// of them if matchAll is false, and none of exclude, ignoring case, ordered
+// by ID.
+func (db *BukuDB) SearchByTags(include, exclude []string, matchAll bool) ([]Bookmark, error) {
+	db.mu.Lock()
+	defer db.mu.Unlock()
+
+	sqlQuery, args := buildTagQuery(include, exclude, matchAll, db.source())
+	bookmarks, err := queryBookmarks(context.Background(), db.conn, sqlQuery, args...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to search bookmarks by tags: %w", err)
+	}
+	return bookmarks, nil
+}
+
+// buildTagQuery returns the SQL and its bound arguments matching the tags
+// column against include and exclude. Tags are matched between the commas of
+// buku's ",tag1,tag2," format, so "go" does not match "golang", a parent tag
+// also matches its children. The bookmarks are selected from source.
+func buildTagQuery(include, exclude []string, matchAll bool, source string) (string, []any) {
+	where := []string{"1"}
+	var args []any
+	if len(include) > 0 {
+		matches := make([]string, 0, len(include))
+		for _, tag := range include {
+			match, patterns := tagCondition(tag)
+			matches = append(matches, match)
+			args = append(args, patterns...)
+		}
+		op := " OR "
+		if matchAll {
+			op = " AND "
+		}
+		where = append(where, "("+strings.Join(matches, op)+")")
+	}
+	for _, tag := range exclude {
+		match, patterns := tagCondition(tag)
+		where = append(where, "NOT "+match)
+		args = append(args, patterns...)
+	}
+
+	query := `SELECT ` + bookmarkColumns + ` FROM ` + source + `
+		WHERE ` + strings.Join(where, " AND ") + `
+		ORDER BY bookmarks.id`
+
+	return query, args
+}
+
+// tagsColumnSQL is the tags column with outer commas, so the patterns of
+// tagPattern also match the first and last tag of columns written without
+// buku's leading and trailing comma.
+const tagsColumnSQL = `(',' || IFNULL(tags, '') || ',')`
+
+// tagPattern returns the LIKE pattern matching tag as a whole tag.
+func tagPattern(tag string) string {
+	return "%," + likeEscaper.Replace(strings.TrimSpace(tag)) + ",%"
+}
+
+// tagCondition returns the condition matching tag and its LIKE patterns. A
+// parent tag, one without TagSeparator, also matches its children, so "lang"
+// matches "lang/go".
+func tagCondition(tag string) (string, []any) {
+	tag = strings.TrimSpace(tag)
+	if strings.Contains(tag, TagSeparator) {
+		return `(` + tagsColumnSQL + ` LIKE ? ESCAPE '\')`, []any{tagPattern(tag)}
+	}
+	return `(` + tagsColumnSQL + ` LIKE ? ESCAPE '\' OR ` + tagsColumnSQL + ` LIKE ? ESCAPE '\')`,
+		[]any{tagPattern(tag), childTagPattern(tag)}
+}
+
+// childTagPattern returns the LIKE pattern matching the children of parent.
+func childTagPattern(parent string) string {
+	return "%," + likeEscaper.Replace(strings.TrimSpace(parent)) + TagSeparator + "%"
+}
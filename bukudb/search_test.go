@@ -0,0 +1,273 @@
+package bukudb
+
+import (
+	"cmp"
+	"fmt"
+	"path/filepath"
+	"slices"
+	"strings"
+	"testing"
+)
+
+func newSearchTestDB(t *testing.T) *BukuDB {
+	path := filepath.Join(t.TempDir(), "bookmarks.db")
+	if err := InitSchema(path); err != nil {
+		t.Fatal(err)
+	}
+	db, err := NewBukuDB(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { db.Close() })
+
+	bookmarks := []Bookmark{
+		{URL: "https://comment.com", Title: "first", Comment: "about golang"},
+		{URL: "https://title.com", Title: "Golang Tutorial"},
+		{URL: "https://golang.org", Title: "home", Tags: []string{"lang"}},
+		{URL: "https://percent.com", Title: "100% go_lang"},
+		{URL: "https://other.com", Title: "1000 golang"},
+	}
+	for _, b := range bookmarks {
+		if err := db.Add(b); err != nil {
+			t.Fatal(err)
+		}
+	}
+	return db
+}
+
+func searchIDs(t *testing.T, db *BukuDB, query string) []uint16 {
+	bookmarks, err := db.SearchRanked(query)
+	if err != nil {
+		t.Fatalf("expected no error on SearchRanked(%q), got '%v'", query, err)
+	}
+	ids := make([]uint16, len(bookmarks))
+	for i, b := range bookmarks {
+		ids[i] = b.ID
+	}
+	return ids
+}
+
+func Test_SearchRanked(t *testing.T) {
+	db := newSearchTestDB(t)
+
+	tests := []struct {
+		query    string
+		expected []uint16
+	}{
+		// title matches sort above URL and comment only matches
+		{"golang", []uint16{2, 5, 3, 1}},
+		{"GOLANG", []uint16{2, 5, 3, 1}},
+		{"golang tutorial", []uint16{2}},
+		{"golang lang", []uint16{2, 5, 3, 1}},
+		{"home lang", []uint16{3}},
+		{"golang missing", []uint16{}},
+		{"100%", []uint16{4}},
+		{"go_lang", []uint16{4}},
+		{"%", []uint16{4}},
+		{"_", []uint16{4}},
+		{"", []uint16{1, 2, 3, 4, 5}},
+	}
+
+	for _, test := range tests {
+		ids := searchIDs(t, db, test.query)
+		if len(ids) != len(test.expected) {
+			t.Errorf("query %q: expected %v, got %v", test.query, test.expected, ids)
+			continue
+		}
+		for i := range ids {
+			if ids[i] != test.expected[i] {
+				t.Errorf("query %q: expected %v, got %v", test.query, test.expected, ids)
+				break
+			}
+		}
+	}
+}
+
+func Test_SearchRanked_Fields(t *testing.T) {
+	db := newSearchTestDB(t)
+
+	bookmarks, err := db.SearchRanked("home")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(bookmarks) != 1 {
+		t.Fatalf("expected 1 bookmark, got %d", len(bookmarks))
+	}
+	b := bookmarks[0]
+	if b.URL != "https://golang.org" || b.Title != "home" {
+		t.Errorf("expected bookmark 'https://golang.org' 'home', got '%s' '%s'", b.URL, b.Title)
+	}
+	if len(b.Tags) != 1 || b.Tags[0] != "lang" {
+		t.Errorf("expected tags [lang], got %v", b.Tags)
+	}
+}
+
+func Test_buildTagQuery(t *testing.T) {
+	query, args := buildTagQuery([]string{"lang/go"}, []string{"video", "50%"}, true, bookmarkSource)
+	for _, want := range []string{
+		`WHERE 1 AND (((',' || IFNULL(tags, '') || ',') LIKE ? ESCAPE '\')) AND NOT ((',' || IFNULL(tags, '') || ',') LIKE ? ESCAPE '\' OR (',' || IFNULL(tags, '') || ',') LIKE ? ESCAPE '\') AND NOT (`,
+		"ORDER BY bookmarks.id",
+	} {
+		if !strings.Contains(query, want) {
+			t.Errorf("expected query to contain %q, got %q", want, query)
+		}
+	}
+	expected := []any{"%,lang/go,%", "%,video,%", "%,video/%", `%,50\%,%`, `%,50\%/%`}
+	if len(args) != len(expected) {
+		t.Fatalf("expected args %v, got %v", expected, args)
+	}
+	for i := range args {
+		if args[i] != expected[i] {
+			t.Errorf("expected args %v, got %v", expected, args)
+			break
+		}
+	}
+
+	if query, args := buildTagQuery(nil, nil, true, bookmarkSource); !strings.Contains(query, "WHERE 1\n") || len(args) != 0 {
+		t.Errorf("expected query without conditions, got %q and %v", query, args)
+	}
+
+	query, _ = buildTagQuery([]string{"go/std", "rust/core"}, []string{"video/old"}, false, bookmarkSource)
+	want := `WHERE 1 AND (((',' || IFNULL(tags, '') || ',') LIKE ? ESCAPE '\') OR ((',' || IFNULL(tags, '') || ',') LIKE ? ESCAPE '\')) AND NOT ((',' || IFNULL(tags, '') || ',') LIKE ?`
+	if !strings.Contains(query, want) {
+		t.Errorf("expected query to contain %q, got %q", want, query)
+	}
+}
+
+func Test_tagCondition(t *testing.T) {
+	tests := []struct {
+		tag      string
+		expected []any
+	}{
+		{" lang ", []any{"%,lang,%", "%,lang/%"}},
+		{"lang/go", []any{"%,lang/go,%"}},
+		{"lang/go/std", []any{"%,lang/go/std,%"}},
+		{"a_b", []any{`%,a\_b,%`, `%,a\_b/%`}},
+	}
+	for _, tt := range tests {
+		cond, args := tagCondition(tt.tag)
+		if strings.Count(cond, "?") != len(args) {
+			t.Errorf("tag '%s': expected a placeholder per argument, got %q and %v", tt.tag, cond, args)
+		}
+		if fmt.Sprint(args) != fmt.Sprint(tt.expected) {
+			t.Errorf("tag '%s': expected args %v, got %v", tt.tag, tt.expected, args)
+		}
+	}
+}
+
+func Test_SearchByTags(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "bookmarks.db")
+	if err := InitSchema(path); err != nil {
+		t.Fatal(err)
+	}
+	db, err := NewBukuDB(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer db.Close()
+
+	for i, tags := range [][]string{
+		{"go", "video"},
+		{"Go", "blog"},
+		{"golang"},
+		{"go"},
+		nil,
+		{"lang/go"},
+		{"lang/rust"},
+		{"lang/go/std"},
+		{"Lang"},
+		{"language"},
+	} {
+		if err := db.Add(Bookmark{URL: fmt.Sprintf("https://%d.com", i), Tags: tags}); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	tests := []struct {
+		include, exclude []string
+		matchAll         bool
+		expected         []uint16
+	}{
+		{[]string{"go"}, nil, true, []uint16{1, 2, 4}},
+		{[]string{"go"}, []string{"video"}, true, []uint16{2, 4}},
+		{[]string{"GO"}, []string{"video", "BLOG"}, true, []uint16{4}},
+		{nil, []string{"go"}, true, []uint16{3, 5, 6, 7, 8, 9, 10}},
+		{[]string{"go", "blog"}, nil, true, []uint16{2}},
+		{nil, nil, true, []uint16{1, 2, 3, 4, 5, 6, 7, 8, 9, 10}},
+		{[]string{"golang", "blog"}, nil, false, []uint16{2, 3}},
+		{[]string{"golang", "video"}, []string{"go"}, false, []uint16{3}},
+		{nil, nil, false, []uint16{1, 2, 3, 4, 5, 6, 7, 8, 9, 10}},
+		{[]string{"lang"}, nil, true, []uint16{6, 7, 8, 9}},
+		{[]string{"LANG/go"}, nil, true, []uint16{6}},
+		{[]string{"lang/go/std"}, nil, true, []uint16{8}},
+		{[]string{"lang/go"}, []string{"lang/go/std"}, true, []uint16{6}},
+		{[]string{"go"}, []string{"lang"}, true, []uint16{1, 2, 4}},
+		{nil, []string{"lang"}, true, []uint16{1, 2, 3, 4, 5, 10}},
+		{[]string{"lan"}, nil, true, nil},
+	}
+	for _, tt := range tests {
+		bookmarks, err := db.SearchByTags(tt.include, tt.exclude, tt.matchAll)
+		if err != nil {
+			t.Fatalf("expected no error on SearchByTags(), got '%v'", err)
+		}
+		ids := make([]uint16, len(bookmarks))
+		for i, b := range bookmarks {
+			ids[i] = b.ID
+		}
+		if fmt.Sprint(ids) != fmt.Sprint(tt.expected) {
+			t.Errorf("include %v exclude %v all %v: expected %v, got %v",
+				tt.include, tt.exclude, tt.matchAll, tt.expected, ids)
+		}
+	}
+}
+
+func Test_SearchComments(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "bookmarks.db")
+	if err := InitSchema(path); err != nil {
+		t.Fatal(err)
+	}
+	db, err := NewBukuDB(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer db.Close()
+
+	for i, b := range []Bookmark{
+		{Title: "the lost phrase", Comment: "nothing here"},
+		{Title: "other", Comment: "I wrote a Lost Phrase here"},
+		{Title: "other", Comment: "phrase first, then lost"},
+		{URL: "https://lost.com", Comment: "100% sure"},
+		{Title: "no comment"},
+	} {
+		b.URL = cmp.Or(b.URL, fmt.Sprintf("https://%d.com", i))
+		if err := db.Add(b); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	tests := []struct {
+		query    string
+		expected []uint16
+	}{
+		{"lost phrase", []uint16{2, 3}},
+		{"LOST", []uint16{2, 3}},
+		{"lost  PHRASE here", []uint16{2}},
+		{"100%", []uint16{4}},
+		{"0%", []uint16{4}},
+		{"_", nil},
+		{"  ", nil},
+	}
+	for _, tt := range tests {
+		bookmarks, err := db.SearchComments(tt.query)
+		if err != nil {
+			t.Fatalf("expected no error on SearchComments(), got '%v'", err)
+		}
+		var ids []uint16
+		for _, b := range bookmarks {
+			ids = append(ids, b.ID)
+		}
+		if !slices.Equal(ids, tt.expected) {
+			t.Errorf("expected ids %v for '%s', got %v", tt.expected, tt.query, ids)
+		}
+	}
+}
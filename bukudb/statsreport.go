@@ -0,0 +1,74 @@
+package bukudb
+
+// StatsReportVersion is the version of the StatsReport format, raised when
+// a field is renamed or removed.
+const StatsReportVersion = 1
+
+// StatsReport is Stats with the counts of every tag and domain, for
+// dashboards reading it as JSON. The field names are stable.
+type StatsReport struct {
+	Version int `json:"version"`
+
+	// SchemaOK is false when the file is not a buku database, the counts
+	// are zero then.
+	SchemaOK bool `json:"schema_ok"`
+
+	Total    int                 `json:"total"`
+	Untagged int                 `json:"untagged"`
+	Untitled int                 `json:"untitled"`
+	Visited  int                 `json:"visited"`
+	FileSize int64               `json:"file_size"`
+	Tags     []tagCountReport    `json:"tags"`
+	Domains  []domainCountReport `json:"domains"`
+}
+
+type tagCountReport struct {
+	Tag   string `json:"tag"`
+	Count int    `json:"count"`
+}
+
+type domainCountReport struct {
+	Domain string `json:"domain"`
+	Count  int    `json:"count"`
+}
+
+// StatsReport returns the Stats of db with the counts of all tags and
+// domains, most used first.
+func (db *BukuDB) StatsReport() (StatsReport, error) {
+	s, err := db.Stats()
+	if err != nil {
+		return StatsReport{}, err
+	}
+
+	db.mu.Lock()
+	defer db.mu.Unlock()
+
+	// a negative LIMIT is no limit in SQLite
+	tags, err := queryTopTags(db.conn, -1)
+	if err != nil {
+		return StatsReport{}, err
+	}
+	domains, err := queryTopDomains(db.conn, -1)
+	if err != nil {
+		return StatsReport{}, err
+	}
+
+	r := StatsReport{
+		Version:  StatsReportVersion,
+		SchemaOK: true,
+		Total:    s.Total,
+		Untagged: s.Untagged,
+		Untitled: s.Untitled,
+		Visited:  s.Visited,
+		FileSize: s.FileSize,
+		Tags:     make([]tagCountReport, 0, len(tags)),
+		Domains:  make([]domainCountReport, 0, len(domains)),
+	}
+	for _, t := range tags {
+		r.Tags = append(r.Tags, tagCountReport{Tag: t.Tag, Count: t.Count})
+	}
+	for _, d := range domains {
+		r.Domains = append(r.Domains, domainCountReport{Domain: d.Domain, Count: d.Count})
+	}
+	return r, nil
+}
@@ -0,0 +1,79 @@
+package bukudb
+
+import (
+	"fmt"
+	"strings"
+)
+
+// TagSeparator splits a tag into a parent and a child, "lang/go" is the
+// child "go" of "lang". Only the first one separates, "lang/go/std" is the
+// child "go/std" of "lang".
+const TagSeparator = "/"
+
+// TagGroup is a top level tag with the tags below it.
+type TagGroup struct {
+	// Name of the parent tag.
+	Name string
+
+	// Count is the sum of the counts of the parent and its children, a
+	// bookmark carrying two of them is counted twice.
+	Count int
+
+	// Children are the tags below the parent, with their full name, in the
+	// order they were given. Empty for a plain tag.
+	Children []TagCount
+}
+
+// Tags returns every tag and the number of bookmarks carrying it, ordered
+// by tag ignoring case.
+func (db *BukuDB) Tags() ([]TagCount, error) {
+	db.mu.Lock()
+	defer db.mu.Unlock()
+
+	rows, err := db.conn.Query(statsTagsCTE + `SELECT tag, COUNT(DISTINCT id)
+		FROM split WHERE tag != '' GROUP BY tag ORDER BY lower(tag), tag`)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query tags: %w", err)
+	}
+	defer rows.Close()
+
+	var tags []TagCount
+	for rows.Next() {
+		var tc TagCount
+		if err := rows.Scan(&tc.Tag, &tc.Count); err != nil {
+			return nil, fmt.Errorf("failed to scan tag count: %w", err)
+		}
+		tags = append(tags, tc)
+	}
+	return tags, rows.Err()
+}
+
+// SplitTag returns the parent of tag and the rest after the first
+// TagSeparator, child is empty for a tag without parent.
+func SplitTag(tag string) (parent, child string) {
+	parent, child, _ = strings.Cut(tag, TagSeparator)
+	return parent, child
+}
+
+// GroupTags groups tags by their parent, ignoring case, in the order the
+// parents first appear. A tag without parent is a group without children,
+// it is merged with the group of its children if both exist.
+func GroupTags(tags []TagCount) []TagGroup {
+	var groups []TagGroup
+	index := make(map[string]int)
+	for _, tc := range tags {
+		parent, child := SplitTag(tc.Tag)
+		key := strings.ToLower(parent)
+		i, ok := index[key]
+		if !ok {
+			i = len(groups)
+			index[key] = i
+			groups = append(groups, TagGroup{Name: parent})
+		}
+		groups[i].Count += tc.Count
+		if child != "" {
+			groups[i].Children = append(groups[i].Children, tc)
+		}
+	}
+	return groups
+}
@@ -0,0 +1,84 @@
+package bukudb
+
+import (
+	"fmt"
+	"path/filepath"
+	"testing"
+)
+
+func Test_SplitTag(t *testing.T) {
+	tests := []struct {
+		tag, parent, child string
+	}{
+		{"go", "go", ""},
+		{"lang/go", "lang", "go"},
+		{"lang/go/std", "lang", "go/std"},
+		{"lang/", "lang", ""},
+	}
+	for _, tt := range tests {
+		if parent, child := SplitTag(tt.tag); parent != tt.parent || child != tt.child {
+			t.Errorf("tag '%s': expected '%s' and '%s', got '%s' and '%s'",
+				tt.tag, tt.parent, tt.child, parent, child)
+		}
+	}
+}
+
+func Test_GroupTags(t *testing.T) {
+	groups := GroupTags([]TagCount{
+		{"blog", 3},
+		{"Lang", 1},
+		{"lang/go", 20},
+		{"lang/go/std", 2},
+		{"LANG/rust", 4},
+		{"video/", 1},
+	})
+	expected := []TagGroup{
+		{Name: "blog", Count: 3},
+		{Name: "Lang", Count: 27, Children: []TagCount{{"lang/go", 20}, {"lang/go/std", 2}, {"LANG/rust", 4}}},
+		{Name: "video", Count: 1},
+	}
+	if fmt.Sprint(groups) != fmt.Sprint(expected) {
+		t.Errorf("expected %v, got %v", expected, groups)
+	}
+
+	if groups := GroupTags(nil); len(groups) != 0 {
+		t.Errorf("expected no groups, got %v", groups)
+	}
+}
+
+func Test_Tags(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "bookmarks.db")
+	if err := InitSchema(path); err != nil {
+		t.Fatal(err)
+	}
+	db, err := NewBukuDB(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer db.Close()
+
+	for i, tags := range [][]string{
+		{"lang/go", "video"},
+		{"lang/go", "Lang/rust/std"},
+		{"blog"},
+		nil,
+	} {
+		if err := db.Add(Bookmark{URL: fmt.Sprintf("https://%d.com", i), Tags: tags}); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	tags, err := db.Tags()
+	if err != nil {
+		t.Fatalf("expected no error on Tags(), got '%v'", err)
+	}
+	expected := []TagCount{{"blog", 1}, {"lang/go", 2}, {"Lang/rust/std", 1}, {"video", 1}}
+	if fmt.Sprint(tags) != fmt.Sprint(expected) {
+		t.Errorf("expected %v, got %v", expected, tags)
+	}
+
+	groups := GroupTags(tags)
+	if len(groups) != 3 || groups[1].Name != "lang" || groups[1].Count != 3 || len(groups[1].Children) != 2 {
+		t.Errorf("expected 'lang' to group 2 children used 3 times, got %v", groups)
+	}
+}
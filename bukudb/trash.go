@@ -0,0 +1,168 @@
+package bukudb
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+	"time"
+)
+
+// trashSchema creates robuku_trash, which keeps bookmarks deleted with
+// robuku so they can be restored. buku ignores it.
+const trashSchema = `CREATE TABLE IF NOT EXISTS robuku_trash (
+    trash_id INTEGER PRIMARY KEY AUTOINCREMENT,
+    URL TEXT NOT NULL,
+    metadata TEXT DEFAULT '',
+    tags TEXT DEFAULT ',',
+    desc TEXT DEFAULT '',
+    flags INTEGER DEFAULT 0,
+    created_at INTEGER,
+    deleted_at INTEGER NOT NULL
+);`
+
+// trashInsert copies the bookmark with the given id into robuku_trash.
+const trashInsert = `INSERT INTO robuku_trash
+	(URL, metadata, tags, desc, flags, created_at, deleted_at)
+	SELECT bookmarks.URL, bookmarks.metadata, bookmarks.tags, bookmarks.desc,
+		bookmarks.flags, robuku_meta.created_at, ?
+	FROM ` + bookmarkSource + ` WHERE bookmarks.id = ?`
+
+// TrashedBookmark is a bookmark deleted with robuku.
+type TrashedBookmark struct {
+	Bookmark
+
+	// TrashID identifies the bookmark in the trash, its ID is always 0.
+	TrashID int64
+
+	// Deleted is when the bookmark was deleted.
+	Deleted time.Time
+}
+
+// setupTrash creates robuku_trash.
+func setupTrash(conn *loggedDB) error {
+	if _, err := conn.Exec(trashSchema); err != nil {
+		return fmt.Errorf("failed to create trash table: %w", err)
+	}
+	return nil
+}
+
+// TrashList returns the bookmarks in the trash, the most recently deleted
+// first.
+func (db *BukuDB) TrashList() ([]TrashedBookmark, error) {
+	db.mu.Lock()
+	defer db.mu.Unlock()
+
+	ok, err := hasTable(db.conn, "robuku_trash")
+	if err != nil || !ok {
+		return nil, err
+	}
+
+	rows, err := db.conn.Query(`SELECT trash_id, URL, metadata, tags, desc,
+		created_at, deleted_at FROM robuku_trash ORDER BY deleted_at DESC, trash_id DESC`)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query trash: %w", err)
+	}
+	defer rows.Close()
+
+	var trashed []TrashedBookmark
+	for rows.Next() {
+		var t TrashedBookmark
+		var deleted int64
+		b, err := scanBookmark(trashScanner{rows, &t.TrashID, &deleted})
+		if err != nil {
+			return nil, err
+		}
+		t.Bookmark = b
+		t.Deleted = time.Unix(deleted, 0)
+		trashed = append(trashed, t)
+	}
+	return trashed, rows.Err()
+}
+
+// Restore moves the bookmark with trashID from the trash back to the
+// bookmarks, at the ID after the last bookmark, and returns that ID. A URL
+// that was bookmarked again meanwhile returns a *DuplicateURLError.
+func (db *BukuDB) Restore(trashID int64) (uint16, error) {
+	db.mu.Lock()
+	defer db.mu.Unlock()
+
+	if err := db.setupLocked(); err != nil {
+		return 0, err
+	}
+
+	tx, err := db.conn.Begin()
+	if err != nil {
+		return 0, fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	// the last ID is read again since buku may have added bookmarks
+	var id int
+	if err := tx.QueryRow(`SELECT COALESCE(MAX(id), 0) + 1 FROM bookmarks`).Scan(&id); err != nil {
+		return 0, fmt.Errorf("failed to get next bookmark id: %w", err)
+	}
+	if id > db.max {
+		return 0, &LimitError{Count: id - 1, Max: db.max}
+	}
+
+	var url string
+	var created sql.NullInt64
+	err = tx.QueryRow(`SELECT URL, created_at FROM robuku_trash WHERE trash_id = ?`, trashID).
+		Scan(&url, &created)
+	if errors.Is(err, sql.ErrNoRows) {
+		return 0, fmt.Errorf("no bookmark %d in trash", trashID)
+	}
+	if err != nil {
+		return 0, fmt.Errorf("failed to get trashed bookmark: %w", err)
+	}
+
+	_, err = tx.Exec(`INSERT INTO bookmarks (id, URL, metadata, tags, desc, flags)
+		SELECT ?, URL, metadata, tags, desc, flags FROM robuku_trash WHERE trash_id = ?`, id, trashID)
+	if dup := duplicateURL(tx, err, url); dup != nil {
+		return 0, dup
+	}
+	if err != nil {
+		return 0, fmt.Errorf("failed to restore bookmark: %w", err)
+	}
+	_, err = tx.Exec(`INSERT OR REPLACE INTO robuku_meta (id, created_at) VALUES (?, ?)`, id, created)
+	if err != nil {
+		return 0, fmt.Errorf("failed to restore bookmark meta: %w", err)
+	}
+	if _, err := tx.Exec(`DELETE FROM robuku_trash WHERE trash_id = ?`, trashID); err != nil {
+		return 0, fmt.Errorf("failed to remove bookmark from trash: %w", err)
+	}
+
+	if err := tx.Commit(); err != nil {
+		return 0, fmt.Errorf("failed to commit restore: %w", err)
+	}
+	db.len = id
+	return uint16(id), nil
+}
+
+// EmptyTrash deletes all bookmarks in the trash for good.
+func (db *BukuDB) EmptyTrash() error {
+	db.mu.Lock()
+	defer db.mu.Unlock()
+
+	if err := db.setupLocked(); err != nil {
+		return err
+	}
+
+	if _, err := db.conn.Exec(`DELETE FROM robuku_trash`); err != nil {
+		return fmt.Errorf("failed to empty trash: %w", err)
+	}
+	return nil
+}
+
+// trashScanner scans a robuku_trash row as bookmarkColumns, with the trash
+// ID in place of the bookmark ID and the deletion time appended.
+type trashScanner struct {
+	rows    *sql.Rows
+	trashID *int64
+	deleted *int64
+}
+
+func (s trashScanner) Scan(dest ...any) error {
+	// dest is id, URL, title, tags, comment, created as in scanBookmark
+	return s.rows.Scan(append([]any{s.trashID}, append(dest[1:], s.deleted)...)...)
+}
@@ -0,0 +1,167 @@
+package bukudb
+
+import (
+	"errors"
+	"testing"
+	"time"
+)
+
+func Test_TrashList(t *testing.T) {
+	createTestDb(t)
+	db, err := NewBukuDB(sqlTestDbPath)
+	defer cleanUpTestDB(t, db)
+
+	if err != nil {
+		t.Fatalf("expected no error on NewBukuDB(), got '%v'", err)
+	}
+
+	if trashed, err := db.TrashList(); err != nil || len(trashed) != 0 {
+		t.Fatalf("expected empty trash, got %v and error '%v'", trashed, err)
+	}
+
+	before := time.Now().Add(-time.Second)
+	if err := db.Remove(1); err != nil {
+		t.Fatal(err)
+	}
+	if err := db.Remove(2); err != nil {
+		t.Fatal(err)
+	}
+
+	trashed, err := db.TrashList()
+	if err != nil {
+		t.Fatalf("expected no error on TrashList(), got '%v'", err)
+	}
+	if len(trashed) != 2 {
+		t.Fatalf("expected 2 trashed bookmarks, got %d", len(trashed))
+	}
+
+	// most recently deleted first, the second removal deleted the former #3
+	if trashed[0].URL != "https://www.c.com" || trashed[1].URL != "https://www.a.com" {
+		t.Errorf("expected c.com then a.com, got %s then %s", trashed[0].URL, trashed[1].URL)
+	}
+	a := trashed[1]
+	if a.Title != "metadata (title) a" || a.Comment != "desc (comment) a" || len(a.Tags) != 3 {
+		t.Errorf("expected trashed bookmark to keep its fields, got %+v", a)
+	}
+	if a.ID != 0 || a.TrashID == 0 {
+		t.Errorf("expected trash id and no bookmark id, got %d and %d", a.TrashID, a.ID)
+	}
+	if a.Deleted.Before(before) {
+		t.Errorf("expected deletion time after %v, got %v", before, a.Deleted)
+	}
+}
+
+func Test_Restore(t *testing.T) {
+	createTestDb(t)
+	db, err := NewBukuDB(sqlTestDbPath)
+	defer cleanUpTestDB(t, db)
+
+	if err != nil {
+		t.Fatalf("expected no error on NewBukuDB(), got '%v'", err)
+	}
+
+	if err := db.Add(Bookmark{URL: "https://www.e.com"}); err != nil {
+		t.Fatal(err)
+	}
+	if err := db.Remove(5); err != nil {
+		t.Fatal(err)
+	}
+	trashed, err := db.TrashList()
+	if err != nil || len(trashed) != 1 {
+		t.Fatalf("expected 1 trashed bookmark, got %v and error '%v'", trashed, err)
+	}
+
+	// the original ID 5 is taken by a bookmark added with buku, which db
+	// does not know about yet
+	_, err = db.conn.Exec(`INSERT INTO bookmarks (id, URL) VALUES (5, 'https://www.f.com')`)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	id, err := db.Restore(trashed[0].TrashID)
+	if err != nil {
+		t.Fatalf("expected no error on Restore(), got '%v'", err)
+	}
+	if id != 6 {
+		t.Errorf("expected restored id 6, got %d", id)
+	}
+	if db.Len() != 6 {
+		t.Errorf("expected length 6, got %d", db.Len())
+	}
+
+	b, err := db.Get(6)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if b.URL != "https://www.e.com" || b.Created == nil {
+		t.Errorf("expected restored e.com with creation time, got %+v", b)
+	}
+	if trashed, _ := db.TrashList(); len(trashed) != 0 {
+		t.Errorf("expected empty trash, got %v", trashed)
+	}
+
+	// missing trash ID
+	if _, err := db.Restore(trashed[0].TrashID); err == nil {
+		t.Errorf("expected error restoring a bookmark not in the trash")
+	}
+}
+
+func Test_Restore_DuplicateURL(t *testing.T) {
+	createTestDb(t)
+	db, err := NewBukuDB(sqlTestDbPath)
+	defer cleanUpTestDB(t, db)
+
+	if err != nil {
+		t.Fatalf("expected no error on NewBukuDB(), got '%v'", err)
+	}
+
+	if err := db.Remove(4); err != nil {
+		t.Fatal(err)
+	}
+	if err := db.Add(Bookmark{URL: "https://www.d.com"}); err != nil {
+		t.Fatal(err)
+	}
+
+	trashed, err := db.TrashList()
+	if err != nil || len(trashed) != 1 {
+		t.Fatalf("expected 1 trashed bookmark, got %v and error '%v'", trashed, err)
+	}
+	_, err = db.Restore(trashed[0].TrashID)
+	var dup *DuplicateURLError
+	if !errors.As(err, &dup) || dup.ID != 4 {
+		t.Errorf("expected a duplicate of bookmark 4 restoring a URL that exists again, got '%v'", err)
+	}
+
+	// the failed restore is rolled back
+	if db.Len() != 4 {
+		t.Errorf("expected length 4, got %d", db.Len())
+	}
+	if trashed, _ := db.TrashList(); len(trashed) != 1 {
+		t.Errorf("expected bookmark to stay in the trash, got %v", trashed)
+	}
+}
+
+func Test_EmptyTrash(t *testing.T) {
+	createTestDb(t)
+	db, err := NewBukuDB(sqlTestDbPath)
+	defer cleanUpTestDB(t, db)
+
+	if err != nil {
+		t.Fatalf("expected no error on NewBukuDB(), got '%v'", err)
+	}
+
+	for range 2 {
+		if err := db.Remove(1); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := db.EmptyTrash(); err != nil {
+		t.Fatalf("expected no error on EmptyTrash(), got '%v'", err)
+	}
+	if trashed, _ := db.TrashList(); len(trashed) != 0 {
+		t.Errorf("expected empty trash, got %v", trashed)
+	}
+	if db.Len() != 2 {
+		t.Errorf("expected length 2, got %d", db.Len())
+	}
+}
@@ -0,0 +1,119 @@
+package bukudb
+
+import (
+	"net/url"
+	"strings"
+)
+
+// opaqueSchemes are URL schemes that are not followed by "//" and never
+// describe a host, anything else without "://" is treated as a bare host.
+var opaqueSchemes = []string{"mailto", "magnet", "data", "javascript", "tel", "about"}
+
+// CleanURL returns a short, human readable form of rawURL for display and
+// search: the scheme and a leading "www." are removed, mailto links become
+// the address, and magnet/data links become a short label.
+func CleanURL(rawURL string) string {
+	rawURL = strings.TrimSpace(rawURL)
+
+	if scheme, rest, ok := splitOpaqueScheme(rawURL); ok {
+		switch scheme {
+		case "mailto":
+			address, _, _ := strings.Cut(rest, "?")
+			if unescaped, err := url.PathUnescape(address); err == nil {
+				address = unescaped
+			}
+			return address
+		case "magnet":
+			if q, err := url.ParseQuery(strings.TrimPrefix(rest, "?")); err == nil {
+				if name := q.Get("dn"); name != "" {
+					return "magnet: " + name
+				}
+			}
+			return "magnet link"
+		case "data":
+			mediaType, _, _ := strings.Cut(rest, ",")
+			mediaType, _, _ = strings.Cut(mediaType, ";")
+			if mediaType == "" {
+				mediaType = "text/plain"
+			}
+			return "data: " + mediaType
+		default:
+			return rawURL
+		}
+	}
+
+	parsedURL, err := parseHierarchical(rawURL)
+	if err != nil {
+		return rawURL
+	}
+	parsedURL.Scheme = ""
+	parsedURL.Host = strings.TrimPrefix(parsedURL.Host, "www.")
+	return strings.TrimPrefix(parsedURL.String(), "//")
+}
+
+// URLHost returns the lower cased host of rawURL without port and leading
+// "www.", or an empty string if rawURL has no host.
+func URLHost(rawURL string) string {
+	rawURL = strings.TrimSpace(rawURL)
+	if _, _, ok := splitOpaqueScheme(rawURL); ok {
+		return ""
+	}
+
+	parsedURL, err := parseHierarchical(rawURL)
+	if err != nil {
+		return ""
+	}
+	return strings.TrimPrefix(strings.ToLower(parsedURL.Hostname()), "www.")
+}
+
+// URLScheme returns the lower cased scheme of rawURL, or an empty string if
+// rawURL is a bare host like "example.com" or "localhost:3000".
+func URLScheme(rawURL string) string {
+	scheme, rest, ok := strings.Cut(strings.TrimSpace(rawURL), ":")
+	if !ok || !isScheme(scheme) {
+		return ""
+	}
+	port, _, _ := strings.Cut(rest, "/")
+	if port != "" && strings.Trim(port, "0123456789") == "" {
+		return ""
+	}
+	return strings.ToLower(scheme)
+}
+
+// isScheme reports whether s is a valid URL scheme as defined by RFC 3986.
+func isScheme(s string) bool {
+	for i, r := range s {
+		switch {
+		case 'a' <= r && r <= 'z', 'A' <= r && r <= 'Z':
+		case i > 0 && ('0' <= r && r <= '9' || r == '+' || r == '-' || r == '.'):
+		default:
+			return false
+		}
+	}
+	return s != ""
+}
+
+// splitOpaqueScheme splits rawURL into its scheme and the rest if it uses
+// one of the opaqueSchemes.
+func splitOpaqueScheme(rawURL string) (string, string, bool) {
+	scheme, rest, ok := strings.Cut(rawURL, ":")
+	if !ok {
+		return "", "", false
+	}
+	scheme = strings.ToLower(scheme)
+	for _, s := range opaqueSchemes {
+		if scheme == s {
+			return scheme, rest, true
+		}
+	}
+	return "", "", false
+}
+
+// parseHierarchical parses rawURL, treating it as a bare host (and path)
+// when it has no "scheme://" prefix.
+func parseHierarchical(rawURL string) (*url.URL, error) {
+	if !strings.Contains(rawURL, "://") {
+		rawURL = "//" + strings.TrimPrefix(rawURL, "//")
+	}
+	return url.Parse(rawURL)
+}
@@ -0,0 +1,86 @@
+package bukudb
+
+import "testing"
+
+func Test_CleanURL(t *testing.T) {
+	tests := []struct {
+		name     string
+		rawURL   string
+		expected string
+	}{
+		{"https", "https://www.google.com", "google.com"},
+		{"https with path", "https://www.a.com/path?q=1", "a.com/path?q=1"},
+		{"http subdomain", "http://docs.example.org/x", "docs.example.org/x"},
+		{"mailto", "mailto:someone@example.com", "someone@example.com"},
+		{"mailto with query", "mailto:someone@example.com?subject=hi", "someone@example.com"},
+		{"magnet with name", "magnet:?xt=urn:btih:abc&dn=Some+File", "magnet: Some File"},
+		{"magnet without name", "magnet:?xt=urn:btih:abc", "magnet link"},
+		{"data", "data:text/html;base64,PGgxPg==", "data: text/html"},
+		{"data without media type", "data:,hello", "data: text/plain"},
+		{"bare host", "example.com", "example.com"},
+		{"bare host with www and path", "www.example.com/a/b", "example.com/a/b"},
+		{"bare host with port", "localhost:3000/app", "localhost:3000/app"},
+		{"ip with port", "http://192.168.1.1:8080/admin", "192.168.1.1:8080/admin"},
+		{"bare ip with port", "192.168.1.1:8080", "192.168.1.1:8080"},
+		{"ipv6", "http://[::1]:8080/", "[::1]:8080/"},
+		{"javascript", "javascript:alert(1)", "javascript:alert(1)"},
+		{"surrounding whitespace", "  https://www.a.com  ", "a.com"},
+	}
+
+	for _, tt := range tests {
+		if actual := CleanURL(tt.rawURL); actual != tt.expected {
+			t.Errorf("%s: expected CleanURL('%s') '%s', got '%s'",
+				tt.name, tt.rawURL, tt.expected, actual)
+		}
+	}
+}
+
+func Test_URLHost(t *testing.T) {
+	tests := []struct {
+		name     string
+		rawURL   string
+		expected string
+	}{
+		{"https", "https://www.GitHub.com/user/repo", "github.com"},
+		{"bare host", "example.com/a", "example.com"},
+		{"ip with port", "http://192.168.1.1:8080/admin", "192.168.1.1"},
+		{"bare host with port", "localhost:3000", "localhost"},
+		{"mailto", "mailto:someone@example.com", ""},
+		{"magnet", "magnet:?xt=urn:btih:abc", ""},
+		{"empty", "", ""},
+	}
+
+	for _, tt := range tests {
+		if actual := URLHost(tt.rawURL); actual != tt.expected {
+			t.Errorf("%s: expected URLHost('%s') '%s', got '%s'",
+				tt.name, tt.rawURL, tt.expected, actual)
+		}
+	}
+}
+
+func Test_URLScheme(t *testing.T) {
+	tests := []struct {
+		name     string
+		rawURL   string
+		expected string
+	}{
+		{"https", "https://www.a.com", "https"},
+		{"upper case", "HTTP://a.com", "http"},
+		{"javascript", "javascript:alert(1)", "javascript"},
+		{"data", " data:text/html,<b>hi</b>", "data"},
+		{"mailto", "mailto:someone@example.com", "mailto"},
+		{"custom", "web+app://x", "web+app"},
+		{"bare host", "example.com/a:b", ""},
+		{"bare host with port", "localhost:3000/app", ""},
+		{"bare ip with port", "192.168.1.1:8080", ""},
+		{"invalid scheme", "1http://a.com", ""},
+		{"empty", "", ""},
+	}
+
+	for _, tt := range tests {
+		if actual := URLScheme(tt.rawURL); actual != tt.expected {
+			t.Errorf("%s: expected URLScheme('%s') '%s', got '%s'",
+				tt.name, tt.rawURL, tt.expected, actual)
+		}
+	}
+}
@@ -0,0 +1,111 @@
+package bukudb
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+	"time"
+)
+
+// visitsTable counts how often each bookmark was opened with robuku by
+// bookmark ID, it only exists once a bookmark has been opened.
+const visitsTable = "robuku_visits"
+
+const visitsSchema = `CREATE TABLE IF NOT EXISTS ` + visitsTable + ` (
+    id INTEGER PRIMARY KEY,
+    count INTEGER NOT NULL,
+    last_opened INTEGER NOT NULL
+);`
+
+// Visits is how often a bookmark was opened with robuku.
+type Visits struct {
+	// Count of the times the bookmark was opened, 0 if never.
+	Count int
+
+	// Last is when the bookmark was last opened.
+	Last time.Time
+}
+
+// RecordVisit counts the bookmark with the given ID as opened at the given
+// time, creating the visits table if needed.
+func (db *BukuDB) RecordVisit(id uint16, at time.Time) error {
+	db.mu.Lock()
+	defer db.mu.Unlock()
+
+	if id < 1 || int(id) > db.len {
+		return fmt.Errorf("id %d out of range (1-%d)", id, db.len)
+	}
+	if _, err := db.conn.Exec(visitsSchema); err != nil {
+		return fmt.Errorf("failed to create visits table: %w", err)
+	}
+	_, err := db.conn.Exec(`INSERT INTO `+visitsTable+` (id, count, last_opened) VALUES (?, 1, ?)
+		ON CONFLICT (id) DO UPDATE SET count = count + 1, last_opened = excluded.last_opened`,
+		id, at.Unix())
+	if err != nil {
+		return fmt.Errorf("failed to record visit: %w", err)
+	}
+	return nil
+}
+
+// GetVisits returns how often the bookmark with the given ID was opened,
+// the zero Visits if never.
+func (db *BukuDB) GetVisits(id uint16) (Visits, error) {
+	db.mu.Lock()
+	defer db.mu.Unlock()
+
+	ok, err := hasTable(db.conn, visitsTable)
+	if err != nil || !ok {
+		return Visits{}, err
+	}
+
+	var v Visits
+	var last int64
+	err = db.conn.QueryRow(`SELECT count, last_opened FROM `+visitsTable+` WHERE id = ?`, id).
+		Scan(&v.Count, &last)
+	if errors.Is(err, sql.ErrNoRows) {
+		return Visits{}, nil
+	}
+	if err != nil {
+		return Visits{}, fmt.Errorf("failed to query visits: %w", err)
+	}
+	v.Last = time.Unix(last, 0)
+	return v, nil
+}
+
+// ResetVisits forgets the visits of the bookmark with the given ID, or of
+// all bookmarks if id is 0.
+func (db *BukuDB) ResetVisits(id uint16) error {
+	db.mu.Lock()
+	defer db.mu.Unlock()
+
+	if int(id) > db.len {
+		return fmt.Errorf("id %d out of range (1-%d)", id, db.len)
+	}
+	ok, err := hasTable(db.conn, visitsTable)
+	if err != nil || !ok {
+		return err
+	}
+
+	if id == 0 {
+		_, err = db.conn.Exec(`DELETE FROM ` + visitsTable)
+	} else {
+		_, err = db.conn.Exec(`DELETE FROM `+visitsTable+` WHERE id = ?`, id)
+	}
+	if err != nil {
+		return fmt.Errorf("failed to reset visits: %w", err)
+	}
+	return nil
+}
+
+// countVisited returns the number of bookmarks opened at least once.
+func countVisited(conn *loggedDB) (int, error) {
+	ok, err := hasTable(conn, visitsTable)
+	if err != nil || !ok {
+		return 0, err
+	}
+	var n int
+	if err := conn.QueryRow(`SELECT COUNT(*) FROM ` + visitsTable).Scan(&n); err != nil {
+		return 0, fmt.Errorf("failed to count visited bookmarks: %w", err)
+	}
+	return n, nil
+}
@@ -0,0 +1,78 @@
+package bukudb
+
+import (
+	"testing"
+	"time"
+)
+
+func Test_Visits(t *testing.T) {
+	createTestDb(t)
+	db, err := NewBukuDB(sqlTestDbPath)
+	defer cleanUpTestDB(t, db)
+
+	if err != nil {
+		t.Fatalf("expected no error on NewBukuDB(), got '%v'", err)
+	}
+
+	// never opened
+	v, err := db.GetVisits(1)
+	if err != nil || v.Count != 0 {
+		t.Fatalf("expected no visits, got %v and error '%v'", v, err)
+	}
+	if ok, _ := hasTable(db.conn, visitsTable); ok {
+		t.Errorf("expected GetVisits() to not create the table")
+	}
+	if err := db.ResetVisits(0); err != nil {
+		t.Errorf("expected no error on ResetVisits() without table, got '%v'", err)
+	}
+
+	first := time.Unix(1700000000, 0)
+	last := time.Unix(1700086400, 0)
+	for _, visit := range []struct {
+		id uint16
+		at time.Time
+	}{{2, first}, {2, last}, {3, first}, {4, first}} {
+		if err := db.RecordVisit(visit.id, visit.at); err != nil {
+			t.Fatalf("expected no error on RecordVisit(), got '%v'", err)
+		}
+	}
+	if err := db.RecordVisit(9, first); err == nil {
+		t.Errorf("expected error on RecordVisit() of an unknown id, got nil")
+	}
+
+	v, _ = db.GetVisits(2)
+	if v.Count != 2 || !v.Last.Equal(last) {
+		t.Errorf("expected 2 visits last at %v, got %v", last, v)
+	}
+	if s, _ := db.Stats(); s.Visited != 3 {
+		t.Errorf("expected 3 visited bookmarks, got %d", s.Visited)
+	}
+
+	// visits follow their bookmarks when IDs shift
+	if err := db.Remove(1); err != nil {
+		t.Fatal(err)
+	}
+	if v, _ := db.GetVisits(1); v.Count != 2 {
+		t.Errorf("expected 2 visits of #1 after remove, got %v", v)
+	}
+
+	if err := db.ResetVisits(1); err != nil {
+		t.Fatalf("expected no error on ResetVisits(), got '%v'", err)
+	}
+	if v, _ := db.GetVisits(1); v.Count != 0 {
+		t.Errorf("expected no visits of #1 after reset, got %v", v)
+	}
+	if v, _ := db.GetVisits(2); v.Count != 1 {
+		t.Errorf("expected #2 to keep its visit, got %v", v)
+	}
+
+	if err := db.ResetVisits(0); err != nil {
+		t.Fatalf("expected no error on ResetVisits(), got '%v'", err)
+	}
+	if s, _ := db.Stats(); s.Visited != 0 {
+		t.Errorf("expected no visited bookmarks after reset, got %d", s.Visited)
+	}
+	if err := db.ResetVisits(9); err == nil {
+		t.Errorf("expected error on ResetVisits() of an unknown id, got nil")
+	}
+}
@@ -0,0 +1,203 @@
+package main
+
+import (
+	"os"
+	"strings"
+
+	"github.com/VannRR/robuku/bukudb"
+)
+
+// argKind is what a shell completes for an argument.
+type argKind int
+
+const (
+	argNone    argKind = iota // nothing, the user types it
+	argFile                   // a file name
+	argChoices                // one of the choices
+)
+
+// cliOption is an optional flag of a cliCommand.
+type cliOption struct {
+	flag string
+
+	// value is the placeholder of the value in the usage, like "REPORT".
+	value string
+
+	// joined options take their value as "flag=VALUE".
+	joined bool
+
+	kind    argKind
+	choices []string
+	help    string
+}
+
+// cliCommand is a command line mode of robuku, selected by flag as the first
+// argument. The table of them drives main, the shell completions, and the
+// man page.
+type cliCommand struct {
+	flag string
+
+	// arg is the placeholder of the positional argument, like "FILE", and
+	// optional if it is in brackets.
+	arg     string
+	kind    argKind
+	choices []string
+
+	options []cliOption
+	help    string
+
+	// run runs the command with the arguments after flag and returns the
+	// exit code.
+	run func(args []string) int
+}
+
+// usage returns the arguments of c after its flag, like
+// "FILE [--report REPORT]".
+func (c cliCommand) usage() string {
+	var parts []string
+	if c.arg != "" {
+		parts = append(parts, c.arg)
+	}
+	for _, o := range c.options {
+		parts = append(parts, "["+o.usage()+"]")
+	}
+	return strings.Join(parts, " ")
+}
+
+// usage returns the flag of o with its value, like "--on-conflict=POLICY".
+func (o cliOption) usage() string {
+	switch {
+	case o.value == "":
+		return o.flag
+	case o.joined:
+		return o.flag + "=" + o.value
+	default:
+		return o.flag + " " + o.value
+	}
+}
+
+// conflictPolicies are the values of --on-conflict.
+var conflictPolicies = []string{
+	string(bukudb.ConflictSkip), string(bukudb.ConflictOverwrite), string(bukudb.ConflictMergeTags),
+}
+
+// completionShells are the shells --completion writes scripts for.
+var completionShells = []string{"bash", "zsh", "fish"}
+
+// cliCommands returns the command line modes of robuku.
+func cliCommands() []cliCommand {
+	onConflict := cliOption{
+		flag: onConflictFlag, value: "POLICY", joined: true, kind: argChoices, choices: conflictPolicies,
+		help: "what to do with bookmarks already in the database, skip (the default), overwrite, or merge-tags",
+	}
+	return []cliCommand{
+		{
+			flag: addURLFlag, arg: "[URL]",
+			help: "open rofi on the add screen with URL, or the one in the clipboard, filled in",
+			run:  runAddURL,
+		},
+		{
+			flag: importFlag, arg: "FILE", kind: argFile,
+			options: []cliOption{
+				{flag: reportFlag, value: "REPORT", kind: argFile,
+					help: "write the outcome of every bookmark to REPORT as TSV"},
+				onConflict,
+			},
+			help: "add the bookmarks of a browser's HTML export or a buku JSON export",
+			run:  func(args []string) int { return runImport(args, os.Stdout) },
+		},
+		{
+			flag: importTextFlag, arg: "FILE", kind: argFile,
+			options: []cliOption{
+				{flag: tagsFlag, value: "TAG,...", help: "tag the added bookmarks"},
+				onConflict,
+			},
+			help: "add the URLs listed in FILE, one per line",
+			run:  func(args []string) int { return runImportText(args, os.Stdout) },
+		},
+		{
+			flag: exportFlag, arg: "FILE", kind: argFile,
+			options: []cliOption{
+				{flag: tagsFlag, value: "TAG,...", help: "export only the bookmarks with every TAG"},
+			},
+			help: "export the bookmarks as HTML, JSON, Markdown, or an Atom feed by the extension of FILE",
+			run:  func(args []string) int { return runExport(args, os.Stdout) },
+		},
+		{
+			flag: readingListFlag, arg: "TAG",
+			options: []cliOption{
+				{flag: outFlag, value: "FILE", kind: argFile,
+					help: "write the page to FILE instead of standard output"},
+			},
+			help: "write the bookmarks tagged TAG as a standalone HTML page to share",
+			run:  func(args []string) int { return runReadingList(args, os.Stdout) },
+		},
+		{
+			flag: exportMetaFlag,
+			help: "print the data robuku keeps besides the bookmarks as JSON",
+			run:  func([]string) int { return runExportMeta(os.Stdout) },
+		},
+		{
+			flag: importMetaFlag,
+			help: "restore the data printed by " + exportMetaFlag + " from standard input",
+			run:  func([]string) int { return runImportMeta(os.Stdin, os.Stdout) },
+		},
+		{
+			flag: statsJSONFlag,
+			help: "print the statistics with the counts of every tag and domain as JSON",
+			run:  func([]string) int { return runStatsJSON(os.Stdout) },
+		},
+		{
+			flag: httpsUpgradeFlag,
+			help: "move http bookmarks to https where the https site is reachable",
+			run:  func([]string) int { return runHTTPSUpgrade(os.Stdout) },
+		},
+		{
+			flag: fixTagsFlag,
+			options: []cliOption{
+				{flag: dryRunFlag, help: "only list the bookmarks whose tags would be rewritten"},
+			},
+			help: "rewrite tags written by other programs to buku's \",tag1,tag2,\" format",
+			run:  func(args []string) int { return runFixTags(args, os.Stdout) },
+		},
+		{
+			flag: rewriteURLFlag, arg: "OLD NEW",
+			options: []cliOption{
+				{flag: dryRunFlag, help: "only list the bookmarks whose URL would be rewritten"},
+			},
+			help: "replace OLD by NEW at the start of every URL, like a site that moved",
+			run:  func(args []string) int { return runRewriteURL(args, os.Stdout) },
+		},
+		{
+			flag: selfTestFlag,
+			help: "check the installed binary against a scratch database without rofi",
+			run:  func([]string) int { return runSelfTest(os.Stdout) },
+		},
+		{
+			flag: replayFlag, arg: "TRACE [DB]", kind: argFile,
+			help: "replay a trace file against a copy of the database",
+			run:  func(args []string) int { return runReplay(args, os.Stdout) },
+		},
+		{
+			flag: completionFlag, arg: "SHELL", kind: argChoices, choices: completionShells,
+			help: "print the completion script of SHELL, bash, zsh, or fish",
+			run:  func(args []string) int { return runCompletion(args, os.Stdout) },
+		},
+		{
+			flag: manFlag,
+			help: "print the manual page as roff",
+			run:  func([]string) int { return runMan(os.Stdout) },
+		},
+	}
+}
+
+// selectedCommand returns the command robuku was started with from the
+// command line, ok is false when it runs as a rofi script.
+func selectedCommand() (cmd cliCommand, ok bool) {
+	for _, c := range cliCommands() {
+		if isCommand(c.flag) {
+			return c, true
+		}
+	}
+	return cliCommand{}, false
+}
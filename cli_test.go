@@ -0,0 +1,69 @@
+package main
+
+import (
+	"os"
+	"testing"
+
+	"github.com/VannRR/robuku/bukudb"
+)
+
+func Test_cliCommands(t *testing.T) {
+	seen := make(map[string]bool)
+	for _, c := range cliCommands() {
+		if seen[c.flag] {
+			t.Errorf("expected flags to be unique, got '%s' twice", c.flag)
+		}
+		seen[c.flag] = true
+		if c.help == "" || c.run == nil {
+			t.Errorf("expected '%s' to have help and run", c.flag)
+		}
+		if (c.kind == argChoices) != (len(c.choices) > 0) {
+			t.Errorf("expected '%s' to have choices only if it completes them", c.flag)
+		}
+		for _, o := range c.options {
+			if o.flag != onConflictFlag {
+				continue
+			}
+			for _, p := range o.choices {
+				if _, err := bukudb.ParseConflictPolicy(p); err != nil {
+					t.Errorf("expected policy '%s' to parse, got '%v'", p, err)
+				}
+			}
+		}
+	}
+}
+
+func Test_cliCommand_usage(t *testing.T) {
+	var usages []string
+	for _, c := range cliCommands() {
+		switch c.flag {
+		case importFlag, exportMetaFlag:
+			usages = append(usages, c.usage())
+		}
+	}
+	expected := []string{"FILE [--report REPORT] [--on-conflict=POLICY]", ""}
+	if len(usages) != 2 || usages[0] != expected[0] || usages[1] != expected[1] {
+		t.Errorf("expected usages %q, got %q", expected, usages)
+	}
+}
+
+func Test_selectedCommand(t *testing.T) {
+	args := os.Args
+	defer func() { os.Args = args }()
+
+	os.Args = []string{"robuku", manFlag}
+	if cmd, ok := selectedCommand(); !ok || cmd.flag != manFlag {
+		t.Errorf("expected '%s' to be selected, got '%s' and %v", manFlag, cmd.flag, ok)
+	}
+
+	os.Args = []string{"robuku"}
+	if _, ok := selectedCommand(); ok {
+		t.Errorf("expected no command without arguments")
+	}
+
+	os.Args = []string{"robuku", manFlag}
+	t.Setenv(rofiRetvEnvVar, "1")
+	if _, ok := selectedCommand(); ok {
+		t.Errorf("expected no command when ran by rofi")
+	}
+}
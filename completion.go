@@ -0,0 +1,223 @@
+package main
+
+import (
+	"fmt"
+	"io"
+	"log"
+	"slices"
+	"strings"
+)
+
+// runCompletion prints the completion script of the shell in args to out.
+// It returns the exit code.
+func runCompletion(args []string, out io.Writer) int {
+	if len(args) != 1 || !slices.Contains(completionShells, args[0]) {
+		log.Println("ERROR", fmt.Errorf("usage: robuku %s %s", completionFlag,
+			strings.Join(completionShells, "|")))
+		return 1
+	}
+	commands := cliCommands()
+	switch args[0] {
+	case "bash":
+		writeBashCompletion(out, commands)
+	case "zsh":
+		writeZshCompletion(out, commands)
+	case "fish":
+		writeFishCompletion(out, commands)
+	}
+	return 0
+}
+
+// bashCompgen returns the compgen call completing kind, "" for argNone.
+func bashCompgen(kind argKind, choices []string) string {
+	switch kind {
+	case argFile:
+		return `COMPREPLY=($(compgen -f -- "$cur"))`
+	case argChoices:
+		return `COMPREPLY=($(compgen -W "` + strings.Join(choices, " ") + `" -- "$cur"))`
+	}
+	return ""
+}
+
+// writeBashCompletion writes the bash completion script of commands to out.
+func writeBashCompletion(out io.Writer, commands []cliCommand) {
+	var flags []string
+	for _, c := range commands {
+		flags = append(flags, c.flag)
+	}
+
+	fmt.Fprintf(out, "# bash completion for robuku, generated by robuku %s bash\n\n", completionFlag)
+	fmt.Fprintln(out, `_robuku() {
+    local cur="${COMP_WORDS[COMP_CWORD]}" prev="${COMP_WORDS[COMP_CWORD-1]}"
+    # bash splits "--flag=value" at the "="
+    if [ "$cur" = "=" ]; then
+        prev="$prev="
+        cur=""
+    elif [ "$prev" = "=" ]; then
+        prev="${COMP_WORDS[COMP_CWORD-2]}="
+    fi
+
+    if [ "$COMP_CWORD" -eq 1 ]; then`)
+	fmt.Fprintf(out, "        COMPREPLY=($(compgen -W \"%s\" -- \"$cur\"))\n", strings.Join(flags, " "))
+	fmt.Fprintln(out, `        return
+    fi
+
+    case "${COMP_WORDS[1]}" in`)
+	for _, c := range commands {
+		if c.kind == argNone && len(c.options) == 0 {
+			continue
+		}
+		fmt.Fprintf(out, "    %s)\n", c.flag)
+		if len(c.options) > 0 {
+			var values, names []string
+			for _, o := range c.options {
+				name := o.flag
+				if o.joined {
+					name += "="
+				}
+				names = append(names, name)
+				if o.value != "" {
+					compgen := bashCompgen(o.kind, o.choices)
+					if compgen != "" {
+						compgen += "; "
+					}
+					values = append(values, fmt.Sprintf("            %s) %sreturn ;;", name, compgen))
+				}
+			}
+			if len(values) > 0 {
+				fmt.Fprintln(out, `        case "$prev" in`)
+				for _, v := range values {
+					fmt.Fprintln(out, v)
+				}
+				fmt.Fprintln(out, "        esac")
+			}
+			fmt.Fprintln(out, `        if [[ "$cur" == -* ]]; then`)
+			fmt.Fprintf(out, "            COMPREPLY=($(compgen -W \"%s\" -- \"$cur\"))\n", strings.Join(names, " "))
+			fmt.Fprintln(out, `            [[ "${COMPREPLY[0]}" == *= ]] && compopt -o nospace`)
+			fmt.Fprintln(out, "            return\n        fi")
+		}
+		if compgen := bashCompgen(c.kind, c.choices); compgen != "" {
+			fmt.Fprintln(out, "        "+compgen)
+		}
+		fmt.Fprintln(out, "        ;;")
+	}
+	fmt.Fprintln(out, `    esac
+}
+
+complete -F _robuku robuku`)
+}
+
+// zshEscaper escapes the characters special in the descriptions of zsh's
+// _describe and _arguments specs.
+var zshEscaper = strings.NewReplacer(`:`, `\:`, `[`, `\[`, `]`, `\]`, `'`, `'\''`)
+
+// zshAction returns the _arguments action completing kind.
+func zshAction(kind argKind, choices []string) string {
+	switch kind {
+	case argFile:
+		return "_files"
+	case argChoices:
+		return "(" + strings.Join(choices, " ") + ")"
+	}
+	return " "
+}
+
+// writeZshCompletion writes the zsh completion script of commands to out.
+func writeZshCompletion(out io.Writer, commands []cliCommand) {
+	fmt.Fprintln(out, "#compdef robuku")
+	fmt.Fprintf(out, "# zsh completion for robuku, generated by robuku %s zsh\n\n", completionFlag)
+	fmt.Fprintln(out, `_robuku() {
+    if (( CURRENT == 2 )); then
+        local -a commands
+        commands=(`)
+	for _, c := range commands {
+		fmt.Fprintf(out, "            '%s:%s'\n", zshEscaper.Replace(c.flag), zshEscaper.Replace(c.help))
+	}
+	fmt.Fprintln(out, `        )
+        _describe 'command' commands
+        return
+    fi
+
+    local command=$words[2]
+    shift words
+    (( CURRENT-- ))
+    case $command in`)
+	for _, c := range commands {
+		if c.kind == argNone && len(c.options) == 0 {
+			continue
+		}
+		fmt.Fprintf(out, "    %s)\n        _arguments", c.flag)
+		if c.kind != argNone {
+			for i, arg := range strings.Fields(strings.ToLower(c.arg)) {
+				optional := ""
+				if strings.HasPrefix(arg, "[") {
+					optional = ":"
+				}
+				fmt.Fprintf(out, " \\\n            '%d:%s%s:%s'", i+1, optional,
+					strings.Trim(arg, "[]"), zshAction(c.kind, c.choices))
+			}
+		}
+		for _, o := range c.options {
+			spec := o.flag
+			if o.joined {
+				spec += "="
+			}
+			spec += "[" + zshEscaper.Replace(o.help) + "]"
+			if o.value != "" {
+				spec += ":" + zshEscaper.Replace(strings.ToLower(o.value)) + ":" + zshAction(o.kind, o.choices)
+			}
+			fmt.Fprintf(out, " \\\n            '%s'", spec)
+		}
+		fmt.Fprintln(out, "\n        ;;")
+	}
+	fmt.Fprintln(out, `    esac
+}
+
+_robuku "$@"`)
+}
+
+// fishQuote returns s as a single quoted fish string.
+func fishQuote(s string) string {
+	return "'" + strings.NewReplacer(`\`, `\\`, `'`, `\'`).Replace(s) + "'"
+}
+
+// fishCompletion returns the arguments of fish's complete completing kind,
+// for the value of an option if option.
+func fishCompletion(kind argKind, choices []string, option bool) string {
+	switch {
+	case kind == argFile && option:
+		return "-r -F"
+	case kind == argFile:
+		return "-F"
+	case kind == argChoices:
+		return "-x -a " + fishQuote(strings.Join(choices, " "))
+	case option:
+		return "-x"
+	}
+	return ""
+}
+
+// writeFishCompletion writes the fish completion script of commands to out.
+func writeFishCompletion(out io.Writer, commands []cliCommand) {
+	fmt.Fprintf(out, "# fish completion for robuku, generated by robuku %s fish\n\n", completionFlag)
+	fmt.Fprintln(out, `function __robuku_command
+    set -l tokens (commandline -opc)
+    test (count $tokens) -ge 2; and test "$tokens[2]" = $argv[1]
+end
+
+complete -c robuku -f`)
+	for _, c := range commands {
+		fmt.Fprintf(out, "complete -c robuku -n 'test (count (commandline -opc)) -eq 1' -l %s -d %s\n",
+			strings.TrimPrefix(c.flag, "--"), fishQuote(c.help))
+	}
+	for _, c := range commands {
+		cond := fishQuote("__robuku_command " + c.flag)
+		if c.kind != argNone {
+			fmt.Fprintf(out, "complete -c robuku -n %s %s\n", cond, fishCompletion(c.kind, c.choices, false))
+		}
+		for _, o := range c.options {
+			fmt.Fprintf(out, "complete -c robuku -n %s -l %s %s -d %s\n", cond,
+				strings.TrimPrefix(o.flag, "--"), fishCompletion(o.kind, o.choices, o.value != ""), fishQuote(o.help))
+		}
+	}
+}
@@ -0,0 +1,46 @@
+package main
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/VannRR/robuku/internal/golden"
+)
+
+func Test_runCompletion(t *testing.T) {
+	for _, shell := range completionShells {
+		t.Run(shell, func(t *testing.T) {
+			var out bytes.Buffer
+			if code := runCompletion([]string{shell}, &out); code != 0 {
+				t.Fatalf("expected exit code 0, got %d", code)
+			}
+			golden.Check(t, "completion_"+shell, out.String())
+		})
+	}
+
+	for _, args := range [][]string{nil, {"ksh"}, {"bash", "zsh"}} {
+		var out bytes.Buffer
+		if code := runCompletion(args, &out); code != 1 || out.Len() != 0 {
+			t.Errorf("args %v: expected exit code 1 and no output, got %d and '%s'", args, code, out.String())
+		}
+	}
+}
+
+func Test_writeBashCompletion_Choices(t *testing.T) {
+	var out bytes.Buffer
+	writeBashCompletion(&out, []cliCommand{{
+		flag: "--sort", arg: "ORDER", kind: argChoices, choices: []string{"asc", "desc"},
+	}})
+	want := `    --sort)
+        COMPREPLY=($(compgen -W "asc desc" -- "$cur"))
+        ;;`
+	if !bytes.Contains(out.Bytes(), []byte(want)) {
+		t.Errorf("expected script to contain\n%s\ngot\n%s", want, out.String())
+	}
+}
+
+func Test_zshEscaper(t *testing.T) {
+	if got := zshEscaper.Replace(`a:b [c] it's`); got != `a\:b \[c\] it'\''s` {
+		t.Errorf("expected escaped description, got '%s'", got)
+	}
+}
@@ -0,0 +1,745 @@
+// config, reads robuku settings from the environment
+package config
+
+import (
+	"errors"
+	"fmt"
+	"io/fs"
+	"log"
+	"math"
+	"os"
+	"path/filepath"
+	"regexp"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const (
+	BrowserEnvVar       = "ROBUKU_BROWSER"
+	MaxTitleLenEnvVar   = "ROBUKU_MAX_TITLE_LEN"
+	MaxURLLenEnvVar     = "ROBUKU_MAX_URL_LEN"
+	MaxCommentLenEnvVar = "ROBUKU_MAX_COMMENT_LEN"
+	MaxTagLenEnvVar     = "ROBUKU_MAX_TAG_LEN"
+	MaxBookmarksEnvVar  = "ROBUKU_MAX_BOOKMARKS"
+	DebugTimingsEnvVar  = "ROBUKU_DEBUG_TIMINGS"
+	SlowQueryEnvVar     = "ROBUKU_SLOW_QUERY_MS"
+
+	DBBusyTimeoutEnvVar  = "ROBUKU_DB_BUSY_TIMEOUT"
+	DBTimeoutEnvVar      = "ROBUKU_DB_TIMEOUT"
+	DBJournalModeEnvVar  = "ROBUKU_DB_JOURNAL_MODE"
+	DBForeignKeysEnvVar  = "ROBUKU_DB_FOREIGN_KEYS"
+	DBMaxOpenConnsEnvVar = "ROBUKU_DB_MAX_OPEN_CONNS"
+	FTSEnvVar            = "ROBUKU_FTS"
+	URLSchemesEnvVar     = "ROBUKU_URL_SCHEMES"
+	ClipboardEnvVar      = "ROBUKU_CLIPBOARD"
+	RememberLastEnvVar   = "ROBUKU_REMEMBER_LAST"
+	StyleLabelEnvVar     = "ROBUKU_STYLE_LABEL"
+	StyleExampleEnvVar   = "ROBUKU_STYLE_EXAMPLE"
+	StyleCurrentEnvVar   = "ROBUKU_STYLE_CURRENT"
+	DryRunEnvVar         = "ROBUKU_DRY_RUN"
+	ExportDirEnvVar      = "ROBUKU_EXPORT_DIR"
+	SnippetsEnvVar       = "ROBUKU_SNIPPETS"
+	IndexModeEnvVar      = "ROBUKU_INDEX_MODE"
+	ConfirmEnvVar        = "ROBUKU_CONFIRM"
+	RowPrefixesEnvVar    = "ROBUKU_ROW_PREFIXES"
+	RichEntriesEnvVar    = "ROBUKU_RICH_ENTRIES"
+	InboxEnvVar          = "ROBUKU_INBOX"
+	FastDeleteEnvVar     = "ROBUKU_FAST_DELETE"
+	TagSigilEnvVar       = "ROBUKU_TAG_SIGIL"
+	FetchTitlesEnvVar    = "ROBUKU_FETCH_TITLES"
+	LaunchersEnvVar      = "ROBUKU_LAUNCHERS"
+	DebugEnvVar          = "ROBUKU_DEBUG"
+	CompactHintsEnvVar   = "ROBUKU_COMPACT_HINTS"
+	MaxEntriesEnvVar     = "ROBUKU_MAX_ENTRIES"
+	ModifyApplyEnvVar    = "ROBUKU_MODIFY_APPLY"
+	PlainEnvVar          = "ROBUKU_PLAIN"
+	TagSplitEnvVar       = "ROBUKU_TAG_SPLIT"
+	TagSuggestionsEnvVar = "ROBUKU_TAG_SUGGESTIONS"
+	AutoTagsEnvVar       = "ROBUKU_AUTO_TAGS"
+	AutoTagSourceEnvVar  = "ROBUKU_AUTO_TAG_SOURCE"
+	HiddenTagsEnvVar     = "ROBUKU_HIDDEN_TAGS"
+	TitleStripEnvVar     = "ROBUKU_TITLE_STRIP"
+	BrowserAltEnvVar     = "ROBUKU_BROWSER_ALT"
+	ReadOnlyEnvVar       = "ROBUKU_READONLY"
+	ProfilesEnvVar       = "ROBUKU_PROFILES"
+	ProfileEnvVar        = "ROBUKU_PROFILE"
+	RecentEnvVar         = "ROBUKU_RECENT"
+	LinesSmallEnvVar     = "ROBUKU_LINES_SMALL"
+	LinesFullEnvVar      = "ROBUKU_LINES_FULL"
+
+	// noColorEnvVar is https://no-color.org, any value enables Plain.
+	noColorEnvVar = "NO_COLOR"
+
+	xdgStateHomeEnvVar  = "XDG_STATE_HOME"
+	xdgConfigHomeEnvVar = "XDG_CONFIG_HOME"
+	xdgRuntimeDirEnvVar = "XDG_RUNTIME_DIR"
+)
+
+// Default field length limits, in characters. They keep the state passed
+// between rofi invocations small but do not bound it, a bookmark at every
+// limit has 4.6K characters and more bytes once serialized. Only input typed
+// in rofi is checked, imported bookmarks can be longer.
+const (
+	DefaultMaxTitleLen   = 512
+	DefaultMaxURLLen     = 2048
+	DefaultMaxCommentLen = 2048
+	DefaultMaxTagLen     = 128
+)
+
+// DefaultMaxBookmarks is the default maximum number of bookmarks, the same
+// as bukudb.MaxBookmarks.
+const DefaultMaxBookmarks = 1000
+
+// DefaultMaxEntries is the default maximum number of bookmarks listed at
+// once, above DefaultMaxBookmarks so only raised limits are capped.
+const DefaultMaxEntries = 5000
+
+// DefaultRecent is the default number of bookmarks listed by the recently
+// added view.
+const DefaultRecent = 20
+
+// DefaultTagSigil is put before the tags in the keywords of the bookmark
+// list, so "#go" matches only the bookmarks tagged go.
+const DefaultTagSigil = "#"
+
+// DefaultDBTimeout is how long slow database operations may take before they
+// are cancelled.
+const DefaultDBTimeout = time.Second
+
+// DefaultURLSchemes returns the URL schemes opened without confirmation, a
+// new slice on every call.
+func DefaultURLSchemes() []string {
+	return []string{"http", "https", "file", "mailto", "ftp", "magnet"}
+}
+
+// Launcher opens the bookmarks tagged Tag with Command instead of the
+// browser. "%u" in Command is replaced by the URL, which is appended if
+// Command has no "%u".
+type Launcher struct {
+	Tag     string
+	Command string
+}
+
+// TagSuggestion suggests Tags for the bookmarks of Site when adding them.
+// Site is a domain like "docs.python.org", matching its subdomains too, or a
+// site name like "python", matching it under any top level domain.
+type TagSuggestion struct {
+	Site string
+	Tags []string
+}
+
+// Profile is a named set of settings merged over the global ones when it is
+// active. Nil settings are inherited.
+type Profile struct {
+	Name       string
+	DBPath     string
+	Browser    *string
+	BrowserAlt *string
+	ReadOnly   *bool
+	HiddenTags *[]string
+}
+
+// ConfirmPolicy decides which actions ask for confirmation.
+type ConfirmPolicy string
+
+const (
+	// ConfirmNone asks for no confirmation.
+	ConfirmNone ConfirmPolicy = "none"
+	// ConfirmDelete asks before deleting a bookmark, the default.
+	ConfirmDelete ConfirmPolicy = "delete"
+	// ConfirmAll also asks before clearing all tags of a bookmark.
+	ConfirmAll ConfirmPolicy = "all"
+)
+
+// ModifyApply decides when the edits of the modify screen are written.
+type ModifyApply string
+
+const (
+	// ModifyApplyImmediate writes every edit right away, the default.
+	ModifyApplyImmediate ModifyApply = "immediate"
+	// ModifyApplyOnConfirm keeps the edits until they are applied together.
+	ModifyApplyOnConfirm ModifyApply = "on_confirm"
+)
+
+// TagSplit decides which characters separate the tags typed in a prompt.
+type TagSplit string
+
+const (
+	// TagSplitComma splits at commas only, so tags may contain spaces, the
+	// default like buku.
+	TagSplitComma TagSplit = "comma"
+	// TagSplitCommaSpace also splits at spaces, "machine learning" is two
+	// tags.
+	TagSplitCommaSpace TagSplit = "comma+space"
+)
+
+// Config holds the robuku settings.
+type Config struct {
+	// Browser used to open bookmarks, xdg-open is used if empty.
+	Browser string
+
+	// BrowserAlt is the second browser bookmarks can be opened with, no
+	// alternative is offered if empty.
+	BrowserAlt string
+
+	// ReadOnly refuses changes to the database.
+	ReadOnly bool
+
+	// MaxTitleLen is the maximum length of a title.
+	MaxTitleLen int
+
+	// MaxURLLen is the maximum length of a URL.
+	MaxURLLen int
+
+	// MaxCommentLen is the maximum length of a comment.
+	MaxCommentLen int
+
+	// MaxTagLen is the maximum length of a single tag.
+	MaxTagLen int
+
+	// MaxBookmarks is the maximum number of bookmarks, at most
+	// math.MaxUint16 since bookmark IDs are 16 bit.
+	MaxBookmarks int
+
+	// DebugTimings shows how long the database and rendering took.
+	DebugTimings bool
+
+	// SlowQuery is the duration above which statements are logged as
+	// warnings, 0 disables the warnings.
+	SlowQuery time.Duration
+
+	// DBBusyTimeout is how long a locked database is retried, 0 keeps the
+	// SQLite default.
+	DBBusyTimeout time.Duration
+
+	// DBTimeout is how long slow database operations may take before they
+	// are cancelled, 0 disables the timeout.
+	DBTimeout time.Duration
+
+	// DBJournalMode is the SQLite journal mode, empty keeps the default.
+	DBJournalMode string
+
+	// DBForeignKeys enables foreign key enforcement, nil keeps the default.
+	DBForeignKeys *bool
+
+	// DBMaxOpenConns is the maximum number of open database connections,
+	// 0 means unlimited.
+	DBMaxOpenConns int
+
+	// FTS enables the SQLite full text search index.
+	FTS bool
+
+	// URLSchemes are the lower cased URL schemes opened without confirmation.
+	URLSchemes []string
+
+	// Clipboard is the command text is piped to for copying, wl-copy or
+	// xclip is used if empty.
+	Clipboard string
+
+	// RememberLast highlights the last opened bookmark on startup.
+	RememberLast bool
+
+	// LastFile stores the last opened bookmark, empty if no state directory
+	// could be found.
+	LastFile string
+
+	// StyleLabel, StyleExample, and StyleCurrent are pango span attributes
+	// for the labels, example values, and current values in the message box.
+	StyleLabel   string
+	StyleExample string
+	StyleCurrent string
+
+	// DryRun reports changes to the database instead of writing them.
+	DryRun bool
+
+	// ExportDir is where exported bookmarks are written, the home directory
+	// if unset.
+	ExportDir string
+
+	// CommentSnippets are offered as comments in the comment prompts.
+	CommentSnippets []string
+
+	// IndexMode groups the bookmarks by initial behind jump entries.
+	IndexMode bool
+
+	// Confirm decides which actions ask for confirmation.
+	Confirm ConfirmPolicy
+
+	// RowPrefixes marks dead links with a text prefix instead of rofi's
+	// urgent row style, for themes that do not style urgent rows.
+	RowPrefixes bool
+
+	// RichEntries shows the entries of the add and modify screens with
+	// pango markup, like the placeholders of empty fields in italics.
+	RichEntries bool
+
+	// FetchTitles fetches the page titles of URLs imported without one.
+	FetchTitles bool
+
+	// Launchers open bookmarks by tag, the first one matching a tag of the
+	// bookmark is used.
+	Launchers []Launcher
+
+	// MaxEntries is the maximum number of bookmarks listed at once, the rest
+	// is counted in a last entry. Index mode lists all bookmarks.
+	MaxEntries int
+
+	// Recent is the number of bookmarks listed by the recently added view.
+	Recent int
+
+	// LinesSmall and LinesFull are the rofi list heights of the short
+	// screens, like add and modify, and of the others. Zero leaves the
+	// height to rofi.
+	LinesSmall int
+	LinesFull  int
+
+	// CompactHints shows only the most relevant hotkeys above the bookmark
+	// list, the others are on the help screen.
+	CompactHints bool
+
+	// ModifyApply decides when the edits of the modify screen are written.
+	ModifyApply ModifyApply
+
+	// Plain shows the message box as plain text, without pango markup.
+	Plain bool
+
+	// TagSplit decides which characters separate the tags typed in a
+	// prompt.
+	TagSplit TagSplit
+
+	// TagSuggestions suggest tags by the host of added bookmarks, before
+	// the built-in suggestions.
+	TagSuggestions []TagSuggestion
+
+	// AutoTags adds the suggested tags to bookmarks added without visiting
+	// the tags screen.
+	AutoTags bool
+
+	// AutoTagSource tags the bookmarks added with robuku with where they
+	// came from, like "via/cli".
+	AutoTagSource bool
+
+	// Inbox tags the bookmarks added with robuku "inbox" until they are
+	// triaged.
+	Inbox bool
+
+	// TagSigil is put before the tags in the keywords of the bookmark list,
+	// besides the bare tags. Empty leaves only the bare tags.
+	TagSigil string
+
+	// FastDelete deletes the highlighted bookmark on the delete hotkey
+	// without asking, the undo hotkey restores it.
+	FastDelete bool
+
+	// HiddenTags are left out of the bookmark list with the bookmarks
+	// carrying them, unless revealed or filtered by.
+	HiddenTags []string
+
+	// TitleStrip are removed from the titles entered or fetched, like the
+	// site name some sites append.
+	TitleStrip []*regexp.Regexp
+
+	// Profiles are the named sets of settings read from the profiles file.
+	Profiles []Profile
+
+	// Profile is the name of the profile to use when none is active.
+	Profile string
+
+	// DBPath is the database of the active profile, empty to look it up
+	// like buku does.
+	DBPath string
+
+	// Debug appends every run to TraceFile for replaying it with --replay.
+	Debug bool
+
+	// TraceFile stores the runs traced with Debug, empty if no state
+	// directory could be found.
+	TraceFile string
+
+	// CrashLogFile stores the stacks of internal errors, empty if no state
+	// directory could be found.
+	CrashLogFile string
+
+	// LockFile is locked while writing to the database so overlapping
+	// instances take turns, in the temporary directory if $XDG_RUNTIME_DIR
+	// is unset.
+	LockFile string
+}
+
+// Default returns the default settings.
+func Default() Config {
+	return Config{
+		MaxTitleLen:   DefaultMaxTitleLen,
+		MaxURLLen:     DefaultMaxURLLen,
+		MaxCommentLen: DefaultMaxCommentLen,
+		MaxTagLen:     DefaultMaxTagLen,
+		MaxBookmarks:  DefaultMaxBookmarks,
+		MaxEntries:    DefaultMaxEntries,
+		Recent:        DefaultRecent,
+		DBTimeout:     DefaultDBTimeout,
+		URLSchemes:    DefaultURLSchemes(),
+		Confirm:       ConfirmDelete,
+		ModifyApply:   ModifyApplyImmediate,
+		TagSplit:      TagSplitComma,
+		TagSigil:      DefaultTagSigil,
+	}
+}
+
+// Load returns the default settings overridden by the environment.
+func Load() Config {
+	c := Default()
+	c.Browser = os.Getenv(BrowserEnvVar)
+	c.BrowserAlt = os.Getenv(BrowserAltEnvVar)
+	c.ReadOnly = os.Getenv(ReadOnlyEnvVar) == "1"
+	c.Profile = os.Getenv(ProfileEnvVar)
+	c.MaxTitleLen = getPositiveInt(MaxTitleLenEnvVar, c.MaxTitleLen)
+	c.MaxURLLen = getPositiveInt(MaxURLLenEnvVar, c.MaxURLLen)
+	c.MaxCommentLen = getPositiveInt(MaxCommentLenEnvVar, c.MaxCommentLen)
+	c.MaxTagLen = getPositiveInt(MaxTagLenEnvVar, c.MaxTagLen)
+	c.MaxBookmarks = min(getPositiveInt(MaxBookmarksEnvVar, c.MaxBookmarks), math.MaxUint16)
+	c.DebugTimings = os.Getenv(DebugTimingsEnvVar) == "1"
+	c.SlowQuery = time.Duration(getPositiveInt(SlowQueryEnvVar, 0)) * time.Millisecond
+	c.DBBusyTimeout = getDuration(DBBusyTimeoutEnvVar, c.DBBusyTimeout)
+	c.DBTimeout = getDuration(DBTimeoutEnvVar, c.DBTimeout)
+	c.DBJournalMode = os.Getenv(DBJournalModeEnvVar)
+	c.DBForeignKeys = getBool(DBForeignKeysEnvVar)
+	c.DBMaxOpenConns = getPositiveInt(DBMaxOpenConnsEnvVar, c.DBMaxOpenConns)
+	c.FTS = os.Getenv(FTSEnvVar) == "1"
+	c.Clipboard = os.Getenv(ClipboardEnvVar)
+	c.RememberLast = os.Getenv(RememberLastEnvVar) == "1"
+	c.StyleLabel = os.Getenv(StyleLabelEnvVar)
+	c.StyleExample = os.Getenv(StyleExampleEnvVar)
+	c.StyleCurrent = os.Getenv(StyleCurrentEnvVar)
+	c.DryRun = os.Getenv(DryRunEnvVar) == "1"
+	c.IndexMode = os.Getenv(IndexModeEnvVar) == "1"
+	c.RowPrefixes = os.Getenv(RowPrefixesEnvVar) == "1"
+	c.RichEntries = os.Getenv(RichEntriesEnvVar) == "1"
+	c.FetchTitles = os.Getenv(FetchTitlesEnvVar) == "1"
+	c.Confirm = getEnum(ConfirmEnvVar, []ConfirmPolicy{ConfirmNone, ConfirmDelete, ConfirmAll}, c.Confirm)
+	c.ModifyApply = getEnum(ModifyApplyEnvVar, []ModifyApply{ModifyApplyImmediate, ModifyApplyOnConfirm}, c.ModifyApply)
+	c.TagSplit = getEnum(TagSplitEnvVar, []TagSplit{TagSplitComma, TagSplitCommaSpace}, c.TagSplit)
+	c.AutoTags = os.Getenv(AutoTagsEnvVar) == "1"
+	c.AutoTagSource = os.Getenv(AutoTagSourceEnvVar) == "1"
+	c.Inbox = os.Getenv(InboxEnvVar) == "1"
+	c.FastDelete = os.Getenv(FastDeleteEnvVar) == "1"
+	// set but empty turns the sigil off
+	if s, ok := os.LookupEnv(TagSigilEnvVar); ok {
+		c.TagSigil = strings.TrimSpace(s)
+	}
+	c.Debug = os.Getenv(DebugEnvVar) == "1"
+	c.CompactHints = os.Getenv(CompactHintsEnvVar) == "1"
+	c.Plain = os.Getenv(PlainEnvVar) == "1" || os.Getenv(noColorEnvVar) != ""
+	c.MaxEntries = getPositiveInt(MaxEntriesEnvVar, c.MaxEntries)
+	c.Recent = getPositiveInt(RecentEnvVar, c.Recent)
+	c.LinesSmall = getPositiveInt(LinesSmallEnvVar, c.LinesSmall)
+	c.LinesFull = getPositiveInt(LinesFullEnvVar, c.LinesFull)
+	c.ExportDir = os.Getenv(ExportDirEnvVar)
+	if c.ExportDir == "" {
+		c.ExportDir, _ = os.UserHomeDir()
+	}
+	if dir := stateDir(); dir != "" {
+		c.LastFile = filepath.Join(dir, "last")
+		c.TraceFile = filepath.Join(dir, "trace")
+		c.CrashLogFile = filepath.Join(dir, "crash.log")
+	}
+	if dir := os.Getenv(xdgRuntimeDirEnvVar); dir != "" {
+		c.LockFile = filepath.Join(dir, "robuku.lock")
+	} else {
+		// the temporary directory is shared, every user gets their own lock
+		c.LockFile = filepath.Join(os.TempDir(), fmt.Sprintf("robuku-%d.lock", os.Getuid()))
+	}
+	if s := os.Getenv(URLSchemesEnvVar); s != "" {
+		c.URLSchemes = ParseSchemes(s)
+	}
+	c.HiddenTags = ParseTagList(os.Getenv(HiddenTagsEnvVar))
+	c.CommentSnippets = readLines(configFile(SnippetsEnvVar, "snippets"))
+	c.Launchers = ParseLaunchers(readLines(configFile(LaunchersEnvVar, "launchers")))
+	c.TagSuggestions = ParseTagSuggestions(readLines(configFile(TagSuggestionsEnvVar, "tag-suggestions")))
+	c.TitleStrip = ParseTitleStrip(readLines(configFile(TitleStripEnvVar, "title-strip")))
+	c.Profiles = ParseProfiles(readLines(configFile(ProfilesEnvVar, "profiles")))
+	return c
+}
+
+// FindProfile returns the profile called name.
+func (c Config) FindProfile(name string) (Profile, bool) {
+	for _, p := range c.Profiles {
+		if p.Name == name {
+			return p, true
+		}
+	}
+	return Profile{}, false
+}
+
+// WithProfile returns the settings with those set by p merged over them,
+// the settings p leaves unset are kept.
+func (c Config) WithProfile(p Profile) Config {
+	if p.DBPath != "" {
+		c.DBPath = p.DBPath
+	}
+	if p.Browser != nil {
+		c.Browser = *p.Browser
+	}
+	if p.BrowserAlt != nil {
+		c.BrowserAlt = *p.BrowserAlt
+	}
+	if p.ReadOnly != nil {
+		c.ReadOnly = *p.ReadOnly
+	}
+	if p.HiddenTags != nil {
+		c.HiddenTags = slices.Clone(*p.HiddenTags)
+	}
+	return c
+}
+
+// configFile returns the path in the env variable key, or of the file name
+// in robuku's config directory.
+func configFile(key, name string) string {
+	if path := os.Getenv(key); path != "" {
+		return path
+	}
+	if dir := os.Getenv(xdgConfigHomeEnvVar); dir != "" {
+		return filepath.Join(dir, "robuku", name)
+	}
+	if home, _ := os.UserHomeDir(); home != "" {
+		return filepath.Join(home, ".config/robuku", name)
+	}
+	return ""
+}
+
+// readLines returns the lines of the config file at path, skipping blank
+// lines and lines starting with "#". A missing file means no lines.
+func readLines(path string) []string {
+	if path == "" {
+		return nil
+	}
+	content, err := os.ReadFile(path)
+	if err != nil {
+		if !errors.Is(err, fs.ErrNotExist) {
+			log.Println("ERROR", fmt.Errorf("failed to read %s: %w", path, err))
+		}
+		return nil
+	}
+
+	var snippets []string
+	for _, line := range strings.Split(string(content), "\n") {
+		line = strings.TrimSpace(line)
+		if line != "" && !strings.HasPrefix(line, "#") {
+			snippets = append(snippets, line)
+		}
+	}
+	return snippets
+}
+
+// ParseLaunchers parses lines like "video = mpv %u" into launchers, in the
+// same order. Lines without a tag or command are skipped.
+func ParseLaunchers(lines []string) []Launcher {
+	var launchers []Launcher
+	for _, line := range lines {
+		tag, command, ok := strings.Cut(line, "=")
+		tag, command = strings.TrimSpace(tag), strings.TrimSpace(command)
+		if !ok || tag == "" || command == "" {
+			log.Printf("ERROR invalid launcher '%s', expected 'tag = command'", line)
+			continue
+		}
+		launchers = append(launchers, Launcher{Tag: tag, Command: command})
+	}
+	return launchers
+}
+
+// ParseTagSuggestions parses lines like "github.com = github, code" into tag
+// suggestions, in the same order. Lines without a site or tags are skipped.
+func ParseTagSuggestions(lines []string) []TagSuggestion {
+	var suggestions []TagSuggestion
+	for _, line := range lines {
+		site, list, ok := strings.Cut(line, "=")
+		site = strings.ToLower(strings.TrimSpace(site))
+		var tags []string
+		for _, t := range strings.Split(list, ",") {
+			if t = strings.TrimSpace(t); t != "" {
+				tags = append(tags, t)
+			}
+		}
+		if !ok || site == "" || len(tags) == 0 {
+			log.Printf("ERROR invalid tag suggestion '%s', expected 'site = tag, ...'", line)
+			continue
+		}
+		suggestions = append(suggestions, TagSuggestion{Site: site, Tags: tags})
+	}
+	return suggestions
+}
+
+// ParseProfiles parses sections like
+//
+//	[work]
+//	db = ~/work/bookmarks.db
+//	browser = firefox -P work
+//
+// into profiles, in the same order. The keys are db, browser, browser_alt,
+// readonly and hidden_tags, invalid lines are skipped.
+func ParseProfiles(lines []string) []Profile {
+	var profiles []Profile
+	for _, line := range lines {
+		if name, ok := strings.CutPrefix(line, "["); ok {
+			name, ok = strings.CutSuffix(strings.TrimSpace(name), "]")
+			if name = strings.TrimSpace(name); !ok || name == "" {
+				log.Printf("ERROR invalid profile '%s', expected '[name]'", line)
+				continue
+			}
+			profiles = append(profiles, Profile{Name: name})
+			continue
+		}
+		key, value, ok := strings.Cut(line, "=")
+		key, value = strings.TrimSpace(key), strings.TrimSpace(value)
+		if !ok || len(profiles) == 0 {
+			log.Printf("ERROR invalid profile setting '%s', expected 'key = value' after '[name]'", line)
+			continue
+		}
+		p := &profiles[len(profiles)-1]
+		switch key {
+		case "db":
+			p.DBPath = expandHome(value)
+		case "browser":
+			p.Browser = &value
+		case "browser_alt":
+			p.BrowserAlt = &value
+		case "readonly":
+			b, err := strconv.ParseBool(value)
+			if err != nil {
+				log.Printf("ERROR invalid value '%s' for readonly in profile '%s'", value, p.Name)
+				continue
+			}
+			p.ReadOnly = &b
+		case "hidden_tags":
+			tags := ParseTagList(value)
+			p.HiddenTags = &tags
+		default:
+			log.Printf("ERROR unknown profile setting '%s' in profile '%s'", key, p.Name)
+		}
+	}
+	return profiles
+}
+
+// expandHome replaces a leading "~/" in path with the home directory.
+func expandHome(path string) string {
+	if rest, ok := strings.CutPrefix(path, "~/"); ok {
+		if home, err := os.UserHomeDir(); err == nil {
+			return filepath.Join(home, rest)
+		}
+	}
+	return path
+}
+
+// ParseTitleStrip compiles lines like `\s+-\s+YouTube$` into the patterns
+// removed from titles, in the same order. Invalid patterns are skipped.
+func ParseTitleStrip(lines []string) []*regexp.Regexp {
+	var patterns []*regexp.Regexp
+	for _, line := range lines {
+		re, err := regexp.Compile(line)
+		if err != nil {
+			log.Println("ERROR", fmt.Errorf("invalid title strip pattern '%s': %w", line, err))
+			continue
+		}
+		patterns = append(patterns, re)
+	}
+	return patterns
+}
+
+// ParseTagList parses a comma separated list of tags like "nsfw, archive",
+// it is nil if there are none.
+func ParseTagList(s string) []string {
+	var tags []string
+	for _, t := range strings.Split(s, ",") {
+		if t = strings.TrimSpace(t); t != "" {
+			tags = append(tags, t)
+		}
+	}
+	return tags
+}
+
+// ParseSchemes parses a comma separated list of URL schemes like
+// "http, https, gemini:", the schemes are lower cased and a trailing ":" or
+// "://" is removed.
+func ParseSchemes(s string) []string {
+	schemes := make([]string, 0)
+	for _, scheme := range strings.Split(s, ",") {
+		scheme = strings.TrimSuffix(strings.TrimSpace(scheme), "://")
+		scheme = strings.ToLower(strings.TrimSuffix(scheme, ":"))
+		if scheme != "" {
+			schemes = append(schemes, scheme)
+		}
+	}
+	return schemes
+}
+
+// stateDir returns robuku's directory under $XDG_STATE_HOME, or
+// ~/.local/state if it is unset.
+func stateDir() string {
+	if dir := os.Getenv(xdgStateHomeEnvVar); dir != "" {
+		return filepath.Join(dir, "robuku")
+	}
+	if home, _ := os.UserHomeDir(); home != "" {
+		return filepath.Join(home, ".local/state/robuku")
+	}
+	return ""
+}
+
+// getPositiveInt returns the value of the env variable key, or def if it is
+// unset or not a positive integer.
+func getPositiveInt(key string, def int) int {
+	s := os.Getenv(key)
+	if s == "" {
+		return def
+	}
+	n, err := strconv.Atoi(s)
+	if err != nil || n < 1 {
+		log.Printf("ERROR invalid value '%s' for $%s, using default %d", s, key, def)
+		return def
+	}
+	return n
+}
+
+// getDuration returns the value of the env variable key, e.g. "5s", or def
+// if it is unset or not a valid non-negative duration.
+func getDuration(key string, def time.Duration) time.Duration {
+	s := os.Getenv(key)
+	if s == "" {
+		return def
+	}
+	d, err := time.ParseDuration(s)
+	if err != nil || d < 0 {
+		log.Printf("ERROR invalid value '%s' for $%s, using default %s", s, key, def)
+		return def
+	}
+	return d
+}
+
+// getEnum returns the value in the env variable key, matched case
+// insensitively against allowed, or def if it is unset or not allowed.
+func getEnum[T ~string](key string, allowed []T, def T) T {
+	s := os.Getenv(key)
+	if s == "" {
+		return def
+	}
+	if v := T(strings.ToLower(strings.TrimSpace(s))); slices.Contains(allowed, v) {
+		return v
+	}
+	log.Printf("ERROR invalid value '%s' for $%s, using default %s", s, key, def)
+	return def
+}
+
+// getBool returns the value of the env variable key, or nil if it is unset
+// or not a valid boolean.
+func getBool(key string) *bool {
+	s := os.Getenv(key)
+	if s == "" {
+		return nil
+	}
+	b, err := strconv.ParseBool(s)
+	if err != nil {
+		log.Printf("ERROR invalid value '%s' for $%s, using default", s, key)
+		return nil
+	}
+	return &b
+}
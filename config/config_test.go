@@ -0,0 +1,542 @@
+package config
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"slices"
+	"testing"
+	"time"
+)
+
+func Test_Load(t *testing.T) {
+	t.Setenv(BrowserEnvVar, "firefox")
+	t.Setenv(MaxTitleLenEnvVar, "100")
+	t.Setenv(MaxURLLenEnvVar, "")
+	t.Setenv(MaxCommentLenEnvVar, "-5")
+	t.Setenv(MaxTagLenEnvVar, "abc")
+	t.Setenv(MaxBookmarksEnvVar, "100000")
+	t.Setenv(DebugTimingsEnvVar, "1")
+	t.Setenv(SlowQueryEnvVar, "250")
+	t.Setenv(DBBusyTimeoutEnvVar, "5s")
+	t.Setenv(DBTimeoutEnvVar, "2500ms")
+	t.Setenv(DBJournalModeEnvVar, "WAL")
+	t.Setenv(DBForeignKeysEnvVar, "true")
+	t.Setenv(DBMaxOpenConnsEnvVar, "0")
+	t.Setenv(FTSEnvVar, "1")
+	t.Setenv(URLSchemesEnvVar, "https, gemini")
+	t.Setenv(ClipboardEnvVar, "xsel -b")
+	t.Setenv(RememberLastEnvVar, "1")
+	t.Setenv(StyleCurrentEnvVar, `foreground="red"`)
+	t.Setenv(DryRunEnvVar, "1")
+	t.Setenv(xdgRuntimeDirEnvVar, "/run/user/1000")
+	t.Setenv(ExportDirEnvVar, "/tmp/exports")
+	t.Setenv(IndexModeEnvVar, "1")
+	t.Setenv(RowPrefixesEnvVar, "1")
+	t.Setenv(RichEntriesEnvVar, "1")
+	t.Setenv(FetchTitlesEnvVar, "1")
+	t.Setenv(ConfirmEnvVar, "None")
+	t.Setenv(DebugEnvVar, "1")
+	t.Setenv(CompactHintsEnvVar, "1")
+	t.Setenv(MaxEntriesEnvVar, "300")
+	t.Setenv(RecentEnvVar, "50")
+	t.Setenv(LinesSmallEnvVar, "6")
+	t.Setenv(LinesFullEnvVar, "20")
+	t.Setenv(ModifyApplyEnvVar, "on_confirm")
+	t.Setenv(PlainEnvVar, "1")
+	t.Setenv(TagSplitEnvVar, "comma+space")
+	t.Setenv(AutoTagsEnvVar, "1")
+	t.Setenv(AutoTagSourceEnvVar, "1")
+	t.Setenv(InboxEnvVar, "1")
+	t.Setenv(FastDeleteEnvVar, "1")
+	t.Setenv(TagSigilEnvVar, " @ ")
+	t.Setenv(HiddenTagsEnvVar, "nsfw, archive")
+	t.Setenv(xdgStateHomeEnvVar, "/tmp/state")
+
+	c := Load()
+
+	if c.Browser != "firefox" {
+		t.Errorf("expected browser 'firefox', got '%s'", c.Browser)
+	}
+	if c.MaxTitleLen != 100 {
+		t.Errorf("expected max title length '100', got '%d'", c.MaxTitleLen)
+	}
+	if c.MaxURLLen != DefaultMaxURLLen {
+		t.Errorf("expected max url length '%d', got '%d'", DefaultMaxURLLen, c.MaxURLLen)
+	}
+	if c.MaxCommentLen != DefaultMaxCommentLen {
+		t.Errorf("expected max comment length '%d', got '%d'", DefaultMaxCommentLen, c.MaxCommentLen)
+	}
+	if c.MaxTagLen != DefaultMaxTagLen {
+		t.Errorf("expected max tag length '%d', got '%d'", DefaultMaxTagLen, c.MaxTagLen)
+	}
+	if c.MaxBookmarks != 65535 {
+		t.Errorf("expected max bookmarks '65535', got '%d'", c.MaxBookmarks)
+	}
+	if !c.DebugTimings {
+		t.Error("expected debug timings to be enabled")
+	}
+	if c.SlowQuery != 250*time.Millisecond {
+		t.Errorf("expected slow query '250ms', got '%s'", c.SlowQuery)
+	}
+	if c.DBBusyTimeout != 5*time.Second {
+		t.Errorf("expected db busy timeout '5s', got '%s'", c.DBBusyTimeout)
+	}
+	if c.DBTimeout != 2500*time.Millisecond {
+		t.Errorf("expected db timeout '2.5s', got '%s'", c.DBTimeout)
+	}
+	if c.DBJournalMode != "WAL" {
+		t.Errorf("expected db journal mode 'WAL', got '%s'", c.DBJournalMode)
+	}
+	if c.DBForeignKeys == nil || !*c.DBForeignKeys {
+		t.Error("expected db foreign keys to be enabled")
+	}
+	if c.DBMaxOpenConns != 0 {
+		t.Errorf("expected db max open connections '0', got '%d'", c.DBMaxOpenConns)
+	}
+	if !c.FTS {
+		t.Error("expected full text search to be enabled")
+	}
+	if !slices.Equal(c.URLSchemes, []string{"https", "gemini"}) {
+		t.Errorf("expected url schemes '[https gemini]', got '%v'", c.URLSchemes)
+	}
+	if c.Clipboard != "xsel -b" {
+		t.Errorf("expected clipboard 'xsel -b', got '%s'", c.Clipboard)
+	}
+	if !c.RememberLast {
+		t.Error("expected remember last to be enabled")
+	}
+	if c.StyleCurrent != `foreground="red"` {
+		t.Errorf("expected style current 'foreground=\"red\"', got '%s'", c.StyleCurrent)
+	}
+	if !c.IndexMode {
+		t.Error("expected index mode to be enabled")
+	}
+	if !c.RowPrefixes {
+		t.Error("expected row prefixes to be enabled")
+	}
+	if !c.RichEntries {
+		t.Error("expected rich entries to be enabled")
+	}
+	if !c.FetchTitles {
+		t.Error("expected title fetching to be enabled")
+	}
+	if c.Confirm != ConfirmNone {
+		t.Errorf("expected confirm '%s', got '%s'", ConfirmNone, c.Confirm)
+	}
+	if c.ModifyApply != ModifyApplyOnConfirm {
+		t.Errorf("expected modify apply '%s', got '%s'", ModifyApplyOnConfirm, c.ModifyApply)
+	}
+	if c.TagSplit != TagSplitCommaSpace {
+		t.Errorf("expected tag split '%s', got '%s'", TagSplitCommaSpace, c.TagSplit)
+	}
+	if !c.AutoTags {
+		t.Error("expected auto tags to be enabled")
+	}
+	if !c.AutoTagSource {
+		t.Error("expected source tags to be enabled")
+	}
+	if !c.Inbox {
+		t.Error("expected the inbox to be enabled")
+	}
+	if !c.FastDelete {
+		t.Error("expected fast delete to be enabled")
+	}
+	if c.TagSigil != "@" {
+		t.Errorf("expected tag sigil '@', got '%s'", c.TagSigil)
+	}
+	if !slices.Equal(c.HiddenTags, []string{"nsfw", "archive"}) {
+		t.Errorf("expected hidden tags [nsfw archive], got %q", c.HiddenTags)
+	}
+	if c.ExportDir != "/tmp/exports" {
+		t.Errorf("expected export dir '/tmp/exports', got '%s'", c.ExportDir)
+	}
+	if !c.DryRun {
+		t.Error("expected dry run to be enabled")
+	}
+	if c.LastFile != "/tmp/state/robuku/last" {
+		t.Errorf("expected last file '/tmp/state/robuku/last', got '%s'", c.LastFile)
+	}
+	if c.MaxEntries != 300 {
+		t.Errorf("expected max entries '300', got '%d'", c.MaxEntries)
+	}
+	if c.Recent != 50 {
+		t.Errorf("expected recent '50', got '%d'", c.Recent)
+	}
+	if c.LinesSmall != 6 || c.LinesFull != 20 {
+		t.Errorf("expected lines 6 and 20, got '%d' and '%d'", c.LinesSmall, c.LinesFull)
+	}
+	if !c.CompactHints {
+		t.Error("expected compact hints to be enabled")
+	}
+	if !c.Plain {
+		t.Error("expected plain messages to be enabled")
+	}
+	if !c.Debug {
+		t.Error("expected debug to be enabled")
+	}
+	if c.TraceFile != "/tmp/state/robuku/trace" {
+		t.Errorf("expected trace file '/tmp/state/robuku/trace', got '%s'", c.TraceFile)
+	}
+	if c.CrashLogFile != "/tmp/state/robuku/crash.log" {
+		t.Errorf("expected crash log file '/tmp/state/robuku/crash.log', got '%s'", c.CrashLogFile)
+	}
+	if c.LockFile != "/run/user/1000/robuku.lock" {
+		t.Errorf("expected lock file '/run/user/1000/robuku.lock', got '%s'", c.LockFile)
+	}
+}
+
+func Test_Load_NoColor(t *testing.T) {
+	t.Setenv(PlainEnvVar, "")
+	t.Setenv(noColorEnvVar, "")
+	if Load().Plain {
+		t.Error("expected plain messages to be disabled by default")
+	}
+
+	t.Setenv(noColorEnvVar, "1")
+	if !Load().Plain {
+		t.Errorf("expected $%s to enable plain messages", noColorEnvVar)
+	}
+}
+
+func Test_Load_TagSigil(t *testing.T) {
+	t.Setenv(TagSigilEnvVar, "")
+	if c := Load(); c.TagSigil != "" {
+		t.Errorf("expected an empty $%s to turn the sigil off, got '%s'", TagSigilEnvVar, c.TagSigil)
+	}
+
+	os.Unsetenv(TagSigilEnvVar)
+	if c := Load(); c.TagSigil != DefaultTagSigil {
+		t.Errorf("expected tag sigil '%s' by default, got '%s'", DefaultTagSigil, c.TagSigil)
+	}
+}
+
+func Test_Load_NoRuntimeDir(t *testing.T) {
+	t.Setenv(xdgRuntimeDirEnvVar, "")
+	expected := filepath.Join(os.TempDir(), fmt.Sprintf("robuku-%d.lock", os.Getuid()))
+	if c := Load(); c.LockFile != expected {
+		t.Errorf("expected lock file '%s' without $%s, got '%s'", expected, xdgRuntimeDirEnvVar, c.LockFile)
+	}
+}
+
+func Test_Load_InvalidDB(t *testing.T) {
+	t.Setenv(DBBusyTimeoutEnvVar, "soon")
+	t.Setenv(DBTimeoutEnvVar, "-1s")
+	t.Setenv(DBForeignKeysEnvVar, "maybe")
+
+	c := Load()
+
+	if c.DBBusyTimeout != 0 {
+		t.Errorf("expected db busy timeout '0s', got '%s'", c.DBBusyTimeout)
+	}
+	if c.DBTimeout != DefaultDBTimeout {
+		t.Errorf("expected db timeout '%s', got '%s'", DefaultDBTimeout, c.DBTimeout)
+	}
+	if c.DBForeignKeys != nil {
+		t.Errorf("expected db foreign keys to be unset, got '%v'", *c.DBForeignKeys)
+	}
+}
+
+func Test_getEnum_ConfirmPolicy(t *testing.T) {
+	tests := []struct {
+		value    string
+		expected ConfirmPolicy
+	}{
+		{"", ConfirmDelete},
+		{"none", ConfirmNone},
+		{" ALL ", ConfirmAll},
+		{"delete", ConfirmDelete},
+		{"sometimes", ConfirmDelete},
+	}
+	for _, tt := range tests {
+		t.Setenv(ConfirmEnvVar, tt.value)
+		if got := getEnum(ConfirmEnvVar, []ConfirmPolicy{ConfirmNone, ConfirmDelete, ConfirmAll}, ConfirmDelete); got != tt.expected {
+			t.Errorf("value '%s': expected '%s', got '%s'", tt.value, tt.expected, got)
+		}
+	}
+}
+
+func Test_getEnum_ModifyApply(t *testing.T) {
+	tests := []struct {
+		value    string
+		expected ModifyApply
+	}{
+		{"", ModifyApplyImmediate},
+		{"immediate", ModifyApplyImmediate},
+		{" On_Confirm ", ModifyApplyOnConfirm},
+		{"later", ModifyApplyImmediate},
+	}
+	for _, tt := range tests {
+		t.Setenv(ModifyApplyEnvVar, tt.value)
+		if got := getEnum(ModifyApplyEnvVar, []ModifyApply{ModifyApplyImmediate, ModifyApplyOnConfirm}, ModifyApplyImmediate); got != tt.expected {
+			t.Errorf("value '%s': expected '%s', got '%s'", tt.value, tt.expected, got)
+		}
+	}
+}
+
+func Test_getEnum_TagSplit(t *testing.T) {
+	tests := []struct {
+		value    string
+		expected TagSplit
+	}{
+		{"", TagSplitComma},
+		{"comma", TagSplitComma},
+		{" Comma+Space ", TagSplitCommaSpace},
+		{"space", TagSplitComma},
+	}
+	for _, tt := range tests {
+		t.Setenv(TagSplitEnvVar, tt.value)
+		if got := getEnum(TagSplitEnvVar, []TagSplit{TagSplitComma, TagSplitCommaSpace}, TagSplitComma); got != tt.expected {
+			t.Errorf("value '%s': expected '%s', got '%s'", tt.value, tt.expected, got)
+		}
+	}
+}
+
+func Test_Load_DefaultURLSchemes(t *testing.T) {
+	t.Setenv(URLSchemesEnvVar, "")
+
+	if c := Load(); !slices.Equal(c.URLSchemes, DefaultURLSchemes()) {
+		t.Errorf("expected url schemes '%v', got '%v'", DefaultURLSchemes(), c.URLSchemes)
+	}
+
+	// changing the schemes of one config leaves the defaults alone
+	c := Load()
+	c.URLSchemes[0] = "gopher"
+	if DefaultURLSchemes()[0] != "http" || Load().URLSchemes[0] != "http" {
+		t.Errorf("expected the default url schemes to be unchanged, got '%v'", DefaultURLSchemes())
+	}
+}
+
+func Test_ParseSchemes(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected []string
+	}{
+		{"http,https", []string{"http", "https"}},
+		{" HTTP , gemini: ,ftp://", []string{"http", "gemini", "ftp"}},
+		{"http,, ,", []string{"http"}},
+		{" , ", []string{}},
+	}
+	for _, test := range tests {
+		if actual := ParseSchemes(test.input); !slices.Equal(actual, test.expected) {
+			t.Errorf("input '%s': expected '%q', got '%q'", test.input, test.expected, actual)
+		}
+	}
+}
+
+func Test_stateDir(t *testing.T) {
+	t.Setenv(xdgStateHomeEnvVar, "")
+	t.Setenv("HOME", "/home/user")
+
+	if dir := stateDir(); dir != "/home/user/.local/state/robuku" {
+		t.Errorf("expected state dir '/home/user/.local/state/robuku', got '%s'", dir)
+	}
+}
+
+func Test_configFile(t *testing.T) {
+	t.Setenv(SnippetsEnvVar, "")
+	t.Setenv(xdgConfigHomeEnvVar, "")
+	t.Setenv("HOME", "/home/user")
+	if path := configFile(SnippetsEnvVar, "snippets"); path != "/home/user/.config/robuku/snippets" {
+		t.Errorf("expected snippets file '/home/user/.config/robuku/snippets', got '%s'", path)
+	}
+
+	t.Setenv(xdgConfigHomeEnvVar, "/tmp/config")
+	if path := configFile(SnippetsEnvVar, "snippets"); path != "/tmp/config/robuku/snippets" {
+		t.Errorf("expected snippets file '/tmp/config/robuku/snippets', got '%s'", path)
+	}
+
+	t.Setenv(SnippetsEnvVar, "/tmp/my-snippets")
+	if path := configFile(SnippetsEnvVar, "snippets"); path != "/tmp/my-snippets" {
+		t.Errorf("expected snippets file '/tmp/my-snippets', got '%s'", path)
+	}
+}
+
+func Test_readLines(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "snippets")
+	content := "# comment snippets\nwhy: … / found via: …\n\n  to read later  \n"
+	if err := os.WriteFile(path, []byte(content), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	t.Setenv(SnippetsEnvVar, path)
+	snippets := Load().CommentSnippets
+	expected := []string{"why: … / found via: …", "to read later"}
+	if !slices.Equal(snippets, expected) {
+		t.Errorf("expected snippets %q, got %q", expected, snippets)
+	}
+
+	if snippets := readLines(filepath.Join(t.TempDir(), "missing")); snippets != nil {
+		t.Errorf("expected no snippets for missing file, got %q", snippets)
+	}
+}
+
+func Test_ParseLaunchers(t *testing.T) {
+	lines := []string{"video = mpv %u", "pdf=zathura", "no command =", "= no tag", "missing separator"}
+	expected := []Launcher{{Tag: "video", Command: "mpv %u"}, {Tag: "pdf", Command: "zathura"}}
+	if actual := ParseLaunchers(lines); !slices.Equal(actual, expected) {
+		t.Errorf("expected launchers %v, got %v", expected, actual)
+	}
+
+	path := filepath.Join(t.TempDir(), "launchers")
+	if err := os.WriteFile(path, []byte("# tag = command\nvideo = mpv %u\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	t.Setenv(LaunchersEnvVar, path)
+	if actual := Load().Launchers; !slices.Equal(actual, expected[:1]) {
+		t.Errorf("expected launchers %v, got %v", expected[:1], actual)
+	}
+}
+
+func Test_ParseTagSuggestions(t *testing.T) {
+	lines := []string{"GitHub.com = github, code", "python=python", "no tags = ,", "= no site", "missing separator"}
+	expected := []TagSuggestion{
+		{Site: "github.com", Tags: []string{"github", "code"}},
+		{Site: "python", Tags: []string{"python"}},
+	}
+	equal := func(a, b TagSuggestion) bool { return a.Site == b.Site && slices.Equal(a.Tags, b.Tags) }
+	if actual := ParseTagSuggestions(lines); !slices.EqualFunc(actual, expected, equal) {
+		t.Errorf("expected tag suggestions %v, got %v", expected, actual)
+	}
+
+	path := filepath.Join(t.TempDir(), "tag-suggestions")
+	if err := os.WriteFile(path, []byte("# site = tags\npython = python\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	t.Setenv(TagSuggestionsEnvVar, path)
+	if actual := Load().TagSuggestions; !slices.EqualFunc(actual, expected[1:], equal) {
+		t.Errorf("expected tag suggestions %v, got %v", expected[1:], actual)
+	}
+}
+
+func Test_ParseTagList(t *testing.T) {
+	tests := []struct {
+		s        string
+		expected []string
+	}{
+		{"", nil},
+		{" , ", nil},
+		{"nsfw", []string{"nsfw"}},
+		{"nsfw, archive ,", []string{"nsfw", "archive"}},
+	}
+	for _, tt := range tests {
+		if actual := ParseTagList(tt.s); !slices.Equal(actual, tt.expected) {
+			t.Errorf("expected tags %q for '%s', got %q", tt.expected, tt.s, actual)
+		}
+	}
+}
+
+func Test_ParseTitleStrip(t *testing.T) {
+	patterns := ParseTitleStrip([]string{`\s+-\s+YouTube$`, `(unclosed`, `^\(\d+\)\s*`})
+	var actual []string
+	for _, re := range patterns {
+		actual = append(actual, re.String())
+	}
+	expected := []string{`\s+-\s+YouTube$`, `^\(\d+\)\s*`}
+	if !slices.Equal(actual, expected) {
+		t.Errorf("expected patterns %q, got %q", expected, actual)
+	}
+
+	path := filepath.Join(t.TempDir(), "title-strip")
+	if err := os.WriteFile(path, []byte("# pattern\n\\s+-\\s+YouTube$\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	t.Setenv(TitleStripEnvVar, path)
+	if actual := Load().TitleStrip; len(actual) != 1 || actual[0].String() != expected[0] {
+		t.Errorf("expected patterns %q, got %v", expected[:1], actual)
+	}
+}
+
+func Test_ParseProfiles(t *testing.T) {
+	lines := []string{
+		"browser = orphan",
+		"[work]",
+		"db = /work/bookmarks.db",
+		"browser = firefox -P work",
+		"readonly = yes",
+		"hidden_tags = nsfw, games",
+		"colour = red",
+		"[ ]",
+		"[home]",
+		"readonly = true",
+		"hidden_tags =",
+	}
+	profiles := ParseProfiles(lines)
+	if len(profiles) != 2 {
+		t.Fatalf("expected 2 profiles, got %d", len(profiles))
+	}
+	work, home := profiles[0], profiles[1]
+	if work.Name != "work" || work.DBPath != "/work/bookmarks.db" {
+		t.Errorf("expected profile work with db /work/bookmarks.db, got %s with %s", work.Name, work.DBPath)
+	}
+	if work.Browser == nil || *work.Browser != "firefox -P work" {
+		t.Errorf("expected browser 'firefox -P work', got %v", work.Browser)
+	}
+	if work.ReadOnly != nil || work.BrowserAlt != nil {
+		t.Errorf("expected readonly and browser_alt unset, got %v and %v", work.ReadOnly, work.BrowserAlt)
+	}
+	if work.HiddenTags == nil || !slices.Equal(*work.HiddenTags, []string{"nsfw", "games"}) {
+		t.Errorf("expected hidden tags [nsfw games], got %v", work.HiddenTags)
+	}
+	if home.ReadOnly == nil || !*home.ReadOnly {
+		t.Errorf("expected home to be read only, got %v", home.ReadOnly)
+	}
+	if home.HiddenTags == nil || len(*home.HiddenTags) != 0 {
+		t.Errorf("expected home to clear the hidden tags, got %v", home.HiddenTags)
+	}
+
+	path := filepath.Join(t.TempDir(), "profiles")
+	if err := os.WriteFile(path, []byte("# profiles\n[home]\nbrowser = firefox\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	t.Setenv(ProfilesEnvVar, path)
+	t.Setenv(ProfileEnvVar, "home")
+	c := Load()
+	if p, ok := c.FindProfile(c.Profile); !ok || *p.Browser != "firefox" {
+		t.Errorf("expected profile home with browser firefox, got %v", c.Profiles)
+	}
+	if _, ok := c.FindProfile("work"); ok {
+		t.Errorf("expected no profile work")
+	}
+}
+
+func Test_Config_WithProfile(t *testing.T) {
+	ptr := func(s string) *string { return &s }
+	yes := true
+	global := Default()
+	global.Browser = "firefox"
+	global.BrowserAlt = "chromium"
+	global.HiddenTags = []string{"nsfw"}
+
+	tests := []struct {
+		name    string
+		profile Profile
+		check   func(c Config) bool
+	}{
+		{"empty", Profile{Name: "empty"}, func(c Config) bool {
+			return c.Browser == "firefox" && c.BrowserAlt == "chromium" && !c.ReadOnly &&
+				slices.Equal(c.HiddenTags, []string{"nsfw"}) && c.DBPath == ""
+		}},
+		{"browser only", Profile{Browser: ptr("firefox -P work")}, func(c Config) bool {
+			return c.Browser == "firefox -P work" && c.BrowserAlt == "chromium" &&
+				slices.Equal(c.HiddenTags, []string{"nsfw"})
+		}},
+		{"clear alt", Profile{BrowserAlt: ptr("")}, func(c Config) bool {
+			return c.Browser == "firefox" && c.BrowserAlt == ""
+		}},
+		{"readonly and db", Profile{DBPath: "/work.db", ReadOnly: &yes}, func(c Config) bool {
+			return c.ReadOnly && c.DBPath == "/work.db" && c.Browser == "firefox"
+		}},
+		{"clear hidden tags", Profile{HiddenTags: &[]string{}}, func(c Config) bool {
+			return len(c.HiddenTags) == 0 && c.Browser == "firefox"
+		}},
+	}
+	for _, tt := range tests {
+		if actual := global.WithProfile(tt.profile); !tt.check(actual) {
+			t.Errorf("expected %s to merge over the global settings, got %+v", tt.name, actual)
+		}
+	}
+	if global.Browser != "firefox" || !slices.Equal(global.HiddenTags, []string{"nsfw"}) {
+		t.Errorf("expected the global settings to be unchanged, got %+v", global)
+	}
+}
@@ -0,0 +1,212 @@
+package examples_test
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/VannRR/robuku/bukudb"
+	"github.com/VannRR/robuku/export"
+	"github.com/VannRR/robuku/importer"
+)
+
+// The signatures other tools rely on, a change to any of them fails to
+// compile.
+var (
+	_ bukudb.DBInterface = (*bukudb.BukuDB)(nil)
+	_ bukudb.DBInterface = (*bukudb.DryRunDB)(nil)
+
+	_ func(string, ...bukudb.Option) (*bukudb.BukuDB, error)  = bukudb.NewBukuDB
+	_ func(string) error                                      = bukudb.InitSchema
+	_ func(bukudb.DBInterface, func(string)) *bukudb.DryRunDB = bukudb.NewDryRunDB
+
+	_ func(int) bukudb.Option                       = bukudb.WithMaxBookmarks
+	_ func(bool) bukudb.Option                      = bukudb.WithFTS
+	_ func(time.Duration) bukudb.Option             = bukudb.WithBusyTimeout
+	_ func(string) bukudb.Option                    = bukudb.WithJournalMode
+	_ func(bool) bukudb.Option                      = bukudb.WithForeignKeys
+	_ func(int) bukudb.Option                       = bukudb.WithMaxOpenConns
+	_ func(bukudb.QueryLogger) bukudb.Option        = bukudb.WithQueryLogger
+	_ func(bukudb.TitleFetcher) bukudb.Option       = bukudb.WithTitleFetcher
+	_ func(context.Context, string) (string, error) = bukudb.TitleFetcher(nil)
+
+	_ error = bukudb.ErrDuplicateURL
+	_ error = bukudb.ErrChangedOnDisk
+	_ error = (*bukudb.DuplicateURLError)(nil)
+	_ error = (*bukudb.LimitError)(nil)
+	_ error = (*bukudb.NotBukuDBError)(nil)
+
+	_ func(io.Writer, export.Format, []bukudb.Bookmark) error = export.Write
+	_ func(io.Writer, export.Format, export.Each) error       = export.WriteEach
+	_ func(string) (export.Format, error)                     = export.ParseFormat
+
+	_ func(io.Reader, export.Format) ([]bukudb.Bookmark, error) = importer.Parse
+	_ func(string) (export.Format, error)                       = importer.FormatOf
+	_ func(bukudb.DBInterface, io.Reader, export.Format, io.Writer,
+		bukudb.ConflictPolicy) (importer.Summary, error) = importer.Import
+	_ func(bukudb.DBInterface, []bukudb.Bookmark, io.Writer,
+		bukudb.ConflictPolicy) (importer.Summary, error) = importer.Apply
+	_ func(bukudb.DBInterface, io.Reader, export.Format, io.Writer,
+		bukudb.ConflictPolicy, importer.Progress) (importer.Summary, error) = importer.ImportProgress
+	_ func(bukudb.DBInterface, []bukudb.Bookmark, io.Writer,
+		bukudb.ConflictPolicy, importer.Progress) (importer.Summary, error) = importer.ApplyProgress
+	_ func(bukudb.DBInterface, []bukudb.Bookmark) (importer.Summary, error) = importer.Preview
+)
+
+// openScratch returns a new buku database in a temporary directory and a
+// function removing it.
+func openScratch(opts ...bukudb.Option) (*bukudb.BukuDB, func()) {
+	dir, err := os.MkdirTemp("", "robuku-example")
+	if err != nil {
+		log.Fatal(err)
+	}
+	path := filepath.Join(dir, "bookmarks.db")
+	if err := bukudb.InitSchema(path); err != nil {
+		log.Fatal(err)
+	}
+	db, err := bukudb.NewBukuDB(path, opts...)
+	if err != nil {
+		log.Fatal(err)
+	}
+	return db, func() {
+		db.Close()
+		os.RemoveAll(dir)
+	}
+}
+
+// Example adds, edits, searches, exports, and imports bookmarks the way a
+// web front end would.
+func Example() {
+	db, cleanup := openScratch()
+	defer cleanup()
+
+	for _, b := range []bukudb.Bookmark{
+		{URL: "https://go.dev", Title: "Go", Tags: []string{"lang/go"}},
+		{URL: "https://www.rust-lang.org", Title: "Rust", Tags: []string{"lang/rust"}},
+		{URL: "https://example.com"},
+	} {
+		if err := db.Add(b); err != nil {
+			log.Fatal(err)
+		}
+	}
+
+	if err := db.UpdateTitle(3, "Example"); err != nil {
+		log.Fatal(err)
+	}
+	err := db.UpdateURL(3, "https://go.dev")
+	var dup *bukudb.DuplicateURLError
+	if errors.As(err, &dup) {
+		fmt.Printf("duplicate of #%d: %v\n", dup.ID, errors.Is(err, bukudb.ErrDuplicateURL))
+	}
+
+	tagged, err := db.SearchByTags([]string{"lang"}, nil, false)
+	if err != nil {
+		log.Fatal(err)
+	}
+	fmt.Println("tagged lang:", len(tagged))
+
+	var md bytes.Buffer
+	all, err := db.GetAll()
+	if err != nil {
+		log.Fatal(err)
+	}
+	if err := export.Write(&md, export.FormatMarkdown, all); err != nil {
+		log.Fatal(err)
+	}
+	fmt.Print(md.String())
+
+	var html bytes.Buffer
+	if err := export.Write(&html, export.FormatHTML, all); err != nil {
+		log.Fatal(err)
+	}
+	other, cleanupOther := openScratch()
+	defer cleanupOther()
+	if err := other.Add(bukudb.Bookmark{URL: "https://go.dev", Title: "Go"}); err != nil {
+		log.Fatal(err)
+	}
+	summary, err := importer.Import(other, &html, export.FormatHTML, nil, bukudb.ConflictSkip)
+	if err != nil {
+		log.Fatal(err)
+	}
+	fmt.Printf("imported %d, skipped %d\n", summary.Added, summary.Duplicates)
+
+	// Output:
+	// duplicate of #1: true
+	// tagged lang: 2
+	// - [Go](https://go.dev) <!-- TAGS: lang/go -->
+	// - [Rust](https://www.rust-lang.org) <!-- TAGS: lang/rust -->
+	// - [Example](https://example.com)
+	// imported 2, skipped 1
+}
+
+// Example_renumbering shows that Remove renumbers the bookmarks after the
+// removed one, so IDs are looked up again by URL.
+func Example_renumbering() {
+	db, cleanup := openScratch()
+	defer cleanup()
+
+	for _, url := range []string{"https://a.example", "https://b.example", "https://c.example"} {
+		if err := db.Add(bukudb.Bookmark{URL: url}); err != nil {
+			log.Fatal(err)
+		}
+	}
+	if err := db.Remove(1); err != nil {
+		log.Fatal(err)
+	}
+
+	all, err := db.GetAll()
+	if err != nil {
+		log.Fatal(err)
+	}
+	for _, b := range all {
+		fmt.Println(b.ID, b.URL)
+	}
+	fmt.Println("len:", db.Len())
+
+	// Output:
+	// 1 https://b.example
+	// 2 https://c.example
+	// len: 2
+}
+
+// Example_limit shows the error of adding more bookmarks than
+// WithMaxBookmarks allows.
+func Example_limit() {
+	db, cleanup := openScratch(bukudb.WithMaxBookmarks(1))
+	defer cleanup()
+
+	if err := db.Add(bukudb.Bookmark{URL: "https://a.example"}); err != nil {
+		log.Fatal(err)
+	}
+	err := db.Add(bukudb.Bookmark{URL: "https://b.example"})
+	var limit *bukudb.LimitError
+	fmt.Println(errors.As(err, &limit), limit.Max)
+
+	// Output:
+	// true 1
+}
+
+// Example_dryRun shows that DryRunDB reports writes without making them.
+func Example_dryRun() {
+	db, cleanup := openScratch()
+	defer cleanup()
+
+	var reports []string
+	dry := bukudb.NewDryRunDB(db, func(msg string) { reports = append(reports, msg) })
+	if err := dry.Add(bukudb.Bookmark{URL: "https://a.example"}); err != nil {
+		log.Fatal(err)
+	}
+	fmt.Println("reported:", len(reports), strings.Contains(reports[0], "https://a.example"))
+	fmt.Println("len:", db.Len())
+
+	// Output:
+	// reported: 1 true
+	// len: 0
+}
@@ -0,0 +1,4 @@
+// examples, shows how other tools use the bukudb, export, and importer
+// packages. Its tests compile against their exported API, so a change that
+// breaks it fails the build of the tests.
+package examples
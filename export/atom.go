@@ -0,0 +1,157 @@
+package export
+
+import (
+	"bytes"
+	"encoding/xml"
+	"io"
+	"strings"
+	"time"
+
+	"github.com/VannRR/robuku/bukudb"
+)
+
+// atomNamespace is the XML namespace of Atom feeds, RFC 4287.
+const atomNamespace = "http://www.w3.org/2005/Atom"
+
+// atomFeedID is the id of the exported feed, the same for every export so
+// feed readers see new bookmarks as new entries of one feed.
+const atomFeedID = "urn:robuku:bookmarks"
+
+// now returns the time of the export, tests replace it.
+var now = time.Now
+
+// Filter reports whether a bookmark is exported.
+type Filter func(bukudb.Bookmark) bool
+
+// Filtered returns the bookmarks of each that filter keeps, all of them if
+// filter is nil.
+func Filtered(each Each, filter Filter) Each {
+	if filter == nil {
+		return each
+	}
+	return func(fn func(bukudb.Bookmark) error) error {
+		return each(func(b bukudb.Bookmark) error {
+			if !filter(b) {
+				return nil
+			}
+			return fn(b)
+		})
+	}
+}
+
+// HasTags returns a Filter keeping the bookmarks tagged with every tag of
+// tags.
+func HasTags(tags []string) Filter {
+	return func(b bukudb.Bookmark) bool {
+		for _, t := range tags {
+			found := false
+			for _, bt := range b.Tags {
+				if strings.EqualFold(bt, t) {
+					found = true
+					break
+				}
+			}
+			if !found {
+				return false
+			}
+		}
+		return true
+	}
+}
+
+// ExportAtom writes the bookmarks of each that filter keeps to w as an Atom
+// feed.
+func ExportAtom(w io.Writer, each Each, filter Filter) error {
+	return WriteEach(w, FormatAtom, Filtered(each, filter))
+}
+
+// atomLink is the link of an entry to its bookmark.
+type atomLink struct {
+	Href string `xml:"href,attr"`
+}
+
+// atomCategory is a tag of a bookmark.
+type atomCategory struct {
+	Term string `xml:"term,attr"`
+}
+
+// atomEntry is a bookmark of the feed.
+type atomEntry struct {
+	XMLName    xml.Name       `xml:"entry"`
+	Title      string         `xml:"title"`
+	Link       atomLink       `xml:"link"`
+	ID         string         `xml:"id"`
+	Updated    string         `xml:"updated"`
+	Summary    string         `xml:"summary,omitempty"`
+	Categories []atomCategory `xml:"category"`
+}
+
+// atomAuthor is the author of the feed, which Atom requires.
+type atomAuthor struct {
+	Name string `xml:"name"`
+}
+
+// atomEncoder writes an Atom feed, every entry is dated by when its
+// bookmark was added, or by the export if that is unknown.
+type atomEncoder struct {
+	updated string
+}
+
+func newAtomEncoder() atomEncoder {
+	return atomEncoder{updated: now().UTC().Format(time.RFC3339)}
+}
+
+func (e atomEncoder) header() string {
+	var b strings.Builder
+	b.WriteString(xml.Header)
+	b.WriteString(`<feed xmlns="` + atomNamespace + `">` + "\n")
+
+	enc := xml.NewEncoder(&b)
+	enc.Indent("  ", "  ")
+	for _, el := range []struct {
+		name  string
+		value any
+	}{
+		{"title", "Bookmarks"},
+		{"id", atomFeedID},
+		{"updated", e.updated},
+		{"author", atomAuthor{Name: "robuku"}},
+		{"generator", "robuku"},
+	} {
+		// writing to a strings.Builder does not fail
+		_ = enc.EncodeElement(el.value, xml.StartElement{Name: xml.Name{Local: el.name}})
+	}
+	_ = enc.Flush()
+	b.WriteString("\n")
+	return b.String()
+}
+
+func (e atomEncoder) bookmark(b *bytes.Buffer, bm bukudb.Bookmark, _ int) error {
+	entry := atomEntry{
+		Title:   bm.Title,
+		Link:    atomLink{Href: bm.URL},
+		ID:      bm.URL,
+		Updated: e.updated,
+		Summary: bm.Comment,
+	}
+	if entry.Title == "" {
+		entry.Title = bm.URL
+	}
+	if bm.Created != nil {
+		entry.Updated = bm.Created.UTC().Format(time.RFC3339)
+	}
+	for _, t := range bm.Tags {
+		entry.Categories = append(entry.Categories, atomCategory{Term: t})
+	}
+	data, err := xml.MarshalIndent(entry, "  ", "  ")
+	if err != nil {
+		return err
+	}
+	b.Write(data)
+	b.WriteString("\n")
+	return nil
+}
+
+func (atomEncoder) footer(int) string {
+	return "</feed>\n"
+}
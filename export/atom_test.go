@@ -0,0 +1,130 @@
+package export
+
+import (
+	"bytes"
+	"encoding/xml"
+	"testing"
+	"time"
+
+	"github.com/VannRR/robuku/bukudb"
+	"github.com/VannRR/robuku/internal/golden"
+)
+
+// atomFeed is what feed readers need of an Atom feed, RFC 4287.
+type atomFeed struct {
+	XMLName xml.Name `xml:"http://www.w3.org/2005/Atom feed"`
+	Title   string   `xml:"title"`
+	ID      string   `xml:"id"`
+	Updated string   `xml:"updated"`
+	Author  struct {
+		Name string `xml:"name"`
+	} `xml:"author"`
+	Entries []struct {
+		Title string `xml:"title"`
+		Link  struct {
+			Href string `xml:"href,attr"`
+		} `xml:"link"`
+		ID         string `xml:"id"`
+		Updated    string `xml:"updated"`
+		Summary    string `xml:"summary"`
+		Categories []struct {
+			Term string `xml:"term,attr"`
+		} `xml:"category"`
+	} `xml:"entry"`
+}
+
+// checkAtom fails t unless data is an Atom feed with the elements RFC 4287
+// requires of feeds and entries, and returns it.
+func checkAtom(t *testing.T, data []byte) atomFeed {
+	t.Helper()
+
+	var feed atomFeed
+	if err := xml.Unmarshal(data, &feed); err != nil {
+		t.Fatalf("expected valid atom feed, got '%v'", err)
+	}
+	validTime := func(s string) bool {
+		_, err := time.Parse(time.RFC3339, s)
+		return err == nil
+	}
+	if feed.Title == "" || feed.ID == "" || feed.Author.Name == "" || !validTime(feed.Updated) {
+		t.Errorf("expected feed title, id, author and updated, got '%+v'", feed)
+	}
+	for i, e := range feed.Entries {
+		if e.Title == "" || e.ID == "" || e.Link.Href == "" || !validTime(e.Updated) {
+			t.Errorf("expected title, id, link and updated of entry %d, got '%+v'", i, e)
+		}
+	}
+	return feed
+}
+
+func Test_Write_Atom(t *testing.T) {
+	now = func() time.Time { return time.Date(2024, 5, 1, 12, 0, 0, 0, time.UTC) }
+	defer func() { now = time.Now }()
+
+	var buf bytes.Buffer
+	if err := Write(&buf, FormatAtom, testBookmarks()); err != nil {
+		t.Fatalf("expected no error on Write(), got '%v'", err)
+	}
+	golden.Check(t, "atom", buf.String())
+
+	feed := checkAtom(t, buf.Bytes())
+	if len(feed.Entries) != 2 {
+		t.Fatalf("expected 2 entries, got %d", len(feed.Entries))
+	}
+	first := feed.Entries[0]
+	if first.Title != "A <b> & [c]" || first.Link.Href != "https://a.com/?q=1&r=2" ||
+		first.Summary != `some "comment"` || len(first.Categories) != 2 || first.Categories[1].Term != "y" {
+		t.Errorf("expected first bookmark as entry, got '%+v'", first)
+	}
+	if first.Updated != "2023-11-14T22:13:20Z" {
+		t.Errorf("expected entry updated when added, got '%s'", first.Updated)
+	}
+	if second := feed.Entries[1]; second.Title != "https://b.com/(wiki)" || second.Updated != "2024-05-01T12:00:00Z" {
+		t.Errorf("expected url as title and export time as updated, got '%+v'", second)
+	}
+}
+
+func Test_ExportAtom(t *testing.T) {
+	each := func(fn func(bukudb.Bookmark) error) error {
+		for _, b := range testBookmarks() {
+			if err := fn(b); err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+
+	var buf bytes.Buffer
+	if err := ExportAtom(&buf, each, HasTags([]string{"Y"})); err != nil {
+		t.Fatalf("expected no error on ExportAtom(), got '%v'", err)
+	}
+	if feed := checkAtom(t, buf.Bytes()); len(feed.Entries) != 1 || feed.Entries[0].ID != "https://a.com/?q=1&r=2" {
+		t.Errorf("expected only the bookmark tagged y, got '%+v'", feed.Entries)
+	}
+
+	buf.Reset()
+	if err := ExportAtom(&buf, each, nil); err != nil {
+		t.Fatalf("expected no error on ExportAtom(), got '%v'", err)
+	}
+	if feed := checkAtom(t, buf.Bytes()); len(feed.Entries) != 2 {
+		t.Errorf("expected every bookmark without a filter, got %d", len(feed.Entries))
+	}
+}
+
+func Test_HasTags(t *testing.T) {
+	b := bukudb.Bookmark{Tags: []string{"go", "reading-list"}}
+	tests := []struct {
+		tags     []string
+		expected bool
+	}{
+		{nil, true},
+		{[]string{"reading-list"}, true},
+		{[]string{"Go", "reading-list"}, true},
+		{[]string{"go", "rust"}, false},
+	}
+	for _, tt := range tests {
+		if actual := HasTags(tt.tags)(b); actual != tt.expected {
+			t.Errorf("expected HasTags(%v) to be %v, got %v", tt.tags, tt.expected, actual)
+		}
+	}
+}
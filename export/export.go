@@ -0,0 +1,229 @@
+// export, writes bookmarks to files other programs can import
+package export
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"html"
+	"io"
+	"strings"
+
+	"github.com/VannRR/robuku/bukudb"
+)
+
+// Format is a bookmark export format.
+type Format string
+
+const (
+	// FormatHTML is the Netscape bookmark file format read by browsers and
+	// by buku --import.
+	FormatHTML Format = "html"
+
+	// FormatJSON is a JSON array of bookmarks.
+	FormatJSON Format = "json"
+
+	// FormatMarkdown is a markdown list of links.
+	FormatMarkdown Format = "md"
+
+	// FormatAtom is an Atom feed with an entry for every bookmark, for
+	// feed readers.
+	FormatAtom Format = "atom"
+)
+
+// Formats are all supported export formats.
+var Formats = []Format{FormatHTML, FormatJSON, FormatMarkdown, FormatAtom}
+
+// ParseFormat returns the Format named s.
+func ParseFormat(s string) (Format, error) {
+	for _, f := range Formats {
+		if string(f) == s {
+			return f, nil
+		}
+	}
+	return "", fmt.Errorf("unknown export format '%s'", s)
+}
+
+// Each calls fn for every bookmark to export in order, stopping at the
+// first error of fn. (*bukudb.BukuDB).ForEach is an Each.
+type Each func(fn func(bukudb.Bookmark) error) error
+
+// flushEvery is the number of bookmarks written between flushes of the
+// buffered output.
+const flushEvery = 256
+
+// encoder renders one export format a bookmark at a time.
+type encoder interface {
+	header() string
+	// bookmark appends the i-th bookmark to buf.
+	bookmark(buf *bytes.Buffer, bm bukudb.Bookmark, i int) error
+	// footer returns the end of the output after n bookmarks.
+	footer(n int) string
+}
+
+// Write writes bookmarks to w in format.
+func Write(w io.Writer, format Format, bookmarks []bukudb.Bookmark) error {
+	return WriteEach(w, format, func(fn func(bukudb.Bookmark) error) error {
+		for _, b := range bookmarks {
+			if err := fn(b); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+}
+
+// WriteEach writes the bookmarks of each to w in format as they come,
+// so only one of them is held in memory. It returns at the first write
+// error.
+func WriteEach(w io.Writer, format Format, each Each) error {
+	var enc encoder
+	switch format {
+	case FormatHTML:
+		enc = htmlEncoder{}
+	case FormatJSON:
+		enc = jsonEncoder{}
+	case FormatMarkdown:
+		enc = markdownEncoder{}
+	case FormatAtom:
+		enc = newAtomEncoder()
+	default:
+		return fmt.Errorf("unknown export format '%s'", format)
+	}
+
+	bw := bufio.NewWriter(w)
+	if _, err := bw.WriteString(enc.header()); err != nil {
+		return err
+	}
+	var buf bytes.Buffer
+	n := 0
+	err := each(func(bm bukudb.Bookmark) error {
+		buf.Reset()
+		if err := enc.bookmark(&buf, bm, n); err != nil {
+			return err
+		}
+		if _, err := bw.Write(buf.Bytes()); err != nil {
+			return err
+		}
+		n++
+		if n%flushEvery == 0 {
+			return bw.Flush()
+		}
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+	if _, err := bw.WriteString(enc.footer(n)); err != nil {
+		return err
+	}
+	return bw.Flush()
+}
+
+type htmlEncoder struct{}
+
+func (htmlEncoder) header() string {
+	return `<!DOCTYPE NETSCAPE-Bookmark-file-1>
+<META HTTP-EQUIV="Content-Type" CONTENT="text/html; charset=UTF-8">
+<TITLE>Bookmarks</TITLE>
+<H1>Bookmarks</H1>
+<DL><p>
+`
+}
+
+func (htmlEncoder) bookmark(b *bytes.Buffer, bm bukudb.Bookmark, _ int) error {
+	title := bm.Title
+	if title == "" {
+		title = bm.URL
+	}
+	fmt.Fprintf(b, `    <DT><A HREF="%s"`, html.EscapeString(bm.URL))
+	if bm.Created != nil {
+		fmt.Fprintf(b, ` ADD_DATE="%d"`, bm.Created.Unix())
+	}
+	if len(bm.Tags) > 0 {
+		fmt.Fprintf(b, ` TAGS="%s"`, html.EscapeString(strings.Join(bm.Tags, ",")))
+	}
+	fmt.Fprintf(b, ">%s</A>\n", html.EscapeString(title))
+	if bm.Comment != "" {
+		fmt.Fprintf(b, "    <DD>%s\n", html.EscapeString(bm.Comment))
+	}
+	return nil
+}
+
+func (htmlEncoder) footer(int) string {
+	return "</DL><p>\n"
+}
+
+// jsonBookmark is the JSON representation of a bookmark.
+type jsonBookmark struct {
+	URL     string   `json:"url"`
+	Title   string   `json:"title"`
+	Tags    []string `json:"tags"`
+	Comment string   `json:"comment"`
+	Created *int64   `json:"created,omitempty"`
+}
+
+// jsonEncoder writes an indented JSON array, like json.Encoder with an
+// indent of two spaces would.
+type jsonEncoder struct{}
+
+func (jsonEncoder) header() string {
+	return "["
+}
+
+func (jsonEncoder) bookmark(b *bytes.Buffer, bm bukudb.Bookmark, i int) error {
+	jb := jsonBookmark{URL: bm.URL, Title: bm.Title, Tags: bm.Tags, Comment: bm.Comment}
+	if jb.Tags == nil {
+		jb.Tags = []string{}
+	}
+	if bm.Created != nil {
+		created := bm.Created.Unix()
+		jb.Created = &created
+	}
+	data, err := json.MarshalIndent(jb, "  ", "  ")
+	if err != nil {
+		return err
+	}
+
+	if i > 0 {
+		b.WriteString(",")
+	}
+	b.WriteString("\n  ")
+	b.Write(data)
+	return nil
+}
+
+func (jsonEncoder) footer(n int) string {
+	if n == 0 {
+		return "]\n"
+	}
+	return "\n]\n"
+}
+
+// markdownEscaper escapes the characters that would end a link early.
+var markdownEscaper = strings.NewReplacer(`\`, `\\`, `[`, `\[`, `]`, `\]`)
+
+type markdownEncoder struct{}
+
+func (markdownEncoder) header() string {
+	return ""
+}
+
+func (markdownEncoder) bookmark(b *bytes.Buffer, bm bukudb.Bookmark, _ int) error {
+	title := bm.Title
+	if title == "" {
+		title = bm.URL
+	}
+	url := strings.NewReplacer("(", "%28", ")", "%29", " ", "%20").Replace(bm.URL)
+	fmt.Fprintf(b, "- [%s](%s)", markdownEscaper.Replace(title), url)
+	if len(bm.Tags) > 0 {
+		fmt.Fprintf(b, " <!-- TAGS: %s -->", strings.Join(bm.Tags, ","))
+	}
+	b.WriteString("\n")
+	return nil
+}
+
+func (markdownEncoder) footer(int) string {
+	return ""
+}
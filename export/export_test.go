@@ -0,0 +1,241 @@
+package export
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"math"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/VannRR/robuku/bukudb"
+)
+
+func testBookmarks() []bukudb.Bookmark {
+	created := time.Unix(1700000000, 0)
+	return []bukudb.Bookmark{
+		{ID: 1, URL: "https://a.com/?q=1&r=2", Title: "A <b> & [c]", Tags: []string{"x", "y"},
+			Comment: "some \"comment\"", Created: &created},
+		{ID: 2, URL: "https://b.com/(wiki)"},
+	}
+}
+
+func Test_ParseFormat(t *testing.T) {
+	for _, f := range Formats {
+		if actual, err := ParseFormat(string(f)); err != nil || actual != f {
+			t.Errorf("expected format '%s', got '%s' and error '%v'", f, actual, err)
+		}
+	}
+	if _, err := ParseFormat("xml"); err == nil {
+		t.Error("expected error on ParseFormat('xml'), got nil")
+	}
+}
+
+func Test_Write_HTML(t *testing.T) {
+	var buf bytes.Buffer
+	if err := Write(&buf, FormatHTML, testBookmarks()); err != nil {
+		t.Fatalf("expected no error on Write(), got '%v'", err)
+	}
+
+	expected := `<!DOCTYPE NETSCAPE-Bookmark-file-1>
+<META HTTP-EQUIV="Content-Type" CONTENT="text/html; charset=UTF-8">
+<TITLE>Bookmarks</TITLE>
+<H1>Bookmarks</H1>
+<DL><p>
+    <DT><A HREF="https://a.com/?q=1&amp;r=2" ADD_DATE="1700000000" TAGS="x,y">A &lt;b&gt; &amp; [c]</A>
+    <DD>some &#34;comment&#34;
+    <DT><A HREF="https://b.com/(wiki)">https://b.com/(wiki)</A>
+</DL><p>
+`
+	if buf.String() != expected {
+		t.Errorf("expected html:\n%s\ngot:\n%s", expected, buf.String())
+	}
+}
+
+func Test_Write_JSON(t *testing.T) {
+	var buf bytes.Buffer
+	if err := Write(&buf, FormatJSON, testBookmarks()); err != nil {
+		t.Fatalf("expected no error on Write(), got '%v'", err)
+	}
+
+	var actual []jsonBookmark
+	if err := json.Unmarshal(buf.Bytes(), &actual); err != nil {
+		t.Fatalf("expected valid json, got '%v'", err)
+	}
+	if len(actual) != 2 {
+		t.Fatalf("expected 2 bookmarks, got %d", len(actual))
+	}
+	if actual[0].Title != "A <b> & [c]" || actual[0].Created == nil || *actual[0].Created != 1700000000 {
+		t.Errorf("expected first bookmark to round trip, got '%v'", actual[0])
+	}
+	if actual[1].Tags == nil || actual[1].Created != nil {
+		t.Errorf("expected empty tags and no created time, got '%v'", actual[1])
+	}
+	if !strings.Contains(buf.String(), `"tags": []`) {
+		t.Errorf("expected empty tags to be written as [], got '%s'", buf.String())
+	}
+}
+
+func Test_Write_Markdown(t *testing.T) {
+	var buf bytes.Buffer
+	if err := Write(&buf, FormatMarkdown, testBookmarks()); err != nil {
+		t.Fatalf("expected no error on Write(), got '%v'", err)
+	}
+
+	expected := "- [A <b> & \\[c\\]](https://a.com/?q=1&r=2) <!-- TAGS: x,y -->\n" +
+		"- [https://b.com/(wiki)](https://b.com/%28wiki%29)\n"
+	if buf.String() != expected {
+		t.Errorf("expected markdown:\n%s\ngot:\n%s", expected, buf.String())
+	}
+}
+
+func Test_Write_UnknownFormat(t *testing.T) {
+	if err := Write(&bytes.Buffer{}, Format("xml"), nil); err == nil {
+		t.Error("expected error on Write() with unknown format, got nil")
+	}
+}
+
+// newTestDB returns a database with n bookmarks, every third one tagged.
+func newTestDB(tb testing.TB, n int) *bukudb.BukuDB {
+	tb.Helper()
+	path := filepath.Join(tb.TempDir(), "bookmarks.db")
+	if err := bukudb.InitSchema(path); err != nil {
+		tb.Fatal(err)
+	}
+	db, err := bukudb.NewBukuDB(path, bukudb.WithMaxBookmarks(math.MaxUint16))
+	if err != nil {
+		tb.Fatal(err)
+	}
+	tb.Cleanup(func() { db.Close() })
+
+	var urls strings.Builder
+	for i := range n {
+		fmt.Fprintf(&urls, "https://example.com/%d?a=<%d>&b=[x]", i, i)
+		if i%3 == 0 {
+			urls.WriteString(" # comment \"quoted\"")
+		}
+		urls.WriteString("\n")
+	}
+	if _, _, err := db.ImportText(strings.NewReader(urls.String()), []string{"tag"}, bukudb.ConflictSkip); err != nil {
+		tb.Fatal(err)
+	}
+	return db
+}
+
+func Test_WriteEach_MatchesWrite(t *testing.T) {
+	for _, n := range []int{0, 1, flushEvery + 1} {
+		db := newTestDB(t, n)
+		bookmarks, err := db.GetAll()
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		for _, format := range Formats {
+			var expected, actual bytes.Buffer
+			if err := Write(&expected, format, bookmarks); err != nil {
+				t.Fatalf("expected no error on Write(), got '%v'", err)
+			}
+			if err := WriteEach(&actual, format, db.ForEach); err != nil {
+				t.Fatalf("expected no error on WriteEach(), got '%v'", err)
+			}
+			if !bytes.Equal(expected.Bytes(), actual.Bytes()) {
+				t.Errorf("%s with %d bookmarks: expected:\n%s\ngot:\n%s", format, n, expected.String(), actual.String())
+			}
+		}
+	}
+}
+
+func Test_Write_JSON_MatchesEncoder(t *testing.T) {
+	for n := range 3 {
+		bookmarks := testBookmarks()[:min(n, 2)]
+		var actual bytes.Buffer
+		if err := Write(&actual, FormatJSON, bookmarks); err != nil {
+			t.Fatalf("expected no error on Write(), got '%v'", err)
+		}
+
+		all := make([]jsonBookmark, 0, len(bookmarks))
+		for _, b := range bookmarks {
+			jb := jsonBookmark{URL: b.URL, Title: b.Title, Tags: b.Tags, Comment: b.Comment}
+			if jb.Tags == nil {
+				jb.Tags = []string{}
+			}
+			if b.Created != nil {
+				created := b.Created.Unix()
+				jb.Created = &created
+			}
+			all = append(all, jb)
+		}
+		var expected bytes.Buffer
+		enc := json.NewEncoder(&expected)
+		enc.SetIndent("", "  ")
+		if err := enc.Encode(all); err != nil {
+			t.Fatal(err)
+		}
+		if actual.String() != expected.String() {
+			t.Errorf("expected json:\n%s\ngot:\n%s", expected.String(), actual.String())
+		}
+	}
+}
+
+type failingWriter struct {
+	writes int
+}
+
+func (w *failingWriter) Write([]byte) (int, error) {
+	w.writes++
+	return 0, errors.New("disk full")
+}
+
+func Test_WriteEach_WriteError(t *testing.T) {
+	calls := 0
+	each := func(fn func(bukudb.Bookmark) error) error {
+		for {
+			calls++
+			if err := fn(testBookmarks()[0]); err != nil {
+				return err
+			}
+		}
+	}
+
+	w := &failingWriter{}
+	err := WriteEach(w, FormatHTML, each)
+	if err == nil || !strings.Contains(err.Error(), "disk full") {
+		t.Errorf("expected write error, got '%v'", err)
+	}
+	if calls > flushEvery || w.writes != 1 {
+		t.Errorf("expected to stop at the first flush, got %d bookmarks and %d writes", calls, w.writes)
+	}
+}
+
+// benchmarkRows is the number of bookmarks of the export benchmarks.
+const benchmarkRows = 10000
+
+// BenchmarkWrite loads every bookmark before exporting them.
+func BenchmarkWrite(b *testing.B) {
+	db := newTestDB(b, benchmarkRows)
+	b.ReportAllocs()
+	for range b.N {
+		bookmarks, err := db.GetAll()
+		if err != nil {
+			b.Fatal(err)
+		}
+		if err := Write(io.Discard, FormatJSON, bookmarks); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
+
+// BenchmarkWriteEach streams the bookmarks, holding one at a time.
+func BenchmarkWriteEach(b *testing.B) {
+	db := newTestDB(b, benchmarkRows)
+	b.ReportAllocs()
+	for range b.N {
+		if err := WriteEach(io.Discard, FormatJSON, db.ForEach); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
@@ -0,0 +1,84 @@
+package export
+
+import (
+	"fmt"
+	"html/template"
+	"io"
+	"net/url"
+	"strings"
+
+	"github.com/VannRR/robuku/bukudb"
+)
+
+// readingListTemplate is a self-contained page listing the bookmarks of a
+// tag, with its CSS inline so it can be sent as a single file.
+const readingListTemplate = `<!DOCTYPE html>
+<html lang="en">
+<head>
+<meta charset="utf-8">
+<meta name="viewport" content="width=device-width, initial-scale=1">
+<title>Reading list: {{.Tag}}</title>
+<style>
+body { max-width: 42rem; margin: 2rem auto; padding: 0 1rem; font: 1rem/1.5 sans-serif; color: #222; background: #fff; }
+h1 { font-size: 1.5rem; }
+ol { padding-left: 1.5rem; }
+li { margin-bottom: 1.25rem; }
+a { color: #1a5fb4; font-weight: bold; text-decoration: none; }
+a:hover { text-decoration: underline; }
+.domain { color: #666; font-size: 0.875rem; margin-left: 0.5rem; }
+p { margin: 0.25rem 0 0; }
+@media (prefers-color-scheme: dark) { body { color: #ddd; background: #1e1e1e; } a { color: #78aeed; } .domain { color: #999; } }
+</style>
+</head>
+<body>
+<h1>Reading list: {{.Tag}}</h1>
+<ol>
+{{- range .Items}}
+<li>
+<a href="{{.URL}}">{{.Title}}</a>{{if .Domain}}<span class="domain">{{.Domain}}</span>{{end}}
+{{- if .Comment}}
+<p>{{.Comment}}</p>
+{{- end}}
+</li>
+{{- end}}
+</ol>
+</body>
+</html>
+`
+
+var readingList = template.Must(template.New("reading-list").Parse(readingListTemplate))
+
+// readingListItem is a bookmark as shown on a reading list.
+type readingListItem struct {
+	URL     string
+	Title   string
+	Domain  string
+	Comment string
+}
+
+// ReadingList writes the bookmarks of each tagged with tag to w as a
+// standalone HTML page, and returns how many there were.
+func ReadingList(w io.Writer, tag string, each Each) (int, error) {
+	data := struct {
+		Tag   string
+		Items []readingListItem
+	}{Tag: tag}
+	err := Filtered(each, HasTags([]string{tag}))(func(b bukudb.Bookmark) error {
+		item := readingListItem{URL: b.URL, Title: b.Title, Comment: b.Comment}
+		if item.Title == "" {
+			item.Title = b.URL
+		}
+		if u, err := url.Parse(b.URL); err == nil {
+			item.Domain = strings.TrimPrefix(u.Hostname(), "www.")
+		}
+		data.Items = append(data.Items, item)
+		return nil
+	})
+	if err != nil {
+		return 0, err
+	}
+	if err := readingList.Execute(w, data); err != nil {
+		return 0, fmt.Errorf("failed to write reading list: %w", err)
+	}
+	return len(data.Items), nil
+}
@@ -0,0 +1,132 @@
+package export
+
+import (
+	"encoding/xml"
+	"errors"
+	"io"
+	"strings"
+	"testing"
+
+	"github.com/VannRR/robuku/bukudb"
+)
+
+// readingListPage is what a reader sees of a reading list.
+type readingListPage struct {
+	title      string
+	links      []string // the href of every anchor
+	texts      []string // the text of every anchor
+	paragraphs []string
+	styles     int
+	scripts    int
+}
+
+// parseReadingList fails t unless data parses as HTML and returns its
+// structure.
+func parseReadingList(t *testing.T, data string) readingListPage {
+	t.Helper()
+
+	d := xml.NewDecoder(strings.NewReader(data))
+	d.Strict = false
+	d.AutoClose = xml.HTMLAutoClose
+	d.Entity = xml.HTMLEntity
+
+	var page readingListPage
+	var open string
+	for {
+		tok, err := d.Token()
+		if errors.Is(err, io.EOF) {
+			break
+		}
+		if err != nil {
+			t.Fatalf("expected parseable HTML, got '%v' in:\n%s", err, data)
+		}
+		switch tok := tok.(type) {
+		case xml.StartElement:
+			open = tok.Name.Local
+			switch open {
+			case "a":
+				for _, a := range tok.Attr {
+					if a.Name.Local == "href" {
+						page.links = append(page.links, a.Value)
+					}
+				}
+				page.texts = append(page.texts, "")
+			case "p":
+				page.paragraphs = append(page.paragraphs, "")
+			case "style":
+				page.styles++
+			case "script":
+				page.scripts++
+			}
+		case xml.EndElement:
+			open = ""
+		case xml.CharData:
+			switch open {
+			case "title":
+				page.title += string(tok)
+			case "a":
+				page.texts[len(page.texts)-1] += string(tok)
+			case "p":
+				page.paragraphs[len(page.paragraphs)-1] += string(tok)
+			}
+		}
+	}
+	return page
+}
+
+func Test_ReadingList(t *testing.T) {
+	bookmarks := []bukudb.Bookmark{
+		{URL: "https://www.a.com/?q=1&r=2", Title: "A <b> & [c]", Tags: []string{"read", "x"},
+			Comment: `worth it, "really" <script>alert(1)</script>`},
+		{URL: "https://b.com/untagged", Title: "B"},
+		{URL: "https://c.org/(wiki)", Tags: []string{"Read"}},
+		{URL: "javascript:alert(1)", Title: "D", Tags: []string{"read"}},
+	}
+	each := func(fn func(bukudb.Bookmark) error) error {
+		for _, b := range bookmarks {
+			if err := fn(b); err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+	var b strings.Builder
+	n, err := ReadingList(&b, "read", each)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if n != 3 {
+		t.Errorf("expected 3 bookmarks, got %d", n)
+	}
+
+	page := parseReadingList(t, b.String())
+	if page.title != "Reading list: read" {
+		t.Errorf("expected title 'Reading list: read', got '%s'", page.title)
+	}
+	if page.styles != 1 || page.scripts != 0 {
+		t.Errorf("expected inline style and no script, got %d styles and %d scripts", page.styles, page.scripts)
+	}
+	// html/template percent-encodes the parentheses and drops the javascript: URL
+	expectedLinks := []string{"https://www.a.com/?q=1&r=2", "https://c.org/%28wiki%29", "#ZgotmplZ"}
+	if strings.Join(page.links, " ") != strings.Join(expectedLinks, " ") {
+		t.Errorf("expected links %q, got %q", expectedLinks, page.links)
+	}
+	expectedTexts := []string{"A <b> & [c]", "https://c.org/(wiki)", "D"}
+	if strings.Join(page.texts, "|") != strings.Join(expectedTexts, "|") {
+		t.Errorf("expected link texts %q, got %q", expectedTexts, page.texts)
+	}
+	if len(page.paragraphs) != 1 || page.paragraphs[0] != bookmarks[0].Comment {
+		t.Errorf("expected the comment as the only paragraph, got %q", page.paragraphs)
+	}
+	if !strings.Contains(b.String(), `<span class="domain">a.com</span>`) {
+		t.Errorf("expected domain of the first bookmark, got:\n%s", b.String())
+	}
+}
+
+func Test_ReadingList_Error(t *testing.T) {
+	failing := func(func(bukudb.Bookmark) error) error { return errors.New("read failed") }
+	var b strings.Builder
+	if _, err := ReadingList(&b, "read", failing); err == nil || b.Len() != 0 {
+		t.Errorf("expected error and no output, got '%v' and '%s'", err, b.String())
+	}
+}
@@ -0,0 +1,264 @@
+// importer, adds bookmarks from files written by export or by browsers to
+// the buku database
+package importer
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"html"
+	"io"
+	"path/filepath"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/VannRR/robuku/bukudb"
+	"github.com/VannRR/robuku/export"
+)
+
+// errNoURL is reported for bookmarks without a URL.
+var errNoURL = errors.New("bookmark has no URL")
+
+// ProgressInterval is the number of bookmarks added between the calls of a
+// Progress.
+const ProgressInterval = 100
+
+// Progress is called with the number of bookmarks done and their total
+// while importing.
+type Progress func(done, total int)
+
+// Import reads bookmarks in format from r and adds those whose URL is not in
+// db yet, the others are handled by policy. The outcome of every bookmark is
+// written to report as TSV unless report is nil.
+func Import(db bukudb.DBInterface, r io.Reader, format export.Format, report io.Writer,
+	policy bukudb.ConflictPolicy) (Summary, error) {
+	return ImportProgress(db, r, format, report, policy, nil)
+}
+
+// ImportProgress is Import calling progress while adding the bookmarks,
+// like ApplyProgress.
+func ImportProgress(db bukudb.DBInterface, r io.Reader, format export.Format, report io.Writer,
+	policy bukudb.ConflictPolicy, progress Progress) (Summary, error) {
+	bookmarks, err := Parse(r, format)
+	if err != nil {
+		return Summary{}, err
+	}
+	return ApplyProgress(db, bookmarks, report, policy, progress)
+}
+
+// FormatOf returns the format of the bookmark file at path by its
+// extension.
+func FormatOf(path string) (export.Format, error) {
+	switch strings.ToLower(filepath.Ext(path)) {
+	case ".html", ".htm":
+		return export.FormatHTML, nil
+	case ".json":
+		return export.FormatJSON, nil
+	default:
+		return "", fmt.Errorf("unknown bookmark file type '%s', expected .html or .json", path)
+	}
+}
+
+// Parse reads bookmarks in format from r without adding them.
+func Parse(r io.Reader, format export.Format) ([]bukudb.Bookmark, error) {
+	switch format {
+	case export.FormatHTML:
+		return ParseHTML(r)
+	case export.FormatJSON:
+		return ParseJSON(r)
+	default:
+		return nil, fmt.Errorf("unsupported import format '%s'", format)
+	}
+}
+
+// Apply adds bookmarks to db. Bookmarks with a URL already in db or earlier
+// in bookmarks are handled by policy, each in a single update. The outcome
+// of every bookmark is written to report as TSV unless report is nil.
+func Apply(db bukudb.DBInterface, bookmarks []bukudb.Bookmark, report io.Writer,
+	policy bukudb.ConflictPolicy) (Summary, error) {
+	return ApplyProgress(db, bookmarks, report, policy, nil)
+}
+
+// ApplyProgress is Apply calling progress, unless it is nil, after every
+// ProgressInterval bookmarks and after the last one. Duplicates and failed
+// bookmarks count as done, so done reaches the total.
+func ApplyProgress(db bukudb.DBInterface, bookmarks []bukudb.Bookmark, report io.Writer,
+	policy bukudb.ConflictPolicy, progress Progress) (Summary, error) {
+	rep := newReporter(report)
+	existing, err := db.GetAll()
+	if err != nil {
+		return Summary{}, err
+	}
+	ids := make(map[string]uint16, len(existing)+len(bookmarks))
+	for _, b := range existing {
+		ids[b.URL] = b.ID
+	}
+
+	for i, b := range bookmarks {
+		apply(db, b, policy, ids, rep)
+		if done := i + 1; progress != nil && (done%ProgressInterval == 0 || done == len(bookmarks)) {
+			progress(done, len(bookmarks))
+		}
+	}
+	return rep.summary, rep.err
+}
+
+// apply adds b to db and reports the outcome to rep, ids maps the URLs
+// already in db to their IDs.
+func apply(db bukudb.DBInterface, b bukudb.Bookmark, policy bukudb.ConflictPolicy,
+	ids map[string]uint16, rep *reporter) {
+	if b.URL == "" {
+		rep.failed(b.URL, errNoURL)
+		return
+	}
+	if id, ok := ids[b.URL]; ok {
+		// the ID of a bookmark added on a dry run is not known
+		if policy == bukudb.ConflictSkip || id == 0 {
+			rep.duplicate(b.URL, id)
+		} else if err := resolveConflict(db, policy, id, b); err != nil {
+			rep.failed(b.URL, err)
+		} else {
+			rep.updated(b.URL, id, policy)
+		}
+		return
+	}
+
+	before := db.Len()
+	if err := db.Add(b); err != nil {
+		rep.failed(b.URL, err)
+		return
+	}
+	var id uint16
+	if db.Len() > before {
+		id = uint16(db.Len())
+	}
+	ids[b.URL] = id
+	rep.added(b.URL, id)
+}
+
+// resolveConflict applies policy to the bookmark with id, which has the URL
+// of the imported bookmark b.
+func resolveConflict(db bukudb.DBInterface, policy bukudb.ConflictPolicy, id uint16, b bukudb.Bookmark) error {
+	switch policy {
+	case bukudb.ConflictOverwrite:
+		return db.Update(id, b, bukudb.MaskTitle|bukudb.MaskComment|bukudb.MaskTags)
+	case bukudb.ConflictMergeTags:
+		if len(b.Tags) == 0 {
+			return nil
+		}
+		return db.AddTags(id, b.Tags)
+	}
+	return fmt.Errorf("unknown conflict policy '%s'", policy)
+}
+
+// Preview counts what Apply would do with bookmarks without changing db.
+func Preview(db bukudb.DBInterface, bookmarks []bukudb.Bookmark) (Summary, error) {
+	existing, err := db.GetAll()
+	if err != nil {
+		return Summary{}, err
+	}
+	seen := make(map[string]bool, len(existing)+len(bookmarks))
+	for _, b := range existing {
+		seen[b.URL] = true
+	}
+
+	var s Summary
+	for _, b := range bookmarks {
+		switch {
+		case b.URL == "":
+			s.Failed++
+		case seen[b.URL]:
+			s.Duplicates++
+		default:
+			s.Added++
+			seen[b.URL] = true
+		}
+	}
+	return s, nil
+}
+
+// jsonBookmark is a bookmark as written by export.FormatJSON.
+type jsonBookmark struct {
+	URL     string   `json:"url"`
+	Title   string   `json:"title"`
+	Tags    []string `json:"tags"`
+	Comment string   `json:"comment"`
+	Created *int64   `json:"created,omitempty"`
+}
+
+// ParseJSON reads bookmarks written by export.FormatJSON.
+func ParseJSON(r io.Reader) ([]bukudb.Bookmark, error) {
+	var in []jsonBookmark
+	if err := json.NewDecoder(r).Decode(&in); err != nil {
+		return nil, fmt.Errorf("failed to read JSON bookmarks: %w", err)
+	}
+
+	bookmarks := make([]bukudb.Bookmark, 0, len(in))
+	for _, jb := range in {
+		b := bukudb.Bookmark{URL: strings.TrimSpace(jb.URL), Title: jb.Title,
+			Tags: jb.Tags, Comment: jb.Comment}
+		if jb.Created != nil {
+			created := time.Unix(*jb.Created, 0)
+			b.Created = &created
+		}
+		bookmarks = append(bookmarks, b)
+	}
+	return bookmarks, nil
+}
+
+var (
+	// htmlLinkRegex matches a link of a Netscape bookmark file and the
+	// description following it.
+	htmlLinkRegex = regexp.MustCompile(`(?is)<DT>\s*<A\s([^>]*)>(.*?)</A>(?:\s*<DD>([^<]*))?`)
+
+	// htmlAttrRegex matches a quoted attribute of a link.
+	htmlAttrRegex = regexp.MustCompile(`(?is)([a-z_]+)\s*=\s*"([^"]*)"`)
+)
+
+// ParseHTML reads the links of a Netscape bookmark file, as written by
+// browsers and export.FormatHTML.
+func ParseHTML(r io.Reader) ([]bukudb.Bookmark, error) {
+	data, err := io.ReadAll(r)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read HTML bookmarks: %w", err)
+	}
+
+	var bookmarks []bukudb.Bookmark
+	for _, m := range htmlLinkRegex.FindAllStringSubmatch(string(data), -1) {
+		var b bukudb.Bookmark
+		for _, attr := range htmlAttrRegex.FindAllStringSubmatch(m[1], -1) {
+			value := html.UnescapeString(attr[2])
+			switch strings.ToUpper(attr[1]) {
+			case "HREF":
+				b.URL = strings.TrimSpace(value)
+			case "TAGS":
+				b.Tags = splitTags(value)
+			case "ADD_DATE":
+				if sec, err := strconv.ParseInt(value, 10, 64); err == nil {
+					created := time.Unix(sec, 0)
+					b.Created = &created
+				}
+			}
+		}
+		// export writes the URL as the title of untitled bookmarks
+		if title := strings.TrimSpace(html.UnescapeString(m[2])); title != b.URL {
+			b.Title = title
+		}
+		b.Comment = strings.TrimSpace(html.UnescapeString(m[3]))
+		bookmarks = append(bookmarks, b)
+	}
+	return bookmarks, nil
+}
+
+// splitTags splits the comma separated tags s, dropping empty ones.
+func splitTags(s string) []string {
+	var tags []string
+	for _, tag := range strings.Split(s, ",") {
+		if tag = strings.TrimSpace(tag); tag != "" {
+			tags = append(tags, tag)
+		}
+	}
+	return tags
+}
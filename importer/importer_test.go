@@ -0,0 +1,314 @@
+package importer
+
+import (
+	"bytes"
+	"fmt"
+	"path/filepath"
+	"slices"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/VannRR/robuku/bukudb"
+	"github.com/VannRR/robuku/export"
+)
+
+func newTestDB(t *testing.T, urls ...string) *bukudb.BukuDB {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), "bookmarks.db")
+	if err := bukudb.InitSchema(path); err != nil {
+		t.Fatal(err)
+	}
+	db, err := bukudb.NewBukuDB(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { db.Close() })
+	for _, url := range urls {
+		if err := db.Add(bukudb.Bookmark{URL: url}); err != nil {
+			t.Fatal(err)
+		}
+	}
+	return db
+}
+
+func testBookmarks() []bukudb.Bookmark {
+	created := time.Unix(1700000000, 0)
+	return []bukudb.Bookmark{
+		{URL: "https://a.com/?q=1&r=2", Title: "A <b> & [c]", Tags: []string{"x", "y"},
+			Comment: "some \"comment\"", Created: &created},
+		{URL: "https://b.com/(wiki)"},
+	}
+}
+
+func Test_Import_RoundTrip(t *testing.T) {
+	for _, format := range []export.Format{export.FormatHTML, export.FormatJSON} {
+		var buf bytes.Buffer
+		if err := export.Write(&buf, format, testBookmarks()); err != nil {
+			t.Fatal(err)
+		}
+
+		db := newTestDB(t)
+		summary, err := Import(db, &buf, format, nil, bukudb.ConflictSkip)
+		if err != nil {
+			t.Fatalf("%s: expected no error on Import(), got '%v'", format, err)
+		}
+		if summary != (Summary{Added: 2}) {
+			t.Errorf("%s: expected 2 added, got %+v", format, summary)
+		}
+
+		bookmarks, _ := db.GetAll()
+		expected := testBookmarks()
+		if len(bookmarks) != len(expected) {
+			t.Fatalf("%s: expected %d bookmarks, got %v", format, len(expected), bookmarks)
+		}
+		for i, b := range bookmarks {
+			e := expected[i]
+			if b.URL != e.URL || b.Title != e.Title || b.Comment != e.Comment ||
+				strings.Join(b.Tags, ",") != strings.Join(e.Tags, ",") {
+				t.Errorf("%s: expected %+v, got %+v", format, e, b)
+			}
+		}
+	}
+}
+
+func Test_Import_Report(t *testing.T) {
+	db := newTestDB(t, "https://a.com")
+
+	input := `[
+  {"url": "https://a.com"},
+  {"url": "https://b.com"},
+  {"url": "https://b.com"},
+  {"url": " "}
+]`
+	var report strings.Builder
+	summary, err := Import(db, strings.NewReader(input), export.FormatJSON, &report, bukudb.ConflictSkip)
+	if err != nil {
+		t.Fatalf("expected no error on Import(), got '%v'", err)
+	}
+	if summary != (Summary{Added: 1, Duplicates: 2, Failed: 1}) {
+		t.Errorf("expected 1 added, 2 duplicates and 1 failed, got %+v", summary)
+	}
+
+	expected := reportHeader +
+		"duplicate\thttps://a.com\t1\t\n" +
+		"added\thttps://b.com\t2\t\n" +
+		"duplicate\thttps://b.com\t2\t\n" +
+		"failed\t\t\t" + errNoURL.Error() + "\n"
+	if report.String() != expected {
+		t.Errorf("expected report %q, got %q", expected, report.String())
+	}
+}
+
+func Test_Import_ConflictPolicies(t *testing.T) {
+	input := `[
+  {"url": "https://a.com", "title": "new title", "comment": "new comment", "tags": ["new", "shared"]},
+  {"url": "https://b.com", "title": "b"}
+]`
+	existing := bukudb.Bookmark{URL: "https://a.com", Title: "old title", Comment: "old comment",
+		Tags: []string{"old", "shared"}}
+
+	tests := []struct {
+		policy   bukudb.ConflictPolicy
+		summary  Summary
+		outcome  string
+		expected bukudb.Bookmark
+	}{
+		{bukudb.ConflictSkip, Summary{Added: 1, Duplicates: 1}, "duplicate", existing},
+		{bukudb.ConflictOverwrite, Summary{Added: 1, Updated: 1}, "overwritten",
+			bukudb.Bookmark{Title: "new title", Comment: "new comment", Tags: []string{"new", "shared"}}},
+		{bukudb.ConflictMergeTags, Summary{Added: 1, Updated: 1}, "merged",
+			bukudb.Bookmark{Title: "old title", Comment: "old comment", Tags: []string{"new", "old", "shared"}}},
+	}
+	for _, tt := range tests {
+		db := newTestDB(t)
+		if err := db.Add(existing); err != nil {
+			t.Fatal(err)
+		}
+
+		var report strings.Builder
+		summary, err := Import(db, strings.NewReader(input), export.FormatJSON, &report, tt.policy)
+		if err != nil {
+			t.Fatalf("%s: expected no error on Import(), got '%v'", tt.policy, err)
+		}
+		if summary != tt.summary {
+			t.Errorf("%s: expected summary %+v, got %+v", tt.policy, tt.summary, summary)
+		}
+		if row := tt.outcome + "\thttps://a.com\t1\t\n"; !strings.Contains(report.String(), row) {
+			t.Errorf("%s: expected report row %q, got %q", tt.policy, row, report.String())
+		}
+
+		b, _ := db.Get(1)
+		if b.Title != tt.expected.Title || b.Comment != tt.expected.Comment ||
+			strings.Join(b.Tags, ",") != strings.Join(tt.expected.Tags, ",") {
+			t.Errorf("%s: expected %+v, got %+v", tt.policy, tt.expected, b)
+		}
+	}
+}
+
+func Test_Import_DryRun(t *testing.T) {
+	db := newTestDB(t)
+	var msgs []string
+	dryRun := bukudb.NewDryRunDB(db, func(msg string) { msgs = append(msgs, msg) })
+
+	var report strings.Builder
+	summary, err := Import(dryRun, strings.NewReader(`[{"url": "https://a.com"}]`),
+		export.FormatJSON, &report, bukudb.ConflictSkip)
+	if err != nil || summary.Added != 1 {
+		t.Fatalf("expected 1 added, got %+v and error '%v'", summary, err)
+	}
+	if !strings.Contains(report.String(), "added\thttps://a.com\t\t\n") {
+		t.Errorf("expected added row without id, got %q", report.String())
+	}
+	if db.Len() != 0 || len(msgs) != 1 {
+		t.Errorf("expected nothing added and 1 message, got %d bookmarks and %v", db.Len(), msgs)
+	}
+}
+
+func Test_ImportProgress(t *testing.T) {
+	db := newTestDB(t, "https://e.com/0")
+	dryRun := bukudb.NewDryRunDB(db, func(string) {})
+
+	// 5050 links, every tenth a duplicate of the one before and the first
+	// already in db
+	var html strings.Builder
+	html.WriteString("<DL><p>\n")
+	const total = 5050
+	for i := range total {
+		n := i
+		if i%10 == 9 {
+			n = i - 1
+		}
+		fmt.Fprintf(&html, "<DT><A HREF=\"https://e.com/%d\">%d</A>\n", n, i)
+	}
+	html.WriteString("</DL><p>\n")
+
+	var calls [][2]int
+	progress := func(done, total int) { calls = append(calls, [2]int{done, total}) }
+	summary, err := ImportProgress(dryRun, strings.NewReader(html.String()), export.FormatHTML, nil,
+		bukudb.ConflictSkip, progress)
+	if err != nil {
+		t.Fatalf("expected no error, got '%v'", err)
+	}
+	if summary.Added != 4544 || summary.Duplicates != 506 {
+		t.Errorf("expected 4544 added and 506 duplicates, got %+v", summary)
+	}
+
+	if len(calls) != total/ProgressInterval+1 {
+		t.Fatalf("expected %d calls, got %d", total/ProgressInterval+1, len(calls))
+	}
+	for i, c := range calls[:len(calls)-1] {
+		if expected := [2]int{(i + 1) * ProgressInterval, total}; c != expected {
+			t.Errorf("expected call %d to be %v, got %v", i, expected, c)
+		}
+	}
+	if last := calls[len(calls)-1]; last != [2]int{total, total} {
+		t.Errorf("expected last call to be %v, got %v", [2]int{total, total}, last)
+	}
+
+	// no extra call when the total is a multiple of the interval
+	calls = nil
+	if _, err := ApplyProgress(dryRun, make([]bukudb.Bookmark, 2*ProgressInterval), nil,
+		bukudb.ConflictSkip, progress); err != nil {
+		t.Fatal(err)
+	}
+	expected := [][2]int{{ProgressInterval, 2 * ProgressInterval}, {2 * ProgressInterval, 2 * ProgressInterval}}
+	if !slices.Equal(calls, expected) {
+		t.Errorf("expected calls %v, got %v", expected, calls)
+	}
+}
+
+func Test_Import_Errors(t *testing.T) {
+	db := newTestDB(t)
+	if _, err := Import(db, strings.NewReader("{"), export.FormatJSON, nil, bukudb.ConflictSkip); err == nil {
+		t.Error("expected error for invalid JSON, got nil")
+	}
+	if _, err := Import(db, strings.NewReader(""), export.FormatMarkdown, nil, bukudb.ConflictSkip); err == nil {
+		t.Error("expected error for markdown, got nil")
+	}
+}
+
+func Test_ParseHTML(t *testing.T) {
+	input := `<!DOCTYPE NETSCAPE-Bookmark-file-1>
+<DL><p>
+    <DT><H3>Folder</H3>
+    <DL><p>
+        <dt><a href="https://a.com" add_date="1700000000" tags="go, ,web">A &amp; B</a>
+        <dd>about a
+        <DT><A HREF="https://b.com">https://b.com</A>
+    </DL><p>
+</DL><p>
+`
+	bookmarks, err := ParseHTML(strings.NewReader(input))
+	if err != nil {
+		t.Fatalf("expected no error on ParseHTML(), got '%v'", err)
+	}
+	if len(bookmarks) != 2 {
+		t.Fatalf("expected 2 bookmarks, got %+v", bookmarks)
+	}
+	a := bookmarks[0]
+	if a.URL != "https://a.com" || a.Title != "A & B" || a.Comment != "about a" ||
+		strings.Join(a.Tags, ",") != "go,web" || a.Created == nil || a.Created.Unix() != 1700000000 {
+		t.Errorf("expected parsed first bookmark, got %+v", a)
+	}
+	if b := bookmarks[1]; b.URL != "https://b.com" || b.Title != "" {
+		t.Errorf("expected untitled second bookmark, got %+v", b)
+	}
+}
+
+func Test_Parse_Apply(t *testing.T) {
+	var buf bytes.Buffer
+	if err := export.Write(&buf, export.FormatJSON, testBookmarks()); err != nil {
+		t.Fatal(err)
+	}
+	bookmarks, err := Parse(&buf, export.FormatJSON)
+	if err != nil || len(bookmarks) != 2 {
+		t.Fatalf("expected 2 parsed bookmarks, got %v and error '%v'", bookmarks, err)
+	}
+
+	// parsing writes nothing
+	db := newTestDB(t, "https://b.com/(wiki)")
+	if db.Len() != 1 {
+		t.Fatalf("expected 1 bookmark before Apply(), got %d", db.Len())
+	}
+
+	bookmarks = append(bookmarks, bukudb.Bookmark{URL: "https://a.com/?q=1&r=2"}, bukudb.Bookmark{})
+	preview, err := Preview(db, bookmarks)
+	if err != nil {
+		t.Fatalf("expected no error on Preview(), got '%v'", err)
+	}
+	expected := Summary{Added: 1, Duplicates: 2, Failed: 1}
+	if preview != expected || db.Len() != 1 {
+		t.Errorf("expected preview %+v without changes, got %+v and %d bookmarks", expected, preview, db.Len())
+	}
+
+	summary, err := Apply(db, bookmarks, nil, bukudb.ConflictSkip)
+	if err != nil {
+		t.Fatalf("expected no error on Apply(), got '%v'", err)
+	}
+	if summary != preview || db.Len() != 2 {
+		t.Errorf("expected summary %+v like the preview and 2 bookmarks, got %+v and %d", preview, summary, db.Len())
+	}
+
+	if _, err := Parse(strings.NewReader(""), export.FormatMarkdown); err == nil {
+		t.Error("expected error for markdown, got nil")
+	}
+}
+
+func Test_FormatOf(t *testing.T) {
+	tests := map[string]export.Format{
+		"a.html":         export.FormatHTML,
+		"/tmp/B.HTM":     export.FormatHTML,
+		"bookmarks.json": export.FormatJSON,
+	}
+	for path, expected := range tests {
+		if format, err := FormatOf(path); err != nil || format != expected {
+			t.Errorf("%s: expected format '%s', got '%s' and error '%v'", path, expected, format, err)
+		}
+	}
+	for _, path := range []string{"a.md", "a.txt", "html"} {
+		if _, err := FormatOf(path); err == nil {
+			t.Errorf("%s: expected error, got nil", path)
+		}
+	}
+}
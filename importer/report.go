@@ -0,0 +1,105 @@
+package importer
+
+import (
+	"fmt"
+	"io"
+	"strings"
+
+	"github.com/VannRR/robuku/bukudb"
+)
+
+// Outcomes of importing a single bookmark, as written to the report.
+const (
+	outcomeAdded     = "added"
+	outcomeDuplicate = "duplicate"
+	outcomeOverwrite = "overwritten"
+	outcomeMerged    = "merged"
+	outcomeFailed    = "failed"
+)
+
+// reportHeader is the first line of a report.
+const reportHeader = "outcome\turl\tid\terror\n"
+
+// Summary counts the outcomes of an import.
+type Summary struct {
+	Added      int
+	Duplicates int
+	Failed     int
+
+	// Updated counts the existing bookmarks changed by the conflict policy
+	// instead of being skipped as duplicates.
+	Updated int
+}
+
+// reporter counts the outcome of every imported bookmark and, if w is not
+// nil, writes it to w as a TSV row.
+type reporter struct {
+	w       io.Writer
+	err     error
+	summary Summary
+}
+
+// newReporter returns a reporter writing to w, which may be nil.
+func newReporter(w io.Writer) *reporter {
+	r := &reporter{w: w}
+	r.write(reportHeader)
+	return r
+}
+
+// added reports a bookmark added with the new id, which is 0 when the id is
+// not known, like on a dry run.
+func (r *reporter) added(url string, id uint16) {
+	r.summary.Added++
+	r.row(outcomeAdded, url, id, nil)
+}
+
+// duplicate reports a bookmark skipped since the bookmark with id has the
+// same URL.
+func (r *reporter) duplicate(url string, id uint16) {
+	r.summary.Duplicates++
+	r.row(outcomeDuplicate, url, id, nil)
+}
+
+// updated reports the bookmark with id changed by policy since it has the
+// same URL.
+func (r *reporter) updated(url string, id uint16, policy bukudb.ConflictPolicy) {
+	r.summary.Updated++
+	outcome := outcomeOverwrite
+	if policy == bukudb.ConflictMergeTags {
+		outcome = outcomeMerged
+	}
+	r.row(outcome, url, id, nil)
+}
+
+// failed reports a bookmark that could not be added.
+func (r *reporter) failed(url string, err error) {
+	r.summary.Failed++
+	r.row(outcomeFailed, url, 0, err)
+}
+
+func (r *reporter) row(outcome, url string, id uint16, err error) {
+	var idField, errField string
+	if id > 0 {
+		idField = fmt.Sprint(id)
+	}
+	if err != nil {
+		errField = err.Error()
+	}
+	r.write(strings.Join([]string{outcome, tsvField(url), idField, tsvField(errField)}, "\t") + "\n")
+}
+
+// write writes s to w, keeping the first error, after which nothing more is
+// written.
+func (r *reporter) write(s string) {
+	if r.w == nil || r.err != nil {
+		return
+	}
+	if _, err := io.WriteString(r.w, s); err != nil {
+		r.err = fmt.Errorf("failed to write import report: %w", err)
+	}
+}
+
+// tsvField replaces the tabs and line breaks of s, which would break the row.
+func tsvField(s string) string {
+	return strings.NewReplacer("\t", " ", "\r", " ", "\n", " ").Replace(s)
+}
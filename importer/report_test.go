@@ -0,0 +1,54 @@
+package importer
+
+import (
+	"errors"
+	"strings"
+	"testing"
+)
+
+func Test_reporter(t *testing.T) {
+	var buf strings.Builder
+	rep := newReporter(&buf)
+	rep.added("https://a.com", 3)
+	rep.added("https://b.com", 0)
+	rep.duplicate("https://c.com/\tx", 1)
+	rep.failed("https://d.com", errors.New("maximum\nreached"))
+
+	expected := reportHeader +
+		"added\thttps://a.com\t3\t\n" +
+		"added\thttps://b.com\t\t\n" +
+		"duplicate\thttps://c.com/ x\t1\t\n" +
+		"failed\thttps://d.com\t\tmaximum reached\n"
+	if buf.String() != expected {
+		t.Errorf("expected report %q, got %q", expected, buf.String())
+	}
+
+	expectedSummary := Summary{Added: 2, Duplicates: 1, Failed: 1}
+	if rep.summary != expectedSummary {
+		t.Errorf("expected summary %+v, got %+v", expectedSummary, rep.summary)
+	}
+}
+
+func Test_reporter_NoWriter(t *testing.T) {
+	rep := newReporter(nil)
+	rep.added("https://a.com", 1)
+	rep.failed("https://b.com", errNoURL)
+	if rep.summary != (Summary{Added: 1, Failed: 1}) || rep.err != nil {
+		t.Errorf("expected counted outcomes and no error, got %+v and '%v'", rep.summary, rep.err)
+	}
+}
+
+type failingWriter struct{}
+
+func (failingWriter) Write([]byte) (int, error) { return 0, errors.New("disk full") }
+
+func Test_reporter_WriteError(t *testing.T) {
+	rep := newReporter(failingWriter{})
+	rep.added("https://a.com", 1)
+	if rep.err == nil || !strings.Contains(rep.err.Error(), "disk full") {
+		t.Errorf("expected write error, got '%v'", rep.err)
+	}
+	if rep.summary.Added != 1 {
+		t.Errorf("expected outcome to be counted, got %+v", rep.summary)
+	}
+}
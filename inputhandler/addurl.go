@@ -0,0 +1,38 @@
+package inputhandler
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"strings"
+)
+
+// HandleAddURL shows the add screen with url filled in instead of the
+// bookmark list, for robuku --add-url, source being SourceCLI or
+// SourceClipboard. The page title is fetched if enabled.
+func (in *InputHandler) HandleAddURL(url, source string) {
+	url = strings.TrimSpace(url)
+	in.api.Data = Data{State: StateAddShow, Source: source}
+	if err := checkLength(url, in.cfg.MaxURLLen); err != nil {
+		in.showWithError(in.handleAddShow, err)
+		return
+	}
+	in.api.Data.Bookmark.URL = url
+
+	if in.cfg.FetchTitles && url != "" {
+		title, err := in.fetchTitle(context.Background(), url)
+		if err == nil {
+			title = in.cleanTitle(title)
+			err = checkLength(title, in.cfg.MaxTitleLen)
+		}
+		if err != nil {
+			log.Println("ERROR", fmt.Errorf("failed to fetch title: %w", err))
+			in.addNotice("no title fetched")
+		} else {
+			in.api.Data.Bookmark.Title = title
+		}
+	}
+
+	in.handleAddShow()
+	in.showNotices()
+}
@@ -0,0 +1,87 @@
+package inputhandler
+
+import (
+	"context"
+	"errors"
+	"regexp"
+	"strings"
+	"testing"
+
+	"github.com/VannRR/robuku/bukudb"
+	rofiapi "github.com/VannRR/rofi-api"
+)
+
+func Test_HandleAddURL(t *testing.T) {
+	in := initInputHandler(t)
+	in.cfg.FetchTitles = false
+	in.api.Data.Filters = []Filter{{Tag: "tag2"}}
+
+	in.HandleAddURL("  https://www.new.com  ", SourceCLI)
+	checkState(t, StateAddSelect, in.api.Data.State)
+	expected := bukudb.Bookmark{URL: "https://www.new.com"}
+	if in.api.Data.Bookmark.URL != expected.URL || in.api.Data.Bookmark.Title != "" {
+		t.Errorf("expected bookmark %+v, got %+v", expected, in.api.Data.Bookmark)
+	}
+	if in.api.Data.Filters != nil {
+		t.Errorf("expected a fresh state, got filters %v", in.api.Data.Filters)
+	}
+	checkEntryTexts(t, []string{opBack, "5. (Title)", "> https://www.new.com", "+ (Comment)",
+		"# (Tags)", opConfirm}, in.api.Entries)
+
+	// the normal add flow continues
+	in.handleAddSelect(opConfirm)
+	checkState(t, StateBookmarksSelect, in.api.Data.State)
+	if b, _ := in.db.Get(5); b.URL != "https://www.new.com" {
+		t.Errorf("expected bookmark #5 to be added, got %+v", b)
+	}
+}
+
+func Test_HandleAddURL_FetchTitle(t *testing.T) {
+	in := initInputHandler(t)
+	in.cfg.FetchTitles = true
+	in.fetchTitle = func(ctx context.Context, url string) (string, error) {
+		return "New Page", nil
+	}
+
+	in.HandleAddURL("https://www.new.com", SourceCLI)
+	if in.api.Data.Bookmark.Title != "New Page" {
+		t.Errorf("expected fetched title, got '%s'", in.api.Data.Bookmark.Title)
+	}
+
+	// fetched titles are cleaned up
+	in.cfg.TitleStrip = []*regexp.Regexp{regexp.MustCompile(`\s+\|\s+New$`)}
+	in.fetchTitle = func(ctx context.Context, url string) (string, error) {
+		return " New\n Page | New", nil
+	}
+	in.HandleAddURL("https://www.new.com", SourceCLI)
+	if in.api.Data.Bookmark.Title != "New Page" {
+		t.Errorf("expected cleaned title, got '%s'", in.api.Data.Bookmark.Title)
+	}
+
+	// a failed fetch leaves the title empty
+	in.fetchTitle = func(ctx context.Context, url string) (string, error) {
+		return "", errors.New("timeout")
+	}
+	in.HandleAddURL("https://www.new.com", SourceCLI)
+	checkState(t, StateAddSelect, in.api.Data.State)
+	if in.api.Data.Bookmark.Title != "" {
+		t.Errorf("expected no title, got '%s'", in.api.Data.Bookmark.Title)
+	}
+	if !strings.Contains(in.api.Options[rofiapi.OptionMessage], "no title fetched") {
+		t.Errorf("expected notice, got '%s'", in.api.Options[rofiapi.OptionMessage])
+	}
+}
+
+func Test_HandleAddURL_TooLong(t *testing.T) {
+	in := initInputHandler(t)
+	in.cfg.MaxURLLen = 10
+
+	in.HandleAddURL("https://www.new.com", SourceCLI)
+	checkState(t, StateAddSelect, in.api.Data.State)
+	if in.api.Data.Bookmark.URL != "" {
+		t.Errorf("expected no url, got '%s'", in.api.Data.Bookmark.URL)
+	}
+	if !strings.Contains(in.api.Options[rofiapi.OptionMessage], "input too long") {
+		t.Errorf("expected length error, got '%s'", in.api.Options[rofiapi.OptionMessage])
+	}
+}
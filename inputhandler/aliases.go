@@ -0,0 +1,133 @@
+package inputhandler
+
+import (
+	"fmt"
+	"log"
+	"slices"
+	"strings"
+
+	rofiapi "github.com/VannRR/rofi-api"
+)
+
+const (
+	// opAliases opens the aliases of the bookmark from the modify screen,
+	// followed by their number if it has any.
+	opAliases = "--> Aliases…"
+
+	// aliasRemovePrefix starts an alias on the aliases screen, selecting it
+	// removes the alias.
+	aliasRemovePrefix = "✕ "
+)
+
+// aliases returns the aliases of the bookmark with id, none if it could not
+// be read.
+func (in *InputHandler) aliases(id uint16) []string {
+	if id == 0 {
+		return nil
+	}
+	aliases, err := in.db.GetAliases(id)
+	if err != nil {
+		log.Println("ERROR", err)
+	}
+	return aliases
+}
+
+// aliasesEntry returns the modify screen entry opening the aliases.
+func (in *InputHandler) aliasesEntry() rofiapi.Entry {
+	if n := len(in.aliases(in.api.Data.Bookmark.ID)); n > 0 {
+		return rofiapi.Entry{Text: fmt.Sprintf("%s (%d)", opAliases, n)}
+	}
+	return rofiapi.Entry{Text: opAliases}
+}
+
+func (in *InputHandler) handleGotoChooseShow() {
+	aliases := in.aliases(in.api.Data.Bookmark.ID)
+	if len(aliases) == 0 {
+		in.openChecked()
+		return
+	}
+	in.showGotoChoose(aliases)
+}
+
+// showGotoChoose lets the user pick which URL of the bookmark to open, its
+// own URL first and then aliases.
+func (in *InputHandler) showGotoChoose(aliases []string) {
+	in.api.Options[rofiapi.OptionMessage] = in.style.generatePangoMarkup(
+		"select the URL to open", "", in.api.Data.Bookmark.Title)
+	in.api.Options[rofiapi.OptionNoCustom] = "true"
+	in.api.Options[rofiapi.OptionUseHotKeys] = "false"
+
+	entries := []rofiapi.Entry{{Text: opBack}, {Text: in.api.Data.Bookmark.URL}}
+	for _, a := range aliases {
+		entries = append(entries, rofiapi.Entry{Text: a})
+	}
+	in.api.Entries = entries
+
+	in.api.Data.State = StateGotoChooseSelect
+}
+
+func (in *InputHandler) handleGotoChooseSelect(input string) {
+	switch {
+	case input == opBack:
+		in.api.Data.Stay = false
+		in.api.Data.AltBrowser = false
+		in.HandleBookmarksShow()
+	case input == in.api.Data.Bookmark.URL:
+		in.openChecked()
+	case slices.Contains(in.aliases(in.api.Data.Bookmark.ID), input):
+		in.api.Data.OpenURL = input
+		in.openChecked()
+	default:
+		in.handleGotoChooseShow()
+	}
+}
+
+func (in *InputHandler) handleAliasesShow() {
+	in.api.Options[rofiapi.OptionMessage] = in.style.generatePangoMarkup(
+		"enter a URL to add as an alias, select one to remove it",
+		"https://mirror.example.org/docs", in.api.Data.Bookmark.URL)
+	in.api.Options[rofiapi.OptionNoCustom] = "false"
+	in.api.Options[rofiapi.OptionUseHotKeys] = "false"
+
+	entries := []rofiapi.Entry{{Text: opBack}}
+	for _, a := range in.aliases(in.api.Data.Bookmark.ID) {
+		entries = append(entries, rofiapi.Entry{Text: aliasRemovePrefix + a})
+	}
+	in.api.Entries = entries
+
+	in.api.Data.State = StateAliasesSelect
+}
+
+func (in *InputHandler) handleAliasesSelect(input string) {
+	if input == opBack {
+		in.handleModifyShow()
+		return
+	}
+	if input == "" {
+		in.handleAliasesShow()
+		return
+	}
+	if in.bookmarkChanged() {
+		return
+	}
+
+	id := in.api.Data.Bookmark.ID
+	if url, ok := strings.CutPrefix(input, aliasRemovePrefix); ok && slices.Contains(in.aliases(id), url) {
+		if err := in.db.RemoveAlias(id, url); err != nil {
+			in.setMessageToError(fmt.Errorf("error removing alias: %w", err))
+			return
+		}
+		in.handleAliasesShow()
+		return
+	}
+
+	if err := checkLength(input, in.cfg.MaxURLLen); err != nil {
+		in.showWithError(in.handleAliasesShow, err)
+		return
+	}
+	if err := in.db.AddAlias(id, input); err != nil {
+		in.showWithError(in.handleAliasesShow, err)
+		return
+	}
+	in.handleAliasesShow()
+}
@@ -0,0 +1,139 @@
+package inputhandler
+
+import (
+	"slices"
+	"strings"
+	"testing"
+
+	rofiapi "github.com/VannRR/rofi-api"
+)
+
+func Test_handleGotoChoose(t *testing.T) {
+	in := initInputHandler(t)
+	in.cfg.Browser = "true"
+	if err := in.db.AddAlias(2, "https://mirror.b.org/docs"); err != nil {
+		t.Fatal(err)
+	}
+
+	// a bookmark with aliases asks which URL to open, its own first
+	in.handleBookmarksSelect("2. metadata (title) b", rofiapi.StateCustomKeybinding7)
+	checkState(t, StateGotoChooseSelect, in.api.Data.State)
+	checkEntries(t, []rofiapi.Entry{
+		{Text: opBack}, {Text: "https://www.b.com"}, {Text: "https://mirror.b.org/docs"},
+	}, in.api.Entries)
+
+	// the alias is opened, the bookmark keeps its URL
+	in.HandleInput("https://mirror.b.org/docs")
+	checkState(t, StateBookmarksSelect, in.api.Data.State)
+	if message := in.api.Options[rofiapi.OptionMessage]; !strings.Contains(message, "opened mirror.b.org") {
+		t.Errorf("expected the alias to be opened, got '%s'", message)
+	}
+	if b, _ := in.db.Get(2); b.URL != "https://www.b.com" || in.api.Data.OpenURL != "" {
+		t.Errorf("expected URL 'https://www.b.com' and no open URL left, got '%s' and '%s'",
+			b.URL, in.api.Data.OpenURL)
+	}
+
+	// the primary URL
+	in.handleBookmarksSelect("2. metadata (title) b", rofiapi.StateCustomKeybinding7)
+	in.HandleInput("https://www.b.com")
+	if message := in.api.Options[rofiapi.OptionMessage]; !strings.Contains(message, "opened b.com") {
+		t.Errorf("expected the bookmark URL to be opened, got '%s'", message)
+	}
+
+	// back returns to the list without opening
+	in.handleBookmarksSelect("2. metadata (title) b", rofiapi.StateCustomKeybinding7)
+	in.HandleInput(opBack)
+	checkState(t, StateBookmarksSelect, in.api.Data.State)
+	if in.api.Data.Stay || strings.Contains(in.api.Options[rofiapi.OptionMessage], "opened") {
+		t.Errorf("expected nothing opened, got '%s'", in.api.Options[rofiapi.OptionMessage])
+	}
+
+	// an unknown URL shows the choice again
+	in.handleBookmarksSelect("2. metadata (title) b", rofiapi.StateSelected)
+	in.HandleInput("https://elsewhere.com")
+	checkState(t, StateGotoChooseSelect, in.api.Data.State)
+
+	// bookmarks without aliases open directly
+	in.handleBookmarksSelect("1. metadata (title) google", rofiapi.StateSelected)
+	checkState(t, StateGotoExec, in.api.Data.State)
+}
+
+func Test_handleGotoChoose_UntrustedAlias(t *testing.T) {
+	in := initInputHandler(t)
+	in.cfg.Browser = "true"
+	if err := in.db.AddAlias(2, "gopher://b.com"); err != nil {
+		t.Fatal(err)
+	}
+
+	in.handleBookmarksSelect("2. metadata (title) b", rofiapi.StateCustomKeybinding7)
+	in.HandleInput("gopher://b.com")
+	checkState(t, StateGotoConfirmSelect, in.api.Data.State)
+	if message := in.api.Options[rofiapi.OptionMessage]; !strings.Contains(message, "gopher://b.com") {
+		t.Errorf("expected the alias to be confirmed, got '%s'", message)
+	}
+
+	// the chosen alias survives the confirmation
+	in.HandleInput(opOpen)
+	if message := in.api.Options[rofiapi.OptionMessage]; !strings.Contains(message, "opened b.com") ||
+		in.api.Data.OpenURL != "" {
+		t.Errorf("expected the alias to be opened, got '%s'", message)
+	}
+}
+
+func Test_handleAliases(t *testing.T) {
+	in := initInputHandler(t)
+	in.api.Data.Bookmark, _ = in.db.Get(1)
+
+	in.handleModifyShow()
+	if !slices.Contains(entryTexts(in.api.Entries), opAliases) {
+		t.Errorf("expected '%s' on the modify screen, got %q", opAliases, entryTexts(in.api.Entries))
+	}
+	in.HandleInput(opAliases)
+	checkState(t, StateAliasesSelect, in.api.Data.State)
+	checkEntries(t, []rofiapi.Entry{{Text: opBack}}, in.api.Entries)
+
+	// typed URLs are added
+	in.HandleInput("https://google.de")
+	in.HandleInput("https://google.fr")
+	checkState(t, StateAliasesSelect, in.api.Data.State)
+	checkEntries(t, []rofiapi.Entry{
+		{Text: opBack}, {Text: aliasRemovePrefix + "https://google.de"}, {Text: aliasRemovePrefix + "https://google.fr"},
+	}, in.api.Entries)
+
+	// the bookmark's own URL is not an alias
+	in.HandleInput("https://www.google.com")
+	checkState(t, StateAliasesSelect, in.api.Data.State)
+	if message := in.api.Options[rofiapi.OptionMessage]; !strings.Contains(message, "the alias is the bookmark") {
+		t.Errorf("expected error for the bookmark URL, got '%s'", message)
+	}
+
+	// selected aliases are removed
+	in.HandleInput(aliasRemovePrefix + "https://google.de")
+	if aliases, _ := in.db.GetAliases(1); !slices.Equal(aliases, []string{"https://google.fr"}) {
+		t.Errorf("expected aliases [https://google.fr], got %q", aliases)
+	}
+
+	// the modify screen counts them
+	in.HandleInput(opBack)
+	checkState(t, StateModifySelect, in.api.Data.State)
+	if !slices.Contains(entryTexts(in.api.Entries), opAliases+" (1)") {
+		t.Errorf("expected '%s (1)' on the modify screen, got %q", opAliases, entryTexts(in.api.Entries))
+	}
+	in.HandleInput(opAliases + " (1)")
+	checkState(t, StateAliasesSelect, in.api.Data.State)
+
+	// aliases are searched like the URL
+	in.HandleBookmarksShow()
+	if meta := in.api.Entries[0].Meta; !strings.Contains(meta, "google.fr") {
+		t.Errorf("expected alias in the meta of bookmark 1, got '%s'", meta)
+	}
+}
+
+// entryTexts returns the texts of entries.
+func entryTexts(entries []rofiapi.Entry) []string {
+	texts := make([]string, len(entries))
+	for i, e := range entries {
+		texts[i] = e.Text
+	}
+	return texts
+}
@@ -0,0 +1,127 @@
+package inputhandler
+
+import (
+	"errors"
+	"fmt"
+	"slices"
+	"strconv"
+	"strings"
+
+	rofiapi "github.com/VannRR/rofi-api"
+)
+
+// confirmYes is typed to confirm a deletion, followed by IDs on the delete
+// confirm screen to delete those bookmarks instead.
+const confirmYes = "yes"
+
+var errNoIDs = errors.New("no bookmark IDs given")
+
+// parseIDList parses the bookmark IDs in s, separated by spaces or commas,
+// with ranges like "3-7". Each ID is kept once, in the order given.
+func parseIDList(s string) ([]uint16, error) {
+	var ids []uint16
+	fields := strings.FieldsFunc(s, func(r rune) bool { return r == ',' || r == ' ' || r == '\t' })
+	for _, f := range fields {
+		from, to, isRange := strings.Cut(f, "-")
+		first, err := parseID(from)
+		if err != nil {
+			return nil, err
+		}
+		last := first
+		if isRange {
+			if last, err = parseID(to); err != nil {
+				return nil, err
+			}
+			if last < first {
+				return nil, fmt.Errorf("invalid range '%s'", f)
+			}
+		}
+		for id := int(first); id <= int(last); id++ {
+			if !slices.Contains(ids, uint16(id)) {
+				ids = append(ids, uint16(id))
+			}
+		}
+	}
+	if len(ids) == 0 {
+		return nil, errNoIDs
+	}
+	return ids, nil
+}
+
+// parseID parses a single bookmark ID.
+func parseID(s string) (uint16, error) {
+	id, err := strconv.ParseUint(s, 10, 16)
+	if err != nil || id == 0 {
+		return 0, fmt.Errorf("invalid bookmark ID '%s'", s)
+	}
+	return uint16(id), nil
+}
+
+// deleteIDsFromInput returns the IDs typed after "yes" on the delete confirm
+// screen, ok is false if input is not of that form.
+func deleteIDsFromInput(input string) (ids []uint16, ok bool, err error) {
+	rest, found := strings.CutPrefix(input, confirmYes+" ")
+	if !found || strings.TrimSpace(rest) == "" {
+		return nil, false, nil
+	}
+	ids, err = parseIDList(rest)
+	return ids, true, err
+}
+
+// checkDeleteIDs returns an error naming every ID in ids without a
+// bookmark, so a list with a typo deletes nothing.
+func (in *InputHandler) checkDeleteIDs(ids []uint16) error {
+	var missing []string
+	for _, id := range ids {
+		if int(id) > in.db.Len() {
+			missing = append(missing, fmt.Sprintf("#%d", id))
+		}
+	}
+	if len(missing) > 0 {
+		return fmt.Errorf("no bookmark %s, nothing deleted", strings.Join(missing, ", "))
+	}
+	return nil
+}
+
+func (in *InputHandler) handleDeleteMultiShow() {
+	bookmarks, err := in.db.GetByIDs(in.api.Data.DeleteIDs)
+	if err != nil {
+		in.setMessageToError(fmt.Errorf("error getting bookmarks: %w", err))
+		return
+	}
+
+	in.api.Options[rofiapi.OptionMessage] = in.style.generatePangoMarkup(
+		fmt.Sprintf("delete these %d bookmarks? (yes/No)", len(bookmarks)), "", "")
+	in.api.Options[rofiapi.OptionNoCustom] = "false"
+	in.api.Options[rofiapi.OptionUseHotKeys] = "false"
+
+	entries := []rofiapi.Entry{{Text: opBack}}
+	for _, b := range bookmarks {
+		title := b.Title
+		if title == "" {
+			title = b.URL
+		}
+		entries = append(entries, rofiapi.Entry{
+			Text: fmt.Sprintf("#%04d %s", b.ID, title), NonSelectable: true})
+	}
+	in.api.Entries = entries
+
+	in.api.Data.State = StateDeleteMultiSelect
+}
+
+func (in *InputHandler) handleDeleteMultiSelect(input string) {
+	ids := in.api.Data.DeleteIDs
+	in.api.Data.DeleteIDs = nil
+	if input != confirmYes {
+		in.HandleBookmarksShow()
+		return
+	}
+
+	if err := in.db.BulkRemove(ids); err != nil {
+		in.setMessageToError(fmt.Errorf("error deleting bookmarks: %w", err))
+		return
+	}
+	in.api.Data.Undo = Undo{}
+	in.HandleBookmarksShow()
+	in.addNotice(fmt.Sprintf("deleted %d bookmarks", len(ids)))
+}
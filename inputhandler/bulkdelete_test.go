@@ -0,0 +1,89 @@
+package inputhandler
+
+import (
+	"slices"
+	"strings"
+	"testing"
+
+	rofiapi "github.com/VannRR/rofi-api"
+)
+
+func Test_parseIDList(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected []uint16
+		err      bool
+	}{
+		{"3 7 19", []uint16{3, 7, 19}, false},
+		{"3,7, 19", []uint16{3, 7, 19}, false},
+		{"3-5", []uint16{3, 4, 5}, false},
+		{"9 3-5 4", []uint16{9, 3, 4, 5}, false},
+		{"2-2", []uint16{2}, false},
+		{"  ", nil, true},
+		{"3 x", nil, true},
+		{"0", nil, true},
+		{"5-3", nil, true},
+		{"3-", nil, true},
+		{"-3", nil, true},
+		{"70000", nil, true},
+	}
+	for _, test := range tests {
+		actual, err := parseIDList(test.input)
+		if (err != nil) != test.err {
+			t.Errorf("input '%s': expected error %v, got '%v'", test.input, test.err, err)
+		}
+		if !slices.Equal(actual, test.expected) {
+			t.Errorf("input '%s': expected %v, got %v", test.input, test.expected, actual)
+		}
+	}
+}
+
+func Test_handleDeleteConfirmSelect_IDList(t *testing.T) {
+	in := initInputHandler(t)
+	in.api.Data.Bookmark, _ = in.db.Get(2)
+
+	// one unknown ID refuses the whole list
+	in.handleDeleteConfirmSelect("yes 1 3-9")
+	checkState(t, StateDeleteConfirmSelect, in.api.Data.State)
+	if msg := in.api.Options[rofiapi.OptionMessage]; !strings.Contains(msg, "no bookmark #5, #6, #7, #8, #9") {
+		t.Errorf("expected the unknown IDs in the message, got '%s'", msg)
+	}
+	in.handleDeleteConfirmSelect("yes 1 x")
+	checkState(t, StateDeleteConfirmSelect, in.api.Data.State)
+	if in.db.Len() != 4 {
+		t.Fatalf("expected no bookmark to be deleted, got %d left", in.db.Len())
+	}
+
+	in.handleDeleteConfirmSelect("yes 1 3-4")
+	checkState(t, StateDeleteMultiSelect, in.api.Data.State)
+	checkEntries(t, []rofiapi.Entry{
+		{Text: opBack},
+		{Text: "#0001 metadata (title) google", NonSelectable: true},
+		{Text: "#0003 metadata (title) c", NonSelectable: true},
+		{Text: "#0004 https://www.d.com", NonSelectable: true},
+	}, in.api.Entries)
+
+	in.handleDeleteMultiSelect("no")
+	checkState(t, StateBookmarksSelect, in.api.Data.State)
+	if in.db.Len() != 4 || in.api.Data.DeleteIDs != nil {
+		t.Errorf("expected nothing deleted and no pending IDs, got %d left and %v",
+			in.db.Len(), in.api.Data.DeleteIDs)
+	}
+
+	in.handleDeleteConfirmSelect("yes 1 3-4")
+	in.handleDeleteMultiSelect("yes")
+	checkState(t, StateBookmarksSelect, in.api.Data.State)
+	if b, _ := in.db.Get(1); in.db.Len() != 1 || b.URL != "https://www.b.com" {
+		t.Errorf("expected only https://www.b.com left, got %d left, #1 %s", in.db.Len(), b.URL)
+	}
+	if !slices.Contains(in.notices, "deleted 3 bookmarks") {
+		t.Errorf("expected a notice of the deletion, got %q", in.notices)
+	}
+
+	// a plain yes still deletes the current bookmark
+	in.api.Data.Bookmark, _ = in.db.Get(1)
+	in.handleDeleteConfirmSelect("yes")
+	if in.db.Len() != 0 {
+		t.Errorf("expected the current bookmark to be deleted, got %d left", in.db.Len())
+	}
+}
@@ -0,0 +1,91 @@
+package inputhandler
+
+import (
+	"fmt"
+	"os"
+	"os/exec"
+	"strings"
+
+	"github.com/VannRR/robuku/bukudb"
+	"github.com/VannRR/robuku/config"
+)
+
+// copyToClipboard pipes text to the configured clipboard command, or to
+// wl-copy on Wayland and xclip otherwise.
+func (in *InputHandler) copyToClipboard(text string) error {
+	args := strings.Fields(in.cfg.Clipboard)
+	if len(args) == 0 {
+		args = []string{"xclip", "-selection", "clipboard"}
+		if os.Getenv("WAYLAND_DISPLAY") != "" {
+			args = []string{"wl-copy"}
+		}
+	}
+
+	cmd := exec.Command(args[0], args[1:]...)
+	cmd.Stdin = strings.NewReader(text)
+	if err := cmd.Run(); err != nil {
+		return fmt.Errorf(
+			"error copying to clipboard with '%s', to use another command set env variable $%s: %w",
+			args[0], config.ClipboardEnvVar, err)
+	}
+	return nil
+}
+
+// ReadClipboard returns the text in the clipboard without surrounding
+// space, read with wl-paste on Wayland and xclip otherwise.
+func ReadClipboard() (string, error) {
+	args := pasteCommand()
+	out, err := exec.Command(args[0], args[1:]...).Output()
+	if err != nil {
+		return "", fmt.Errorf("error reading the clipboard with '%s': %w", args[0], err)
+	}
+	return strings.TrimSpace(string(out)), nil
+}
+
+// pasteCommand returns the command printing the clipboard.
+func pasteCommand() []string {
+	if os.Getenv("WAYLAND_DISPLAY") != "" {
+		return []string{"wl-paste", "--no-newline"}
+	}
+	return []string{"xclip", "-selection", "clipboard", "-o"}
+}
+
+// bukuAddCommand returns a buku command adding b, fields that are empty are
+// left out.
+func bukuAddCommand(b bukudb.Bookmark) string {
+	cmd := "buku --add " + shellQuote(b.URL)
+	if b.Title != "" {
+		cmd += " --title " + shellQuote(b.Title)
+	}
+	if len(b.Tags) > 0 {
+		cmd += " --tag " + shellQuoteIfNeeded(strings.Join(b.Tags, ","))
+	}
+	if b.Comment != "" {
+		cmd += " --comment " + shellQuote(b.Comment)
+	}
+	return cmd
+}
+
+// shellQuote quotes s for POSIX shells, within single quotes everything,
+// including newlines, is literal except the single quote itself.
+func shellQuote(s string) string {
+	return "'" + strings.ReplaceAll(s, "'", `'\''`) + "'"
+}
+
+// shellQuoteIfNeeded quotes s only if it contains characters the shell
+// treats specially.
+func shellQuoteIfNeeded(s string) string {
+	safe := func(r rune) bool {
+		return 'a' <= r && r <= 'z' || 'A' <= r && r <= 'Z' || '0' <= r && r <= '9' ||
+			strings.ContainsRune(",._+-:@/%=", r)
+	}
+	for _, r := range s {
+		if !safe(r) {
+			return shellQuote(s)
+		}
+	}
+	if s == "" {
+		return "''"
+	}
+	return s
+}
@@ -0,0 +1,87 @@
+package inputhandler
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/VannRR/robuku/bukudb"
+	rofiapi "github.com/VannRR/rofi-api"
+)
+
+func Test_bukuAddCommand(t *testing.T) {
+	tests := []struct {
+		name     string
+		b        bukudb.Bookmark
+		expected string
+	}{
+		{"all fields",
+			bukudb.Bookmark{URL: "https://a.com", Title: "A", Tags: []string{"tag1", "tag2"},
+				Comment: "some comment"},
+			"buku --add 'https://a.com' --title 'A' --tag tag1,tag2 --comment 'some comment'"},
+		{"only url",
+			bukudb.Bookmark{URL: "https://a.com"},
+			"buku --add 'https://a.com'"},
+		{"empty title and comment",
+			bukudb.Bookmark{URL: "https://a.com", Tags: []string{"a"}},
+			"buku --add 'https://a.com' --tag a"},
+		{"single quotes",
+			bukudb.Bookmark{URL: "https://a.com/it's", Title: "it's 'quoted'"},
+			`buku --add 'https://a.com/it'\''s' --title 'it'\''s '\''quoted'\'''`},
+		{"newlines in comment",
+			bukudb.Bookmark{URL: "https://a.com", Comment: "line 1\nline 2"},
+			"buku --add 'https://a.com' --comment 'line 1\nline 2'"},
+		{"tags with spaces and shell characters",
+			bukudb.Bookmark{URL: "https://a.com", Tags: []string{"a tag", "$HOME"}},
+			"buku --add 'https://a.com' --tag 'a tag,$HOME'"},
+	}
+
+	for _, test := range tests {
+		if actual := bukuAddCommand(test.b); actual != test.expected {
+			t.Errorf("%s: expected '%s', got '%s'", test.name, test.expected, actual)
+		}
+	}
+}
+
+func Test_handleCopyBukuCommand(t *testing.T) {
+	in := initInputHandler(t)
+	path := filepath.Join(t.TempDir(), "clipboard")
+	in.cfg.Clipboard = "tee " + path
+
+	in.handleBookmarksSelect("2. metadata (title) b", rofiapi.StateCustomKeybinding5)
+	checkState(t, StateBookmarksSelect, in.api.Data.State)
+	if !strings.Contains(in.api.Options[rofiapi.OptionMessage], "copied buku command") {
+		t.Errorf("expected message to confirm the copy, got '%s'",
+			in.api.Options[rofiapi.OptionMessage])
+	}
+
+	copied, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	expected := "buku --add 'https://www.b.com' --title 'metadata (title) b' --tag b,tag2,tag3"
+	if string(copied) != expected {
+		t.Errorf("expected clipboard '%s', got '%s'", expected, copied)
+	}
+
+	// failing clipboard command
+	in.cfg.Clipboard = "false"
+	in.handleBookmarksSelect("2. metadata (title) b", rofiapi.StateCustomKeybinding5)
+	checkState(t, StateBookmarksSelect, in.api.Data.State)
+	if !strings.Contains(in.api.Options[rofiapi.OptionMessage], "error copying to clipboard") {
+		t.Errorf("expected message to contain the error, got '%s'",
+			in.api.Options[rofiapi.OptionMessage])
+	}
+}
+
+func Test_pasteCommand(t *testing.T) {
+	t.Setenv("WAYLAND_DISPLAY", "")
+	if args := pasteCommand(); args[0] != "xclip" || args[len(args)-1] != "-o" {
+		t.Errorf("expected xclip printing the clipboard, got %v", args)
+	}
+	t.Setenv("WAYLAND_DISPLAY", "wayland-0")
+	if args := pasteCommand(); args[0] != "wl-paste" {
+		t.Errorf("expected wl-paste on Wayland, got %v", args)
+	}
+}
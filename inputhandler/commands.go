@@ -0,0 +1,169 @@
+package inputhandler
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/VannRR/robuku/bukudb"
+	rofiapi "github.com/VannRR/rofi-api"
+)
+
+// commandPrefix starts a command typed into the bookmark list prompt.
+const commandPrefix = ":"
+
+const (
+	cmdAdd    = "add"
+	cmdTag    = "tag"
+	cmdRandom = "random"
+	cmdHelp   = "help"
+	cmdImport = "import"
+	cmdTags   = "tags"
+)
+
+// errNoBookmarks is shown when :random finds nothing to open.
+var errNoBookmarks = errors.New("no bookmarks to open")
+
+// command is a parsed prompt command like ":tag golang".
+type command struct {
+	name string
+	arg  string
+}
+
+// parseCommand parses input typed into the bookmark list prompt, ok is false
+// if input is not a command.
+func parseCommand(input string) (cmd command, ok bool, err error) {
+	rest, ok := strings.CutPrefix(strings.TrimSpace(input), commandPrefix)
+	if !ok {
+		return command{}, false, nil
+	}
+	name, arg, _ := strings.Cut(strings.TrimSpace(rest), " ")
+	cmd = command{name: strings.ToLower(name), arg: strings.TrimSpace(arg)}
+
+	switch cmd.name {
+	case cmdAdd:
+	case cmdTag:
+		if cmd.arg == "" {
+			return command{}, true, fmt.Errorf("usage: %s%s <name>", commandPrefix, cmdTag)
+		}
+	case cmdImport:
+		if cmd.arg == "" {
+			return command{}, true, fmt.Errorf("usage: %s%s <file>", commandPrefix, cmdImport)
+		}
+	case cmdRandom, cmdHelp, cmdTags:
+		if cmd.arg != "" {
+			return command{}, true, fmt.Errorf("%s%s takes no argument", commandPrefix, cmd.name)
+		}
+	default:
+		return command{}, true, fmt.Errorf("unknown command '%s%s', try %s%s",
+			commandPrefix, cmd.name, commandPrefix, cmdHelp)
+	}
+	return cmd, true, nil
+}
+
+// handleCustomInput runs a command typed into the bookmark list prompt,
+// other text only shows the bookmarks again.
+func (in *InputHandler) handleCustomInput(input string) {
+	cmd, ok, err := parseCommand(input)
+	if err != nil {
+		in.showWithError(in.HandleBookmarksShow, err)
+		return
+	}
+	if !ok {
+		in.HandleBookmarksShow()
+		return
+	}
+
+	switch cmd.name {
+	case cmdAdd:
+		if err := checkLength(cmd.arg, in.cfg.MaxURLLen); err != nil {
+			in.showWithError(in.HandleBookmarksShow, err)
+			return
+		}
+		in.api.Data.Bookmark = bukudb.Bookmark{URL: cmd.arg}
+		in.api.Data.Source = SourceRofi
+		in.handleAddShow()
+	case cmdTag:
+		if err := in.pushFilter(filterTagPrefix + cmd.arg); err != nil {
+			in.showWithError(in.HandleBookmarksShow, err)
+			return
+		}
+		in.HandleBookmarksShow()
+	case cmdRandom:
+		in.openRandom()
+	case cmdHelp:
+		in.handleHelpShow()
+	case cmdImport:
+		in.startImport(cmd.arg)
+	case cmdTags:
+		in.api.Data.TagParent = ""
+		in.handleTagsShow()
+	}
+}
+
+// openRandom opens a random bookmark of the filtered list.
+func (in *InputHandler) openRandom() {
+	bookmarks, err := watchdog(in.cfg.DBTimeout, func(ctx context.Context) ([]bukudb.Bookmark, error) {
+		return applyFilters(ctx, in.db, in.activeFilter())
+	})
+	if err != nil {
+		in.setMessageToError(err)
+		return
+	}
+	if len(bookmarks) == 0 {
+		in.showWithError(in.HandleBookmarksShow, errNoBookmarks)
+		return
+	}
+
+	in.api.Data.Bookmark = bookmarks[in.randN(len(bookmarks))]
+	in.api.Data.Stay = false
+	in.handleGotoExec()
+}
+
+// commandHelpLines describe the prompt commands of the bookmark list.
+var commandHelpLines = []string{
+	commandPrefix + cmdAdd + " <url>: add a bookmark with the url",
+	commandPrefix + cmdTag + " <name>: show the bookmarks tagged name",
+	commandPrefix + cmdTags + ": browse the tags, 'lang/go' is listed under 'lang'",
+	commandPrefix + cmdRandom + ": open a random bookmark",
+	commandPrefix + cmdImport + " <file>: import an html or json bookmark file",
+	commandPrefix + cmdHelp + ": show this help",
+}
+
+// helpLines describe the hotkeys of actions and the commands of the
+// bookmark list.
+func helpLines(actions []hotkeyAction) []string {
+	lines := []string{"Enter: open the bookmark"}
+	for _, a := range actions {
+		lines = append(lines, a.helpText())
+	}
+	lines = append(lines, moreAction.helpText())
+	return append(lines, commandHelpLines...)
+}
+
+func (in *InputHandler) handleHelpShow() {
+	in.api.Options[rofiapi.OptionMessage] = in.style.generatePangoMarkup("help", "", "")
+	in.api.Options[rofiapi.OptionNoCustom] = "true"
+	in.api.Options[rofiapi.OptionUseHotKeys] = "false"
+
+	entries := []rofiapi.Entry{{Text: opBack}}
+	for _, l := range helpLines(in.hotkeyActions()) {
+		entries = append(entries, rofiapi.Entry{Text: l, NonSelectable: true})
+	}
+	if s := in.cfg.TagSigil; s != "" {
+		entries = append(entries, rofiapi.Entry{
+			Text: s + "golang: match only the bookmarks tagged golang, not the titles", NonSelectable: true})
+	}
+	in.api.Entries = entries
+
+	in.api.Data.State = StateHelpSelect
+}
+
+func (in *InputHandler) handleHelpSelect(input string) {
+	if input == opBack {
+		in.HandleBookmarksShow()
+		return
+	}
+	in.handleHelpShow()
+}
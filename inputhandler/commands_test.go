@@ -0,0 +1,89 @@
+package inputhandler
+
+import (
+	"testing"
+
+	rofiapi "github.com/VannRR/rofi-api"
+)
+
+func Test_parseCommand(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected command
+		ok       bool
+		err      string
+	}{
+		{"google", command{}, false, ""},
+		{"", command{}, false, ""},
+		{":add https://go.dev", command{cmdAdd, "https://go.dev"}, true, ""},
+		{" :ADD ", command{cmdAdd, ""}, true, ""},
+		{":tag  golang ", command{cmdTag, "golang"}, true, ""},
+		{":tag", command{}, true, "usage: :tag <name>"},
+		{":tags", command{cmdTags, ""}, true, ""},
+		{":tags lang", command{}, true, ":tags takes no argument"},
+		{":random", command{cmdRandom, ""}, true, ""},
+		{":random now", command{}, true, ":random takes no argument"},
+		{":help", command{cmdHelp, ""}, true, ""},
+		{":import ~/bookmarks.html", command{cmdImport, "~/bookmarks.html"}, true, ""},
+		{":import", command{}, true, "usage: :import <file>"},
+		{":quit", command{}, true, "unknown command ':quit', try :help"},
+		{":", command{}, true, "unknown command ':', try :help"},
+	}
+	for _, tt := range tests {
+		cmd, ok, err := parseCommand(tt.input)
+		if cmd != tt.expected || ok != tt.ok {
+			t.Errorf("input '%s': expected %+v and %v, got %+v and %v", tt.input, tt.expected, tt.ok, cmd, ok)
+		}
+		if (err == nil && tt.err != "") || (err != nil && err.Error() != tt.err) {
+			t.Errorf("input '%s': expected error '%s', got %v", tt.input, tt.err, err)
+		}
+	}
+}
+
+func Test_handleBookmarksSelect_Commands(t *testing.T) {
+	in := initInputHandler(t)
+	in.cfg.Browser = "true"
+
+	// other text shows the bookmarks again
+	in.handleBookmarksSelect("goog", rofiapi.StateSelectedCustom)
+	checkState(t, StateBookmarksSelect, in.api.Data.State)
+	if len(in.api.Entries) != 4 {
+		t.Errorf("expected 4 entries, got %d", len(in.api.Entries))
+	}
+
+	// unknown commands are shown in the message
+	in.handleBookmarksSelect(":quit", rofiapi.StateSelectedCustom)
+	checkState(t, StateBookmarksSelect, in.api.Data.State)
+	checkMessageContains(t, in, "unknown command &#39;:quit&#39;, try :help")
+
+	in.handleBookmarksSelect(":add https://go.dev", rofiapi.StateSelectedCustom)
+	checkState(t, StateAddSelect, in.api.Data.State)
+	if in.api.Data.Bookmark.URL != "https://go.dev" {
+		t.Errorf("expected prefilled url 'https://go.dev', got '%s'", in.api.Data.Bookmark.URL)
+	}
+
+	in.HandleBookmarksShow()
+	in.handleBookmarksSelect(":tag tag2", rofiapi.StateSelectedCustom)
+	checkEntryTexts(t, []string{opBack, opExportList, "1. metadata (title) google", "2. metadata (title) b"}, in.api.Entries)
+
+	// random picks from the filtered bookmarks
+	in.randN = func(n int) int { return n - 1 }
+	in.handleBookmarksSelect(":random", rofiapi.StateSelectedCustom)
+	if in.api.Data.Bookmark.ID != 2 {
+		t.Errorf("expected bookmark 2 to be opened, got %d", in.api.Data.Bookmark.ID)
+	}
+
+	in.handleBookmarksSelect(":help", rofiapi.StateSelectedCustom)
+	checkState(t, StateHelpSelect, in.api.Data.State)
+	in.handleHelpSelect(opBack)
+	checkState(t, StateBookmarksSelect, in.api.Data.State)
+}
+
+func Test_openRandom_NoBookmarks(t *testing.T) {
+	in := initInputHandler(t)
+	in.api.Data.Filters = []Filter{{Tag: "missing"}}
+
+	in.openRandom()
+	checkState(t, StateBookmarksSelect, in.api.Data.State)
+	checkMessageContains(t, in, errNoBookmarks.Error())
+}
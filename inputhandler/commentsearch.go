@@ -0,0 +1,72 @@
+package inputhandler
+
+import (
+	"strings"
+	"unicode"
+)
+
+// snippetRadius is the number of characters of a comment shown on each side
+// of the first search hit.
+const snippetRadius = 30
+
+// snippetTitleLen is the length titles are cut to when a comment snippet
+// follows them, so the snippet fits the entry.
+const snippetTitleLen = 30
+
+// commentSnippet returns the text of comment up to snippetRadius characters
+// around the first hit of a word of query, ignoring case, with "…" where it
+// is cut. It is empty if no word is found.
+func commentSnippet(comment, query string) string {
+	text := []rune(strings.Join(strings.Fields(comment), " "))
+	lower := make([]rune, len(text))
+	for i, r := range text {
+		lower[i] = unicode.ToLower(r)
+	}
+
+	start, end := -1, -1
+	for _, word := range strings.Fields(query) {
+		w := []rune(word)
+		for i, r := range w {
+			w[i] = unicode.ToLower(r)
+		}
+		if i := runeIndex(lower, w); i >= 0 && (start < 0 || i < start) {
+			start, end = i, i+len(w)
+		}
+	}
+	if start < 0 {
+		return ""
+	}
+
+	from, to := max(start-snippetRadius, 0), min(end+snippetRadius, len(text))
+	snippet := string(text[from:to])
+	if from > 0 {
+		snippet = "…" + snippet
+	}
+	if to < len(text) {
+		snippet += "…"
+	}
+	return snippet
+}
+
+// runeIndex returns the index of the first sub in s, or -1.
+func runeIndex(s, sub []rune) int {
+	for i := 0; i+len(sub) <= len(s); i++ {
+		if string(s[i:i+len(sub)]) == string(sub) {
+			return i
+		}
+	}
+	return -1
+}
+
+// snippetTitle returns the entry title of b with the snippet of its comment
+// matching query.
+func snippetTitle(title, comment, query string) string {
+	snippet := commentSnippet(comment, query)
+	if snippet == "" {
+		return title
+	}
+	if len([]rune(title)) > snippetTitleLen {
+		title = string([]rune(title)[:snippetTitleLen-1]) + "…"
+	}
+	return title + " — " + snippet
+}
@@ -0,0 +1,88 @@
+package inputhandler
+
+import (
+	"strings"
+	"testing"
+	"unicode/utf8"
+
+	rofiapi "github.com/VannRR/rofi-api"
+)
+
+func Test_commentSnippet(t *testing.T) {
+	long := strings.Repeat("a", 40) + " needle " + strings.Repeat("b", 40)
+	tests := []struct {
+		comment  string
+		query    string
+		expected string
+	}{
+		{"short note with a needle", "NEEDLE", "short note with a needle"},
+		{"no match here", "needle", ""},
+		{"", "needle", ""},
+		{long, "needle", "…" + strings.Repeat("a", 29) + " needle " + strings.Repeat("b", 29) + "…"},
+		// the first hit of any word
+		{"the second word comes first, then the Needle", "needle second", "the second word comes first, then the Ne…"},
+		// line breaks and runs of spaces are collapsed
+		{"line one\n\nneedle   here", "needle", "line one needle here"},
+		// cut between characters, not bytes
+		{strings.Repeat("ä", 35) + "Öl" + strings.Repeat("ß", 35), "öl",
+			"…" + strings.Repeat("ä", 30) + "Öl" + strings.Repeat("ß", 30) + "…"},
+		{"日本語のコメントで検索する", "コメント", "日本語のコメントで検索する"},
+	}
+	for _, tt := range tests {
+		actual := commentSnippet(tt.comment, tt.query)
+		if actual != tt.expected {
+			t.Errorf("expected snippet '%s' for '%s' in '%s', got '%s'", tt.expected, tt.query, tt.comment, actual)
+		}
+		if !utf8.ValidString(actual) {
+			t.Errorf("expected valid UTF-8 snippet, got %q", actual)
+		}
+	}
+}
+
+func Test_snippetTitle(t *testing.T) {
+	if actual := snippetTitle("title", "a needle", "needle"); actual != "title — a needle" {
+		t.Errorf("expected 'title — a needle', got '%s'", actual)
+	}
+	if actual := snippetTitle("title", "nothing", "needle"); actual != "title" {
+		t.Errorf("expected 'title' without a hit, got '%s'", actual)
+	}
+	long := strings.Repeat("ü", 40)
+	expected := strings.Repeat("ü", snippetTitleLen-1) + "… — needle"
+	if actual := snippetTitle(long, "needle", "needle"); actual != expected {
+		t.Errorf("expected '%s', got '%s'", expected, actual)
+	}
+}
+
+func Test_HandleBookmarksShow_commentFilter(t *testing.T) {
+	in := initInputHandler(t)
+	b, _ := in.db.Get(3)
+	b.Comment = "remember: the lost phrase was here"
+	loadBookmark(in, b)
+	b, _ = in.db.Get(4)
+	b.Title = "lost phrase in the title"
+	loadBookmark(in, b)
+
+	if err := in.pushFilter("comment: Lost Phrase"); err != nil {
+		t.Fatal(err)
+	}
+	in.HandleBookmarksShow()
+	expected := []rofiapi.Entry{
+		{Text: opBack},
+		{Text: opExportList},
+		{Text: "3. metadata (title) c — remember: the lost phrase was here", Meta: in.render.meta(b)},
+	}
+	if len(in.api.Entries) != 3 || in.api.Entries[2].Text != expected[2].Text {
+		t.Errorf("expected only bookmark 3 with its snippet, got %v", in.api.Entries)
+	}
+	if message := in.api.Options[rofiapi.OptionMessage]; !strings.Contains(message, "comment:&#34;Lost Phrase&#34;") {
+		t.Errorf("expected comment filter in message, got '%s'", message)
+	}
+
+	// a search replaces the comment search and drops the snippets
+	if err := in.pushFilter("lost"); err != nil {
+		t.Fatal(err)
+	}
+	if f := in.activeFilter(); f.Comment != "" || f.Query != "lost" {
+		t.Errorf("expected the search to replace the comment search, got %+v", f)
+	}
+}
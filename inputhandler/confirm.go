@@ -0,0 +1,83 @@
+package inputhandler
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/VannRR/robuku/bukudb"
+	"github.com/VannRR/robuku/config"
+	rofiapi "github.com/VannRR/rofi-api"
+)
+
+// confirmAction is an action the confirmation policy applies to.
+type confirmAction int
+
+const (
+	confirmDelete confirmAction = iota
+	confirmClearTags
+)
+
+// needsConfirm reports whether policy asks for confirmation before a.
+func needsConfirm(policy config.ConfirmPolicy, a confirmAction) bool {
+	switch policy {
+	case config.ConfirmNone:
+		return false
+	case config.ConfirmAll:
+		return true
+	}
+	return a == confirmDelete
+}
+
+// deleteBookmark deletes the current bookmark and shows the bookmarks.
+func (in *InputHandler) deleteBookmark() {
+	if in.bookmarkChanged() {
+		return
+	}
+	if err := in.db.Remove(in.api.Data.Bookmark.ID); err != nil {
+		in.setMessageToError(fmt.Errorf("error deleting bookmark: %w", err))
+		return
+	}
+	in.api.Data.Undo = Undo{}
+	in.HandleBookmarksShow()
+}
+
+// clearTags removes all tags of the current bookmark and shows the modify
+// screen.
+func (in *InputHandler) clearTags() {
+	if in.deferWrites() {
+		in.api.Data.Bookmark.Tags = []string{}
+		in.stageField(bukudb.MaskTags)
+		return
+	}
+	if in.bookmarkChanged() || in.fieldConflict(fieldTags, opDelete) {
+		return
+	}
+	if err := in.db.ClearTags(in.api.Data.Bookmark.ID); err != nil {
+		in.setMessageToError(fmt.Errorf("error clearing tags: %w", err))
+		return
+	}
+	in.saveUndo(fieldTags)
+	in.api.Data.Bookmark.Tags = []string{}
+	in.handleModifyShow()
+}
+
+func (in *InputHandler) handleClearTagsShow() {
+	in.api.Options[rofiapi.OptionMessage] = in.style.generatePangoMarkup(
+		"clear all tags? (yes/No)", "", strings.Join(in.api.Data.Bookmark.Tags, ", "))
+	in.api.Options[rofiapi.OptionNoCustom] = "false"
+	in.api.Options[rofiapi.OptionUseHotKeys] = "false"
+
+	in.api.Entries = []rofiapi.Entry{
+		{Text: opBack},
+	}
+
+	in.api.Data.State = StateClearTagsSelect
+}
+
+func (in *InputHandler) handleClearTagsSelect(input string) {
+	if input != "yes" {
+		in.handleModifyTagsShow()
+		return
+	}
+	in.clearTags()
+}
@@ -0,0 +1,96 @@
+package inputhandler
+
+import (
+	"testing"
+
+	"github.com/VannRR/robuku/config"
+	rofiapi "github.com/VannRR/rofi-api"
+)
+
+func Test_needsConfirm(t *testing.T) {
+	tests := []struct {
+		policy   config.ConfirmPolicy
+		action   confirmAction
+		expected bool
+	}{
+		{config.ConfirmNone, confirmDelete, false},
+		{config.ConfirmNone, confirmClearTags, false},
+		{config.ConfirmDelete, confirmDelete, true},
+		{config.ConfirmDelete, confirmClearTags, false},
+		{config.ConfirmAll, confirmDelete, true},
+		{config.ConfirmAll, confirmClearTags, true},
+	}
+	for _, tt := range tests {
+		if got := needsConfirm(tt.policy, tt.action); got != tt.expected {
+			t.Errorf("policy '%s', action %d: expected %v, got %v", tt.policy, tt.action, tt.expected, got)
+		}
+	}
+}
+
+func Test_handleBookmarksSelect_ConfirmPolicy(t *testing.T) {
+	tests := []struct {
+		policy   config.ConfirmPolicy
+		expected State
+		deleted  bool
+	}{
+		{config.ConfirmNone, StateBookmarksSelect, true},
+		{config.ConfirmDelete, StateDeleteConfirmSelect, false},
+		{config.ConfirmAll, StateDeleteConfirmSelect, false},
+	}
+	for _, tt := range tests {
+		in := initInputHandler(t)
+		in.cfg.Confirm = tt.policy
+
+		in.handleBookmarksSelect("1. metadata (title) google", rofiapi.StateCustomKeybinding3)
+
+		checkState(t, tt.expected, in.api.Data.State)
+		if deleted := in.db.Len() == 3; deleted != tt.deleted {
+			t.Errorf("policy '%s': expected deleted %v, got %v", tt.policy, tt.deleted, deleted)
+		}
+	}
+}
+
+func Test_handleModifyTagsSelect_ConfirmPolicy(t *testing.T) {
+	tests := []struct {
+		policy   config.ConfirmPolicy
+		expected State
+		cleared  bool
+	}{
+		{config.ConfirmNone, StateModifySelect, true},
+		{config.ConfirmDelete, StateModifySelect, true},
+		{config.ConfirmAll, StateClearTagsSelect, false},
+	}
+	for _, tt := range tests {
+		in := initInputHandler(t)
+		in.cfg.Confirm = tt.policy
+		in.api.Data.Bookmark, _ = in.db.Get(1)
+
+		in.handleModifyTagsSelect(opDelete)
+
+		checkState(t, tt.expected, in.api.Data.State)
+		b, _ := in.db.Get(1)
+		if cleared := len(b.Tags) == 0; cleared != tt.cleared {
+			t.Errorf("policy '%s': expected cleared %v, got %v", tt.policy, tt.cleared, cleared)
+		}
+	}
+}
+
+func Test_handleClearTagsSelect(t *testing.T) {
+	in := initInputHandler(t)
+	in.api.Data.Bookmark, _ = in.db.Get(1)
+
+	in.handleClearTagsSelect(opBack)
+	checkState(t, StateModifyTagsSelect, in.api.Data.State)
+	if b, _ := in.db.Get(1); len(b.Tags) != 3 {
+		t.Errorf("expected tags to be kept, got %v", b.Tags)
+	}
+
+	in.handleClearTagsSelect("yes")
+	checkState(t, StateModifySelect, in.api.Data.State)
+	if b, _ := in.db.Get(1); len(b.Tags) != 0 {
+		t.Errorf("expected tags to be cleared, got %v", b.Tags)
+	}
+	if len(in.api.Data.Bookmark.Tags) != 0 || in.api.Data.Undo.Field != fieldTags {
+		t.Errorf("expected cleared tags with undo, got %+v", in.api.Data)
+	}
+}
@@ -0,0 +1,120 @@
+package inputhandler
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/VannRR/robuku/bukudb"
+	rofiapi "github.com/VannRR/rofi-api"
+)
+
+const (
+	opOverwrite string = "--> Overwrite anyway"
+	opReload    string = "--> Reload"
+)
+
+// Conflict is an edit of the modify screen held back because the field
+// changed in the database since the screen was shown.
+type Conflict struct {
+	// Field that changed.
+	Field bookmarkField
+
+	// Input is what was entered on the field's screen, entered again to
+	// overwrite. It is empty if it was longer than maxUndoLen.
+	Input string
+}
+
+// fieldValue returns the value of field f of b.
+func fieldValue(b bukudb.Bookmark, f bookmarkField) string {
+	switch f {
+	case fieldTitle:
+		return b.Title
+	case fieldURL:
+		return b.URL
+	case fieldComment:
+		return b.Comment
+	case fieldTags:
+		return strings.Join(b.Tags, ", ")
+	}
+	return ""
+}
+
+// fieldConflict reports whether field f of the bookmark being modified
+// changed in the database since it was shown, e.g. by buku or another
+// robuku, and shows the conflict screen if so. input is what was entered,
+// to write it anyway.
+func (in *InputHandler) fieldConflict(f bookmarkField, input string) bool {
+	current, err := in.db.Get(in.api.Data.Bookmark.ID)
+	if err != nil {
+		// the write itself reports the error
+		return false
+	}
+	if fieldValue(current, f) == fieldValue(in.api.Data.Bookmark, f) {
+		return false
+	}
+
+	switch {
+	case input == "":
+		// a cleared title or comment, entered as opDelete
+		input = opDelete
+	case len(input) > maxUndoLen:
+		input = ""
+	}
+	in.api.Data.Conflict = Conflict{Field: f, Input: input}
+	in.handleConflictShow()
+	return true
+}
+
+func (in *InputHandler) handleConflictShow() {
+	c := in.api.Data.Conflict
+	current, err := in.db.Get(in.api.Data.Bookmark.ID)
+	if err != nil {
+		in.setMessageToError(fmt.Errorf("error getting bookmark: %w", err))
+		return
+	}
+
+	in.api.Options[rofiapi.OptionMessage] = in.style.generatePangoMarkup(
+		"value changed since you opened this screen", "", fieldValue(current, c.Field))
+	in.api.Options[rofiapi.OptionNoCustom] = "true"
+	in.api.Options[rofiapi.OptionUseHotKeys] = "false"
+
+	in.api.Entries = []rofiapi.Entry{{Text: opReload}}
+	if c.Input != "" {
+		in.api.Entries = append(in.api.Entries, rofiapi.Entry{Text: opOverwrite})
+	}
+
+	in.api.Data.State = StateConflictSelect
+}
+
+// handleConflictSelect reloads the bookmark being modified, and with
+// opOverwrite enters the held back input again, which now matches what was
+// seen unless the bookmark changed once more.
+func (in *InputHandler) handleConflictSelect(input string) {
+	c := in.api.Data.Conflict
+	in.api.Data.Conflict = Conflict{}
+
+	b, err := in.db.Get(in.api.Data.Bookmark.ID)
+	if err != nil {
+		in.setMessageToError(fmt.Errorf("error getting bookmark: %w", err))
+		return
+	}
+	in.api.Data.Bookmark = b
+
+	if input == opOverwrite && c.Input != "" {
+		switch {
+		case c.Field == fieldTitle:
+			in.handleModifyTitleSelect(c.Input)
+		case c.Field == fieldURL:
+			in.handleModifyUrlSelect(c.Input)
+		case c.Field == fieldComment:
+			in.handleModifyCommentSelect(c.Input)
+		case c.Field == fieldTags && c.Input == opDelete:
+			// the clear was confirmed already
+			in.clearTags()
+		case c.Field == fieldTags:
+			in.handleModifyTagsSelect(c.Input)
+		}
+		return
+	}
+	in.handleModifyShow()
+}
@@ -0,0 +1,137 @@
+package inputhandler
+
+import (
+	"slices"
+	"strings"
+	"testing"
+
+	"github.com/VannRR/robuku/bukudb"
+	rofiapi "github.com/VannRR/rofi-api"
+)
+
+// changeOnDisk changes bookmark id of the mock behind the handler's back,
+// like buku or another robuku would.
+func changeOnDisk(in *InputHandler, id uint16, change func(b *bukudb.Bookmark)) {
+	change(&in.db.(*mockDB).bookmarks[id-1])
+}
+
+func checkConflictScreen(t *testing.T, in *InputHandler, current string, entries []rofiapi.Entry) {
+	t.Helper()
+	checkState(t, StateConflictSelect, in.api.Data.State)
+	msg := in.api.Options[rofiapi.OptionMessage]
+	if !strings.Contains(msg, "value changed since you opened this screen") || !strings.Contains(msg, current) {
+		t.Errorf("expected the conflict message with '%s', got '%s'", current, msg)
+	}
+	checkEntries(t, entries, in.api.Entries)
+}
+
+func Test_fieldConflict_Title(t *testing.T) {
+	in := initInputHandler(t)
+	in.api.Data.Bookmark, _ = in.db.Get(1)
+	changeOnDisk(in, 1, func(b *bukudb.Bookmark) {
+		b.Title = "changed elsewhere"
+	})
+
+	in.handleModifyTitleSelect("mine")
+	checkConflictScreen(t, in, "changed elsewhere",
+		[]rofiapi.Entry{{Text: opReload}, {Text: opOverwrite}})
+	if b, _ := in.db.Get(1); b.Title != "changed elsewhere" {
+		t.Errorf("expected the title to be kept, got '%s'", b.Title)
+	}
+
+	in.handleConflictSelect(opOverwrite)
+	checkState(t, StateModifySelect, in.api.Data.State)
+	if b, _ := in.db.Get(1); b.Title != "mine" || in.api.Data.Bookmark.Title != "mine" {
+		t.Errorf("expected title 'mine', got '%s' and '%s'", b.Title, in.api.Data.Bookmark.Title)
+	}
+	if in.api.Data.Undo.Value != "changed elsewhere" {
+		t.Errorf("expected undo to restore 'changed elsewhere', got '%s'", in.api.Data.Undo.Value)
+	}
+	if in.api.Data.Conflict != (Conflict{}) {
+		t.Errorf("expected the conflict to be cleared, got %+v", in.api.Data.Conflict)
+	}
+}
+
+func Test_fieldConflict_Reload(t *testing.T) {
+	in := initInputHandler(t)
+	in.api.Data.Bookmark, _ = in.db.Get(1)
+	changeOnDisk(in, 1, func(b *bukudb.Bookmark) {
+		b.Comment = "changed elsewhere"
+	})
+
+	// clearing the comment can be overwritten too
+	in.handleModifyCommentSelect(opDelete)
+	checkConflictScreen(t, in, "changed elsewhere",
+		[]rofiapi.Entry{{Text: opReload}, {Text: opOverwrite}})
+
+	in.handleConflictSelect(opReload)
+	checkState(t, StateModifySelect, in.api.Data.State)
+	if b, _ := in.db.Get(1); b.Comment != "changed elsewhere" || in.api.Data.Bookmark.Comment != "changed elsewhere" {
+		t.Errorf("expected the comment to be reloaded, got '%s' and '%s'", b.Comment, in.api.Data.Bookmark.Comment)
+	}
+
+	// after the reload the edit goes through
+	in.handleModifyCommentSelect(opDelete)
+	if b, _ := in.db.Get(1); b.Comment != "" {
+		t.Errorf("expected the comment to be cleared, got '%s'", b.Comment)
+	}
+}
+
+func Test_fieldConflict_OtherField(t *testing.T) {
+	in := initInputHandler(t)
+	in.api.Data.Bookmark, _ = in.db.Get(1)
+	changeOnDisk(in, 1, func(b *bukudb.Bookmark) {
+		b.Comment = "changed elsewhere"
+	})
+
+	in.handleModifyTitleSelect("mine")
+	checkState(t, StateModifySelect, in.api.Data.State)
+	if b, _ := in.db.Get(1); b.Title != "mine" || b.Comment != "changed elsewhere" {
+		t.Errorf("expected only the title to change, got '%s' and '%s'", b.Title, b.Comment)
+	}
+}
+
+func Test_fieldConflict_Tags(t *testing.T) {
+	in := initInputHandler(t)
+	in.api.Data.Bookmark, _ = in.db.Get(3)
+	changeOnDisk(in, 3, func(b *bukudb.Bookmark) {
+		b.Tags = []string{"theirs"}
+	})
+
+	in.handleModifyTagsSelect("+ mine")
+	checkConflictScreen(t, in, "theirs", []rofiapi.Entry{{Text: opReload}, {Text: opOverwrite}})
+	in.handleConflictSelect(opOverwrite)
+	if b, _ := in.db.Get(3); !slices.Equal(b.Tags, []string{"mine", "theirs"}) ||
+		!slices.Equal(in.api.Data.Bookmark.Tags, []string{"mine", "theirs"}) {
+		t.Errorf("expected tags 'mine' and 'theirs', got %q and %q", b.Tags, in.api.Data.Bookmark.Tags)
+	}
+
+	// a confirmed clear is not asked again
+	changeOnDisk(in, 3, func(b *bukudb.Bookmark) {
+		b.Tags = []string{"again"}
+	})
+	in.clearTags()
+	checkConflictScreen(t, in, "again", []rofiapi.Entry{{Text: opReload}, {Text: opOverwrite}})
+	in.handleConflictSelect(opOverwrite)
+	checkState(t, StateModifySelect, in.api.Data.State)
+	if b, _ := in.db.Get(3); len(b.Tags) != 0 {
+		t.Errorf("expected the tags to be cleared, got %q", b.Tags)
+	}
+}
+
+func Test_fieldConflict_LongInput(t *testing.T) {
+	in := initInputHandler(t)
+	in.api.Data.Bookmark, _ = in.db.Get(1)
+	changeOnDisk(in, 1, func(b *bukudb.Bookmark) {
+		b.Comment = "changed elsewhere"
+	})
+
+	// too long to keep in Data, it can only be reloaded
+	in.handleModifyCommentSelect(strings.Repeat("a", maxUndoLen+1))
+	checkConflictScreen(t, in, "changed elsewhere", []rofiapi.Entry{{Text: opReload}})
+	in.handleConflictSelect(opOverwrite)
+	checkState(t, StateModifySelect, in.api.Data.State)
+	if b, _ := in.db.Get(1); b.Comment != "changed elsewhere" {
+		t.Errorf("expected the comment to be kept, got '%s'", b.Comment)
+	}
+}
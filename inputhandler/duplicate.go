@@ -0,0 +1,136 @@
+package inputhandler
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/VannRR/robuku/bukudb"
+	rofiapi "github.com/VannRR/rofi-api"
+)
+
+// opEditDuplicate opens the modify screen of the bookmark that already has
+// the entered URL.
+const opEditDuplicate string = "--> Edit that bookmark"
+
+// handleUpdateURLError shows err of changing the URL of the bookmark being
+// modified, offering to edit the bookmark that already has the URL.
+func (in *InputHandler) handleUpdateURLError(err error) {
+	var dup *bukudb.DuplicateURLError
+	if errors.As(err, &dup) && dup.ID != 0 {
+		in.api.Data.Duplicate = dup.ID
+		in.handleDuplicateURLShow()
+		return
+	}
+	in.setMessageToError(fmt.Errorf("error updating url: %w", err))
+}
+
+func (in *InputHandler) handleDuplicateURLShow() {
+	in.api.Options[rofiapi.OptionMessage] = in.style.generatePangoMarkup(
+		fmt.Sprintf("that URL already belongs to bookmark #%04d — edit that one instead?",
+			in.api.Data.Duplicate), "", "")
+	in.api.Options[rofiapi.OptionNoCustom] = "true"
+	in.api.Options[rofiapi.OptionUseHotKeys] = "false"
+
+	in.api.Entries = []rofiapi.Entry{
+		{Text: opBack},
+		{Text: opEditDuplicate},
+	}
+
+	in.api.Data.State = StateDuplicateURLSelect
+}
+
+func (in *InputHandler) handleDuplicateURLSelect(input string) {
+	id := in.api.Data.Duplicate
+	in.api.Data.Duplicate = 0
+	if input != opEditDuplicate {
+		in.handleModifyUrlShow()
+		return
+	}
+
+	b, err := in.db.Get(id)
+	if err != nil {
+		in.setMessageToError(fmt.Errorf("error getting bookmark: %w", err))
+		return
+	}
+	in.api.Data.Bookmark = b
+	in.handleModifyShow()
+}
+
+const (
+	opViewDuplicate  string = "--> View existing bookmark"
+	opMergeDuplicate string = "--> Merge my tags into it"
+	opBackToEdit     string = "<-- Back to edit"
+)
+
+// handleAddDuplicateShow offers the ways out of adding a URL that another
+// bookmark got since the add screen was shown. The draft stays in
+// Data.Bookmark.
+func (in *InputHandler) handleAddDuplicateShow() {
+	in.api.Options[rofiapi.OptionMessage] = in.style.generatePangoMarkup(
+		fmt.Sprintf("that URL is already bookmark #%04d", in.api.Data.Duplicate),
+		"", in.api.Data.Bookmark.URL)
+	in.api.Options[rofiapi.OptionNoCustom] = "true"
+	in.api.Options[rofiapi.OptionUseHotKeys] = "false"
+
+	in.api.Entries = []rofiapi.Entry{
+		{Text: opBackToEdit},
+		{Text: opViewDuplicate},
+		{Text: opMergeDuplicate},
+	}
+
+	in.api.Data.State = StateAddDuplicateSelect
+}
+
+func (in *InputHandler) handleAddDuplicateSelect(input string) {
+	id := in.api.Data.Duplicate
+	switch input {
+	case opViewDuplicate:
+		b, err := in.db.Get(id)
+		if err != nil {
+			in.setMessageToError(fmt.Errorf("error getting bookmark: %w", err))
+			return
+		}
+		in.api.Data.Duplicate = 0
+		in.api.Data.Source = ""
+		in.api.Data.Bookmark = b
+		in.handleModifyShow()
+	case opMergeDuplicate:
+		if err := in.mergeDraft(id); err != nil {
+			in.setMessageToError(err)
+			return
+		}
+		in.api.Data.Duplicate = 0
+		in.api.Data.Source = ""
+		in.HandleBookmarksShow()
+		in.selectBookmark(id)
+		in.addNotice(fmt.Sprintf("merged into #%04d", id))
+	case opBackToEdit:
+		in.api.Data.Duplicate = 0
+		in.handleAddShow()
+	default:
+		in.handleAddDuplicateShow()
+	}
+}
+
+// mergeDraft adds the tags of the bookmark being added to bookmark id, and
+// its comment as a note unless the comment already has it.
+func (in *InputHandler) mergeDraft(id uint16) error {
+	existing, err := in.db.Get(id)
+	if err != nil {
+		return fmt.Errorf("error getting bookmark: %w", err)
+	}
+	draft := in.api.Data.Bookmark
+	if len(draft.Tags) > 0 {
+		if err := in.db.AddTags(id, draft.Tags); err != nil {
+			return fmt.Errorf("error adding tag: %w", err)
+		}
+	}
+	if draft.Comment != "" && !strings.Contains(existing.Comment, draft.Comment) {
+		comment := appendNote(existing.Comment, draft.Comment)
+		if err := in.db.UpdateComment(id, comment); err != nil {
+			return fmt.Errorf("error updating comment: %w", err)
+		}
+	}
+	return nil
+}
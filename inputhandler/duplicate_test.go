@@ -0,0 +1,145 @@
+package inputhandler
+
+import (
+	"slices"
+	"strings"
+	"testing"
+
+	"github.com/VannRR/robuku/bukudb"
+	rofiapi "github.com/VannRR/rofi-api"
+)
+
+func Test_handleModifyUrlSelect_Duplicate(t *testing.T) {
+	in := initInputHandler(t)
+	in.api.Data.Bookmark, _ = in.db.Get(1)
+
+	in.handleModifyUrlSelect("https://www.c.com")
+	checkState(t, StateDuplicateURLSelect, in.api.Data.State)
+	if in.api.Data.Duplicate != 3 {
+		t.Errorf("expected duplicate #3, got #%d", in.api.Data.Duplicate)
+	}
+	want := "that URL already belongs to bookmark #0003 — edit that one instead?"
+	if msg := in.api.Options[rofiapi.OptionMessage]; !strings.Contains(msg, want) {
+		t.Errorf("expected message to contain '%s', got '%s'", want, msg)
+	}
+	checkEntries(t, []rofiapi.Entry{{Text: opBack}, {Text: opEditDuplicate}}, in.api.Entries)
+	if b, _ := in.db.Get(1); b.URL != "https://www.google.com" {
+		t.Errorf("expected url to be unchanged, got '%s'", b.URL)
+	}
+
+	// Back returns to the url prompt of the same bookmark
+	in.handleDuplicateURLSelect(opBack)
+	checkState(t, StateModifyUrlSelect, in.api.Data.State)
+	if in.api.Data.Bookmark.ID != 1 || in.api.Data.Duplicate != 0 {
+		t.Errorf("expected to still modify #1 with no duplicate, got #%d and #%d",
+			in.api.Data.Bookmark.ID, in.api.Data.Duplicate)
+	}
+
+	// the jump loads the other bookmark
+	in.handleModifyUrlSelect("https://www.c.com")
+	in.handleDuplicateURLSelect(opEditDuplicate)
+	checkState(t, StateModifySelect, in.api.Data.State)
+	if in.api.Data.Bookmark.ID != 3 || in.api.Data.Bookmark.URL != "https://www.c.com" {
+		t.Errorf("expected to modify #3, got %+v", in.api.Data.Bookmark)
+	}
+}
+
+func initAddDuplicate(t *testing.T) *InputHandler {
+	t.Helper()
+	in := initInputHandler(t)
+	in.api.Data.Bookmark = bukudb.Bookmark{URL: "https://www.b.com", Title: "draft",
+		Tags: []string{"go", "tag2"}, Comment: "from the draft"}
+	in.handleAddSelect(opConfirm)
+	checkState(t, StateAddDuplicateSelect, in.api.Data.State)
+	if in.api.Data.Duplicate != 2 {
+		t.Fatalf("expected duplicate #2, got #%d", in.api.Data.Duplicate)
+	}
+	checkMessageContains(t, in, "that URL is already bookmark #0002")
+	checkEntries(t, []rofiapi.Entry{
+		{Text: opBackToEdit}, {Text: opViewDuplicate}, {Text: opMergeDuplicate},
+	}, in.api.Entries)
+	if in.db.Len() != 4 {
+		t.Errorf("expected no bookmark added, got %d", in.db.Len())
+	}
+	return in
+}
+
+func Test_handleAddDuplicateSelect_view(t *testing.T) {
+	in := initAddDuplicate(t)
+
+	in.HandleInput(opViewDuplicate)
+	checkState(t, StateModifySelect, in.api.Data.State)
+	if in.api.Data.Bookmark.ID != 2 || in.api.Data.Duplicate != 0 {
+		t.Errorf("expected to modify #2 with no duplicate, got #%d and #%d",
+			in.api.Data.Bookmark.ID, in.api.Data.Duplicate)
+	}
+	if b, _ := in.db.Get(2); !slices.Equal(b.Tags, []string{"b", "tag2", "tag3"}) {
+		t.Errorf("expected tags to be unchanged, got %v", b.Tags)
+	}
+}
+
+func Test_handleAddDuplicateSelect_merge(t *testing.T) {
+	in := initAddDuplicate(t)
+
+	in.HandleInput(opMergeDuplicate)
+	checkState(t, StateBookmarksSelect, in.api.Data.State)
+	checkMessageContains(t, in, "merged into #0002")
+	b, _ := in.db.Get(2)
+	if !slices.Equal(b.Tags, []string{"b", "go", "tag2", "tag3"}) {
+		t.Errorf("expected merged tags, got %v", b.Tags)
+	}
+	if b.Comment != "from the draft" || b.Title != "metadata (title) b" {
+		t.Errorf("expected the draft comment and the old title, got %+v", b)
+	}
+	if in.api.Options[rofiapi.OptionNewSelection] != "1" {
+		t.Errorf("expected #2 to be selected, got '%s'", in.api.Options[rofiapi.OptionNewSelection])
+	}
+
+	// an empty draft comment leaves the comment alone
+	in = initAddDuplicate(t)
+	in.api.Data.Bookmark.Comment = ""
+	in.HandleInput(opMergeDuplicate)
+	if b, _ := in.db.Get(2); b.Comment != "" {
+		t.Errorf("expected no comment, got '%s'", b.Comment)
+	}
+}
+
+func Test_handleAddDuplicateSelect_back(t *testing.T) {
+	in := initAddDuplicate(t)
+
+	in.HandleInput(opBackToEdit)
+	checkState(t, StateAddSelect, in.api.Data.State)
+	if in.api.Data.Bookmark.Title != "draft" || in.api.Data.Duplicate != 0 {
+		t.Errorf("expected the draft with no duplicate, got %+v and #%d",
+			in.api.Data.Bookmark, in.api.Data.Duplicate)
+	}
+	if in.db.Len() != 4 {
+		t.Errorf("expected no bookmark added, got %d", in.db.Len())
+	}
+}
+
+func Test_handleAddSelect_DuplicateKeepsDraft(t *testing.T) {
+	in := initInputHandler(t)
+	in.cfg.AutoTags = true
+	in.cfg.Inbox = true
+	in.cfg.AutoTagSource = true
+	draft := bukudb.Bookmark{URL: "https://www.b.com", Tags: []string{"go"}}
+	in.api.Data.Bookmark = draft
+	in.api.Data.Source = SourceCLI
+	in.handleAddSelect(opConfirm)
+	checkState(t, StateAddDuplicateSelect, in.api.Data.State)
+	if !slices.Equal(in.api.Data.Bookmark.Tags, draft.Tags) {
+		t.Errorf("expected the draft tags %v, got %v", draft.Tags, in.api.Data.Bookmark.Tags)
+	}
+	if in.api.Data.Source != SourceCLI {
+		t.Errorf("expected the source to be kept, got '%s'", in.api.Data.Source)
+	}
+
+	in.HandleInput(opMergeDuplicate)
+	if b, _ := in.db.Get(2); !slices.Equal(b.Tags, []string{"b", "go", "tag2", "tag3"}) {
+		t.Errorf("expected only the typed tags merged, got %v", b.Tags)
+	}
+	if in.api.Data.Source != "" {
+		t.Errorf("expected the source to be forgotten, got '%s'", in.api.Data.Source)
+	}
+}
@@ -0,0 +1,28 @@
+package inputhandler
+
+import (
+	"fmt"
+
+	"github.com/VannRR/robuku/bukudb"
+	rofiapi "github.com/VannRR/rofi-api"
+)
+
+// capBookmarks returns the first max of bookmarks, in their listed order,
+// and the entry telling how many were left out. ok is false if nothing was
+// left out, when max is 0 or index mode jumps through all bookmarks.
+func capBookmarks(bookmarks []bukudb.Bookmark, max int, indexMode bool) ([]bukudb.Bookmark, rofiapi.Entry, bool) {
+	if max <= 0 || indexMode || len(bookmarks) <= max {
+		return bookmarks, rofiapi.Entry{}, false
+	}
+	return bookmarks[:max], overflowEntry(len(bookmarks) - max), true
+}
+
+// overflowEntry returns the last entry of a capped list, hidden bookmarks
+// were left out.
+func overflowEntry(hidden int) rofiapi.Entry {
+	return rofiapi.Entry{
+		Text: fmt.Sprintf("… %d more (press %s to search)", hidden,
+			hotkeyKeyName("filter")),
+		NonSelectable: true,
+	}
+}
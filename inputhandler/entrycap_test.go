@@ -0,0 +1,85 @@
+package inputhandler
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/VannRR/robuku/inputhandler/testutil"
+	rofiapi "github.com/VannRR/rofi-api"
+)
+
+func Test_capBookmarks(t *testing.T) {
+	bookmarks := testutil.Bookmarks(10)
+	tests := []struct {
+		name      string
+		max       int
+		indexMode bool
+		expected  int
+		capped    bool
+	}{
+		{"no cap", 0, false, 10, false},
+		{"below cap", 20, false, 10, false},
+		{"at cap", 10, false, 10, false},
+		{"over cap", 4, false, 4, true},
+		{"index mode", 4, true, 10, false},
+	}
+	for _, tt := range tests {
+		got, overflow, capped := capBookmarks(bookmarks, tt.max, tt.indexMode)
+		if len(got) != tt.expected || capped != tt.capped {
+			t.Errorf("%s: expected %d bookmarks capped %v, got %d capped %v",
+				tt.name, tt.expected, tt.capped, len(got), capped)
+		}
+		if capped && (got[0].ID != 1 || got[len(got)-1].ID != uint16(tt.max)) {
+			t.Errorf("%s: expected the first %d bookmarks, got %d to %d",
+				tt.name, tt.max, got[0].ID, got[len(got)-1].ID)
+		}
+		if capped != (overflow.Text != "") {
+			t.Errorf("%s: expected an overflow entry only if capped, got '%s'", tt.name, overflow.Text)
+		}
+	}
+}
+
+func Test_overflowEntry(t *testing.T) {
+	e := overflowEntry(412)
+	if e.Text != "… 412 more (press Alt+9 to search)" {
+		t.Errorf("expected '… 412 more (press Alt+9 to search)', got '%s'", e.Text)
+	}
+	if !e.NonSelectable {
+		t.Error("expected the overflow entry to be nonselectable")
+	}
+}
+
+func Test_HandleBookmarksShow_MaxEntries(t *testing.T) {
+	in := initInputHandler(t)
+	in.cfg.MaxEntries = 2
+	in.HandleBookmarksShow()
+	checkEntryTexts(t, []string{
+		"1. metadata (title) google",
+		"2. metadata (title) b",
+		"… 2 more (press Alt+9 to search)",
+	}, in.api.Entries)
+	if in.api.Options[rofiapi.OptionUseHotKeys] != "true" ||
+		!strings.Contains(in.api.Options[rofiapi.OptionMessage], "add: Alt+1") {
+		t.Errorf("expected the hotkeys to stay, got '%s'", in.api.Options[rofiapi.OptionMessage])
+	}
+
+	// the first of the filtered bookmarks are kept, after Back and the
+	// export entry
+	in.api.Data.Filters = []Filter{{Query: "metadata"}}
+	in.HandleBookmarksShow()
+	checkEntryTexts(t, []string{
+		opBack,
+		opExportList,
+		"1. metadata (title) google",
+		"2. metadata (title) b",
+		"… 1 more (press Alt+9 to search)",
+	}, in.api.Entries)
+
+	// index mode jumps through all bookmarks
+	in.api.Data.Filters = nil
+	in.cfg.IndexMode = true
+	in.HandleBookmarksShow()
+	if last := in.api.Entries[len(in.api.Entries)-1]; strings.HasPrefix(last.Text, "…") {
+		t.Errorf("expected no overflow entry in index mode, got '%s'", last.Text)
+	}
+}
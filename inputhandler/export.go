@@ -0,0 +1,221 @@
+package inputhandler
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/VannRR/robuku/bukudb"
+	"github.com/VannRR/robuku/export"
+	rofiapi "github.com/VannRR/rofi-api"
+)
+
+// handleExportShow offers the export formats for the bookmarks in
+// Data.ResultIDs.
+func (in *InputHandler) handleExportShow() {
+	n := len(in.api.Data.ResultIDs)
+	noun := "bookmarks"
+	if n == 1 {
+		noun = "bookmark"
+	}
+	in.api.Options[rofiapi.OptionMessage] = in.style.generatePangoMarkup(
+		fmt.Sprintf("export %d %s to %s", n, noun, in.cfg.ExportDir), "", "")
+	in.api.Options[rofiapi.OptionNoCustom] = "true"
+	in.api.Options[rofiapi.OptionUseHotKeys] = "false"
+
+	entries := []rofiapi.Entry{{Text: opBack}}
+	for _, f := range export.Formats {
+		entries = append(entries, rofiapi.Entry{Text: opExportAs + string(f)})
+	}
+	in.api.Entries = entries
+
+	in.api.Data.State = StateExportSelect
+}
+
+// exportListed offers the export formats for the bookmarks of the filtered
+// or recent list.
+func (in *InputHandler) exportListed() {
+	filter := in.activeFilter()
+	if in.api.Data.Recent {
+		filter = Filter{}
+	}
+	bookmarks, err := in.listedBookmarks(filter, in.hiddenTags(filter))
+	if err != nil {
+		in.setMessageToError(err)
+		return
+	}
+	ids := make([]uint16, len(bookmarks))
+	for i, b := range bookmarks {
+		ids[i] = b.ID
+	}
+	in.api.Data.ResultIDs = ids
+	in.handleExportShow()
+}
+
+func (in *InputHandler) handleExportSelect(input string) {
+	name, ok := strings.CutPrefix(input, opExportAs)
+	if !ok {
+		in.api.Data.ResultIDs = nil
+		in.HandleBookmarksShow()
+		return
+	}
+	format, err := export.ParseFormat(name)
+	if err != nil {
+		in.showWithError(in.handleExportShow, err)
+		return
+	}
+
+	in.api.Data.Export = ExportTarget{Format: format, Path: in.suggestedExportPath(format)}
+	in.handleExportPathShow()
+}
+
+// ExportTarget is where the bookmarks of Data.ResultIDs are exported to.
+type ExportTarget struct {
+	Format export.Format
+
+	// Path is the suggested path until the user chose one.
+	Path string
+}
+
+// suggestedExportPath returns a new file name in the export directory.
+func (in *InputHandler) suggestedExportPath(format export.Format) string {
+	name := fmt.Sprintf("robuku-export-%s.%s", in.now().Format("20060102-150405"), format)
+	return filepath.Join(in.cfg.ExportDir, name)
+}
+
+// handleExportPathShow asks where to write the export, selecting the
+// suggested path accepts it.
+func (in *InputHandler) handleExportPathShow() {
+	in.api.Options[rofiapi.OptionMessage] = in.style.generatePangoMarkup(
+		"export to", "~/bookmarks."+string(in.api.Data.Export.Format), in.api.Data.Export.Path)
+	in.api.Options[rofiapi.OptionNoCustom] = "false"
+	in.api.Options[rofiapi.OptionUseHotKeys] = "false"
+
+	in.api.Entries = []rofiapi.Entry{
+		{Text: opBack},
+		{Text: in.api.Data.Export.Path},
+	}
+
+	in.api.Data.State = StateExportPathSelect
+}
+
+func (in *InputHandler) handleExportPathSelect(input string) {
+	if input == opBack {
+		in.handleExportShow()
+		return
+	}
+
+	home, _ := os.UserHomeDir()
+	path, err := resolveExportPath(input, in.cfg.ExportDir, home)
+	if err != nil {
+		in.showWithError(in.handleExportPathShow, err)
+		return
+	}
+	in.api.Data.Export.Path = path
+
+	if _, err := os.Stat(path); err == nil {
+		in.handleOverwriteShow()
+		return
+	}
+	in.exportTo(false)
+}
+
+// resolveExportPath returns the absolute path of the export file input, a
+// leading "~" is expanded to home and relative paths are in dir. The parent
+// directory must exist, and the path must not be a directory.
+func resolveExportPath(input, dir, home string) (string, error) {
+	path, err := expandHome(strings.TrimSpace(input), home)
+	if err != nil {
+		return "", err
+	}
+	if !filepath.IsAbs(path) {
+		path = filepath.Join(dir, path)
+	}
+	path = filepath.Clean(path)
+
+	parent := filepath.Dir(path)
+	if info, err := os.Stat(parent); err != nil || !info.IsDir() {
+		return "", fmt.Errorf("directory %s does not exist", parent)
+	}
+	if info, err := os.Stat(path); err == nil && info.IsDir() {
+		return "", fmt.Errorf("%s is a directory", path)
+	}
+	return path, nil
+}
+
+// expandHome returns path with a leading "~" replaced by home.
+func expandHome(path, home string) (string, error) {
+	if path == "" {
+		return "", errors.New("no path entered")
+	}
+	if path != "~" && !strings.HasPrefix(path, "~/") {
+		return path, nil
+	}
+	if home == "" {
+		return "", errors.New("no home directory to expand '~'")
+	}
+	return filepath.Join(home, path[1:]), nil
+}
+
+func (in *InputHandler) handleOverwriteShow() {
+	in.api.Options[rofiapi.OptionMessage] = in.style.generatePangoMarkup(
+		"file exists, overwrite? (yes/No)", "", in.api.Data.Export.Path)
+	in.api.Options[rofiapi.OptionNoCustom] = "false"
+	in.api.Options[rofiapi.OptionUseHotKeys] = "false"
+
+	in.api.Entries = []rofiapi.Entry{
+		{Text: opBack},
+	}
+
+	in.api.Data.State = StateOverwriteSelect
+}
+
+func (in *InputHandler) handleOverwriteSelect(input string) {
+	if input != "yes" {
+		in.handleExportPathShow()
+		return
+	}
+	in.exportTo(true)
+}
+
+// exportTo writes the bookmarks of Data.ResultIDs to Data.Export, replacing
+// an existing file only if overwrite is true.
+func (in *InputHandler) exportTo(overwrite bool) {
+	// the bookmarks are fetched again since Data only holds their IDs
+	bookmarks, err := in.db.GetByIDs(in.api.Data.ResultIDs)
+	if err != nil {
+		in.setMessageToError(fmt.Errorf("error exporting bookmarks: %w", err))
+		return
+	}
+
+	target := in.api.Data.Export
+	if err := in.writeExport(target, bookmarks, overwrite); err != nil {
+		in.setMessageToError(fmt.Errorf("error exporting bookmarks: %w", err))
+		return
+	}
+
+	in.api.Data.ResultIDs = nil
+	in.api.Data.Export = ExportTarget{}
+	in.HandleBookmarksShow()
+	in.prependMessage(in.style.spanMarkup("", fmt.Sprintf("exported %d to %s", len(bookmarks), target.Path)))
+}
+
+// writeExport writes bookmarks to target, an existing file is only
+// replaced if overwrite is true.
+func writeExport(target ExportTarget, bookmarks []bukudb.Bookmark, overwrite bool) error {
+	flag := os.O_WRONLY | os.O_CREATE | os.O_EXCL
+	if overwrite {
+		flag = os.O_WRONLY | os.O_CREATE | os.O_TRUNC
+	}
+	f, err := os.OpenFile(target.Path, flag, 0o644)
+	if err != nil {
+		return err
+	}
+	if err := export.Write(f, target.Format, bookmarks); err != nil {
+		f.Close()
+		return err
+	}
+	return f.Close()
+}
@@ -0,0 +1,221 @@
+package inputhandler
+
+import (
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"testing"
+	"time"
+
+	rofiapi "github.com/VannRR/rofi-api"
+)
+
+func Test_handleExportShow(t *testing.T) {
+	in := initInputHandler(t)
+	in.cfg.ExportDir = "/tmp/exports"
+
+	in.handleBookmarksSelect("2. metadata (title) b", rofiapi.StateCustomKeybinding6)
+	checkState(t, StateExportSelect, in.api.Data.State)
+	if len(in.api.Data.ResultIDs) != 1 || in.api.Data.ResultIDs[0] != 2 {
+		t.Errorf("expected result ids [2], got %v", in.api.Data.ResultIDs)
+	}
+
+	checkOptions(t, map[rofiapi.Option]string{
+		rofiapi.OptionMessage: defaultStyle.generatePangoMarkup("export 1 bookmark to /tmp/exports", "", ""),
+	}, in.api.Options)
+	checkEntries(t, []rofiapi.Entry{
+		{Text: opBack},
+		{Text: opExportAs + "html"},
+		{Text: opExportAs + "json"},
+		{Text: opExportAs + "md"},
+		{Text: opExportAs + "atom"},
+	}, in.api.Entries)
+
+	// nothing selected
+	in = initInputHandler(t)
+	in.handleBookmarksSelect("", rofiapi.StateCustomKeybinding6)
+	checkState(t, StateBookmarksSelect, in.api.Data.State)
+}
+
+func Test_exportListed(t *testing.T) {
+	in := initInputHandler(t)
+	in.cfg.ExportDir = "/tmp/exports"
+	in.api.Data.Filters = []Filter{{Tag: "tag2"}}
+	in.HandleBookmarksShow()
+	if len(in.api.Entries) < 2 || in.api.Entries[1].Text != opExportList {
+		t.Fatalf("expected the second entry to be '%s', got %v", opExportList, in.api.Entries)
+	}
+
+	in.handleBookmarksSelect(opExportList, rofiapi.StateSelected)
+	checkState(t, StateExportSelect, in.api.Data.State)
+	if !slices.Equal(in.api.Data.ResultIDs, []uint16{1, 2}) {
+		t.Errorf("expected result ids [1 2], got %v", in.api.Data.ResultIDs)
+	}
+	checkOptions(t, map[rofiapi.Option]string{
+		rofiapi.OptionMessage: defaultStyle.generatePangoMarkup("export 2 bookmarks to /tmp/exports", "", ""),
+	}, in.api.Options)
+
+	// back keeps the filter
+	in.handleExportSelect(opBack)
+	checkState(t, StateBookmarksSelect, in.api.Data.State)
+	if len(in.api.Data.Filters) != 1 {
+		t.Errorf("expected the filter to be kept, got %v", in.api.Data.Filters)
+	}
+
+	// the unfiltered list and an empty result have no export entry
+	for _, filters := range [][]Filter{nil, {{Tag: "missing"}}} {
+		in.api.Data.Filters = filters
+		in.HandleBookmarksShow()
+		for _, e := range in.api.Entries {
+			if e.Text == opExportList {
+				t.Errorf("expected no '%s' entry with filters %v", opExportList, filters)
+			}
+		}
+	}
+}
+
+func Test_handleExportSelect(t *testing.T) {
+	in := initInputHandler(t)
+	in.cfg.ExportDir = t.TempDir()
+	in.now = func() time.Time { return time.Date(2024, 3, 9, 8, 7, 6, 0, time.Local) }
+
+	// selected back option
+	in.api.Data.ResultIDs = []uint16{1}
+	in.handleExportSelect(opBack)
+	checkState(t, StateBookmarksSelect, in.api.Data.State)
+	if in.api.Data.ResultIDs != nil {
+		t.Errorf("expected result ids to be cleared, got %v", in.api.Data.ResultIDs)
+	}
+
+	// unknown format
+	in.api.Data.ResultIDs = []uint16{1}
+	in.handleExportSelect(opExportAs + "xml")
+	checkState(t, StateExportSelect, in.api.Data.State)
+
+	// the suggested path is offered
+	in.api.Data.ResultIDs = []uint16{3, 1, 42}
+	in.handleExportSelect(opExportAs + "md")
+	checkState(t, StateExportPathSelect, in.api.Data.State)
+	path := filepath.Join(in.cfg.ExportDir, "robuku-export-20240309-080706.md")
+	checkEntries(t, []rofiapi.Entry{{Text: opBack}, {Text: path}}, in.api.Entries)
+
+	// result set with a deleted bookmark
+	in.handleExportPathSelect(path)
+	checkState(t, StateBookmarksSelect, in.api.Data.State)
+	if !strings.Contains(in.api.Options[rofiapi.OptionMessage], "exported 2 to "+path) {
+		t.Errorf("expected message to contain the export path, got '%s'",
+			in.api.Options[rofiapi.OptionMessage])
+	}
+	content, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	expected := "- [metadata (title) c](https://www.c.com)\n" +
+		"- [metadata (title) google](https://www.google.com) <!-- TAGS: google,tag2,tag3 -->\n"
+	if string(content) != expected {
+		t.Errorf("expected export:\n%s\ngot:\n%s", expected, content)
+	}
+	if in.api.Data.ResultIDs != nil || in.api.Data.Export != (ExportTarget{}) {
+		t.Errorf("expected export state to be cleared, got %v %+v",
+			in.api.Data.ResultIDs, in.api.Data.Export)
+	}
+}
+
+func Test_handleExportPathSelect(t *testing.T) {
+	in := initInputHandler(t)
+	in.cfg.ExportDir = t.TempDir()
+	in.api.Data.ResultIDs = []uint16{1}
+	in.handleExportSelect(opExportAs + "md")
+
+	// back to the formats
+	in.handleExportPathSelect(opBack)
+	checkState(t, StateExportSelect, in.api.Data.State)
+
+	// a missing directory asks again
+	in.handleExportSelect(opExportAs + "md")
+	in.handleExportPathSelect("missing/bookmarks.md")
+	checkState(t, StateExportPathSelect, in.api.Data.State)
+	if !strings.Contains(in.api.Options[rofiapi.OptionMessage], "does not exist") {
+		t.Errorf("expected missing directory error, got '%s'", in.api.Options[rofiapi.OptionMessage])
+	}
+
+	// a typed relative path is in the export directory
+	in.handleExportPathSelect("bookmarks.md")
+	checkState(t, StateBookmarksSelect, in.api.Data.State)
+	path := filepath.Join(in.cfg.ExportDir, "bookmarks.md")
+	if _, err := os.Stat(path); err != nil {
+		t.Fatalf("expected export at %s, got %v", path, err)
+	}
+}
+
+func Test_handleOverwriteSelect(t *testing.T) {
+	in := initInputHandler(t)
+	in.cfg.ExportDir = t.TempDir()
+	path := filepath.Join(in.cfg.ExportDir, "bookmarks.md")
+	if err := os.WriteFile(path, []byte("old"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	in.api.Data.ResultIDs = []uint16{3}
+	in.handleExportSelect(opExportAs + "md")
+	in.handleExportPathSelect(path)
+	checkState(t, StateOverwriteSelect, in.api.Data.State)
+	checkOptions(t, map[rofiapi.Option]string{
+		rofiapi.OptionMessage: defaultStyle.generatePangoMarkup("file exists, overwrite? (yes/No)", "", path),
+	}, in.api.Options)
+
+	// anything but yes keeps the file and asks for the path again
+	in.handleOverwriteSelect("no")
+	checkState(t, StateExportPathSelect, in.api.Data.State)
+	if content, _ := os.ReadFile(path); string(content) != "old" {
+		t.Errorf("expected file to be kept, got '%s'", content)
+	}
+
+	in.handleExportPathSelect(path)
+	in.handleOverwriteSelect("yes")
+	checkState(t, StateBookmarksSelect, in.api.Data.State)
+	expected := "- [metadata (title) c](https://www.c.com)\n"
+	if content, _ := os.ReadFile(path); string(content) != expected {
+		t.Errorf("expected overwritten export '%s', got '%s'", expected, content)
+	}
+}
+
+func Test_resolveExportPath(t *testing.T) {
+	dir := t.TempDir()
+	home := t.TempDir()
+	if err := os.Mkdir(filepath.Join(dir, "sub"), 0o755); err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		input    string
+		expected string
+		err      string
+	}{
+		{"bookmarks.html", filepath.Join(dir, "bookmarks.html"), ""},
+		{"  sub/../sub/b.json ", filepath.Join(dir, "sub", "b.json"), ""},
+		{"~/b.md", filepath.Join(home, "b.md"), ""},
+		{filepath.Join(home, "b.md"), filepath.Join(home, "b.md"), ""},
+		{"", "", "no path entered"},
+		{"missing/b.md", "", "does not exist"},
+		{"sub", "", "is a directory"},
+		{"~", "", "is a directory"},
+	}
+	for _, tt := range tests {
+		path, err := resolveExportPath(tt.input, dir, home)
+		if tt.err != "" {
+			if err == nil || !strings.Contains(err.Error(), tt.err) {
+				t.Errorf("input %q: expected error '%s', got '%v'", tt.input, tt.err, err)
+			}
+			continue
+		}
+		if err != nil || path != tt.expected {
+			t.Errorf("input %q: expected '%s', got '%s' and error '%v'", tt.input, tt.expected, path, err)
+		}
+	}
+
+	if _, err := resolveExportPath("~/b.md", dir, ""); err == nil {
+		t.Error("expected error without home directory, got nil")
+	}
+}
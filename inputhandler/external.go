@@ -0,0 +1,24 @@
+package inputhandler
+
+import (
+	"fmt"
+
+	"github.com/VannRR/robuku/bukudb"
+)
+
+// bookmarkChanged reports whether the bookmark being modified was changed
+// on disk by another program since it was shown, showing the error if so.
+// Its URL is only compared when no new URL is pending, which would not be
+// on disk yet.
+func (in *InputHandler) bookmarkChanged() bool {
+	b := in.api.Data.Bookmark
+	url := b.URL
+	if in.api.Data.Pending&bukudb.MaskURL != 0 {
+		url = ""
+	}
+	if err := in.db.CheckBookmark(b.ID, url); err != nil {
+		in.setMessageToError(fmt.Errorf("error checking bookmark: %w", err))
+		return true
+	}
+	return false
+}
@@ -0,0 +1,34 @@
+package inputhandler
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/VannRR/robuku/bukudb"
+	rofiapi "github.com/VannRR/rofi-api"
+)
+
+func Test_bookmarkChanged(t *testing.T) {
+	in := initInputHandler(t)
+	db := in.db.(*mockDB)
+	in.api.Data.Bookmark, _ = in.db.Get(1)
+
+	if in.bookmarkChanged() {
+		t.Errorf("expected an unchanged bookmark")
+	}
+
+	db.checkErr = bukudb.ErrChangedOnDisk
+	in.handleModifyTitleSelect("new title")
+	checkState(t, StateErrorShow, in.api.Data.State)
+	if msg := in.api.Options[rofiapi.OptionMessage]; !strings.Contains(msg, bukudb.ErrChangedOnDisk.Error()) {
+		t.Errorf("expected message to contain '%s', got '%s'", bukudb.ErrChangedOnDisk, msg)
+	}
+	if b, _ := in.db.Get(1); b.Title != "metadata (title) google" {
+		t.Errorf("expected title to be unchanged, got '%s'", b.Title)
+	}
+
+	in.deleteBookmark()
+	if in.db.Len() != 4 {
+		t.Errorf("expected no bookmark to be deleted, got %d left", in.db.Len())
+	}
+}
@@ -0,0 +1,93 @@
+package inputhandler
+
+import (
+	"fmt"
+	"strconv"
+
+	rofiapi "github.com/VannRR/rofi-api"
+)
+
+// undoDeleteAction restores the bookmark deleted last with
+// $ROBUKU_FAST_DELETE=1.
+var undoDeleteAction = hotkeyAction{key: 15, hint: "undo delete", help: "restore the bookmark deleted last"}
+
+// fastDelete deletes the current bookmark without asking and shows the
+// bookmarks at the same position, offering to undo it.
+func (in *InputHandler) fastDelete() {
+	if in.bookmarkChanged() {
+		return
+	}
+	b := in.api.Data.Bookmark
+	if err := in.db.Remove(b.ID); err != nil {
+		in.setMessageToError(fmt.Errorf("error deleting bookmark: %w", err))
+		return
+	}
+	in.api.Data.Undo = Undo{}
+	in.api.Data.Deleted = in.trashID(b.URL)
+
+	in.HandleBookmarksShow()
+	// the bookmarks after it moved up an ID
+	in.selectFrom(b.ID)
+	// nothing is in the trash on a dry run
+	if in.api.Data.Deleted != 0 {
+		in.addNotice(fmt.Sprintf("deleted #%04d — press %s to undo", b.ID, undoDeleteAction.keyName()))
+	}
+}
+
+// trashID returns the trash ID of the bookmark with url deleted last, or 0
+// if none is in the trash.
+func (in *InputHandler) trashID(url string) int64 {
+	trashed, err := in.db.TrashList()
+	if err != nil {
+		return 0
+	}
+	var id int64
+	for _, t := range trashed {
+		if t.URL == url && t.TrashID > id {
+			id = t.TrashID
+		}
+	}
+	return id
+}
+
+// selectFrom highlights the first listed bookmark with an ID of at least
+// id, or the last entry if there is none.
+func (in *InputHandler) selectFrom(id uint16) {
+	last := -1
+	for i, e := range in.api.Entries {
+		entryID, err := getIdFromBookmarkString(e.Text)
+		if err != nil {
+			continue
+		}
+		last = i
+		if entryID >= id {
+			break
+		}
+	}
+	if last >= 0 {
+		in.api.Options[rofiapi.OptionKeepSelection] = "true"
+		in.api.Options[rofiapi.OptionNewSelection] = strconv.Itoa(last)
+	}
+}
+
+// undoFastDelete restores the bookmark deleted last with fastDelete and
+// highlights it.
+func (in *InputHandler) undoFastDelete() {
+	trashID := in.api.Data.Deleted
+	if !in.cfg.FastDelete || trashID == 0 {
+		in.HandleBookmarksShow()
+		if in.cfg.FastDelete {
+			in.addNotice("nothing to undo")
+		}
+		return
+	}
+	in.api.Data.Deleted = 0
+	id, err := in.db.Restore(trashID)
+	if err != nil {
+		in.showWithError(in.HandleBookmarksShow, fmt.Errorf("error restoring bookmark: %w", err))
+		return
+	}
+	in.HandleBookmarksShow()
+	in.selectBookmark(id)
+	in.addNotice(fmt.Sprintf("restored #%04d", id))
+}
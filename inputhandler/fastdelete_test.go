@@ -0,0 +1,110 @@
+package inputhandler
+
+import (
+	"slices"
+	"strings"
+	"testing"
+
+	"github.com/VannRR/robuku/bukudb"
+	"github.com/VannRR/robuku/config"
+	rofiapi "github.com/VannRR/rofi-api"
+)
+
+func Test_fastDelete(t *testing.T) {
+	in := initInputHandler(t)
+	in.cfg.FastDelete = true
+
+	in.handleBookmarksSelect("2. metadata (title) b", rofiapi.StateCustomKeybinding3)
+	checkState(t, StateBookmarksSelect, in.api.Data.State)
+	in.showNotices()
+	checkMessageContains(t, in, "deleted #0002 — press Alt+Shift+5 to undo")
+	if in.db.Len() != 3 {
+		t.Errorf("expected 3 bookmarks, got %d", in.db.Len())
+	}
+	if in.api.Data.Deleted != 1 {
+		t.Errorf("expected trash ID 1 to undo, got %d", in.api.Data.Deleted)
+	}
+	// the next bookmark took its place
+	if in.api.Options[rofiapi.OptionNewSelection] != "1" {
+		t.Errorf("expected the same position to be selected, got '%s'", in.api.Options[rofiapi.OptionNewSelection])
+	}
+
+	// the last bookmark leaves the new last one selected
+	in.handleBookmarksSelect("3. metadata (title) d", rofiapi.StateCustomKeybinding3)
+	if in.api.Options[rofiapi.OptionNewSelection] != "1" {
+		t.Errorf("expected the new last bookmark to be selected, got '%s'", in.api.Options[rofiapi.OptionNewSelection])
+	}
+	if in.api.Data.Deleted != 2 {
+		t.Errorf("expected trash ID 2 to undo, got %d", in.api.Data.Deleted)
+	}
+}
+
+func Test_undoFastDelete(t *testing.T) {
+	in := initInputHandler(t)
+	in.cfg.FastDelete = true
+
+	in.handleBookmarksSelect("1. metadata (title) google", rofiapi.StateCustomKeybinding3)
+	in.handleBookmarksSelect("1. metadata (title) b", rofiapi.StateCustomKeybinding3)
+	in.showNotices()
+
+	// the most recent deletion is restored
+	in.handleBookmarksSelect("", rofiapi.StateCustomKeybinding15)
+	in.showNotices()
+	checkMessageContains(t, in, "restored #0003")
+	if b, _ := in.db.Get(3); b.URL != "https://www.b.com" {
+		t.Errorf("expected b to be restored as #3, got %+v", b)
+	}
+	if in.api.Options[rofiapi.OptionNewSelection] != "2" {
+		t.Errorf("expected the restored bookmark to be selected, got '%s'", in.api.Options[rofiapi.OptionNewSelection])
+	}
+	if in.api.Data.Deleted != 0 {
+		t.Errorf("expected nothing left to undo, got %d", in.api.Data.Deleted)
+	}
+
+	// undo only goes back once
+	in.handleBookmarksSelect("", rofiapi.StateCustomKeybinding15)
+	in.showNotices()
+	checkMessageContains(t, in, "nothing to undo")
+	if in.db.Len() != 3 {
+		t.Errorf("expected 3 bookmarks, got %d", in.db.Len())
+	}
+}
+
+func Test_fastDelete_disabled(t *testing.T) {
+	in := initInputHandler(t)
+
+	in.handleBookmarksSelect("2. metadata (title) b", rofiapi.StateCustomKeybinding3)
+	checkState(t, StateDeleteConfirmSelect, in.api.Data.State)
+	if in.db.Len() != 4 {
+		t.Errorf("expected no bookmark deleted, got %d", in.db.Len())
+	}
+
+	// the undo hotkey only shows the bookmarks
+	in.api.Data.Deleted = 1
+	in.handleBookmarksSelect("", rofiapi.StateCustomKeybinding15)
+	checkState(t, StateBookmarksSelect, in.api.Data.State)
+	if in.db.Len() != 4 {
+		t.Errorf("expected no bookmark restored, got %d", in.db.Len())
+	}
+	if slices.Contains(in.hotkeyActions(), undoDeleteAction) {
+		t.Errorf("expected no undo hotkey")
+	}
+
+	in.cfg.FastDelete = true
+	if !slices.Contains(in.hotkeyActions(), undoDeleteAction) {
+		t.Errorf("expected the undo hotkey")
+	}
+}
+
+func Test_fastDelete_errorStyle(t *testing.T) {
+	t.Setenv(config.PlainEnvVar, "1")
+	in := initInputHandler(t)
+	in.cfg.FastDelete = true
+
+	in.api.Data.Bookmark = bukudb.Bookmark{ID: 9}
+	in.fastDelete()
+	checkState(t, StateErrorShow, in.api.Data.State)
+	if msg := in.api.Options[rofiapi.OptionMessage]; !strings.HasPrefix(msg, "error: error deleting bookmark") {
+		t.Errorf("expected a plain error message, got %q", msg)
+	}
+}
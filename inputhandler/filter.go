@@ -0,0 +1,365 @@
+package inputhandler
+
+import (
+	"cmp"
+	"context"
+	"errors"
+	"fmt"
+	"slices"
+	"strings"
+
+	"github.com/VannRR/robuku/bukudb"
+	"github.com/VannRR/robuku/config"
+	rofiapi "github.com/VannRR/rofi-api"
+)
+
+const (
+	filterTagPrefix     = "tag:"
+	filterExcludePrefix = "-tag:"
+	filterDomainPrefix  = "domain:"
+	filterCommentPrefix = "comment:"
+)
+
+// tagAllSeparator separates the tags of "tag:a,b", matching bookmarks with
+// all of them like buku's --stag "a, b", tagAnySeparator those of "tag:a|b",
+// matching bookmarks with any of them.
+const (
+	tagAllSeparator = ","
+	tagAnySeparator = "|"
+)
+
+// errEmptyFilter is shown when the filter input is empty.
+var errEmptyFilter = errors.New("no filter entered")
+
+// errMixedTagSeparators is shown when a tag filter has both separators.
+var errMixedTagSeparators = errors.New("use either ',' for all tags or '|' for any tag, not both")
+
+// Filter narrows the bookmark list, empty fields match every bookmark.
+type Filter struct {
+	// Tag the bookmarks must carry, compared case-insensitively.
+	Tag string
+
+	// Exclude are the comma separated tags the bookmarks must not carry,
+	// compared case-insensitively.
+	Exclude string
+
+	// Domain the bookmarks must point to, without "www.".
+	Domain string
+
+	// Query is searched for with the ranked search.
+	Query string
+
+	// Comment is searched for in the comments only, it replaces Query.
+	Comment string
+}
+
+// isEmpty reports whether f matches every bookmark.
+func (f Filter) isEmpty() bool {
+	return f == Filter{}
+}
+
+// excluded returns the tags of f.Exclude.
+func (f Filter) excluded() []string {
+	return getTagsFromInput(f.Exclude, config.TagSplitComma)
+}
+
+// toggleExclude returns f with tag added to its excluded tags, or removed if
+// it is already excluded.
+func (f Filter) toggleExclude(tag string) Filter {
+	tags := f.excluded()
+	if i := slices.IndexFunc(tags, func(t string) bool { return strings.EqualFold(t, tag) }); i >= 0 {
+		tags = slices.Delete(tags, i, i+1)
+	} else {
+		tags = append(tags, tag)
+	}
+	f.Exclude = strings.Join(tags, ",")
+	return f
+}
+
+// String describes f for the message box, like
+// `tag:golang · -tag:video · "generics"`.
+func (f Filter) String() string {
+	parts := make([]string, 0, 3)
+	if f.Tag != "" {
+		parts = append(parts, filterTagPrefix+f.Tag)
+	}
+	for _, tag := range f.excluded() {
+		parts = append(parts, filterExcludePrefix+tag)
+	}
+	if f.Domain != "" {
+		parts = append(parts, filterDomainPrefix+f.Domain)
+	}
+	if f.Query != "" {
+		parts = append(parts, fmt.Sprintf("%q", f.Query))
+	}
+	if f.Comment != "" {
+		parts = append(parts, fmt.Sprintf("%s%q", filterCommentPrefix, f.Comment))
+	}
+	return strings.Join(parts, " · ")
+}
+
+// activeFilter returns the innermost filter layer of Data.Filters.
+func (in *InputHandler) activeFilter() Filter {
+	if n := len(in.api.Data.Filters); n > 0 {
+		return in.api.Data.Filters[n-1]
+	}
+	return Filter{}
+}
+
+// pushFilter adds a filter layer narrowing the active filter by input,
+// which is "tag:<tags>", "-tag:<tag>", "domain:<domain>", "comment:<words>",
+// or a search query. A search keeps the tags of the active filter, so both
+// must match, and replaces the previous search.
+func (in *InputHandler) pushFilter(input string) error {
+	f := in.activeFilter()
+	if tag, ok := cutPrefixFold(input, filterTagPrefix); ok {
+		if tag != "" {
+			tags, matchAll, err := parseTags(tag)
+			if err != nil {
+				return err
+			}
+			tag = joinTags(tags, matchAll)
+		}
+		f.Tag = tag
+		input = tag
+	} else if tag, ok := cutPrefixFold(input, filterExcludePrefix); ok {
+		if tag != "" && !containsTag(f.excluded(), tag) {
+			f = f.toggleExclude(tag)
+		}
+		input = tag
+	} else if domain, ok := cutPrefixFold(input, filterDomainPrefix); ok {
+		f.Domain = bukudb.URLHost(domain)
+		input = f.Domain
+	} else if comment, ok := cutPrefixFold(input, filterCommentPrefix); ok {
+		f.Comment, f.Query = comment, ""
+		input = comment
+	} else {
+		f.Query, f.Comment = input, ""
+	}
+	if input == "" {
+		return errEmptyFilter
+	}
+
+	// filtering leaves the recently added view
+	in.api.Data.Recent = false
+	if f != in.activeFilter() {
+		in.api.Data.Filters = append(in.api.Data.Filters, f)
+	}
+	return nil
+}
+
+// parseTags returns the tags of the tag filter s, "a,b" matching bookmarks
+// with all of them and "a|b" bookmarks with any of them.
+func parseTags(s string) (tags []string, matchAll bool, err error) {
+	sep, matchAll := tagAllSeparator, true
+	if strings.Contains(s, tagAnySeparator) {
+		if strings.Contains(s, tagAllSeparator) {
+			return nil, false, errMixedTagSeparators
+		}
+		sep, matchAll = tagAnySeparator, false
+	}
+
+	for _, t := range strings.Split(s, sep) {
+		t = strings.TrimSpace(t)
+		if t == "" {
+			return nil, false, fmt.Errorf("empty tag in '%s'", s)
+		}
+		if !containsTag(tags, t) {
+			tags = append(tags, t)
+		}
+	}
+	return tags, matchAll, nil
+}
+
+// joinTags returns the tag filter of tags, the reverse of parseTags.
+func joinTags(tags []string, matchAll bool) string {
+	if matchAll {
+		return strings.Join(tags, tagAllSeparator)
+	}
+	return strings.Join(tags, tagAnySeparator)
+}
+
+// tags returns the tags of f.Tag and whether bookmarks need all of them.
+func (f Filter) tags() ([]string, bool) {
+	if f.Tag == "" {
+		return nil, true
+	}
+	tags, matchAll, err := parseTags(f.Tag)
+	if err != nil {
+		return []string{f.Tag}, true
+	}
+	return tags, matchAll
+}
+
+// matchesTags reports whether a bookmark with tags passes the tag filter of
+// f, ignoring its excluded tags.
+func (f Filter) matchesTags(tags []string) bool {
+	include, matchAll := f.tags()
+	has := func(t string) bool { return matchesTag(tags, t) }
+	if matchAll {
+		return !slices.ContainsFunc(include, func(t string) bool { return !has(t) })
+	}
+	return slices.ContainsFunc(include, has)
+}
+
+// matchesTag reports whether tags contains tag, ignoring case, or a child of
+// tag if it is a parent tag, like buildTagQuery matches them.
+func matchesTag(tags []string, tag string) bool {
+	if containsTag(tags, tag) {
+		return true
+	}
+	if strings.Contains(tag, bukudb.TagSeparator) {
+		return false
+	}
+	prefix := strings.ToLower(tag + bukudb.TagSeparator)
+	return slices.ContainsFunc(tags, func(t string) bool {
+		return strings.HasPrefix(strings.ToLower(t), prefix)
+	})
+}
+
+// cutPrefixFold returns s without prefix, matched case-insensitively, and
+// trimmed of whitespace.
+func cutPrefixFold(s, prefix string) (string, bool) {
+	if len(s) < len(prefix) || !strings.EqualFold(s[:len(prefix)], prefix) {
+		return "", false
+	}
+	return strings.TrimSpace(s[len(prefix):]), true
+}
+
+// popFilter removes the innermost filter layer.
+func (in *InputHandler) popFilter() {
+	if n := len(in.api.Data.Filters); n > 0 {
+		in.api.Data.Filters = in.api.Data.Filters[:n-1]
+	}
+}
+
+// applyFilters returns the bookmarks of db matching f, ranked by the query
+// if it has one.
+func applyFilters(ctx context.Context, db bukudb.DBInterface, f Filter) ([]bukudb.Bookmark, error) {
+	include, matchAll := f.tags()
+	exclude := f.excluded()
+
+	var bookmarks []bukudb.Bookmark
+	var err error
+	switch {
+	case f.Comment != "":
+		bookmarks, err = db.SearchComments(f.Comment)
+	case f.Query != "":
+		bookmarks, err = db.SearchRankedContext(ctx, f.Query)
+	case len(include) > 0 || len(exclude) > 0:
+		bookmarks, err = db.SearchByTags(include, exclude, matchAll)
+	default:
+		bookmarks, err = db.GetAllContext(ctx)
+	}
+	if err != nil || f.Query == "" && f.Comment == "" && f.Domain == "" {
+		return bookmarks, err
+	}
+
+	matching := make([]bukudb.Bookmark, 0, len(bookmarks))
+	for _, b := range bookmarks {
+		if !f.matchesTags(b.Tags) {
+			continue
+		}
+		if slices.ContainsFunc(exclude, func(t string) bool { return matchesTag(b.Tags, t) }) {
+			continue
+		}
+		if f.Domain != "" && bukudb.URLHost(b.URL) != f.Domain {
+			continue
+		}
+		matching = append(matching, b)
+	}
+	return matching, nil
+}
+
+// filterTags returns the tags of the bookmarks matching the active filter,
+// most used first, with the excluded tags in front.
+func (in *InputHandler) filterTags() ([]string, error) {
+	f := in.activeFilter()
+	bookmarks, err := applyFilters(context.Background(), in.db, f)
+	if err != nil {
+		return nil, err
+	}
+
+	included, _ := f.tags()
+	counts := make(map[string]int)
+	for _, b := range bookmarks {
+		for _, t := range b.Tags {
+			if !containsTag(included, t) {
+				counts[strings.ToLower(t)]++
+			}
+		}
+	}
+	tags := make([]string, 0, len(counts))
+	for t := range counts {
+		tags = append(tags, t)
+	}
+	slices.SortFunc(tags, func(a, b string) int {
+		return cmp.Or(counts[b]-counts[a], strings.Compare(a, b))
+	})
+	return append(f.excluded(), tags...), nil
+}
+
+func (in *InputHandler) handleFilterShow() {
+	f := in.activeFilter()
+	in.api.Options[rofiapi.OptionMessage] = in.style.generatePangoMarkup(
+		"filter the bookmarks | exclude tag: Alt+1",
+		"'tag:go,rust' (all), 'tag:go|rust' (any), '-tag:video', 'domain:github.com', 'comment:words', or search words",
+		f.String())
+	in.api.Options[rofiapi.OptionNoCustom] = "false"
+	in.api.Options[rofiapi.OptionUseHotKeys] = "true"
+
+	in.api.Entries = []rofiapi.Entry{
+		{Text: opBack},
+	}
+
+	tags, err := in.filterTags()
+	if err != nil {
+		in.setMessageToError(fmt.Errorf("error getting tags: %w", err))
+	}
+	excluded := f.excluded()
+	for _, t := range tags {
+		if containsTag(excluded, t) {
+			in.api.Entries = append(in.api.Entries, rofiapi.Entry{Text: filterExcludePrefix + t, Active: true})
+		} else {
+			in.api.Entries = append(in.api.Entries, rofiapi.Entry{Text: filterTagPrefix + t})
+		}
+	}
+
+	in.api.Data.State = StateFilterSelect
+}
+
+func (in *InputHandler) handleFilterSelect(input string, rofiState rofiapi.State) {
+	if input == opBack {
+		in.HandleBookmarksShow()
+		return
+	}
+	if rofiState == rofiapi.StateCustomKeybinding1 {
+		in.toggleExcludedTag(input)
+		in.handleFilterShow()
+		return
+	}
+	if err := in.pushFilter(input); err != nil {
+		in.showWithError(in.handleFilterShow, err)
+		return
+	}
+	in.HandleBookmarksShow()
+}
+
+// toggleExcludedTag moves the tag of the highlighted "tag:" or "-tag:" entry
+// into or out of the excluded tags of the active filter.
+func (in *InputHandler) toggleExcludedTag(input string) {
+	tag, ok := cutPrefixFold(input, filterExcludePrefix)
+	if !ok {
+		tag, ok = cutPrefixFold(input, filterTagPrefix)
+	}
+	if !ok || tag == "" {
+		return
+	}
+
+	f := in.activeFilter().toggleExclude(tag)
+	if n := len(in.api.Data.Filters); n > 0 {
+		in.api.Data.Filters[n-1] = f
+	} else {
+		in.api.Data.Filters = []Filter{f}
+	}
+}
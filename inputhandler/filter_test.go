@@ -0,0 +1,325 @@
+package inputhandler
+
+import (
+	"context"
+	"slices"
+	"strings"
+	"testing"
+
+	"github.com/VannRR/robuku/bukudb"
+	rofiapi "github.com/VannRR/rofi-api"
+)
+
+func Test_Filter_String(t *testing.T) {
+	tests := []struct {
+		filter   Filter
+		expected string
+	}{
+		{Filter{}, ""},
+		{Filter{Tag: "golang"}, "tag:golang"},
+		{Filter{Tag: "golang", Query: "generics"}, `tag:golang · "generics"`},
+		{Filter{Domain: "go.dev", Query: "a b"}, `domain:go.dev · "a b"`},
+		{Filter{Tag: "go", Exclude: "video,blog", Query: "x"}, `tag:go · -tag:video · -tag:blog · "x"`},
+	}
+	for _, tt := range tests {
+		if got := tt.filter.String(); got != tt.expected {
+			t.Errorf("expected '%s', got '%s'", tt.expected, got)
+		}
+	}
+}
+
+func Test_pushFilter(t *testing.T) {
+	in := initInputHandler(t)
+
+	for _, input := range []string{"TAG: golang", "domain:https://www.Go.dev/doc", "generics"} {
+		if err := in.pushFilter(input); err != nil {
+			t.Fatalf("expected no error for '%s', got %v", input, err)
+		}
+	}
+	expected := Filter{Tag: "golang", Domain: "go.dev", Query: "generics"}
+	if len(in.api.Data.Filters) != 3 || in.activeFilter() != expected {
+		t.Errorf("expected 3 layers ending in %+v, got %+v", expected, in.api.Data.Filters)
+	}
+	if in.api.Data.Filters[0] != (Filter{Tag: "golang"}) {
+		t.Errorf("expected first layer to only filter by tag, got %+v", in.api.Data.Filters[0])
+	}
+
+	// empty values and unchanged filters add no layer
+	for _, input := range []string{"", "tag:", "domain: ", "generics"} {
+		in.pushFilter(input)
+	}
+	if len(in.api.Data.Filters) != 3 {
+		t.Errorf("expected 3 layers, got %d", len(in.api.Data.Filters))
+	}
+	if err := in.pushFilter("tag: "); err != errEmptyFilter {
+		t.Errorf("expected error '%v', got %v", errEmptyFilter, err)
+	}
+
+	// excluded tags add up, excluding one twice adds no layer
+	in.pushFilter("-tag:video")
+	in.pushFilter("-TAG: blog")
+	in.pushFilter("-tag:Video")
+	expected.Exclude = "video,blog"
+	if len(in.api.Data.Filters) != 5 || in.activeFilter() != expected {
+		t.Errorf("expected 5 layers ending in %+v, got %+v", expected, in.api.Data.Filters)
+	}
+	if err := in.pushFilter("-tag:"); err != errEmptyFilter {
+		t.Errorf("expected error '%v', got %v", errEmptyFilter, err)
+	}
+	in.popFilter()
+	in.popFilter()
+
+	in.popFilter()
+	in.popFilter()
+	if in.activeFilter() != (Filter{Tag: "golang"}) {
+		t.Errorf("expected tag filter after popping two layers, got %+v", in.activeFilter())
+	}
+	in.popFilter()
+	in.popFilter()
+	if !in.activeFilter().isEmpty() {
+		t.Errorf("expected no filter, got %+v", in.activeFilter())
+	}
+}
+
+func Test_parseTags(t *testing.T) {
+	tests := []struct {
+		input    string
+		tags     []string
+		matchAll bool
+		err      bool
+	}{
+		{"golang", []string{"golang"}, true, false},
+		{"go,rust", []string{"go", "rust"}, true, false},
+		{"go, rust ,Go", []string{"go", "rust"}, true, false},
+		{"go|rust", []string{"go", "rust"}, false, false},
+		{" go | rust |GO", []string{"go", "rust"}, false, false},
+		{"my tag|other", []string{"my tag", "other"}, false, false},
+		{"go,rust|c", nil, false, true},
+		{"go|rust,c", nil, false, true},
+		{"go,,rust", nil, false, true},
+		{"go|", nil, false, true},
+		{",", nil, false, true},
+		{"|", nil, false, true},
+		{" ", nil, false, true},
+	}
+	for _, tt := range tests {
+		tags, matchAll, err := parseTags(tt.input)
+		if (err != nil) != tt.err {
+			t.Errorf("'%s': expected error %v, got '%v'", tt.input, tt.err, err)
+			continue
+		}
+		if !slices.Equal(tags, tt.tags) || matchAll != tt.matchAll {
+			t.Errorf("'%s': expected %v all %v, got %v all %v", tt.input, tt.tags, tt.matchAll, tags, matchAll)
+		}
+	}
+
+	if _, _, err := parseTags("a,b|c"); err != errMixedTagSeparators {
+		t.Errorf("expected error '%v', got '%v'", errMixedTagSeparators, err)
+	}
+}
+
+func Test_pushFilter_Tags(t *testing.T) {
+	in := initInputHandler(t)
+
+	// tags are stored trimmed and without duplicates
+	if err := in.pushFilter("tag: go | rust |Go"); err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+	if in.activeFilter().Tag != "go|rust" {
+		t.Errorf("expected tag filter 'go|rust', got '%s'", in.activeFilter().Tag)
+	}
+
+	// search words narrow the tags, both must match
+	in.pushFilter("generics")
+	expected := Filter{Tag: "go|rust", Query: "generics"}
+	if in.activeFilter() != expected {
+		t.Errorf("expected %+v, got %+v", expected, in.activeFilter())
+	}
+
+	// "-tag:" is not read as "tag:", and malformed tags add no layer
+	in.pushFilter("-tag:video")
+	if in.activeFilter().Tag != "go|rust" || in.activeFilter().Exclude != "video" {
+		t.Errorf("expected excluded video besides the tags, got %+v", in.activeFilter())
+	}
+	for _, input := range []string{"tag:a,b|c", "tag:a,,b"} {
+		if err := in.pushFilter(input); err == nil {
+			t.Errorf("expected an error for '%s'", input)
+		}
+	}
+	if len(in.api.Data.Filters) != 3 {
+		t.Errorf("expected 3 layers, got %d", len(in.api.Data.Filters))
+	}
+
+	in.handleFilterSelect("tag:a|b,c", rofiapi.StateSelected)
+	checkState(t, StateFilterSelect, in.api.Data.State)
+	if !strings.Contains(in.api.Options[rofiapi.OptionMessage], "error:") {
+		t.Errorf("expected an error message, got '%s'", in.api.Options[rofiapi.OptionMessage])
+	}
+}
+
+func Test_applyFilters(t *testing.T) {
+	db := newMockDB()
+	tests := []struct {
+		filter   Filter
+		expected []uint16
+	}{
+		{Filter{}, []uint16{1, 2, 3, 4}},
+		{Filter{Tag: "TAG2"}, []uint16{1, 2}},
+		{Filter{Query: "metadata"}, []uint16{1, 2, 3}},
+		{Filter{Tag: "tag2", Query: "google"}, []uint16{1}},
+		{Filter{Tag: "tag2", Query: "www.c.com"}, nil},
+		{Filter{Domain: "b.com"}, []uint16{2}},
+		{Filter{Domain: "b.com", Tag: "google"}, nil},
+		{Filter{Exclude: "google"}, []uint16{2, 3, 4}},
+		{Filter{Tag: "tag2", Exclude: "B,google"}, nil},
+		{Filter{Tag: "tag2", Exclude: "b"}, []uint16{1}},
+		{Filter{Query: "metadata", Exclude: "tag3"}, []uint16{3}},
+		{Filter{Domain: "b.com", Exclude: "tag3"}, nil},
+		{Filter{Tag: "google|b"}, []uint16{1, 2}},
+		{Filter{Tag: "google,b"}, nil},
+		{Filter{Tag: "tag2,TAG3"}, []uint16{1, 2}},
+		{Filter{Tag: "google|b", Query: "metadata"}, []uint16{1, 2}},
+		{Filter{Tag: "google|b", Exclude: "google"}, []uint16{2}},
+		{Filter{Tag: "google|b", Domain: "b.com"}, []uint16{2}},
+	}
+
+	for _, tt := range tests {
+		bookmarks, err := applyFilters(context.Background(), db, tt.filter)
+		if err != nil {
+			t.Fatalf("expected no error, got %v", err)
+		}
+		var ids []uint16
+		for _, b := range bookmarks {
+			ids = append(ids, b.ID)
+		}
+		if len(ids) != len(tt.expected) {
+			t.Errorf("filter %+v: expected ids %v, got %v", tt.filter, tt.expected, ids)
+			continue
+		}
+		for i := range ids {
+			if ids[i] != tt.expected[i] {
+				t.Errorf("filter %+v: expected ids %v, got %v", tt.filter, tt.expected, ids)
+				break
+			}
+		}
+	}
+}
+
+func Test_HandleBookmarksShow_Filters(t *testing.T) {
+	in := initInputHandler(t)
+
+	in.handleBookmarksSelect("", rofiapi.StateCustomKeybinding9)
+	checkState(t, StateFilterSelect, in.api.Data.State)
+
+	// empty input asks again
+	in.handleFilterSelect("", rofiapi.StateSelected)
+	checkState(t, StateFilterSelect, in.api.Data.State)
+
+	// tag filter
+	in.handleFilterSelect("tag:tag2", rofiapi.StateSelected)
+	checkState(t, StateBookmarksSelect, in.api.Data.State)
+	checkEntryTexts(t, []string{opBack, opExportList, "1. metadata (title) google", "2. metadata (title) b"}, in.api.Entries)
+
+	// search within the tag filter
+	in.handleBookmarksSelect("", rofiapi.StateCustomKeybinding9)
+	in.handleFilterSelect("google", rofiapi.StateSelected)
+	checkEntryTexts(t, []string{opBack, opExportList, "1. metadata (title) google"}, in.api.Entries)
+	message := in.api.Options[rofiapi.OptionMessage]
+	if !strings.Contains(message, "tag:tag2 · ") || !strings.Contains(message, "google") {
+		t.Errorf("expected message to describe both filters, got '%s'", message)
+	}
+
+	// filters stay while moving between screens
+	in.handleBookmarksSelect("1. metadata (title) google", rofiapi.StateCustomKeybinding2)
+	in.handleModifySelect(opBack)
+	checkEntryTexts(t, []string{opBack, opExportList, "1. metadata (title) google"}, in.api.Entries)
+
+	// back removes one layer at a time
+	in.handleBookmarksSelect(opBack, rofiapi.StateSelected)
+	checkEntryTexts(t, []string{opBack, opExportList, "1. metadata (title) google", "2. metadata (title) b"}, in.api.Entries)
+	in.handleBookmarksSelect(opBack, rofiapi.StateSelected)
+	if len(in.api.Entries) != 4 || in.api.Entries[0].Text == opBack {
+		t.Errorf("expected all 4 bookmarks without back entry, got %v", in.api.Entries)
+	}
+	if strings.Contains(in.api.Options[rofiapi.OptionMessage], "tag:") {
+		t.Errorf("expected no filter in message, got '%s'", in.api.Options[rofiapi.OptionMessage])
+	}
+}
+
+func Test_handleFilterSelect_ExcludeTag(t *testing.T) {
+	in := initInputHandler(t)
+
+	in.handleFilterShow()
+	checkEntryTexts(t, []string{opBack, "tag:tag2", "tag:tag3", "tag:b", "tag:google"}, in.api.Entries)
+
+	in.handleFilterSelect("tag:tag2", rofiapi.StateCustomKeybinding1)
+	checkState(t, StateFilterSelect, in.api.Data.State)
+	checkEntryTexts(t, []string{opBack, "-tag:tag2"}, in.api.Entries)
+	if !in.api.Entries[1].Active {
+		t.Errorf("expected excluded tag to be highlighted")
+	}
+	if message := in.api.Options[rofiapi.OptionMessage]; !strings.Contains(message, "-tag:tag2") {
+		t.Errorf("expected excluded tag in message, got '%s'", message)
+	}
+
+	// selecting an entry applies the filter
+	in.handleFilterSelect("-tag:tag2", rofiapi.StateSelected)
+	checkState(t, StateBookmarksSelect, in.api.Data.State)
+	checkEntryTexts(t, []string{opBack, opExportList, "3. metadata (title) c", "4. https://www.d.com"}, in.api.Entries)
+
+	// toggling again includes the tag
+	in.handleBookmarksSelect("", rofiapi.StateCustomKeybinding9)
+	in.handleFilterSelect("-tag:tag2", rofiapi.StateCustomKeybinding1)
+	if !in.activeFilter().isEmpty() {
+		t.Errorf("expected empty filter, got %+v", in.activeFilter())
+	}
+}
+
+func Test_handleModifySelect_MoreFromSite(t *testing.T) {
+	in := initInputHandler(t)
+	if err := in.db.Add(bukudb.Bookmark{URL: "https://b.com/other", Title: "other b"}); err != nil {
+		t.Fatal(err)
+	}
+
+	in.handleBookmarksSelect("2. metadata (title) b", rofiapi.StateCustomKeybinding2)
+	checkState(t, StateModifySelect, in.api.Data.State)
+	last := in.api.Entries[len(in.api.Entries)-1]
+	if last.Text != opMoreFrom+"b.com" {
+		t.Fatalf("expected entry '%s', got '%s'", opMoreFrom+"b.com", last.Text)
+	}
+
+	in.handleModifySelect(last.Text)
+	checkState(t, StateBookmarksSelect, in.api.Data.State)
+	checkEntryTexts(t, []string{opBack, opExportList, "2. metadata (title) b", "5. other b"}, in.api.Entries)
+	if message := in.api.Options[rofiapi.OptionMessage]; !strings.Contains(message, "domain:b.com") {
+		t.Errorf("expected domain in message, got '%s'", message)
+	}
+
+	// back clears the domain filter
+	in.handleBookmarksSelect(opBack, rofiapi.StateSelected)
+	if len(in.api.Entries) != 5 || !in.activeFilter().isEmpty() {
+		t.Errorf("expected all 5 bookmarks and no filter, got %v and %+v", in.api.Entries, in.activeFilter())
+	}
+
+	// bookmarks without a host have no entry
+	in.api.Data.Bookmark = bukudb.Bookmark{ID: 1, URL: "javascript:alert(1)"}
+	in.handleModifyShow()
+	for _, e := range in.api.Entries {
+		if strings.HasPrefix(e.Text, opMoreFrom) {
+			t.Errorf("expected no '%s' entry, got '%s'", opMoreFrom, e.Text)
+		}
+	}
+}
+
+func checkEntryTexts(t *testing.T, expected []string, entries []rofiapi.Entry) {
+	t.Helper()
+	if len(entries) != len(expected) {
+		t.Errorf("expected entries %q, got %v", expected, entries)
+		return
+	}
+	for i, e := range entries {
+		if e.Text != expected[i] {
+			t.Errorf("expected entry '%s' at %d, got '%s'", expected[i], i, e.Text)
+		}
+	}
+}
@@ -0,0 +1,143 @@
+package inputhandler
+
+import (
+	"testing"
+	"time"
+
+	"github.com/VannRR/robuku/bukudb"
+	"github.com/VannRR/robuku/config"
+	"github.com/VannRR/robuku/inputhandler/testutil"
+	"github.com/VannRR/robuku/internal/golden"
+)
+
+// initGoldenInputHandler returns an InputHandler on the standard fixture,
+// the mock database with one bookmark in the trash, and the default
+// settings regardless of the environment.
+func initGoldenInputHandler(t *testing.T) *InputHandler {
+	t.Helper()
+
+	in := initInputHandler(t)
+	in.cfg = config.Default()
+	in.cfg.ExportDir = "/exports"
+	in.style = newMarkupStyle(in.cfg)
+
+	db := newMockDB()
+	db.trash = []bukudb.TrashedBookmark{{
+		Bookmark: bukudb.Bookmark{URL: "https://www.e.com", Title: "metadata (title) e"},
+		TrashID:  1,
+		Deleted:  time.Date(2024, 5, 1, 12, 30, 0, 0, time.UTC),
+	}}
+	in.db = db
+	return in
+}
+
+// checkGoldenShow runs show on the standard fixture after setup and
+// compares the rendered screen with the golden file called name.
+func checkGoldenShow(t *testing.T, name string, setup func(in *InputHandler), show func(in *InputHandler)) {
+	t.Helper()
+
+	in := initGoldenInputHandler(t)
+	if setup != nil {
+		setup(in)
+	}
+	show(in)
+	golden.Check(t, name, testutil.RenderSnapshot(in.api, in.api.Data.State))
+}
+
+func Test_Golden(t *testing.T) {
+	selectFirst := func(in *InputHandler) { in.api.Data.Bookmark, _ = in.db.Get(1) }
+
+	tests := []struct {
+		name  string
+		setup func(in *InputHandler)
+		show  func(in *InputHandler)
+	}{
+		{"bookmarks", nil, (*InputHandler).HandleBookmarksShow},
+		{"add", nil, (*InputHandler).handleAddShow},
+		{"add_title", nil, (*InputHandler).handleAddTitleShow},
+		{"add_url", nil, (*InputHandler).handleAddUrlShow},
+		{"add_comment", nil, (*InputHandler).handleAddCommentShow},
+		{"add_tags", nil, (*InputHandler).handleAddTagsShow},
+		{"modify", selectFirst, (*InputHandler).handleModifyShow},
+		{"modify_plain", func(in *InputHandler) {
+			selectFirst(in)
+			in.UsePlainMessages()
+		}, (*InputHandler).handleModifyShow},
+		{"modify_title", selectFirst, (*InputHandler).handleModifyTitleShow},
+		{"modify_url", selectFirst, (*InputHandler).handleModifyUrlShow},
+		{"modify_comment", selectFirst, (*InputHandler).handleModifyCommentShow},
+		{"modify_tags", selectFirst, (*InputHandler).handleModifyTagsShow},
+		{"append_note", selectFirst, (*InputHandler).handleAppendNoteShow},
+		{"modify_pending", func(in *InputHandler) {
+			selectFirst(in)
+			in.api.Data.Bookmark.Title = "new title"
+			in.api.Data.Pending = bukudb.MaskTitle
+		}, (*InputHandler).handleModifyShow},
+		{"modify_visits", func(in *InputHandler) {
+			selectFirst(in)
+			in.db.RecordVisit(1, time.Date(2024, 5, 1, 12, 0, 0, 0, time.UTC))
+			in.db.RecordVisit(1, time.Date(2024, 6, 2, 12, 0, 0, 0, time.UTC))
+		}, (*InputHandler).handleModifyShow},
+		{"reset_visits", nil, (*InputHandler).handleResetVisitsShow},
+		{"discard", func(in *InputHandler) {
+			selectFirst(in)
+			in.api.Data.Pending = bukudb.MaskTitle | bukudb.MaskTags
+		}, (*InputHandler).handleDiscardShow},
+		{"duplicate_url", func(in *InputHandler) {
+			selectFirst(in)
+			in.api.Data.Duplicate = 17
+		}, (*InputHandler).handleDuplicateURLShow},
+		{"delete_confirm", selectFirst, (*InputHandler).handleDeleteConfirmShow},
+		{"stats", nil, (*InputHandler).handleStatsShow},
+		{"goto_confirm", func(in *InputHandler) {
+			in.api.Data.Bookmark = bukudb.Bookmark{ID: 1, URL: "javascript:alert(1)"}
+		}, (*InputHandler).handleGotoConfirmShow},
+		{"export", func(in *InputHandler) {
+			in.api.Data.ResultIDs = []uint16{1, 2}
+		}, (*InputHandler).handleExportShow},
+		{"import_preview", func(in *InputHandler) {
+			in.api.Data.Import = PendingImport{Source: "testdata/import.json"}
+		}, (*InputHandler).handleImportPreviewShow},
+		{"export_path", func(in *InputHandler) {
+			in.api.Data.ResultIDs = []uint16{1, 2}
+			in.api.Data.Export = ExportTarget{Format: "md", Path: "/exports/bookmarks.md"}
+		}, (*InputHandler).handleExportPathShow},
+		{"export_overwrite", func(in *InputHandler) {
+			in.api.Data.ResultIDs = []uint16{1, 2}
+			in.api.Data.Export = ExportTarget{Format: "md", Path: "/exports/bookmarks.md"}
+		}, (*InputHandler).handleOverwriteShow},
+		{"tags", setTagTree, (*InputHandler).handleTagsShow},
+		{"tags_expanded", func(in *InputHandler) {
+			setTagTree(in)
+			in.api.Data.TagParent = "lang"
+		}, (*InputHandler).handleTagsShow},
+		{"trash", nil, (*InputHandler).handleTrashShow},
+		{"trash_empty", nil, (*InputHandler).handleTrashEmptyShow},
+		{"bookmarks_dead_links", func(in *InputHandler) {
+			in.db.(*mockDB).statuses = map[uint16]bukudb.LinkStatus{2: {Dead: true, StatusCode: 404}}
+		}, (*InputHandler).HandleBookmarksShow},
+		{"bookmarks_near_limit", func(in *InputHandler) {
+			in.cfg.MaxBookmarks = 4
+		}, (*InputHandler).HandleBookmarksShow},
+		{"filter", func(in *InputHandler) {
+			in.api.Data.Filters = []Filter{{Tag: "tag2"}}
+		}, (*InputHandler).handleFilterShow},
+		{"filtered_bookmarks", func(in *InputHandler) {
+			in.api.Data.Filters = []Filter{{Tag: "tag2"}}
+		}, (*InputHandler).HandleBookmarksShow},
+		{"titles", func(in *InputHandler) {
+			in.api.Data.TitleCleanup = TitleCleanup{IDs: []uint16{4}}
+		}, (*InputHandler).handleTitlesShow},
+		{"title_enter", func(in *InputHandler) {
+			in.api.Data.Bookmark, _ = in.db.Get(4)
+		}, (*InputHandler).handleTitleEnterShow},
+		{"clear_tags", selectFirst, (*InputHandler).handleClearTagsShow},
+		{"help", nil, (*InputHandler).handleHelpShow},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			checkGoldenShow(t, tt.name, tt.setup, tt.show)
+		})
+	}
+}
@@ -0,0 +1,71 @@
+package inputhandler
+
+import (
+	"fmt"
+	"log"
+	"slices"
+	"strings"
+
+	"github.com/VannRR/robuku/bukudb"
+)
+
+// revealAction shows or hides the bookmarks with hidden tags, it is only
+// offered when tags are hidden.
+var revealAction = hotkeyAction{key: 11, hint: "hidden", help: "show or hide the bookmarks with hidden tags"}
+
+// hiddenTags returns the hidden tags the bookmarks of the list filtered by f
+// must not carry. Tags f filters by, or their children, are shown, and
+// nothing is hidden once revealed.
+func (in *InputHandler) hiddenTags(f Filter) []string {
+	if in.api.Data.RevealHidden {
+		return nil
+	}
+	include, _ := f.tags()
+	var tags []string
+	for _, h := range in.cfg.HiddenTags {
+		if slices.ContainsFunc(include, func(t string) bool { return isTagOrChild(t, h) }) ||
+			containsTag(f.excluded(), h) {
+			continue
+		}
+		tags = append(tags, h)
+	}
+	return tags
+}
+
+// isTagOrChild reports whether tag is parent or one of its children,
+// ignoring case.
+func isTagOrChild(tag, parent string) bool {
+	return strings.EqualFold(tag, parent) ||
+		strings.HasPrefix(strings.ToLower(tag), strings.ToLower(parent+bukudb.TagSeparator))
+}
+
+// hideTags returns f also excluding tags, so the database leaves out the
+// bookmarks carrying them.
+func hideTags(f Filter, tags []string) Filter {
+	for _, t := range tags {
+		f = f.toggleExclude(t)
+	}
+	return f
+}
+
+// hiddenLine returns the line of the message box counting the bookmarks with
+// hidden tags, hidden being the tags left out of the list. It is empty if
+// no bookmark has a hidden tag.
+func (in *InputHandler) hiddenLine(hidden []string) string {
+	format := "%d hidden"
+	if in.api.Data.RevealHidden {
+		hidden, format = in.cfg.HiddenTags, "showing %d hidden"
+	}
+	if len(hidden) == 0 {
+		return ""
+	}
+	bookmarks, err := in.db.SearchByTags(hidden, nil, false)
+	if err != nil {
+		log.Println("ERROR", fmt.Errorf("failed to count hidden bookmarks: %w", err))
+		return ""
+	}
+	if len(bookmarks) == 0 {
+		return ""
+	}
+	return fmt.Sprintf(format, len(bookmarks))
+}
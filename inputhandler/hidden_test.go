@@ -0,0 +1,129 @@
+package inputhandler
+
+import (
+	"slices"
+	"strings"
+	"testing"
+
+	rofiapi "github.com/VannRR/rofi-api"
+)
+
+// listedIDs returns the IDs of the bookmarks listed by the entries.
+func listedIDs(entries []rofiapi.Entry) []uint16 {
+	var ids []uint16
+	for _, e := range entries {
+		if id, err := getIdFromBookmarkString(e.Text); err == nil {
+			ids = append(ids, id)
+		}
+	}
+	return ids
+}
+
+func Test_HandleBookmarksShow_hiddenTags(t *testing.T) {
+	in := initInputHandler(t)
+	in.cfg.HiddenTags = []string{"google", "B"}
+
+	in.HandleBookmarksShow()
+	if ids := listedIDs(in.api.Entries); !slices.Equal(ids, []uint16{3, 4}) {
+		t.Errorf("expected bookmarks 3 and 4, got %v", ids)
+	}
+	message := in.api.Options[rofiapi.OptionMessage]
+	if !strings.Contains(message, "2 hidden") {
+		t.Errorf("expected hidden count in message, got '%s'", message)
+	}
+	if !slices.Contains(in.hotkeyActions(), revealAction) {
+		t.Errorf("expected the reveal hotkey")
+	}
+
+	// revealed for the session
+	in.handleBookmarksSelect("", rofiapi.StateCustomKeybinding11)
+	checkState(t, StateBookmarksSelect, in.api.Data.State)
+	if ids := listedIDs(in.api.Entries); !slices.Equal(ids, []uint16{1, 2, 3, 4}) {
+		t.Errorf("expected all bookmarks when revealed, got %v", ids)
+	}
+	if message := in.api.Options[rofiapi.OptionMessage]; !strings.Contains(message, "showing 2 hidden") {
+		t.Errorf("expected revealed count in message, got '%s'", message)
+	}
+	in.HandleBookmarksShow()
+	if ids := listedIDs(in.api.Entries); len(ids) != 4 {
+		t.Errorf("expected bookmarks to stay revealed, got %v", ids)
+	}
+
+	// hidden again
+	in.handleBookmarksSelect("", rofiapi.StateCustomKeybinding11)
+	if ids := listedIDs(in.api.Entries); !slices.Equal(ids, []uint16{3, 4}) {
+		t.Errorf("expected bookmarks 3 and 4 when hidden again, got %v", ids)
+	}
+}
+
+func Test_HandleBookmarksShow_hiddenTagsFiltered(t *testing.T) {
+	in := initInputHandler(t)
+	in.cfg.HiddenTags = []string{"google", "b"}
+
+	// filtering by a hidden tag shows its bookmarks, the others stay hidden
+	in.api.Data.Filters = []Filter{{Tag: "tag2"}}
+	in.HandleBookmarksShow()
+	if ids := listedIDs(in.api.Entries); len(ids) != 0 {
+		t.Errorf("expected no bookmarks, got %v", ids)
+	}
+	in.api.Data.Filters = []Filter{{Tag: "google"}}
+	in.HandleBookmarksShow()
+	if ids := listedIDs(in.api.Entries); !slices.Equal(ids, []uint16{1}) {
+		t.Errorf("expected bookmark 1, got %v", ids)
+	}
+	in.api.Data.Filters = []Filter{{Tag: "google|b"}}
+	in.HandleBookmarksShow()
+	if ids := listedIDs(in.api.Entries); !slices.Equal(ids, []uint16{1, 2}) {
+		t.Errorf("expected bookmarks 1 and 2, got %v", ids)
+	}
+
+	// searches leave them out too
+	in.api.Data.Filters = []Filter{{Query: "metadata"}}
+	in.HandleBookmarksShow()
+	if ids := listedIDs(in.api.Entries); !slices.Equal(ids, []uint16{3}) {
+		t.Errorf("expected bookmark 3, got %v", ids)
+	}
+}
+
+func Test_hiddenTags(t *testing.T) {
+	in := initInputHandler(t)
+	in.cfg.HiddenTags = []string{"archive", "nsfw"}
+
+	tests := []struct {
+		filter   Filter
+		expected []string
+	}{
+		{Filter{}, []string{"archive", "nsfw"}},
+		{Filter{Tag: "Archive"}, []string{"nsfw"}},
+		{Filter{Tag: "archive/2023"}, []string{"nsfw"}},
+		{Filter{Tag: "archived"}, []string{"archive", "nsfw"}},
+		{Filter{Exclude: "nsfw"}, []string{"archive"}},
+	}
+	for _, tt := range tests {
+		if actual := in.hiddenTags(tt.filter); !slices.Equal(actual, tt.expected) {
+			t.Errorf("expected hidden tags %q for %v, got %q", tt.expected, tt.filter, actual)
+		}
+	}
+
+	in.api.Data.RevealHidden = true
+	if actual := in.hiddenTags(Filter{}); actual != nil {
+		t.Errorf("expected no hidden tags when revealed, got %q", actual)
+	}
+}
+
+func Test_hotkeyActions_hiddenTags(t *testing.T) {
+	in := initInputHandler(t)
+	if slices.Contains(in.hotkeyActions(), revealAction) {
+		t.Error("expected no reveal hotkey without hidden tags")
+	}
+	in.cfg.HiddenTags = []string{"nsfw"}
+	if !slices.Contains(in.hotkeyActions(), revealAction) {
+		t.Error("expected reveal hotkey with hidden tags")
+	}
+	if len(hotkeyActions) != 9 {
+		t.Errorf("expected hotkeyActions to be unchanged, got %d actions", len(hotkeyActions))
+	}
+	if !slices.Contains(helpLines(in.hotkeyActions()), "Alt+Shift+1: show or hide the bookmarks with hidden tags") {
+		t.Errorf("expected reveal hotkey on the help screen, got %q", helpLines(in.hotkeyActions()))
+	}
+}
@@ -0,0 +1,148 @@
+package inputhandler
+
+import (
+	"fmt"
+	"slices"
+	"strings"
+	"unicode/utf8"
+)
+
+// hotkeyAction is an action of the bookmark list bound to a custom
+// keybinding of rofi, Alt+1 for kb-custom-1 up to Alt+0 for kb-custom-10.
+type hotkeyAction struct {
+	key int
+
+	// hint labels the action in the message box, help describes it on the
+	// help screen.
+	hint string
+	help string
+
+	// compact actions are shown when $ROBUKU_COMPACT_HINTS=1, in the order
+	// of hotkeyActions.
+	compact bool
+
+	// needsBookmark actions are left out of the compact hints when no
+	// bookmark is listed.
+	needsBookmark bool
+}
+
+// hotkeyActions are the hotkeys of the bookmark list.
+var hotkeyActions = []hotkeyAction{
+	{key: 1, hint: "add", help: "add a bookmark", compact: true},
+	{key: 2, hint: "modify", help: "modify the bookmark", compact: true, needsBookmark: true},
+	{key: 3, hint: "delete", help: "delete the bookmark", needsBookmark: true},
+	{key: 4, hint: "stats", help: "statistics"},
+	{key: 5, hint: "copy as buku", help: "copy the bookmark as a buku command", needsBookmark: true},
+	{key: 6, hint: "export", help: "export the bookmark", needsBookmark: true},
+	{key: 7, hint: "open & stay", help: "open the bookmark and stay", needsBookmark: true},
+	{key: 8, hint: "trash", help: "trash"},
+	{key: 9, hint: "filter", help: "filter the bookmarks", compact: true},
+}
+
+// moreAction opens the help screen, it ends the hints that are cut short.
+var moreAction = hotkeyAction{key: 10, hint: "more…", help: "show this help"}
+
+// hotkeyActions returns the hotkeys of the bookmark list.
+func (in *InputHandler) hotkeyActions() []hotkeyAction {
+	actions := append(slices.Clip(hotkeyActions), recentAction)
+	if len(in.cfg.HiddenTags) > 0 {
+		actions = append(slices.Clip(actions), revealAction)
+	}
+	if in.hasAltBrowser() {
+		actions = append(slices.Clip(actions), altBrowserAction)
+	}
+	if in.cfg.Inbox {
+		actions = append(slices.Clip(actions), inboxAction)
+	}
+	if in.cfg.FastDelete {
+		actions = append(slices.Clip(actions), undoDeleteAction)
+	}
+	return actions
+}
+
+// compactHintsMax is the number of actions shown in the compact hints
+// besides moreAction.
+const compactHintsMax = 3
+
+// keyName returns the keys pressed for a, like "Alt+1", or "Alt+Shift+1"
+// for kb-custom-11 and up.
+func (a hotkeyAction) keyName() string {
+	if a.key > 10 {
+		return fmt.Sprintf("Alt+Shift+%d", (a.key-10)%10)
+	}
+	return fmt.Sprintf("Alt+%d", a.key%10)
+}
+
+// hotkeyKeyName returns the keys of the action of hotkeyActions labeled
+// hint, like "Alt+9" for "filter".
+func hotkeyKeyName(hint string) string {
+	for _, a := range hotkeyActions {
+		if a.hint == hint {
+			return a.keyName()
+		}
+	}
+	return ""
+}
+
+// hintText returns the hint of a in the message box, like "add: Alt+1".
+func (a hotkeyAction) hintText() string {
+	return a.hint + ": " + a.keyName()
+}
+
+// helpText returns the line of a on the help screen, like
+// "Alt+1: add a bookmark".
+func (a hotkeyAction) helpText() string {
+	return a.keyName() + ": " + a.help
+}
+
+// fittedHints returns the hints of all actions if they fit in width runes,
+// or else the hints of as many leading actions as fit followed by
+// moreAction.
+func fittedHints(actions []hotkeyAction, width int) string {
+	hints := make([]string, 0, len(actions)+1)
+	for _, a := range actions {
+		hints = append(hints, a.hintText())
+	}
+	if line := strings.Join(hints, " | "); utf8.RuneCountInString(line) <= width {
+		return line
+	}
+	return fitHints(append(hints, moreAction.hintText()), width)
+}
+
+// compactHints returns the hints of the first compact actions that apply,
+// followed by moreAction, fitted in width runes like fitHints.
+func compactHints(actions []hotkeyAction, hasBookmarks bool, width int) string {
+	hints := make([]string, 0, compactHintsMax+1)
+	for _, a := range actions {
+		if len(hints) == compactHintsMax {
+			break
+		}
+		if a.compact && (hasBookmarks || !a.needsBookmark) {
+			hints = append(hints, a.hintText())
+		}
+	}
+	return fitHints(append(hints, moreAction.hintText()), width)
+}
+
+// fitHints joins hints, which end with the hint of moreAction. Hints before
+// it are dropped from the end until the line fits in width runes, moreAction
+// is truncated if it alone does not fit.
+func fitHints(hints []string, width int) string {
+	for {
+		line := strings.Join(hints, " | ")
+		if utf8.RuneCountInString(line) <= width || len(hints) == 1 {
+			return truncateEnd(line, width)
+		}
+		hints = append(hints[:len(hints)-2], hints[len(hints)-1])
+	}
+}
+
+// hotkeyHints returns the hints in the message box of the bookmark list,
+// hasBookmarks tells whether any bookmark is listed. The hints that do not
+// fit in entryMaxLen are left to the help screen.
+func (in *InputHandler) hotkeyHints(hasBookmarks bool) string {
+	if in.cfg.CompactHints {
+		return compactHints(in.hotkeyActions(), hasBookmarks, entryMaxLen)
+	}
+	return fittedHints(in.hotkeyActions(), entryMaxLen)
+}
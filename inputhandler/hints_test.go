@@ -0,0 +1,106 @@
+package inputhandler
+
+import (
+	"strings"
+	"testing"
+	"unicode/utf8"
+
+	"github.com/VannRR/robuku/bukudb"
+	rofiapi "github.com/VannRR/rofi-api"
+)
+
+func Test_fittedHints(t *testing.T) {
+	full := "add: Alt+1 | modify: Alt+2 | delete: Alt+3 | stats: Alt+4 | copy as buku: Alt+5 | export: Alt+6 | open & stay: Alt+7 | trash: Alt+8 | filter: Alt+9"
+	tests := []struct {
+		name     string
+		width    int
+		expected string
+	}{
+		{"all fit", 200, full},
+		{"exact width", utf8.RuneCountInString(full), full},
+		{"entry width", entryMaxLen, "add: Alt+1 | modify: Alt+2 | delete: Alt+3 | stats: Alt+4 | copy as buku: Alt+5 | more…: Alt+0"},
+		{"narrow", 30, "add: Alt+1 | more…: Alt+0"},
+		{"narrower than more", 5, "more…"},
+	}
+	for _, tt := range tests {
+		got := fittedHints(hotkeyActions, tt.width)
+		if got != tt.expected {
+			t.Errorf("%s: expected '%s', got '%s'", tt.name, tt.expected, got)
+		}
+		if n := utf8.RuneCountInString(got); n > tt.width {
+			t.Errorf("%s: expected at most %d runes, got %d", tt.name, tt.width, n)
+		}
+	}
+}
+
+func Test_compactHints(t *testing.T) {
+	tests := []struct {
+		name         string
+		hasBookmarks bool
+		width        int
+		expected     string
+	}{
+		{"bookmarks", true, entryMaxLen, "add: Alt+1 | modify: Alt+2 | filter: Alt+9 | more…: Alt+0"},
+		{"no bookmarks", false, entryMaxLen, "add: Alt+1 | filter: Alt+9 | more…: Alt+0"},
+		{"exact width", true, 57, "add: Alt+1 | modify: Alt+2 | filter: Alt+9 | more…: Alt+0"},
+		{"one too narrow", true, 56, "add: Alt+1 | modify: Alt+2 | more…: Alt+0"},
+		{"only more", true, 20, "more…: Alt+0"},
+		{"narrower than more", true, 5, "more…"},
+		{"zero", false, 0, ""},
+	}
+	for _, tt := range tests {
+		got := compactHints(hotkeyActions, tt.hasBookmarks, tt.width)
+		if got != tt.expected {
+			t.Errorf("%s: expected '%s', got '%s'", tt.name, tt.expected, got)
+		}
+		if n := utf8.RuneCountInString(got); n > tt.width {
+			t.Errorf("%s: expected at most %d runes, got %d", tt.name, tt.width, n)
+		}
+	}
+}
+
+func Test_compactHints_Max(t *testing.T) {
+	actions := make([]hotkeyAction, 0, 9)
+	for i := 1; i <= 9; i++ {
+		actions = append(actions, hotkeyAction{key: i, hint: "a", compact: true})
+	}
+	got := compactHints(actions, true, entryMaxLen)
+	if n := strings.Count(got, "|"); n != compactHintsMax {
+		t.Errorf("expected %d actions besides more, got '%s'", compactHintsMax, got)
+	}
+}
+
+func Test_HandleBookmarksShow_CompactHints(t *testing.T) {
+	in := initInputHandler(t)
+	in.cfg.CompactHints = true
+	in.HandleBookmarksShow()
+
+	message := in.api.Options[rofiapi.OptionMessage]
+	if !strings.Contains(message, "modify: Alt+2") || strings.Contains(message, "delete: Alt+3") {
+		t.Errorf("expected compact hints with modify, got '%s'", message)
+	}
+
+	in.api.Data.Filters = []Filter{{Tag: "nothing"}}
+	in.HandleBookmarksShow()
+	message = in.api.Options[rofiapi.OptionMessage]
+	if strings.Contains(message, "modify: Alt+2") || !strings.Contains(message, "more…: Alt+0") {
+		t.Errorf("expected compact hints without modify, got '%s'", message)
+	}
+}
+
+func Test_HandleBookmarksShow_FittedHints(t *testing.T) {
+	in := initInputHandler(t)
+	in.HandleBookmarksShow()
+
+	message := in.api.Options[rofiapi.OptionMessage]
+	if !strings.Contains(message, "copy as buku: Alt+5 | more…: Alt+0") || strings.Contains(message, "trash: Alt+8") {
+		t.Errorf("expected the hints cut short with more, got '%s'", message)
+	}
+}
+
+func Test_handleBookmarksSelect_Help(t *testing.T) {
+	in := initInputHandler(t)
+	in.api.Data.Bookmark = bukudb.Bookmark{ID: 1}
+	in.handleBookmarksSelect("", rofiapi.StateCustomKeybinding10)
+	checkState(t, StateHelpSelect, in.api.Data.State)
+}
@@ -0,0 +1,21 @@
+package inputhandler
+
+import (
+	"context"
+	"fmt"
+)
+
+const opUpgradeHTTPS string = "--> Upgrade http links to https"
+
+// upgradeToHTTPS moves the http bookmarks to https where the https variant
+// of their URL answers, then shows the statistics with a summary.
+func (in *InputHandler) upgradeToHTTPS() {
+	summary, err := in.upgradeHTTPS(context.Background(), in.db)
+	if err != nil {
+		in.showWithError(in.handleStatsShow, fmt.Errorf("error upgrading to https: %w", err))
+		return
+	}
+	in.handleStatsShow()
+	in.addNotice(fmt.Sprintf("checked %d http bookmarks: %d upgraded, %d kept",
+		summary.Checked, summary.Upgraded, summary.Failed))
+}
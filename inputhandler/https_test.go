@@ -0,0 +1,57 @@
+package inputhandler
+
+import (
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/VannRR/robuku/bukudb"
+	"github.com/VannRR/robuku/maintenance"
+	rofiapi "github.com/VannRR/rofi-api"
+)
+
+func Test_upgradeToHTTPS(t *testing.T) {
+	srv := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+	defer srv.Close()
+
+	in := initInputHandler(t)
+	in.upgradeHTTPS = (&maintenance.HTTPSUpgrader{
+		Client:      srv.Client(),
+		Timeout:     time.Second,
+		Concurrency: 1,
+		Out:         io.Discard,
+	}).Run
+
+	// the entry is only offered with http bookmarks
+	in.handleStatsShow()
+	for _, e := range in.api.Entries {
+		if e.Text == opUpgradeHTTPS {
+			t.Errorf("expected no '%s' entry without http bookmarks", opUpgradeHTTPS)
+		}
+	}
+
+	httpURL := "http://" + strings.TrimPrefix(srv.URL, "https://") + "/page"
+	if err := in.db.Add(bukudb.Bookmark{URL: httpURL}); err != nil {
+		t.Fatal(err)
+	}
+	in.handleStatsShow()
+	checkEntryTexts(t, []string{opBack, opFixTitles, opUpgradeHTTPS}, in.api.Entries[:3])
+
+	in.HandleInput(opUpgradeHTTPS)
+	checkState(t, StateStatsSelect, in.api.Data.State)
+	checkMessageContains(t, in, "checked 1 http bookmarks: 1 upgraded, 0 kept")
+	if b, _ := in.db.Get(5); b.URL != srv.URL+"/page" {
+		t.Errorf("expected the url to be upgraded to '%s', got '%s'", srv.URL+"/page", b.URL)
+	}
+	for _, e := range in.api.Entries {
+		if e.Text == opUpgradeHTTPS {
+			t.Errorf("expected no '%s' entry after the upgrade", opUpgradeHTTPS)
+		}
+	}
+	if in.api.Options[rofiapi.OptionNoCustom] != "true" {
+		t.Errorf("expected the statistics screen, got options %v", in.api.Options)
+	}
+}
@@ -0,0 +1,129 @@
+package inputhandler
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/VannRR/robuku/bukudb"
+	"github.com/VannRR/robuku/importer"
+	rofiapi "github.com/VannRR/rofi-api"
+)
+
+// importPreviewTitles is the number of titles shown in the import preview.
+const importPreviewTitles = 10
+
+// PendingImport is an import waiting for confirmation on the preview.
+type PendingImport struct {
+	// Source is the file the bookmarks are read from. It is parsed again on
+	// confirm, the bookmarks are too many for the state rofi passes between
+	// runs.
+	Source string
+}
+
+// startImport shows what importing the HTML or JSON bookmark file input
+// would do. Relative paths are in the working directory.
+func (in *InputHandler) startImport(input string) {
+	home, _ := os.UserHomeDir()
+	path, err := resolveImportPath(input, home)
+	if err != nil {
+		in.showWithError(in.HandleBookmarksShow, err)
+		return
+	}
+
+	in.api.Data.Import = PendingImport{Source: path}
+	in.handleImportPreviewShow()
+}
+
+// resolveImportPath returns the absolute path of the bookmark file input,
+// with a leading "~" expanded to home.
+func resolveImportPath(input, home string) (string, error) {
+	path, err := expandHome(strings.TrimSpace(input), home)
+	if err != nil {
+		return "", err
+	}
+	return filepath.Abs(path)
+}
+
+// pendingBookmarks parses the bookmark file of the pending import.
+func (in *InputHandler) pendingBookmarks() ([]bukudb.Bookmark, error) {
+	path := in.api.Data.Import.Source
+	format, err := importer.FormatOf(path)
+	if err != nil {
+		return nil, err
+	}
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+	return importer.Parse(f, format)
+}
+
+// endImport forgets the pending import.
+func (in *InputHandler) endImport() {
+	in.api.Data.Import = PendingImport{}
+}
+
+func (in *InputHandler) handleImportPreviewShow() {
+	bookmarks, err := in.pendingBookmarks()
+	if err != nil {
+		in.endImport()
+		in.showWithError(in.HandleBookmarksShow, err)
+		return
+	}
+	preview, err := importer.Preview(in.db, bookmarks)
+	if err != nil {
+		in.endImport()
+		in.showWithError(in.HandleBookmarksShow, err)
+		return
+	}
+
+	in.api.Options[rofiapi.OptionMessage] = in.style.generatePangoMarkup(
+		fmt.Sprintf("%d bookmarks in %s: %d new, %d duplicates, %d without URL",
+			len(bookmarks), in.api.Data.Import.Source, preview.Added, preview.Duplicates, preview.Failed),
+		"", "")
+	in.api.Options[rofiapi.OptionNoCustom] = "true"
+	in.api.Options[rofiapi.OptionUseHotKeys] = "false"
+
+	entries := []rofiapi.Entry{{Text: opBack}, {Text: opConfirm}}
+	for _, b := range bookmarks[:min(len(bookmarks), importPreviewTitles)] {
+		title := b.Title
+		if title == "" {
+			title = b.URL
+		}
+		entries = append(entries, rofiapi.Entry{Text: replaceNewlines(title), NonSelectable: true})
+	}
+	if n := len(bookmarks) - importPreviewTitles; n > 0 {
+		entries = append(entries, rofiapi.Entry{Text: fmt.Sprintf("… and %d more", n), NonSelectable: true})
+	}
+	in.api.Entries = entries
+
+	in.api.Data.State = StateImportPreviewSelect
+}
+
+func (in *InputHandler) handleImportPreviewSelect(input string) {
+	if input != opConfirm {
+		in.endImport()
+		in.HandleBookmarksShow()
+		return
+	}
+
+	bookmarks, err := in.pendingBookmarks()
+	if err != nil {
+		in.endImport()
+		in.showWithError(in.HandleBookmarksShow, err)
+		return
+	}
+	summary, err := importer.Apply(in.db, bookmarks, nil, bukudb.ConflictSkip)
+	in.endImport()
+	if err != nil {
+		in.showWithError(in.HandleBookmarksShow, fmt.Errorf("error importing bookmarks: %w", err))
+		return
+	}
+
+	in.HandleBookmarksShow()
+	in.prependMessage(in.style.spanMarkup("", fmt.Sprintf("imported %d, %d duplicates skipped, %d failed",
+		summary.Added, summary.Duplicates, summary.Failed)))
+}
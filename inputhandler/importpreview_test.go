@@ -0,0 +1,130 @@
+package inputhandler
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	rofiapi "github.com/VannRR/rofi-api"
+)
+
+// initImportTest returns an InputHandler whose working directory holds
+// bookmarks.html, temporary files are written to a test directory.
+func initImportTest(t *testing.T) *InputHandler {
+	t.Helper()
+	t.Setenv("TMPDIR", t.TempDir())
+
+	in := initInputHandler(t)
+	in.cfg.ExportDir = t.TempDir()
+	dir := t.TempDir()
+	wd, err := os.Getwd()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := os.Chdir(dir); err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { os.Chdir(wd) })
+	html := `<DL><p>
+    <DT><A HREF="https://www.google.com">google</A>
+    <DT><A HREF="https://new.com" TAGS="x">New</A>
+</DL><p>
+`
+	path := filepath.Join(dir, "bookmarks.html")
+	if err := os.WriteFile(path, []byte(html), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	return in
+}
+
+func Test_startImport_Confirm(t *testing.T) {
+	in := initImportTest(t)
+	before := in.db.Len()
+
+	in.handleCustomInput(":import bookmarks.html")
+	checkState(t, StateImportPreviewSelect, in.api.Data.State)
+	checkEntryTexts(t, []string{opBack, opConfirm, "google", "New"}, in.api.Entries)
+	if message := in.api.Options[rofiapi.OptionMessage]; !strings.Contains(message, "2 bookmarks in") ||
+		!strings.Contains(message, "1 new, 1 duplicates") {
+		t.Errorf("expected counts in message, got '%s'", message)
+	}
+	if in.db.Len() != before {
+		t.Errorf("expected nothing imported before confirming, got %d bookmarks", in.db.Len())
+	}
+
+	in.HandleInput(opConfirm)
+	checkState(t, StateBookmarksSelect, in.api.Data.State)
+	if in.db.Len() != before+1 {
+		t.Errorf("expected %d bookmarks, got %d", before+1, in.db.Len())
+	}
+	if message := in.api.Options[rofiapi.OptionMessage]; !strings.Contains(message, "imported 1, 1 duplicates skipped") {
+		t.Errorf("expected import summary, got '%s'", message)
+	}
+	if in.api.Data.Import != (PendingImport{}) {
+		t.Errorf("expected no pending import, got %+v", in.api.Data.Import)
+	}
+	// nothing with the bookmarks is left behind
+	if files, _ := os.ReadDir(os.TempDir()); len(files) != 0 {
+		t.Errorf("expected no temporary files, got %v", files)
+	}
+}
+
+func Test_startImport_Back(t *testing.T) {
+	in := initImportTest(t)
+	before := in.db.Len()
+
+	in.handleCustomInput(":import bookmarks.html")
+
+	in.HandleInput(opBack)
+	checkState(t, StateBookmarksSelect, in.api.Data.State)
+	if in.db.Len() != before {
+		t.Errorf("expected nothing imported, got %d bookmarks", in.db.Len())
+	}
+	if in.api.Data.Import != (PendingImport{}) {
+		t.Errorf("expected no pending import, got %+v", in.api.Data.Import)
+	}
+}
+
+func Test_startImport_Errors(t *testing.T) {
+	in := initImportTest(t)
+
+	for _, input := range []string{":import missing.html", ":import bookmarks.md"} {
+		in.handleCustomInput(input)
+		checkState(t, StateBookmarksSelect, in.api.Data.State)
+		if in.api.Data.Import != (PendingImport{}) {
+			t.Errorf("%s: expected no pending import, got %+v", input, in.api.Data.Import)
+		}
+		if !strings.Contains(in.api.Options[rofiapi.OptionMessage], "error:") {
+			t.Errorf("%s: expected error in message, got '%s'", input, in.api.Options[rofiapi.OptionMessage])
+		}
+	}
+
+	// a lost bookmark file ends the preview
+	in.api.Data.Import = PendingImport{Source: filepath.Join(t.TempDir(), "gone.json")}
+	in.handleImportPreviewShow()
+	checkState(t, StateBookmarksSelect, in.api.Data.State)
+}
+
+func Test_resolveImportPath(t *testing.T) {
+	wd, err := os.Getwd()
+	if err != nil {
+		t.Fatal(err)
+	}
+	tests := []struct {
+		input    string
+		expected string
+	}{
+		{"bookmarks.html", filepath.Join(wd, "bookmarks.html")},
+		{" ~/b.json ", "/home/u/b.json"},
+		{"/tmp/b.html", "/tmp/b.html"},
+	}
+	for _, tt := range tests {
+		if actual, err := resolveImportPath(tt.input, "/home/u"); err != nil || actual != tt.expected {
+			t.Errorf("input '%s': expected '%s', got '%s' and %v", tt.input, tt.expected, actual, err)
+		}
+	}
+	if _, err := resolveImportPath(" ", "/home/u"); err == nil {
+		t.Error("expected an error for no path, got nil")
+	}
+}
@@ -0,0 +1,183 @@
+package inputhandler
+
+import (
+	"fmt"
+
+	"github.com/VannRR/robuku/bukudb"
+	rofiapi "github.com/VannRR/rofi-api"
+)
+
+// inboxTag is added to the bookmarks added with robuku when $ROBUKU_INBOX=1,
+// until they are triaged.
+const inboxTag = "inbox"
+
+const (
+	opKeep  string = "--> Keep"
+	opRetag string = "--> Retag"
+)
+
+// inboxAction starts triaging the bookmarks tagged inboxTag.
+var inboxAction = hotkeyAction{key: 14, hint: "inbox", help: "triage the bookmarks in the inbox"}
+
+// Inbox is the progress of triaging the bookmarks tagged inboxTag.
+type Inbox struct {
+	// Skipped is the number of inbox bookmarks skipped, the current one is
+	// the next tagged bookmark after them. Kept, retagged, and deleted
+	// bookmarks leave the inbox, so the next one takes their place.
+	Skipped int
+}
+
+// tagInbox adds inboxTag to b, the copy of the bookmark being added that
+// is inserted, if the inbox is on.
+func (in *InputHandler) tagInbox(b *bukudb.Bookmark) {
+	if in.cfg.Inbox {
+		b.Tags = withTags(b.Tags, []string{inboxTag})
+	}
+}
+
+// inboxBookmarks returns the bookmarks tagged inboxTag in ID order.
+func (in *InputHandler) inboxBookmarks() ([]bukudb.Bookmark, error) {
+	bookmarks, err := in.db.GetAll()
+	if err != nil {
+		return nil, err
+	}
+	var inbox []bukudb.Bookmark
+	for _, b := range bookmarks {
+		if containsTag(b.Tags, inboxTag) {
+			inbox = append(inbox, b)
+		}
+	}
+	return inbox, nil
+}
+
+// startInbox starts triaging the inbox from its first bookmark.
+func (in *InputHandler) startInbox() {
+	in.api.Data.Inbox = Inbox{}
+	in.handleInboxShow()
+}
+
+// handleInboxShow offers the triage actions for the current inbox bookmark,
+// or shows the bookmarks once none is left.
+func (in *InputHandler) handleInboxShow() {
+	inbox, err := in.inboxBookmarks()
+	if err != nil {
+		in.setMessageToError(fmt.Errorf("error getting inbox: %w", err))
+		return
+	}
+	pos := in.api.Data.Inbox.Skipped
+	if pos >= len(inbox) {
+		in.stopInbox()
+		if len(inbox) == 0 {
+			in.addNotice("inbox is empty")
+		} else {
+			in.addNotice(fmt.Sprintf("%d skipped in the inbox", len(inbox)))
+		}
+		return
+	}
+
+	b := inbox[pos]
+	in.api.Data.Bookmark = b
+	in.api.Options[rofiapi.OptionMessage] = in.style.generatePangoMarkup(
+		fmt.Sprintf("inbox %d of %d", pos+1, len(inbox)), "", b.URL)
+	in.api.Options[rofiapi.OptionNoCustom] = "true"
+	in.api.Options[rofiapi.OptionUseHotKeys] = "false"
+
+	title := b.Title
+	if title == "" {
+		title = b.URL
+	}
+	in.api.Entries = []rofiapi.Entry{
+		{Text: formatEntryText(title), NonSelectable: true},
+		{Text: opKeep},
+		{Text: opRetag},
+		{Text: opDelete},
+		{Text: opSkip},
+		{Text: opStop},
+	}
+
+	in.api.Data.State = StateInboxSelect
+}
+
+func (in *InputHandler) handleInboxSelect(input string) {
+	switch input {
+	case opKeep:
+		in.leaveInbox(nil)
+	case opRetag:
+		in.handleInboxTagsShow()
+	case opDelete:
+		if in.bookmarkChanged() {
+			return
+		}
+		if err := in.db.Remove(in.api.Data.Bookmark.ID); err != nil {
+			in.setMessageToError(fmt.Errorf("error deleting bookmark: %w", err))
+			return
+		}
+		in.api.Data.Undo = Undo{}
+		in.handleInboxShow()
+	case opSkip:
+		in.api.Data.Inbox.Skipped++
+		in.handleInboxShow()
+	case opStop:
+		in.stopInbox()
+	default:
+		in.handleInboxShow()
+	}
+}
+
+func (in *InputHandler) handleInboxTagsShow() {
+	in.api.Options[rofiapi.OptionMessage] = in.style.generatePangoMarkup(
+		"enter the tags to add, the bookmark leaves the inbox",
+		tagsExample(in.cfg.TagSplit),
+		in.api.Data.Bookmark.URL)
+	in.api.Options[rofiapi.OptionNoCustom] = "false"
+	in.api.Options[rofiapi.OptionUseHotKeys] = "false"
+
+	in.api.Entries = []rofiapi.Entry{
+		{Text: opBack},
+	}
+
+	in.api.Data.State = StateInboxTagsSelect
+}
+
+func (in *InputHandler) handleInboxTagsSelect(input string) {
+	if input == opBack {
+		in.handleInboxShow()
+		return
+	}
+	tags := getTagsFromInput(input, in.cfg.TagSplit)
+	if len(tags) == 0 {
+		in.showWithError(in.handleInboxTagsShow, errNoTags)
+		return
+	}
+	if err := checkTagsLength(tags, in.cfg.MaxTagLen); err != nil {
+		in.showWithError(in.handleInboxTagsShow, err)
+		return
+	}
+	in.leaveInbox(tags)
+}
+
+// leaveInbox adds tags to the current inbox bookmark, removes inboxTag from
+// it, and moves on to the next one.
+func (in *InputHandler) leaveInbox(tags []string) {
+	if in.bookmarkChanged() {
+		return
+	}
+	id := in.api.Data.Bookmark.ID
+	if len(tags) > 0 {
+		if err := in.db.AddTags(id, tags); err != nil {
+			in.setMessageToError(fmt.Errorf("error adding tag: %w", err))
+			return
+		}
+	}
+	if err := in.db.RemoveTags(id, []string{inboxTag}); err != nil {
+		in.setMessageToError(fmt.Errorf("error removing tag: %w", err))
+		return
+	}
+	in.handleInboxShow()
+}
+
+// stopInbox ends the triage and shows the bookmarks.
+func (in *InputHandler) stopInbox() {
+	in.api.Data.Inbox = Inbox{}
+	in.HandleBookmarksShow()
+}
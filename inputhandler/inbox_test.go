@@ -0,0 +1,127 @@
+package inputhandler
+
+import (
+	"slices"
+	"testing"
+
+	"github.com/VannRR/robuku/bukudb"
+	rofiapi "github.com/VannRR/rofi-api"
+)
+
+func initInboxInputHandler(t *testing.T) *InputHandler {
+	t.Helper()
+	in := initInputHandler(t)
+	in.cfg.Inbox = true
+	for _, id := range []uint16{1, 2, 4} {
+		if err := in.db.AddTags(id, []string{inboxTag}); err != nil {
+			t.Fatal(err)
+		}
+	}
+	return in
+}
+
+func checkInboxItem(t *testing.T, in *InputHandler, id uint16, progress string) {
+	t.Helper()
+	checkState(t, StateInboxSelect, in.api.Data.State)
+	if in.api.Data.Bookmark.ID != id {
+		t.Errorf("expected inbox bookmark %d, got %d", id, in.api.Data.Bookmark.ID)
+	}
+	checkMessageContains(t, in, progress)
+}
+
+func Test_Inbox(t *testing.T) {
+	in := initInboxInputHandler(t)
+
+	in.handleBookmarksSelect("", rofiapi.StateCustomKeybinding14)
+	checkInboxItem(t, in, 1, "inbox 1 of 3")
+	for _, op := range []string{opKeep, opRetag, opDelete, opSkip, opStop} {
+		if !hasEntry(in.api.Entries, op) {
+			t.Errorf("expected entry '%s'", op)
+		}
+	}
+
+	// skip leaves the bookmark in the inbox
+	in.HandleInput(opSkip)
+	checkInboxItem(t, in, 2, "inbox 2 of 3")
+
+	// back from retag returns to the same bookmark
+	in.HandleInput(opRetag)
+	checkState(t, StateInboxTagsSelect, in.api.Data.State)
+	in.HandleInput(opBack)
+	checkInboxItem(t, in, 2, "inbox 2 of 3")
+
+	// retag adds the tags and removes inbox
+	in.HandleInput(opRetag)
+	in.HandleInput("read, later")
+	if b, _ := in.db.Get(2); !slices.Equal(b.Tags, []string{"b", "later", "read", "tag2", "tag3"}) {
+		t.Errorf("expected retagged bookmark without inbox, got %v", b.Tags)
+	}
+	checkInboxItem(t, in, 4, "inbox 2 of 2")
+
+	// delete moves on, past the skipped bookmark the inbox is done
+	in.HandleInput(opDelete)
+	checkState(t, StateBookmarksSelect, in.api.Data.State)
+	checkMessageContains(t, in, "1 skipped in the inbox")
+	if in.db.Len() != 3 {
+		t.Errorf("expected 3 bookmarks after delete, got %d", in.db.Len())
+	}
+	if in.api.Data.Inbox != (Inbox{}) {
+		t.Errorf("expected the inbox progress to be reset, got %+v", in.api.Data.Inbox)
+	}
+
+	// the skipped bookmark is first again
+	in.startInbox()
+	checkInboxItem(t, in, 1, "inbox 1 of 1")
+	in.HandleInput(opKeep)
+	checkState(t, StateBookmarksSelect, in.api.Data.State)
+	checkMessageContains(t, in, "inbox is empty")
+	if b, _ := in.db.Get(1); containsTag(b.Tags, inboxTag) {
+		t.Errorf("expected kept bookmark without inbox, got %v", b.Tags)
+	}
+}
+
+func Test_Inbox_retagErrors(t *testing.T) {
+	in := initInboxInputHandler(t)
+	in.startInbox()
+	in.HandleInput(opRetag)
+
+	in.HandleInput(", ,")
+	checkState(t, StateInboxTagsSelect, in.api.Data.State)
+	checkMessageContains(t, in, errNoTags.Error())
+	if b, _ := in.db.Get(1); !containsTag(b.Tags, inboxTag) {
+		t.Errorf("expected bookmark to stay in the inbox, got %v", b.Tags)
+	}
+}
+
+func Test_Inbox_disabled(t *testing.T) {
+	in := initInputHandler(t)
+
+	in.handleBookmarksSelect("", rofiapi.StateCustomKeybinding14)
+	checkState(t, StateBookmarksSelect, in.api.Data.State)
+	if slices.Contains(in.hotkeyActions(), inboxAction) {
+		t.Errorf("expected no inbox hotkey")
+	}
+
+	in.cfg.Inbox = true
+	if !slices.Contains(in.hotkeyActions(), inboxAction) {
+		t.Errorf("expected the inbox hotkey")
+	}
+}
+
+func Test_tagInbox(t *testing.T) {
+	in := initInputHandler(t)
+
+	// added without the inbox
+	in.api.Data.Bookmark = bukudb.Bookmark{URL: "https://new.com", Tags: []string{"go"}}
+	in.handleAddSelect(opConfirm)
+	if b, _ := in.db.Get(5); !slices.Equal(b.Tags, []string{"go"}) {
+		t.Errorf("expected tags [go], got %v", b.Tags)
+	}
+
+	in.cfg.Inbox = true
+	in.api.Data.Bookmark = bukudb.Bookmark{URL: "https://newer.com", Tags: []string{"go"}}
+	in.handleAddSelect(opConfirm)
+	if b, _ := in.db.Get(6); !slices.Equal(b.Tags, []string{"go", inboxTag}) {
+		t.Errorf("expected tags [go inbox], got %v", b.Tags)
+	}
+}
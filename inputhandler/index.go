@@ -0,0 +1,118 @@
+package inputhandler
+
+import (
+	"sort"
+	"strconv"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+
+	"github.com/VannRR/robuku/bukudb"
+	rofiapi "github.com/VannRR/rofi-api"
+)
+
+const (
+	// indexDigits and indexSymbols are the index groups of bookmarks whose
+	// initial is a digit or neither a letter nor a digit.
+	indexDigits  = "0-9"
+	indexSymbols = "#"
+
+	jumpPrefix = "— "
+	jumpSuffix = " —"
+)
+
+// indexGroup is a group of bookmarks sharing an index key, first is the
+// position of its first bookmark in the ordered bookmarks.
+type indexGroup struct {
+	key   string
+	first int
+}
+
+// indexKey returns the index group of b, the upper cased initial of its
+// title, or of its host if it has no title.
+func indexKey(b bukudb.Bookmark) string {
+	text := strings.TrimSpace(b.Title)
+	if text == "" {
+		text = bukudb.URLHost(b.URL)
+	}
+	r, _ := utf8.DecodeRuneInString(text)
+	switch {
+	case unicode.IsLetter(r):
+		return string(unicode.ToUpper(r))
+	case unicode.IsDigit(r):
+		return indexDigits
+	default:
+		return indexSymbols
+	}
+}
+
+// indexLess orders index keys, digits first, then letters, then symbols.
+func indexLess(a, b string) bool {
+	rank := func(key string) int {
+		switch key {
+		case indexDigits:
+			return 0
+		case indexSymbols:
+			return 2
+		default:
+			return 1
+		}
+	}
+	if rank(a) != rank(b) {
+		return rank(a) < rank(b)
+	}
+	return a < b
+}
+
+// groupByIndex returns bookmarks ordered by index key, keeping their order
+// within a group, and the groups in that order.
+func groupByIndex(bookmarks []bukudb.Bookmark) ([]bukudb.Bookmark, []indexGroup) {
+	keys := make([]string, len(bookmarks))
+	for i, b := range bookmarks {
+		keys[i] = indexKey(b)
+	}
+	order := make([]int, len(bookmarks))
+	for i := range order {
+		order[i] = i
+	}
+	sort.SliceStable(order, func(i, j int) bool {
+		return indexLess(keys[order[i]], keys[order[j]])
+	})
+
+	ordered := make([]bukudb.Bookmark, len(bookmarks))
+	groups := make([]indexGroup, 0)
+	for i, o := range order {
+		ordered[i] = bookmarks[o]
+		if len(groups) == 0 || groups[len(groups)-1].key != keys[o] {
+			groups = append(groups, indexGroup{key: keys[o], first: i})
+		}
+	}
+	return ordered, groups
+}
+
+// jumpEntry returns the entry jumping to the index group key.
+func jumpEntry(key string) rofiapi.Entry {
+	return rofiapi.Entry{Text: jumpPrefix + key + jumpSuffix}
+}
+
+// parseJumpEntry returns the index key of a jump entry, ok is false if
+// input is not one.
+func parseJumpEntry(input string) (key string, ok bool) {
+	key, ok = strings.CutPrefix(input, jumpPrefix)
+	if !ok {
+		return "", false
+	}
+	return strings.CutSuffix(key, jumpSuffix)
+}
+
+// selectGroup highlights the first bookmark of the index group in
+// in.jumpTo, offset is the number of entries before the bookmarks.
+func (in *InputHandler) selectGroup(groups []indexGroup, offset int) {
+	for _, g := range groups {
+		if g.key == in.jumpTo {
+			in.api.Options[rofiapi.OptionKeepSelection] = "true"
+			in.api.Options[rofiapi.OptionNewSelection] = strconv.Itoa(offset + g.first)
+			return
+		}
+	}
+}
@@ -0,0 +1,118 @@
+package inputhandler
+
+import (
+	"testing"
+
+	"github.com/VannRR/robuku/bukudb"
+	rofiapi "github.com/VannRR/rofi-api"
+)
+
+func Test_indexKey(t *testing.T) {
+	tests := []struct {
+		bookmark bukudb.Bookmark
+		expected string
+	}{
+		{bukudb.Bookmark{Title: "apple"}, "A"},
+		{bukudb.Bookmark{Title: "  Zebra"}, "Z"},
+		{bukudb.Bookmark{Title: "école"}, "É"},
+		{bukudb.Bookmark{Title: "ñandú"}, "Ñ"},
+		{bukudb.Bookmark{Title: "Ωmega"}, "Ω"},
+		{bukudb.Bookmark{Title: "東京"}, "東"},
+		{bukudb.Bookmark{Title: "9 lives"}, indexDigits},
+		{bukudb.Bookmark{Title: "٣ arabic digit"}, indexDigits},
+		{bukudb.Bookmark{Title: "[draft] notes"}, indexSymbols},
+		{bukudb.Bookmark{Title: "★ starred"}, indexSymbols},
+		{bukudb.Bookmark{URL: "https://www.golang.org/doc"}, "G"},
+		{bukudb.Bookmark{URL: "mailto:a@b.com"}, indexSymbols},
+		{bukudb.Bookmark{}, indexSymbols},
+	}
+
+	for _, tt := range tests {
+		if got := indexKey(tt.bookmark); got != tt.expected {
+			t.Errorf("expected index key of '%s' '%s' to be '%s', got '%s'",
+				tt.bookmark.Title, tt.bookmark.URL, tt.expected, got)
+		}
+	}
+}
+
+func Test_groupByIndex(t *testing.T) {
+	bookmarks := []bukudb.Bookmark{
+		{ID: 1, Title: "beta"},
+		{ID: 2, Title: "#hash"},
+		{ID: 3, Title: "alpha"},
+		{ID: 4, Title: "2fa"},
+		{ID: 5, Title: "Bravo"},
+	}
+
+	ordered, groups := groupByIndex(bookmarks)
+
+	expectedIDs := []uint16{4, 3, 1, 5, 2}
+	for i, b := range ordered {
+		if b.ID != expectedIDs[i] {
+			t.Errorf("expected bookmark %d at %d, got %d", expectedIDs[i], i, b.ID)
+		}
+	}
+
+	expectedGroups := []indexGroup{
+		{key: indexDigits, first: 0},
+		{key: "A", first: 1},
+		{key: "B", first: 2},
+		{key: indexSymbols, first: 4},
+	}
+	if len(groups) != len(expectedGroups) {
+		t.Fatalf("expected groups %v, got %v", expectedGroups, groups)
+	}
+	for i, g := range groups {
+		if g != expectedGroups[i] {
+			t.Errorf("expected group %v, got %v", expectedGroups[i], g)
+		}
+	}
+}
+
+func Test_parseJumpEntry(t *testing.T) {
+	if key, ok := parseJumpEntry(jumpEntry("Ñ").Text); !ok || key != "Ñ" {
+		t.Errorf("expected key 'Ñ', got '%s' %v", key, ok)
+	}
+	if _, ok := parseJumpEntry("1. — A —"); ok {
+		t.Errorf("expected bookmark entry to not be a jump entry")
+	}
+}
+
+func Test_HandleBookmarksShow_IndexMode(t *testing.T) {
+	in := initInputHandler(t)
+	in.cfg.IndexMode = true
+	in.HandleBookmarksShow()
+
+	expectedTexts := []string{
+		"— D —",
+		"— M —",
+		"4. https://www.d.com",
+		"1. metadata (title) google",
+		"2. metadata (title) b",
+		"3. metadata (title) c",
+	}
+	if len(in.api.Entries) != len(expectedTexts) {
+		t.Fatalf("expected %d entries, got %d", len(expectedTexts), len(in.api.Entries))
+	}
+	for i, e := range in.api.Entries {
+		if e.Text != expectedTexts[i] {
+			t.Errorf("expected entry '%s' at %d, got '%s'", expectedTexts[i], i, e.Text)
+		}
+	}
+
+	// selecting a jump entry highlights the first bookmark of its group
+	in.handleBookmarksSelect("— M —", rofiapi.StateSelected)
+	checkState(t, StateBookmarksSelect, in.api.Data.State)
+	if in.api.Options[rofiapi.OptionNewSelection] != "3" {
+		t.Errorf("expected new selection '3', got '%s'", in.api.Options[rofiapi.OptionNewSelection])
+	}
+
+	// hotkeys on a jump entry need a bookmark
+	in = initInputHandler(t)
+	in.cfg.IndexMode = true
+	in.handleBookmarksSelect("— M —", rofiapi.StateCustomKeybinding2)
+	checkState(t, StateBookmarksSelect, in.api.Data.State)
+	if _, ok := in.api.Options[rofiapi.OptionNewSelection]; ok {
+		t.Errorf("expected no new selection")
+	}
+}
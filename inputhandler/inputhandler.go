@@ -2,56 +2,136 @@
 package inputhandler
 
 import (
+	"context"
+	"errors"
 	"fmt"
+	"io"
 	"log"
-	"net/url"
-	"os"
+	"math/rand/v2"
 	"os/exec"
 	"slices"
-	"sort"
 	"strconv"
 	"strings"
+	"time"
+	"unicode"
+	"unicode/utf8"
 
 	"github.com/VannRR/robuku/bukudb"
+	"github.com/VannRR/robuku/config"
+	"github.com/VannRR/robuku/maintenance"
 	rofiapi "github.com/VannRR/rofi-api"
 )
 
-const robukuBrowserEnvVar = "ROBUKU_BROWSER"
 const entryMaxLen = 100
 
+// deadLinkPrefix marks bookmarks the dead link checker found dead, it goes
+// after the ID so entries still start with it.
+const deadLinkPrefix = "✗ "
+const commentPreviewLines = 4
+
+// errNoTags is shown when the tags input contains nothing but commas and
+// whitespace.
+var errNoTags = errors.New("no tags entered")
+
+// errNoBookmarkSelected is shown when an action needing a bookmark is used
+// while no entry is highlighted, e.g. when the filter matches nothing.
+var errNoBookmarkSelected = errors.New("no bookmark selected")
+
+// maxUndoLen is the maximum length in bytes of a value kept for undo, so
+// the serialized Data stays below the size rofi-api allows.
+const maxUndoLen = 1024
+
+// State is the screen robuku shows next. It is saved between runs by its
+// number, so a State keeps its value: add new states at the end with the
+// next free number and never reuse one.
 type State byte
 
 const (
-	StateNull                State = iota // 0
-	StateErrorShow                        // 1
-	StateErrorSelect                      // 2
-	StateBookmarksShow                    // 3
-	StateBookmarksSelect                  // 4
-	StateAddShow                          // 5
-	StateAddSelect                        // 6
-	StateAddTitleShow                     // 7
-	StateAddTitleSelect                   // 8
-	StateAddUrlShow                       // 9
-	StateAddUrlSelect                     // 10
-	StateAddCommentShow                   // 11
-	StateAddCommentSelect                 // 12
-	StateAddTagsShow                      // 13
-	StateAddTagsSelect                    // 14
-	StateGotoExec                         // 15
-	StateModifyShow                       // 16
-	StateModifySelect                     // 17
-	StateModifyTitleShow                  // 18
-	StateModifyTitleSelect                // 19
-	StateModifyUrlShow                    // 20
-	StateModifyUrlSelect                  // 21
-	StateModifyCommentShow                // 22
-	StateModifyCommentSelect              // 23
-	StateModifyTagsShow                   // 24
-	StateModifyTagsSelect                 // 25
-	StateDeleteConfirmShow                // 26
-	StateDeleteConfirmSelect              // 27
+	StateNull                State = 0
+	StateErrorShow           State = 1
+	StateErrorSelect         State = 2
+	StateBookmarksShow       State = 3
+	StateBookmarksSelect     State = 4
+	StateAddShow             State = 5
+	StateAddSelect           State = 6
+	StateAddTitleShow        State = 7
+	StateAddTitleSelect      State = 8
+	StateAddUrlShow          State = 9
+	StateAddUrlSelect        State = 10
+	StateAddCommentShow      State = 11
+	StateAddCommentSelect    State = 12
+	StateAddTagsShow         State = 13
+	StateAddTagsSelect       State = 14
+	StateGotoExec            State = 15
+	StateModifyShow          State = 16
+	StateModifySelect        State = 17
+	StateModifyTitleShow     State = 18
+	StateModifyTitleSelect   State = 19
+	StateModifyUrlShow       State = 20
+	StateModifyUrlSelect     State = 21
+	StateModifyCommentShow   State = 22
+	StateModifyCommentSelect State = 23
+	StateModifyTagsShow      State = 24
+	StateModifyTagsSelect    State = 25
+	StateDeleteConfirmShow   State = 26
+	StateDeleteConfirmSelect State = 27
+	StateStatsShow           State = 28
+	StateStatsSelect         State = 29
+	StateInitSchemaSelect    State = 30
+	StateGotoConfirmShow     State = 31
+	StateGotoConfirmSelect   State = 32
+	StateExportShow          State = 33
+	StateExportSelect        State = 34
+	StateTrashShow           State = 35
+	StateTrashSelect         State = 36
+	StateTrashEmptyShow      State = 37
+	StateTrashEmptySelect    State = 38
+	StateFilterShow          State = 39
+	StateFilterSelect        State = 40
+	StateTitlesShow          State = 41
+	StateTitlesSelect        State = 42
+	StateTitleEnterShow      State = 43
+	StateTitleEnterSelect    State = 44
+	StateClearTagsShow       State = 45
+	StateClearTagsSelect     State = 46
+	StateHelpShow            State = 47
+	StateHelpSelect          State = 48
+	StateExportPathShow      State = 49
+	StateExportPathSelect    State = 50
+	StateOverwriteShow       State = 51
+	StateOverwriteSelect     State = 52
+	StateImportPreviewShow   State = 53
+	StateImportPreviewSelect State = 54
+	StateAppendNoteShow      State = 55
+	StateAppendNoteSelect    State = 56
+	StateDiscardShow         State = 57
+	StateDiscardSelect       State = 58
+	StateResetVisitsShow     State = 59
+	StateResetVisitsSelect   State = 60
+	StateTagsShow            State = 61
+	StateTagsSelect          State = 62
+	StateDuplicateURLShow    State = 63
+	StateDuplicateURLSelect  State = 64
+	StateDeleteMultiShow     State = 65
+	StateDeleteMultiSelect   State = 66
+	StateConflictShow        State = 67
+	StateConflictSelect      State = 68
+	StateGotoChooseShow      State = 69
+	StateGotoChooseSelect    State = 70
+	StateAliasesShow         State = 71
+	StateAliasesSelect       State = 72
+	StateInboxShow           State = 73
+	StateInboxSelect         State = 74
+	StateInboxTagsShow       State = 75
+	StateInboxTagsSelect     State = 76
+	StateAddDuplicateShow    State = 77
+	StateAddDuplicateSelect  State = 78
 )
 
+// stateLast is the highest State this version knows, higher states were
+// saved by a newer robuku.
+const stateLast = StateAddDuplicateSelect
+
 const (
 	opAdd     string = "--> Add"
 	opExit    string = "--> Exit"
@@ -59,34 +139,190 @@ const (
 	opConfirm string = "--> Confirm"
 	opModify  string = "--> Modify"
 	opDelete  string = "--> Delete"
+
+	opInitSchema string = "--> Initialize buku database"
+	opUndo       string = "↶ Undo last edit"
+	opOpen       string = "--> Open"
+	opExportAs   string = "--> Export as "
+	opExportList string = "--> Export these"
+	opEmptyTrash string = "--> Empty trash"
+	opMoreFrom   string = "--> More from "
+	opAppendNote string = "--> Append note"
 )
 
 type Data struct {
 	Bookmark bukudb.Bookmark
 	State    State
+	Undo     Undo
+
+	// ResultIDs are the IDs of the bookmarks to export.
+	ResultIDs []uint16
+
+	// Export is the chosen format and path of the export.
+	Export ExportTarget
+
+	// Stay keeps rofi open after opening Bookmark.
+	Stay bool
+
+	// Filters are the filter layers of the bookmark list, the last one is
+	// active and Back removes it.
+	Filters []Filter
+
+	// TitleCleanup is the progress of the title assistant.
+	TitleCleanup TitleCleanup
+
+	// Import is the import waiting for confirmation.
+	Import PendingImport
+
+	// Pending are the fields of Bookmark edited but not yet written, with
+	// $ROBUKU_MODIFY_APPLY=on_confirm.
+	Pending bukudb.FieldMask
+
+	// TagParent is the parent tag expanded on the tag browser, empty for
+	// the top level.
+	TagParent string
+
+	// Duplicate is the ID of the bookmark that already has the URL entered
+	// on the modify screen, or of the bookmark being added.
+	Duplicate uint16
+
+	// DeleteIDs are the IDs typed on the delete confirm screen, waiting for
+	// the second confirmation.
+	DeleteIDs []uint16
+
+	// Conflict is the edit held back because the field changed in the
+	// database since it was shown.
+	Conflict Conflict
+
+	// Suggested is the URL whose suggested tags were added to the bookmark
+	// being added.
+	Suggested string
+
+	// RevealHidden lists the bookmarks with hidden tags for the rest of the
+	// session.
+	RevealHidden bool
+
+	// Profile is the name of the active profile, empty if none is.
+	Profile string
+
+	// AltBrowser opens Bookmark with the alternative browser.
+	AltBrowser bool
+
+	// Recent lists the most recently added bookmarks instead of Filters.
+	Recent bool
+
+	// OpenURL is the alias of Bookmark chosen to open, empty to open its
+	// own URL.
+	OpenURL string
+
+	// Inbox is the progress of the inbox triage.
+	Inbox Inbox
+
+	// Deleted is the trash ID of the bookmark deleted last with
+	// $ROBUKU_FAST_DELETE=1, 0 when there is nothing to undo.
+	Deleted int64
+
+	// Source is where the bookmark being added came from, one of the
+	// Source constants.
+	Source string
+}
+
+// Undo holds the previous value of the last edited field of a bookmark.
+type Undo struct {
+	// BookmarkID of the edited bookmark, 0 when there is nothing to undo.
+	BookmarkID uint16
+
+	// Field that was edited.
+	Field bookmarkField
+
+	// Value is the previous title, url, or comment.
+	Value string
+
+	// Tags are the previous tags.
+	Tags []string
 }
 
 // InputHandler is the struct that handles input from rofi and manages app state
 type InputHandler struct {
-	db      bukudb.DBInterface
-	api     *rofiapi.RofiApi[Data]
-	browser string
+	db  bukudb.DBInterface
+	api *rofiapi.RofiApi[Data]
+	cfg config.Config
+	now func() time.Time
+
+	// randN returns a random number in [0, n), for :random.
+	randN func(n int) int
+
+	// fetchTitle fetches the title of a page for the title assistant.
+	fetchTitle func(ctx context.Context, url string) (string, error)
+
+	// upgradeHTTPS moves the http bookmarks of db to https where the https
+	// variant answers.
+	upgradeHTTPS func(ctx context.Context, db bukudb.DBInterface) (maintenance.HTTPSSummary, error)
+
+	// writeExport writes the bookmarks of an export to its file.
+	writeExport func(target ExportTarget, bookmarks []bukudb.Bookmark, overwrite bool) error
+
+	// notices are shown above the message once the input has been handled.
+	notices []string
+
+	// jumpTo is the index group to highlight when the bookmarks are shown.
+	jumpTo string
+
+	// render builds the entries of the bookmark list.
+	render entryRenderer
+
+	// style is the markup of the message box.
+	style markupStyle
 }
 
 // NewInputHandler returns a new instance of the InputHandler struct
 func NewInputHandler(db bukudb.DBInterface, api *rofiapi.RofiApi[Data]) *InputHandler {
+	return NewInputHandlerWithConfig(db, api, config.Load())
+}
+
+// NewInputHandlerWithConfig returns a new InputHandler using cfg, like the
+// settings of the active profile, instead of loading them.
+func NewInputHandlerWithConfig(db bukudb.DBInterface, api *rofiapi.RofiApi[Data],
+	cfg config.Config) *InputHandler {
 	in := InputHandler{
-		db:      db,
-		api:     api,
-		browser: os.Getenv(robukuBrowserEnvVar),
+		db:  db,
+		api: api,
+		cfg: cfg,
+		now: time.Now,
+
+		randN:        rand.IntN,
+		fetchTitle:   fetchPageTitle,
+		upgradeHTTPS: maintenance.NewHTTPSUpgrader(io.Discard).Run,
+		writeExport:  writeExport,
+
+		render: entryRenderer{sigil: cfg.TagSigil},
+		style:  newMarkupStyle(cfg),
+	}
+	if in.cfg.DryRun {
+		in.db = bukudb.NewDryRunDB(db, in.addNotice)
+	} else if in.cfg.ReadOnly {
+		in.db = bukudb.NewReadOnlyDB(db, in.addNotice)
 	}
+	in.disableEntryMarkup()
 	return &in
 }
 
 // HandleInput takes the selected rofi entry/input and processes it based on app state
 func (in *InputHandler) HandleInput(input string) {
+	defer in.recoverPanic()
+	in.handleInput(input, in.api.GetState())
+}
+
+// handleInput handles input selected in rofiState.
+func (in *InputHandler) handleInput(input string, rofiState rofiapi.State) {
 	input = strings.TrimSpace(input)
-	rofiState := in.api.GetState()
+
+	if in.api.Data.State > stateLast {
+		in.resetUnknownState()
+	} else if isStale(in.api.Data.State, rofiState, input) {
+		log.Printf("stale state %s without a selection, showing bookmarks", in.api.Data.State)
+		in.api.Data = Data{State: StateBookmarksShow}
+	}
 
 	switch in.api.Data.State {
 	case StateBookmarksShow:
@@ -137,81 +373,385 @@ func (in *InputHandler) HandleInput(input string) {
 		in.handleDeleteConfirmShow()
 	case StateDeleteConfirmSelect:
 		in.handleDeleteConfirmSelect(input)
+	case StateStatsShow:
+		in.handleStatsShow()
+	case StateStatsSelect:
+		in.handleStatsSelect(input)
+	case StateGotoConfirmShow:
+		in.handleGotoConfirmShow()
+	case StateGotoConfirmSelect:
+		in.handleGotoConfirmSelect(input)
+	case StateExportShow:
+		in.handleExportShow()
+	case StateExportSelect:
+		in.handleExportSelect(input)
+	case StateExportPathShow:
+		in.handleExportPathShow()
+	case StateExportPathSelect:
+		in.handleExportPathSelect(input)
+	case StateOverwriteShow:
+		in.handleOverwriteShow()
+	case StateOverwriteSelect:
+		in.handleOverwriteSelect(input)
+	case StateTrashShow:
+		in.handleTrashShow()
+	case StateTrashSelect:
+		in.handleTrashSelect(input)
+	case StateTrashEmptyShow:
+		in.handleTrashEmptyShow()
+	case StateTrashEmptySelect:
+		in.handleTrashEmptySelect(input)
+	case StateFilterShow:
+		in.handleFilterShow()
+	case StateFilterSelect:
+		in.handleFilterSelect(input, rofiState)
+	case StateTitlesShow:
+		in.handleTitlesShow()
+	case StateTitlesSelect:
+		in.handleTitlesSelect(input)
+	case StateTitleEnterShow:
+		in.handleTitleEnterShow()
+	case StateTitleEnterSelect:
+		in.handleTitleEnterSelect(input)
+	case StateClearTagsShow:
+		in.handleClearTagsShow()
+	case StateClearTagsSelect:
+		in.handleClearTagsSelect(input)
+	case StateHelpShow:
+		in.handleHelpShow()
+	case StateHelpSelect:
+		in.handleHelpSelect(input)
+	case StateImportPreviewShow:
+		in.handleImportPreviewShow()
+	case StateImportPreviewSelect:
+		in.handleImportPreviewSelect(input)
+	case StateAppendNoteShow:
+		in.handleAppendNoteShow()
+	case StateAppendNoteSelect:
+		in.handleAppendNoteSelect(input)
+	case StateDiscardShow:
+		in.handleDiscardShow()
+	case StateDiscardSelect:
+		in.handleDiscardSelect(input)
+	case StateResetVisitsShow:
+		in.handleResetVisitsShow()
+	case StateResetVisitsSelect:
+		in.handleResetVisitsSelect(input)
+	case StateTagsShow:
+		in.handleTagsShow()
+	case StateTagsSelect:
+		in.handleTagsSelect(input)
+	case StateDuplicateURLShow:
+		in.handleDuplicateURLShow()
+	case StateDuplicateURLSelect:
+		in.handleDuplicateURLSelect(input)
+	case StateDeleteMultiShow:
+		in.handleDeleteMultiShow()
+	case StateDeleteMultiSelect:
+		in.handleDeleteMultiSelect(input)
+	case StateConflictShow:
+		in.handleConflictShow()
+	case StateConflictSelect:
+		in.handleConflictSelect(input)
+	case StateGotoChooseShow:
+		in.handleGotoChooseShow()
+	case StateGotoChooseSelect:
+		in.handleGotoChooseSelect(input)
+	case StateAliasesShow:
+		in.handleAliasesShow()
+	case StateAliasesSelect:
+		in.handleAliasesSelect(input)
+	case StateInboxShow:
+		in.handleInboxShow()
+	case StateInboxSelect:
+		in.handleInboxSelect(input)
+	case StateInboxTagsShow:
+		in.handleInboxTagsShow()
+	case StateInboxTagsSelect:
+		in.handleInboxTagsSelect(input)
+	case StateAddDuplicateShow:
+		in.handleAddDuplicateShow()
+	case StateAddDuplicateSelect:
+		in.handleAddDuplicateSelect(input)
 	default:
-		log.Printf("Unhandled state: %v", in.api.Data.State)
+		in.resetUnknownState()
+		in.HandleBookmarksShow()
+	}
+
+	in.showNotices()
+	in.setListLines()
+}
+
+// resetUnknownState goes back to the bookmark list when the saved state is
+// not handled, telling the user why.
+func (in *InputHandler) resetUnknownState() {
+	state := in.api.Data.State
+	log.Printf("unknown state %s, showing bookmarks", state)
+	in.api.Data = Data{State: StateBookmarksShow}
+	in.addNotice(fmt.Sprintf("state reset (unknown state %s)", state))
+}
+
+// isStale reports whether state waits for a selection that rofi did not
+// make, e.g. when a previous run crashed after saving the state but before
+// drawing its entries. The bookmark list handles an empty selection itself.
+func isStale(state State, rofiState rofiapi.State, input string) bool {
+	if input != "" || !state.isSelect() || state == StateBookmarksSelect {
+		return false
+	}
+	return rofiState == rofiapi.StateInit || rofiState == rofiapi.StateSelected
+}
+
+// isSelect reports whether s handles a selection from rofi.
+func (s State) isSelect() bool {
+	switch s {
+	case StateErrorSelect, StateBookmarksSelect, StateAddSelect, StateAddTitleSelect,
+		StateAddUrlSelect, StateAddCommentSelect, StateAddTagsSelect, StateModifySelect,
+		StateModifyTitleSelect, StateModifyUrlSelect, StateModifyCommentSelect,
+		StateModifyTagsSelect, StateDeleteConfirmSelect, StateStatsSelect,
+		StateInitSchemaSelect, StateGotoConfirmSelect, StateExportSelect, StateTrashSelect,
+		StateTrashEmptySelect, StateFilterSelect, StateTitlesSelect, StateTitleEnterSelect,
+		StateClearTagsSelect, StateHelpSelect, StateExportPathSelect, StateOverwriteSelect,
+		StateImportPreviewSelect, StateAppendNoteSelect, StateDiscardSelect,
+		StateResetVisitsSelect, StateTagsSelect, StateDuplicateURLSelect,
+		StateDeleteMultiSelect, StateConflictSelect, StateGotoChooseSelect,
+		StateAliasesSelect, StateInboxSelect, StateInboxTagsSelect,
+		StateAddDuplicateSelect:
+		return true
 	}
+	return false
 }
 
 // HandleBookmarksShow sets rofi's initial state and shows all bookmarks
 func (in *InputHandler) HandleBookmarksShow() {
-	in.api.Options[rofiapi.OptionMessage] = generatePangoMarkup(
-		"add: Alt+1 | modify: Alt+2 | delete: Alt+3", "", "")
-	in.api.Options[rofiapi.OptionNoCustom] = "true"
+	// custom input runs prompt commands like ":help"
+	in.api.Options[rofiapi.OptionNoCustom] = "false"
 	in.api.Options[rofiapi.OptionUseHotKeys] = "true"
 
-	numPadding := len(fmt.Sprint(bukudb.MaxBookmarks))
-	allBookmarks, err := in.db.GetAll()
+	tm := in.startTimings("HandleBookmarksShow")
+	recent := in.api.Data.Recent
+	filter := in.activeFilter()
+	if recent {
+		filter = Filter{}
+	}
+	hidden := in.hiddenTags(filter)
+	allBookmarks, err := in.listedBookmarks(filter, hidden)
 	if err != nil {
-		SetMessageToError(in.api, err)
+		in.setMessageToError(err)
 		return
 	}
+	statuses, err := in.db.GetLinkStatuses()
+	if err != nil {
+		log.Println("ERROR", err)
+	}
+	aliases, err := in.db.GetAllAliases()
+	if err != nil {
+		log.Println("ERROR", err)
+	}
+	tm.lap("db")
+
 	entries := make([]rofiapi.Entry, 0, in.db.Len())
-	for _, b := range allBookmarks {
-		id := fmt.Sprint(b.ID)
-		for j := len(id); j < numPadding; j++ {
-			id = "0" + id
+	if !filter.isEmpty() || recent {
+		entries = append(entries, rofiapi.Entry{Text: opBack})
+		if len(allBookmarks) > 0 {
+			entries = append(entries, rofiapi.Entry{Text: opExportList})
+		}
+	}
+	if in.cfg.IndexMode && !recent {
+		var groups []indexGroup
+		allBookmarks, groups = groupByIndex(allBookmarks)
+		for _, g := range groups {
+			entries = append(entries, jumpEntry(g.key))
 		}
+		in.selectGroup(groups, len(entries))
+	}
+	allBookmarks, overflow, capped := capBookmarks(allBookmarks, in.cfg.MaxEntries, in.cfg.IndexMode)
+	in.selectLast(allBookmarks, len(entries))
+	entries = in.appendBookmarkEntries(entries, allBookmarks, statuses, aliases, filter.Comment)
+	if capped {
+		entries = append(entries, overflow)
+	}
 
-		text := b.Title
-		meta := strings.Join(b.Tags, " ")
+	in.api.Options[rofiapi.OptionMessage] = in.style.generatePangoMarkup(
+		in.hotkeyHints(len(allBookmarks) > 0), "", "")
+	in.api.Entries = entries
+	in.api.Data.State = StateBookmarksSelect
+	in.api.Data.Bookmark = bukudb.Bookmark{}
+	in.api.Data.Pending = 0
+	in.api.Data.Suggested = ""
+	if line := in.hiddenLine(hidden); line != "" {
+		in.prependMessage(in.style.spanMarkup(in.style.current, line))
+	}
+	if recent {
+		in.prependMessage(in.style.spanMarkup(in.style.current, recentLine))
+	} else if !filter.isEmpty() {
+		in.prependMessage(in.style.spanMarkup(in.style.current, filter.String()))
+	}
+	if warning := limitWarning(in.db.Len(), in.cfg.MaxBookmarks); warning != "" {
+		in.prependMessage(in.style.warningMarkup(warning))
+	}
+	tm.lap("render")
+	tm.appendTo(in.api, in.style)
+}
+
+// listedBookmarks returns the recent bookmarks or those matching filter,
+// without the ones tagged with a hidden tag.
+func (in *InputHandler) listedBookmarks(filter Filter, hidden []string) ([]bukudb.Bookmark, error) {
+	return watchdog(in.cfg.DBTimeout, func(ctx context.Context) ([]bukudb.Bookmark, error) {
+		if in.api.Data.Recent {
+			return in.db.GetRecent(in.cfg.Recent, hidden)
+		}
+		return applyFilters(ctx, in.db, hideTags(filter, hidden))
+	})
+}
 
+// appendBookmarkEntries appends the list entries of bookmarks to entries,
+// with the snippet of their comment matching comment if it is not empty.
+// Their aliases are searched like their URL.
+func (in *InputHandler) appendBookmarkEntries(entries []rofiapi.Entry, bookmarks []bukudb.Bookmark,
+	statuses map[uint16]bukudb.LinkStatus, aliases map[uint16][]string, comment string) []rofiapi.Entry {
+	width := idWidth(bookmarks)
+	entries = slices.Grow(entries, len(bookmarks))
+	for _, b := range bookmarks {
+		text := b.Title
 		if b.Title == "" {
 			text = b.URL
-		} else {
-			if meta != "" {
-				meta += " " + cleanURL(b.URL)
+		}
+		if comment != "" {
+			text = snippetTitle(text, b.Comment, comment)
+		}
+		entry := rofiapi.Entry{Meta: in.render.meta(b, aliases[b.ID]...)}
+		prefix := ""
+		if statuses[b.ID].Dead {
+			if in.cfg.RowPrefixes {
+				prefix = deadLinkPrefix
 			} else {
-				meta = cleanURL(b.URL)
+				entry.Urgent = true
 			}
 		}
+		entry.Text = in.render.text(width, b.ID, prefix, text)
 
-		entries = append(entries, rofiapi.Entry{
-			Text: formatEntryText(fmt.Sprintf("%s. %s", id, text)),
-			Meta: meta,
-		})
+		entries = append(entries, entry)
 	}
-
-	in.api.Entries = entries
-	in.api.Data.State = StateBookmarksSelect
-	in.api.Data.Bookmark = bukudb.Bookmark{}
+	return entries
 }
 
 func (in *InputHandler) handleBookmarksSelect(input string, rofiState rofiapi.State) {
-	if rofiState == rofiapi.StateCustomKeybinding1 {
+	switch rofiState {
+	case rofiapi.StateCustomKeybinding1:
+		in.api.Data.Source = SourceRofi
 		in.handleAddShow()
 		return
+	case rofiapi.StateCustomKeybinding4:
+		in.handleStatsShow()
+		return
+	case rofiapi.StateCustomKeybinding8:
+		in.handleTrashShow()
+		return
+	case rofiapi.StateCustomKeybinding9:
+		in.handleFilterShow()
+		return
+	case rofiapi.StateCustomKeybinding10:
+		in.handleHelpShow()
+		return
+	case rofiapi.StateCustomKeybinding11:
+		in.api.Data.RevealHidden = !in.api.Data.RevealHidden
+		in.HandleBookmarksShow()
+		return
+	case rofiapi.StateCustomKeybinding13:
+		in.toggleRecent()
+		return
+	case rofiapi.StateCustomKeybinding14:
+		if in.cfg.Inbox {
+			in.startInbox()
+		} else {
+			in.HandleBookmarksShow()
+		}
+		return
+	case rofiapi.StateCustomKeybinding15:
+		in.undoFastDelete()
+		return
+	}
+
+	if rofiState == rofiapi.StateSelectedCustom {
+		in.handleCustomInput(input)
+		return
+	}
+
+	if input == opBack && rofiState == rofiapi.StateSelected {
+		if in.api.Data.Recent {
+			in.api.Data.Recent = false
+		} else {
+			in.popFilter()
+		}
+		in.HandleBookmarksShow()
+		return
+	}
+
+	if input == opExportList && rofiState == rofiapi.StateSelected {
+		in.exportListed()
+		return
+	}
+
+	if key, ok := parseJumpEntry(input); ok && in.cfg.IndexMode {
+		if rofiState == rofiapi.StateSelected {
+			in.jumpTo = key
+			in.HandleBookmarksShow()
+			return
+		}
+		input = ""
+	}
+
+	if input == "" {
+		in.showWithError(in.HandleBookmarksShow, errNoBookmarkSelected)
+		return
 	}
 
 	id, err := getIdFromBookmarkString(input)
 	if err != nil {
-		SetMessageToError(in.api, err)
+		in.setMessageToError(err)
 		return
 	}
 
 	b, err := in.db.Get(id)
 	if err != nil {
-		SetMessageToError(in.api, err)
+		in.setMessageToError(err)
 		return
 	}
 
 	in.api.Data.Bookmark = b
+	if in.api.Data.Undo.BookmarkID != b.ID {
+		in.api.Data.Undo = Undo{}
+	}
 
 	switch rofiState {
 	case rofiapi.StateCustomKeybinding2:
 		in.handleModifyShow()
 	case rofiapi.StateCustomKeybinding3:
-		in.handleDeleteConfirmShow()
+		if in.cfg.FastDelete {
+			in.fastDelete()
+		} else if needsConfirm(in.cfg.Confirm, confirmDelete) {
+			in.handleDeleteConfirmShow()
+		} else {
+			in.deleteBookmark()
+		}
+	case rofiapi.StateCustomKeybinding5:
+		in.handleCopyBukuCommand()
+	case rofiapi.StateCustomKeybinding6:
+		in.api.Data.ResultIDs = []uint16{b.ID}
+		in.handleExportShow()
+	case rofiapi.StateCustomKeybinding7:
+		in.api.Data.Stay = true
+		in.handleGotoExec()
+	case rofiapi.StateCustomKeybinding12:
+		if !in.hasAltBrowser() {
+			in.HandleBookmarksShow()
+			return
+		}
+		in.api.Data.Stay = false
+		in.api.Data.AltBrowser = true
+		in.handleGotoExec()
 	case rofiapi.StateSelected:
+		in.api.Data.Stay = false
 		in.handleGotoExec()
 	default:
 		in.HandleBookmarksShow()
@@ -219,25 +759,35 @@ func (in *InputHandler) handleBookmarksSelect(input string, rofiState rofiapi.St
 }
 
 func (in *InputHandler) handleAddShow() {
-	in.api.Options[rofiapi.OptionMessage] = generatePangoMarkup(
+	in.api.Options[rofiapi.OptionMessage] = in.style.generatePangoMarkup(
 		"select a field to add, all are optional except the url", "", "")
 	in.api.Options[rofiapi.OptionNoCustom] = "true"
 	in.api.Options[rofiapi.OptionUseHotKeys] = "false"
 
+	tm := in.startTimings("handleAddShow")
 	b := in.api.Data.Bookmark
 	b.ID = uint16(in.db.Len() + 1)
+	tm.lap("db")
 	entries := []rofiapi.Entry{{Text: opBack}}
 	bookmark := multiLineBookmark(b)
 	for _, l := range bookmark {
-		entries = append(entries, rofiapi.Entry{Text: l})
+		entries = append(entries, in.bookmarkLineEntry(l))
 	}
 	entries = append(entries, rofiapi.Entry{Text: opConfirm})
+	in.useRichEntries(entries)
 	in.api.Entries = entries
 
 	in.api.Data.State = StateAddSelect
+	tm.lap("render")
+	tm.appendTo(in.api, in.style)
 }
 
 func (in *InputHandler) handleAddSelect(input string) {
+	if len(input) < 1 {
+		in.handleAddShow()
+		return
+	}
+
 	if input == opBack {
 		in.HandleBookmarksShow()
 		return
@@ -245,26 +795,45 @@ func (in *InputHandler) handleAddSelect(input string) {
 
 	if input == opConfirm {
 		if in.api.Data.Bookmark.URL == "" {
-			SetMessageToError(in.api, fmt.Errorf("error: bookmark has no url"))
+			in.setMessageToError(fmt.Errorf("error: bookmark has no url"))
+			return
+		}
+		// the tags are added to a copy, the draft stays as typed for a
+		// duplicate or back to edit
+		b := in.api.Data.Bookmark
+		if in.cfg.AutoTags && in.api.Data.Suggested != b.URL {
+			b.Tags = withTags(b.Tags, in.suggestedTags())
+		}
+		in.tagInbox(&b)
+		in.tagSource(&b)
+		before := in.db.Len()
+		err := in.db.Add(b)
+		var dup *bukudb.DuplicateURLError
+		if errors.As(err, &dup) && dup.ID != 0 {
+			in.api.Data.Duplicate = dup.ID
+			in.handleAddDuplicateShow()
 			return
 		}
-		err := in.db.Add(in.api.Data.Bookmark)
 		if err != nil {
-			SetMessageToError(in.api, err)
+			in.setMessageToError(explainLimit(err))
 			return
 		}
-		in.HandleBookmarksShow()
+		in.api.Data.Source = ""
+		in.showAdded(b, before)
 		return
 	}
 
-	switch input[0] {
-	case '0', '1', '2', '3', '4', '5', '6', '7', '8', '9':
+	b := in.api.Data.Bookmark
+	b.ID = uint16(in.db.Len() + 1)
+
+	switch in.selectedField(b, input) {
+	case fieldTitle:
 		in.handleAddTitleShow()
-	case '>':
+	case fieldURL:
 		in.handleAddUrlShow()
-	case '+':
+	case fieldComment:
 		in.handleAddCommentShow()
-	case '#':
+	case fieldTags:
 		in.handleAddTagsShow()
 	default:
 		in.handleAddShow()
@@ -272,7 +841,7 @@ func (in *InputHandler) handleAddSelect(input string) {
 }
 
 func (in *InputHandler) handleAddTitleShow() {
-	in.api.Options[rofiapi.OptionMessage] = generatePangoMarkup("enter a title", "", "")
+	in.api.Options[rofiapi.OptionMessage] = in.style.generatePangoMarkup("enter a title", "", "")
 	in.api.Options[rofiapi.OptionNoCustom] = "false"
 	in.api.Options[rofiapi.OptionUseHotKeys] = "false"
 
@@ -285,19 +854,24 @@ func (in *InputHandler) handleAddTitleShow() {
 }
 
 func (in *InputHandler) handleAddTitleSelect(input string) {
+	if err := checkLength(input, in.cfg.MaxTitleLen); err != nil {
+		in.showWithError(in.handleAddTitleShow, err)
+		return
+	}
+
 	switch input {
 	case opBack:
 		break
 	case opDelete:
 		in.api.Data.Bookmark.Title = ""
 	default:
-		in.api.Data.Bookmark.Title = input
+		in.api.Data.Bookmark.Title = in.cleanTitle(input)
 	}
 	in.handleAddShow()
 }
 
 func (in *InputHandler) handleAddUrlShow() {
-	in.api.Options[rofiapi.OptionMessage] = generatePangoMarkup("enter a url", "", "")
+	in.api.Options[rofiapi.OptionMessage] = in.style.generatePangoMarkup("enter a url", "", "")
 	in.api.Options[rofiapi.OptionNoCustom] = "false"
 	in.api.Options[rofiapi.OptionUseHotKeys] = "false"
 
@@ -310,6 +884,11 @@ func (in *InputHandler) handleAddUrlShow() {
 }
 
 func (in *InputHandler) handleAddUrlSelect(input string) {
+	if err := checkLength(input, in.cfg.MaxURLLen); err != nil {
+		in.showWithError(in.handleAddUrlShow, err)
+		return
+	}
+
 	switch input {
 	case opBack:
 		break
@@ -322,19 +901,25 @@ func (in *InputHandler) handleAddUrlSelect(input string) {
 }
 
 func (in *InputHandler) handleAddCommentShow() {
-	in.api.Options[rofiapi.OptionMessage] = generatePangoMarkup("enter a comment", "", "")
+	in.api.Options[rofiapi.OptionMessage] = in.style.generatePangoMarkup("enter a comment", "", "")
 	in.api.Options[rofiapi.OptionNoCustom] = "false"
 	in.api.Options[rofiapi.OptionUseHotKeys] = "false"
 
-	in.api.Entries = []rofiapi.Entry{
-		{Text: opBack},
-		{Text: opDelete},
-	}
+	in.api.Entries = in.commentEntries()
 
 	in.api.Data.State = StateAddCommentSelect
 }
 
 func (in *InputHandler) handleAddCommentSelect(input string) {
+	if err := checkLength(input, in.cfg.MaxCommentLen); err != nil {
+		in.showWithError(in.handleAddCommentShow, err)
+		return
+	}
+
+	if snippet, ok := in.snippetFromInput(input); ok {
+		input = snippet
+	}
+
 	switch input {
 	case opBack:
 		break
@@ -346,73 +931,246 @@ func (in *InputHandler) handleAddCommentSelect(input string) {
 	in.handleAddShow()
 }
 
+// handleAddTagsShow asks for the tags, the tags suggested for the URL are
+// added on the first visit and listed to toggle them.
 func (in *InputHandler) handleAddTagsShow() {
-	in.api.Options[rofiapi.OptionMessage] = generatePangoMarkup(
-		"enter some tags", "'mytag, some-tag, a tag'", "")
+	in.applySuggestedTags()
+	in.api.Options[rofiapi.OptionMessage] = in.style.generatePangoMarkup(
+		"enter some tags", tagsExample(in.cfg.TagSplit), "")
 	in.api.Options[rofiapi.OptionNoCustom] = "false"
 	in.api.Options[rofiapi.OptionUseHotKeys] = "false"
 
-	in.api.Entries = []rofiapi.Entry{
+	in.api.Entries = append([]rofiapi.Entry{
 		{Text: opBack},
 		{Text: opDelete},
-	}
+	}, in.suggestionEntries()...)
 
 	in.api.Data.State = StateAddTagsSelect
 }
 
 func (in *InputHandler) handleAddTagsSelect(input string) {
+	if in.toggleSuggestedTag(input) {
+		in.handleAddTagsShow()
+		return
+	}
+
 	switch input {
 	case opBack:
 		break
 	case opDelete:
 		in.api.Data.Bookmark.Tags = []string{}
 	default:
-		tags := strings.Split(input, ",")
-		for i, t := range tags {
-			tags[i] = strings.TrimSpace(t)
+		tags := getTagsFromInput(input, in.cfg.TagSplit)
+		if len(tags) == 0 {
+			in.showWithError(in.handleAddTagsShow, errNoTags)
+			return
 		}
-		in.api.Data.Bookmark.Tags = tags
-
-		sort.Slice(in.api.Data.Bookmark.Tags, func(i, j int) bool {
-			return strings.ToLower(in.api.Data.Bookmark.Tags[i]) <
-				strings.ToLower(in.api.Data.Bookmark.Tags[j])
-		})
+		if err := checkTagsLength(tags, in.cfg.MaxTagLen); err != nil {
+			in.showWithError(in.handleAddTagsShow, err)
+			return
+		}
+		in.api.Data.Bookmark.Tags = in.keepSuggestedTags(tags)
+		sortTags(in.api.Data.Bookmark.Tags)
 	}
 	in.handleAddShow()
 }
 
+// handleGotoExec opens the bookmark, a bookmark with aliases first asks
+// which of its URLs to open.
 func (in *InputHandler) handleGotoExec() {
+	in.api.Data.OpenURL = ""
+	if aliases := in.aliases(in.api.Data.Bookmark.ID); len(aliases) > 0 {
+		in.showGotoChoose(aliases)
+		return
+	}
+	in.openChecked()
+}
+
+// openChecked opens the chosen URL of the bookmark, URLs with a scheme not
+// in the configured allowlist are only opened after confirmation.
+func (in *InputHandler) openChecked() {
+	if !isAllowedScheme(bukudb.URLScheme(in.openTarget()), in.cfg.URLSchemes) {
+		in.handleGotoConfirmShow()
+		return
+	}
+	in.openURL()
+}
+
+// openTarget returns the URL of the bookmark to open, the chosen alias or
+// its own URL.
+func (in *InputHandler) openTarget() string {
+	if in.api.Data.OpenURL != "" {
+		return in.api.Data.OpenURL
+	}
+	return in.api.Data.Bookmark.URL
+}
+
+func (in *InputHandler) handleGotoConfirmShow() {
+	scheme := bukudb.URLScheme(in.openTarget())
+	in.api.Options[rofiapi.OptionMessage] = in.style.generatePangoMarkup(
+		fmt.Sprintf("open URL with untrusted scheme '%s:'?", scheme), "",
+		in.openTarget())
+	in.api.Options[rofiapi.OptionNoCustom] = "true"
+	in.api.Options[rofiapi.OptionUseHotKeys] = "false"
+
+	in.api.Entries = []rofiapi.Entry{
+		{Text: opBack},
+		{Text: opOpen},
+	}
+
+	in.api.Data.State = StateGotoConfirmSelect
+}
+
+func (in *InputHandler) handleGotoConfirmSelect(input string) {
+	if input != opOpen {
+		in.api.Data.OpenURL = ""
+		in.HandleBookmarksShow()
+		return
+	}
+	in.openURL()
+}
+
+// openURL opens the bookmark with the launcher of its tags, or the browser
+// if no launcher matches. The alternative browser skips the launchers. The
+// command gets the bookmark in its environment, see launcherCommand.
+func (in *InputHandler) openURL() {
 	in.api.Data.State = StateGotoExec
-	b := in.browser
-	if b == "" {
-		b = "xdg-open"
+	url := in.openTarget()
+	in.api.Data.OpenURL = ""
+	var b string
+	var cmd *exec.Cmd
+	if in.api.Data.AltBrowser {
+		in.api.Data.AltBrowser = false
+		cmd = launcherCommand(in.cfg.BrowserAlt, url, in.api.Data.Bookmark)
+	} else if command, ok := resolveLauncher(in.api.Data.Bookmark.Tags, in.cfg.Launchers); ok {
+		cmd = launcherCommand(command, url, in.api.Data.Bookmark)
+	} else {
+		b = in.cfg.Browser
+		if strings.TrimSpace(b) == "" {
+			b = "xdg-open"
+		}
+		cmd = launcherCommand(b, url, in.api.Data.Bookmark)
 	}
-	cmd := exec.Command(b, in.api.Data.Bookmark.URL)
 	if err := cmd.Start(); err != nil {
 		e := fmt.Errorf("error opening URL: %w", err)
 		if b == "xdg-open" {
 			e = fmt.Errorf(
 				"error opening URL: xdg-utils is not installed, to use without set env variable $%s",
-				robukuBrowserEnvVar)
+				config.BrowserEnvVar)
 		}
-		SetMessageToError(in.api, e)
+		in.setMessageToError(e)
+		return
+	}
+	in.recordVisit()
+	in.saveLast()
+
+	if in.api.Data.Stay {
+		opened := in.api.Data.Bookmark
+		opened.URL = url
+		in.showOpened(opened)
 	}
 }
 
+// showOpened shows the bookmarks again after b was opened, with b
+// highlighted and a note in the message box.
+func (in *InputHandler) showOpened(b bukudb.Bookmark) {
+	in.api.Data.Stay = false
+	in.HandleBookmarksShow()
+	if in.api.Data.State != StateBookmarksSelect {
+		return
+	}
+
+	in.selectBookmark(b.ID)
+	in.prependMessage(in.style.spanMarkup("", "opened "+shortHost(b.URL)))
+}
+
+// showAdded shows the bookmarks again after b was added, with b highlighted
+// and a note in the message box, before being the number of bookmarks
+// before. The filters are cleared since b may not match them.
+func (in *InputHandler) showAdded(b bukudb.Bookmark, before int) {
+	filter := in.activeFilter()
+	in.api.Data.Filters = nil
+	in.HandleBookmarksShow()
+	if in.api.Data.State != StateBookmarksSelect {
+		return
+	}
+
+	added := "added " + shortHost(b.URL)
+	// Add doesn't return the ID, a new bookmark gets the highest, nothing
+	// is added on a dry run
+	if in.db.Len() > before {
+		id := uint16(in.db.Len())
+		in.selectBookmark(id)
+		added = fmt.Sprintf("added #%04d %s", id, shortHost(b.URL))
+	}
+	if !filter.isEmpty() {
+		in.prependMessage(in.style.spanMarkup("", "cleared filter "+filter.String()))
+	}
+	in.prependMessage(in.style.spanMarkup("", added))
+}
+
+// selectBookmark highlights the entry of the bookmark with id, if listed.
+func (in *InputHandler) selectBookmark(id uint16) {
+	for i, e := range in.api.Entries {
+		if entryID, err := getIdFromBookmarkString(e.Text); err == nil && entryID == id {
+			in.api.Options[rofiapi.OptionKeepSelection] = "true"
+			in.api.Options[rofiapi.OptionNewSelection] = strconv.Itoa(i)
+			return
+		}
+	}
+}
+
+// shortHost returns the host of url, or the cleaned url if it has none.
+func shortHost(url string) string {
+	if host := bukudb.URLHost(url); host != "" {
+		return host
+	}
+	return bukudb.CleanURL(url)
+}
+
+// handleModifyShow shows the fields of the bookmark. While edits are
+// pending they can only be applied or discarded, the actions writing on
+// their own are hidden.
 func (in *InputHandler) handleModifyShow() {
-	in.api.Options[rofiapi.OptionMessage] = generatePangoMarkup(
+	pending := in.api.Data.Pending
+	in.api.Options[rofiapi.OptionMessage] = in.style.generatePangoMarkup(
 		"select a field to edit", "", "")
+	if pending != 0 {
+		in.prependMessage(in.style.warningMarkup("unapplied changes to " + pendingFieldNames(pending)))
+	}
 	in.api.Options[rofiapi.OptionNoCustom] = "true"
 	in.api.Options[rofiapi.OptionUseHotKeys] = "false"
 
+	tm := in.startTimings("handleModifyShow")
 	entries := []rofiapi.Entry{{Text: opBack}}
+	if pending != 0 {
+		entries = append(entries, rofiapi.Entry{Text: opApplyChanges})
+	} else if in.canUndo() {
+		entries = append(entries, rofiapi.Entry{Text: opUndo})
+	}
 	bookmark := multiLineBookmark(in.api.Data.Bookmark)
 	for _, l := range bookmark {
-		entries = append(entries, rofiapi.Entry{Text: l})
+		entries = append(entries, in.bookmarkLineEntry(l))
+	}
+	if in.api.Data.Bookmark.ID > 0 && pending == 0 {
+		entries = append(entries, rofiapi.Entry{Text: opAppendNote}, in.aliasesEntry())
+	}
+	if created := in.api.Data.Bookmark.Created; created != nil {
+		entries = append(entries, rofiapi.Entry{
+			Text:          "added: " + created.Format(time.DateOnly),
+			NonSelectable: true,
+		})
+	}
+	entries = append(entries, in.visitEntries(pending == 0)...)
+	if host := bukudb.URLHost(in.api.Data.Bookmark.URL); host != "" && pending == 0 {
+		entries = append(entries, rofiapi.Entry{Text: opMoreFrom + host})
 	}
 
+	in.useRichEntries(entries)
 	in.api.Entries = entries
 	in.api.Data.State = StateModifySelect
+	tm.lap("render")
+	tm.appendTo(in.api, in.style)
 }
 
 func (in *InputHandler) handleModifySelect(input string) {
@@ -422,26 +1180,72 @@ func (in *InputHandler) handleModifySelect(input string) {
 	}
 
 	if input == opBack {
-		in.HandleBookmarksShow()
+		if in.api.Data.Pending != 0 {
+			in.handleDiscardShow()
+		} else {
+			in.HandleBookmarksShow()
+		}
 		return
 	}
 
-	switch input[0] {
-	case '0', '1', '2', '3', '4', '5', '6', '7', '8', '9':
+	if input == opApplyChanges && in.api.Data.Pending != 0 {
+		in.applyChanges()
+		return
+	}
+
+	if input == opUndo {
+		in.handleModifyUndo()
+		return
+	}
+
+	if strings.HasPrefix(input, opMoreFrom) {
+		in.handleMoreFromSite()
+		return
+	}
+
+	if input == opAppendNote {
+		in.handleAppendNoteShow()
+		return
+	}
+
+	if strings.HasPrefix(input, opAliases) && in.api.Data.Pending == 0 {
+		in.handleAliasesShow()
+		return
+	}
+
+	if input == opResetOpenCount && in.api.Data.Pending == 0 {
+		in.resetOpenCount()
+		return
+	}
+
+	switch in.selectedField(in.api.Data.Bookmark, input) {
+	case fieldTitle:
 		in.handleModifyTitleShow()
-	case '>':
+	case fieldURL:
 		in.handleModifyUrlShow()
-	case '+':
+	case fieldComment:
 		in.handleModifyCommentShow()
-	case '#':
+	case fieldTags:
 		in.handleModifyTagsShow()
 	default:
 		in.handleModifyShow()
 	}
 }
 
+// handleMoreFromSite shows the bookmark list filtered to the host of the
+// bookmark being modified, Back on the list removes the filter again.
+func (in *InputHandler) handleMoreFromSite() {
+	host := bukudb.URLHost(in.api.Data.Bookmark.URL)
+	if err := in.pushFilter(filterDomainPrefix + host); err != nil {
+		in.showWithError(in.handleModifyShow, err)
+		return
+	}
+	in.api.Data.Bookmark = bukudb.Bookmark{}
+	in.HandleBookmarksShow()
+}
+
 func (in *InputHandler) handleModifyTitleShow() {
-	in.api.Options[rofiapi.OptionMessage] = generatePangoMarkup(
+	in.api.Options[rofiapi.OptionMessage] = in.style.generatePangoMarkup(
 		"enter a new title", "", in.api.Data.Bookmark.Title)
 	in.api.Options[rofiapi.OptionNoCustom] = "false"
 	in.api.Options[rofiapi.OptionUseHotKeys] = "false"
@@ -455,22 +1259,34 @@ func (in *InputHandler) handleModifyTitleShow() {
 }
 
 func (in *InputHandler) handleModifyTitleSelect(input string) {
+	if err := checkLength(input, in.cfg.MaxTitleLen); err != nil {
+		in.showWithError(in.handleModifyTitleShow, err)
+		return
+	}
+
 	if input == opDelete {
 		input = ""
+	} else if input != opBack {
+		input = in.cleanTitle(input)
 	}
 
 	if input == opBack {
 		in.handleModifyShow()
+	} else if in.deferWrites() {
+		in.api.Data.Bookmark.Title = input
+		in.stageField(bukudb.MaskTitle)
+	} else if in.bookmarkChanged() || in.fieldConflict(fieldTitle, input) {
 	} else if err := in.db.UpdateTitle(in.api.Data.Bookmark.ID, input); err != nil {
-		SetMessageToError(in.api, fmt.Errorf("error updating title: %w", err))
+		in.setMessageToError(fmt.Errorf("error updating title: %w", err))
 	} else {
+		in.saveUndo(fieldTitle)
 		in.api.Data.Bookmark.Title = input
 		in.handleModifyShow()
 	}
 }
 
 func (in *InputHandler) handleModifyUrlShow() {
-	in.api.Options[rofiapi.OptionMessage] = generatePangoMarkup(
+	in.api.Options[rofiapi.OptionMessage] = in.style.generatePangoMarkup(
 		"enter a new url", "", in.api.Data.Bookmark.URL)
 	in.api.Options[rofiapi.OptionNoCustom] = "false"
 	in.api.Options[rofiapi.OptionUseHotKeys] = "false"
@@ -483,51 +1299,72 @@ func (in *InputHandler) handleModifyUrlShow() {
 }
 
 func (in *InputHandler) handleModifyUrlSelect(input string) {
+	if err := checkLength(input, in.cfg.MaxURLLen); err != nil {
+		in.showWithError(in.handleModifyUrlShow, err)
+		return
+	}
+
 	if input == "" {
 		in.handleModifyUrlShow()
 	} else if input == opBack {
 		in.handleModifyShow()
+	} else if in.deferWrites() {
+		in.api.Data.Bookmark.URL = input
+		in.stageField(bukudb.MaskURL)
+	} else if in.bookmarkChanged() || in.fieldConflict(fieldURL, input) {
 	} else if err := in.db.UpdateURL(in.api.Data.Bookmark.ID, input); err != nil {
-		SetMessageToError(in.api, fmt.Errorf("error updating url: %w", err))
+		in.handleUpdateURLError(err)
 	} else {
+		in.saveUndo(fieldURL)
 		in.api.Data.Bookmark.URL = input
 		in.handleModifyShow()
 	}
 }
 
 func (in *InputHandler) handleModifyCommentShow() {
-	in.api.Options[rofiapi.OptionMessage] = generatePangoMarkup(
+	in.api.Options[rofiapi.OptionMessage] = in.style.generatePangoMarkup(
 		"enter a new comment", "", in.api.Data.Bookmark.Comment)
 	in.api.Options[rofiapi.OptionNoCustom] = "false"
 	in.api.Options[rofiapi.OptionUseHotKeys] = "false"
 
-	in.api.Entries = []rofiapi.Entry{
-		{Text: opBack},
-		{Text: opDelete},
-	}
+	in.api.Entries = in.commentEntries()
 
 	in.api.Data.State = StateModifyCommentSelect
 }
 
 func (in *InputHandler) handleModifyCommentSelect(input string) {
+	if snippet, ok := in.snippetFromInput(input); ok {
+		input = appendSnippet(in.api.Data.Bookmark.Comment, snippet)
+	}
+
+	if err := checkLength(input, in.cfg.MaxCommentLen); err != nil {
+		in.showWithError(in.handleModifyCommentShow, err)
+		return
+	}
+
 	if input == opDelete {
 		input = ""
 	}
 
 	if input == opBack {
 		in.handleModifyShow()
+	} else if in.deferWrites() {
+		in.api.Data.Bookmark.Comment = input
+		in.stageField(bukudb.MaskComment)
+	} else if in.bookmarkChanged() || in.fieldConflict(fieldComment, input) {
 	} else if err := in.db.UpdateComment(in.api.Data.Bookmark.ID, input); err != nil {
-		SetMessageToError(in.api, fmt.Errorf("error updating comment: %w", err))
+		in.setMessageToError(fmt.Errorf("error updating comment: %w", err))
 	} else {
+		in.saveUndo(fieldComment)
 		in.api.Data.Bookmark.Comment = input
 		in.handleModifyShow()
 	}
 }
 
 func (in *InputHandler) handleModifyTagsShow() {
-	in.api.Options[rofiapi.OptionMessage] = generatePangoMarkup(
+	in.api.Options[rofiapi.OptionMessage] = in.style.generatePangoMarkup(
 		"add or remove tags",
-		"'+ newtag1, ...' or '- oldtag1, ...'",
+		modifyTagsExample(in.cfg.TagSplit),
 		strings.Join(in.api.Data.Bookmark.Tags, ", "))
 	in.api.Options[rofiapi.OptionNoCustom] = "false"
 	in.api.Options[rofiapi.OptionUseHotKeys] = "false"
@@ -545,41 +1382,41 @@ func (in *InputHandler) handleModifyTagsSelect(input string) {
 	case input == opBack:
 		in.handleModifyShow()
 	case input == opDelete:
-		if err := in.db.ClearTags(in.api.Data.Bookmark.ID); err != nil {
-			SetMessageToError(in.api, fmt.Errorf("error clearing tags: %w", err))
+		if needsConfirm(in.cfg.Confirm, confirmClearTags) {
+			in.handleClearTagsShow()
 		} else {
-			in.api.Data.Bookmark.Tags = []string{}
-			in.handleModifyShow()
+			in.clearTags()
 		}
 	case strings.HasPrefix(input, "+"):
-		tags := getTagsFromInput(input[1:])
-		if err := in.db.AddTags(in.api.Data.Bookmark.ID, tags); err != nil {
-			SetMessageToError(in.api, fmt.Errorf("error adding tag: %w", err))
+		tags := getTagsFromInput(input[1:], in.cfg.TagSplit)
+		if len(tags) == 0 {
+			in.showWithError(in.handleModifyTagsShow, errNoTags)
+		} else if err := checkTagsLength(tags, in.cfg.MaxTagLen); err != nil {
+			in.showWithError(in.handleModifyTagsShow, err)
+		} else if in.deferWrites() {
+			in.addDataTags(tags)
+			in.stageField(bukudb.MaskTags)
+		} else if in.bookmarkChanged() || in.fieldConflict(fieldTags, input) {
+		} else if err := in.db.AddTags(in.api.Data.Bookmark.ID, tags); err != nil {
+			in.setMessageToError(fmt.Errorf("error adding tag: %w", err))
 		} else {
-			for _, t := range tags {
-				if !slices.Contains(in.api.Data.Bookmark.Tags, t) {
-					in.api.Data.Bookmark.Tags = append(in.api.Data.Bookmark.Tags, t)
-				}
-			}
-
-			sort.Slice(in.api.Data.Bookmark.Tags, func(i, j int) bool {
-				return strings.ToLower(in.api.Data.Bookmark.Tags[i]) <
-					strings.ToLower(in.api.Data.Bookmark.Tags[j])
-			})
+			in.saveUndo(fieldTags)
+			in.addDataTags(tags)
 			in.handleModifyShow()
 		}
 	case strings.HasPrefix(input, "-"):
-		tags := getTagsFromInput(input[1:])
-		if err := in.db.RemoveTags(in.api.Data.Bookmark.ID, tags); err != nil {
-			SetMessageToError(in.api, fmt.Errorf("error removing tag: %w", err))
+		tags := getTagsFromInput(input[1:], in.cfg.TagSplit)
+		if len(tags) == 0 {
+			in.showWithError(in.handleModifyTagsShow, errNoTags)
+		} else if in.deferWrites() {
+			in.removeDataTags(tags)
+			in.stageField(bukudb.MaskTags)
+		} else if in.bookmarkChanged() || in.fieldConflict(fieldTags, input) {
+		} else if err := in.db.RemoveTags(in.api.Data.Bookmark.ID, tags); err != nil {
+			in.setMessageToError(fmt.Errorf("error removing tag: %w", err))
 		} else {
-			tmp := make([]string, 0)
-			for _, t := range in.api.Data.Bookmark.Tags {
-				if !slices.Contains(tags, t) {
-					tmp = append(tmp, t)
-				}
-			}
-			in.api.Data.Bookmark.Tags = tmp
+			in.saveUndo(fieldTags)
+			in.removeDataTags(tags)
 			in.handleModifyShow()
 		}
 	default:
@@ -587,9 +1424,104 @@ func (in *InputHandler) handleModifyTagsSelect(input string) {
 	}
 }
 
+// addDataTags adds the tags missing from the bookmark being modified, in
+// Data only.
+func (in *InputHandler) addDataTags(tags []string) {
+	for _, t := range tags {
+		if !containsTag(in.api.Data.Bookmark.Tags, t) {
+			in.api.Data.Bookmark.Tags = append(in.api.Data.Bookmark.Tags, t)
+		}
+	}
+
+	sortTags(in.api.Data.Bookmark.Tags)
+}
+
+// removeDataTags removes tags from the bookmark being modified, in Data
+// only.
+func (in *InputHandler) removeDataTags(tags []string) {
+	tmp := make([]string, 0)
+	for _, t := range in.api.Data.Bookmark.Tags {
+		if !slices.Contains(tags, t) {
+			tmp = append(tmp, t)
+		}
+	}
+	in.api.Data.Bookmark.Tags = tmp
+}
+
+// saveUndo keeps the current value of field of the bookmark being modified,
+// it must be called before the field is changed in Data.
+func (in *InputHandler) saveUndo(field bookmarkField) {
+	b := in.api.Data.Bookmark
+	u := Undo{BookmarkID: b.ID, Field: field}
+	switch field {
+	case fieldTitle:
+		u.Value = b.Title
+	case fieldURL:
+		u.Value = b.URL
+	case fieldComment:
+		u.Value = b.Comment
+	case fieldTags:
+		u.Tags = slices.Clone(b.Tags)
+	}
+
+	if len(u.Value)+len(strings.Join(u.Tags, ",")) > maxUndoLen {
+		u = Undo{}
+	}
+	in.api.Data.Undo = u
+}
+
+func (in *InputHandler) canUndo() bool {
+	u := in.api.Data.Undo
+	return u.BookmarkID != 0 && u.BookmarkID == in.api.Data.Bookmark.ID && u.Field != fieldNone
+}
+
+func (in *InputHandler) handleModifyUndo() {
+	if !in.canUndo() {
+		in.handleModifyShow()
+		return
+	}
+
+	if in.bookmarkChanged() {
+		return
+	}
+	u := in.api.Data.Undo
+	b := &in.api.Data.Bookmark
+	var err error
+	switch u.Field {
+	case fieldTitle:
+		if err = in.db.UpdateTitle(b.ID, u.Value); err == nil {
+			b.Title = u.Value
+		}
+	case fieldURL:
+		if err = in.db.UpdateURL(b.ID, u.Value); err == nil {
+			b.URL = u.Value
+		}
+	case fieldComment:
+		if err = in.db.UpdateComment(b.ID, u.Value); err == nil {
+			b.Comment = u.Value
+		}
+	case fieldTags:
+		err = in.db.ClearTags(b.ID)
+		if err == nil && len(u.Tags) > 0 {
+			err = in.db.AddTags(b.ID, u.Tags)
+		}
+		if err == nil {
+			b.Tags = u.Tags
+		}
+	}
+
+	if err != nil {
+		in.setMessageToError(fmt.Errorf("error undoing edit: %w", err))
+		return
+	}
+	in.api.Data.Undo = Undo{}
+	in.handleModifyShow()
+}
+
 func (in *InputHandler) handleDeleteConfirmShow() {
-	in.api.Options[rofiapi.OptionMessage] = generatePangoMarkup(
-		"delete? (yes/No)", "", in.api.Data.Bookmark.URL)
+	in.api.Options[rofiapi.OptionMessage] = in.style.generatePangoMarkup(
+		"delete? (yes/No)", "'yes 3 7-9' deletes those bookmarks instead",
+		in.api.Data.Bookmark.URL)
 	in.api.Options[rofiapi.OptionNoCustom] = "false"
 	in.api.Options[rofiapi.OptionUseHotKeys] = "false"
 
@@ -601,15 +1533,70 @@ func (in *InputHandler) handleDeleteConfirmShow() {
 }
 
 func (in *InputHandler) handleDeleteConfirmSelect(input string) {
-	if input == opBack || input != "yes" {
+	if ids, ok, err := deleteIDsFromInput(input); ok {
+		if err == nil {
+			err = in.checkDeleteIDs(ids)
+		}
+		if err != nil {
+			in.showWithError(in.handleDeleteConfirmShow, err)
+			return
+		}
+		in.api.Data.DeleteIDs = ids
+		in.handleDeleteMultiShow()
+		return
+	}
+	if input == opBack || input != confirmYes {
 		in.HandleBookmarksShow()
 		return
 	}
+	in.deleteBookmark()
+}
 
-	if err := in.db.Remove(in.api.Data.Bookmark.ID); err != nil {
-		SetMessageToError(in.api, fmt.Errorf("error deleting bookmark: %w", err))
-	} else {
+func (in *InputHandler) handleStatsShow() {
+	in.api.Options[rofiapi.OptionMessage] = in.style.generatePangoMarkup("statistics", "", "")
+	in.api.Options[rofiapi.OptionNoCustom] = "true"
+	in.api.Options[rofiapi.OptionUseHotKeys] = "false"
+
+	tm := in.startTimings("handleStatsShow")
+	stats, err := in.db.Stats()
+	if err != nil {
+		in.setMessageToError(fmt.Errorf("error getting statistics: %w", err))
+		return
+	}
+	tm.lap("db")
+
+	entries := []rofiapi.Entry{{Text: opBack}}
+	if stats.Untitled > 0 {
+		entries = append(entries, rofiapi.Entry{Text: opFixTitles})
+	}
+	if stats.Visited > 0 {
+		entries = append(entries, rofiapi.Entry{Text: opResetAllOpenCounts})
+	}
+	if stats.Insecure > 0 {
+		entries = append(entries, rofiapi.Entry{Text: opUpgradeHTTPS})
+	}
+	for _, l := range statsLines(stats) {
+		entries = append(entries, rofiapi.Entry{Text: formatEntryText(l), NonSelectable: true})
+	}
+
+	in.api.Entries = entries
+	in.api.Data.State = StateStatsSelect
+	tm.lap("render")
+	tm.appendTo(in.api, in.style)
+}
+
+func (in *InputHandler) handleStatsSelect(input string) {
+	switch input {
+	case opBack:
 		in.HandleBookmarksShow()
+	case opFixTitles:
+		in.startTitleCleanup()
+	case opResetAllOpenCounts:
+		in.handleResetVisitsShow()
+	case opUpgradeHTTPS:
+		in.upgradeToHTTPS()
+	default:
+		in.handleStatsShow()
 	}
 }
 
@@ -622,36 +1609,338 @@ func (in *InputHandler) getSelectedFromInput(input string) (bukudb.Bookmark, err
 	return b, nil
 }
 
+// handleCopyBukuCommand copies a buku command adding the selected bookmark
+// to the clipboard and shows the bookmarks again.
+func (in *InputHandler) handleCopyBukuCommand() {
+	if err := in.copyToClipboard(bukuAddCommand(in.api.Data.Bookmark)); err != nil {
+		in.showWithError(in.HandleBookmarksShow, err)
+		return
+	}
+	in.HandleBookmarksShow()
+	in.prependMessage(in.style.spanMarkup("", "copied buku command to clipboard"))
+}
+
+// prependMessage adds the line markup above the current message, markup is
+// built with spanMarkup.
+func (in *InputHandler) prependMessage(markup string) {
+	message := in.api.Options[rofiapi.OptionMessage]
+	if in.style.plain {
+		in.api.Options[rofiapi.OptionMessage] = joinMessageLines(markup, message)
+		return
+	}
+	in.api.Options[rofiapi.OptionMessage] = strings.Replace(message, "<markup>", "<markup>"+markup+"\r", 1)
+}
+
+// addNotice queues msg to be shown above the message once the input has
+// been handled.
+func (in *InputHandler) addNotice(msg string) {
+	in.notices = append(in.notices, msg)
+}
+
+// showNotices adds the queued notices above the message.
+func (in *InputHandler) showNotices() {
+	for i := len(in.notices) - 1; i >= 0; i-- {
+		in.prependMessage(in.style.spanMarkup(in.style.label, in.notices[i]))
+	}
+	in.notices = nil
+}
+
+// showWithError calls show and adds the text of err above its message, so
+// the user can retry the input
+func (in *InputHandler) showWithError(show func(), err error) {
+	log.Println("ERROR", err)
+	show()
+	in.prependMessage(in.style.errorMarkup(err))
+}
+
 // SetMessageToError sets rofi's message box to the text of an error and
-// replaces rofi's entries with the back option
+// replaces rofi's entries with the back option. The text of err is raw, it
+// is escaped here. It is for errors before there is an InputHandler, which
+// uses setMessageToError in its own style.
 func SetMessageToError(api *rofiapi.RofiApi[Data], err error) {
+	showError(api, defaultStyle, err)
+}
+
+// setMessageToError is SetMessageToError in the style of in.
+func (in *InputHandler) setMessageToError(err error) {
+	showError(in.api, in.style, err)
+}
+
+// showError is SetMessageToError in style s.
+func showError(api *rofiapi.RofiApi[Data], s markupStyle, err error) {
 	log.Println("ERROR", err)
-	api.Options[rofiapi.OptionMessage] = fmt.Sprintf(
-		"<markup><span font_weight=\"bold\">error:</span><span> %s</span></markup>",
-		rofiapi.EscapePangoMarkup(err.Error()))
+	api.Options[rofiapi.OptionMessage] = s.wrapMessage(s.errorMarkup(err))
 	api.Options[rofiapi.OptionNoCustom] = "true"
 	api.Entries = []rofiapi.Entry{{Text: opExit}}
 	api.Data.State = StateErrorShow
 }
 
+// HandleNotBukuDB shows that the database at path is not a buku database,
+// offering to initialize it with initSchema when canInit is true. selected
+// is the entry selected on the previous screen, it returns true once the
+// database was initialized.
+func HandleNotBukuDB(api *rofiapi.RofiApi[Data], selected, path string,
+	canInit bool, initSchema func(path string) error) bool {
+	if api.Data.State == StateInitSchemaSelect {
+		if !canInit || strings.TrimSpace(selected) != opInitSchema {
+			api.Data.State = StateErrorSelect
+			return false
+		}
+		if err := initSchema(path); err != nil {
+			SetMessageToError(api, fmt.Errorf("error initializing database: %w", err))
+			return false
+		}
+		api.Data.State = StateBookmarksShow
+		return true
+	}
+
+	instructions := fmt.Sprintf(
+		"'%s' is not a buku database, set the env variable $ROBUKU_DB_PATH to a buku bookmarks.db", path)
+	if canInit {
+		instructions = fmt.Sprintf("'%s' is not a buku database, initialize it?", path)
+	}
+	api.Options[rofiapi.OptionMessage] = defaultStyle.generatePangoMarkup(instructions, "", "")
+	api.Options[rofiapi.OptionNoCustom] = "true"
+	api.Options[rofiapi.OptionUseHotKeys] = "false"
+
+	api.Entries = []rofiapi.Entry{{Text: opExit}}
+	if canInit {
+		api.Entries = append(api.Entries, rofiapi.Entry{Text: opInitSchema})
+	}
+	api.Data.State = StateInitSchemaSelect
+	return false
+}
+
+func (s markupStyle) errorMarkup(err error) string {
+	return s.spanMarkup(s.label, "error:") + s.spanMarkup("", " "+err.Error())
+}
+
+// spanMarkup returns text in a span with the pango attributes attrs. Message
+// text, errors included, stays raw until it is escaped once here. Plain
+// messages keep text as it is.
+func (s markupStyle) spanMarkup(attrs, text string) string {
+	if s.plain {
+		return text
+	}
+	if attrs == "" {
+		return "<span>" + rofiapi.EscapePangoMarkup(text) + "</span>"
+	}
+	return fmt.Sprintf("<span %s>%s</span>", attrs, rofiapi.EscapePangoMarkup(text))
+}
+
+// checkLength returns an error if input is longer than max characters.
+func checkLength(input string, max int) error {
+	if n := utf8.RuneCountInString(input); n > max {
+		return fmt.Errorf("input too long (%d > %d)", n, max)
+	}
+	return nil
+}
+
+// checkTagsLength returns an error if any tag is longer than max characters.
+func checkTagsLength(tags []string, max int) error {
+	for _, t := range tags {
+		if err := checkLength(t, max); err != nil {
+			return fmt.Errorf("tag '%s': %w", truncateEnd(t, 20), err)
+		}
+	}
+	return nil
+}
+
+// isAllowedScheme reports whether scheme is in allowed, bare hosts without a
+// scheme are always allowed.
+func isAllowedScheme(scheme string, allowed []string) bool {
+	return scheme == "" || slices.Contains(allowed, scheme)
+}
+
+// fullWidthDigits maps the full-width digits some input methods type to
+// ASCII digits.
+var fullWidthDigits = strings.NewReplacer(
+	"０", "0", "１", "1", "２", "2", "３", "3", "４", "4",
+	"５", "5", "６", "6", "７", "7", "８", "8", "９", "9",
+)
+
+// getIdFromBookmarkString parses the ID in front of the dot of a bookmark
+// entry like "42. title", a bare number like "42" is accepted too.
 func getIdFromBookmarkString(input string) (uint16, error) {
-	idString := strings.Split(input, ".")[0]
+	idString, _, _ := strings.Cut(strings.TrimSpace(input), ".")
+	idString = fullWidthDigits.Replace(strings.TrimSpace(idString))
 	idUint64, err := strconv.ParseUint(idString, 10, 16)
 	if err != nil {
-		return 0, fmt.Errorf("error parsing id from entry: %s", input)
+		return 0, fmt.Errorf("no bookmark id in '%s'", truncateEnd(idString, 10))
+	}
+	if idUint64 == 0 {
+		return 0, errors.New("bookmark ids start at 1")
 	}
 	return uint16(idUint64), nil
 }
 
-func getTagsFromInput(input string) []string {
-	tags := strings.Split(input, ",")
-	for i, t := range tags {
-		tags[i] = strings.TrimSpace(t)
+// idWidth returns the number of digits of the highest ID of bookmarks, the
+// IDs of the bookmark list are zero padded to it so the titles line up.
+func idWidth(bookmarks []bukudb.Bookmark) int {
+	var highest uint16
+	for _, b := range bookmarks {
+		highest = max(highest, b.ID)
+	}
+	return len(strconv.Itoa(int(highest)))
+}
+
+// getTagsFromInput splits input into tags at commas, and at spaces too with
+// config.TagSplitCommaSpace. Empty tags are dropped and tags differing only
+// by case are kept once.
+func getTagsFromInput(input string, split config.TagSplit) []string {
+	isSep := func(r rune) bool { return r == ',' }
+	if split == config.TagSplitCommaSpace {
+		isSep = func(r rune) bool { return r == ',' || unicode.IsSpace(r) }
+	}
+	tags := make([]string, 0)
+	for _, t := range strings.FieldsFunc(input, isSep) {
+		t = strings.TrimSpace(t)
+		if t != "" && !containsTag(tags, t) {
+			tags = append(tags, t)
+		}
 	}
 	return tags
 }
 
-func multiLineBookmark(b bukudb.Bookmark) []string {
+// tagsExample returns the example of the add tags prompt for split.
+func tagsExample(split config.TagSplit) string {
+	if split == config.TagSplitCommaSpace {
+		return "'mytag some-tag, other'"
+	}
+	return "'mytag, some-tag, a tag'"
+}
+
+// modifyTagsExample returns the example of the modify tags prompt for split.
+func modifyTagsExample(split config.TagSplit) string {
+	if split == config.TagSplitCommaSpace {
+		return "'+ newtag1 newtag2 ...' or '- oldtag1 ...'"
+	}
+	return "'+ newtag1, ...' or '- oldtag1, ...'"
+}
+
+// containsTag reports whether tags contains tag, ignoring case.
+func containsTag(tags []string, tag string) bool {
+	return slices.ContainsFunc(tags, func(t string) bool {
+		return strings.EqualFold(t, tag)
+	})
+}
+
+// bookmarkField is a field of a bookmark shown by multiLineBookmark.
+type bookmarkField byte
+
+const (
+	fieldNone bookmarkField = iota
+	fieldTitle
+	fieldURL
+	fieldComment
+	fieldTags
+)
+
+// fieldInfoPrefix starts the info of the entries of multiLineBookmark,
+// followed by the field name and placeholderInfo if the field is empty.
+const (
+	fieldInfoPrefix = "field:"
+	placeholderInfo = ":empty"
+)
+
+var fieldNames = map[bookmarkField]string{
+	fieldTitle:   "title",
+	fieldURL:     "url",
+	fieldComment: "comment",
+	fieldTags:    "tags",
+}
+
+// info returns the entry info of l, naming its field and whether it is a
+// placeholder.
+func (l bookmarkLine) info() string {
+	info := fieldInfoPrefix + fieldNames[l.Field]
+	if l.Placeholder {
+		info += placeholderInfo
+	}
+	return info
+}
+
+// parseFieldInfo returns the field and placeholder flag of the entry info
+// set by bookmarkLine.info, ok is false for any other info.
+func parseFieldInfo(info string) (field bookmarkField, placeholder, ok bool) {
+	name, found := strings.CutPrefix(info, fieldInfoPrefix)
+	if !found {
+		return fieldNone, false, false
+	}
+	name, placeholder = strings.CutSuffix(name, placeholderInfo)
+	for f, n := range fieldNames {
+		if n == name {
+			return f, placeholder, true
+		}
+	}
+	return fieldNone, false, false
+}
+
+// selectedField returns the field of b selected with input. The info of the
+// selected rofi entry decides if it has one, otherwise the field whose
+// multiLineBookmark entry is exactly input, or fieldNone if there is none.
+func (in *InputHandler) selectedField(b bukudb.Bookmark, input string) bookmarkField {
+	if e, ok := in.api.GetSelectedEntry(); ok && strings.TrimSpace(e.Text) == input {
+		if f, _, ok := parseFieldInfo(e.Info); ok {
+			return f
+		}
+	}
+	return selectedField(b, input)
+}
+
+// selectedField returns the field of b whose multiLineBookmark entry is
+// exactly input, or fieldNone if there is none.
+func selectedField(b bukudb.Bookmark, input string) bookmarkField {
+	for _, l := range multiLineBookmark(b) {
+		if strings.TrimSpace(l.Text) == input {
+			return l.Field
+		}
+	}
+	return fieldNone
+}
+
+// bookmarkLine is an entry of multiLineBookmark and the field it shows,
+// Placeholder is set when the field is empty and Text only names it.
+type bookmarkLine struct {
+	Text        string
+	Field       bookmarkField
+	Placeholder bool
+}
+
+// bookmarkLineEntry returns the rofi entry of l. With rich entries the placeholder of
+// an empty field is shown in italics.
+func (in *InputHandler) bookmarkLineEntry(l bookmarkLine) rofiapi.Entry {
+	e := rofiapi.Entry{Text: l.Text, Info: l.info()}
+	if in.richEntries() && l.Placeholder {
+		prefix, name, _ := strings.Cut(l.Text, " ")
+		e.Display = rofiapi.EscapePangoMarkup(prefix) + " <i>" + rofiapi.EscapePangoMarkup(name) + "</i>"
+	}
+	return e
+}
+
+// richEntries reports whether entries may be shown with pango markup.
+func (in *InputHandler) richEntries() bool {
+	return in.cfg.RichEntries && !in.style.plain
+}
+
+// useRichEntries turns on pango markup for entries, those without a display
+// text show their escaped text so that only the markup added on purpose is
+// rendered.
+func (in *InputHandler) useRichEntries(entries []rofiapi.Entry) {
+	if !in.richEntries() {
+		return
+	}
+	in.api.Options[rofiapi.OptionMarkupRows] = "true"
+	for i := range entries {
+		if entries[i].Display == "" {
+			entries[i].Display = rofiapi.EscapePangoMarkup(entries[i].Text)
+		}
+	}
+}
+
+// multiLineBookmark returns the title, url, comment, and tags entries of b,
+// the comment is wrapped over up to commentPreviewLines entries.
+func multiLineBookmark(b bukudb.Bookmark) []bookmarkLine {
 	title := b.Title
 	if title == "" {
 		title = "(Title)"
@@ -662,56 +1951,143 @@ func multiLineBookmark(b bukudb.Bookmark) []string {
 		url = "(Url)"
 	}
 
-	comment := b.Comment
-	if comment == "" {
-		comment = "(Comment)"
+	comment := wrapText(b.Comment, entryMaxLen-2)
+	noComment := len(comment) == 0
+	if noComment {
+		comment = []string{"(Comment)"}
+	}
+	if len(comment) > commentPreviewLines {
+		comment = comment[:commentPreviewLines]
+		comment[commentPreviewLines-1] += "…"
 	}
 
 	tags := strings.Join(b.Tags, ", ")
-	if tags == "" {
+	noTags := tags == ""
+	if noTags {
 		tags = "(Tags)"
 	}
 
-	return []string{
-		formatEntryText(fmt.Sprintf("%d. %s", b.ID, title)),
-		formatEntryText("> " + url),
-		formatEntryText("+ " + comment),
-		formatEntryText("# " + tags),
+	lines := []bookmarkLine{
+		{formatEntryText(fmt.Sprintf("%d. %s", b.ID, title)), fieldTitle, b.Title == ""},
+		{formatEntryText("> " + url), fieldURL, b.URL == ""},
+	}
+	for _, l := range comment {
+		lines = append(lines, bookmarkLine{formatEntryText("+ " + l), fieldComment, noComment})
+	}
+	lines = append(lines, bookmarkLine{formatEntryText("# " + tags), fieldTags, noTags})
+	return lines
+}
+
+// wrapText splits s into lines of at most width characters, breaking at
+// spaces and newlines, words longer than width are broken where needed.
+func wrapText(s string, width int) []string {
+	if width < 1 {
+		return nil
+	}
+
+	var lines []string
+	for _, paragraph := range strings.Split(s, "\n") {
+		var line []rune
+		for _, word := range strings.Fields(paragraph) {
+			w := []rune(word)
+			if len(line) > 0 && len(line)+1+len(w) <= width {
+				line = append(append(line, ' '), w...)
+				continue
+			}
+			if len(line) > 0 {
+				lines = append(lines, string(line))
+			}
+			for len(w) > width {
+				lines = append(lines, string(w[:width]))
+				w = w[width:]
+			}
+			line = w
+		}
+		if len(line) > 0 {
+			lines = append(lines, string(line))
+		}
 	}
+	return lines
 }
 
-func generatePangoMarkup(instructions, example, currentValue string) string {
+func statsLines(s bukudb.Stats) []string {
+	lines := []string{
+		fmt.Sprintf("bookmarks: %d", s.Total),
+		fmt.Sprintf("tags: %d", s.Tags),
+		fmt.Sprintf("untagged bookmarks: %d", s.Untagged),
+		fmt.Sprintf("bookmarks without title: %d", s.Untitled),
+		fmt.Sprintf("opened bookmarks: %d", s.Visited),
+		fmt.Sprintf("database size: %s", formatByteSize(s.FileSize)),
+	}
+
+	if len(s.TopTags) > 0 {
+		lines = append(lines, "top tags:")
+		for _, t := range s.TopTags {
+			lines = append(lines, fmt.Sprintf("    %s (%d)", t.Tag, t.Count))
+		}
+	}
+
+	if len(s.TopDomains) > 0 {
+		lines = append(lines, "top domains:")
+		for _, d := range s.TopDomains {
+			lines = append(lines, fmt.Sprintf("    %s (%d)", d.Domain, d.Count))
+		}
+	}
+
+	return lines
+}
+
+func formatByteSize(b int64) string {
+	const unit = 1024
+	if b < unit {
+		return fmt.Sprintf("%d B", b)
+	}
+	div, exp := int64(unit), 0
+	for n := b / unit; n >= unit; n /= unit {
+		div *= unit
+		exp++
+	}
+	return fmt.Sprintf("%.1f %ciB", float64(b)/float64(div), "KMGTPE"[exp])
+}
+
+func (s markupStyle) generatePangoMarkup(instructions, example, currentValue string) string {
+	currentValue = truncateMiddle(currentValue, entryMaxLen)
+	if s.plain {
+		return generatePlainMessage(instructions, example, currentValue)
+	}
+
 	markup := "<markup>"
 
 	if instructions != "" {
-		instructions = rofiapi.EscapePangoMarkup(instructions)
-		markup += fmt.Sprintf(
-			"<span font_weight=\"bold\">%s</span>", instructions)
+		markup += s.spanMarkup(s.label, instructions)
 	}
 	if example != "" {
-		example = rofiapi.EscapePangoMarkup(example)
 		if instructions != "" {
 			markup += "\r"
 		}
-		markup += fmt.Sprintf(
-			"<span font_weight=\"bold\">example:</span><span> <i>%s</i></span>",
-			example)
+		markup += s.spanMarkup(s.label, "example:") +
+			"<span> " + s.spanMarkup(s.example, example) + "</span>"
 	}
 	if currentValue != "" {
-		currentValue = truncateMiddle(currentValue, entryMaxLen)
-		currentValue = rofiapi.EscapePangoMarkup(currentValue)
 		if example != "" || instructions != "" {
 			markup += "\r"
 		}
-		markup += fmt.Sprintf(
-			"<span font_weight=\"bold\">current:</span><span> <u>%s</u></span>",
-			currentValue)
+		markup += s.spanMarkup(s.label, "current:") +
+			"<span> " + s.spanMarkup(s.current, currentValue) + "</span>"
 	}
 
 	markup += "</markup>"
 	return markup
 }
 
+// buildMeta returns the space separated search keywords of a bookmark: its
+// tags, bare and after config.DefaultTagSigil, the cleaned URL, and the
+// words of its comment, lower cased and without duplicates.
+func buildMeta(b bukudb.Bookmark) string {
+	r := entryRenderer{sigil: config.DefaultTagSigil}
+	return r.meta(b)
+}
+
 func formatEntryText(e string) string {
 	e = truncateEnd(e, entryMaxLen)
 	e = replaceNewlines(e)
@@ -728,8 +2104,8 @@ func truncateMiddle(s string, l int) string {
 }
 
 func truncateEnd(s string, l int) string {
-	if len(s) > l && l >= 0 {
-		return s[0:l]
+	if utf8.RuneCountInString(s) > l && l >= 0 {
+		return string([]rune(s)[0:l])
 	} else {
 		return s
 	}
@@ -738,13 +2114,3 @@ func truncateEnd(s string, l int) string {
 func replaceNewlines(s string) string {
 	return strings.ReplaceAll(s, "\n", " ")
 }
-
-func cleanURL(rawURL string) string {
-	parsedURL, err := url.Parse(rawURL)
-	if err != nil {
-		return rawURL
-	}
-	parsedURL.Scheme = ""
-	parsedURL.Host = strings.TrimPrefix(parsedURL.Host, "www.")
-	return strings.TrimPrefix(parsedURL.String(), "//")
-}
@@ -1,13 +1,21 @@
 package inputhandler
 
 import (
+	"bytes"
+	"context"
+	"encoding/gob"
 	"fmt"
+	"path/filepath"
+	"regexp"
 	"slices"
 	"sort"
 	"strings"
 	"testing"
+	"time"
 
 	"github.com/VannRR/robuku/bukudb"
+	"github.com/VannRR/robuku/config"
+	"github.com/VannRR/robuku/inputhandler/testutil"
 	"github.com/VannRR/rofi-api"
 
 	_ "github.com/mattn/go-sqlite3"
@@ -15,6 +23,14 @@ import (
 
 type mockDB struct {
 	bookmarks []bukudb.Bookmark
+	trash     []bukudb.TrashedBookmark
+	statuses  map[uint16]bukudb.LinkStatus
+	visits    map[uint16]bukudb.Visits
+	aliases   map[uint16][]string
+
+	// checkErr is returned by CheckBookmark, like a database changed on
+	// disk.
+	checkErr error
 }
 
 func newMockDB() *mockDB {
@@ -39,10 +55,123 @@ func (db *mockDB) Len() int {
 	return len(db.bookmarks)
 }
 
+func (db *mockDB) Stats() (bukudb.Stats, error) {
+	s := bukudb.Stats{Total: len(db.bookmarks), FileSize: 2048}
+	for _, b := range db.bookmarks {
+		if len(b.Tags) == 0 {
+			s.Untagged++
+		}
+		if b.Title == "" {
+			s.Untitled++
+		}
+		if strings.HasPrefix(b.URL, "http://") {
+			s.Insecure++
+		}
+	}
+	s.Visited = len(db.visits)
+	return s, nil
+}
+
+func (db *mockDB) Tags() ([]bukudb.TagCount, error) {
+	counts := make(map[string]int)
+	for _, b := range db.bookmarks {
+		for _, t := range b.Tags {
+			counts[t]++
+		}
+	}
+	var tags []bukudb.TagCount
+	for t, n := range counts {
+		tags = append(tags, bukudb.TagCount{Tag: t, Count: n})
+	}
+	slices.SortFunc(tags, func(a, b bukudb.TagCount) int {
+		return strings.Compare(strings.ToLower(a.Tag), strings.ToLower(b.Tag))
+	})
+	return tags, nil
+}
+
 func (db *mockDB) GetAll() ([]bukudb.Bookmark, error) {
 	return db.bookmarks, nil
 }
 
+func (db *mockDB) GetAllContext(ctx context.Context) ([]bukudb.Bookmark, error) {
+	return db.GetAll()
+}
+
+func (db *mockDB) GetAllByCreated() ([]bukudb.Bookmark, error) {
+	bookmarks := slices.Clone(db.bookmarks)
+	slices.Reverse(bookmarks)
+	return bookmarks, nil
+}
+
+func (db *mockDB) GetRecent(n int, exclude []string) ([]bukudb.Bookmark, error) {
+	bookmarks, _ := db.SearchByTags(nil, exclude, false)
+	slices.Reverse(bookmarks)
+	return bookmarks[:min(n, len(bookmarks))], nil
+}
+
+func (db *mockDB) GetByIDs(ids []uint16) ([]bukudb.Bookmark, error) {
+	var bookmarks []bukudb.Bookmark
+	for _, id := range ids {
+		if b, err := db.Get(id); err == nil {
+			bookmarks = append(bookmarks, b)
+		}
+	}
+	return bookmarks, nil
+}
+
+func (db *mockDB) SearchRankedContext(ctx context.Context, query string) ([]bukudb.Bookmark, error) {
+	return db.SearchRanked(query)
+}
+
+func (db *mockDB) SearchRanked(query string) ([]bukudb.Bookmark, error) {
+	var found []bukudb.Bookmark
+	for _, b := range db.bookmarks {
+		text := strings.ToLower(strings.Join(
+			[]string{b.Title, b.URL, strings.Join(b.Tags, ","), b.Comment}, " "))
+		matches := true
+		for _, term := range strings.Fields(strings.ToLower(query)) {
+			matches = matches && strings.Contains(text, term)
+		}
+		if matches {
+			found = append(found, b)
+		}
+	}
+	return found, nil
+}
+
+func (db *mockDB) SearchByTags(include, exclude []string, matchAll bool) ([]bukudb.Bookmark, error) {
+	var found []bukudb.Bookmark
+	for _, b := range db.bookmarks {
+		has := func(t string) bool { return matchesTag(b.Tags, t) }
+		matches := true
+		if matchAll {
+			matches = !slices.ContainsFunc(include, func(t string) bool { return !has(t) })
+		} else if len(include) > 0 {
+			matches = slices.ContainsFunc(include, has)
+		}
+		for _, t := range exclude {
+			matches = matches && !matchesTag(b.Tags, t)
+		}
+		if matches {
+			found = append(found, b)
+		}
+	}
+	return found, nil
+}
+
+func (db *mockDB) SearchComments(query string) ([]bukudb.Bookmark, error) {
+	var found []bukudb.Bookmark
+	for _, b := range db.bookmarks {
+		comment := strings.ToLower(b.Comment)
+		if !slices.ContainsFunc(strings.Fields(strings.ToLower(query)), func(w string) bool {
+			return !strings.Contains(comment, w)
+		}) {
+			found = append(found, b)
+		}
+	}
+	return found, nil
+}
+
 func (db *mockDB) Get(id uint16) (bukudb.Bookmark, error) {
 	if id > uint16(len(db.bookmarks)) || id < 1 {
 		return bukudb.Bookmark{}, fmt.Errorf("id out of range")
@@ -51,17 +180,11 @@ func (db *mockDB) Get(id uint16) (bukudb.Bookmark, error) {
 }
 
 func (db *mockDB) Add(b bukudb.Bookmark) error {
-	isNew := true
-	for _, en := range db.bookmarks {
-		if b.URL == en.URL {
-			isNew = false
-			break
-		}
-	}
-	if isNew {
-		b.ID = 1 + uint16(db.Len())
-		db.bookmarks = append(db.bookmarks, b)
+	if dup := db.duplicateURL(0, b.URL); dup != nil {
+		return dup
 	}
+	b.ID = 1 + uint16(db.Len())
+	db.bookmarks = append(db.bookmarks, b)
 	return nil
 }
 
@@ -77,10 +200,24 @@ func (db *mockDB) UpdateURL(id uint16, url string) error {
 	if id > uint16(len(db.bookmarks)) || id < 1 {
 		return fmt.Errorf("id out of range")
 	}
+	if dup := db.duplicateURL(id, url); dup != nil {
+		return dup
+	}
 	db.bookmarks[id-1].URL = url
 	return nil
 }
 
+// duplicateURL returns the error of giving bookmark id the url of another
+// bookmark, like the UNIQUE URL column, or nil.
+func (db *mockDB) duplicateURL(id uint16, url string) error {
+	for _, en := range db.bookmarks {
+		if en.ID != id && en.URL == url {
+			return &bukudb.DuplicateURLError{URL: url, ID: en.ID}
+		}
+	}
+	return nil
+}
+
 func (db *mockDB) UpdateComment(id uint16, comment string) error {
 	if id > uint16(len(db.bookmarks)) || id < 1 {
 		return fmt.Errorf("id out of range")
@@ -89,6 +226,39 @@ func (db *mockDB) UpdateComment(id uint16, comment string) error {
 	return nil
 }
 
+func (db *mockDB) Update(id uint16, b bukudb.Bookmark, fields bukudb.FieldMask) error {
+	if id > uint16(len(db.bookmarks)) || id < 1 {
+		return fmt.Errorf("id out of range")
+	}
+	if fields&bukudb.MaskURL != 0 {
+		if dup := db.duplicateURL(id, b.URL); dup != nil {
+			return dup
+		}
+	}
+	current := &db.bookmarks[int(id-1)]
+	if fields&bukudb.MaskTitle != 0 {
+		current.Title = b.Title
+	}
+	if fields&bukudb.MaskURL != 0 {
+		current.URL = b.URL
+	}
+	if fields&bukudb.MaskComment != 0 {
+		current.Comment = b.Comment
+	}
+	if fields&bukudb.MaskTags != 0 {
+		current.Tags = b.Tags
+	}
+	return nil
+}
+
+func (db *mockDB) AppendComment(id uint16, note string) error {
+	if id > uint16(len(db.bookmarks)) || id < 1 {
+		return fmt.Errorf("id out of range")
+	}
+	db.bookmarks[id-1].Comment = appendNote(db.bookmarks[id-1].Comment, note)
+	return nil
+}
+
 func (db *mockDB) AddTags(id uint16, tags []string) error {
 	if id > uint16(len(db.bookmarks)) || id < 1 {
 		return fmt.Errorf("id out of range")
@@ -110,8 +280,8 @@ func (db *mockDB) RemoveTags(id uint16, tags []string) error {
 		return fmt.Errorf("id out of range")
 	}
 	tmp := make([]string, 0)
-	for _, t := range tags {
-		if !slices.Contains(db.bookmarks[id-1].Tags, t) {
+	for _, t := range db.bookmarks[id-1].Tags {
+		if !containsTag(tags, t) {
 			tmp = append(tmp, t)
 		}
 	}
@@ -131,26 +301,133 @@ func (db *mockDB) Remove(id uint16) error {
 	if id > uint16(len(db.bookmarks)) || id < 1 {
 		return fmt.Errorf("id out of range")
 	}
-	db.bookmarks = slices.Delete(db.bookmarks, int(id-1), 1)
+	b := db.bookmarks[id-1]
+	b.ID = 0
+	db.trash = append(db.trash, bukudb.TrashedBookmark{
+		Bookmark: b, TrashID: int64(len(db.trash) + 1), Deleted: time.Unix(0, 0)})
+	db.bookmarks = slices.Delete(db.bookmarks, int(id-1), int(id))
+	// renumber like BukuDB
+	for i := range db.bookmarks {
+		db.bookmarks[i].ID = uint16(i + 1)
+	}
+	return nil
+}
+
+func (db *mockDB) BulkRemove(ids []uint16) error {
+	for _, id := range ids {
+		if id > uint16(len(db.bookmarks)) || id < 1 {
+			return fmt.Errorf("id out of range")
+		}
+	}
+	sorted := slices.Clone(ids)
+	slices.Sort(sorted)
+	for _, id := range slices.Backward(slices.Compact(sorted)) {
+		if err := db.Remove(id); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (db *mockDB) GetLinkStatuses() (map[uint16]bukudb.LinkStatus, error) {
+	return db.statuses, nil
+}
+
+func (db *mockDB) RecordVisit(id uint16, at time.Time) error {
+	if id > uint16(len(db.bookmarks)) || id < 1 {
+		return fmt.Errorf("id out of range")
+	}
+	if db.visits == nil {
+		db.visits = make(map[uint16]bukudb.Visits)
+	}
+	v := db.visits[id]
+	db.visits[id] = bukudb.Visits{Count: v.Count + 1, Last: at}
+	return nil
+}
+
+func (db *mockDB) GetVisits(id uint16) (bukudb.Visits, error) {
+	return db.visits[id], nil
+}
+
+func (db *mockDB) ResetVisits(id uint16) error {
+	if id == 0 {
+		db.visits = nil
+	} else {
+		delete(db.visits, id)
+	}
+	return nil
+}
+
+func (db *mockDB) AddAlias(id uint16, url string) error {
+	if id > uint16(len(db.bookmarks)) || id < 1 {
+		return fmt.Errorf("id out of range")
+	}
+	if url == db.bookmarks[id-1].URL {
+		return bukudb.ErrAliasIsURL
+	}
+	if db.aliases == nil {
+		db.aliases = make(map[uint16][]string)
+	}
+	if !slices.Contains(db.aliases[id], url) {
+		db.aliases[id] = append(db.aliases[id], url)
+	}
 	return nil
 }
 
+func (db *mockDB) RemoveAlias(id uint16, url string) error {
+	db.aliases[id] = slices.DeleteFunc(db.aliases[id], func(a string) bool { return a == url })
+	return nil
+}
+
+func (db *mockDB) GetAliases(id uint16) ([]string, error) {
+	return slices.Clone(db.aliases[id]), nil
+}
+
+func (db *mockDB) GetAllAliases() (map[uint16][]string, error) {
+	return db.aliases, nil
+}
+
+func (db *mockDB) TrashList() ([]bukudb.TrashedBookmark, error) {
+	return db.trash, nil
+}
+
+func (db *mockDB) Restore(trashID int64) (uint16, error) {
+	for i, t := range db.trash {
+		if t.TrashID == trashID {
+			t.Bookmark.ID = uint16(len(db.bookmarks) + 1)
+			db.bookmarks = append(db.bookmarks, t.Bookmark)
+			db.trash = slices.Delete(db.trash, i, i+1)
+			return t.Bookmark.ID, nil
+		}
+	}
+	return 0, fmt.Errorf("no bookmark %d in trash", trashID)
+}
+
+func (db *mockDB) EmptyTrash() error {
+	db.trash = nil
+	return nil
+}
+
+func (db *mockDB) CheckBookmark(id uint16, url string) error {
+	return db.checkErr
+}
+
 func Test_HandleBookmarksShow(t *testing.T) {
 	in := initInputHandler(t)
 	in.HandleBookmarksShow()
 
 	expectedOptions := map[rofiapi.Option]string{
-		rofiapi.OptionMessage: generatePangoMarkup(
-			"add: Alt+1 | modify: Alt+2 | delete: Alt+3", "", ""),
-		rofiapi.OptionNoCustom: "true",
+		rofiapi.OptionMessage: defaultStyle.generatePangoMarkup(
+			"add: Alt+1 | modify: Alt+2 | delete: Alt+3 | stats: Alt+4 | copy as buku: Alt+5 | more…: Alt+0", "", ""),
+		rofiapi.OptionNoCustom: "false",
 	}
 	checkOptions(t, expectedOptions, in.api.Options)
 
 	expectedEntries := []rofiapi.Entry{
-		{Text: "0001. metadata (title) google", Meta: "google tag2 tag3 google.com"},
-		{Text: "0002. metadata (title) b", Meta: "b tag2 tag3 b.com"},
-		{Text: "0003. metadata (title) c", Meta: "c.com"},
-		{Text: "0004. https://www.d.com"},
+		{Text: "1. metadata (title) google", Meta: "google #google tag2 #tag2 tag3 #tag3 google.com desc comment"},
+		{Text: "2. metadata (title) b", Meta: "b #b tag2 #tag2 tag3 #tag3 b.com"},
+		{Text: "3. metadata (title) c", Meta: "c.com c"},
+		{Text: "4. https://www.d.com", Meta: "d.com d"},
 	}
 	checkEntries(t, expectedEntries, in.api.Entries)
 
@@ -161,6 +438,42 @@ func Test_HandleBookmarksShow(t *testing.T) {
 	}
 }
 
+func Test_HandleBookmarksShow_LinkStatuses(t *testing.T) {
+	in := initInputHandler(t)
+	in.db.(*mockDB).statuses = map[uint16]bukudb.LinkStatus{
+		2: {Dead: true, StatusCode: 404},
+		3: {Dead: false, StatusCode: 200},
+		4: {Dead: true},
+	}
+	in.HandleBookmarksShow()
+
+	// dead links use the urgent row style
+	expectedEntries := []rofiapi.Entry{
+		{Text: "1. metadata (title) google", Meta: "google #google tag2 #tag2 tag3 #tag3 google.com desc comment"},
+		{Text: "2. metadata (title) b", Meta: "b #b tag2 #tag2 tag3 #tag3 b.com", Urgent: true},
+		{Text: "3. metadata (title) c", Meta: "c.com c"},
+		{Text: "4. https://www.d.com", Meta: "d.com d", Urgent: true},
+	}
+	checkEntries(t, expectedEntries, in.api.Entries)
+
+	// or a prefix for themes without it
+	in.cfg.RowPrefixes = true
+	in.HandleBookmarksShow()
+	expectedEntries = []rofiapi.Entry{
+		{Text: "1. metadata (title) google", Meta: "google #google tag2 #tag2 tag3 #tag3 google.com desc comment"},
+		{Text: "2. " + deadLinkPrefix + "metadata (title) b", Meta: "b #b tag2 #tag2 tag3 #tag3 b.com"},
+		{Text: "3. metadata (title) c", Meta: "c.com c"},
+		{Text: "4. " + deadLinkPrefix + "https://www.d.com", Meta: "d.com d"},
+	}
+	checkEntries(t, expectedEntries, in.api.Entries)
+
+	// marked entries still select their bookmark
+	in.handleBookmarksSelect(in.api.Entries[1].Text, rofiapi.StateCustomKeybinding2)
+	if in.api.Data.Bookmark.ID != 2 {
+		t.Errorf("expected Bookmark ID '2', got '%d'", in.api.Data.Bookmark.ID)
+	}
+}
+
 func Test_handleBookmarksSelect(t *testing.T) {
 	in := initInputHandler(t)
 
@@ -168,16 +481,20 @@ func Test_handleBookmarksSelect(t *testing.T) {
 	in.handleBookmarksSelect("", rofiapi.StateCustomKeybinding1)
 	checkState(t, StateAddSelect, in.api.Data.State)
 
+	// selected stats option
+	in.handleBookmarksSelect("", rofiapi.StateCustomKeybinding4)
+	checkState(t, StateStatsSelect, in.api.Data.State)
+
 	// selected modify option
-	in.handleBookmarksSelect("0001. metadata (title) a", rofiapi.StateCustomKeybinding2)
+	in.handleBookmarksSelect("1. metadata (title) a", rofiapi.StateCustomKeybinding2)
 	checkState(t, StateModifySelect, in.api.Data.State)
 
 	// selected delete option
-	in.handleBookmarksSelect("0001. metadata (title) a", rofiapi.StateCustomKeybinding3)
+	in.handleBookmarksSelect("1. metadata (title) a", rofiapi.StateCustomKeybinding3)
 	checkState(t, StateDeleteConfirmSelect, in.api.Data.State)
 
 	// selected valid bookmark
-	in.handleBookmarksSelect("0001. metadata (title) a", rofiapi.StateSelected)
+	in.handleBookmarksSelect("1. metadata (title) a", rofiapi.StateSelected)
 	checkState(t, StateGotoExec, in.api.Data.State)
 	if in.api.Data.Bookmark.ID != 1 {
 		t.Errorf("expected Bookmark ID '1', got '%d'", in.api.Data.Bookmark.ID)
@@ -192,12 +509,42 @@ func Test_handleBookmarksSelect(t *testing.T) {
 	checkState(t, StateErrorShow, in.api.Data.State)
 }
 
+func Test_handleBookmarksSelect_EmptyInput(t *testing.T) {
+	// add and stats need no bookmark
+	in := initInputHandler(t)
+	in.handleBookmarksSelect("", rofiapi.StateCustomKeybinding1)
+	checkState(t, StateAddSelect, in.api.Data.State)
+
+	in = initInputHandler(t)
+	in.handleBookmarksSelect("", rofiapi.StateCustomKeybinding4)
+	checkState(t, StateStatsSelect, in.api.Data.State)
+
+	// modify, delete, and open re-render the list with an error
+	for _, rofiState := range []rofiapi.State{
+		rofiapi.StateCustomKeybinding2,
+		rofiapi.StateCustomKeybinding3,
+		rofiapi.StateSelected,
+	} {
+		in = initInputHandler(t)
+		in.handleBookmarksSelect("", rofiState)
+		checkState(t, StateBookmarksSelect, in.api.Data.State)
+		if !strings.Contains(in.api.Options[rofiapi.OptionMessage], errNoBookmarkSelected.Error()) {
+			t.Errorf("rofi state %d: expected message to contain '%s', got '%s'",
+				rofiState, errNoBookmarkSelected, in.api.Options[rofiapi.OptionMessage])
+		}
+		if len(in.api.Entries) != in.db.Len() {
+			t.Errorf("rofi state %d: expected %d entries, got %d",
+				rofiState, in.db.Len(), len(in.api.Entries))
+		}
+	}
+}
+
 func Test_handleAddShow(t *testing.T) {
 	in := initInputHandler(t)
 	in.handleAddShow()
 
 	expectedOptions := map[rofiapi.Option]string{
-		rofiapi.OptionMessage: generatePangoMarkup(
+		rofiapi.OptionMessage: defaultStyle.generatePangoMarkup(
 			"select a field to add, all are optional except the url", "", ""),
 		rofiapi.OptionNoCustom: "true",
 	}
@@ -208,7 +555,7 @@ func Test_handleAddShow(t *testing.T) {
 	expectedEntries := []rofiapi.Entry{{Text: opBack}}
 	bookmark := multiLineBookmark(b)
 	for _, l := range bookmark {
-		expectedEntries = append(expectedEntries, rofiapi.Entry{Text: l})
+		expectedEntries = append(expectedEntries, rofiapi.Entry{Text: l.Text, Info: l.info()})
 	}
 	expectedEntries = append(expectedEntries, rofiapi.Entry{Text: opConfirm})
 	checkEntries(t, expectedEntries, in.api.Entries)
@@ -227,30 +574,80 @@ func Test_handleAddSelect(t *testing.T) {
 	in.handleAddSelect(opConfirm)
 	checkState(t, StateErrorShow, in.api.Data.State)
 
-	// selected confirm option with url entered
+	// selected confirm option with url entered, the filter is cleared and
+	// the new bookmark highlighted
+	in.api.Data.Filters = []Filter{{Tag: "google"}}
 	in.api.Data.Bookmark.URL = "https://www.a-new-bookmark.com"
 	in.handleAddSelect(opConfirm)
 	checkState(t, StateBookmarksSelect, in.api.Data.State)
+	if len(in.api.Data.Filters) != 0 {
+		t.Errorf("expected filters to be cleared, got %v", in.api.Data.Filters)
+	}
+	if selected := in.api.Options[rofiapi.OptionNewSelection]; selected != "4" ||
+		in.api.Entries[4].Text != "5. https://www.a-new-bookmark.com" {
+		t.Errorf("expected the new bookmark to be selected, got row '%s' of %v", selected, in.api.Entries)
+	}
+	if in.api.Options[rofiapi.OptionKeepSelection] != "true" {
+		t.Errorf("expected the selection to be kept")
+	}
+	message := in.api.Options[rofiapi.OptionMessage]
+	if !strings.Contains(message, "added #0005 a-new-bookmark.com") ||
+		!strings.Contains(message, "cleared filter tag:google") {
+		t.Errorf("expected added and cleared filter notes in message, got '%s'", message)
+	}
+
+	// without a filter there is no note
+	in.api.Data.Bookmark = bukudb.Bookmark{URL: "https://b.example.org/x"}
+	in.handleAddSelect(opConfirm)
+	message = in.api.Options[rofiapi.OptionMessage]
+	if !strings.Contains(message, "added #0006 b.example.org") || strings.Contains(message, "cleared filter") {
+		t.Errorf("expected only the added note in message, got '%s'", message)
+	}
+	if selected := in.api.Options[rofiapi.OptionNewSelection]; selected != "5" {
+		t.Errorf("expected row 5 to be selected, got '%s'", selected)
+	}
+
+	// entries as shown by handleAddShow
+	in.api.Data.Bookmark = bukudb.Bookmark{}
+	b := bukudb.Bookmark{ID: uint16(in.db.Len() + 1)}
+	lines := multiLineBookmark(b)
 
 	// selected title
-	in.handleAddSelect("1. (title)")
+	in.handleAddSelect(lines[0].Text)
 	checkState(t, StateAddTitleSelect, in.api.Data.State)
 
 	// selected url
-	in.handleAddSelect("> (url)")
+	in.handleAddSelect(lines[1].Text)
 	checkState(t, StateAddUrlSelect, in.api.Data.State)
 
 	// selected comment
-	in.handleAddSelect("+ (comment)")
+	in.handleAddSelect(lines[2].Text)
 	checkState(t, StateAddCommentSelect, in.api.Data.State)
 
 	// selected tags
-	in.handleAddSelect("# (tags)")
+	in.handleAddSelect(lines[3].Text)
 	checkState(t, StateAddTagsSelect, in.api.Data.State)
 
 	// selected invalid
 	in.handleAddSelect("AAAAAAA")
 	checkState(t, StateAddSelect, in.api.Data.State)
+
+	// selected entry with prefix but not matching
+	in.handleAddSelect("> (url) but different")
+	checkState(t, StateAddSelect, in.api.Data.State)
+
+	// empty input
+	in.handleAddSelect("")
+	checkState(t, StateAddSelect, in.api.Data.State)
+
+	// titles starting with field prefixes or equal to a placeholder
+	for _, title := range []string{"+ plus", "> greater", "# hash", "(Title)", "(Url)"} {
+		in.api.Data.Bookmark = bukudb.Bookmark{Title: title, URL: "https://x.com"}
+		b = in.api.Data.Bookmark
+		b.ID = uint16(in.db.Len() + 1)
+		in.handleAddSelect(multiLineBookmark(b)[0].Text)
+		checkState(t, StateAddTitleSelect, in.api.Data.State)
+	}
 }
 
 func Test_handleAddTitleShow(t *testing.T) {
@@ -258,7 +655,7 @@ func Test_handleAddTitleShow(t *testing.T) {
 	in.handleAddTitleShow()
 
 	expectedOptions := map[rofiapi.Option]string{
-		rofiapi.OptionMessage:  generatePangoMarkup("enter a title", "", ""),
+		rofiapi.OptionMessage:  defaultStyle.generatePangoMarkup("enter a title", "", ""),
 		rofiapi.OptionNoCustom: "false",
 	}
 	checkOptions(t, expectedOptions, in.api.Options)
@@ -294,6 +691,14 @@ func Test_handleAddTitleSelect(t *testing.T) {
 		t.Errorf("expected bookmark title '', got '%v'",
 			in.api.Data.Bookmark.Title)
 	}
+
+	// pasted title cleaned up
+	in.cfg.TitleStrip = []*regexp.Regexp{regexp.MustCompile(`\s+-\s+YouTube$`)}
+	in.handleAddTitleSelect("some\n  title - YouTube")
+	if in.api.Data.Bookmark.Title != "some title" {
+		t.Errorf("expected bookmark title 'some title', got '%v'",
+			in.api.Data.Bookmark.Title)
+	}
 }
 
 func Test_handleAddUrlShow(t *testing.T) {
@@ -301,7 +706,7 @@ func Test_handleAddUrlShow(t *testing.T) {
 	in.handleAddUrlShow()
 
 	expectedOptions := map[rofiapi.Option]string{
-		rofiapi.OptionMessage:  generatePangoMarkup("enter a url", "", ""),
+		rofiapi.OptionMessage:  defaultStyle.generatePangoMarkup("enter a url", "", ""),
 		rofiapi.OptionNoCustom: "false",
 	}
 	checkOptions(t, expectedOptions, in.api.Options)
@@ -344,7 +749,7 @@ func Test_handleAddCommentShow(t *testing.T) {
 	in.handleAddCommentShow()
 
 	expectedOptions := map[rofiapi.Option]string{
-		rofiapi.OptionMessage:  generatePangoMarkup("enter a comment", "", ""),
+		rofiapi.OptionMessage:  defaultStyle.generatePangoMarkup("enter a comment", "", ""),
 		rofiapi.OptionNoCustom: "false",
 	}
 	checkOptions(t, expectedOptions, in.api.Options)
@@ -387,7 +792,7 @@ func Test_handleAddTagsShow(t *testing.T) {
 	in.handleAddTagsShow()
 
 	expectedOptions := map[rofiapi.Option]string{
-		rofiapi.OptionMessage: generatePangoMarkup(
+		rofiapi.OptionMessage: defaultStyle.generatePangoMarkup(
 			"enter some tags", "'mytag, some-tag, a tag'", ""),
 		rofiapi.OptionNoCustom: "false",
 	}
@@ -428,6 +833,163 @@ func Test_handleAddTagsSelect(t *testing.T) {
 		t.Errorf("expected bookmark tags empty , got length '%v'",
 			in.api.Data.Bookmark.Tags)
 	}
+
+	// entered tags with empty segments and duplicates
+	in.handleAddTagsSelect("foo,,Bar, , bar,FOO, ")
+	checkState(t, StateAddSelect, in.api.Data.State)
+	if !slices.Equal(in.api.Data.Bookmark.Tags, []string{"Bar", "foo"}) {
+		t.Errorf("expected bookmark tags '[Bar foo]', got '%v'", in.api.Data.Bookmark.Tags)
+	}
+
+	// entered only commas and whitespace
+	in.handleAddTagsSelect(" , ,, ")
+	checkState(t, StateAddTagsSelect, in.api.Data.State)
+	if !strings.Contains(in.api.Options[rofiapi.OptionMessage], errNoTags.Error()) {
+		t.Errorf("expected message to contain '%s', got '%s'",
+			errNoTags, in.api.Options[rofiapi.OptionMessage])
+	}
+	if !slices.Equal(in.api.Data.Bookmark.Tags, []string{"Bar", "foo"}) {
+		t.Errorf("expected bookmark tags '[Bar foo]', got '%v'", in.api.Data.Bookmark.Tags)
+	}
+}
+
+func Test_getIdFromBookmarkString(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected uint16
+		ok       bool
+	}{
+		{"1. metadata (title) google", 1, true},
+		{"01. metadata (title) google", 1, true},
+		{"0001. metadata (title) google", 1, true},
+		{"12. v1.2 release notes", 12, true},
+		{"65535. last", 65535, true},
+		{"65536. too big", 0, false},
+		{"metadata. no id", 0, false},
+		{"", 0, false},
+		{"  7. leading space", 7, true},
+		{"42", 42, true},
+		{" 42 ", 42, true},
+		{"４２. full-width", 42, true},
+		{"0. zero", 0, false},
+		{"0", 0, false},
+		{"-1. negative", 0, false},
+		{"4 2. inner space", 0, false},
+	}
+	for _, test := range tests {
+		id, err := getIdFromBookmarkString(test.input)
+		if id != test.expected || (err == nil) != test.ok {
+			t.Errorf("input '%s': expected %d and ok %v, got %d and error '%v'",
+				test.input, test.expected, test.ok, id, err)
+		}
+	}
+
+	_, err := getIdFromBookmarkString("some long title without an id")
+	if err == nil || strings.Contains(err.Error(), "without an id") {
+		t.Errorf("expected error naming only the prefix, got '%v'", err)
+	}
+	_, err = getIdFromBookmarkString("0. zero")
+	if err == nil || err.Error() != "bookmark ids start at 1" {
+		t.Errorf("expected zero to be rejected, got '%v'", err)
+	}
+}
+
+func Test_idWidth(t *testing.T) {
+	tests := []struct {
+		ids      []uint16
+		expected int
+	}{
+		{nil, 1},
+		{[]uint16{1, 4}, 1},
+		{[]uint16{3, 12, 9}, 2},
+		{[]uint16{100}, 3},
+		{[]uint16{65535}, 5},
+	}
+	for _, test := range tests {
+		bookmarks := make([]bukudb.Bookmark, len(test.ids))
+		for i, id := range test.ids {
+			bookmarks[i].ID = id
+		}
+		if actual := idWidth(bookmarks); actual != test.expected {
+			t.Errorf("ids %v: expected width %d, got %d", test.ids, test.expected, actual)
+		}
+	}
+}
+
+func Test_HandleBookmarksShow_IDPadding(t *testing.T) {
+	in := initInputHandler(t)
+	db := in.db.(*mockDB)
+	for i := db.Len(); i < 12; i++ {
+		db.Add(bukudb.Bookmark{URL: fmt.Sprintf("https://%d.com", i+1)})
+	}
+
+	in.HandleBookmarksShow()
+	if len(in.api.Entries) != 12 {
+		t.Fatalf("expected 12 entries, got %d", len(in.api.Entries))
+	}
+	if text := in.api.Entries[0].Text; text != "01. metadata (title) google" {
+		t.Errorf("expected first entry padded to 2 digits, got '%s'", text)
+	}
+	if text := in.api.Entries[11].Text; text != "12. https://12.com" {
+		t.Errorf("expected last entry '12. https://12.com', got '%s'", text)
+	}
+
+	// the width follows the highest ID shown
+	in.api.Data.Filters = []Filter{{Tag: "tag2"}}
+	in.HandleBookmarksShow()
+	checkEntryTexts(t, []string{opBack, opExportList, "1. metadata (title) google", "2. metadata (title) b"}, in.api.Entries)
+}
+
+func Test_getTagsFromInput(t *testing.T) {
+	tests := []struct {
+		input    string
+		split    config.TagSplit
+		expected []string
+	}{
+		{"a, b", config.TagSplitComma, []string{"a", "b"}},
+		{"a, b,", config.TagSplitComma, []string{"a", "b"}},
+		{"a,,b, ,", config.TagSplitComma, []string{"a", "b"}},
+		{" ,  , ", config.TagSplitComma, []string{}},
+		{"", config.TagSplitComma, []string{}},
+		{"Tag, tag, TAG, other", config.TagSplitComma, []string{"Tag", "other"}},
+		{"a tag, some-tag", config.TagSplitComma, []string{"a tag", "some-tag"}},
+		{"machine learning, go", config.TagSplitComma, []string{"machine learning", "go"}},
+
+		{"machine learning, go", config.TagSplitCommaSpace, []string{"machine", "learning", "go"}},
+		{"a,b  c\td", config.TagSplitCommaSpace, []string{"a", "b", "c", "d"}},
+		{" , \t, ", config.TagSplitCommaSpace, []string{}},
+		{"Tag tag,TAG other", config.TagSplitCommaSpace, []string{"Tag", "other"}},
+		{"lang/go some-tag", config.TagSplitCommaSpace, []string{"lang/go", "some-tag"}},
+	}
+	for _, test := range tests {
+		if actual := getTagsFromInput(test.input, test.split); !slices.Equal(actual, test.expected) {
+			t.Errorf("input '%s' (%s): expected '%q', got '%q'", test.input, test.split, test.expected, actual)
+		}
+	}
+}
+
+func Test_handleTagsSelect_TagSplit(t *testing.T) {
+	in := initInputHandler(t)
+	in.cfg.TagSplit = config.TagSplitCommaSpace
+
+	in.handleAddTagsShow()
+	if msg := in.api.Options[rofiapi.OptionMessage]; !strings.Contains(msg, "mytag some-tag, other") {
+		t.Errorf("expected the space separated example, got '%s'", msg)
+	}
+	in.handleAddTagsSelect("machine learning")
+	if !slices.Equal(in.api.Data.Bookmark.Tags, []string{"learning", "machine"}) {
+		t.Errorf("expected tags 'learning' and 'machine', got '%q'", in.api.Data.Bookmark.Tags)
+	}
+
+	in.api.Data.Bookmark, _ = in.db.Get(3)
+	in.handleModifyTagsShow()
+	if msg := in.api.Options[rofiapi.OptionMessage]; !strings.Contains(msg, "newtag1 newtag2") {
+		t.Errorf("expected the space separated example, got '%s'", msg)
+	}
+	in.handleModifyTagsSelect("+ deep learning")
+	if b, _ := in.db.Get(3); !slices.Equal(b.Tags, []string{"deep", "learning"}) {
+		t.Errorf("expected tags 'deep' and 'learning', got '%q'", b.Tags)
+	}
 }
 
 func Test_handleModifyShow(t *testing.T) {
@@ -435,7 +997,7 @@ func Test_handleModifyShow(t *testing.T) {
 	in.handleModifyShow()
 
 	expectedOptions := map[rofiapi.Option]string{
-		rofiapi.OptionMessage:  generatePangoMarkup("select a field to edit", "", ""),
+		rofiapi.OptionMessage:  defaultStyle.generatePangoMarkup("select a field to edit", "", ""),
 		rofiapi.OptionNoCustom: "true",
 	}
 	checkOptions(t, expectedOptions, in.api.Options)
@@ -443,11 +1005,20 @@ func Test_handleModifyShow(t *testing.T) {
 	expectedEntries := []rofiapi.Entry{{Text: opBack}}
 	bookmark := multiLineBookmark(in.api.Data.Bookmark)
 	for _, l := range bookmark {
-		expectedEntries = append(expectedEntries, rofiapi.Entry{Text: l})
+		expectedEntries = append(expectedEntries, rofiapi.Entry{Text: l.Text, Info: l.info()})
 	}
 	checkEntries(t, expectedEntries, in.api.Entries)
 
 	checkState(t, StateModifySelect, in.api.Data.State)
+
+	// bookmark with a known creation time
+	created := time.Date(2024, 3, 9, 12, 0, 0, 0, time.Local)
+	in.api.Data.Bookmark.Created = &created
+	in.handleModifyShow()
+	last := in.api.Entries[len(in.api.Entries)-1]
+	if last.Text != "added: 2024-03-09" || !last.NonSelectable {
+		t.Errorf("expected non-selectable entry 'added: 2024-03-09', got '%v'", last)
+	}
 }
 
 func Test_handleModifySelect(t *testing.T) {
@@ -457,25 +1028,204 @@ func Test_handleModifySelect(t *testing.T) {
 	in.handleModifySelect(opBack)
 	checkState(t, StateBookmarksSelect, in.api.Data.State)
 
+	// entries as shown by handleModifyShow
+	in.api.Data.Bookmark, _ = in.db.Get(1)
+	lines := multiLineBookmark(in.api.Data.Bookmark)
+
 	// selected title
-	in.handleModifySelect("1. (title)")
+	in.handleModifySelect(lines[0].Text)
 	checkState(t, StateModifyTitleSelect, in.api.Data.State)
 
 	// selected url
-	in.handleModifySelect("> (url)")
+	in.handleModifySelect(lines[1].Text)
 	checkState(t, StateModifyUrlSelect, in.api.Data.State)
 
 	// selected comment
-	in.handleModifySelect("+ (comment)")
+	in.handleModifySelect(lines[2].Text)
 	checkState(t, StateModifyCommentSelect, in.api.Data.State)
 
 	// selected tags
-	in.handleModifySelect("# (tags)")
+	in.handleModifySelect(lines[len(lines)-1].Text)
 	checkState(t, StateModifyTagsSelect, in.api.Data.State)
 
 	// selected invalid
 	in.handleModifySelect("AAAAAAA")
 	checkState(t, StateModifySelect, in.api.Data.State)
+
+	// empty input
+	in.handleModifySelect("")
+	checkState(t, StateModifySelect, in.api.Data.State)
+
+	// titles starting with field prefixes or equal to a placeholder
+	for _, title := range []string{"+ plus", "> greater", "# hash", "(Title)", "(Url)"} {
+		in.api.Data.Bookmark.Title = title
+		in.handleModifySelect(multiLineBookmark(in.api.Data.Bookmark)[0].Text)
+		checkState(t, StateModifyTitleSelect, in.api.Data.State)
+	}
+
+	// comment starting with tags prefix
+	in.api.Data.Bookmark.Comment = "# not tags"
+	in.handleModifySelect(multiLineBookmark(in.api.Data.Bookmark)[2].Text)
+	checkState(t, StateModifyCommentSelect, in.api.Data.State)
+
+	// continuation line of a wrapped comment
+	in.api.Data.Bookmark.Comment = "first paragraph\nsecond paragraph"
+	lines = multiLineBookmark(in.api.Data.Bookmark)
+	in.handleModifySelect(lines[3].Text)
+	checkState(t, StateModifyCommentSelect, in.api.Data.State)
+}
+
+func Test_multiLineBookmark(t *testing.T) {
+	b := bukudb.Bookmark{ID: 7, URL: "https://a.com", Title: "A",
+		Comment: "one\ntwo\nthree\nfour\nfive", Tags: []string{"x", "y"}}
+
+	expected := []bookmarkLine{
+		{"7. A", fieldTitle, false},
+		{"> https://a.com", fieldURL, false},
+		{"+ one", fieldComment, false},
+		{"+ two", fieldComment, false},
+		{"+ three", fieldComment, false},
+		{"+ four…", fieldComment, false},
+		{"# x, y", fieldTags, false},
+	}
+	if actual := multiLineBookmark(b); !slices.Equal(expected, actual) {
+		t.Errorf("expected lines '%v', got '%v'", expected, actual)
+	}
+
+	expected = []bookmarkLine{
+		{"0. (Title)", fieldTitle, true},
+		{"> (Url)", fieldURL, true},
+		{"+ (Comment)", fieldComment, true},
+		{"# (Tags)", fieldTags, true},
+	}
+	if actual := multiLineBookmark(bukudb.Bookmark{}); !slices.Equal(expected, actual) {
+		t.Errorf("expected lines '%v', got '%v'", expected, actual)
+	}
+
+	// fields equal to the placeholders are not placeholders
+	b = bukudb.Bookmark{Title: "(Title)", URL: "(Url)", Comment: "(Comment)", Tags: []string{"(Tags)"}}
+	expected = []bookmarkLine{
+		{"0. (Title)", fieldTitle, false},
+		{"> (Url)", fieldURL, false},
+		{"+ (Comment)", fieldComment, false},
+		{"# (Tags)", fieldTags, false},
+	}
+	if actual := multiLineBookmark(b); !slices.Equal(expected, actual) {
+		t.Errorf("expected lines '%v', got '%v'", expected, actual)
+	}
+}
+
+func Test_parseFieldInfo(t *testing.T) {
+	tests := []struct {
+		info        string
+		field       bookmarkField
+		placeholder bool
+		ok          bool
+	}{
+		{"field:title", fieldTitle, false, true},
+		{"field:url:empty", fieldURL, true, true},
+		{"field:comment", fieldComment, false, true},
+		{"field:tags:empty", fieldTags, true, true},
+		{"field:other", fieldNone, false, false},
+		{"(Title)", fieldNone, false, false},
+		{"", fieldNone, false, false},
+	}
+
+	for _, tt := range tests {
+		field, placeholder, ok := parseFieldInfo(tt.info)
+		if field != tt.field || placeholder != tt.placeholder || ok != tt.ok {
+			t.Errorf("info '%s': expected %v %v %v, got %v %v %v", tt.info,
+				tt.field, tt.placeholder, tt.ok, field, placeholder, ok)
+		}
+	}
+
+	for _, l := range multiLineBookmark(bukudb.Bookmark{Title: "(Title)"}) {
+		field, placeholder, ok := parseFieldInfo(l.info())
+		if !ok || field != l.Field || placeholder != l.Placeholder {
+			t.Errorf("line '%s': info '%s' does not round trip", l.Text, l.info())
+		}
+	}
+}
+
+func Test_bookmarkLineEntry(t *testing.T) {
+	in := initInputHandler(t)
+	b := bukudb.Bookmark{ID: 3, Title: "(Title)", Comment: "a <b> & c"}
+	lines := multiLineBookmark(b)
+
+	// without rich entries there is no display text
+	for _, l := range lines {
+		if e := in.bookmarkLineEntry(l); e.Display != "" || e.Text != l.Text {
+			t.Errorf("line '%s': expected plain entry, got %+v", l.Text, e)
+		}
+	}
+
+	in.cfg.RichEntries = true
+	entries := []rofiapi.Entry{{Text: opBack}}
+	for _, l := range lines {
+		entries = append(entries, in.bookmarkLineEntry(l))
+	}
+	in.useRichEntries(entries)
+
+	expected := []string{
+		"&lt;-- Back",
+		"3. (Title)",
+		"&gt; <i>(Url)</i>",
+		"+ a &lt;b&gt; &amp; c",
+		"# <i>(Tags)</i>",
+	}
+	for i, e := range entries {
+		if e.Display != expected[i] {
+			t.Errorf("entry %d: expected display '%s', got '%s'", i, expected[i], e.Display)
+		}
+	}
+	if in.api.Options[rofiapi.OptionMarkupRows] != "true" {
+		t.Errorf("expected entry markup to be enabled, got '%s'", in.api.Options[rofiapi.OptionMarkupRows])
+	}
+}
+
+func Test_truncateEnd(t *testing.T) {
+	tests := []struct {
+		input    string
+		l        int
+		expected string
+	}{
+		{"abcdef", 3, "abc"},
+		{"abc", 3, "abc"},
+		{"ééééé", 3, "ééé"},
+		{"abc", -1, "abc"},
+	}
+
+	for _, tt := range tests {
+		if actual := truncateEnd(tt.input, tt.l); actual != tt.expected {
+			t.Errorf("expected truncateEnd('%s', %d) '%s', got '%s'", tt.input, tt.l, tt.expected, actual)
+		}
+	}
+}
+
+func Test_wrapText(t *testing.T) {
+	tests := []struct {
+		name     string
+		input    string
+		width    int
+		expected []string
+	}{
+		{"empty", "", 10, nil},
+		{"fits", "short text", 10, []string{"short text"}},
+		{"wraps at spaces", "aaa bbb ccc", 7, []string{"aaa bbb", "ccc"}},
+		{"collapses spaces", "  aaa   bbb  ", 10, []string{"aaa bbb"}},
+		{"paragraphs", "aaa\n\nbbb", 10, []string{"aaa", "bbb"}},
+		{"utf-8", "ééé ààà ùùù", 7, []string{"ééé ààà", "ùùù"}},
+		{"long token", "see https://example.com/a/very/long/path ok", 12,
+			[]string{"see", "https://exam", "ple.com/a/ve", "ry/long/path", "ok"}},
+		{"long utf-8 token", "日本語日本語日本語", 4, []string{"日本語日", "本語日本", "語"}},
+		{"zero width", "abc", 0, nil},
+	}
+
+	for _, tt := range tests {
+		if actual := wrapText(tt.input, tt.width); !slices.Equal(tt.expected, actual) {
+			t.Errorf("%s: expected lines '%q', got '%q'", tt.name, tt.expected, actual)
+		}
+	}
 }
 
 func Test_handleModifyTitleShow(t *testing.T) {
@@ -484,7 +1234,7 @@ func Test_handleModifyTitleShow(t *testing.T) {
 	in.handleModifyTitleShow()
 
 	expectedOptions := map[rofiapi.Option]string{
-		rofiapi.OptionMessage: generatePangoMarkup(
+		rofiapi.OptionMessage: defaultStyle.generatePangoMarkup(
 			"enter a new title", "", in.api.Data.Bookmark.Title),
 		rofiapi.OptionNoCustom: "false",
 	}
@@ -501,10 +1251,9 @@ func Test_handleModifyTitleShow(t *testing.T) {
 
 func Test_handleModifyTitleSelect(t *testing.T) {
 	in := initInputHandler(t)
-	in.api.Data.Bookmark.ID = 1
 
 	// selected delete option
-	in.api.Data.Bookmark.Title = "some title"
+	loadBookmark(in, bukudb.Bookmark{ID: 1, Title: "some title"})
 	in.handleModifyTitleSelect(opDelete)
 	checkState(t, StateModifySelect, in.api.Data.State)
 	if in.api.Data.Bookmark.Title != "" {
@@ -521,6 +1270,18 @@ func Test_handleModifyTitleSelect(t *testing.T) {
 	if in.api.Data.Bookmark.Title != "some new title" {
 		t.Errorf("expected bookmark title 'some new title', got '%s'", in.api.Data.Bookmark.Title)
 	}
+
+	// pasted title cleaned up, unless nothing would be left
+	in.cfg.TitleStrip = []*regexp.Regexp{regexp.MustCompile(`\s+-\s+YouTube$`)}
+	in.handleModifyTitleSelect("some\tpasted   title - YouTube")
+	if b, _ := in.db.Get(1); b.Title != "some pasted title" || in.api.Data.Bookmark.Title != b.Title {
+		t.Errorf("expected bookmark title 'some pasted title', got '%s'", b.Title)
+	}
+	in.cfg.TitleStrip = []*regexp.Regexp{regexp.MustCompile(`.*`)}
+	in.handleModifyTitleSelect("only strip")
+	if b, _ := in.db.Get(1); b.Title != "only strip" {
+		t.Errorf("expected bookmark title 'only strip', got '%s'", b.Title)
+	}
 }
 
 func Test_handleModifyUrlShow(t *testing.T) {
@@ -529,7 +1290,7 @@ func Test_handleModifyUrlShow(t *testing.T) {
 	in.handleModifyUrlShow()
 
 	expectedOptions := map[rofiapi.Option]string{
-		rofiapi.OptionMessage: generatePangoMarkup(
+		rofiapi.OptionMessage: defaultStyle.generatePangoMarkup(
 			"enter a new url", "", in.api.Data.Bookmark.URL),
 		rofiapi.OptionNoCustom: "false",
 	}
@@ -545,10 +1306,9 @@ func Test_handleModifyUrlShow(t *testing.T) {
 
 func Test_handleModifyUrlSelect(t *testing.T) {
 	in := initInputHandler(t)
-	in.api.Data.Bookmark.ID = 1
 
 	// entered empty input
-	in.api.Data.Bookmark.URL = "old url"
+	loadBookmark(in, bukudb.Bookmark{ID: 1, URL: "old url"})
 	in.handleModifyUrlSelect("")
 	checkState(t, StateModifyUrlSelect, in.api.Data.State)
 	if in.api.Data.Bookmark.URL != "old url" {
@@ -573,7 +1333,7 @@ func Test_handleModifyCommentShow(t *testing.T) {
 	in.handleModifyCommentShow()
 
 	expectedOptions := map[rofiapi.Option]string{
-		rofiapi.OptionMessage: generatePangoMarkup(
+		rofiapi.OptionMessage: defaultStyle.generatePangoMarkup(
 			"enter a new comment", "", in.api.Data.Bookmark.Comment),
 		rofiapi.OptionNoCustom: "false",
 	}
@@ -590,10 +1350,9 @@ func Test_handleModifyCommentShow(t *testing.T) {
 
 func Test_handleModifyCommentSelect(t *testing.T) {
 	in := initInputHandler(t)
-	in.api.Data.Bookmark.ID = 1
 
 	// selected delete option
-	in.api.Data.Bookmark.Comment = "some comment"
+	loadBookmark(in, bukudb.Bookmark{ID: 1, Comment: "some comment"})
 	in.handleModifyCommentSelect(opDelete)
 	checkState(t, StateModifySelect, in.api.Data.State)
 	if in.api.Data.Bookmark.Comment != "" {
@@ -619,7 +1378,7 @@ func Test_handleModifyTagShow(t *testing.T) {
 	in.handleModifyTagsShow()
 
 	expectedOptions := map[rofiapi.Option]string{
-		rofiapi.OptionMessage: generatePangoMarkup(
+		rofiapi.OptionMessage: defaultStyle.generatePangoMarkup(
 			"add or remove tags",
 			"'+ newtag1, ...' or '- oldtag1, ...'",
 			strings.Join(in.api.Data.Bookmark.Tags, ", ")),
@@ -638,14 +1397,14 @@ func Test_handleModifyTagShow(t *testing.T) {
 
 func Test_handleModifyTagSelect(t *testing.T) {
 	in := initInputHandler(t)
-	in.api.Data.Bookmark.ID = 1
+	loadBookmark(in, bukudb.Bookmark{ID: 1})
 
 	// selected back option
 	in.handleModifyTagsSelect(opBack)
 	checkState(t, StateModifySelect, in.api.Data.State)
 
 	// selected delete option
-	in.api.Data.Bookmark.Tags = []string{"tag1", "tag2"}
+	loadBookmark(in, bukudb.Bookmark{ID: 1, Tags: []string{"tag1", "tag2"}})
 	in.handleModifyTagsSelect(opDelete)
 	checkState(t, StateModifySelect, in.api.Data.State)
 	if len(in.api.Data.Bookmark.Tags) != 0 {
@@ -653,7 +1412,7 @@ func Test_handleModifyTagSelect(t *testing.T) {
 	}
 
 	// entered new tags starting with + prefix
-	in.api.Data.Bookmark.Tags = []string{"tag1", "tag2"}
+	loadBookmark(in, bukudb.Bookmark{ID: 1, Tags: []string{"tag1", "tag2"}})
 	in.handleModifyTagsSelect("+ wow, zow")
 	checkState(t, StateModifySelect, in.api.Data.State)
 	if len(in.api.Data.Bookmark.Tags) != 4 {
@@ -662,7 +1421,7 @@ func Test_handleModifyTagSelect(t *testing.T) {
 	}
 
 	// entered existing tags starting with + prefix
-	in.api.Data.Bookmark.Tags = []string{"tag1", "tag2"}
+	loadBookmark(in, bukudb.Bookmark{ID: 1, Tags: []string{"tag1", "tag2"}})
 	in.handleModifyTagsSelect("+ tag1, tag2")
 	checkState(t, StateModifySelect, in.api.Data.State)
 	if len(in.api.Data.Bookmark.Tags) != 2 {
@@ -671,7 +1430,7 @@ func Test_handleModifyTagSelect(t *testing.T) {
 	}
 
 	// entered existing tags starting with - prefix
-	in.api.Data.Bookmark.Tags = []string{"tag1", "tag2"}
+	loadBookmark(in, bukudb.Bookmark{ID: 1, Tags: []string{"tag1", "tag2"}})
 	in.handleModifyTagsSelect("- tag1, tag2")
 	checkState(t, StateModifySelect, in.api.Data.State)
 	if len(in.api.Data.Bookmark.Tags) != 0 {
@@ -680,7 +1439,7 @@ func Test_handleModifyTagSelect(t *testing.T) {
 	}
 
 	// entered new tags starting with - prefix
-	in.api.Data.Bookmark.Tags = []string{"tag1", "tag2"}
+	loadBookmark(in, bukudb.Bookmark{ID: 1, Tags: []string{"tag1", "tag2"}})
 	in.handleModifyTagsSelect("- new1, new2")
 	checkState(t, StateModifySelect, in.api.Data.State)
 	if len(in.api.Data.Bookmark.Tags) != 2 {
@@ -688,8 +1447,28 @@ func Test_handleModifyTagSelect(t *testing.T) {
 			2, len(in.api.Data.Bookmark.Tags))
 	}
 
+	// entered existing tags differing by case and empty segments with + prefix
+	loadBookmark(in, bukudb.Bookmark{ID: 1, Tags: []string{"tag1", "tag2"}})
+	in.handleModifyTagsSelect("+ TAG1,, tag3, Tag3,")
+	checkState(t, StateModifySelect, in.api.Data.State)
+	if !slices.Equal(in.api.Data.Bookmark.Tags, []string{"tag1", "tag2", "tag3"}) {
+		t.Errorf("expected bookmark tags '[tag1 tag2 tag3]', got '%v'",
+			in.api.Data.Bookmark.Tags)
+	}
+
+	// entered only commas with + and - prefix
+	for _, input := range []string{"+ ,, ", "-,"} {
+		loadBookmark(in, bukudb.Bookmark{ID: 1, Tags: []string{"tag1", "tag2"}})
+		in.handleModifyTagsSelect(input)
+		checkState(t, StateModifyTagsSelect, in.api.Data.State)
+		if len(in.api.Data.Bookmark.Tags) != 2 {
+			t.Errorf("expected bookmark tags len '%d', got '%d'",
+				2, len(in.api.Data.Bookmark.Tags))
+		}
+	}
+
 	// entered test without prefix, default option
-	in.api.Data.Bookmark.Tags = []string{"tag1", "tag2"}
+	loadBookmark(in, bukudb.Bookmark{ID: 1, Tags: []string{"tag1", "tag2"}})
 	in.handleModifyTagsSelect("AAAAAAA")
 	checkState(t, StateModifyTagsSelect, in.api.Data.State)
 	if len(in.api.Data.Bookmark.Tags) != 2 {
@@ -698,13 +1477,77 @@ func Test_handleModifyTagSelect(t *testing.T) {
 	}
 }
 
+func Test_handleModifyUndo(t *testing.T) {
+	in := initInputHandler(t)
+	in.handleBookmarksSelect("1. metadata (title) google", rofiapi.StateCustomKeybinding2)
+
+	// nothing to undo
+	checkEntries(t, []rofiapi.Entry{{Text: opBack}}, in.api.Entries[:1])
+	if in.api.Entries[1].Text == opUndo {
+		t.Error("expected no undo entry before an edit")
+	}
+
+	// undo after title edit
+	in.handleModifyTitleSelect("fat-fingered title")
+	checkState(t, StateModifySelect, in.api.Data.State)
+	if in.api.Entries[1].Text != opUndo {
+		t.Fatalf("expected undo entry at index 1, got '%v'", in.api.Entries[1])
+	}
+	in.api.Data = roundTripData(t, in.api.Data)
+	in.handleModifySelect(opUndo)
+	checkState(t, StateModifySelect, in.api.Data.State)
+	b, _ := in.db.Get(1)
+	if b.Title != "metadata (title) google" || in.api.Data.Bookmark.Title != b.Title {
+		t.Errorf("expected title 'metadata (title) google', got '%s' in db and '%s' in data",
+			b.Title, in.api.Data.Bookmark.Title)
+	}
+	if in.api.Data.Undo.BookmarkID != 0 || in.api.Entries[1].Text == opUndo {
+		t.Errorf("expected undo to be cleared, got '%v'", in.api.Data.Undo)
+	}
+
+	// undo after tags edit
+	in.handleModifyTagsSelect(opDelete)
+	b, _ = in.db.Get(1)
+	if len(b.Tags) != 0 {
+		t.Fatalf("expected tags to be cleared, got '%v'", b.Tags)
+	}
+	in.api.Data = roundTripData(t, in.api.Data)
+	in.handleModifySelect(opUndo)
+	b, _ = in.db.Get(1)
+	expectedTags := []string{"google", "tag2", "tag3"}
+	if !slices.Equal(b.Tags, expectedTags) || !slices.Equal(in.api.Data.Bookmark.Tags, expectedTags) {
+		t.Errorf("expected tags '%v', got '%v' in db and '%v' in data",
+			expectedTags, b.Tags, in.api.Data.Bookmark.Tags)
+	}
+
+	// undo is cleared when another bookmark is selected
+	in.handleModifyCommentSelect("new comment")
+	in.handleModifySelect(opBack)
+	in.handleBookmarksSelect("2. metadata (title) b", rofiapi.StateCustomKeybinding2)
+	if in.api.Data.Undo.BookmarkID != 0 {
+		t.Errorf("expected undo to be cleared, got '%v'", in.api.Data.Undo)
+	}
+	in.handleModifySelect(opUndo)
+	b, _ = in.db.Get(1)
+	if b.Comment != "new comment" {
+		t.Errorf("expected comment 'new comment', got '%s'", b.Comment)
+	}
+
+	// values too large for the serialized data are not kept
+	in.handleModifyCommentSelect(strings.Repeat("a", maxUndoLen+1))
+	in.handleModifyCommentSelect("short")
+	if in.api.Data.Undo.BookmarkID != 0 {
+		t.Errorf("expected no undo for large value, got '%v'", in.api.Data.Undo.BookmarkID)
+	}
+}
+
 func Test_handleDeleteConfirmShow(t *testing.T) {
 	in := initInputHandler(t)
 	in.handleDeleteConfirmShow()
 
 	expectedOptions := map[rofiapi.Option]string{
-		rofiapi.OptionMessage: generatePangoMarkup(
-			"delete? (yes/No)", "", in.api.Data.Bookmark.URL),
+		rofiapi.OptionMessage: defaultStyle.generatePangoMarkup(
+			"delete? (yes/No)", "'yes 3 7-9' deletes those bookmarks instead", in.api.Data.Bookmark.URL),
 		rofiapi.OptionNoCustom: "false",
 	}
 	checkOptions(t, expectedOptions, in.api.Options)
@@ -770,11 +1613,367 @@ func Test_handleDeleteConfirmSelect(t *testing.T) {
 	}
 }
 
+func Test_handleStatsShow(t *testing.T) {
+	in := initInputHandler(t)
+	in.handleStatsShow()
+
+	expectedOptions := map[rofiapi.Option]string{
+		rofiapi.OptionMessage:  defaultStyle.generatePangoMarkup("statistics", "", ""),
+		rofiapi.OptionNoCustom: "true",
+	}
+	checkOptions(t, expectedOptions, in.api.Options)
+
+	expectedEntries := []rofiapi.Entry{
+		{Text: opBack},
+		{Text: opFixTitles},
+		{Text: "bookmarks: 4", NonSelectable: true},
+		{Text: "tags: 0", NonSelectable: true},
+		{Text: "untagged bookmarks: 2", NonSelectable: true},
+		{Text: "bookmarks without title: 1", NonSelectable: true},
+		{Text: "opened bookmarks: 0", NonSelectable: true},
+		{Text: "database size: 2.0 KiB", NonSelectable: true},
+	}
+	checkEntries(t, expectedEntries, in.api.Entries)
+
+	checkState(t, StateStatsSelect, in.api.Data.State)
+}
+
+func Test_handleStatsSelect(t *testing.T) {
+	in := initInputHandler(t)
+
+	// selected back option
+	in.handleStatsSelect(opBack)
+	checkState(t, StateBookmarksSelect, in.api.Data.State)
+
+	// selected a statistics line
+	in.handleStatsSelect("bookmarks: 4")
+	checkState(t, StateStatsSelect, in.api.Data.State)
+}
+
+func Test_handleBookmarksSelect_Stay(t *testing.T) {
+	in := initInputHandler(t)
+	in.cfg.Browser = "true"
+
+	// accept opens the bookmark and closes rofi
+	in.handleBookmarksSelect("2. metadata (title) b", rofiapi.StateSelected)
+	checkState(t, StateGotoExec, in.api.Data.State)
+
+	// open and stay shows the bookmarks again with the opened one selected
+	in = initInputHandler(t)
+	in.cfg.Browser = "true"
+	in.handleBookmarksSelect("2. metadata (title) b", rofiapi.StateCustomKeybinding7)
+	checkState(t, StateBookmarksSelect, in.api.Data.State)
+	if in.api.Data.Stay {
+		t.Errorf("expected stay to be reset")
+	}
+	if in.api.Options[rofiapi.OptionNewSelection] != "1" {
+		t.Errorf("expected new selection '1', got '%s'", in.api.Options[rofiapi.OptionNewSelection])
+	}
+	if !strings.Contains(in.api.Options[rofiapi.OptionMessage], "opened b.com") {
+		t.Errorf("expected message to contain 'opened b.com', got '%s'",
+			in.api.Options[rofiapi.OptionMessage])
+	}
+
+	// open and stay is kept through the untrusted scheme confirmation
+	in = initInputHandler(t)
+	in.cfg.Browser = "true"
+	in.db.(*mockDB).bookmarks[2].URL = "javascript:alert(1)"
+	in.handleBookmarksSelect("3. metadata (title) c", rofiapi.StateCustomKeybinding7)
+	checkState(t, StateGotoConfirmSelect, in.api.Data.State)
+	in.handleGotoConfirmSelect(opOpen)
+	checkState(t, StateBookmarksSelect, in.api.Data.State)
+	if in.api.Options[rofiapi.OptionNewSelection] != "2" {
+		t.Errorf("expected new selection '2', got '%s'", in.api.Options[rofiapi.OptionNewSelection])
+	}
+}
+
+func Test_handleGotoExec_UntrustedScheme(t *testing.T) {
+	in := initInputHandler(t)
+	in.cfg.Browser = "true"
+
+	// untrusted scheme asks for confirmation
+	in.api.Data.Bookmark = bukudb.Bookmark{ID: 1, URL: "javascript:alert(1)"}
+	in.handleGotoExec()
+	checkState(t, StateGotoConfirmSelect, in.api.Data.State)
+	checkEntries(t, []rofiapi.Entry{{Text: opBack}, {Text: opOpen}}, in.api.Entries)
+	if !strings.Contains(in.api.Options[rofiapi.OptionMessage], "javascript:") {
+		t.Errorf("expected message to contain the scheme, got '%s'",
+			in.api.Options[rofiapi.OptionMessage])
+	}
+
+	// selected back option
+	in.handleGotoConfirmSelect(opBack)
+	checkState(t, StateBookmarksSelect, in.api.Data.State)
+
+	// selected open option
+	in.handleGotoExec()
+	in.handleGotoConfirmSelect(opOpen)
+	checkState(t, StateGotoExec, in.api.Data.State)
+
+	// allowed schemes and bare hosts open directly
+	for _, url := range []string{"https://a.com", "a.com", "MAILTO:a@b.com"} {
+		in.api.Data.Bookmark = bukudb.Bookmark{ID: 1, URL: url}
+		in.handleGotoExec()
+		checkState(t, StateGotoExec, in.api.Data.State)
+	}
+
+	// configured allowlist
+	in.cfg.URLSchemes = []string{"javascript"}
+	in.api.Data.Bookmark = bukudb.Bookmark{ID: 1, URL: "javascript:alert(1)"}
+	in.handleGotoExec()
+	checkState(t, StateGotoExec, in.api.Data.State)
+}
+
+func Test_isAllowedScheme(t *testing.T) {
+	allowed := []string{"http", "https"}
+	tests := []struct {
+		scheme   string
+		expected bool
+	}{
+		{"http", true},
+		{"https", true},
+		{"", true},
+		{"javascript", false},
+		{"data", false},
+	}
+	for _, test := range tests {
+		if actual := isAllowedScheme(test.scheme, allowed); actual != test.expected {
+			t.Errorf("scheme '%s': expected %v, got %v", test.scheme, test.expected, actual)
+		}
+	}
+}
+
+func Test_statsLines(t *testing.T) {
+	s := bukudb.Stats{
+		Total:      3,
+		Tags:       2,
+		TopTags:    []bukudb.TagCount{{Tag: "go", Count: 2}, {Tag: "db", Count: 1}},
+		Untagged:   1,
+		Untitled:   0,
+		TopDomains: []bukudb.DomainCount{{Domain: "go.dev", Count: 3}},
+		Visited:    2,
+		FileSize:   512,
+	}
+
+	expected := []string{
+		"bookmarks: 3",
+		"tags: 2",
+		"untagged bookmarks: 1",
+		"bookmarks without title: 0",
+		"opened bookmarks: 2",
+		"database size: 512 B",
+		"top tags:",
+		"    go (2)",
+		"    db (1)",
+		"top domains:",
+		"    go.dev (3)",
+	}
+
+	actual := statsLines(s)
+	if !slices.Equal(expected, actual) {
+		t.Errorf("expected stats lines '%v', got '%v'", expected, actual)
+	}
+}
+
+func Test_formatByteSize(t *testing.T) {
+	tests := map[int64]string{
+		0:                      "0 B",
+		1023:                   "1023 B",
+		1024:                   "1.0 KiB",
+		1536:                   "1.5 KiB",
+		5 * 1024 * 1024:        "5.0 MiB",
+		3 * 1024 * 1024 * 1024: "3.0 GiB",
+	}
+	for in, expected := range tests {
+		if actual := formatByteSize(in); actual != expected {
+			t.Errorf("expected formatByteSize(%d) '%s', got '%s'", in, expected, actual)
+		}
+	}
+}
+
+func Test_buildMeta(t *testing.T) {
+	tests := []struct {
+		name     string
+		bookmark bukudb.Bookmark
+		expected string
+	}{
+		{"empty bookmark", bukudb.Bookmark{}, ""},
+		{"url only", bukudb.Bookmark{URL: "https://www.a.com/path"}, "a.com/path a"},
+		{"title no tags", bukudb.Bookmark{URL: "https://a.com", Title: "A"}, "a.com a"},
+		{"tags no title", bukudb.Bookmark{URL: "https://a.com", Tags: []string{"x", "y"}},
+			"x #x y #y a.com a"},
+		{"comment words", bukudb.Bookmark{URL: "https://a.com", Comment: "Read this, (later)!"},
+			"a.com a read this later"},
+		{"duplicates", bukudb.Bookmark{URL: "https://go.dev", Tags: []string{"Go", "go"},
+			Comment: "go GO go.dev"}, "go #go go.dev"},
+		{"tag with spaces", bukudb.Bookmark{Tags: []string{"a tag"}}, "a #a tag #tag"},
+		{"host labels", bukudb.Bookmark{URL: "https://www.docs.example.org:8080/a"},
+			"docs.example.org:8080/a docs example"},
+		{"ip address", bukudb.Bookmark{URL: "http://127.0.0.1/x"}, "127.0.0.1/x"},
+	}
+
+	for _, tt := range tests {
+		if actual := buildMeta(tt.bookmark); actual != tt.expected {
+			t.Errorf("%s: expected meta '%s', got '%s'", tt.name, tt.expected, actual)
+		}
+	}
+}
+
+func Test_HandleNotBukuDB(t *testing.T) {
+	in := initInputHandler(t)
+	initCalls := 0
+	initSchema := func(path string) error {
+		initCalls++
+		return nil
+	}
+
+	// first shown without init option
+	if HandleNotBukuDB(in.api, "", "/tmp/x.db", false, initSchema) {
+		t.Error("expected HandleNotBukuDB() to return false")
+	}
+	checkState(t, StateInitSchemaSelect, in.api.Data.State)
+	checkEntries(t, []rofiapi.Entry{{Text: opExit}}, in.api.Entries)
+	if !strings.Contains(in.api.Options[rofiapi.OptionMessage], "/tmp/x.db") {
+		t.Errorf("expected message to contain path, got '%s'", in.api.Options[rofiapi.OptionMessage])
+	}
+
+	// selected exit
+	HandleNotBukuDB(in.api, opExit, "/tmp/x.db", false, initSchema)
+	checkState(t, StateErrorSelect, in.api.Data.State)
+
+	// first shown with init option
+	in.api.Data.State = StateNull
+	HandleNotBukuDB(in.api, "", "/tmp/x.db", true, initSchema)
+	checkState(t, StateInitSchemaSelect, in.api.Data.State)
+	checkEntries(t, []rofiapi.Entry{{Text: opExit}, {Text: opInitSchema}}, in.api.Entries)
+
+	// selected init option
+	if !HandleNotBukuDB(in.api, opInitSchema, "/tmp/x.db", true, initSchema) {
+		t.Error("expected HandleNotBukuDB() to return true")
+	}
+	checkState(t, StateBookmarksShow, in.api.Data.State)
+	if initCalls != 1 {
+		t.Errorf("expected initSchema to be called once, got '%d'", initCalls)
+	}
+
+	// selected init option but initializing failed
+	in.api.Data.State = StateInitSchemaSelect
+	failing := func(path string) error { return fmt.Errorf("read-only") }
+	if HandleNotBukuDB(in.api, opInitSchema, "/tmp/x.db", true, failing) {
+		t.Error("expected HandleNotBukuDB() to return false")
+	}
+	checkState(t, StateErrorShow, in.api.Data.State)
+
+	// init option selected while it is disabled
+	in.api.Data.State = StateInitSchemaSelect
+	initCalls = 0
+	HandleNotBukuDB(in.api, opInitSchema, "/tmp/x.db", false, initSchema)
+	checkState(t, StateErrorSelect, in.api.Data.State)
+	if initCalls != 0 {
+		t.Errorf("expected initSchema to not be called, got '%d'", initCalls)
+	}
+}
+
+func Test_checkLength(t *testing.T) {
+	tests := []struct {
+		name    string
+		input   string
+		max     int
+		wantErr bool
+	}{
+		{"empty", "", 3, false},
+		{"under limit", "ab", 3, false},
+		{"at limit", "abc", 3, false},
+		{"over limit", "abcd", 3, true},
+		{"multibyte at limit", "ééé", 3, false},
+		{"multibyte over limit", "日本語字", 3, true},
+	}
+
+	for _, tt := range tests {
+		err := checkLength(tt.input, tt.max)
+		if (err != nil) != tt.wantErr {
+			t.Errorf("%s: expected error '%v', got '%v'", tt.name, tt.wantErr, err)
+		}
+	}
+
+	if err := checkLength("abcd", 3); err.Error() != "input too long (4 > 3)" {
+		t.Errorf("expected error 'input too long (4 > 3)', got '%v'", err)
+	}
+}
+
+func Test_inputLengthLimits(t *testing.T) {
+	in := initInputHandler(t)
+	in.cfg.MaxTitleLen = 5
+	in.cfg.MaxURLLen = 5
+	in.cfg.MaxCommentLen = 5
+	in.cfg.MaxTagLen = 5
+
+	// add title at limit
+	in.handleAddTitleSelect("ééééé")
+	checkState(t, StateAddSelect, in.api.Data.State)
+	if in.api.Data.Bookmark.Title != "ééééé" {
+		t.Errorf("expected bookmark title 'ééééé', got '%s'", in.api.Data.Bookmark.Title)
+	}
+
+	// add title over limit
+	in.handleAddTitleSelect("title too long")
+	checkState(t, StateAddTitleSelect, in.api.Data.State)
+	if in.api.Data.Bookmark.Title != "ééééé" {
+		t.Errorf("expected bookmark title 'ééééé', got '%s'", in.api.Data.Bookmark.Title)
+	}
+	if !strings.Contains(in.api.Options[rofiapi.OptionMessage], "input too long (14 &gt; 5)") {
+		t.Errorf("expected message to contain length error, got '%s'",
+			in.api.Options[rofiapi.OptionMessage])
+	}
+	if !strings.Contains(in.api.Options[rofiapi.OptionMessage], "enter a title") {
+		t.Errorf("expected message to contain instructions, got '%s'",
+			in.api.Options[rofiapi.OptionMessage])
+	}
+
+	// add url over limit
+	in.handleAddUrlSelect("https://a.com")
+	checkState(t, StateAddUrlSelect, in.api.Data.State)
+	if in.api.Data.Bookmark.URL != "" {
+		t.Errorf("expected bookmark url '', got '%s'", in.api.Data.Bookmark.URL)
+	}
+
+	// add comment over limit
+	in.handleAddCommentSelect("comment")
+	checkState(t, StateAddCommentSelect, in.api.Data.State)
+	if in.api.Data.Bookmark.Comment != "" {
+		t.Errorf("expected bookmark comment '', got '%s'", in.api.Data.Bookmark.Comment)
+	}
+
+	// add tags, one over limit
+	in.handleAddTagsSelect("ok, too-long")
+	checkState(t, StateAddTagsSelect, in.api.Data.State)
+	if len(in.api.Data.Bookmark.Tags) != 0 {
+		t.Errorf("expected bookmark tags empty, got '%v'", in.api.Data.Bookmark.Tags)
+	}
+
+	// modify title, comment, and tags over limit never reach the db
+	in.api.Data.Bookmark, _ = in.db.Get(1)
+	in.handleModifyTitleSelect("title too long")
+	checkState(t, StateModifyTitleSelect, in.api.Data.State)
+	in.handleModifyUrlSelect("https://a.com")
+	checkState(t, StateModifyUrlSelect, in.api.Data.State)
+	in.handleModifyCommentSelect("comment too long")
+	checkState(t, StateModifyCommentSelect, in.api.Data.State)
+	in.handleModifyTagsSelect("+ ok, too-long")
+	checkState(t, StateModifyTagsSelect, in.api.Data.State)
+
+	b, _ := in.db.Get(1)
+	if b.Title != "metadata (title) google" || b.Comment != "desc (comment) google" ||
+		b.URL != "https://www.google.com" || len(b.Tags) != 3 {
+		t.Errorf("expected bookmark to be unchanged, got '%v'", b)
+	}
+}
+
 func Test_getSelectedFromInput(t *testing.T) {
 	in := initInputHandler(t)
 
 	// valid input
-	sel, err := in.getSelectedFromInput("0001. this is valid")
+	sel, err := in.getSelectedFromInput("1. this is valid")
 	if err != nil {
 		t.Errorf("expected no error from getSelectedFromInput(), got '%v'", err)
 	}
@@ -823,12 +2022,35 @@ func checkState(t *testing.T, expectedState, actualState State) {
 	t.Helper()
 
 	if actualState != expectedState {
-		t.Errorf("expected state '%d', got '%d'",
+		t.Errorf("expected state '%s', got '%s'",
 			expectedState, actualState)
 	}
 }
 
-func initInputHandler(t *testing.T) *InputHandler {
+// roundTripData encodes and decodes d like rofi-api does between invocations.
+func roundTripData(t *testing.T, d Data) Data {
+	t.Helper()
+
+	var buf bytes.Buffer
+	if err := gob.NewEncoder(&buf).Encode(d); err != nil {
+		t.Fatalf("expected no error encoding data, got %v", err)
+	}
+	var decoded Data
+	if err := gob.NewDecoder(&buf).Decode(&decoded); err != nil {
+		t.Fatalf("expected no error decoding data, got %v", err)
+	}
+	return decoded
+}
+
+// loadBookmark makes b the bookmark being modified, as loaded from the
+// mock.
+func loadBookmark(in *InputHandler, b bukudb.Bookmark) {
+	in.db.(*mockDB).bookmarks[b.ID-1] = b
+	b.Tags = slices.Clone(b.Tags)
+	in.api.Data.Bookmark = b
+}
+
+func initInputHandler(t testing.TB) *InputHandler {
 	t.Helper()
 
 	db := newMockDB()
@@ -838,3 +2060,238 @@ func initInputHandler(t *testing.T) *InputHandler {
 	}
 	return NewInputHandler(db, api)
 }
+
+func Test_dryRun(t *testing.T) {
+	t.Setenv(config.DryRunEnvVar, "1")
+
+	db := newMockDB()
+	api, err := rofiapi.NewRofiApi(Data{})
+	if err != nil {
+		t.Fatalf("expected no error from NewRofiApi(), got %v", err)
+	}
+	in := NewInputHandler(db, api)
+
+	// delete
+	in.api.Data.Bookmark, _ = db.Get(1)
+	in.api.Data.State = StateDeleteConfirmSelect
+	in.HandleInput("yes")
+	checkState(t, StateBookmarksSelect, in.api.Data.State)
+	if db.Len() != 4 {
+		t.Errorf("expected bookmarks length '4', got '%d'", db.Len())
+	}
+	expected := "DRY RUN: would delete #1 https://www.google.com"
+	if !strings.Contains(in.api.Options[rofiapi.OptionMessage], expected) {
+		t.Errorf("expected message to contain '%s', got '%s'",
+			expected, in.api.Options[rofiapi.OptionMessage])
+	}
+
+	// modify title
+	in.api.Data.Bookmark, _ = db.Get(2)
+	in.api.Data.State = StateModifyTitleSelect
+	in.HandleInput("new title")
+	if b, _ := db.Get(2); b.Title != "metadata (title) b" {
+		t.Errorf("expected title to be unchanged, got '%s'", b.Title)
+	}
+	expected = "DRY RUN: would set the title of #2 https://www.b.com to &#39;new title&#39;"
+	if !strings.Contains(in.api.Options[rofiapi.OptionMessage], expected) {
+		t.Errorf("expected message to contain '%s', got '%s'",
+			expected, in.api.Options[rofiapi.OptionMessage])
+	}
+}
+
+func Test_readOnly(t *testing.T) {
+	db := newMockDB()
+	api, err := rofiapi.NewRofiApi(Data{})
+	if err != nil {
+		t.Fatalf("expected no error from NewRofiApi(), got %v", err)
+	}
+	cfg := config.Default()
+	cfg.ReadOnly = true
+	in := NewInputHandlerWithConfig(db, api, cfg)
+
+	in.api.Data.Bookmark, _ = db.Get(1)
+	in.api.Data.State = StateDeleteConfirmSelect
+	in.HandleInput("yes")
+	checkState(t, StateBookmarksSelect, in.api.Data.State)
+	if db.Len() != 4 {
+		t.Errorf("expected bookmarks length '4', got '%d'", db.Len())
+	}
+	expected := "READ ONLY: would delete #1 https://www.google.com"
+	if !strings.Contains(in.api.Options[rofiapi.OptionMessage], expected) {
+		t.Errorf("expected message to contain '%s', got '%s'",
+			expected, in.api.Options[rofiapi.OptionMessage])
+	}
+}
+
+func Test_HandleInput_StaleState(t *testing.T) {
+	tests := []struct {
+		retv  string
+		state State
+		input string
+		stale bool
+	}{
+		{"0", StateModifyTitleSelect, "", true},
+		{"1", StateAddTagsSelect, "", true},
+		{"1", StateDeleteConfirmSelect, "", true},
+		{"1", StateExportSelect, "  ", true},
+		{"1", StateModifyTitleSelect, "new title", false},
+		{"2", StateModifyCommentSelect, "", false},
+		{"10", StateModifySelect, "", false},
+		{"1", StateBookmarksSelect, "", false},
+		{"0", StateBookmarksShow, "", false},
+	}
+
+	for _, tt := range tests {
+		t.Setenv("ROFI_RETV", tt.retv)
+		api, err := rofiapi.NewRofiApi(Data{})
+		if err != nil {
+			t.Fatalf("expected no error from NewRofiApi(), got %v", err)
+		}
+		in := NewInputHandler(newMockDB(), api)
+		in.api.Data = Data{State: tt.state, Bookmark: bukudb.Bookmark{ID: 1}, ResultIDs: []uint16{1}}
+
+		if got := isStale(tt.state, api.GetState(), strings.TrimSpace(tt.input)); got != tt.stale {
+			t.Errorf("state %d, retv %s, input '%s': expected stale %v, got %v",
+				tt.state, tt.retv, tt.input, tt.stale, got)
+		}
+		if !tt.stale {
+			continue
+		}
+
+		in.HandleInput(tt.input)
+		checkState(t, StateBookmarksSelect, in.api.Data.State)
+		if len(in.api.Entries) != in.db.Len() {
+			t.Errorf("state %d: expected %d entries, got %d", tt.state, in.db.Len(), len(in.api.Entries))
+		}
+		if in.api.Data.ResultIDs != nil {
+			t.Errorf("state %d: expected result ids to be reset, got %v", tt.state, in.api.Data.ResultIDs)
+		}
+	}
+}
+
+func Test_HandleInput_UnknownState(t *testing.T) {
+	for _, state := range []State{stateLast + 1, 200, StateGotoExec} {
+		in := initInputHandler(t)
+		in.api.Data = Data{State: state, Bookmark: bukudb.Bookmark{ID: 1}, ResultIDs: []uint16{1}}
+
+		in.HandleInput("1. metadata (title) google")
+
+		checkState(t, StateBookmarksSelect, in.api.Data.State)
+		if len(in.api.Entries) != in.db.Len() {
+			t.Errorf("state %s: expected %d entries, got %d", state, in.db.Len(), len(in.api.Entries))
+		}
+		if in.api.Data.ResultIDs != nil || in.api.Data.Bookmark.ID != 0 {
+			t.Errorf("state %s: expected data to be reset, got %+v", state, in.api.Data)
+		}
+		expected := fmt.Sprintf("state reset (unknown state %s)", state)
+		if !strings.Contains(in.api.Options[rofiapi.OptionMessage], expected) {
+			t.Errorf("expected message to contain '%s', got '%s'", expected, in.api.Options[rofiapi.OptionMessage])
+		}
+	}
+}
+
+func Benchmark_HandleBookmarksShow(b *testing.B) {
+	db, err := bukudb.NewBukuDB(testutil.NewFixtureDB(b, bukudb.MaxBookmarks))
+	if err != nil {
+		b.Fatal(err)
+	}
+	defer db.Close()
+
+	api, err := rofiapi.NewRofiApi(Data{})
+	if err != nil {
+		b.Fatal(err)
+	}
+	in := NewInputHandler(db, api)
+
+	b.ResetTimer()
+	for range b.N {
+		in.HandleBookmarksShow()
+	}
+}
+
+func Benchmark_appendBookmarkEntries(b *testing.B) {
+	in := initInputHandler(b)
+	bookmarks := testutil.Bookmarks(bukudb.MaxBookmarks)
+	statuses := map[uint16]bukudb.LinkStatus{7: {Dead: true}}
+	entries := make([]rofiapi.Entry, 0, len(bookmarks))
+
+	b.ReportAllocs()
+	b.ResetTimer()
+	for range b.N {
+		entries = in.appendBookmarkEntries(entries[:0], bookmarks, statuses, nil, "")
+	}
+}
+
+// specialURL has characters pango markup must escape, and an untrusted
+// scheme so opening it asks for confirmation.
+const specialURL = "gopher://example.com/?q=a&b=<c>%20d"
+
+// checkEscapedOnce checks that raw is in message escaped exactly once.
+func checkEscapedOnce(t *testing.T, name, message, raw string) {
+	t.Helper()
+
+	escaped := rofiapi.EscapePangoMarkup(raw)
+	if !strings.Contains(message, escaped) {
+		t.Errorf("%s: expected '%s' in message, got '%s'", name, escaped, message)
+	}
+	if strings.Contains(message, rofiapi.EscapePangoMarkup(escaped)) {
+		t.Errorf("%s: expected '%s' to be escaped once, got '%s'", name, raw, message)
+	}
+	if strings.Contains(message, raw) {
+		t.Errorf("%s: expected '%s' to be escaped, got '%s'", name, raw, message)
+	}
+}
+
+func Test_messages_EscapeOnce(t *testing.T) {
+	exportPath := filepath.Join(t.TempDir(), "a&b<c>%d.md")
+
+	tests := []struct {
+		name string
+		raw  string
+		show func(in *InputHandler)
+	}{
+		{"goto confirm", specialURL, func(in *InputHandler) {
+			in.handleGotoExec()
+		}},
+		{"delete confirm", specialURL, func(in *InputHandler) {
+			in.handleDeleteConfirmShow()
+		}},
+		{"modify url", specialURL, func(in *InputHandler) {
+			in.handleModifyUrlShow()
+		}},
+		{"error", "error opening " + specialURL + ": failed", func(in *InputHandler) {
+			in.setMessageToError(fmt.Errorf("error opening %s: %w", specialURL, fmt.Errorf("failed")))
+		}},
+		{"error above screen", specialURL, func(in *InputHandler) {
+			in.showWithError(in.HandleBookmarksShow, fmt.Errorf("%s", specialURL))
+		}},
+		{"notice", specialURL, func(in *InputHandler) {
+			in.addNotice(specialURL)
+			in.HandleInput("")
+		}},
+		{"warning", specialURL, func(in *InputHandler) {
+			in.HandleBookmarksShow()
+			in.prependMessage(in.style.warningMarkup(specialURL))
+		}},
+		{"filter", fmt.Sprintf("%q", specialURL), func(in *InputHandler) {
+			in.api.Data.Filters = []Filter{{Query: specialURL}}
+			in.HandleBookmarksShow()
+		}},
+		{"bookmark id", "no bookmark id in '<c>%20d'", func(in *InputHandler) {
+			in.handleBookmarksSelect("<c>%20d", rofiapi.StateSelected)
+		}},
+		{"exported", exportPath, func(in *InputHandler) {
+			in.api.Data.ResultIDs = []uint16{1}
+			in.api.Data.Export = ExportTarget{Format: "md", Path: exportPath}
+			in.exportTo(false)
+		}},
+	}
+	for _, tt := range tests {
+		in := initInputHandler(t)
+		in.api.Data.Bookmark = bukudb.Bookmark{ID: 1, URL: specialURL, Title: "title"}
+		in.api.Data.State = StateBookmarksShow
+		tt.show(in)
+
+		checkEscapedOnce(t, tt.name, in.api.Options[rofiapi.OptionMessage], tt.raw)
+	}
+}
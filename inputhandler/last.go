@@ -0,0 +1,85 @@
+package inputhandler
+
+import (
+	"fmt"
+	"log"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+
+	"github.com/VannRR/robuku/bukudb"
+	rofiapi "github.com/VannRR/rofi-api"
+)
+
+// readLast returns the ID and URL of the last opened bookmark stored at
+// path, ok is false if the file is missing or its content is invalid.
+func readLast(path string) (id uint16, url string, ok bool) {
+	content, err := os.ReadFile(path)
+	if err != nil {
+		return 0, "", false
+	}
+
+	idString, url, found := strings.Cut(strings.TrimSpace(string(content)), "\t")
+	idUint64, err := strconv.ParseUint(idString, 10, 16)
+	if !found || err != nil || idUint64 == 0 || url == "" {
+		return 0, "", false
+	}
+	return uint16(idUint64), url, true
+}
+
+// writeLast stores the ID and URL of b at path, creating its directory if
+// needed.
+func writeLast(path string, b bukudb.Bookmark) error {
+	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		return fmt.Errorf("failed to create state directory: %w", err)
+	}
+	content := fmt.Sprintf("%d\t%s\n", b.ID, b.URL)
+	if err := os.WriteFile(path, []byte(content), 0o644); err != nil {
+		return fmt.Errorf("failed to write last bookmark: %w", err)
+	}
+	return nil
+}
+
+// lastIndex returns the index of the last opened bookmark in bookmarks. It
+// is looked up by ID and then by URL, since IDs shift when a bookmark before
+// it is deleted.
+func lastIndex(path string, bookmarks []bukudb.Bookmark) (int, bool) {
+	id, url, ok := readLast(path)
+	if !ok {
+		return 0, false
+	}
+	for i, b := range bookmarks {
+		if b.ID == id && b.URL == url {
+			return i, true
+		}
+	}
+	for i, b := range bookmarks {
+		if b.URL == url {
+			return i, true
+		}
+	}
+	return 0, false
+}
+
+// selectLast highlights the last opened bookmark, offset is the number of
+// entries before the bookmarks. It only applies when robuku starts.
+func (in *InputHandler) selectLast(bookmarks []bukudb.Bookmark, offset int) {
+	if !in.cfg.RememberLast || in.cfg.LastFile == "" || in.api.Data.State != StateNull {
+		return
+	}
+	if i, ok := lastIndex(in.cfg.LastFile, bookmarks); ok {
+		in.api.Options[rofiapi.OptionKeepSelection] = "true"
+		in.api.Options[rofiapi.OptionNewSelection] = strconv.Itoa(offset + i)
+	}
+}
+
+// saveLast stores the bookmark being opened if remembering it is enabled.
+func (in *InputHandler) saveLast() {
+	if !in.cfg.RememberLast || in.cfg.LastFile == "" {
+		return
+	}
+	if err := writeLast(in.cfg.LastFile, in.api.Data.Bookmark); err != nil {
+		log.Println("ERROR", err)
+	}
+}
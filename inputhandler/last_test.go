@@ -0,0 +1,115 @@
+package inputhandler
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/VannRR/robuku/bukudb"
+	rofiapi "github.com/VannRR/rofi-api"
+)
+
+func Test_readLast_writeLast(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "robuku", "last")
+
+	// missing file
+	if _, _, ok := readLast(path); ok {
+		t.Error("expected missing file to not be ok")
+	}
+
+	if err := writeLast(path, bukudb.Bookmark{ID: 12, URL: "https://a.com"}); err != nil {
+		t.Fatalf("expected no error on writeLast(), got '%v'", err)
+	}
+	id, url, ok := readLast(path)
+	if !ok || id != 12 || url != "https://a.com" {
+		t.Errorf("expected 12 'https://a.com' true, got %d '%s' %v", id, url, ok)
+	}
+
+	// corrupt content
+	for _, content := range []string{"", "abc\thttps://a.com", "0\thttps://a.com",
+		"70000\thttps://a.com", "12", "12\t", "\x00\x01"} {
+		if err := os.WriteFile(path, []byte(content), 0o644); err != nil {
+			t.Fatal(err)
+		}
+		if _, _, ok := readLast(path); ok {
+			t.Errorf("expected content %q to not be ok", content)
+		}
+	}
+}
+
+func Test_lastIndex(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "last")
+	bookmarks := newMockDB().bookmarks
+
+	if _, ok := lastIndex(path, bookmarks); ok {
+		t.Error("expected missing file to not be ok")
+	}
+
+	// matching ID and URL
+	writeLast(path, bukudb.Bookmark{ID: 3, URL: "https://www.c.com"})
+	if i, ok := lastIndex(path, bookmarks); !ok || i != 2 {
+		t.Errorf("expected index 2, got %d %v", i, ok)
+	}
+
+	// ID shifted by a deletion
+	writeLast(path, bukudb.Bookmark{ID: 4, URL: "https://www.c.com"})
+	if i, ok := lastIndex(path, bookmarks); !ok || i != 2 {
+		t.Errorf("expected index 2, got %d %v", i, ok)
+	}
+
+	// deleted bookmark
+	writeLast(path, bukudb.Bookmark{ID: 1, URL: "https://deleted.com"})
+	if _, ok := lastIndex(path, bookmarks); ok {
+		t.Error("expected deleted bookmark to not be ok")
+	}
+}
+
+func Test_HandleBookmarksShow_rememberLast(t *testing.T) {
+	lastFile := filepath.Join(t.TempDir(), "last")
+
+	in := initInputHandler(t)
+	in.cfg.Browser = "true"
+	in.cfg.RememberLast = true
+	in.cfg.LastFile = lastFile
+
+	// no last bookmark yet
+	in.HandleBookmarksShow()
+	if v, ok := in.api.Options[rofiapi.OptionNewSelection]; ok {
+		t.Errorf("expected no new selection, got '%s'", v)
+	}
+
+	// opening a bookmark stores it
+	in.handleBookmarksSelect("2. metadata (title) b", rofiapi.StateSelected)
+	checkState(t, StateGotoExec, in.api.Data.State)
+	if id, _, _ := readLast(lastFile); id != 2 {
+		t.Errorf("expected last bookmark '2', got '%d'", id)
+	}
+
+	// the initial show selects it
+	in = initInputHandler(t)
+	in.cfg.RememberLast = true
+	in.cfg.LastFile = lastFile
+	in.HandleBookmarksShow()
+	checkOptions(t, map[rofiapi.Option]string{
+		rofiapi.OptionKeepSelection: "true",
+		rofiapi.OptionNewSelection:  "1",
+	}, in.api.Options)
+
+	// later shows keep rofi's selection
+	in = initInputHandler(t)
+	in.cfg.RememberLast = true
+	in.cfg.LastFile = lastFile
+	in.api.Data.State = StateStatsSelect
+	in.HandleBookmarksShow()
+	if v, ok := in.api.Options[rofiapi.OptionNewSelection]; ok {
+		t.Errorf("expected no new selection, got '%s'", v)
+	}
+
+	// disabled
+	in = initInputHandler(t)
+	in.cfg.LastFile = lastFile
+	in.HandleBookmarksShow()
+	if v, ok := in.api.Options[rofiapi.OptionNewSelection]; ok {
+		t.Errorf("expected no new selection, got '%s'", v)
+	}
+}
@@ -0,0 +1,61 @@
+package inputhandler
+
+import (
+	"os"
+	"os/exec"
+	"strconv"
+	"strings"
+
+	"github.com/VannRR/robuku/bukudb"
+	"github.com/VannRR/robuku/config"
+)
+
+// urlPlaceholder is replaced by the bookmark URL in launcher commands.
+const urlPlaceholder = "%u"
+
+// The environment of the browser and launchers describes the bookmark
+// opened, so a script can e.g. pick the browser of the current workspace.
+const (
+	bookmarkIDEnvVar    = "ROBUKU_BOOKMARK_ID"
+	bookmarkTitleEnvVar = "ROBUKU_BOOKMARK_TITLE"
+	bookmarkTagsEnvVar  = "ROBUKU_BOOKMARK_TAGS"
+)
+
+// altBrowserAction opens the bookmark with $ROBUKU_BROWSER_ALT, it is only
+// offered when set.
+var altBrowserAction = hotkeyAction{key: 12, hint: "open alt", help: "open the bookmark with the alternative browser",
+	needsBookmark: true}
+
+// hasAltBrowser reports whether an alternative browser is set.
+func (in *InputHandler) hasAltBrowser() bool {
+	return strings.TrimSpace(in.cfg.BrowserAlt) != ""
+}
+
+// resolveLauncher returns the command of the first of launchers whose tag
+// is one of tags, compared case-insensitively.
+func resolveLauncher(tags []string, launchers []config.Launcher) (string, bool) {
+	for _, l := range launchers {
+		if containsTag(tags, l.Tag) {
+			return l.Command, true
+		}
+	}
+	return "", false
+}
+
+// launcherCommand returns command with "%u" replaced by url, or url
+// appended if command has no "%u", with b in its environment.
+func launcherCommand(command, url string, b bukudb.Bookmark) *exec.Cmd {
+	args := strings.Fields(command)
+	if !strings.Contains(command, urlPlaceholder) {
+		args = append(args, urlPlaceholder)
+	}
+	for i, a := range args {
+		args[i] = strings.ReplaceAll(a, urlPlaceholder, url)
+	}
+	cmd := exec.Command(args[0], args[1:]...)
+	cmd.Env = append(os.Environ(),
+		bookmarkIDEnvVar+"="+strconv.Itoa(int(b.ID)),
+		bookmarkTitleEnvVar+"="+b.Title,
+		bookmarkTagsEnvVar+"="+strings.Join(b.Tags, ","))
+	return cmd
+}
@@ -0,0 +1,179 @@
+package inputhandler
+
+import (
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/VannRR/robuku/bukudb"
+	"github.com/VannRR/robuku/config"
+	rofiapi "github.com/VannRR/rofi-api"
+)
+
+func Test_resolveLauncher(t *testing.T) {
+	launchers := []config.Launcher{
+		{Tag: "video", Command: "mpv %u"},
+		{Tag: "music", Command: "mpv --no-video %u"},
+		{Tag: "pdf", Command: "zathura"},
+	}
+	tests := []struct {
+		tags     []string
+		expected string
+		ok       bool
+	}{
+		{[]string{"video"}, "mpv %u", true},
+		{[]string{"music", "video"}, "mpv %u", true},
+		{[]string{"VIDEO"}, "mpv %u", true},
+		{[]string{"docs", "pdf", "music"}, "mpv --no-video %u", true},
+		{[]string{"docs"}, "", false},
+		{nil, "", false},
+	}
+	for _, test := range tests {
+		command, ok := resolveLauncher(test.tags, launchers)
+		if command != test.expected || ok != test.ok {
+			t.Errorf("tags %q: expected '%s' and %v, got '%s' and %v",
+				test.tags, test.expected, test.ok, command, ok)
+		}
+	}
+}
+
+func Test_launcherCommand(t *testing.T) {
+	tests := []struct {
+		command  string
+		expected []string
+	}{
+		{"mpv %u", []string{"mpv", "https://a.com"}},
+		{"zathura", []string{"zathura", "https://a.com"}},
+		{"open --url=%u -n", []string{"open", "--url=https://a.com", "-n"}},
+	}
+	for _, test := range tests {
+		if args := launcherCommand(test.command, "https://a.com", bukudb.Bookmark{}).Args; !slices.Equal(args, test.expected) {
+			t.Errorf("command '%s': expected args %q, got %q", test.command, test.expected, args)
+		}
+	}
+}
+
+func Test_openURL_Launcher(t *testing.T) {
+	in := initInputHandler(t)
+	in.cfg.Browser = "true"
+	in.cfg.Launchers = []config.Launcher{{Tag: "video", Command: "robuku-missing-player %u"}}
+
+	// tagged bookmarks use the launcher
+	in.api.Data.Bookmark = bukudb.Bookmark{ID: 1, URL: "https://a.com", Tags: []string{"video"}}
+	in.handleGotoExec()
+	checkState(t, StateErrorShow, in.api.Data.State)
+	if !strings.Contains(in.api.Options[rofiapi.OptionMessage], "robuku-missing-player") {
+		t.Errorf("expected launcher error, got '%s'", in.api.Options[rofiapi.OptionMessage])
+	}
+
+	// other bookmarks use the browser
+	in.api.Data.Bookmark = bukudb.Bookmark{ID: 1, URL: "https://a.com", Tags: []string{"docs"}}
+	in.handleGotoExec()
+	checkState(t, StateGotoExec, in.api.Data.State)
+}
+
+func Test_openURL_BrowserArgs(t *testing.T) {
+	in := initInputHandler(t)
+	in.cfg.Browser = "true -P work"
+
+	in.api.Data.Bookmark = bukudb.Bookmark{ID: 1, URL: "https://a.com"}
+	in.handleGotoExec()
+	checkState(t, StateGotoExec, in.api.Data.State)
+
+	in.cfg.Browser = "robuku-missing-browser -P work"
+	in.handleGotoExec()
+	checkState(t, StateErrorShow, in.api.Data.State)
+	if !strings.Contains(in.api.Options[rofiapi.OptionMessage], "robuku-missing-browser") {
+		t.Errorf("expected browser error, got '%s'", in.api.Options[rofiapi.OptionMessage])
+	}
+}
+
+func Test_handleBookmarksSelect_AltBrowser(t *testing.T) {
+	in := initInputHandler(t)
+	in.cfg.Browser = "true"
+	in.cfg.Launchers = []config.Launcher{{Tag: "google", Command: "true"}}
+
+	// not offered without an alternative browser
+	in.HandleBookmarksShow()
+	if slices.Contains(in.hotkeyActions(), altBrowserAction) {
+		t.Errorf("expected no alternative browser hotkey")
+	}
+	in.handleBookmarksSelect("1. metadata (title) google", rofiapi.StateCustomKeybinding12)
+	checkState(t, StateBookmarksSelect, in.api.Data.State)
+
+	// skips the launchers
+	in.cfg.BrowserAlt = "robuku-missing-alt --private %u"
+	in.HandleBookmarksShow()
+	if !slices.Contains(in.hotkeyActions(), altBrowserAction) {
+		t.Errorf("expected alternative browser hotkey")
+	}
+	in.handleBookmarksSelect("1. metadata (title) google", rofiapi.StateCustomKeybinding12)
+	checkState(t, StateErrorShow, in.api.Data.State)
+	if !strings.Contains(in.api.Options[rofiapi.OptionMessage], "robuku-missing-alt") {
+		t.Errorf("expected alternative browser error, got '%s'", in.api.Options[rofiapi.OptionMessage])
+	}
+	if in.api.Data.AltBrowser {
+		t.Errorf("expected the alternative browser to be used once")
+	}
+}
+
+// writeEnvScript writes a script to dir that dumps its environment to the
+// file it returns, the file appears once the dump is complete.
+func writeEnvScript(t *testing.T, dir string) (script, out string) {
+	t.Helper()
+	script = filepath.Join(dir, "capture-env")
+	out = filepath.Join(dir, "env")
+	content := "#!/bin/sh\nenv > '" + out + ".tmp' && mv '" + out + ".tmp' '" + out + "'\n"
+	if err := os.WriteFile(script, []byte(content), 0o755); err != nil {
+		t.Fatal(err)
+	}
+	return script, out
+}
+
+// readEnvDump waits for the environment dumped to path and returns it.
+func readEnvDump(t *testing.T, path string) []string {
+	t.Helper()
+	for range 200 {
+		if data, err := os.ReadFile(path); err == nil {
+			return strings.Split(string(data), "\n")
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+	t.Fatalf("expected the environment to be dumped to %s", path)
+	return nil
+}
+
+func Test_openURL_Environment(t *testing.T) {
+	for _, launcher := range []bool{false, true} {
+		script, out := writeEnvScript(t, t.TempDir())
+		in := initInputHandler(t)
+		if launcher {
+			in.cfg.Launchers = []config.Launcher{{Tag: "go", Command: script + " %u"}}
+		} else {
+			in.cfg.Browser = script
+		}
+
+		in.api.Data.Bookmark = bukudb.Bookmark{ID: 42, URL: "https://go.dev",
+			Title: "The Go Programming Language", Tags: []string{"go", "lang"}}
+		in.handleGotoExec()
+		checkState(t, StateGotoExec, in.api.Data.State)
+
+		env := readEnvDump(t, out)
+		for _, v := range []string{
+			"ROBUKU_BOOKMARK_ID=42",
+			"ROBUKU_BOOKMARK_TITLE=The Go Programming Language",
+			"ROBUKU_BOOKMARK_TAGS=go,lang",
+		} {
+			if !slices.Contains(env, v) {
+				t.Errorf("launcher %t: expected '%s' in the environment, got %q", launcher, v, env)
+			}
+		}
+		// the environment of robuku is passed on
+		if !slices.ContainsFunc(env, func(v string) bool { return strings.HasPrefix(v, "PATH=") }) {
+			t.Errorf("launcher %t: expected PATH in the environment", launcher)
+		}
+	}
+}
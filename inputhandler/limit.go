@@ -0,0 +1,44 @@
+package inputhandler
+
+import (
+	"errors"
+	"fmt"
+
+	"github.com/VannRR/robuku/bukudb"
+	"github.com/VannRR/robuku/config"
+)
+
+// limitWarningPercent is how full the database may get, in percent of the
+// maximum number of bookmarks, before the bookmark list warns about it.
+const limitWarningPercent = 90
+
+// nearLimit reports whether n bookmarks are more than limitWarningPercent
+// of max.
+func nearLimit(n, max int) bool {
+	return max > 0 && n*100 > max*limitWarningPercent
+}
+
+// limitWarning returns the warning shown above the bookmark list when n of
+// max bookmarks are used, empty if the database is not near the limit.
+func limitWarning(n, max int) string {
+	if !nearLimit(n, max) {
+		return ""
+	}
+	return fmt.Sprintf("%d of %d bookmarks used, raise the limit with $%s",
+		n, max, config.MaxBookmarksEnvVar)
+}
+
+// explainLimit adds how to raise the limit to err if it is a
+// bukudb.LimitError.
+func explainLimit(err error) error {
+	var le *bukudb.LimitError
+	if errors.As(err, &le) {
+		return fmt.Errorf("%w, raise it with $%s", err, config.MaxBookmarksEnvVar)
+	}
+	return err
+}
+
+// warningMarkup formats msg as a warning line for the message box.
+func (s markupStyle) warningMarkup(msg string) string {
+	return s.spanMarkup(s.label, "warning:") + s.spanMarkup("", " "+msg)
+}
@@ -0,0 +1,70 @@
+package inputhandler
+
+import (
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/VannRR/robuku/bukudb"
+	rofiapi "github.com/VannRR/rofi-api"
+)
+
+func Test_limitWarning(t *testing.T) {
+	tests := []struct {
+		n, max   int
+		expected string
+	}{
+		{0, 1000, ""},
+		{900, 1000, ""},
+		{901, 1000, "901 of 1000 bookmarks used, raise the limit with $ROBUKU_MAX_BOOKMARKS"},
+		{1000, 1000, "1000 of 1000 bookmarks used, raise the limit with $ROBUKU_MAX_BOOKMARKS"},
+		{9, 10, ""},
+		{10, 10, "10 of 10 bookmarks used, raise the limit with $ROBUKU_MAX_BOOKMARKS"},
+		{5, 0, ""},
+	}
+	for _, tt := range tests {
+		if got := limitWarning(tt.n, tt.max); got != tt.expected {
+			t.Errorf("%d of %d: expected '%s', got '%s'", tt.n, tt.max, tt.expected, got)
+		}
+	}
+}
+
+func Test_explainLimit(t *testing.T) {
+	err := explainLimit(&bukudb.LimitError{Count: 1000, Max: 1000})
+	expected := "maximum number of bookmarks reached (1000 of 1000), raise it with $ROBUKU_MAX_BOOKMARKS"
+	if err.Error() != expected {
+		t.Errorf("expected error '%s', got '%s'", expected, err)
+	}
+	var le *bukudb.LimitError
+	if !errors.As(err, &le) {
+		t.Errorf("expected explained error to wrap the limit error")
+	}
+
+	other := errors.New("disk full")
+	if err := explainLimit(other); err != other {
+		t.Errorf("expected other errors unchanged, got '%v'", err)
+	}
+}
+
+// fullDB is a mockDB that has no room for more bookmarks.
+type fullDB struct {
+	*mockDB
+}
+
+func (db *fullDB) Add(b bukudb.Bookmark) error {
+	return &bukudb.LimitError{Count: db.Len(), Max: db.Len()}
+}
+
+func Test_handleAddSelect_Limit(t *testing.T) {
+	in := initInputHandler(t)
+	in.db = &fullDB{mockDB: newMockDB()}
+
+	in.api.Data.Bookmark = bukudb.Bookmark{URL: "https://www.new.com"}
+	in.handleAddSelect(opConfirm)
+
+	checkState(t, StateErrorShow, in.api.Data.State)
+	message := in.api.Options[rofiapi.OptionMessage]
+	if !strings.Contains(message, "(4 of 4), raise it with $ROBUKU_MAX_BOOKMARKS") {
+		t.Errorf("expected message to explain the limit, got '%s'", message)
+	}
+}
@@ -0,0 +1,69 @@
+package inputhandler
+
+import (
+	"fmt"
+
+	rofiapi "github.com/VannRR/rofi-api"
+)
+
+// defaultLinesFull is rofi's own list height, used for the long screens
+// when only $ROBUKU_LINES_SMALL is set, as rofi keeps the height of the
+// last theme snippet otherwise.
+const defaultLinesFull = 15
+
+// isSmallScreen reports whether state shows a short fixed list, like the
+// add and modify fields or a confirmation. Other states, including new
+// ones, get the full height.
+func (s State) isSmallScreen() bool {
+	switch s {
+	case StateErrorShow, StateErrorSelect,
+		StateAddShow, StateAddSelect,
+		StateModifyShow, StateModifySelect,
+		StateDeleteConfirmShow, StateDeleteConfirmSelect,
+		StateGotoConfirmShow, StateGotoConfirmSelect,
+		StateInitSchemaSelect,
+		StateTrashEmptyShow, StateTrashEmptySelect,
+		StateClearTagsShow, StateClearTagsSelect,
+		StateOverwriteShow, StateOverwriteSelect,
+		StateDiscardShow, StateDiscardSelect,
+		StateResetVisitsShow, StateResetVisitsSelect,
+		StateDuplicateURLShow, StateDuplicateURLSelect,
+		StateConflictShow, StateConflictSelect,
+		StateGotoChooseShow, StateGotoChooseSelect,
+		StateAliasesShow, StateAliasesSelect,
+		StateInboxShow, StateInboxSelect,
+		StateAddDuplicateShow, StateAddDuplicateSelect:
+		return true
+	}
+	return false
+}
+
+// listLines returns the list height of state, ok is false when it is left
+// to rofi.
+func (in *InputHandler) listLines(state State) (lines int, ok bool) {
+	if in.cfg.LinesSmall == 0 && in.cfg.LinesFull == 0 {
+		return 0, false
+	}
+	full := in.cfg.LinesFull
+	if full == 0 {
+		full = defaultLinesFull
+	}
+	if state.isSmallScreen() && in.cfg.LinesSmall > 0 {
+		return in.cfg.LinesSmall, true
+	}
+	return full, true
+}
+
+// setListLines sets the list height of the shown state with a theme
+// snippet. Launchers other than rofi, shown plain messages, don't read
+// theme snippets and are left alone.
+func (in *InputHandler) setListLines() {
+	if in.style.plain {
+		return
+	}
+	lines, ok := in.listLines(in.api.Data.State)
+	if !ok {
+		return
+	}
+	in.api.Options[rofiapi.OptionTheme] = fmt.Sprintf("listview { lines: %d; }", lines)
+}
@@ -0,0 +1,72 @@
+package inputhandler
+
+import (
+	"testing"
+
+	rofiapi "github.com/VannRR/rofi-api"
+)
+
+func Test_listLines(t *testing.T) {
+	in := initInputHandler(t)
+
+	tests := []struct {
+		small, full int
+		state       State
+		expected    int
+		ok          bool
+	}{
+		{0, 0, StateAddSelect, 0, false},
+		{0, 0, StateBookmarksSelect, 0, false},
+		{6, 20, StateAddSelect, 6, true},
+		{6, 20, StateModifySelect, 6, true},
+		{6, 20, StateDeleteConfirmSelect, 6, true},
+		{6, 20, StateErrorSelect, 6, true},
+		{6, 20, StateBookmarksSelect, 20, true},
+		{6, 20, StateTagsSelect, 20, true},
+		// rofi's height when only the small screens are set
+		{6, 0, StateBookmarksSelect, defaultLinesFull, true},
+		// the full height everywhere when only it is set
+		{0, 20, StateAddSelect, 20, true},
+		// states added later get the full height
+		{6, 20, stateLast + 1, 20, true},
+	}
+	for _, tt := range tests {
+		in.cfg.LinesSmall, in.cfg.LinesFull = tt.small, tt.full
+		lines, ok := in.listLines(tt.state)
+		if lines != tt.expected || ok != tt.ok {
+			t.Errorf("expected %d lines (%t) of state %d with small %d and full %d, got %d (%t)",
+				tt.expected, tt.ok, tt.state, tt.small, tt.full, lines, ok)
+		}
+	}
+}
+
+func Test_setListLines(t *testing.T) {
+	in := initInputHandler(t)
+
+	in.HandleInput("")
+	if theme, ok := in.api.Options[rofiapi.OptionTheme]; ok {
+		t.Errorf("expected no theme without line settings, got '%s'", theme)
+	}
+
+	in.cfg.LinesSmall, in.cfg.LinesFull = 6, 20
+	in.HandleInput("")
+	checkState(t, StateBookmarksSelect, in.api.Data.State)
+	if theme := in.api.Options[rofiapi.OptionTheme]; theme != "listview { lines: 20; }" {
+		t.Errorf("expected full height on the bookmark list, got '%s'", theme)
+	}
+
+	in.api.Data.State = StateAddShow
+	in.HandleInput("")
+	checkState(t, StateAddSelect, in.api.Data.State)
+	if theme := in.api.Options[rofiapi.OptionTheme]; theme != "listview { lines: 6; }" {
+		t.Errorf("expected small height on the add screen, got '%s'", theme)
+	}
+
+	in.UsePlainMessages()
+	delete(in.api.Options, rofiapi.OptionTheme)
+	in.api.Data.State = StateAddShow
+	in.HandleInput("")
+	if theme, ok := in.api.Options[rofiapi.OptionTheme]; ok {
+		t.Errorf("expected no theme with plain messages, got '%s'", theme)
+	}
+}
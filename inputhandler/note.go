@@ -0,0 +1,71 @@
+package inputhandler
+
+import (
+	"errors"
+	"fmt"
+	"time"
+
+	rofiapi "github.com/VannRR/rofi-api"
+)
+
+// errEmptyNote is shown when no note is entered.
+var errEmptyNote = errors.New("no note entered")
+
+// appendNote returns comment with note on a new line, or note if comment is
+// empty, like bukudb.AppendComment.
+func appendNote(comment, note string) string {
+	if comment == "" {
+		return note
+	}
+	return comment + "\n" + note
+}
+
+// datedNote returns note prefixed with the date of now, like
+// "2024-06-01: rechecked".
+func datedNote(now time.Time, note string) string {
+	return now.Format(time.DateOnly) + ": " + replaceNewlines(note)
+}
+
+func (in *InputHandler) handleAppendNoteShow() {
+	in.api.Options[rofiapi.OptionMessage] = in.style.generatePangoMarkup(
+		"enter a note to add to the comment with today's date",
+		"rechecked, still relevant",
+		in.api.Data.Bookmark.Comment)
+	in.api.Options[rofiapi.OptionNoCustom] = "false"
+	in.api.Options[rofiapi.OptionUseHotKeys] = "false"
+
+	in.api.Entries = []rofiapi.Entry{
+		{Text: opBack},
+	}
+
+	in.api.Data.State = StateAppendNoteSelect
+}
+
+func (in *InputHandler) handleAppendNoteSelect(input string) {
+	if input == opBack {
+		in.handleModifyShow()
+		return
+	}
+	if input == "" {
+		in.showWithError(in.handleAppendNoteShow, errEmptyNote)
+		return
+	}
+
+	note := datedNote(in.now(), input)
+	comment := appendNote(in.api.Data.Bookmark.Comment, note)
+	if err := checkLength(comment, in.cfg.MaxCommentLen); err != nil {
+		in.showWithError(in.handleAppendNoteShow, err)
+		return
+	}
+
+	if in.bookmarkChanged() {
+		return
+	}
+	if err := in.db.AppendComment(in.api.Data.Bookmark.ID, note); err != nil {
+		in.setMessageToError(fmt.Errorf("error appending note: %w", err))
+		return
+	}
+	in.saveUndo(fieldComment)
+	in.api.Data.Bookmark.Comment = comment
+	in.handleModifyShow()
+}
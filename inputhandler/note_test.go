@@ -0,0 +1,77 @@
+package inputhandler
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	rofiapi "github.com/VannRR/rofi-api"
+)
+
+func Test_handleAppendNote(t *testing.T) {
+	in := initInputHandler(t)
+	in.now = func() time.Time { return time.Date(2024, 6, 1, 9, 0, 0, 0, time.Local) }
+	in.api.Data.Bookmark, _ = in.db.Get(1)
+	old := in.api.Data.Bookmark.Comment
+
+	in.handleModifyShow()
+	checkState(t, StateModifySelect, in.api.Data.State)
+	in.handleModifySelect(opAppendNote)
+	checkState(t, StateAppendNoteSelect, in.api.Data.State)
+
+	in.HandleInput("rechecked, still relevant")
+	checkState(t, StateModifySelect, in.api.Data.State)
+	expected := old + "\n2024-06-01: rechecked, still relevant"
+	if b, _ := in.db.Get(1); b.Comment != expected || in.api.Data.Bookmark.Comment != expected {
+		t.Errorf("expected comment %q, got %q and %q", expected, b.Comment, in.api.Data.Bookmark.Comment)
+	}
+
+	// every note is its own line of the bookmark
+	texts := make([]string, len(in.api.Entries))
+	for i, e := range in.api.Entries {
+		texts[i] = e.Text
+	}
+	if !strings.Contains(strings.Join(texts, "\n"), "\n+ 2024-06-01: rechecked, still relevant\n") {
+		t.Errorf("expected note entry, got %q", texts)
+	}
+
+	// the note can be undone
+	in.handleModifySelect(opUndo)
+	if b, _ := in.db.Get(1); b.Comment != old {
+		t.Errorf("expected comment %q after undo, got %q", old, b.Comment)
+	}
+}
+
+func Test_handleAppendNoteSelect_Errors(t *testing.T) {
+	in := initInputHandler(t)
+	in.api.Data.Bookmark, _ = in.db.Get(1)
+
+	in.handleAppendNoteSelect("")
+	checkState(t, StateAppendNoteSelect, in.api.Data.State)
+	if !strings.Contains(in.api.Options[rofiapi.OptionMessage], errEmptyNote.Error()) {
+		t.Errorf("expected empty note error, got '%s'", in.api.Options[rofiapi.OptionMessage])
+	}
+
+	in.cfg.MaxCommentLen = len(in.api.Data.Bookmark.Comment) + 5
+	in.handleAppendNoteSelect("too long for the comment")
+	checkState(t, StateAppendNoteSelect, in.api.Data.State)
+	if b, _ := in.db.Get(1); b.Comment != in.api.Data.Bookmark.Comment {
+		t.Errorf("expected comment unchanged, got %q", b.Comment)
+	}
+
+	in.handleAppendNoteSelect(opBack)
+	checkState(t, StateModifySelect, in.api.Data.State)
+}
+
+func Test_datedNote(t *testing.T) {
+	now := time.Date(2024, 6, 1, 23, 59, 0, 0, time.Local)
+	if note := datedNote(now, "line one\nline two"); note != "2024-06-01: line one line two" {
+		t.Errorf("expected note on one line, got %q", note)
+	}
+	if comment := appendNote("", "note"); comment != "note" {
+		t.Errorf("expected 'note', got %q", comment)
+	}
+	if comment := appendNote("old", "note"); comment != "old\nnote" {
+		t.Errorf("expected %q, got %q", "old\nnote", comment)
+	}
+}
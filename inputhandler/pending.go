@@ -0,0 +1,87 @@
+package inputhandler
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/VannRR/robuku/bukudb"
+	"github.com/VannRR/robuku/config"
+	rofiapi "github.com/VannRR/rofi-api"
+)
+
+const (
+	opApplyChanges   string = "--> Apply changes"
+	opDiscardChanges string = "--> Discard changes"
+)
+
+// deferWrites reports whether the edits of the modify screen are kept in
+// Data until they are applied, instead of being written right away.
+func (in *InputHandler) deferWrites() bool {
+	return in.cfg.ModifyApply == config.ModifyApplyOnConfirm
+}
+
+// stageField marks field of the bookmark being modified as changed and
+// shows the modify screen, the change must already be made in Data.
+func (in *InputHandler) stageField(field bukudb.FieldMask) {
+	in.api.Data.Pending |= field
+	in.handleModifyShow()
+}
+
+// pendingFieldNames returns the names of the fields in mask, like
+// "title, tags".
+func pendingFieldNames(mask bukudb.FieldMask) string {
+	var names []string
+	for _, f := range []struct {
+		mask bukudb.FieldMask
+		name string
+	}{
+		{bukudb.MaskTitle, "title"},
+		{bukudb.MaskURL, "url"},
+		{bukudb.MaskComment, "comment"},
+		{bukudb.MaskTags, "tags"},
+	} {
+		if mask&f.mask != 0 {
+			names = append(names, f.name)
+		}
+	}
+	return strings.Join(names, ", ")
+}
+
+// applyChanges writes the pending fields of the bookmark in one update and
+// shows the bookmarks. If the update fails nothing is written and the
+// changes stay pending, e.g. to fix a duplicate url.
+func (in *InputHandler) applyChanges() {
+	if in.bookmarkChanged() {
+		return
+	}
+	b := in.api.Data.Bookmark
+	if err := in.db.Update(b.ID, b, in.api.Data.Pending); err != nil {
+		in.showWithError(in.handleModifyShow, fmt.Errorf("error applying changes: %w", err))
+		return
+	}
+	in.api.Data.Pending = 0
+	in.api.Data.Undo = Undo{}
+	in.HandleBookmarksShow()
+}
+
+func (in *InputHandler) handleDiscardShow() {
+	in.api.Options[rofiapi.OptionMessage] = in.style.generatePangoMarkup(
+		fmt.Sprintf("discard the changes to %s?", pendingFieldNames(in.api.Data.Pending)), "", "")
+	in.api.Options[rofiapi.OptionNoCustom] = "true"
+	in.api.Options[rofiapi.OptionUseHotKeys] = "false"
+
+	in.api.Entries = []rofiapi.Entry{
+		{Text: opBack},
+		{Text: opDiscardChanges},
+	}
+
+	in.api.Data.State = StateDiscardSelect
+}
+
+func (in *InputHandler) handleDiscardSelect(input string) {
+	if input != opDiscardChanges {
+		in.handleModifyShow()
+		return
+	}
+	in.HandleBookmarksShow()
+}
@@ -0,0 +1,163 @@
+package inputhandler
+
+import (
+	"slices"
+	"strings"
+	"testing"
+
+	"github.com/VannRR/robuku/bukudb"
+	"github.com/VannRR/robuku/config"
+	rofiapi "github.com/VannRR/rofi-api"
+)
+
+func initDeferredInputHandler(t *testing.T) *InputHandler {
+	t.Helper()
+	in := initInputHandler(t)
+	in.cfg.ModifyApply = config.ModifyApplyOnConfirm
+	in.api.Data.Bookmark, _ = in.db.Get(1)
+	return in
+}
+
+func hasEntry(entries []rofiapi.Entry, text string) bool {
+	return slices.ContainsFunc(entries, func(e rofiapi.Entry) bool { return e.Text == text })
+}
+
+func Test_pendingFieldNames(t *testing.T) {
+	tests := []struct {
+		mask     bukudb.FieldMask
+		expected string
+	}{
+		{0, ""},
+		{bukudb.MaskURL, "url"},
+		{bukudb.MaskTitle | bukudb.MaskTags, "title, tags"},
+		{bukudb.MaskAll, "title, url, comment, tags"},
+	}
+	for _, tt := range tests {
+		if got := pendingFieldNames(tt.mask); got != tt.expected {
+			t.Errorf("mask %#b: expected '%s', got '%s'", tt.mask, tt.expected, got)
+		}
+	}
+}
+
+func Test_modify_Immediate(t *testing.T) {
+	in := initInputHandler(t)
+	in.api.Data.Bookmark, _ = in.db.Get(1)
+
+	in.handleModifyTitleSelect("new title")
+	checkState(t, StateModifySelect, in.api.Data.State)
+	if b, _ := in.db.Get(1); b.Title != "new title" {
+		t.Errorf("expected title 'new title' to be written, got '%s'", b.Title)
+	}
+	if in.api.Data.Pending != 0 {
+		t.Errorf("expected no pending fields, got %#b", in.api.Data.Pending)
+	}
+	if hasEntry(in.api.Entries, opApplyChanges) {
+		t.Errorf("expected no '%s' entry", opApplyChanges)
+	}
+
+	in.handleModifySelect(opBack)
+	checkState(t, StateBookmarksSelect, in.api.Data.State)
+}
+
+func Test_modify_OnConfirm(t *testing.T) {
+	in := initDeferredInputHandler(t)
+	original, _ := in.db.Get(1)
+
+	in.handleModifyTitleSelect("new title")
+	in.handleModifyUrlSelect("https://www.new.com")
+	in.handleModifyTagsSelect("+ new")
+	in.handleModifyTagsSelect("- tag2")
+
+	checkState(t, StateModifySelect, in.api.Data.State)
+	expectedPending := bukudb.MaskTitle | bukudb.MaskURL | bukudb.MaskTags
+	if in.api.Data.Pending != expectedPending {
+		t.Errorf("expected pending fields %#b, got %#b", expectedPending, in.api.Data.Pending)
+	}
+	if b, _ := in.db.Get(1); !slices.Equal(b.Tags, original.Tags) || b.Title != original.Title ||
+		b.URL != original.URL {
+		t.Errorf("expected bookmark to be unchanged before applying, got %+v", b)
+	}
+	if !hasEntry(in.api.Entries, opApplyChanges) {
+		t.Errorf("expected a '%s' entry", opApplyChanges)
+	}
+	if hasEntry(in.api.Entries, opAppendNote) {
+		t.Errorf("expected no '%s' entry while changes are pending", opAppendNote)
+	}
+	if msg := in.api.Options[rofiapi.OptionMessage]; !strings.Contains(msg, "title, url, tags") {
+		t.Errorf("expected message to name the pending fields, got '%s'", msg)
+	}
+
+	in.handleModifySelect(opApplyChanges)
+	checkState(t, StateBookmarksSelect, in.api.Data.State)
+	if in.api.Data.Pending != 0 {
+		t.Errorf("expected no pending fields, got %#b", in.api.Data.Pending)
+	}
+	b, _ := in.db.Get(1)
+	expectedTags := []string{"google", "new", "tag3"}
+	if b.Title != "new title" || b.URL != "https://www.new.com" || !slices.Equal(b.Tags, expectedTags) {
+		t.Errorf("expected applied changes, got %+v", b)
+	}
+}
+
+func Test_modify_OnConfirm_ClearTags(t *testing.T) {
+	in := initDeferredInputHandler(t)
+	in.cfg.Confirm = config.ConfirmAll
+
+	in.handleModifyTagsSelect(opDelete)
+	checkState(t, StateClearTagsSelect, in.api.Data.State)
+	in.handleClearTagsSelect("yes")
+
+	checkState(t, StateModifySelect, in.api.Data.State)
+	if in.api.Data.Pending != bukudb.MaskTags || len(in.api.Data.Bookmark.Tags) != 0 {
+		t.Errorf("expected cleared tags to be pending, got %+v", in.api.Data)
+	}
+	if b, _ := in.db.Get(1); len(b.Tags) != 3 {
+		t.Errorf("expected tags to be kept, got %v", b.Tags)
+	}
+}
+
+func Test_handleModifySelect_DiscardChanges(t *testing.T) {
+	in := initDeferredInputHandler(t)
+	in.handleModifyCommentSelect("new comment")
+
+	in.handleModifySelect(opBack)
+	checkState(t, StateDiscardSelect, in.api.Data.State)
+	expectedEntries := []rofiapi.Entry{{Text: opBack}, {Text: opDiscardChanges}}
+	checkEntries(t, expectedEntries, in.api.Entries)
+
+	// back keeps the changes
+	in.handleDiscardSelect(opBack)
+	checkState(t, StateModifySelect, in.api.Data.State)
+	if in.api.Data.Pending != bukudb.MaskComment || in.api.Data.Bookmark.Comment != "new comment" {
+		t.Errorf("expected the comment to stay pending, got %+v", in.api.Data)
+	}
+
+	in.handleModifySelect(opBack)
+	in.handleDiscardSelect(opDiscardChanges)
+	checkState(t, StateBookmarksSelect, in.api.Data.State)
+	if in.api.Data.Pending != 0 {
+		t.Errorf("expected no pending fields, got %#b", in.api.Data.Pending)
+	}
+	if b, _ := in.db.Get(1); b.Comment != "desc (comment) google" {
+		t.Errorf("expected comment to be discarded, got '%s'", b.Comment)
+	}
+}
+
+func Test_applyChanges_DuplicateURL(t *testing.T) {
+	in := initDeferredInputHandler(t)
+	in.handleModifyTitleSelect("new title")
+	in.handleModifyUrlSelect("https://www.b.com")
+
+	in.handleModifySelect(opApplyChanges)
+
+	checkState(t, StateModifySelect, in.api.Data.State)
+	if msg := in.api.Options[rofiapi.OptionMessage]; !strings.Contains(msg, "error applying changes") {
+		t.Errorf("expected message to contain the error, got '%s'", msg)
+	}
+	if in.api.Data.Pending != bukudb.MaskTitle|bukudb.MaskURL {
+		t.Errorf("expected the changes to stay pending, got %#b", in.api.Data.Pending)
+	}
+	if b, _ := in.db.Get(1); b.Title != "metadata (title) google" {
+		t.Errorf("expected title to be unchanged, got '%s'", b.Title)
+	}
+}
@@ -0,0 +1,58 @@
+package inputhandler
+
+import (
+	"strings"
+
+	rofiapi "github.com/VannRR/rofi-api"
+)
+
+// UsePlainMessages drops the pango markup from the message box, like
+// $ROBUKU_PLAIN=1, for a launcher that does not speak rofi's script protocol
+// and likely renders no markup either.
+func (in *InputHandler) UsePlainMessages() {
+	in.style.plain = true
+	in.disableEntryMarkup()
+}
+
+// generatePlainMessage is generatePangoMarkup without markup, for
+// $ROBUKU_PLAIN=1.
+func generatePlainMessage(instructions, example, currentValue string) string {
+	var lines []string
+	if instructions != "" {
+		lines = append(lines, instructions)
+	}
+	if example != "" {
+		lines = append(lines, "example: "+example)
+	}
+	if currentValue != "" {
+		lines = append(lines, "current: "+currentValue)
+	}
+	return joinMessageLines(lines...)
+}
+
+// joinMessageLines returns the non-empty plain message lines joined by the
+// line break of the message box.
+func joinMessageLines(lines ...string) string {
+	var kept []string
+	for _, l := range lines {
+		if l != "" {
+			kept = append(kept, l)
+		}
+	}
+	return strings.Join(kept, "\r")
+}
+
+// wrapMessage returns the message box of body, built with spanMarkup.
+func (s markupStyle) wrapMessage(body string) string {
+	if s.plain {
+		return body
+	}
+	return "<markup>" + body + "</markup>"
+}
+
+// disableEntryMarkup makes sure rofi shows the entries as plain text too.
+func (in *InputHandler) disableEntryMarkup() {
+	if in.style.plain {
+		in.api.Options[rofiapi.OptionMarkupRows] = "false"
+	}
+}
@@ -0,0 +1,92 @@
+package inputhandler
+
+import (
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/VannRR/robuku/config"
+	rofiapi "github.com/VannRR/rofi-api"
+)
+
+func Test_generatePangoMarkup_Plain(t *testing.T) {
+	long := strings.Repeat("a", 60) + strings.Repeat("b", 60)
+	truncated := strings.Repeat("a", 49) + "…" + strings.Repeat("b", 50)
+	tests := []struct {
+		instructions, example, current string
+		markup, plain                  string
+	}{
+		{"enter a tag", "", "",
+			`<markup><span font_weight="bold">enter a tag</span></markup>`,
+			"enter a tag"},
+		{"enter a url", "https://go.dev", "",
+			`<markup><span font_weight="bold">enter a url</span>` + "\r" +
+				`<span font_weight="bold">example:</span><span> <span style="italic">https://go.dev</span></span></markup>`,
+			"enter a url\rexample: https://go.dev"},
+		{"", "", "a & b",
+			`<markup><span font_weight="bold">current:</span><span> <span underline="single">a &amp; b</span></span></markup>`,
+			"current: a & b"},
+		{"enter a title", "", long,
+			`<markup><span font_weight="bold">enter a title</span>` + "\r" +
+				`<span font_weight="bold">current:</span><span> <span underline="single">` + truncated + `</span></span></markup>`,
+			"enter a title\rcurrent: " + truncated},
+		{"", "", "", "<markup></markup>", ""},
+	}
+	for _, tt := range tests {
+		for _, plain := range []bool{false, true} {
+			s := newMarkupStyle(config.Config{Plain: plain})
+			expected := tt.markup
+			if plain {
+				expected = tt.plain
+			}
+			if got := s.generatePangoMarkup(tt.instructions, tt.example, tt.current); got != expected {
+				t.Errorf("plain %v, instructions '%s': expected %q, got %q", plain, tt.instructions, expected, got)
+			}
+		}
+	}
+}
+
+func Test_UsePlainMessages(t *testing.T) {
+	in := initInputHandler(t)
+	in.UsePlainMessages()
+	if in.api.Options[rofiapi.OptionMarkupRows] != "false" {
+		t.Errorf("expected entry markup to be disabled, got '%s'", in.api.Options[rofiapi.OptionMarkupRows])
+	}
+
+	in.handleModifyShow()
+	in.showWithError(in.handleModifyShow, errors.New("bad <input>"))
+	in.prependMessage(in.style.warningMarkup("low & slow"))
+	expected := "warning: low & slow\rerror: bad <input>\rselect a field to edit"
+	if msg := in.api.Options[rofiapi.OptionMessage]; !strings.HasPrefix(msg, expected) {
+		t.Errorf("expected message to start with %q, got %q", expected, msg)
+	}
+
+	in.setMessageToError(errors.New("failed"))
+	if msg := in.api.Options[rofiapi.OptionMessage]; msg != "error: failed" {
+		t.Errorf("expected plain error message, got %q", msg)
+	}
+}
+
+func Test_NewInputHandler_Plain(t *testing.T) {
+	t.Setenv(config.PlainEnvVar, "1")
+
+	in := initInputHandler(t)
+	if !in.style.plain || in.api.Options[rofiapi.OptionMarkupRows] != "false" {
+		t.Errorf("expected $%s=1 to enable plain messages", config.PlainEnvVar)
+	}
+}
+
+func Test_NewInputHandler_PlainPerHandler(t *testing.T) {
+	in := initInputHandler(t)
+	t.Setenv(config.PlainEnvVar, "1")
+	plain := initInputHandler(t)
+
+	plain.handleModifyShow()
+	if msg := plain.api.Options[rofiapi.OptionMessage]; msg != "select a field to edit" {
+		t.Errorf("expected a plain message, got %q", msg)
+	}
+	in.handleModifyShow()
+	if msg := in.api.Options[rofiapi.OptionMessage]; !strings.HasPrefix(msg, "<markup>") {
+		t.Errorf("expected the other handler to keep its markup, got %q", msg)
+	}
+}
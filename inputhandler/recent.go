@@ -0,0 +1,13 @@
+package inputhandler
+
+// recentAction lists the recently added bookmarks, or the whole list again.
+var recentAction = hotkeyAction{key: 13, hint: "recent", help: "list the recently added bookmarks"}
+
+// recentLine labels the recently added view in the message box.
+const recentLine = "recently added"
+
+// toggleRecent switches between the recently added view and the list.
+func (in *InputHandler) toggleRecent() {
+	in.api.Data.Recent = !in.api.Data.Recent
+	in.HandleBookmarksShow()
+}
@@ -0,0 +1,115 @@
+package inputhandler
+
+import (
+	"slices"
+	"strings"
+	"testing"
+
+	rofiapi "github.com/VannRR/rofi-api"
+)
+
+func Test_HandleBookmarksShow_recent(t *testing.T) {
+	in := initInputHandler(t)
+	in.cfg.Recent = 3
+
+	in.handleBookmarksSelect("", rofiapi.StateCustomKeybinding13)
+	checkState(t, StateBookmarksSelect, in.api.Data.State)
+	if !in.api.Data.Recent {
+		t.Errorf("expected the recently added view")
+	}
+	if in.api.Entries[0].Text != opBack {
+		t.Errorf("expected '%s' first, got '%s'", opBack, in.api.Entries[0].Text)
+	}
+	if ids := listedIDs(in.api.Entries); !slices.Equal(ids, []uint16{4, 3, 2}) {
+		t.Errorf("expected bookmarks 4, 3, 2, got %v", ids)
+	}
+	if message := in.api.Options[rofiapi.OptionMessage]; !strings.Contains(message, recentLine) {
+		t.Errorf("expected '%s' in message, got '%s'", recentLine, message)
+	}
+
+	// limit boundary
+	for _, tt := range []struct {
+		n        int
+		expected []uint16
+	}{
+		{1, []uint16{4}},
+		{4, []uint16{4, 3, 2, 1}},
+		{5, []uint16{4, 3, 2, 1}},
+	} {
+		in.cfg.Recent = tt.n
+		in.HandleBookmarksShow()
+		if ids := listedIDs(in.api.Entries); !slices.Equal(ids, tt.expected) {
+			t.Errorf("expected bookmarks %v for %d recent, got %v", tt.expected, tt.n, ids)
+		}
+	}
+
+	// hidden tags stay hidden
+	in.cfg.HiddenTags = []string{"b"}
+	in.HandleBookmarksShow()
+	if ids := listedIDs(in.api.Entries); !slices.Equal(ids, []uint16{4, 3, 1}) {
+		t.Errorf("expected bookmarks 4, 3, 1 with b hidden, got %v", ids)
+	}
+	in.cfg.HiddenTags = nil
+
+	// back leaves the view, keeping the filters
+	in.api.Data.Filters = []Filter{{Tag: "tag2"}}
+	in.handleBookmarksSelect(opBack, rofiapi.StateSelected)
+	if in.api.Data.Recent || len(in.api.Data.Filters) != 1 {
+		t.Errorf("expected back to leave the view only, got recent %v and filters %v",
+			in.api.Data.Recent, in.api.Data.Filters)
+	}
+	if ids := listedIDs(in.api.Entries); !slices.Equal(ids, []uint16{1, 2}) {
+		t.Errorf("expected the filtered bookmarks 1 and 2, got %v", ids)
+	}
+
+	// filtering leaves the view too
+	in.api.Data.Recent = true
+	if err := in.pushFilter("tag:google"); err != nil {
+		t.Fatal(err)
+	}
+	if in.api.Data.Recent {
+		t.Errorf("expected filtering to leave the recently added view")
+	}
+}
+
+func Test_handleBookmarksSelect_recent(t *testing.T) {
+	in := initInputHandler(t)
+	in.cfg.Recent = 2
+	in.api.Data.Recent = true
+
+	// modify returns to the view
+	in.HandleBookmarksShow()
+	in.handleBookmarksSelect(in.api.Entries[2].Text, rofiapi.StateCustomKeybinding2)
+	checkState(t, StateModifySelect, in.api.Data.State)
+	if in.api.Data.Bookmark.ID != 4 {
+		t.Errorf("expected bookmark 4 to be modified, got %d", in.api.Data.Bookmark.ID)
+	}
+	in.HandleInput(opBack)
+	checkState(t, StateBookmarksSelect, in.api.Data.State)
+	if ids := listedIDs(in.api.Entries); !slices.Equal(ids, []uint16{4, 3}) {
+		t.Errorf("expected to return to the recently added view, got %v", ids)
+	}
+
+	// open and stay
+	in.cfg.Browser = "true"
+	in.handleBookmarksSelect(in.api.Entries[3].Text, rofiapi.StateCustomKeybinding7)
+	checkState(t, StateBookmarksSelect, in.api.Data.State)
+	if ids := listedIDs(in.api.Entries); !slices.Equal(ids, []uint16{4, 3}) {
+		t.Errorf("expected to stay in the recently added view, got %v", ids)
+	}
+
+	// delete
+	if len(in.api.Entries) < 3 {
+		t.Fatalf("expected the recently added bookmarks, got %v", in.api.Entries)
+	}
+	in.handleBookmarksSelect(in.api.Entries[2].Text, rofiapi.StateCustomKeybinding3)
+	checkState(t, StateDeleteConfirmSelect, in.api.Data.State)
+	in.HandleInput("yes")
+	checkState(t, StateBookmarksSelect, in.api.Data.State)
+	if in.db.Len() != 3 {
+		t.Errorf("expected 3 bookmarks after delete, got %d", in.db.Len())
+	}
+	if ids := listedIDs(in.api.Entries); !slices.Equal(ids, []uint16{3, 2}) {
+		t.Errorf("expected the recently added view after delete, got %v", ids)
+	}
+}
@@ -0,0 +1,63 @@
+package inputhandler
+
+import (
+	"fmt"
+	"log"
+	"os"
+	"path/filepath"
+	"runtime/debug"
+	"time"
+
+	rofiapi "github.com/VannRR/rofi-api"
+)
+
+// recoverPanic shows the error screen instead of crashing when handling the
+// input panicked, it must be deferred.
+func (in *InputHandler) recoverPanic() {
+	if r := recover(); r != nil {
+		showPanic(in.api, in.style, in.cfg.CrashLogFile, r)
+	}
+}
+
+// ShowPanic logs the panic r with the stack of the panicking goroutine,
+// appends both to the crash log at path, and shows an error screen pointing
+// there. It must be called from the deferred function that recovered r.
+func ShowPanic(api *rofiapi.RofiApi[Data], path string, r any) {
+	showPanic(api, defaultStyle, path, r)
+}
+
+// showPanic is ShowPanic showing the error in style s.
+func showPanic(api *rofiapi.RofiApi[Data], s markupStyle, path string, r any) {
+	stack := debug.Stack()
+	log.Printf("ERROR panic: %v\n%s", r, stack)
+
+	where := "stderr"
+	if path != "" {
+		if err := appendCrashLog(path, time.Now(), r, stack); err != nil {
+			log.Println("ERROR", err)
+		} else {
+			where = path
+		}
+	}
+
+	// the state that panicked is not shown again
+	api.Data = Data{}
+	showError(api, s, fmt.Errorf("internal error — see log at %s", where))
+}
+
+// appendCrashLog appends the panic r at t with its stack to the file at
+// path.
+func appendCrashLog(path string, t time.Time, r any, stack []byte) error {
+	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		return fmt.Errorf("failed to create state directory: %w", err)
+	}
+	f, err := os.OpenFile(path, os.O_WRONLY|os.O_CREATE|os.O_APPEND, 0o600)
+	if err != nil {
+		return fmt.Errorf("failed to open crash log: %w", err)
+	}
+	if _, err := fmt.Fprintf(f, "%s panic: %v\n%s\n", t.Format(time.RFC3339), r, stack); err != nil {
+		f.Close()
+		return fmt.Errorf("failed to write crash log: %w", err)
+	}
+	return f.Close()
+}
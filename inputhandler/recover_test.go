@@ -0,0 +1,65 @@
+package inputhandler
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/VannRR/robuku/bukudb"
+	rofiapi "github.com/VannRR/rofi-api"
+)
+
+// panicDB is a mockDB whose queries of the bookmark list panic.
+type panicDB struct {
+	*mockDB
+}
+
+func (panicDB) GetAllContext(context.Context) ([]bukudb.Bookmark, error) {
+	var ids []uint16
+	_ = ids[len(ids)]
+	return nil, nil
+}
+
+func Test_HandleInput_panic(t *testing.T) {
+	for _, timeout := range []time.Duration{0, time.Second} {
+		api, err := rofiapi.NewRofiApi(Data{})
+		if err != nil {
+			t.Fatalf("expected no error from NewRofiApi(), got %v", err)
+		}
+		in := NewInputHandler(panicDB{newMockDB()}, api)
+		in.cfg.DBTimeout = timeout
+		in.cfg.CrashLogFile = filepath.Join(t.TempDir(), "state", "crash.log")
+		in.api.Data = Data{State: StateBookmarksShow, ResultIDs: []uint16{1}}
+
+		in.HandleInput("")
+
+		checkState(t, StateErrorShow, in.api.Data.State)
+		checkEntries(t, []rofiapi.Entry{{Text: opExit}}, in.api.Entries)
+		expected := "internal error — see log at " + in.cfg.CrashLogFile
+		if !strings.Contains(in.api.Options[rofiapi.OptionMessage], expected) {
+			t.Errorf("expected message to contain '%s', got '%s'", expected, in.api.Options[rofiapi.OptionMessage])
+		}
+		if in.api.Data.ResultIDs != nil {
+			t.Errorf("expected data to be reset, got %v", in.api.Data)
+		}
+		log, err := os.ReadFile(in.cfg.CrashLogFile)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !strings.Contains(string(log), "index out of range") || !strings.Contains(string(log), "GetAllContext") {
+			t.Errorf("expected panic and stack in crash log, got '%s'", log)
+		}
+	}
+}
+
+func Test_ShowPanic_noCrashLog(t *testing.T) {
+	in := initInputHandler(t)
+	ShowPanic(in.api, "", "boom")
+	checkState(t, StateErrorShow, in.api.Data.State)
+	if !strings.Contains(in.api.Options[rofiapi.OptionMessage], "internal error — see log at stderr") {
+		t.Errorf("expected message pointing at stderr, got '%s'", in.api.Options[rofiapi.OptionMessage])
+	}
+}
@@ -0,0 +1,180 @@
+package inputhandler
+
+import (
+	"net"
+	"strconv"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+
+	"github.com/VannRR/robuku/bukudb"
+)
+
+// maxCachedURLs bounds the URL keywords an entryRenderer keeps, past it the
+// cache starts over.
+const maxCachedURLs = 1 << 16
+
+// entryRenderer builds the text and keywords of bookmark list entries,
+// reusing its buffers from entry to entry so the list allocates little more
+// than the strings it returns.
+type entryRenderer struct {
+	buf  []byte
+	seen map[string]struct{}
+
+	// sigil is put before the tags, which are also added without it.
+	sigil string
+
+	// urls are the URL keywords by URL, the same bookmarks are listed
+	// again and again in a session.
+	urls map[string][]string
+}
+
+// text returns the entry text of the bookmark with id, padded with zeros to
+// width, prefix, and title.
+func (r *entryRenderer) text(width int, id uint16, prefix, title string) string {
+	buf := r.buf[:0]
+	for n := digits(id); n < width; n++ {
+		buf = append(buf, '0')
+	}
+	buf = strconv.AppendUint(buf, uint64(id), 10)
+	buf = append(buf, ". "...)
+	buf = append(buf, prefix...)
+	buf = append(buf, title...)
+	r.buf = buf
+
+	if utf8.RuneCount(buf) > entryMaxLen {
+		return replaceNewlines(truncateEnd(string(buf), entryMaxLen))
+	}
+	return replaceNewlines(string(buf))
+}
+
+// digits returns the number of decimal digits of id.
+func digits(id uint16) int {
+	n := 1
+	for ; id >= 10; id /= 10 {
+		n++
+	}
+	return n
+}
+
+// meta returns the keywords of b like buildMeta, with the URLs of its
+// aliases.
+func (r *entryRenderer) meta(b bukudb.Bookmark, aliases ...string) string {
+	if r.seen == nil {
+		r.seen = make(map[string]struct{})
+	}
+	clear(r.seen)
+	r.buf = r.buf[:0]
+
+	for _, t := range b.Tags {
+		for f := range fields(t) {
+			r.addTag(f)
+		}
+	}
+	if b.URL != "" {
+		for _, k := range r.urlKeywords(b.URL) {
+			r.add(k)
+		}
+	}
+	for _, a := range aliases {
+		for _, k := range r.urlKeywords(a) {
+			r.add(k)
+		}
+	}
+	for w := range fields(b.Comment) {
+		r.add(strings.TrimFunc(w, func(r rune) bool {
+			return !unicode.IsLetter(r) && !unicode.IsDigit(r)
+		}))
+	}
+	return string(r.buf)
+}
+
+// add appends the lower case t to the keywords unless it is empty or
+// already there.
+func (r *entryRenderer) add(t string) {
+	t = strings.ToLower(t)
+	if t == "" {
+		return
+	}
+	if _, ok := r.seen[t]; ok {
+		return
+	}
+	r.seen[t] = struct{}{}
+	if len(r.buf) > 0 {
+		r.buf = append(r.buf, ' ')
+	}
+	r.buf = append(r.buf, t...)
+}
+
+// addTag appends the lower case tag t and, with a sigil, t after it, unless
+// t is empty or already there. The tag with the sigil is not kept in seen,
+// it is there whenever the bare tag is.
+func (r *entryRenderer) addTag(t string) {
+	t = strings.ToLower(t)
+	if t == "" {
+		return
+	}
+	if _, ok := r.seen[t]; ok {
+		return
+	}
+	r.seen[t] = struct{}{}
+	if len(r.buf) > 0 {
+		r.buf = append(r.buf, ' ')
+	}
+	r.buf = append(r.buf, t...)
+	if r.sigil != "" {
+		r.buf = append(r.buf, ' ')
+		r.buf = append(r.buf, r.sigil...)
+		r.buf = append(r.buf, t...)
+	}
+}
+
+// urlKeywords returns the keywords of a URL, the cleaned url without spaces
+// followed by the labels of its host but the top level domain, so
+// "docs.example.org" also matches "docs" and "example".
+func (r *entryRenderer) urlKeywords(url string) []string {
+	if k, ok := r.urls[url]; ok {
+		return k
+	}
+	if r.urls == nil || len(r.urls) >= maxCachedURLs {
+		r.urls = make(map[string][]string)
+	}
+	k := []string{strings.Join(strings.Fields(bukudb.CleanURL(url)), "")}
+	k = append(k, hostLabels(bukudb.URLHost(url))...)
+	r.urls[url] = k
+	return k
+}
+
+// hostLabels returns the labels of host without the top level domain, or
+// nil for a host with a single label or an IP address.
+func hostLabels(host string) []string {
+	if net.ParseIP(host) != nil {
+		return nil
+	}
+	labels := strings.Split(host, ".")
+	if len(labels) < 2 {
+		return nil
+	}
+	return labels[:len(labels)-1]
+}
+
+// fields yields the space separated fields of s like strings.Fields,
+// without allocating a slice for them.
+func fields(s string) func(yield func(string) bool) {
+	return func(yield func(string) bool) {
+		start := -1
+		for i, c := range s {
+			if unicode.IsSpace(c) {
+				if start >= 0 && !yield(s[start:i]) {
+					return
+				}
+				start = -1
+			} else if start < 0 {
+				start = i
+			}
+		}
+		if start >= 0 {
+			yield(s[start:])
+		}
+	}
+}
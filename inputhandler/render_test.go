@@ -0,0 +1,112 @@
+package inputhandler
+
+import (
+	"fmt"
+	"slices"
+	"strings"
+	"testing"
+
+	"github.com/VannRR/robuku/bukudb"
+	"github.com/VannRR/robuku/inputhandler/testutil"
+	rofiapi "github.com/VannRR/rofi-api"
+)
+
+func Test_entryRenderer_text(t *testing.T) {
+	tests := []struct {
+		width  int
+		id     uint16
+		prefix string
+		title  string
+	}{
+		{1, 7, "", "title"},
+		{4, 7, "", "title"},
+		{3, 123, deadLinkPrefix, "dead"},
+		{2, 65535, "", "wider than width"},
+		{1, 1, "", "two\nlines"},
+		{3, 42, "", strings.Repeat("é", entryMaxLen)},
+	}
+	var r entryRenderer
+	for _, tt := range tests {
+		expected := formatEntryText(fmt.Sprintf("%0*d. %s", tt.width, tt.id, tt.prefix+tt.title))
+		if actual := r.text(tt.width, tt.id, tt.prefix, tt.title); actual != expected {
+			t.Errorf("id %d: expected '%s', got '%s'", tt.id, expected, actual)
+		}
+	}
+}
+
+func Test_entryRenderer_meta(t *testing.T) {
+	bookmarks := append(testutil.Bookmarks(50), bukudb.Bookmark{
+		ID: 51, URL: "mailto:Someone@Example.com", Tags: []string{"Two Words", "two"},
+		Comment: "  Hello, hello\tWORLD!  "})
+
+	// one renderer for all bookmarks, twice to use its URL cache
+	var r entryRenderer
+	for range 2 {
+		for _, b := range bookmarks {
+			var fresh entryRenderer
+			if expected, actual := fresh.meta(b), r.meta(b); actual != expected {
+				t.Errorf("bookmark %d: expected '%s', got '%s'", b.ID, expected, actual)
+			}
+		}
+	}
+	if expected := "two #two words #words someone@example.com hello world"; buildMeta(bookmarks[50]) != expected {
+		t.Errorf("expected '%s', got '%s'", expected, buildMeta(bookmarks[50]))
+	}
+}
+
+func Test_entryRenderer_meta_sigil(t *testing.T) {
+	b := bukudb.Bookmark{URL: "https://go.dev", Tags: []string{"golang", "Lang/Go"}, Comment: "#golang"}
+	tests := []struct {
+		sigil    string
+		expected string
+	}{
+		{"#", "golang #golang lang/go #lang/go go.dev go"},
+		{"@", "golang @golang lang/go @lang/go go.dev go"},
+		{"", "golang lang/go go.dev go"},
+	}
+	for _, tt := range tests {
+		r := entryRenderer{sigil: tt.sigil}
+		if actual := r.meta(b); actual != tt.expected {
+			t.Errorf("sigil '%s': expected '%s', got '%s'", tt.sigil, tt.expected, actual)
+		}
+	}
+}
+
+func Test_handleHelpShow_tagSigil(t *testing.T) {
+	in := initInputHandler(t)
+	in.cfg.TagSigil = "@"
+	in.handleHelpShow()
+	if !hasEntry(in.api.Entries, "@golang: match only the bookmarks tagged golang, not the titles") {
+		t.Errorf("expected the tag sigil on the help screen, got %v", in.api.Entries)
+	}
+
+	in.cfg.TagSigil = ""
+	in.handleHelpShow()
+	for _, e := range in.api.Entries {
+		if strings.Contains(e.Text, "golang") {
+			t.Errorf("expected no tag sigil line, got '%s'", e.Text)
+		}
+	}
+}
+
+func Test_fields(t *testing.T) {
+	for _, s := range []string{"", "  ", "a", " a  b\tc\n", "ä ö\u00a0ü"} {
+		if actual := slices.Collect(fields(s)); !slices.Equal(actual, strings.Fields(s)) {
+			t.Errorf("input %q: expected %q, got %q", s, strings.Fields(s), actual)
+		}
+	}
+}
+
+func Test_appendBookmarkEntries_Allocs(t *testing.T) {
+	in := initInputHandler(t)
+	bookmarks := testutil.Bookmarks(bukudb.MaxBookmarks)
+	entries := make([]rofiapi.Entry, 0, len(bookmarks))
+
+	allocs := testing.AllocsPerRun(10, func() {
+		entries = in.appendBookmarkEntries(entries[:0], bookmarks, nil, nil, "")
+	})
+	// the text and keywords of each entry, the buffers are reused
+	if limit := float64(2*len(bookmarks) + 10); allocs > limit {
+		t.Errorf("expected at most %.0f allocations, got %.0f", limit, allocs)
+	}
+}
@@ -0,0 +1,47 @@
+package inputhandler
+
+import (
+	"slices"
+	"strings"
+
+	rofiapi "github.com/VannRR/rofi-api"
+)
+
+const (
+	// snippetPrefix marks comment snippet entries.
+	snippetPrefix = "✎ "
+	// snippetSeparator separates an existing comment from an appended snippet.
+	snippetSeparator = "; "
+)
+
+// commentEntries returns the entries of the comment prompts, Back and Delete
+// followed by the comment snippets.
+func (in *InputHandler) commentEntries() []rofiapi.Entry {
+	entries := []rofiapi.Entry{
+		{Text: opBack},
+		{Text: opDelete},
+	}
+	for _, snippet := range in.cfg.CommentSnippets {
+		entries = append(entries, rofiapi.Entry{Text: snippetPrefix + snippet})
+	}
+	return entries
+}
+
+// snippetFromInput returns the comment snippet selected by input, ok is
+// false if input is not a snippet entry.
+func (in *InputHandler) snippetFromInput(input string) (snippet string, ok bool) {
+	snippet, ok = strings.CutPrefix(input, snippetPrefix)
+	if !ok || !slices.Contains(in.cfg.CommentSnippets, snippet) {
+		return "", false
+	}
+	return snippet, true
+}
+
+// appendSnippet appends snippet to comment, separated by snippetSeparator
+// unless comment is empty.
+func appendSnippet(comment, snippet string) string {
+	if comment == "" {
+		return snippet
+	}
+	return comment + snippetSeparator + snippet
+}
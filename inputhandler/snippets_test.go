@@ -0,0 +1,79 @@
+package inputhandler
+
+import (
+	"testing"
+
+	"github.com/VannRR/robuku/bukudb"
+	rofiapi "github.com/VannRR/rofi-api"
+)
+
+const testSnippet = "why: … / found via: …"
+
+func Test_commentEntries(t *testing.T) {
+	in := initInputHandler(t)
+	in.cfg.CommentSnippets = []string{testSnippet, "to read later"}
+
+	in.handleAddCommentShow()
+	expectedEntries := []rofiapi.Entry{
+		{Text: opBack},
+		{Text: opDelete},
+		{Text: snippetPrefix + testSnippet},
+		{Text: snippetPrefix + "to read later"},
+	}
+	checkEntries(t, expectedEntries, in.api.Entries)
+
+	in.handleModifyCommentShow()
+	checkEntries(t, expectedEntries, in.api.Entries)
+}
+
+func Test_handleAddCommentSelect_Snippet(t *testing.T) {
+	in := initInputHandler(t)
+	in.cfg.CommentSnippets = []string{testSnippet}
+	in.api.Data.Bookmark.Comment = "old comment"
+
+	// snippets replace the comment while adding
+	in.handleAddCommentSelect(snippetPrefix + testSnippet)
+	checkState(t, StateAddSelect, in.api.Data.State)
+	if in.api.Data.Bookmark.Comment != testSnippet {
+		t.Errorf("expected bookmark comment '%s', got '%s'", testSnippet,
+			in.api.Data.Bookmark.Comment)
+	}
+
+	// unknown snippets are kept as typed
+	in.handleAddCommentSelect(snippetPrefix + "not a snippet")
+	if in.api.Data.Bookmark.Comment != snippetPrefix+"not a snippet" {
+		t.Errorf("expected bookmark comment '%s', got '%s'", snippetPrefix+"not a snippet",
+			in.api.Data.Bookmark.Comment)
+	}
+}
+
+func Test_handleModifyCommentSelect_Snippet(t *testing.T) {
+	in := initInputHandler(t)
+	in.cfg.CommentSnippets = []string{testSnippet}
+
+	// snippets are appended to the existing comment
+	loadBookmark(in, bukudb.Bookmark{ID: 1, Comment: "old comment"})
+	in.handleModifyCommentSelect(snippetPrefix + testSnippet)
+	checkState(t, StateModifySelect, in.api.Data.State)
+	expected := "old comment" + snippetSeparator + testSnippet
+	if in.api.Data.Bookmark.Comment != expected {
+		t.Errorf("expected bookmark comment '%s', got '%s'", expected, in.api.Data.Bookmark.Comment)
+	}
+
+	// the combined comment must fit the length limit
+	in.cfg.MaxCommentLen = len([]rune(expected))
+	in.handleModifyCommentSelect(snippetPrefix + testSnippet)
+	checkState(t, StateModifyCommentSelect, in.api.Data.State)
+	if in.api.Data.Bookmark.Comment != expected {
+		t.Errorf("expected bookmark comment '%s', got '%s'", expected, in.api.Data.Bookmark.Comment)
+	}
+}
+
+func Test_appendSnippet(t *testing.T) {
+	if got := appendSnippet("", "b"); got != "b" {
+		t.Errorf("expected 'b', got '%s'", got)
+	}
+	if got := appendSnippet("a", "b"); got != "a"+snippetSeparator+"b" {
+		t.Errorf("expected 'a%sb', got '%s'", snippetSeparator, got)
+	}
+}
@@ -0,0 +1,30 @@
+package inputhandler
+
+import "github.com/VannRR/robuku/bukudb"
+
+// The sources of a bookmark being added, tagged "via/" and the source with
+// $ROBUKU_AUTO_TAG_SOURCE=1.
+const (
+	SourceCLI       = "cli"
+	SourceClipboard = "clipboard"
+	SourceRofi      = "rofi"
+)
+
+// sourceTagPrefix groups the source tags under "via" in the tag browser.
+const sourceTagPrefix = "via/"
+
+// sourceTag returns the tag of source, like "via/cli", or "" for no source.
+func sourceTag(source string) string {
+	if source == "" {
+		return ""
+	}
+	return sourceTagPrefix + source
+}
+
+// tagSource adds the tag of the source of the bookmark being added to b,
+// the copy that is inserted, if source tags are on.
+func (in *InputHandler) tagSource(b *bukudb.Bookmark) {
+	if tag := sourceTag(in.api.Data.Source); in.cfg.AutoTagSource && tag != "" {
+		b.Tags = withTags(b.Tags, []string{tag})
+	}
+}
@@ -0,0 +1,97 @@
+package inputhandler
+
+import (
+	"slices"
+	"testing"
+
+	"github.com/VannRR/robuku/bukudb"
+	"github.com/VannRR/robuku/config"
+	"github.com/VannRR/robuku/inputhandler/testutil"
+	rofiapi "github.com/VannRR/rofi-api"
+)
+
+func Test_sourceTag(t *testing.T) {
+	tests := []struct {
+		source   string
+		expected string
+	}{
+		{SourceCLI, "via/cli"},
+		{SourceClipboard, "via/clipboard"},
+		{SourceRofi, "via/rofi"},
+		{"", ""},
+	}
+	for _, tt := range tests {
+		if actual := sourceTag(tt.source); actual != tt.expected {
+			t.Errorf("source '%s': expected '%s', got '%s'", tt.source, tt.expected, actual)
+		}
+	}
+}
+
+func Test_tagSource(t *testing.T) {
+	in := initInputHandler(t)
+
+	// added without the source tag
+	in.handleBookmarksSelect("", rofiapi.StateCustomKeybinding1)
+	if in.api.Data.Source != SourceRofi {
+		t.Errorf("expected source '%s', got '%s'", SourceRofi, in.api.Data.Source)
+	}
+	in.api.Data.Bookmark = bukudb.Bookmark{URL: "https://new.com", Tags: []string{"go"}}
+	in.handleAddSelect(opConfirm)
+	if b, _ := in.db.Get(5); !slices.Equal(b.Tags, []string{"go"}) {
+		t.Errorf("expected tags [go], got %v", b.Tags)
+	}
+
+	in.cfg.AutoTagSource = true
+	in.HandleAddURL("https://newer.com", SourceClipboard)
+	in.api.Data.Bookmark.Tags = []string{"go"}
+	in.handleAddSelect(opConfirm)
+	if b, _ := in.db.Get(6); !slices.Equal(b.Tags, []string{"go", "via/clipboard"}) {
+		t.Errorf("expected tags [go via/clipboard], got %v", b.Tags)
+	}
+	if in.api.Data.Source != "" {
+		t.Errorf("expected the source to be forgotten, got '%s'", in.api.Data.Source)
+	}
+
+	// the add command of the prompt is rofi
+	in.handleBookmarksSelect(":add https://newest.com", rofiapi.StateSelectedCustom)
+	in.handleAddSelect(opConfirm)
+	if b, _ := in.db.Get(7); !slices.Equal(b.Tags, []string{"via/rofi"}) {
+		t.Errorf("expected tags [via/rofi], got %v", b.Tags)
+	}
+}
+
+// Test_tagSource_normalized checks the source tags come back from the
+// database and the tags prompt as they were written.
+func Test_tagSource_normalized(t *testing.T) {
+	db, err := bukudb.NewBukuDB(testutil.NewFixtureDB(t, 0))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer db.Close()
+	api, err := rofiapi.NewRofiApi(Data{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	cfg := config.Default()
+	cfg.AutoTagSource = true
+	in := NewInputHandlerWithConfig(db, api, cfg)
+
+	in.HandleAddURL("https://a.com", SourceCLI)
+	in.handleAddSelect(opConfirm)
+	b, err := db.Get(1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !slices.Equal(b.Tags, []string{"via/cli"}) {
+		t.Errorf("expected tags [via/cli], got %v", b.Tags)
+	}
+
+	for _, split := range []config.TagSplit{config.TagSplitComma, config.TagSplitCommaSpace} {
+		for _, source := range []string{SourceCLI, SourceClipboard, SourceRofi} {
+			tag := sourceTag(source)
+			if tags := getTagsFromInput(tag, split); !slices.Equal(tags, []string{tag}) {
+				t.Errorf("split %s: expected tags [%s], got %v", split, tag, tags)
+			}
+		}
+	}
+}
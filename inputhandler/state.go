@@ -0,0 +1,95 @@
+package inputhandler
+
+import "strconv"
+
+// stateNames are the names of the states for logs and traces.
+var stateNames = [...]string{
+	StateNull:                "Null",
+	StateErrorShow:           "ErrorShow",
+	StateErrorSelect:         "ErrorSelect",
+	StateBookmarksShow:       "BookmarksShow",
+	StateBookmarksSelect:     "BookmarksSelect",
+	StateAddShow:             "AddShow",
+	StateAddSelect:           "AddSelect",
+	StateAddTitleShow:        "AddTitleShow",
+	StateAddTitleSelect:      "AddTitleSelect",
+	StateAddUrlShow:          "AddUrlShow",
+	StateAddUrlSelect:        "AddUrlSelect",
+	StateAddCommentShow:      "AddCommentShow",
+	StateAddCommentSelect:    "AddCommentSelect",
+	StateAddTagsShow:         "AddTagsShow",
+	StateAddTagsSelect:       "AddTagsSelect",
+	StateGotoExec:            "GotoExec",
+	StateModifyShow:          "ModifyShow",
+	StateModifySelect:        "ModifySelect",
+	StateModifyTitleShow:     "ModifyTitleShow",
+	StateModifyTitleSelect:   "ModifyTitleSelect",
+	StateModifyUrlShow:       "ModifyUrlShow",
+	StateModifyUrlSelect:     "ModifyUrlSelect",
+	StateModifyCommentShow:   "ModifyCommentShow",
+	StateModifyCommentSelect: "ModifyCommentSelect",
+	StateModifyTagsShow:      "ModifyTagsShow",
+	StateModifyTagsSelect:    "ModifyTagsSelect",
+	StateDeleteConfirmShow:   "DeleteConfirmShow",
+	StateDeleteConfirmSelect: "DeleteConfirmSelect",
+	StateStatsShow:           "StatsShow",
+	StateStatsSelect:         "StatsSelect",
+	StateInitSchemaSelect:    "InitSchemaSelect",
+	StateGotoConfirmShow:     "GotoConfirmShow",
+	StateGotoConfirmSelect:   "GotoConfirmSelect",
+	StateExportShow:          "ExportShow",
+	StateExportSelect:        "ExportSelect",
+	StateTrashShow:           "TrashShow",
+	StateTrashSelect:         "TrashSelect",
+	StateTrashEmptyShow:      "TrashEmptyShow",
+	StateTrashEmptySelect:    "TrashEmptySelect",
+	StateFilterShow:          "FilterShow",
+	StateFilterSelect:        "FilterSelect",
+	StateTitlesShow:          "TitlesShow",
+	StateTitlesSelect:        "TitlesSelect",
+	StateTitleEnterShow:      "TitleEnterShow",
+	StateTitleEnterSelect:    "TitleEnterSelect",
+	StateClearTagsShow:       "ClearTagsShow",
+	StateClearTagsSelect:     "ClearTagsSelect",
+	StateHelpShow:            "HelpShow",
+	StateHelpSelect:          "HelpSelect",
+	StateExportPathShow:      "ExportPathShow",
+	StateExportPathSelect:    "ExportPathSelect",
+	StateOverwriteShow:       "OverwriteShow",
+	StateOverwriteSelect:     "OverwriteSelect",
+	StateImportPreviewShow:   "ImportPreviewShow",
+	StateImportPreviewSelect: "ImportPreviewSelect",
+	StateAppendNoteShow:      "AppendNoteShow",
+	StateAppendNoteSelect:    "AppendNoteSelect",
+	StateDiscardShow:         "DiscardShow",
+	StateDiscardSelect:       "DiscardSelect",
+	StateResetVisitsShow:     "ResetVisitsShow",
+	StateResetVisitsSelect:   "ResetVisitsSelect",
+	StateTagsShow:            "TagsShow",
+	StateTagsSelect:          "TagsSelect",
+	StateDuplicateURLShow:    "DuplicateURLShow",
+	StateDuplicateURLSelect:  "DuplicateURLSelect",
+	StateDeleteMultiShow:     "DeleteMultiShow",
+	StateDeleteMultiSelect:   "DeleteMultiSelect",
+	StateConflictShow:        "ConflictShow",
+	StateConflictSelect:      "ConflictSelect",
+	StateGotoChooseShow:      "GotoChooseShow",
+	StateGotoChooseSelect:    "GotoChooseSelect",
+	StateAliasesShow:         "AliasesShow",
+	StateAliasesSelect:       "AliasesSelect",
+	StateInboxShow:           "InboxShow",
+	StateInboxSelect:         "InboxSelect",
+	StateInboxTagsShow:       "InboxTagsShow",
+	StateInboxTagsSelect:     "InboxTagsSelect",
+	StateAddDuplicateShow:    "AddDuplicateShow",
+	StateAddDuplicateSelect:  "AddDuplicateSelect",
+}
+
+// String returns the name of s without the State prefix, like
+// "BookmarksSelect", or its number for a state this version doesn't know.
+func (s State) String() string {
+	if int(s) < len(stateNames) && stateNames[s] != "" {
+		return stateNames[s]
+	}
+	return "State(" + strconv.Itoa(int(s)) + ")"
+}
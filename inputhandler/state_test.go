@@ -0,0 +1,40 @@
+package inputhandler
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+
+	"github.com/VannRR/robuku/internal/golden"
+)
+
+// Test_State_values guards the numbers of the states, which are saved
+// between runs: changing one breaks the sessions of robuku in flight.
+func Test_State_values(t *testing.T) {
+	var got strings.Builder
+	for s := StateNull; s <= stateLast; s++ {
+		if s.String() == fmt.Sprintf("State(%d)", s) {
+			t.Errorf("expected state %d to have a name", s)
+		}
+		fmt.Fprintf(&got, "%s %d\n", s, s)
+	}
+	golden.Check(t, "state_values", got.String())
+}
+
+func Test_State_String(t *testing.T) {
+	tests := []struct {
+		state    State
+		expected string
+	}{
+		{StateNull, "Null"},
+		{StateBookmarksSelect, "BookmarksSelect"},
+		{StateAddDuplicateSelect, "AddDuplicateSelect"},
+		{stateLast + 1, fmt.Sprintf("State(%d)", stateLast+1)},
+		{255, "State(255)"},
+	}
+	for _, tt := range tests {
+		if got := tt.state.String(); got != tt.expected {
+			t.Errorf("expected '%s', got '%s'", tt.expected, got)
+		}
+	}
+}
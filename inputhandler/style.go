@@ -0,0 +1,100 @@
+package inputhandler
+
+import (
+	"fmt"
+	"log"
+	"regexp"
+	"slices"
+	"strings"
+
+	"github.com/VannRR/robuku/config"
+)
+
+// markupStyle holds the pango span attributes used in the message box.
+type markupStyle struct {
+	// label of instructions and of the example and current values.
+	label string
+
+	// example value.
+	example string
+
+	// current value.
+	current string
+
+	// plain drops the markup, the message box is plain text for launchers
+	// that do not render pango.
+	plain bool
+}
+
+var defaultStyle = markupStyle{
+	label:   `font_weight="bold"`,
+	example: `style="italic"`,
+	current: `underline="single"`,
+}
+
+// spanAttributes are the pango span attributes allowed in styles.
+var spanAttributes = []string{
+	"font", "font_desc", "font_family", "face", "font_size", "size",
+	"font_style", "style", "font_weight", "weight", "font_variant", "variant",
+	"font_stretch", "stretch", "foreground", "fgcolor", "color",
+	"background", "bgcolor", "alpha", "fgalpha", "background_alpha", "bgalpha",
+	"underline", "underline_color", "overline", "overline_color",
+	"strikethrough", "strikethrough_color", "rise", "letter_spacing",
+}
+
+// spanAttributeRe matches one name="value" or name='value' attribute, values
+// may not contain characters that would break out of the span tag.
+var spanAttributeRe = regexp.MustCompile(`^\s*([a-z_]+)=(?:"([^"'<>&]*)"|'([^"'<>&]*)')`)
+
+// parseSpanAttributes validates s, pango span attributes like
+// `foreground="#ff0000" font_weight="bold"`, and returns them with double
+// quoted values.
+func parseSpanAttributes(s string) (string, error) {
+	var attributes []string
+	rest := s
+	for strings.TrimSpace(rest) != "" {
+		m := spanAttributeRe.FindStringSubmatch(rest)
+		if m == nil {
+			return "", fmt.Errorf("invalid span attributes '%s'", s)
+		}
+		if !slices.Contains(spanAttributes, m[1]) {
+			return "", fmt.Errorf("span attribute '%s' is not allowed", m[1])
+		}
+		attributes = append(attributes, fmt.Sprintf(`%s="%s"`, m[1], m[2]+m[3]))
+		rest = rest[len(m[0]):]
+	}
+	if len(attributes) == 0 {
+		return "", fmt.Errorf("no span attributes in '%s'", s)
+	}
+	return strings.Join(attributes, " "), nil
+}
+
+// newMarkupStyle returns the style set in cfg, invalid or unset attributes
+// fall back to the default style.
+func newMarkupStyle(cfg config.Config) markupStyle {
+	s := defaultStyle
+	if cfg.Plain {
+		s.plain = true
+		return s
+	}
+	for _, a := range []struct {
+		value  string
+		envVar string
+		field  *string
+	}{
+		{cfg.StyleLabel, config.StyleLabelEnvVar, &s.label},
+		{cfg.StyleExample, config.StyleExampleEnvVar, &s.example},
+		{cfg.StyleCurrent, config.StyleCurrentEnvVar, &s.current},
+	} {
+		if a.value == "" {
+			continue
+		}
+		attributes, err := parseSpanAttributes(a.value)
+		if err != nil {
+			log.Println("ERROR", fmt.Errorf("ignoring $%s: %w", a.envVar, err))
+			continue
+		}
+		*a.field = attributes
+	}
+	return s
+}
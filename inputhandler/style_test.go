@@ -0,0 +1,77 @@
+package inputhandler
+
+import (
+	"testing"
+
+	"github.com/VannRR/robuku/config"
+)
+
+func Test_parseSpanAttributes(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected string
+		ok       bool
+	}{
+		{`font_weight="bold"`, `font_weight="bold"`, true},
+		{` foreground="#ff0000"  underline='double' `, `foreground="#ff0000" underline="double"`, true},
+		{`size="large" style="italic"`, `size="large" style="italic"`, true},
+		{`onclick="x"`, "", false},
+		{`foreground=red`, "", false},
+		{`foreground="red`, "", false},
+		{`foreground="a"b"`, "", false},
+		{`foreground="'"`, "", false},
+		{`foreground="<b>"`, "", false},
+		{`foreground="red"><b`, "", false},
+		{`foreground="&amp;"`, "", false},
+		{`   `, "", false},
+	}
+
+	for _, test := range tests {
+		actual, err := parseSpanAttributes(test.input)
+		if (err == nil) != test.ok {
+			t.Errorf("input '%s': expected ok %v, got error '%v'", test.input, test.ok, err)
+		}
+		if actual != test.expected {
+			t.Errorf("input '%s': expected '%s', got '%s'", test.input, test.expected, actual)
+		}
+	}
+}
+
+func Test_newMarkupStyle(t *testing.T) {
+	cfg := config.Default()
+	if s := newMarkupStyle(cfg); s != defaultStyle {
+		t.Errorf("expected default style '%v', got '%v'", defaultStyle, s)
+	}
+
+	cfg.StyleLabel = `foreground="#00ff00"`
+	cfg.StyleExample = `onclick="x"`
+	cfg.StyleCurrent = `underline="none" weight='heavy'`
+	expected := markupStyle{
+		label:   `foreground="#00ff00"`,
+		example: defaultStyle.example,
+		current: `underline="none" weight="heavy"`,
+	}
+	if s := newMarkupStyle(cfg); s != expected {
+		t.Errorf("expected style '%v', got '%v'", expected, s)
+	}
+}
+
+func Test_generatePangoMarkup_style(t *testing.T) {
+	expected := `<markup><span font_weight="bold">do it</span>` +
+		"\r" + `<span font_weight="bold">example:</span><span> <span style="italic">a &amp; b</span></span>` +
+		"\r" + `<span font_weight="bold">current:</span><span> <span underline="single">c</span></span></markup>`
+	if actual := defaultStyle.generatePangoMarkup("do it", "a & b", "c"); actual != expected {
+		t.Errorf("expected markup '%s', got '%s'", expected, actual)
+	}
+
+	s := markupStyle{
+		label:   `foreground="red"`,
+		example: `size="small"`,
+		current: `background="blue"`,
+	}
+	expected = `<markup><span foreground="red">example:</span><span> <span size="small">a</span></span>` +
+		"\r" + `<span foreground="red">current:</span><span> <span background="blue">c</span></span></markup>`
+	if actual := s.generatePangoMarkup("", "a", "c"); actual != expected {
+		t.Errorf("expected markup '%s', got '%s'", expected, actual)
+	}
+}
@@ -0,0 +1,165 @@
+package inputhandler
+
+import (
+	"net"
+	"slices"
+	"sort"
+	"strings"
+
+	"github.com/VannRR/robuku/bukudb"
+	"github.com/VannRR/robuku/config"
+	rofiapi "github.com/VannRR/rofi-api"
+)
+
+const (
+	tagChecked   string = "[x] "
+	tagUnchecked string = "[ ] "
+)
+
+// builtinTagSuggestions are the tags suggested for well known sites, after
+// the configured ones.
+var builtinTagSuggestions = []config.TagSuggestion{
+	{Site: "github", Tags: []string{"github", "code"}},
+	{Site: "gitlab", Tags: []string{"gitlab", "code"}},
+	{Site: "codeberg", Tags: []string{"codeberg", "code"}},
+	{Site: "stackoverflow", Tags: []string{"stackoverflow", "code"}},
+	{Site: "youtube", Tags: []string{"video"}},
+	{Site: "youtu.be", Tags: []string{"video"}},
+	{Site: "vimeo", Tags: []string{"video"}},
+	{Site: "twitch", Tags: []string{"video"}},
+	{Site: "wikipedia", Tags: []string{"wiki"}},
+	{Site: "arxiv", Tags: []string{"paper"}},
+	{Site: "reddit", Tags: []string{"reddit", "forum"}},
+}
+
+// countrySecondLevels are the second levels sites register under in some
+// country top level domains, like "co" of "bbc.co.uk".
+var countrySecondLevels = map[string]bool{
+	"co": true, "com": true, "net": true, "org": true, "gov": true,
+	"edu": true, "ac": true, "or": true, "ne": true, "go": true,
+}
+
+// siteName returns the name of the site of host, like "bbc" of
+// "news.bbc.co.uk", or an empty string for IP addresses and hosts without a
+// top level domain.
+func siteName(host string) string {
+	if net.ParseIP(host) != nil {
+		return ""
+	}
+	labels := strings.Split(host, ".")
+	n := len(labels)
+	switch {
+	case n < 2:
+		return ""
+	case n > 2 && len(labels[n-1]) == 2 && countrySecondLevels[labels[n-2]]:
+		return labels[n-3]
+	default:
+		return labels[n-2]
+	}
+}
+
+// suggestTags returns the tags suggested for a bookmark of rawURL, those of
+// the first suggestion of custom or the built-in ones matching its host,
+// or else the site name.
+func suggestTags(rawURL string, custom []config.TagSuggestion) []string {
+	host := bukudb.URLHost(rawURL)
+	name := siteName(host)
+	if name == "" {
+		return nil
+	}
+	for _, suggestions := range [][]config.TagSuggestion{custom, builtinTagSuggestions} {
+		for _, s := range suggestions {
+			if s.Site == name || s.Site == host || strings.HasSuffix(host, "."+s.Site) {
+				return slices.Clone(s.Tags)
+			}
+		}
+	}
+	return []string{name}
+}
+
+// suggestedTags returns the tags suggested for the bookmark being added that
+// are not too long.
+func (in *InputHandler) suggestedTags() []string {
+	var tags []string
+	for _, t := range suggestTags(in.api.Data.Bookmark.URL, in.cfg.TagSuggestions) {
+		if checkLength(t, in.cfg.MaxTagLen) == nil {
+			tags = append(tags, t)
+		}
+	}
+	return tags
+}
+
+// applySuggestedTags adds the suggested tags to the bookmark being added,
+// once per URL so the ones toggled off stay off.
+func (in *InputHandler) applySuggestedTags() {
+	b := &in.api.Data.Bookmark
+	if b.URL == "" || in.api.Data.Suggested == b.URL {
+		return
+	}
+	in.api.Data.Suggested = b.URL
+	b.Tags = withTags(b.Tags, in.suggestedTags())
+}
+
+// withTags returns tags with those of add it doesn't have yet, sorted.
+// tags is not modified.
+func withTags(tags, add []string) []string {
+	tags = slices.Clone(tags)
+	for _, t := range add {
+		if !containsTag(tags, t) {
+			tags = append(tags, t)
+		}
+	}
+	sortTags(tags)
+	return tags
+}
+
+// suggestionEntries returns an entry toggling each suggested tag, checked if
+// the bookmark being added has it.
+func (in *InputHandler) suggestionEntries() []rofiapi.Entry {
+	var entries []rofiapi.Entry
+	for _, t := range in.suggestedTags() {
+		prefix := tagUnchecked
+		if containsTag(in.api.Data.Bookmark.Tags, t) {
+			prefix = tagChecked
+		}
+		entries = append(entries, rofiapi.Entry{Text: prefix + t})
+	}
+	return entries
+}
+
+// toggleSuggestedTag adds or removes the suggested tag of the selected entry
+// and reports whether input was such an entry.
+func (in *InputHandler) toggleSuggestedTag(input string) bool {
+	b := &in.api.Data.Bookmark
+	for _, t := range in.suggestedTags() {
+		switch input {
+		case tagChecked + t:
+			b.Tags = slices.DeleteFunc(b.Tags, func(bt string) bool { return strings.EqualFold(bt, t) })
+		case tagUnchecked + t:
+			b.Tags = append(b.Tags, t)
+			sortTags(b.Tags)
+		default:
+			continue
+		}
+		return true
+	}
+	return false
+}
+
+// keepSuggestedTags returns tags with the suggested tags the bookmark being
+// added has, so typing tags does not uncheck them.
+func (in *InputHandler) keepSuggestedTags(tags []string) []string {
+	for _, t := range in.suggestedTags() {
+		if containsTag(in.api.Data.Bookmark.Tags, t) && !containsTag(tags, t) {
+			tags = append(tags, t)
+		}
+	}
+	return tags
+}
+
+// sortTags sorts tags case insensitively.
+func sortTags(tags []string) {
+	sort.Slice(tags, func(i, j int) bool {
+		return strings.ToLower(tags[i]) < strings.ToLower(tags[j])
+	})
+}
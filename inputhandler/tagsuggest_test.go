@@ -0,0 +1,114 @@
+package inputhandler
+
+import (
+	"slices"
+	"testing"
+
+	"github.com/VannRR/robuku/config"
+	rofiapi "github.com/VannRR/rofi-api"
+)
+
+func Test_suggestTags(t *testing.T) {
+	custom := []config.TagSuggestion{
+		{Site: "docs.python.org", Tags: []string{"python", "docs"}},
+		{Site: "gitlab", Tags: []string{"work"}},
+	}
+	tests := []struct {
+		url      string
+		expected []string
+	}{
+		{"https://github.com/VannRR/robuku", []string{"github", "code"}},
+		{"https://gist.github.com/x", []string{"github", "code"}},
+		{"https://www.youtube.com/watch?v=x", []string{"video"}},
+		{"https://m.youtube.co.uk/watch?v=x", []string{"video"}},
+		{"https://youtube.de", []string{"video"}},
+		{"https://youtu.be/x", []string{"video"}},
+		{"https://docs.python.org/3/", []string{"python", "docs"}},
+		{"https://en.python.org", []string{"python"}},
+		{"https://gitlab.com/x", []string{"work"}},
+		{"https://news.bbc.co.uk/story", []string{"bbc"}},
+		{"https://example.org:8080/", []string{"example"}},
+		{"https://example.com.au", []string{"example"}},
+		{"http://127.0.0.1/", nil},
+		{"http://localhost:3000", nil},
+		{"mailto:someone@example.com", nil},
+		{"", nil},
+	}
+	for _, tt := range tests {
+		if actual := suggestTags(tt.url, custom); !slices.Equal(actual, tt.expected) {
+			t.Errorf("expected suggestions %q for '%s', got %q", tt.expected, tt.url, actual)
+		}
+	}
+}
+
+func Test_handleAddTagsShow_suggestions(t *testing.T) {
+	in := initInputHandler(t)
+	in.api.Data.Bookmark.URL = "https://github.com/VannRR/robuku"
+	in.api.Data.Bookmark.Tags = []string{"go"}
+
+	in.handleAddTagsShow()
+	if !slices.Equal(in.api.Data.Bookmark.Tags, []string{"code", "github", "go"}) {
+		t.Errorf("expected suggested tags to be added, got %q", in.api.Data.Bookmark.Tags)
+	}
+	checkEntries(t, []rofiapi.Entry{
+		{Text: opBack},
+		{Text: opDelete},
+		{Text: tagChecked + "github"},
+		{Text: tagChecked + "code"},
+	}, in.api.Entries)
+
+	// toggled off, and not added again on the next visit
+	in.handleAddTagsSelect(tagChecked + "code")
+	checkState(t, StateAddTagsSelect, in.api.Data.State)
+	if !hasEntry(in.api.Entries, tagUnchecked+"code") {
+		t.Errorf("expected unchecked suggestion, got %v", in.api.Entries)
+	}
+	in.handleAddTagsSelect(opBack)
+	in.handleAddTagsShow()
+	if !slices.Equal(in.api.Data.Bookmark.Tags, []string{"github", "go"}) {
+		t.Errorf("expected toggled off tag to stay off, got %q", in.api.Data.Bookmark.Tags)
+	}
+
+	// typed tags keep the checked suggestions
+	in.handleAddTagsSelect("rofi, tools")
+	checkState(t, StateAddSelect, in.api.Data.State)
+	if !slices.Equal(in.api.Data.Bookmark.Tags, []string{"github", "rofi", "tools"}) {
+		t.Errorf("expected typed and checked tags, got %q", in.api.Data.Bookmark.Tags)
+	}
+
+	// toggled on again
+	in.handleAddTagsShow()
+	in.handleAddTagsSelect(tagUnchecked + "code")
+	if !slices.Equal(in.api.Data.Bookmark.Tags, []string{"code", "github", "rofi", "tools"}) {
+		t.Errorf("expected toggled on tag, got %q", in.api.Data.Bookmark.Tags)
+	}
+}
+
+func Test_handleAddTagsShow_suggestionsTooLong(t *testing.T) {
+	in := initInputHandler(t)
+	in.cfg.MaxTagLen = 5
+	in.api.Data.Bookmark.URL = "https://github.com"
+
+	in.handleAddTagsShow()
+	if !slices.Equal(in.api.Data.Bookmark.Tags, []string{"code"}) {
+		t.Errorf("expected only the suggestions within the tag length, got %q", in.api.Data.Bookmark.Tags)
+	}
+}
+
+func Test_handleAddSelect_autoTags(t *testing.T) {
+	for _, autoTags := range []bool{false, true} {
+		in := initInputHandler(t)
+		in.cfg.AutoTags = autoTags
+		in.api.Data.Bookmark.URL = "https://www.youtube.com/watch?v=x"
+
+		in.handleAddSelect(opConfirm)
+		checkState(t, StateBookmarksSelect, in.api.Data.State)
+		b, err := in.db.Get(uint16(in.db.Len()))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if actual := slices.Equal(b.Tags, []string{"video"}); actual != autoTags {
+			t.Errorf("expected suggested tags added %v with auto tags %v, got %q", autoTags, autoTags, b.Tags)
+		}
+	}
+}
@@ -0,0 +1,127 @@
+package inputhandler
+
+import (
+	"fmt"
+
+	"github.com/VannRR/robuku/bukudb"
+	rofiapi "github.com/VannRR/rofi-api"
+)
+
+// opAllTagged filters by the expanded parent tag, matching all its children.
+const opAllTagged string = "--> All tagged "
+
+// tagEntryText returns the tag browser entry of name used count times.
+func tagEntryText(name string, count int) string {
+	return fmt.Sprintf("%s (%d)", name, count)
+}
+
+// tagGroups returns the tags of db grouped by their parent.
+func (in *InputHandler) tagGroups() ([]bukudb.TagGroup, error) {
+	tags, err := in.db.Tags()
+	if err != nil {
+		return nil, fmt.Errorf("error getting tags: %w", err)
+	}
+	return bukudb.GroupTags(tags), nil
+}
+
+// expandedGroup returns the group of the expanded parent tag, ok is false if
+// it has no children anymore.
+func expandedGroup(groups []bukudb.TagGroup, parent string) (bukudb.TagGroup, bool) {
+	for _, g := range groups {
+		if g.Name == parent && len(g.Children) > 0 {
+			return g, true
+		}
+	}
+	return bukudb.TagGroup{}, false
+}
+
+func (in *InputHandler) handleTagsShow() {
+	in.api.Options[rofiapi.OptionNoCustom] = "true"
+	in.api.Options[rofiapi.OptionUseHotKeys] = "false"
+	in.api.Entries = []rofiapi.Entry{
+		{Text: opBack},
+	}
+	in.api.Data.State = StateTagsSelect
+
+	groups, err := in.tagGroups()
+	if err != nil {
+		in.setMessageToError(err)
+		return
+	}
+
+	if g, ok := expandedGroup(groups, in.api.Data.TagParent); ok {
+		in.api.Options[rofiapi.OptionMessage] = in.style.generatePangoMarkup(
+			"select a tag to show its bookmarks", "", g.Name)
+		in.api.Entries = append(in.api.Entries, rofiapi.Entry{Text: opAllTagged + g.Name})
+		for _, c := range g.Children {
+			in.api.Entries = append(in.api.Entries, rofiapi.Entry{Text: tagEntryText(c.Tag, c.Count)})
+		}
+		return
+	}
+
+	in.api.Data.TagParent = ""
+	in.api.Options[rofiapi.OptionMessage] = in.style.generatePangoMarkup(
+		"select a tag to show its bookmarks, or a parent tag to expand it", "", "")
+	if len(groups) == 0 {
+		in.api.Entries = append(in.api.Entries, rofiapi.Entry{Text: "no tags", NonSelectable: true})
+	}
+	for _, g := range groups {
+		in.api.Entries = append(in.api.Entries, rofiapi.Entry{Text: tagEntryText(g.Name, g.Count)})
+	}
+}
+
+func (in *InputHandler) handleTagsSelect(input string) {
+	parent := in.api.Data.TagParent
+	if input == opBack {
+		in.api.Data.TagParent = ""
+		if parent == "" {
+			in.HandleBookmarksShow()
+			return
+		}
+		in.handleTagsShow()
+		return
+	}
+	if parent != "" && input == opAllTagged+parent {
+		in.showTagged(parent)
+		return
+	}
+
+	groups, err := in.tagGroups()
+	if err != nil {
+		in.setMessageToError(err)
+		return
+	}
+	if g, ok := expandedGroup(groups, parent); ok {
+		for _, c := range g.Children {
+			if input == tagEntryText(c.Tag, c.Count) {
+				in.showTagged(c.Tag)
+				return
+			}
+		}
+	} else {
+		for _, g := range groups {
+			if input != tagEntryText(g.Name, g.Count) {
+				continue
+			}
+			if len(g.Children) == 0 {
+				in.showTagged(g.Name)
+				return
+			}
+			in.api.Data.TagParent = g.Name
+			in.handleTagsShow()
+			return
+		}
+	}
+	in.handleTagsShow()
+}
+
+// showTagged leaves the tag browser for the bookmarks tagged tag, or its
+// children if it is a parent tag.
+func (in *InputHandler) showTagged(tag string) {
+	in.api.Data.TagParent = ""
+	if err := in.pushFilter(filterTagPrefix + tag); err != nil {
+		in.showWithError(in.HandleBookmarksShow, err)
+		return
+	}
+	in.HandleBookmarksShow()
+}
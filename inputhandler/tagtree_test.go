@@ -0,0 +1,104 @@
+package inputhandler
+
+import (
+	"context"
+	"slices"
+	"testing"
+
+	rofiapi "github.com/VannRR/rofi-api"
+)
+
+// setTagTree tags the mock bookmarks with parent and child tags.
+func setTagTree(in *InputHandler) {
+	db := in.db.(*mockDB)
+	db.bookmarks[0].Tags = []string{"lang/go", "web"}
+	db.bookmarks[1].Tags = []string{"lang/go", "lang/rust/std"}
+	db.bookmarks[2].Tags = []string{"lang"}
+	db.bookmarks[3].Tags = nil
+}
+
+func Test_matchesTag(t *testing.T) {
+	tags := []string{"Lang/Go", "web"}
+	tests := []struct {
+		tag      string
+		expected bool
+	}{
+		{"lang/go", true},
+		{"lang", true},
+		{"LANG", true},
+		{"lang/rust", false},
+		{"lan", false},
+		{"web", true},
+		{"lang/go/std", false},
+	}
+	for _, tt := range tests {
+		if got := matchesTag(tags, tt.tag); got != tt.expected {
+			t.Errorf("tag '%s': expected %v, got %v", tt.tag, tt.expected, got)
+		}
+	}
+}
+
+func Test_handleTagsSelect(t *testing.T) {
+	in := initInputHandler(t)
+	setTagTree(in)
+
+	in.handleCustomInput(":tags")
+	checkState(t, StateTagsSelect, in.api.Data.State)
+	checkEntries(t, []rofiapi.Entry{
+		{Text: opBack},
+		{Text: "lang (4)"},
+		{Text: "web (1)"},
+	}, in.api.Entries)
+
+	// a parent expands into its children
+	in.handleTagsSelect("lang (4)")
+	checkState(t, StateTagsSelect, in.api.Data.State)
+	if in.api.Data.TagParent != "lang" {
+		t.Errorf("expected parent 'lang', got '%s'", in.api.Data.TagParent)
+	}
+	checkEntries(t, []rofiapi.Entry{
+		{Text: opBack},
+		{Text: opAllTagged + "lang"},
+		{Text: "lang/go (2)"},
+		{Text: "lang/rust/std (1)"},
+	}, in.api.Entries)
+
+	in.handleTagsSelect(opBack)
+	checkState(t, StateTagsSelect, in.api.Data.State)
+	if in.api.Data.TagParent != "" {
+		t.Errorf("expected the top level, got parent '%s'", in.api.Data.TagParent)
+	}
+
+	// the parent filter matches the parent and all its children
+	in.handleTagsSelect("lang (4)")
+	in.handleTagsSelect(opAllTagged + "lang")
+	checkState(t, StateBookmarksSelect, in.api.Data.State)
+	if f := in.activeFilter(); f.Tag != "lang" {
+		t.Errorf("expected tag filter 'lang', got '%s'", f.Tag)
+	}
+	if in.api.Data.TagParent != "" {
+		t.Errorf("expected parent to be cleared, got '%s'", in.api.Data.TagParent)
+	}
+	bookmarks, _ := applyFilters(context.Background(), in.db, in.activeFilter())
+	var ids []uint16
+	for _, b := range bookmarks {
+		ids = append(ids, b.ID)
+	}
+	if !slices.Equal(ids, []uint16{1, 2, 3}) {
+		t.Errorf("expected bookmarks [1 2 3], got %v", ids)
+	}
+
+	// a child filters by itself only
+	in.popFilter()
+	in.handleCustomInput(":tags")
+	in.handleTagsSelect("lang (4)")
+	in.handleTagsSelect("lang/rust/std (1)")
+	if f := in.activeFilter(); f.Tag != "lang/rust/std" {
+		t.Errorf("expected tag filter 'lang/rust/std', got '%s'", f.Tag)
+	}
+
+	in.popFilter()
+	in.handleCustomInput(":tags")
+	in.handleTagsSelect(opBack)
+	checkState(t, StateBookmarksSelect, in.api.Data.State)
+}
@@ -0,0 +1,62 @@
+package testutil
+
+import (
+	"fmt"
+	"path/filepath"
+	"testing"
+
+	"github.com/VannRR/robuku/bukudb"
+)
+
+// fixtureTags are the tags given to fixture bookmarks in turn.
+var fixtureTags = []string{"golang", "rofi", "linux", "news", "music", "recipes", "work"}
+
+// Bookmarks returns n generated bookmarks with IDs from 1, the same for the
+// same n. Some have no title, tags, or comment, and the URLs spread over 20
+// domains.
+func Bookmarks(n int) []bukudb.Bookmark {
+	bookmarks := make([]bukudb.Bookmark, 0, n)
+	for i := 1; i <= n; i++ {
+		b := bukudb.Bookmark{
+			ID:  uint16(i),
+			URL: fmt.Sprintf("https://site%02d.example.com/page/%d", i%20, i),
+		}
+		if i%7 != 0 {
+			b.Title = fmt.Sprintf("Bookmark %d", i)
+		}
+		if i%5 != 0 {
+			b.Tags = []string{fixtureTags[i%len(fixtureTags)], fixtureTags[(i*3)%len(fixtureTags)]}
+			if b.Tags[0] == b.Tags[1] {
+				b.Tags = b.Tags[:1]
+			}
+		}
+		if i%3 == 0 {
+			b.Comment = fmt.Sprintf("comment of bookmark %d", i)
+		}
+		bookmarks = append(bookmarks, b)
+	}
+	return bookmarks
+}
+
+// NewFixtureDB creates a buku database holding Bookmarks(n) in a temporary
+// directory of t and returns its path.
+func NewFixtureDB(t testing.TB, n int) string {
+	t.Helper()
+
+	path := filepath.Join(t.TempDir(), "bookmarks.db")
+	if err := bukudb.InitSchema(path); err != nil {
+		t.Fatal(err)
+	}
+	db, err := bukudb.NewBukuDB(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer db.Close()
+
+	for _, b := range Bookmarks(n) {
+		if err := db.Add(b); err != nil {
+			t.Fatalf("failed to add fixture bookmark %d: %v", b.ID, err)
+		}
+	}
+	return path
+}
@@ -0,0 +1,53 @@
+package testutil
+
+import (
+	"slices"
+	"testing"
+
+	"github.com/VannRR/robuku/bukudb"
+)
+
+func Test_Bookmarks(t *testing.T) {
+	bookmarks := Bookmarks(50)
+	if len(bookmarks) != 50 {
+		t.Fatalf("expected 50 bookmarks, got %d", len(bookmarks))
+	}
+
+	var untitled, untagged int
+	for i, b := range bookmarks {
+		if b.ID != uint16(i+1) {
+			t.Errorf("expected id %d, got %d", i+1, b.ID)
+		}
+		if b.Title == "" {
+			untitled++
+		}
+		if len(b.Tags) == 0 {
+			untagged++
+		}
+	}
+	if untitled != 7 || untagged != 10 {
+		t.Errorf("expected 7 untitled and 10 untagged bookmarks, got %d and %d", untitled, untagged)
+	}
+
+	again := Bookmarks(50)
+	for i := range bookmarks {
+		if bookmarks[i].URL != again[i].URL || !slices.Equal(bookmarks[i].Tags, again[i].Tags) {
+			t.Errorf("expected the same bookmarks, got %+v and %+v", bookmarks[i], again[i])
+		}
+	}
+}
+
+func Test_NewFixtureDB(t *testing.T) {
+	db, err := bukudb.NewBukuDB(NewFixtureDB(t, 30))
+	if err != nil {
+		t.Fatalf("expected no error on NewBukuDB(), got '%v'", err)
+	}
+	defer db.Close()
+
+	if db.Len() != 30 {
+		t.Errorf("expected 30 bookmarks, got %d", db.Len())
+	}
+	if b, _ := db.Get(7); b.URL != Bookmarks(7)[6].URL || b.Title != "" {
+		t.Errorf("expected untitled fixture bookmark 7, got %+v", b)
+	}
+}
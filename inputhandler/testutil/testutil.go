@@ -0,0 +1,64 @@
+// testutil, helps testing the rofi screens of robuku. It renders them to a
+// stable text form for golden files, and builds fixture databases of any
+// size.
+package testutil
+
+import (
+	"fmt"
+	"slices"
+	"strings"
+
+	rofiapi "github.com/VannRR/rofi-api"
+)
+
+// RenderSnapshot serializes state, the state of the screen in the data of
+// api, and the options and entries of api into a stable text form, options
+// sorted by name and one entry per line. The rest of the data is left out,
+// so a new field doesn't change every snapshot.
+func RenderSnapshot[D any](api *rofiapi.RofiApi[D], state fmt.Stringer) string {
+	var sb strings.Builder
+
+	fmt.Fprintf(&sb, "state: %s\n", state)
+
+	sb.WriteString("options:\n")
+	options := make([]string, 0, len(api.Options))
+	for o := range api.Options {
+		options = append(options, string(o))
+	}
+	slices.Sort(options)
+	for _, o := range options {
+		fmt.Fprintf(&sb, "  %s: %q\n", o, api.Options[rofiapi.Option(o)])
+	}
+
+	sb.WriteString("entries:\n")
+	for _, e := range api.Entries {
+		fmt.Fprintf(&sb, "  %q%s\n", e.Text, entryAttributes(e))
+	}
+	return sb.String()
+}
+
+// entryAttributes returns the set attributes of e besides its text.
+func entryAttributes(e rofiapi.Entry) string {
+	var attrs []string
+	for _, a := range []struct{ name, value string }{
+		{"display", e.Display}, {"icon", e.Icon}, {"meta", e.Meta}, {"info", e.Info},
+	} {
+		if a.value != "" {
+			attrs = append(attrs, fmt.Sprintf("%s=%q", a.name, a.value))
+		}
+	}
+	for _, f := range []struct {
+		name string
+		set  bool
+	}{
+		{"nonselectable", e.NonSelectable}, {"urgent", e.Urgent}, {"active", e.Active},
+	} {
+		if f.set {
+			attrs = append(attrs, f.name)
+		}
+	}
+	if len(attrs) == 0 {
+		return ""
+	}
+	return " " + strings.Join(attrs, " ")
+}
@@ -0,0 +1,42 @@
+package testutil
+
+import (
+	"fmt"
+	"testing"
+
+	rofiapi "github.com/VannRR/rofi-api"
+)
+
+func Test_RenderSnapshot(t *testing.T) {
+	api := &rofiapi.RofiApi[struct{ Page int }]{
+		Options: map[rofiapi.Option]string{
+			rofiapi.OptionNoCustom: "true",
+			rofiapi.OptionMessage:  "<markup>a\rb</markup>",
+		},
+		Entries: []rofiapi.Entry{
+			{Text: "<-- Back"},
+			{Text: "0001. title", Meta: "tag", NonSelectable: true, Active: true},
+		},
+		Data: struct{ Page int }{Page: 4},
+	}
+
+	expected := `state: page 4
+options:
+  message: "<markup>a\rb</markup>"
+  no-custom: "true"
+entries:
+  "<-- Back"
+  "0001. title" meta="tag" nonselectable active
+`
+	for range 3 {
+		if got := RenderSnapshot(api, page(api.Data.Page)); got != expected {
+			t.Errorf("expected\n%s\ngot\n%s", expected, got)
+		}
+	}
+}
+
+type page int
+
+func (p page) String() string {
+	return fmt.Sprintf("page %d", int(p))
+}
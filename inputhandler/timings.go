@@ -0,0 +1,76 @@
+package inputhandler
+
+import (
+	"fmt"
+	"log"
+	"strings"
+	"time"
+
+	rofiapi "github.com/VannRR/rofi-api"
+)
+
+// timings measures the duration of consecutive phases of a Show handler,
+// a nil *timings measures nothing.
+type timings struct {
+	name   string
+	now    func() time.Time
+	last   time.Time
+	phases []phase
+}
+
+type phase struct {
+	name     string
+	duration time.Duration
+}
+
+// startTimings returns timings for the handler name, or nil when debug
+// timings are disabled.
+func (in *InputHandler) startTimings(name string) *timings {
+	if !in.cfg.DebugTimings {
+		return nil
+	}
+	return &timings{name: name, now: in.now, last: in.now()}
+}
+
+// lap ends the current phase and names it.
+func (t *timings) lap(name string) {
+	if t == nil {
+		return
+	}
+	now := t.now()
+	t.phases = append(t.phases, phase{name, now.Sub(t.last)})
+	t.last = now
+}
+
+func (t *timings) String() string {
+	parts := make([]string, len(t.phases))
+	for i, p := range t.phases {
+		parts[i] = fmt.Sprintf("%s %dms", p.name, p.duration.Milliseconds())
+	}
+	return "(" + strings.Join(parts, ", ") + ")"
+}
+
+// appendTo logs the measured phases and appends them to the message of api
+// in style s.
+func (t *timings) appendTo(api *rofiapi.RofiApi[Data], s markupStyle) {
+	if t == nil {
+		return
+	}
+	log.Printf("DEBUG %s %s", t.name, t)
+
+	message := api.Options[rofiapi.OptionMessage]
+	line := s.spanMarkup("", t.String())
+	if s.plain {
+		api.Options[rofiapi.OptionMessage] = joinMessageLines(message, line)
+		return
+	}
+	if strings.HasSuffix(message, "</markup>") {
+		message = strings.TrimSuffix(message, "</markup>")
+		if message != "<markup>" {
+			message += "\r"
+		}
+		api.Options[rofiapi.OptionMessage] = message + line + "</markup>"
+	} else {
+		api.Options[rofiapi.OptionMessage] = "<markup>" + line + "</markup>"
+	}
+}
@@ -0,0 +1,79 @@
+package inputhandler
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	rofiapi "github.com/VannRR/rofi-api"
+)
+
+// fakeClock returns times advancing by the given steps on each call.
+func fakeClock(steps ...time.Duration) func() time.Time {
+	now := time.Date(2024, 6, 1, 0, 0, 0, 0, time.UTC)
+	i := 0
+	return func() time.Time {
+		if i > 0 && i <= len(steps) {
+			now = now.Add(steps[i-1])
+		}
+		i++
+		return now
+	}
+}
+
+func Test_timings(t *testing.T) {
+	in := initInputHandler(t)
+
+	// disabled
+	in.cfg.DebugTimings = false
+	tm := in.startTimings("test")
+	tm.lap("db")
+	tm.appendTo(in.api, in.style)
+	if tm != nil {
+		t.Error("expected nil timings when disabled")
+	}
+
+	// enabled
+	in.cfg.DebugTimings = true
+	in.now = fakeClock(12*time.Millisecond, 3500*time.Microsecond)
+	in.api.Options[rofiapi.OptionMessage] = defaultStyle.generatePangoMarkup("hello", "", "")
+	tm = in.startTimings("test")
+	tm.lap("db")
+	tm.lap("render")
+
+	if tm.String() != "(db 12ms, render 3ms)" {
+		t.Errorf("expected timings '(db 12ms, render 3ms)', got '%s'", tm.String())
+	}
+
+	tm.appendTo(in.api, in.style)
+	expected := "<markup><span font_weight=\"bold\">hello</span>\r" +
+		"<span>(db 12ms, render 3ms)</span></markup>"
+	if in.api.Options[rofiapi.OptionMessage] != expected {
+		t.Errorf("expected message '%s', got '%s'", expected, in.api.Options[rofiapi.OptionMessage])
+	}
+
+	// plain
+	in.UsePlainMessages()
+	in.api.Options[rofiapi.OptionMessage] = in.style.generatePangoMarkup("hello", "", "")
+	tm = in.startTimings("test")
+	tm.lap("db")
+	tm.appendTo(in.api, in.style)
+	if msg := in.api.Options[rofiapi.OptionMessage]; !strings.HasPrefix(msg, "hello\r(db ") {
+		t.Errorf("expected plain timings after the message, got '%s'", msg)
+	}
+}
+
+func Test_HandleBookmarksShow_timings(t *testing.T) {
+	in := initInputHandler(t)
+	in.cfg.DebugTimings = true
+	in.now = fakeClock(7*time.Millisecond, 2*time.Millisecond)
+
+	in.HandleBookmarksShow()
+
+	expected := "<markup><span font_weight=\"bold\">" +
+		"add: Alt+1 | modify: Alt+2 | delete: Alt+3 | stats: Alt+4 | copy as buku: Alt+5 | more…: Alt+0</span>\r" +
+		"<span>(db 7ms, render 2ms)</span></markup>"
+	if in.api.Options[rofiapi.OptionMessage] != expected {
+		t.Errorf("expected message '%s', got '%s'", expected, in.api.Options[rofiapi.OptionMessage])
+	}
+}
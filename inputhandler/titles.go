@@ -0,0 +1,174 @@
+package inputhandler
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+
+	"github.com/VannRR/robuku/maintenance"
+	rofiapi "github.com/VannRR/rofi-api"
+)
+
+const (
+	opFixTitles  string = "--> Fix empty titles"
+	opFetchTitle string = "--> Fetch title"
+	opEnterTitle string = "--> Enter manually"
+	opSkip       string = "--> Skip"
+	opStop       string = "<-- Stop"
+)
+
+// errEmptyTitle is shown when no title is entered in the title assistant.
+var errEmptyTitle = errors.New("no title entered")
+
+// TitleCleanup is the progress of the assistant giving titles to untitled
+// bookmarks.
+type TitleCleanup struct {
+	// IDs of the bookmarks without a title when the assistant started.
+	IDs []uint16
+
+	// Pos is the index in IDs of the current bookmark.
+	Pos int
+}
+
+// fetchPageTitle fetches the title of the page at url.
+func fetchPageTitle(ctx context.Context, url string) (string, error) {
+	ctx, cancel := context.WithTimeout(ctx, maintenance.DefaultTitleTimeout)
+	defer cancel()
+	return maintenance.FetchTitle(ctx, http.DefaultClient, url)
+}
+
+// cleanTitle returns title with its whitespace cleaned up and the
+// configured strip patterns removed.
+func (in *InputHandler) cleanTitle(title string) string {
+	return maintenance.NewTitleCleaner(in.cfg.TitleStrip).CleanTitle(title)
+}
+
+// startTitleCleanup starts the title assistant with the bookmarks that have
+// no title.
+func (in *InputHandler) startTitleCleanup() {
+	bookmarks, err := in.db.GetAll()
+	if err != nil {
+		in.setMessageToError(fmt.Errorf("error getting bookmarks: %w", err))
+		return
+	}
+
+	var ids []uint16
+	for _, b := range bookmarks {
+		if strings.TrimSpace(b.Title) == "" {
+			ids = append(ids, b.ID)
+		}
+	}
+	if len(ids) == 0 {
+		in.handleStatsShow()
+		in.addNotice("no bookmarks without a title")
+		return
+	}
+
+	in.api.Data.TitleCleanup = TitleCleanup{IDs: ids}
+	in.handleTitlesShow()
+}
+
+// handleTitlesShow offers the ways to give the current bookmark a title,
+// skipping bookmarks that were deleted or got a title in the meantime.
+func (in *InputHandler) handleTitlesShow() {
+	tc := &in.api.Data.TitleCleanup
+	for ; tc.Pos < len(tc.IDs); tc.Pos++ {
+		b, err := in.db.Get(tc.IDs[tc.Pos])
+		if err != nil || strings.TrimSpace(b.Title) != "" {
+			continue
+		}
+
+		in.api.Data.Bookmark = b
+		in.api.Options[rofiapi.OptionMessage] = in.style.generatePangoMarkup(
+			fmt.Sprintf("bookmark without title %d of %d", tc.Pos+1, len(tc.IDs)), "", b.URL)
+		in.api.Options[rofiapi.OptionNoCustom] = "true"
+		in.api.Options[rofiapi.OptionUseHotKeys] = "false"
+
+		in.api.Entries = []rofiapi.Entry{
+			{Text: opFetchTitle},
+			{Text: opEnterTitle},
+			{Text: opSkip},
+			{Text: opStop},
+		}
+
+		in.api.Data.State = StateTitlesSelect
+		return
+	}
+
+	in.stopTitleCleanup()
+	in.addNotice("no bookmarks without a title left")
+}
+
+func (in *InputHandler) handleTitlesSelect(input string) {
+	switch input {
+	case opFetchTitle:
+		title, err := in.fetchTitle(context.Background(), in.api.Data.Bookmark.URL)
+		if err != nil {
+			in.showWithError(in.handleTitlesShow, fmt.Errorf("failed to fetch title: %w", err))
+			return
+		}
+		in.setCleanupTitle(title)
+	case opEnterTitle:
+		in.handleTitleEnterShow()
+	case opSkip:
+		in.api.Data.TitleCleanup.Pos++
+		in.handleTitlesShow()
+	case opStop:
+		in.stopTitleCleanup()
+	default:
+		in.handleTitlesShow()
+	}
+}
+
+func (in *InputHandler) handleTitleEnterShow() {
+	in.api.Options[rofiapi.OptionMessage] = in.style.generatePangoMarkup(
+		"enter a title", "", in.api.Data.Bookmark.URL)
+	in.api.Options[rofiapi.OptionNoCustom] = "false"
+	in.api.Options[rofiapi.OptionUseHotKeys] = "false"
+
+	in.api.Entries = []rofiapi.Entry{
+		{Text: opBack},
+	}
+
+	in.api.Data.State = StateTitleEnterSelect
+}
+
+func (in *InputHandler) handleTitleEnterSelect(input string) {
+	if input == opBack {
+		in.handleTitlesShow()
+		return
+	}
+	if input == "" {
+		in.showWithError(in.handleTitleEnterShow, errEmptyTitle)
+		return
+	}
+	if err := checkLength(input, in.cfg.MaxTitleLen); err != nil {
+		in.showWithError(in.handleTitleEnterShow, err)
+		return
+	}
+	in.setCleanupTitle(input)
+}
+
+// setCleanupTitle sets the title of the current bookmark of the title
+// assistant and moves on to the next one.
+func (in *InputHandler) setCleanupTitle(title string) {
+	title = truncateEnd(in.cleanTitle(title), in.cfg.MaxTitleLen)
+	if in.bookmarkChanged() {
+		return
+	}
+	if err := in.db.UpdateTitle(in.api.Data.Bookmark.ID, title); err != nil {
+		in.setMessageToError(fmt.Errorf("error updating title: %w", err))
+		return
+	}
+	in.api.Data.TitleCleanup.Pos++
+	in.handleTitlesShow()
+	in.addNotice(fmt.Sprintf("title set to %q", title))
+}
+
+// stopTitleCleanup ends the title assistant and shows the bookmarks.
+func (in *InputHandler) stopTitleCleanup() {
+	in.api.Data.TitleCleanup = TitleCleanup{}
+	in.HandleBookmarksShow()
+}
@@ -0,0 +1,114 @@
+package inputhandler
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+
+	rofiapi "github.com/VannRR/rofi-api"
+)
+
+func initTitlesInputHandler(t *testing.T) *InputHandler {
+	t.Helper()
+	in := initInputHandler(t)
+	for id := uint16(2); id <= 3; id++ {
+		if err := in.db.UpdateTitle(id, ""); err != nil {
+			t.Fatal(err)
+		}
+	}
+	in.fetchTitle = func(ctx context.Context, url string) (string, error) {
+		if url == "https://www.b.com" {
+			return "", errors.New("connection refused")
+		}
+		return "fetched " + url, nil
+	}
+	return in
+}
+
+func checkMessageContains(t *testing.T, in *InputHandler, expected string) {
+	t.Helper()
+	if msg := in.api.Options[rofiapi.OptionMessage]; !strings.Contains(msg, expected) {
+		t.Errorf("expected message to contain '%s', got '%s'", expected, msg)
+	}
+}
+
+func Test_TitleCleanup(t *testing.T) {
+	in := initTitlesInputHandler(t)
+
+	in.handleStatsSelect(opFixTitles)
+	checkState(t, StateTitlesSelect, in.api.Data.State)
+	checkEntryTexts(t, []string{opFetchTitle, opEnterTitle, opSkip, opStop}, in.api.Entries)
+	checkMessageContains(t, in, "bookmark without title 1 of 3")
+	checkMessageContains(t, in, "https://www.b.com")
+
+	// a failed fetch stays on the bookmark
+	in.HandleInput(opFetchTitle)
+	checkState(t, StateTitlesSelect, in.api.Data.State)
+	checkMessageContains(t, in, "connection refused")
+	checkMessageContains(t, in, "1 of 3")
+
+	in.HandleInput(opSkip)
+	checkMessageContains(t, in, "2 of 3")
+
+	in.HandleInput(opFetchTitle)
+	checkMessageContains(t, in, "3 of 3")
+	checkMessageContains(t, in, "title set to")
+	if b, _ := in.db.Get(3); b.Title != "fetched https://www.c.com" {
+		t.Errorf("expected fetched title, got '%s'", b.Title)
+	}
+
+	in.HandleInput(opEnterTitle)
+	checkState(t, StateTitleEnterSelect, in.api.Data.State)
+
+	in.HandleInput(opBack)
+	checkState(t, StateTitlesSelect, in.api.Data.State)
+	checkMessageContains(t, in, "3 of 3")
+
+	// empty input is handled directly, HandleInput treats it as a stale state
+	in.HandleInput(opEnterTitle)
+	in.handleTitleEnterSelect("")
+	checkState(t, StateTitleEnterSelect, in.api.Data.State)
+	checkMessageContains(t, in, errEmptyTitle.Error())
+
+	in.HandleInput("title d")
+	checkState(t, StateBookmarksSelect, in.api.Data.State)
+	checkMessageContains(t, in, "no bookmarks without a title left")
+	if b, _ := in.db.Get(4); b.Title != "title d" {
+		t.Errorf("expected entered title, got '%s'", b.Title)
+	}
+	if b, _ := in.db.Get(2); b.Title != "" {
+		t.Errorf("expected skipped bookmark to keep no title, got '%s'", b.Title)
+	}
+	if in.api.Data.TitleCleanup.IDs != nil {
+		t.Errorf("expected title cleanup to be reset, got %+v", in.api.Data.TitleCleanup)
+	}
+}
+
+func Test_TitleCleanup_Stop(t *testing.T) {
+	in := initTitlesInputHandler(t)
+
+	in.handleStatsSelect(opFixTitles)
+	in.HandleInput(opSkip)
+	if in.api.Data.TitleCleanup.Pos != 1 {
+		t.Errorf("expected position 1, got %d", in.api.Data.TitleCleanup.Pos)
+	}
+
+	in.HandleInput(opStop)
+	checkState(t, StateBookmarksSelect, in.api.Data.State)
+	if in.api.Data.TitleCleanup.IDs != nil {
+		t.Errorf("expected title cleanup to be reset, got %+v", in.api.Data.TitleCleanup)
+	}
+}
+
+func Test_TitleCleanup_NoUntitled(t *testing.T) {
+	in := initInputHandler(t)
+	if err := in.db.UpdateTitle(4, "title d"); err != nil {
+		t.Fatal(err)
+	}
+
+	in.api.Data.State = StateStatsSelect
+	in.HandleInput(opFixTitles)
+	checkState(t, StateStatsSelect, in.api.Data.State)
+	checkMessageContains(t, in, "no bookmarks without a title")
+}
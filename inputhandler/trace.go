@@ -0,0 +1,132 @@
+package inputhandler
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/VannRR/robuku/bukudb"
+	"github.com/VannRR/robuku/maintenance"
+	rofiapi "github.com/VannRR/rofi-api"
+)
+
+// errReplay is returned by the title fetcher during a replay.
+var errReplay = errors.New("not fetched during a replay")
+
+// errReplayExport is returned by exports during a replay.
+var errReplayExport = errors.New("not written during a replay")
+
+// TraceRecord is one run of robuku traced with $ROBUKU_DEBUG=1, the Data
+// rofi passed in, what was selected, and the Data passed back.
+type TraceRecord struct {
+	Time      time.Time
+	RofiState rofiapi.State
+
+	// Selected is the selected entry or custom input, Selection is false
+	// when rofi ran robuku without one.
+	Selected  string
+	Selection bool
+
+	Before Data
+	After  Data
+}
+
+// NewTraceRecord starts the record of a run with the selection and a copy
+// of the Data of api, After is set once the input is handled.
+func NewTraceRecord(api *rofiapi.RofiApi[Data], now time.Time) TraceRecord {
+	selected, ok := api.GetSelectedEntry()
+	return TraceRecord{
+		Time:      now,
+		RofiState: api.GetState(),
+		Selected:  selected.Text,
+		Selection: ok,
+		Before:    cloneData(api.Data),
+	}
+}
+
+// cloneData returns a copy of d that shares no slices with it.
+func cloneData(d Data) Data {
+	b, err := json.Marshal(d)
+	if err != nil {
+		return d
+	}
+	var c Data
+	if err := json.Unmarshal(b, &c); err != nil {
+		return d
+	}
+	return c
+}
+
+// AppendTrace appends r to the trace file at path as one line of JSON,
+// creating the file and its directory if needed.
+func AppendTrace(path string, r TraceRecord) error {
+	line, err := json.Marshal(r)
+	if err != nil {
+		return fmt.Errorf("failed to encode trace record: %w", err)
+	}
+	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		return fmt.Errorf("failed to create state directory: %w", err)
+	}
+	f, err := os.OpenFile(path, os.O_WRONLY|os.O_CREATE|os.O_APPEND, 0o600)
+	if err != nil {
+		return fmt.Errorf("failed to open trace file: %w", err)
+	}
+	if _, err := f.Write(append(line, '\n')); err != nil {
+		f.Close()
+		return fmt.Errorf("failed to write trace file: %w", err)
+	}
+	return f.Close()
+}
+
+// ReadTrace returns the records of a trace file in the order they were
+// written.
+func ReadTrace(r io.Reader) ([]TraceRecord, error) {
+	var records []TraceRecord
+	dec := json.NewDecoder(r)
+	for {
+		var record TraceRecord
+		err := dec.Decode(&record)
+		if err == io.EOF {
+			return records, nil
+		}
+		if err != nil {
+			return nil, fmt.Errorf("invalid trace record %d: %w", len(records)+1, err)
+		}
+		records = append(records, record)
+	}
+}
+
+// Replay handles the selection of r again, starting from the Data it was
+// traced with. Bookmarks are not opened, nothing is copied or exported, and
+// no titles are fetched, so only the database is changed. Imports still read
+// their file.
+func (in *InputHandler) Replay(r TraceRecord) {
+	in.cfg.Browser = "true"
+	in.cfg.Launchers = nil
+	in.cfg.Clipboard = "true"
+	if in.hasAltBrowser() {
+		in.cfg.BrowserAlt = "true"
+	}
+	in.cfg.RememberLast = false
+	in.fetchTitle = func(context.Context, string) (string, error) {
+		return "", errReplay
+	}
+	in.upgradeHTTPS = func(context.Context, bukudb.DBInterface) (maintenance.HTTPSSummary, error) {
+		return maintenance.HTTPSSummary{}, errReplay
+	}
+	in.writeExport = func(ExportTarget, []bukudb.Bookmark, bool) error {
+		return errReplayExport
+	}
+
+	in.api.Data = cloneData(r.Before)
+	if r.Selection {
+		in.handleInput(r.Selected, r.RofiState)
+	} else {
+		in.HandleBookmarksShow()
+	}
+}
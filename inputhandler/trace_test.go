@@ -0,0 +1,169 @@
+package inputhandler
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/VannRR/robuku/bukudb"
+	"github.com/VannRR/robuku/export"
+	rofiapi "github.com/VannRR/rofi-api"
+)
+
+func Test_AppendTrace_ReadTrace(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "state", "trace")
+	records := []TraceRecord{
+		{
+			Time:   time.Date(2024, 6, 1, 12, 0, 0, 0, time.UTC),
+			Before: Data{State: StateNull},
+			After:  Data{State: StateBookmarksSelect},
+		},
+		{
+			Time:      time.Date(2024, 6, 1, 12, 0, 1, 0, time.UTC),
+			RofiState: rofiapi.StateSelectedCustom,
+			Selected:  ":tag golang",
+			Selection: true,
+			Before:    Data{State: StateBookmarksSelect},
+			After: Data{
+				State:   StateBookmarksSelect,
+				Filters: []Filter{{Tag: "golang"}},
+			},
+		},
+	}
+	for _, r := range records {
+		if err := AppendTrace(path, r); err != nil {
+			t.Fatalf("expected no error on AppendTrace(), got '%v'", err)
+		}
+	}
+
+	f, err := os.Open(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer f.Close()
+	got, err := ReadTrace(f)
+	if err != nil {
+		t.Fatalf("expected no error on ReadTrace(), got '%v'", err)
+	}
+	if len(got) != len(records) {
+		t.Fatalf("expected %d records, got %d", len(records), len(got))
+	}
+	for i, r := range records {
+		g := got[i]
+		if !g.Time.Equal(r.Time) || g.RofiState != r.RofiState || g.Selected != r.Selected ||
+			g.Selection != r.Selection || g.Before.State != r.Before.State ||
+			g.After.State != r.After.State || len(g.After.Filters) != len(r.After.Filters) {
+			t.Errorf("expected record %+v, got %+v", r, g)
+		}
+	}
+}
+
+func Test_ReadTrace_Invalid(t *testing.T) {
+	_, err := ReadTrace(strings.NewReader("{\"Selected\":\"a\"}\n{"))
+	if err == nil || !strings.Contains(err.Error(), "invalid trace record 2") {
+		t.Errorf("expected error of record 2, got '%v'", err)
+	}
+
+	records, err := ReadTrace(strings.NewReader(""))
+	if err != nil || len(records) != 0 {
+		t.Errorf("expected no records and no error, got %d and '%v'", len(records), err)
+	}
+}
+
+func Test_NewTraceRecord(t *testing.T) {
+	in := initInputHandler(t)
+	in.api.Data.State = StateBookmarksSelect
+	in.api.Data.Filters = []Filter{{Tag: "golang"}}
+
+	r := NewTraceRecord(in.api, time.Now())
+	in.api.Data.Filters[0].Tag = "changed"
+
+	if r.Before.State != StateBookmarksSelect {
+		t.Errorf("expected state %d, got %d", StateBookmarksSelect, r.Before.State)
+	}
+	if len(r.Before.Filters) != 1 || r.Before.Filters[0].Tag != "golang" {
+		t.Errorf("expected a copy of the filters, got %+v", r.Before.Filters)
+	}
+}
+
+func Test_Replay(t *testing.T) {
+	tests := []struct {
+		name     string
+		record   TraceRecord
+		expected State
+	}{
+		{"no selection", TraceRecord{}, StateBookmarksSelect},
+		{"url", TraceRecord{
+			Selected:  "https://example.com",
+			Selection: true,
+			Before:    Data{State: StateAddUrlSelect},
+		}, StateAddSelect},
+		{"open", TraceRecord{
+			Selected:  "1. metadata (title) google",
+			Selection: true,
+			RofiState: rofiapi.StateSelected,
+			Before:    Data{State: StateBookmarksSelect},
+		}, StateGotoExec},
+	}
+	for _, tt := range tests {
+		in := initInputHandler(t)
+		in.Replay(tt.record)
+		if in.api.Data.State != tt.expected {
+			t.Errorf("%s: expected state %d, got %d", tt.name, tt.expected, in.api.Data.State)
+		}
+	}
+}
+
+func Test_Replay_AltBrowser(t *testing.T) {
+	in := initInputHandler(t)
+	in.cfg.BrowserAlt = "robuku-missing-alt --private %u"
+	in.Replay(TraceRecord{
+		Selected:  "1. metadata (title) google",
+		Selection: true,
+		RofiState: rofiapi.StateCustomKeybinding12,
+		Before:    Data{State: StateBookmarksSelect},
+	})
+	checkState(t, StateGotoExec, in.api.Data.State)
+	if strings.Contains(in.api.Options[rofiapi.OptionMessage], "robuku-missing-alt") {
+		t.Errorf("expected the alternative browser not to be started, got '%s'", in.api.Options[rofiapi.OptionMessage])
+	}
+}
+
+func Test_Replay_Export(t *testing.T) {
+	in := initInputHandler(t)
+	path := filepath.Join(t.TempDir(), "bookmarks.json")
+	if err := os.WriteFile(path, []byte("kept"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	in.Replay(TraceRecord{
+		Selected:  "yes",
+		Selection: true,
+		Before: Data{
+			State:     StateOverwriteSelect,
+			ResultIDs: []uint16{1},
+			Export:    ExportTarget{Format: export.FormatJSON, Path: path},
+		},
+	})
+	if content, err := os.ReadFile(path); err != nil || string(content) != "kept" {
+		t.Errorf("expected the file not to be overwritten, got '%s', %v", content, err)
+	}
+	if !strings.Contains(in.api.Options[rofiapi.OptionMessage], errReplayExport.Error()) {
+		t.Errorf("expected the export to be refused, got '%s'", in.api.Options[rofiapi.OptionMessage])
+	}
+}
+
+func Test_Replay_KeepsRecord(t *testing.T) {
+	in := initInputHandler(t)
+	r := TraceRecord{
+		Selected:  "https://example.com",
+		Selection: true,
+		Before:    Data{State: StateAddUrlSelect, Bookmark: bukudb.Bookmark{Tags: []string{"a"}}},
+	}
+	in.Replay(r)
+	in.api.Data.Bookmark.Tags[0] = "changed"
+	if r.Before.Bookmark.Tags[0] != "a" {
+		t.Errorf("expected the record to be unchanged, got tags %v", r.Before.Bookmark.Tags)
+	}
+}
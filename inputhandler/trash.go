@@ -0,0 +1,117 @@
+package inputhandler
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/VannRR/robuku/bukudb"
+	rofiapi "github.com/VannRR/rofi-api"
+)
+
+// handleTrashShow lists the deleted bookmarks, selecting one restores it.
+func (in *InputHandler) handleTrashShow() {
+	in.api.Options[rofiapi.OptionMessage] = in.style.generatePangoMarkup(
+		"select a bookmark to restore it", "", "")
+	in.api.Options[rofiapi.OptionNoCustom] = "true"
+	in.api.Options[rofiapi.OptionUseHotKeys] = "false"
+
+	tm := in.startTimings("handleTrashShow")
+	trashed, err := in.db.TrashList()
+	if err != nil {
+		in.setMessageToError(fmt.Errorf("error getting trash: %w", err))
+		return
+	}
+	tm.lap("db")
+
+	entries := []rofiapi.Entry{{Text: opBack}}
+	if len(trashed) > 0 {
+		entries = append(entries, rofiapi.Entry{Text: opEmptyTrash})
+	}
+	for _, b := range trashed {
+		entries = append(entries, rofiapi.Entry{
+			Text: formatEntryText(trashEntryText(b)),
+			Meta: in.render.meta(b.Bookmark),
+		})
+	}
+
+	in.api.Entries = entries
+	in.api.Data.State = StateTrashSelect
+	tm.lap("render")
+	tm.appendTo(in.api, in.style)
+}
+
+func (in *InputHandler) handleTrashSelect(input string) {
+	switch input {
+	case opBack:
+		in.HandleBookmarksShow()
+		return
+	case opEmptyTrash:
+		in.handleTrashEmptyShow()
+		return
+	}
+
+	trashID, err := getTrashIDFromEntry(input)
+	if err != nil {
+		in.showWithError(in.handleTrashShow, err)
+		return
+	}
+	id, err := in.db.Restore(trashID)
+	if err != nil {
+		in.showWithError(in.handleTrashShow, fmt.Errorf("error restoring bookmark: %w", err))
+		return
+	}
+
+	in.handleTrashShow()
+	// a dry run restores nothing and has no ID to show
+	if id > 0 {
+		in.prependMessage(in.style.spanMarkup("", fmt.Sprintf("restored as #%d", id)))
+	}
+}
+
+func (in *InputHandler) handleTrashEmptyShow() {
+	in.api.Options[rofiapi.OptionMessage] = in.style.generatePangoMarkup(
+		"delete all bookmarks in the trash for good? (yes/No)", "", "")
+	in.api.Options[rofiapi.OptionNoCustom] = "false"
+	in.api.Options[rofiapi.OptionUseHotKeys] = "false"
+
+	in.api.Entries = []rofiapi.Entry{
+		{Text: opBack},
+	}
+
+	in.api.Data.State = StateTrashEmptySelect
+}
+
+func (in *InputHandler) handleTrashEmptySelect(input string) {
+	if input != "yes" {
+		in.handleTrashShow()
+		return
+	}
+
+	if err := in.db.EmptyTrash(); err != nil {
+		in.setMessageToError(fmt.Errorf("error emptying trash: %w", err))
+		return
+	}
+	in.handleTrashShow()
+}
+
+// trashEntryText returns the entry text of a trashed bookmark, its trash ID
+// followed by its title, or URL if it has none, and when it was deleted.
+func trashEntryText(b bukudb.TrashedBookmark) string {
+	text := b.Title
+	if text == "" {
+		text = b.URL
+	}
+	return fmt.Sprintf("%d. %s (deleted %s)", b.TrashID, text, b.Deleted.Format("2006-01-02 15:04"))
+}
+
+// getTrashIDFromEntry parses the trash ID of an entry made by
+// trashEntryText.
+func getTrashIDFromEntry(input string) (int64, error) {
+	idString, _, _ := strings.Cut(input, ".")
+	id, err := strconv.ParseInt(idString, 10, 64)
+	if err != nil || id < 1 {
+		return 0, fmt.Errorf("error parsing trash id from entry: %s", input)
+	}
+	return id, nil
+}
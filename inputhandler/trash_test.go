@@ -0,0 +1,123 @@
+package inputhandler
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/VannRR/robuku/bukudb"
+	"github.com/VannRR/robuku/inputhandler/testutil"
+	"github.com/VannRR/robuku/internal/golden"
+	rofiapi "github.com/VannRR/rofi-api"
+)
+
+func Test_handleTrashShow(t *testing.T) {
+	in := initInputHandler(t)
+
+	// empty trash
+	in.handleBookmarksSelect("", rofiapi.StateCustomKeybinding8)
+	checkState(t, StateTrashSelect, in.api.Data.State)
+	checkEntries(t, []rofiapi.Entry{{Text: opBack}}, in.api.Entries)
+
+	if err := in.db.Remove(1); err != nil {
+		t.Fatal(err)
+	}
+	in.handleTrashShow()
+	checkState(t, StateTrashSelect, in.api.Data.State)
+	checkEntries(t, []rofiapi.Entry{
+		{Text: opBack},
+		{Text: opEmptyTrash},
+		{Text: "1. metadata (title) google (deleted " +
+			time.Unix(0, 0).Format("2006-01-02 15:04") + ")",
+			Meta: "google #google tag2 #tag2 tag3 #tag3 google.com desc comment"},
+	}, in.api.Entries)
+}
+
+func Test_handleTrashShow_golden(t *testing.T) {
+	in := initGoldenInputHandler(t)
+	in.db.(*mockDB).trash = nil
+
+	in.handleBookmarksSelect("", rofiapi.StateCustomKeybinding8)
+	checkState(t, StateTrashSelect, in.api.Data.State)
+	golden.Check(t, "trash_none", testutil.RenderSnapshot(in.api, in.api.Data.State))
+}
+
+func Test_handleTrashShow_tagSigil(t *testing.T) {
+	in := initInputHandler(t)
+	in.render.sigil = "@"
+	if err := in.db.Remove(1); err != nil {
+		t.Fatal(err)
+	}
+
+	in.handleTrashShow()
+	if meta := in.api.Entries[2].Meta; !strings.Contains(meta, "@tag2") || strings.Contains(meta, "#tag2") {
+		t.Errorf("expected the tags after the configured sigil, got '%s'", meta)
+	}
+}
+
+func Test_handleTrashSelect(t *testing.T) {
+	in := initInputHandler(t)
+	if err := in.db.Remove(1); err != nil {
+		t.Fatal(err)
+	}
+
+	// selected back option
+	in.handleTrashSelect(opBack)
+	checkState(t, StateBookmarksSelect, in.api.Data.State)
+
+	// selected a missing trash entry
+	in.handleTrashSelect("7. gone (deleted 1970-01-01 00:00)")
+	checkState(t, StateTrashSelect, in.api.Data.State)
+	if !strings.Contains(in.api.Options[rofiapi.OptionMessage], "no bookmark 7 in trash") {
+		t.Errorf("expected restore error in message, got '%s'", in.api.Options[rofiapi.OptionMessage])
+	}
+
+	// selected a trash entry
+	in.handleTrashSelect(in.api.Entries[2].Text)
+	checkState(t, StateTrashSelect, in.api.Data.State)
+	checkEntries(t, []rofiapi.Entry{{Text: opBack}}, in.api.Entries)
+	if in.db.Len() != 4 {
+		t.Errorf("expected 4 bookmarks, got %d", in.db.Len())
+	}
+	if b, _ := in.db.Get(4); b.URL != "https://www.google.com" {
+		t.Errorf("expected google.com restored as #4, got '%s'", b.URL)
+	}
+	if !strings.Contains(in.api.Options[rofiapi.OptionMessage], "restored as #4") {
+		t.Errorf("expected restored message, got '%s'", in.api.Options[rofiapi.OptionMessage])
+	}
+}
+
+func Test_handleEmptyTrash(t *testing.T) {
+	in := initInputHandler(t)
+	if err := in.db.Remove(1); err != nil {
+		t.Fatal(err)
+	}
+	in.handleTrashShow()
+
+	// selected empty trash, answered no
+	in.handleTrashSelect(opEmptyTrash)
+	checkState(t, StateTrashEmptySelect, in.api.Data.State)
+	in.handleTrashEmptySelect("no")
+	checkState(t, StateTrashSelect, in.api.Data.State)
+	if trashed, _ := in.db.TrashList(); len(trashed) != 1 {
+		t.Errorf("expected 1 trashed bookmark, got %d", len(trashed))
+	}
+
+	// answered yes
+	in.handleTrashSelect(opEmptyTrash)
+	in.handleTrashEmptySelect("yes")
+	checkState(t, StateTrashSelect, in.api.Data.State)
+	if trashed, _ := in.db.TrashList(); len(trashed) != 0 {
+		t.Errorf("expected empty trash, got %d", len(trashed))
+	}
+}
+
+func Test_getTrashIDFromEntry(t *testing.T) {
+	b := bukudb.TrashedBookmark{Bookmark: bukudb.Bookmark{URL: "https://a.com"}, TrashID: 1234}
+	if id, err := getTrashIDFromEntry(trashEntryText(b)); err != nil || id != 1234 {
+		t.Errorf("expected trash id 1234, got %d and error '%v'", id, err)
+	}
+	if _, err := getTrashIDFromEntry(opBack); err == nil {
+		t.Errorf("expected error for entry without trash id")
+	}
+}
@@ -0,0 +1,99 @@
+package inputhandler
+
+import (
+	"fmt"
+	"log"
+	"time"
+
+	"github.com/VannRR/robuku/bukudb"
+	rofiapi "github.com/VannRR/rofi-api"
+)
+
+const (
+	opResetOpenCount     string = "--> Reset open count"
+	opResetAllOpenCounts string = "--> Reset all open counts"
+)
+
+// recordVisit counts the bookmark being opened, a failure is only logged
+// since the bookmark is open already.
+func (in *InputHandler) recordVisit() {
+	if in.api.Data.Bookmark.ID == 0 {
+		return
+	}
+	if err := in.db.RecordVisit(in.api.Data.Bookmark.ID, in.now()); err != nil {
+		log.Println("ERROR", fmt.Errorf("error counting visit: %w", err))
+	}
+}
+
+// visitLines returns the detail rows of v, none if the bookmark was never
+// opened.
+func visitLines(v bukudb.Visits) []string {
+	switch v.Count {
+	case 0:
+		return nil
+	case 1:
+		return []string{"opened: once", "last opened: " + v.Last.Format(time.DateOnly)}
+	}
+	return []string{
+		fmt.Sprintf("opened: %d times", v.Count),
+		"last opened: " + v.Last.Format(time.DateOnly),
+	}
+}
+
+// visitEntries returns the detail rows of the visits of the bookmark being
+// modified, followed by the entry resetting them if canReset.
+func (in *InputHandler) visitEntries(canReset bool) []rofiapi.Entry {
+	if in.api.Data.Bookmark.ID == 0 {
+		return nil
+	}
+	v, err := in.db.GetVisits(in.api.Data.Bookmark.ID)
+	if err != nil {
+		log.Println("ERROR", fmt.Errorf("error getting visits: %w", err))
+		return nil
+	}
+
+	var entries []rofiapi.Entry
+	for _, l := range visitLines(v) {
+		entries = append(entries, rofiapi.Entry{Text: l, NonSelectable: true})
+	}
+	if len(entries) > 0 && canReset {
+		entries = append(entries, rofiapi.Entry{Text: opResetOpenCount})
+	}
+	return entries
+}
+
+// resetOpenCount forgets the visits of the bookmark being modified and shows
+// the modify screen again.
+func (in *InputHandler) resetOpenCount() {
+	if err := in.db.ResetVisits(in.api.Data.Bookmark.ID); err != nil {
+		in.setMessageToError(fmt.Errorf("error resetting open count: %w", err))
+		return
+	}
+	in.handleModifyShow()
+}
+
+func (in *InputHandler) handleResetVisitsShow() {
+	in.api.Options[rofiapi.OptionMessage] = in.style.generatePangoMarkup(
+		"reset the open counts of all bookmarks? (yes/No)", "", "")
+	in.api.Options[rofiapi.OptionNoCustom] = "false"
+	in.api.Options[rofiapi.OptionUseHotKeys] = "false"
+
+	in.api.Entries = []rofiapi.Entry{
+		{Text: opBack},
+	}
+
+	in.api.Data.State = StateResetVisitsSelect
+}
+
+func (in *InputHandler) handleResetVisitsSelect(input string) {
+	if input != "yes" {
+		in.handleStatsShow()
+		return
+	}
+
+	if err := in.db.ResetVisits(0); err != nil {
+		in.setMessageToError(fmt.Errorf("error resetting open counts: %w", err))
+		return
+	}
+	in.handleStatsShow()
+}
@@ -0,0 +1,107 @@
+package inputhandler
+
+import (
+	"slices"
+	"testing"
+	"time"
+
+	"github.com/VannRR/robuku/bukudb"
+	rofiapi "github.com/VannRR/rofi-api"
+)
+
+func Test_visitLines(t *testing.T) {
+	last := time.Date(2024, 6, 2, 12, 0, 0, 0, time.Local)
+	tests := []struct {
+		visits   bukudb.Visits
+		expected []string
+	}{
+		{bukudb.Visits{}, nil},
+		{bukudb.Visits{Count: 1, Last: last}, []string{"opened: once", "last opened: 2024-06-02"}},
+		{bukudb.Visits{Count: 12, Last: last}, []string{"opened: 12 times", "last opened: 2024-06-02"}},
+	}
+	for _, tt := range tests {
+		if got := visitLines(tt.visits); !slices.Equal(got, tt.expected) {
+			t.Errorf("visits %v: expected %q, got %q", tt.visits, tt.expected, got)
+		}
+	}
+}
+
+func Test_handleGotoExec_CountsVisits(t *testing.T) {
+	in := initInputHandler(t)
+	in.cfg.Browser = "true"
+	now := time.Date(2024, 6, 1, 9, 0, 0, 0, time.Local)
+	in.now = func() time.Time { return now }
+
+	for range 2 {
+		in.api.Data.Bookmark, _ = in.db.Get(1)
+		in.handleGotoExec()
+	}
+	v, _ := in.db.GetVisits(1)
+	if v.Count != 2 || !v.Last.Equal(now) {
+		t.Errorf("expected 2 visits last at %v, got %v", now, v)
+	}
+
+	// a bookmark that failed to open is not counted
+	in.cfg.Browser = "robuku-no-such-browser"
+	in.api.Data.Bookmark, _ = in.db.Get(2)
+	in.handleGotoExec()
+	checkState(t, StateErrorShow, in.api.Data.State)
+	if v, _ := in.db.GetVisits(2); v.Count != 0 {
+		t.Errorf("expected no visits of #2, got %v", v)
+	}
+}
+
+func Test_handleModifyShow_Visits(t *testing.T) {
+	in := initInputHandler(t)
+	in.api.Data.Bookmark, _ = in.db.Get(1)
+
+	in.handleModifyShow()
+	if hasEntry(in.api.Entries, opResetOpenCount) {
+		t.Errorf("expected no '%s' entry for a bookmark never opened", opResetOpenCount)
+	}
+
+	in.db.RecordVisit(1, time.Date(2024, 6, 2, 12, 0, 0, 0, time.Local))
+	in.handleModifyShow()
+	if !hasEntry(in.api.Entries, "opened: once") || !hasEntry(in.api.Entries, "last opened: 2024-06-02") {
+		t.Errorf("expected visit rows, got %v", in.api.Entries)
+	}
+
+	in.handleModifySelect(opResetOpenCount)
+	checkState(t, StateModifySelect, in.api.Data.State)
+	if v, _ := in.db.GetVisits(1); v.Count != 0 {
+		t.Errorf("expected open count to be reset, got %v", v)
+	}
+	if hasEntry(in.api.Entries, opResetOpenCount) {
+		t.Errorf("expected no '%s' entry after reset", opResetOpenCount)
+	}
+}
+
+func Test_handleResetVisitsSelect(t *testing.T) {
+	in := initInputHandler(t)
+	in.db.RecordVisit(1, time.Now())
+	in.db.RecordVisit(3, time.Now())
+
+	in.handleStatsShow()
+	if !hasEntry(in.api.Entries, opResetAllOpenCounts) {
+		t.Fatalf("expected a '%s' entry", opResetAllOpenCounts)
+	}
+	in.handleStatsSelect(opResetAllOpenCounts)
+	checkState(t, StateResetVisitsSelect, in.api.Data.State)
+	checkEntries(t, []rofiapi.Entry{{Text: opBack}}, in.api.Entries)
+
+	in.handleResetVisitsSelect(opBack)
+	checkState(t, StateStatsSelect, in.api.Data.State)
+	if v, _ := in.db.GetVisits(3); v.Count != 1 {
+		t.Errorf("expected visits to be kept, got %v", v)
+	}
+
+	in.handleStatsSelect(opResetAllOpenCounts)
+	in.handleResetVisitsSelect("yes")
+	checkState(t, StateStatsSelect, in.api.Data.State)
+	if s, _ := in.db.Stats(); s.Visited != 0 {
+		t.Errorf("expected all open counts to be reset, got %d visited", s.Visited)
+	}
+	if hasEntry(in.api.Entries, opResetAllOpenCounts) {
+		t.Errorf("expected no '%s' entry after reset", opResetAllOpenCounts)
+	}
+}
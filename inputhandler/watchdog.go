@@ -0,0 +1,39 @@
+package inputhandler
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+)
+
+// timeoutError is returned by watchdog when an operation takes too long.
+type timeoutError struct {
+	timeout time.Duration
+}
+
+func (e *timeoutError) Error() string {
+	return fmt.Sprintf("operation timed out after %s — database may be on slow storage",
+		e.timeout)
+}
+
+// watchdog runs op and returns its result, if op does not finish within
+// timeout its context is cancelled and a timeoutError is returned once op
+// returned. op runs in the caller, so it is never left holding the database
+// while the next one starts, and must stop when its context is done. A
+// timeout of 0 waits for op indefinitely.
+func watchdog[T any](timeout time.Duration, op func(ctx context.Context) (T, error)) (T, error) {
+	if timeout <= 0 {
+		return op(context.Background())
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), timeout)
+	defer cancel()
+
+	v, err := op(ctx)
+	if errors.Is(ctx.Err(), context.DeadlineExceeded) {
+		var zero T
+		return zero, &timeoutError{timeout: timeout}
+	}
+	return v, err
+}
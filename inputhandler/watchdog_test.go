@@ -0,0 +1,104 @@
+package inputhandler
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/VannRR/robuku/bukudb"
+	rofiapi "github.com/VannRR/rofi-api"
+)
+
+// slowDB is a mockDB whose context aware queries take delay, it records
+// whether the context was cancelled.
+type slowDB struct {
+	*mockDB
+	delay     time.Duration
+	cancelled chan bool
+}
+
+func (db *slowDB) GetAllContext(ctx context.Context) ([]bukudb.Bookmark, error) {
+	select {
+	case <-time.After(db.delay):
+		db.cancelled <- false
+		return db.GetAll()
+	case <-ctx.Done():
+		db.cancelled <- true
+		return nil, ctx.Err()
+	}
+}
+
+func Test_watchdog(t *testing.T) {
+	// finishes in time
+	v, err := watchdog(time.Second, func(ctx context.Context) (int, error) {
+		return 1, nil
+	})
+	if v != 1 || err != nil {
+		t.Errorf("expected 1 and no error, got %d and '%v'", v, err)
+	}
+
+	// errors are passed through
+	opErr := errors.New("op error")
+	if _, err := watchdog(time.Second, func(ctx context.Context) (int, error) {
+		return 0, opErr
+	}); !errors.Is(err, opErr) {
+		t.Errorf("expected error '%v', got '%v'", opErr, err)
+	}
+
+	// no timeout
+	if v, _ := watchdog(0, func(ctx context.Context) (int, error) {
+		time.Sleep(10 * time.Millisecond)
+		return 2, nil
+	}); v != 2 {
+		t.Errorf("expected 2, got %d", v)
+	}
+
+	// times out
+	_, err = watchdog(10*time.Millisecond, func(ctx context.Context) (int, error) {
+		<-ctx.Done()
+		return 0, ctx.Err()
+	})
+	var te *timeoutError
+	if !errors.As(err, &te) {
+		t.Fatalf("expected timeout error, got '%v'", err)
+	}
+	expected := "operation timed out after 10ms — database may be on slow storage"
+	if err.Error() != expected {
+		t.Errorf("expected error '%s', got '%s'", expected, err)
+	}
+}
+
+func Test_HandleBookmarksShow_timeout(t *testing.T) {
+	db := &slowDB{mockDB: newMockDB(), delay: time.Minute, cancelled: make(chan bool, 1)}
+	api, err := rofiapi.NewRofiApi(Data{})
+	if err != nil {
+		t.Fatalf("expected no error from NewRofiApi(), got %v", err)
+	}
+	in := NewInputHandler(db, api)
+	in.cfg.DBTimeout = 20 * time.Millisecond
+
+	start := time.Now()
+	in.HandleBookmarksShow()
+	if elapsed := time.Since(start); elapsed > 5*time.Second {
+		t.Errorf("expected HandleBookmarksShow to return after the timeout, took %s", elapsed)
+	}
+
+	checkState(t, StateErrorShow, in.api.Data.State)
+	if !strings.Contains(in.api.Options[rofiapi.OptionMessage], "operation timed out after 20ms") {
+		t.Errorf("expected message to contain the timeout error, got '%s'",
+			in.api.Options[rofiapi.OptionMessage])
+	}
+
+	// the query returned before HandleBookmarksShow, so it no longer holds
+	// the database
+	select {
+	case cancelled := <-db.cancelled:
+		if !cancelled {
+			t.Error("expected the query context to be cancelled")
+		}
+	default:
+		t.Error("expected the query to return before HandleBookmarksShow")
+	}
+}
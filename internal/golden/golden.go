@@ -0,0 +1,40 @@
+// golden, compares test output with golden files kept under testdata/golden
+// of the tested package, rewritten when the tests run with -update.
+package golden
+
+import (
+	"flag"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+// dir is where golden files are kept, relative to the tested package.
+const dir = "testdata/golden"
+
+var update = flag.Bool("update", false, "rewrite the golden files instead of comparing with them")
+
+// Check compares got with the golden file called name, or rewrites it when
+// the tests run with -update.
+func Check(t testing.TB, name, got string) {
+	t.Helper()
+
+	path := filepath.Join(dir, name+".golden")
+	if *update {
+		if err := os.MkdirAll(dir, 0o755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(path, []byte(got), 0o644); err != nil {
+			t.Fatal(err)
+		}
+		return
+	}
+
+	expected, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatalf("failed to read golden file, run the tests with -update to create it: %v", err)
+	}
+	if got != string(expected) {
+		t.Errorf("%s: expected\n%s\ngot\n%s", path, expected, got)
+	}
+}
@@ -0,0 +1,7 @@
+package golden
+
+import "testing"
+
+func Test_Check(t *testing.T) {
+	Check(t, "check", "a\nb\n")
+}
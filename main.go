@@ -1,41 +1,774 @@
 package main
 
 import (
+	"context"
+	"encoding/json"
+	"errors"
 	"fmt"
+	"io"
+	"log"
+	"net/http"
 	"os"
+	"os/exec"
 	"path/filepath"
+	"strings"
+	"time"
 
 	"github.com/VannRR/robuku/bukudb"
+	"github.com/VannRR/robuku/config"
+	"github.com/VannRR/robuku/export"
+	"github.com/VannRR/robuku/importer"
 	"github.com/VannRR/robuku/inputhandler"
+	"github.com/VannRR/robuku/maintenance"
 	rofiapi "github.com/VannRR/rofi-api"
 )
 
 const (
-	bukuDbEnvVar      = "ROBUKU_DB_PATH"
-	xdgDataHomeEnvVar = "XDG_DATA_HOME"
+	bukuDbEnvVar       = "ROBUKU_DB_PATH"
+	createSchemaEnvVar = "ROBUKU_CREATE_SCHEMA"
+	xdgDataHomeEnvVar  = "XDG_DATA_HOME"
+	rofiRetvEnvVar     = "ROFI_RETV"
+
+	// addURLEnvVar passes the URL of --add-url to the first run by rofi,
+	// addSourceEnvVar where it came from.
+	addURLEnvVar    = "ROBUKU_ADD_URL"
+	addSourceEnvVar = "ROBUKU_ADD_SOURCE"
+)
+
+const (
+	httpsUpgradeFlag = "--https-upgrade"
+	exportMetaFlag   = "--export-meta"
+	statsJSONFlag    = "--stats-json"
+	importMetaFlag   = "--import-meta"
+	importFlag       = "--import"
+	reportFlag       = "--report"
+	importTextFlag   = "--import-text"
+	tagsFlag         = "--tags"
+	onConflictFlag   = "--on-conflict"
+	addURLFlag       = "--add-url"
+	exportFlag       = "--export"
+	readingListFlag  = "--reading-list"
+	outFlag          = "--out"
+	fixTagsFlag      = "--fix-tags"
+	rewriteURLFlag   = "--rewrite-url"
+	dryRunFlag       = "--dry-run"
+	selfTestFlag     = "--self-test"
+	replayFlag       = "--replay"
+	completionFlag   = "--completion"
+	manFlag          = "--man"
 )
 
 func main() {
+	if cmd, ok := selectedCommand(); ok {
+		os.Exit(cmd.run(os.Args[2:]))
+	}
+
 	api, err := rofiapi.NewRofiApi(inputhandler.Data{})
 	handleInitError(api, err)
+
+	run(api)
+
 	if api.Data.State != inputhandler.StateErrorSelect {
-		defer api.Draw()
+		draw(api)
+	}
+}
+
+func run(api *rofiapi.RofiApi[inputhandler.Data]) {
+	defer recoverRun(api)
+
+	cfg, err := resolveProfile(&api.Data, config.Load())
+	if err != nil {
+		inputhandler.SetMessageToError(api, err)
+		return
 	}
 
-	bukuDbPath, err := getBukuDbPath()
+	bukuDbPath, err := dbPath(cfg)
 	if err != nil {
 		inputhandler.SetMessageToError(api, err)
 		return
 	}
 
-	db, err := bukudb.NewBukuDB(bukuDbPath)
+	opts := dbOptions(cfg)
+	db, err := bukudb.NewBukuDB(bukuDbPath, opts...)
+	var notBukuDBErr *bukudb.NotBukuDBError
+	if errors.As(err, &notBukuDBErr) {
+		selected, _ := api.GetSelectedEntry()
+		canInit := os.Getenv(createSchemaEnvVar) == "1"
+		if !inputhandler.HandleNotBukuDB(api, selected.Text, notBukuDBErr.Path,
+			canInit, bukudb.InitSchema) {
+			return
+		}
+		db, err = bukudb.NewBukuDB(bukuDbPath, opts...)
+	}
 	if err != nil {
 		inputhandler.SetMessageToError(api, err)
 		return
 	}
+	defer closeDB(db)
 
-	in := inputhandler.NewInputHandler(db, api)
+	in := inputhandler.NewInputHandlerWithConfig(lockWrites(db, cfg), api, cfg)
+	if !api.IsRanByRofi() {
+		in.UsePlainMessages()
+	}
+	if !cfg.Debug {
+		handleApiInput(api, in)
+		return
+	}
+	trace := inputhandler.NewTraceRecord(api, time.Now())
 	handleApiInput(api, in)
+	writeTrace(cfg.TraceFile, trace, api.Data)
+}
+
+// resolveProfile returns cfg with the settings of the active profile merged
+// over it. The profile of $ROBUKU_PROFILE is activated in data if none is.
+func resolveProfile(data *inputhandler.Data, cfg config.Config) (config.Config, error) {
+	if data.Profile == "" {
+		data.Profile = cfg.Profile
+	}
+	if data.Profile == "" {
+		return cfg, nil
+	}
+	p, ok := cfg.FindProfile(data.Profile)
+	if !ok {
+		name := data.Profile
+		data.Profile = ""
+		return cfg, fmt.Errorf("unknown profile '%s', add it to the profiles file or set $%s",
+			name, config.ProfilesEnvVar)
+	}
+	return cfg.WithProfile(p), nil
+}
+
+// recoverRun shows the error screen instead of crashing when run panicked,
+// it must be deferred.
+func recoverRun(api *rofiapi.RofiApi[inputhandler.Data]) {
+	if r := recover(); r != nil {
+		inputhandler.ShowPanic(api, config.Load().CrashLogFile, r)
+	}
+}
+
+// writeTrace appends trace ending with data to the trace file at path.
+func writeTrace(path string, trace inputhandler.TraceRecord, data inputhandler.Data) {
+	if path == "" {
+		log.Println("ERROR", errors.New("no state directory for the trace file"))
+		return
+	}
+	trace.After = data
+	if err := inputhandler.AppendTrace(path, trace); err != nil {
+		log.Println("ERROR", err)
+	}
+}
+
+// isCommand reports whether robuku was started from the command line, not
+// by rofi, with name as its first argument.
+func isCommand(name string) bool {
+	_, ranByRofi := os.LookupEnv(rofiRetvEnvVar)
+	return !ranByRofi && len(os.Args) > 1 && os.Args[1] == name
+}
+
+// runHTTPSUpgrade moves http bookmarks to https where the https variant is
+// reachable, printing the progress to out. It returns the exit code.
+func runHTTPSUpgrade(out io.Writer) int {
+	cfg := config.Load()
+
+	db, err := openCommandDB(cfg)
+	if err != nil {
+		log.Println("ERROR", err)
+		return 1
+	}
+	defer closeDB(db)
+
+	target := lockWrites(db, cfg)
+	if cfg.DryRun {
+		target = bukudb.NewDryRunDB(db, func(msg string) { fmt.Fprintln(out, msg) })
+	}
+
+	summary, err := maintenance.NewHTTPSUpgrader(out).Run(context.Background(), target)
+	fmt.Fprintf(out, "checked %d http bookmarks: %d upgraded, %d kept\n",
+		summary.Checked, summary.Upgraded, summary.Failed)
+	if err != nil {
+		log.Println("ERROR", err)
+		return 1
+	}
+	return 0
+}
+
+// runFixTags rewrites the tags columns other programs wrote differently
+// from buku, args being "[--dry-run]". With --dry-run, or $ROBUKU_DRY_RUN,
+// it only lists them. It returns the exit code.
+func runFixTags(args []string, out io.Writer) int {
+	cfg := config.Load()
+	dryRun := cfg.DryRun
+	switch {
+	case len(args) == 1 && args[0] == dryRunFlag:
+		dryRun = true
+	case len(args) != 0:
+		log.Println("ERROR", fmt.Errorf("usage: robuku %s [%s]", fixTagsFlag, dryRunFlag))
+		return 1
+	}
+
+	db, err := openCommandDB(cfg)
+	if err != nil {
+		log.Println("ERROR", err)
+		return 1
+	}
+	defer closeDB(db)
+
+	var fixes []bukudb.TagFix
+	err = lockedWrite(db, cfg, func() (err error) {
+		fixes, err = db.FixTags(dryRun)
+		return err
+	})
+	if err != nil {
+		log.Println("ERROR", err)
+		return 1
+	}
+	for _, fix := range fixes {
+		fmt.Fprintf(out, "%d: %q -> %q\n", fix.ID, fix.Old, fix.New)
+	}
+	if dryRun {
+		fmt.Fprintf(out, "would fix the tags of %d bookmarks\n", len(fixes))
+	} else {
+		fmt.Fprintf(out, "fixed the tags of %d bookmarks\n", len(fixes))
+	}
+	return 0
+}
+
+// runRewriteURL replaces the start of the URLs starting with OLD by NEW,
+// args being "OLD NEW [--dry-run]", and lists them. A URL that another
+// bookmark already has is skipped. With --dry-run, or $ROBUKU_DRY_RUN, it
+// only lists them. It returns the exit code.
+func runRewriteURL(args []string, out io.Writer) int {
+	cfg := config.Load()
+	dryRun := cfg.DryRun
+	if len(args) == 3 && args[2] == dryRunFlag {
+		dryRun = true
+		args = args[:2]
+	}
+	if len(args) != 2 || args[0] == "" {
+		log.Println("ERROR", fmt.Errorf("usage: robuku %s OLD NEW [%s]", rewriteURLFlag, dryRunFlag))
+		return 1
+	}
+
+	db, err := openCommandDB(cfg)
+	if err != nil {
+		log.Println("ERROR", err)
+		return 1
+	}
+	defer closeDB(db)
+
+	var rewrites []bukudb.URLRewrite
+	err = lockedWrite(db, cfg, func() (err error) {
+		rewrites, err = db.URLRewrites(args[0], args[1], true, dryRun)
+		return err
+	})
+	if err != nil {
+		log.Println("ERROR", err)
+		return 1
+	}
+	skipped := 0
+	for _, r := range rewrites {
+		if r.Duplicate != 0 {
+			skipped++
+			fmt.Fprintf(out, "%d: %s -> %s skipped, already bookmarked as #%d\n", r.ID, r.Old, r.New, r.Duplicate)
+			continue
+		}
+		fmt.Fprintf(out, "%d: %s -> %s\n", r.ID, r.Old, r.New)
+	}
+	if dryRun {
+		fmt.Fprintf(out, "would rewrite %d urls, %d skipped\n", len(rewrites)-skipped, skipped)
+	} else {
+		fmt.Fprintf(out, "rewrote %d urls, %d skipped\n", len(rewrites)-skipped, skipped)
+	}
+	return 0
+}
+
+// runExportMeta writes the data robuku keeps besides the bookmarks to out as
+// JSON. It returns the exit code.
+func runExportMeta(out io.Writer) int {
+	db, err := openCommandDB(config.Load())
+	if err != nil {
+		log.Println("ERROR", err)
+		return 1
+	}
+	defer closeDB(db)
+
+	if err := db.ExportMeta(out); err != nil {
+		log.Println("ERROR", err)
+		return 1
+	}
+	return 0
+}
+
+// runStatsJSON writes the statistics of the database with the counts of
+// every tag and domain to out as JSON. A file that is not a buku database is
+// reported with schema_ok false. It returns the exit code.
+func runStatsJSON(out io.Writer) int {
+	code := 0
+	report := bukudb.StatsReport{Version: bukudb.StatsReportVersion}
+	db, err := openCommandDB(config.Load())
+	var notBukuDBErr *bukudb.NotBukuDBError
+	switch {
+	case errors.As(err, &notBukuDBErr):
+		log.Println("ERROR", err)
+		code = 1
+	case err != nil:
+		log.Println("ERROR", err)
+		return 1
+	default:
+		defer closeDB(db)
+		if report, err = db.StatsReport(); err != nil {
+			log.Println("ERROR", err)
+			return 1
+		}
+	}
+
+	enc := json.NewEncoder(out)
+	enc.SetIndent("", "  ")
+	if err := enc.Encode(report); err != nil {
+		log.Println("ERROR", err)
+		return 1
+	}
+	return code
+}
+
+// runImportMeta restores data written by runExportMeta from in, reporting a
+// dry run to out. It returns the exit code.
+func runImportMeta(in io.Reader, out io.Writer) int {
+	cfg := config.Load()
+
+	db, err := openCommandDB(cfg)
+	if err != nil {
+		log.Println("ERROR", err)
+		return 1
+	}
+	defer closeDB(db)
+
+	if cfg.DryRun {
+		fmt.Fprintln(out, "would import robuku metadata")
+		return 0
+	}
+	if err := lockedWrite(db, cfg, func() error { return db.ImportMeta(in) }); err != nil {
+		log.Println("ERROR", err)
+		return 1
+	}
+	return 0
+}
+
+// runImport adds the bookmarks of the HTML or JSON file named by args to the
+// database, args being "FILE [--report REPORT] [--on-conflict=POLICY]". The
+// outcome of every bookmark is written to REPORT as TSV. It returns the exit
+// code.
+func runImport(args []string, out io.Writer) int {
+	policy, args, err := cutConflictPolicy(args)
+	if err != nil {
+		log.Println("ERROR", err)
+		return 1
+	}
+	path, reportPath, err := parseFileArgs(importFlag, reportFlag, "REPORT", args)
+	if err != nil {
+		log.Println("ERROR", err)
+		return 1
+	}
+	format, err := importer.FormatOf(path)
+	if err != nil {
+		log.Println("ERROR", err)
+		return 1
+	}
+
+	cfg := config.Load()
+	db, err := openCommandDB(cfg)
+	if err != nil {
+		log.Println("ERROR", err)
+		return 1
+	}
+	defer closeDB(db)
+
+	target := lockWrites(db, cfg)
+	if cfg.DryRun {
+		target = bukudb.NewDryRunDB(db, func(msg string) { fmt.Fprintln(out, msg) })
+	}
+
+	in, err := os.Open(path)
+	if err != nil {
+		log.Println("ERROR", err)
+		return 1
+	}
+	defer in.Close()
+
+	var report io.Writer
+	if reportPath != "" {
+		f, err := os.Create(reportPath)
+		if err != nil {
+			log.Println("ERROR", err)
+			return 1
+		}
+		defer f.Close()
+		report = f
+	}
+
+	summary, err := importer.ImportProgress(target, in, format, report, policy, importProgress(out))
+	if policy == bukudb.ConflictSkip {
+		fmt.Fprintf(out, "imported %d bookmarks: %d skipped as duplicates, %d failed\n",
+			summary.Added, summary.Duplicates, summary.Failed)
+	} else {
+		fmt.Fprintf(out, "imported %d bookmarks: %d duplicates updated (%s), %d failed\n",
+			summary.Added, summary.Updated, policy, summary.Failed)
+	}
+	if err != nil {
+		log.Println("ERROR", err)
+		return 1
+	}
+	return 0
+}
+
+// importProgress returns a Progress printing a line to out, which the next
+// call overwrites, ended after the last bookmark.
+func importProgress(out io.Writer) importer.Progress {
+	return func(done, total int) {
+		fmt.Fprintf(out, "\rimporting %d/%d", done, total)
+		if done == total {
+			fmt.Fprintln(out)
+		}
+	}
+}
+
+// cutConflictPolicy returns the policy of the "--on-conflict=POLICY" arg of
+// args, ConflictSkip if there is none, and the other args.
+func cutConflictPolicy(args []string) (bukudb.ConflictPolicy, []string, error) {
+	var rest []string
+	var value string
+	for _, a := range args {
+		if v, ok := strings.CutPrefix(a, onConflictFlag+"="); ok {
+			value = v
+			continue
+		}
+		rest = append(rest, a)
+	}
+	policy, err := bukudb.ParseConflictPolicy(value)
+	return policy, rest, err
+}
+
+// parseFileArgs returns the file and the value of the optional flag of the
+// args of command, which are "FILE [flag VALUE]".
+func parseFileArgs(command, flag, value string, args []string) (path, flagValue string, err error) {
+	switch {
+	case len(args) == 1:
+		return args[0], "", nil
+	case len(args) == 3 && args[1] == flag:
+		return args[0], args[2], nil
+	default:
+		return "", "", fmt.Errorf("usage: robuku %s FILE [%s %s]", command, flag, value)
+	}
+}
+
+// runImportText adds the URLs listed in the file named by args, one per
+// line, args being "FILE [--tags TAG,...] [--on-conflict=POLICY]". It
+// returns the exit code.
+func runImportText(args []string, out io.Writer) int {
+	policy, args, err := cutConflictPolicy(args)
+	if err != nil {
+		log.Println("ERROR", err)
+		return 1
+	}
+	path, tags, err := parseFileArgs(importTextFlag, tagsFlag, "TAG,...", args)
+	if err != nil {
+		log.Println("ERROR", err)
+		return 1
+	}
+
+	cfg := config.Load()
+	db, err := openCommandDB(cfg)
+	if err != nil {
+		log.Println("ERROR", err)
+		return 1
+	}
+	defer closeDB(db)
+
+	in, err := os.Open(path)
+	if err != nil {
+		log.Println("ERROR", err)
+		return 1
+	}
+	defer in.Close()
+
+	if cfg.DryRun {
+		fmt.Fprintf(out, "would import the URLs of %s\n", path)
+		return 0
+	}
+	var added, updated int
+	err = lockedWrite(db, cfg, func() (err error) {
+		added, updated, err = db.ImportText(in, strings.Split(tags, ","), policy)
+		return err
+	})
+	if err != nil {
+		log.Println("ERROR", err)
+		return 1
+	}
+	if policy == bukudb.ConflictSkip {
+		fmt.Fprintf(out, "imported %d bookmarks\n", added)
+	} else {
+		fmt.Fprintf(out, "imported %d bookmarks, %d duplicates updated (%s)\n", added, updated, policy)
+	}
+	return 0
+}
+
+// exportFormat returns the export format of the file at path by its
+// extension.
+func exportFormat(path string) (export.Format, error) {
+	switch strings.ToLower(filepath.Ext(path)) {
+	case ".html", ".htm":
+		return export.FormatHTML, nil
+	case ".json":
+		return export.FormatJSON, nil
+	case ".md":
+		return export.FormatMarkdown, nil
+	case ".atom":
+		return export.FormatAtom, nil
+	default:
+		return "", fmt.Errorf("unknown bookmark file type '%s', expected .html, .json, .md or .atom", path)
+	}
+}
+
+// runExport writes the bookmarks to the file named by args, streaming them
+// from the database so large ones are not loaded at once, args being
+// "FILE [--tags TAG,...]". With --tags only the bookmarks with every tag
+// are written. It returns the exit code.
+func runExport(args []string, out io.Writer) int {
+	path, tags, err := parseFileArgs(exportFlag, tagsFlag, "TAG,...", args)
+	if err != nil {
+		log.Println("ERROR", err)
+		return 1
+	}
+	var filter export.Filter
+	if tags != "" {
+		filter = export.HasTags(strings.Split(tags, ","))
+	}
+	format, err := exportFormat(path)
+	if err != nil {
+		log.Println("ERROR", err)
+		return 1
+	}
+
+	db, err := openCommandDB(config.Load())
+	if err != nil {
+		log.Println("ERROR", err)
+		return 1
+	}
+	defer closeDB(db)
+
+	f, err := os.Create(path)
+	if err != nil {
+		log.Println("ERROR", err)
+		return 1
+	}
+	n := 0
+	err = export.WriteEach(f, format, func(fn func(bukudb.Bookmark) error) error {
+		return export.Filtered(db.ForEach, filter)(func(b bukudb.Bookmark) error {
+			n++
+			return fn(b)
+		})
+	})
+	if closeErr := f.Close(); err == nil {
+		err = closeErr
+	}
+	if err != nil {
+		log.Println("ERROR", fmt.Errorf("failed to export bookmarks: %w", err))
+		return 1
+	}
+	fmt.Fprintf(out, "exported %d bookmarks to %s\n", n, path)
+	return 0
+}
+
+// runReadingList writes the bookmarks tagged with the tag of args as a
+// standalone HTML page, args being "TAG [--out FILE]". Without --out the
+// page is written to out. It returns the exit code.
+func runReadingList(args []string, out io.Writer) int {
+	tag, path, err := parseFileArgs(readingListFlag, outFlag, "FILE", args)
+	if err != nil {
+		log.Println("ERROR", fmt.Errorf("usage: robuku %s TAG [%s FILE]", readingListFlag, outFlag))
+		return 1
+	}
+
+	db, err := openCommandDB(config.Load())
+	if err != nil {
+		log.Println("ERROR", err)
+		return 1
+	}
+	defer closeDB(db)
+
+	var page strings.Builder
+	n, err := export.ReadingList(&page, tag, db.ForEach)
+	if err != nil {
+		log.Println("ERROR", err)
+		return 1
+	}
+	if n == 0 {
+		log.Println("ERROR", fmt.Errorf("no bookmarks tagged '%s'", tag))
+		return 1
+	}
+	if path == "" {
+		fmt.Fprint(out, page.String())
+		return 0
+	}
+	if err := os.WriteFile(path, []byte(page.String()), 0o644); err != nil {
+		log.Println("ERROR", fmt.Errorf("failed to write reading list: %w", err))
+		return 1
+	}
+	fmt.Fprintf(out, "wrote %d bookmarks tagged %s to %s\n", n, tag, path)
+	return 0
+}
+
+// runAddURL opens rofi on the add screen with the URL of args filled in, or
+// without args the one in the clipboard. It returns the exit code.
+func runAddURL(args []string) int {
+	if len(args) > 1 {
+		log.Println("ERROR", fmt.Errorf("usage: robuku %s [URL]", addURLFlag))
+		return 1
+	}
+	url, source := "", inputhandler.SourceClipboard
+	if len(args) == 1 {
+		url, source = args[0], inputhandler.SourceCLI
+	} else {
+		var err error
+		if url, err = inputhandler.ReadClipboard(); err != nil {
+			log.Println("ERROR", err)
+			return 1
+		}
+	}
+	exe, err := os.Executable()
+	if err != nil {
+		log.Println("ERROR", err)
+		return 1
+	}
+
+	cmd := addURLCommand(exe, url, source)
+	cmd.Stdin, cmd.Stdout, cmd.Stderr = os.Stdin, os.Stdout, os.Stderr
+	if err := cmd.Run(); err != nil {
+		log.Println("ERROR", fmt.Errorf("failed to run rofi: %w", err))
+		return 1
+	}
+	return 0
+}
+
+// addURLCommand returns the rofi command running robuku at exe, which
+// starts on the add screen with url from source filled in.
+func addURLCommand(exe, url, source string) *exec.Cmd {
+	cmd := exec.Command("rofi", "-show", "robuku", "-modi", "robuku:"+exe)
+	cmd.Env = append(os.Environ(), addSourceEnvVar+"="+source, addURLEnvVar+"="+url)
+	return cmd
+}
+
+// openCommandDB opens the buku database for a command line command, the
+// one of the profile of $ROBUKU_PROFILE if set.
+func openCommandDB(cfg config.Config) (*bukudb.BukuDB, error) {
+	cfg, err := resolveProfile(&inputhandler.Data{}, cfg)
+	if err != nil {
+		return nil, err
+	}
+	bukuDbPath, err := dbPath(cfg)
+	if err != nil {
+		return nil, err
+	}
+	return bukudb.NewBukuDB(bukuDbPath, dbOptions(cfg)...)
+}
+
+// dbPath returns the database of the profile of cfg, or looks up buku's
+// when it has none.
+func dbPath(cfg config.Config) (string, error) {
+	if cfg.DBPath != "" {
+		return cfg.DBPath, nil
+	}
+	return getBukuDbPath()
+}
+
+// lockWrites returns db taking the lock file of cfg while writing, so
+// overlapping instances don't write at the same time. Without a lock file
+// db is returned as is.
+func lockWrites(db bukudb.DBInterface, cfg config.Config) bukudb.DBInterface {
+	if cfg.LockFile == "" {
+		return db
+	}
+	return bukudb.NewLockedDB(db, cfg.LockFile, bukudb.DefaultLockWait)
+}
+
+// lockedWrite runs write, which changes db in ways bukudb.DBInterface has no
+// method for, holding the lock of lockWrites.
+func lockedWrite(db bukudb.DBInterface, cfg config.Config, write func() error) error {
+	if locked, ok := lockWrites(db, cfg).(*bukudb.LockedDB); ok {
+		return locked.Locked(write)
+	}
+	return write()
+}
+
+// dbOptions maps the database settings of cfg onto bukudb options, unset
+// settings keep the SQLite defaults.
+func dbOptions(cfg config.Config) []bukudb.Option {
+	var opts []bukudb.Option
+	if cfg.DBBusyTimeout > 0 {
+		opts = append(opts, bukudb.WithBusyTimeout(cfg.DBBusyTimeout))
+	}
+	if cfg.DBJournalMode != "" {
+		opts = append(opts, bukudb.WithJournalMode(cfg.DBJournalMode))
+	}
+	if cfg.DBForeignKeys != nil {
+		opts = append(opts, bukudb.WithForeignKeys(*cfg.DBForeignKeys))
+	}
+	if cfg.DBMaxOpenConns > 0 {
+		opts = append(opts, bukudb.WithMaxOpenConns(cfg.DBMaxOpenConns))
+	}
+	if cfg.MaxBookmarks != config.DefaultMaxBookmarks {
+		opts = append(opts, bukudb.WithMaxBookmarks(cfg.MaxBookmarks))
+	}
+	if cfg.FTS {
+		opts = append(opts, bukudb.WithFTS(true))
+	}
+	if cfg.FetchTitles {
+		opts = append(opts, bukudb.WithTitleFetcher(titleFetcher(fetchTitle, maintenance.NewTitleCleaner(cfg.TitleStrip))))
+	}
+	if cfg.DebugTimings || cfg.SlowQuery > 0 {
+		opts = append(opts, bukudb.WithQueryLogger(newQueryLogger(cfg)))
+	}
+	return opts
+}
+
+// fetchTitle fetches the title of the page at url for imports.
+func fetchTitle(ctx context.Context, url string) (string, error) {
+	ctx, cancel := context.WithTimeout(ctx, maintenance.DefaultTitleTimeout)
+	defer cancel()
+	return maintenance.FetchTitle(ctx, http.DefaultClient, url)
+}
+
+// titleFetcher returns fetch with the titles it fetches cleaned by c.
+func titleFetcher(fetch bukudb.TitleFetcher, c maintenance.TitleCleaner) bukudb.TitleFetcher {
+	return func(ctx context.Context, url string) (string, error) {
+		title, err := fetch(ctx, url)
+		if err != nil {
+			return "", err
+		}
+		return c.CleanTitle(title), nil
+	}
+}
+
+// newQueryLogger logs statements slower than cfg.SlowQuery as warnings, and
+// all others when debug timings are enabled.
+func newQueryLogger(cfg config.Config) bukudb.QueryLogger {
+	return func(query string, d time.Duration, err error) {
+		line := fmt.Sprintf("query %dms: %s", d.Milliseconds(), strings.Join(strings.Fields(query), " "))
+		if err != nil {
+			line += fmt.Sprintf(" (%v)", err)
+		}
+		if cfg.SlowQuery > 0 && d >= cfg.SlowQuery {
+			log.Println("WARNING slow", line)
+		} else if cfg.DebugTimings {
+			log.Println("DEBUG", line)
+		}
+	}
+}
+
+func closeDB(db *bukudb.BukuDB) {
+	if err := db.Close(); err != nil {
+		log.Println("ERROR", err)
+	}
 }
 
 func handleInitError(api *rofiapi.RofiApi[inputhandler.Data], err error) {
@@ -52,6 +785,39 @@ func handleInitError(api *rofiapi.RofiApi[inputhandler.Data], err error) {
 	}
 }
 
+// draw outputs api to rofi, if that fails (e.g. Data is too large to be
+// serialized) a minimal error screen without the bookmark data is drawn instead.
+func draw(api *rofiapi.RofiApi[inputhandler.Data]) {
+	err := api.Draw()
+	if err == nil {
+		return
+	}
+
+	// the error screen only points to the log, its cause may be too large
+	// to show too
+	log.Println("ERROR", fmt.Errorf("failed to draw rofi output: %w", err))
+	err = errors.New("failed to draw rofi output, see the log for details")
+	if fallbackErr := newFallbackApi(err).Draw(); fallbackErr != nil {
+		log.Println("ERROR", fmt.Errorf("failed to draw error screen: %w", fallbackErr))
+	}
+}
+
+// newFallbackApi returns an api holding nothing but the error screen for err.
+func newFallbackApi(err error) *rofiapi.RofiApi[inputhandler.Data] {
+	api := newEmptyApi()
+	inputhandler.SetMessageToError(api, err)
+	return api
+}
+
+// newEmptyApi returns an api without a selection that was not started by
+// rofi.
+func newEmptyApi() *rofiapi.RofiApi[inputhandler.Data] {
+	return &rofiapi.RofiApi[inputhandler.Data]{
+		Options: make(map[rofiapi.Option]string),
+		Entries: make([]rofiapi.Entry, 0),
+	}
+}
+
 func getBukuDbPath() (string, error) {
 	if path := os.Getenv(bukuDbEnvVar); path != "" {
 		if _, err := os.Stat(path); err == nil {
@@ -81,6 +847,12 @@ func getBukuDbPath() (string, error) {
 func handleApiInput(api *rofiapi.RofiApi[inputhandler.Data], in *inputhandler.InputHandler) {
 	if selected, ok := api.GetSelectedEntry(); ok {
 		in.HandleInput(selected.Text)
+	} else if url, ok := os.LookupEnv(addURLEnvVar); ok {
+		source := inputhandler.SourceCLI
+		if os.Getenv(addSourceEnvVar) == inputhandler.SourceClipboard {
+			source = inputhandler.SourceClipboard
+		}
+		in.HandleAddURL(url, source)
 	} else {
 		in.HandleBookmarksShow()
 	}
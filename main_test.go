@@ -0,0 +1,786 @@
+package main
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"io"
+	"log"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/VannRR/robuku/bukudb"
+	"github.com/VannRR/robuku/config"
+	"github.com/VannRR/robuku/inputhandler"
+	"github.com/VannRR/robuku/inputhandler/testutil"
+	"github.com/VannRR/robuku/internal/golden"
+	"github.com/VannRR/robuku/maintenance"
+	rofiapi "github.com/VannRR/rofi-api"
+)
+
+func Test_dbOptions(t *testing.T) {
+	if opts := dbOptions(config.Default()); len(opts) != 0 {
+		t.Errorf("expected no options for default config, got %d", len(opts))
+	}
+
+	foreignKeys := true
+	cfg := config.Default()
+	cfg.DBBusyTimeout = time.Second
+	cfg.DBJournalMode = "wal"
+	cfg.DBForeignKeys = &foreignKeys
+	cfg.DBMaxOpenConns = 2
+	cfg.FTS = true
+	cfg.SlowQuery = time.Second
+	cfg.MaxBookmarks = 5000
+	cfg.FetchTitles = true
+	if opts := dbOptions(cfg); len(opts) != 8 {
+		t.Errorf("expected 8 options, got %d", len(opts))
+	}
+}
+
+func Test_titleFetcher(t *testing.T) {
+	fetch := titleFetcher(func(context.Context, string) (string, error) {
+		return "  Some\n video - YouTube ", nil
+	}, maintenance.NewTitleCleaner([]*regexp.Regexp{regexp.MustCompile(`\s+-\s+YouTube$`)}))
+	if title, err := fetch(context.Background(), "https://youtube.com"); err != nil || title != "Some video" {
+		t.Errorf("expected cleaned title 'Some video', got '%s' and error '%v'", title, err)
+	}
+
+	fetchErr := errors.New("fetch error")
+	fetch = titleFetcher(func(context.Context, string) (string, error) {
+		return "", fetchErr
+	}, maintenance.TitleCleaner{})
+	if _, err := fetch(context.Background(), "https://youtube.com"); !errors.Is(err, fetchErr) {
+		t.Errorf("expected error '%v', got '%v'", fetchErr, err)
+	}
+}
+
+func Test_newQueryLogger(t *testing.T) {
+	var out strings.Builder
+	log.SetOutput(&out)
+	defer log.SetOutput(os.Stderr)
+
+	cfg := config.Default()
+	cfg.SlowQuery = 100 * time.Millisecond
+	logger := newQueryLogger(cfg)
+
+	logger("SELECT 1", 10*time.Millisecond, nil)
+	if out.Len() != 0 {
+		t.Errorf("expected fast query not to be logged, got '%s'", out.String())
+	}
+	logger("SELECT\n    2", 150*time.Millisecond, nil)
+	if !strings.Contains(out.String(), "WARNING slow query 150ms: SELECT 2") {
+		t.Errorf("expected slow query warning, got '%s'", out.String())
+	}
+
+	out.Reset()
+	cfg.DebugTimings = true
+	logger = newQueryLogger(cfg)
+	logger("SELECT 3", 10*time.Millisecond, errors.New("no such table"))
+	if !strings.Contains(out.String(), "DEBUG query 10ms: SELECT 3 (no such table)") {
+		t.Errorf("expected debug line, got '%s'", out.String())
+	}
+}
+
+func Test_draw(t *testing.T) {
+	api, err := rofiapi.NewRofiApi(inputhandler.Data{})
+	if err != nil {
+		t.Fatalf("expected no error from NewRofiApi(), got %v", err)
+	}
+
+	// data too large to be serialized by rofi-api
+	api.Data.Bookmark = bukudb.Bookmark{ID: 1, Comment: strings.Repeat("a", 8192)}
+	api.Data.State = inputhandler.StateModifySelect
+	api.Entries = []rofiapi.Entry{{Text: "some entry"}}
+
+	var logged strings.Builder
+	log.SetOutput(&logged)
+	defer log.SetOutput(os.Stderr)
+	out := captureStdout(t, func() { draw(api) })
+
+	if !strings.Contains(logged.String(), "failed to draw rofi output: ") {
+		t.Errorf("expected the draw error to be logged, got '%s'", logged.String())
+	}
+
+	if !strings.Contains(out, "failed to draw rofi output") {
+		t.Errorf("expected output to contain draw error message, got '%s'", out)
+	}
+	if strings.Contains(out, "some entry") {
+		t.Errorf("expected output to not contain original entries, got '%s'", out)
+	}
+	if !strings.Contains(out, "--> Exit") {
+		t.Errorf("expected output to contain exit entry, got '%s'", out)
+	}
+}
+
+func Test_recoverRun(t *testing.T) {
+	t.Setenv("XDG_STATE_HOME", t.TempDir())
+	api, err := rofiapi.NewRofiApi(inputhandler.Data{})
+	if err != nil {
+		t.Fatalf("expected no error from NewRofiApi(), got %v", err)
+	}
+
+	func() {
+		defer recoverRun(api)
+		panic("run panic")
+	}()
+
+	if api.Data.State != inputhandler.StateErrorShow {
+		t.Errorf("expected state '%d', got '%d'", inputhandler.StateErrorShow, api.Data.State)
+	}
+	path := config.Load().CrashLogFile
+	if !strings.Contains(api.Options[rofiapi.OptionMessage], "internal error — see log at "+path) {
+		t.Errorf("expected message pointing at the crash log, got '%s'", api.Options[rofiapi.OptionMessage])
+	}
+	if data, err := os.ReadFile(path); err != nil || !strings.Contains(string(data), "run panic") {
+		t.Errorf("expected panic in crash log, got '%s' and error '%v'", data, err)
+	}
+}
+
+func Test_newFallbackApi(t *testing.T) {
+	api := newFallbackApi(io.ErrUnexpectedEOF)
+
+	if api.Data.State != inputhandler.StateErrorShow {
+		t.Errorf("expected state '%d', got '%d'", inputhandler.StateErrorShow, api.Data.State)
+	}
+	if api.Data.Bookmark.ID != 0 {
+		t.Errorf("expected empty bookmark, got '%v'", api.Data.Bookmark)
+	}
+	if len(api.Entries) != 1 {
+		t.Errorf("expected 1 entry, got '%v'", api.Entries)
+	}
+	if !strings.Contains(api.Options[rofiapi.OptionMessage], io.ErrUnexpectedEOF.Error()) {
+		t.Errorf("expected message to contain error, got '%s'", api.Options[rofiapi.OptionMessage])
+	}
+
+	out := captureStdout(t, func() {
+		if err := api.Draw(); err != nil {
+			t.Errorf("expected no error from Draw(), got %v", err)
+		}
+	})
+	if !strings.Contains(out, "--> Exit") {
+		t.Errorf("expected output to contain exit entry, got '%s'", out)
+	}
+}
+
+func Test_isCommand(t *testing.T) {
+	args := os.Args
+	defer func() { os.Args = args }()
+
+	os.Args = []string{"robuku", httpsUpgradeFlag}
+	if !isCommand(httpsUpgradeFlag) {
+		t.Errorf("expected isCommand() to be true outside of rofi")
+	}
+
+	t.Setenv(rofiRetvEnvVar, "1")
+	if isCommand(httpsUpgradeFlag) {
+		t.Errorf("expected isCommand() to be false when ran by rofi")
+	}
+}
+
+func Test_runHTTPSUpgrade(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "bookmarks.db")
+	if err := bukudb.InitSchema(path); err != nil {
+		t.Fatal(err)
+	}
+	db, err := bukudb.NewBukuDB(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	// nothing listens on port 1, so the https check fails fast
+	if err := db.Add(bukudb.Bookmark{URL: "http://127.0.0.1:1/"}); err != nil {
+		t.Fatal(err)
+	}
+	if err := db.Add(bukudb.Bookmark{URL: "https://example.com"}); err != nil {
+		t.Fatal(err)
+	}
+	closeDB(db)
+
+	t.Setenv(bukuDbEnvVar, path)
+	var out strings.Builder
+	if code := runHTTPSUpgrade(&out); code != 0 {
+		t.Errorf("expected exit code 0, got %d", code)
+	}
+	if !strings.Contains(out.String(), "checked 1 http bookmarks: 0 upgraded, 1 kept") {
+		t.Errorf("expected summary in output, got '%s'", out.String())
+	}
+}
+
+func Test_runExportMeta_runImportMeta(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "bookmarks.db")
+	if err := bukudb.InitSchema(path); err != nil {
+		t.Fatal(err)
+	}
+	db, err := bukudb.NewBukuDB(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := db.Add(bukudb.Bookmark{URL: "https://example.com"}); err != nil {
+		t.Fatal(err)
+	}
+	if err := db.Remove(1); err != nil {
+		t.Fatal(err)
+	}
+	closeDB(db)
+	t.Setenv(bukuDbEnvVar, path)
+
+	var export strings.Builder
+	if code := runExportMeta(&export); code != 0 {
+		t.Fatalf("expected exit code 0, got %d", code)
+	}
+	if !strings.Contains(export.String(), "https://example.com") {
+		t.Errorf("expected trashed bookmark in export, got '%s'", export.String())
+	}
+
+	var out strings.Builder
+	if code := runImportMeta(strings.NewReader(export.String()), &out); code != 0 {
+		t.Errorf("expected exit code 0, got %d", code)
+	}
+	if code := runImportMeta(strings.NewReader("{"), &out); code != 1 {
+		t.Errorf("expected exit code 1 for invalid input, got %d", code)
+	}
+}
+
+func Test_runStatsJSON(t *testing.T) {
+	t.Setenv(bukuDbEnvVar, testutil.NewFixtureDB(t, 30))
+
+	var out strings.Builder
+	if code := runStatsJSON(&out); code != 0 {
+		t.Fatalf("expected exit code 0, got %d", code)
+	}
+
+	// the file size depends on the SQLite version
+	fileSize := regexp.MustCompile(`"file_size": [1-9][0-9]*`)
+	if !fileSize.MatchString(out.String()) {
+		t.Fatalf("expected a file size in '%s'", out.String())
+	}
+	golden.Check(t, "stats_json", fileSize.ReplaceAllString(out.String(), `"file_size": 0`))
+
+	// not a buku database
+	path := filepath.Join(t.TempDir(), "other.db")
+	if err := os.WriteFile(path, nil, 0o644); err != nil {
+		t.Fatal(err)
+	}
+	t.Setenv(bukuDbEnvVar, path)
+	out.Reset()
+	if code := runStatsJSON(&out); code != 1 {
+		t.Errorf("expected exit code 1, got %d", code)
+	}
+	if !strings.Contains(out.String(), `"schema_ok": false`) {
+		t.Errorf("expected schema_ok false, got '%s'", out.String())
+	}
+}
+
+func captureStdout(t *testing.T, f func()) string {
+	t.Helper()
+
+	r, w, err := os.Pipe()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	stdout := os.Stdout
+	os.Stdout = w
+	defer func() { os.Stdout = stdout }()
+
+	f()
+
+	w.Close()
+	out, err := io.ReadAll(r)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return string(out)
+}
+
+func Test_runImport(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "bookmarks.db")
+	if err := bukudb.InitSchema(path); err != nil {
+		t.Fatal(err)
+	}
+	t.Setenv(bukuDbEnvVar, path)
+
+	file := filepath.Join(dir, "bookmarks.json")
+	err := os.WriteFile(file, []byte(`[{"url": "https://a.com"}, {"url": "https://a.com"}]`), 0o600)
+	if err != nil {
+		t.Fatal(err)
+	}
+	reportPath := filepath.Join(dir, "report.tsv")
+
+	var out strings.Builder
+	if code := runImport([]string{file, reportFlag, reportPath}, &out); code != 0 {
+		t.Fatalf("expected exit code 0, got %d", code)
+	}
+	if !strings.HasPrefix(out.String(), "\rimporting 2/2\nimported 1 bookmarks: 1 skipped as duplicates, 0 failed") {
+		t.Errorf("expected summary in output, got '%s'", out.String())
+	}
+	report, err := os.ReadFile(reportPath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(string(report), "duplicate\thttps://a.com\t1\t\n") {
+		t.Errorf("expected duplicate row in report, got %q", report)
+	}
+
+	out.Reset()
+	if code := runImport([]string{file, onConflictFlag + "=merge-tags"}, &out); code != 0 {
+		t.Fatalf("expected exit code 0, got %d", code)
+	}
+	if !strings.Contains(out.String(), "imported 0 bookmarks: 2 duplicates updated (merge-tags), 0 failed") {
+		t.Errorf("expected summary in output, got '%s'", out.String())
+	}
+
+	for _, args := range [][]string{{}, {file, "--other", reportPath}, {"bookmarks.md"},
+		{file, onConflictFlag + "=replace"}} {
+		if code := runImport(args, &out); code != 1 {
+			t.Errorf("expected exit code 1 for %q, got %d", args, code)
+		}
+	}
+}
+
+func Test_runExport(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "bookmarks.db")
+	if err := bukudb.InitSchema(path); err != nil {
+		t.Fatal(err)
+	}
+	t.Setenv(bukuDbEnvVar, path)
+
+	db, err := bukudb.NewBukuDB(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, b := range []bukudb.Bookmark{
+		{URL: "https://a.com"},
+		{URL: "https://b.com", Tags: []string{"reading-list"}},
+	} {
+		if err := db.Add(b); err != nil {
+			t.Fatal(err)
+		}
+	}
+	closeDB(db)
+
+	file := filepath.Join(dir, "bookmarks.md")
+	var out strings.Builder
+	if code := runExport([]string{file}, &out); code != 0 {
+		t.Fatalf("expected exit code 0, got %d", code)
+	}
+	if out.String() != "exported 2 bookmarks to "+file+"\n" {
+		t.Errorf("expected summary, got '%s'", out.String())
+	}
+	data, err := os.ReadFile(file)
+	if err != nil {
+		t.Fatal(err)
+	}
+	expected := "- [https://a.com](https://a.com)\n- [https://b.com](https://b.com) <!-- TAGS: reading-list -->\n"
+	if string(data) != expected {
+		t.Errorf("expected export %q, got %q", expected, data)
+	}
+
+	feed := filepath.Join(dir, "reading-list.atom")
+	out.Reset()
+	if code := runExport([]string{feed, tagsFlag, "reading-list"}, &out); code != 0 {
+		t.Fatalf("expected exit code 0, got %d", code)
+	}
+	if out.String() != "exported 1 bookmarks to "+feed+"\n" {
+		t.Errorf("expected summary, got '%s'", out.String())
+	}
+	if data, err = os.ReadFile(feed); err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(string(data), "<id>https://b.com</id>") || strings.Contains(string(data), "https://a.com") {
+		t.Errorf("expected feed of the tagged bookmark, got '%s'", data)
+	}
+
+	for _, args := range [][]string{{}, {"bookmarks.txt"}, {file, file}, {file, tagsFlag}} {
+		if code := runExport(args, &out); code != 1 {
+			t.Errorf("expected exit code 1 for %q, got %d", args, code)
+		}
+	}
+}
+
+func Test_runReadingList(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "bookmarks.db")
+	if err := bukudb.InitSchema(path); err != nil {
+		t.Fatal(err)
+	}
+	t.Setenv(bukuDbEnvVar, path)
+
+	db, err := bukudb.NewBukuDB(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, b := range []bukudb.Bookmark{
+		{URL: "https://a.com"},
+		{URL: "https://b.com", Title: "B", Tags: []string{"share"}, Comment: "read this"},
+	} {
+		if err := db.Add(b); err != nil {
+			t.Fatal(err)
+		}
+	}
+	closeDB(db)
+
+	file := filepath.Join(dir, "list.html")
+	var out strings.Builder
+	if code := runReadingList([]string{"share", outFlag, file}, &out); code != 0 {
+		t.Fatalf("expected exit code 0, got %d", code)
+	}
+	if out.String() != "wrote 1 bookmarks tagged share to "+file+"\n" {
+		t.Errorf("expected summary, got '%s'", out.String())
+	}
+	data, err := os.ReadFile(file)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(string(data), `<a href="https://b.com">B</a>`) || strings.Contains(string(data), "https://a.com") {
+		t.Errorf("expected page of the tagged bookmark, got '%s'", data)
+	}
+
+	out.Reset()
+	if code := runReadingList([]string{"share"}, &out); code != 0 {
+		t.Fatalf("expected exit code 0, got %d", code)
+	}
+	if out.String() != string(data) {
+		t.Errorf("expected the page on standard output, got '%s'", out.String())
+	}
+
+	for _, args := range [][]string{{}, {"none"}, {"share", outFlag}, {"share", file}} {
+		if code := runReadingList(args, &out); code != 1 {
+			t.Errorf("expected exit code 1 for %q, got %d", args, code)
+		}
+	}
+}
+
+func Test_runFixTags(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "bookmarks.db")
+	if err := bukudb.InitSchema(path); err != nil {
+		t.Fatal(err)
+	}
+	t.Setenv(bukuDbEnvVar, path)
+
+	conn, err := sql.Open("sqlite3", path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer conn.Close()
+	if _, err := conn.Exec(`INSERT INTO bookmarks (id, URL, tags) VALUES
+		(1, 'https://a.com', ',go,'), (2, 'https://b.com', 'go, video')`); err != nil {
+		t.Fatal(err)
+	}
+	column := func() string {
+		var tags string
+		if err := conn.QueryRow(`SELECT tags FROM bookmarks WHERE id = 2`).Scan(&tags); err != nil {
+			t.Fatal(err)
+		}
+		return tags
+	}
+
+	var out strings.Builder
+	if code := runFixTags([]string{dryRunFlag}, &out); code != 0 {
+		t.Fatalf("expected exit code 0, got %d", code)
+	}
+	expected := "2: \"go, video\" -> \",go,video,\"\nwould fix the tags of 1 bookmarks\n"
+	if out.String() != expected {
+		t.Errorf("expected output %q, got %q", expected, out.String())
+	}
+	if actual := column(); actual != "go, video" {
+		t.Errorf("expected a dry run to keep the tags, got '%s'", actual)
+	}
+
+	// another instance is writing
+	t.Setenv("XDG_RUNTIME_DIR", dir)
+	lock := bukudb.NewLockedDB(nil, filepath.Join(dir, "robuku.lock"), 0)
+	err = lock.Locked(func() error {
+		if code := runFixTags(nil, &out); code != 1 {
+			t.Errorf("expected exit code 1 while locked, got %d", code)
+		}
+		return nil
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if actual := column(); actual != "go, video" {
+		t.Errorf("expected the tags to be kept while locked, got '%s'", actual)
+	}
+
+	out.Reset()
+	if code := runFixTags(nil, &out); code != 0 {
+		t.Fatalf("expected exit code 0, got %d", code)
+	}
+	if !strings.HasSuffix(out.String(), "fixed the tags of 1 bookmarks\n") {
+		t.Errorf("expected summary, got '%s'", out.String())
+	}
+	if actual := column(); actual != ",go,video," {
+		t.Errorf("expected tags ',go,video,', got '%s'", actual)
+	}
+
+	if code := runFixTags([]string{"--force"}, &out); code != 1 {
+		t.Errorf("expected exit code 1 for an unknown argument, got %d", code)
+	}
+}
+
+func Test_runRewriteURL(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "bookmarks.db")
+	if err := bukudb.InitSchema(path); err != nil {
+		t.Fatal(err)
+	}
+	t.Setenv(bukuDbEnvVar, path)
+
+	conn, err := sql.Open("sqlite3", path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer conn.Close()
+	if _, err := conn.Exec(`INSERT INTO bookmarks (id, URL) VALUES
+		(1, 'https://old.org/a'), (2, 'https://old.org/b'), (3, 'https://new.org/b')`); err != nil {
+		t.Fatal(err)
+	}
+	url := func(id int) string {
+		var url string
+		if err := conn.QueryRow(`SELECT URL FROM bookmarks WHERE id = ?`, id).Scan(&url); err != nil {
+			t.Fatal(err)
+		}
+		return url
+	}
+
+	var out strings.Builder
+	if code := runRewriteURL([]string{"https://old.org", "https://new.org", dryRunFlag}, &out); code != 0 {
+		t.Fatalf("expected exit code 0, got %d", code)
+	}
+	expected := "1: https://old.org/a -> https://new.org/a\n" +
+		"2: https://old.org/b -> https://new.org/b skipped, already bookmarked as #3\n" +
+		"would rewrite 1 urls, 1 skipped\n"
+	if out.String() != expected {
+		t.Errorf("expected output %q, got %q", expected, out.String())
+	}
+	if actual := url(1); actual != "https://old.org/a" {
+		t.Errorf("expected a dry run to keep the url, got '%s'", actual)
+	}
+
+	out.Reset()
+	if code := runRewriteURL([]string{"https://old.org", "https://new.org"}, &out); code != 0 {
+		t.Fatalf("expected exit code 0, got %d", code)
+	}
+	if !strings.HasSuffix(out.String(), "rewrote 1 urls, 1 skipped\n") {
+		t.Errorf("expected summary, got '%s'", out.String())
+	}
+	if actual := url(1); actual != "https://new.org/a" {
+		t.Errorf("expected url 'https://new.org/a', got '%s'", actual)
+	}
+	if actual := url(2); actual != "https://old.org/b" {
+		t.Errorf("expected the colliding url to be kept, got '%s'", actual)
+	}
+
+	if code := runRewriteURL([]string{"https://old.org"}, &out); code != 1 {
+		t.Errorf("expected exit code 1 without NEW, got %d", code)
+	}
+}
+
+func Test_runImportText(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "bookmarks.db")
+	if err := bukudb.InitSchema(path); err != nil {
+		t.Fatal(err)
+	}
+	t.Setenv(bukuDbEnvVar, path)
+
+	file := filepath.Join(dir, "urls.txt")
+	if err := os.WriteFile(file, []byte("https://a.com # later\nb.com\nnotes\n"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+
+	var out strings.Builder
+	if code := runImportText([]string{file, tagsFlag, "inbox,read"}, &out); code != 0 {
+		t.Fatalf("expected exit code 0, got %d", code)
+	}
+	if out.String() != "imported 2 bookmarks\n" {
+		t.Errorf("expected summary, got '%s'", out.String())
+	}
+
+	db, err := bukudb.NewBukuDB(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer closeDB(db)
+	b, err := db.Get(1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if b.URL != "https://a.com" || b.Comment != "later" || strings.Join(b.Tags, ",") != "inbox,read" {
+		t.Errorf("expected tagged bookmark with comment, got %+v", b)
+	}
+
+	out.Reset()
+	args := []string{onConflictFlag + "=overwrite", file, tagsFlag, "new"}
+	if code := runImportText(args, &out); code != 0 {
+		t.Fatalf("expected exit code 0, got %d", code)
+	}
+	if out.String() != "imported 0 bookmarks, 2 duplicates updated (overwrite)\n" {
+		t.Errorf("expected summary, got '%s'", out.String())
+	}
+	if b, _ := db.Get(1); b.Comment != "later" || strings.Join(b.Tags, ",") != "new" {
+		t.Errorf("expected overwritten tags, got %+v", b)
+	}
+
+	if code := runImportText([]string{file, "--tag", "x"}, &out); code != 1 {
+		t.Errorf("expected exit code 1 for unknown flag, got %d", code)
+	}
+}
+
+func Test_cutConflictPolicy(t *testing.T) {
+	policy, rest, err := cutConflictPolicy([]string{"a.json", onConflictFlag + "=overwrite", reportFlag, "r.tsv"})
+	if err != nil || policy != bukudb.ConflictOverwrite || strings.Join(rest, " ") != "a.json --report r.tsv" {
+		t.Errorf("expected overwrite and the other args, got '%s', %q and '%v'", policy, rest, err)
+	}
+
+	policy, rest, err = cutConflictPolicy([]string{"a.json"})
+	if err != nil || policy != bukudb.ConflictSkip || len(rest) != 1 {
+		t.Errorf("expected skip and the file, got '%s', %q and '%v'", policy, rest, err)
+	}
+
+	if _, _, err := cutConflictPolicy([]string{onConflictFlag + "=newest"}); err == nil {
+		t.Error("expected error for an unknown policy, got nil")
+	}
+}
+
+func Test_addURLCommand(t *testing.T) {
+	cmd := addURLCommand("/usr/bin/robuku", "https://a.com", inputhandler.SourceClipboard)
+
+	expected := []string{"rofi", "-show", "robuku", "-modi", "robuku:/usr/bin/robuku"}
+	if strings.Join(cmd.Args, " ") != strings.Join(expected, " ") {
+		t.Errorf("expected args %v, got %v", expected, cmd.Args)
+	}
+	if env := cmd.Env[len(cmd.Env)-1]; env != addURLEnvVar+"=https://a.com" {
+		t.Errorf("expected URL in environment, got '%s'", env)
+	}
+	if env := cmd.Env[len(cmd.Env)-2]; env != addSourceEnvVar+"=clipboard" {
+		t.Errorf("expected source in environment, got '%s'", env)
+	}
+
+	if code := runAddURL([]string{"https://a.com", "https://b.com"}); code != 1 {
+		t.Errorf("expected exit code 1 with two URLs, got %d", code)
+	}
+}
+
+func Test_handleApiInput_AddURL(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "bookmarks.db")
+	if err := bukudb.InitSchema(path); err != nil {
+		t.Fatal(err)
+	}
+	db, err := bukudb.NewBukuDB(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer closeDB(db)
+
+	args := os.Args
+	defer func() { os.Args = args }()
+	os.Args = []string{"robuku"}
+
+	api, err := rofiapi.NewRofiApi(inputhandler.Data{})
+	if err != nil {
+		t.Fatalf("expected no error from NewRofiApi(), got %v", err)
+	}
+	t.Setenv(addURLEnvVar, "https://a.com")
+
+	handleApiInput(api, inputhandler.NewInputHandler(db, api))
+
+	if api.Data.State != inputhandler.StateAddSelect {
+		t.Errorf("expected state '%d', got '%d'", inputhandler.StateAddSelect, api.Data.State)
+	}
+	if api.Data.Bookmark.URL != "https://a.com" {
+		t.Errorf("expected URL to be set, got '%s'", api.Data.Bookmark.URL)
+	}
+	if api.Data.Source != inputhandler.SourceCLI {
+		t.Errorf("expected source '%s', got '%s'", inputhandler.SourceCLI, api.Data.Source)
+	}
+
+	t.Setenv(addSourceEnvVar, inputhandler.SourceClipboard)
+	handleApiInput(api, inputhandler.NewInputHandler(db, api))
+	if api.Data.Source != inputhandler.SourceClipboard {
+		t.Errorf("expected source '%s', got '%s'", inputhandler.SourceClipboard, api.Data.Source)
+	}
+}
+
+func Test_writeTrace(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "trace")
+	trace := inputhandler.TraceRecord{Selected: "a", Selection: true}
+	writeTrace(path, trace, inputhandler.Data{State: inputhandler.StateHelpSelect})
+	writeTrace("", trace, inputhandler.Data{})
+
+	records, err := readTraceFile(path)
+	if err != nil {
+		t.Fatalf("expected no error on readTraceFile(), got '%v'", err)
+	}
+	if len(records) != 1 || records[0].Selected != "a" ||
+		records[0].After.State != inputhandler.StateHelpSelect {
+		t.Errorf("expected one record ending in state %d, got %+v", inputhandler.StateHelpSelect, records)
+	}
+}
+
+func Test_resolveProfile(t *testing.T) {
+	browser := "firefox -P work"
+	cfg := config.Default()
+	cfg.Browser = "firefox"
+	cfg.Profiles = []config.Profile{{Name: "work", DBPath: "/work.db", Browser: &browser}}
+
+	var data inputhandler.Data
+	if actual, err := resolveProfile(&data, cfg); err != nil || actual.Browser != "firefox" || data.Profile != "" {
+		t.Errorf("expected no profile, got '%s' with browser '%s' and error '%v'", data.Profile, actual.Browser, err)
+	}
+
+	cfg.Profile = "work"
+	actual, err := resolveProfile(&data, cfg)
+	if err != nil || data.Profile != "work" || actual.Browser != browser || actual.DBPath != "/work.db" {
+		t.Errorf("expected profile work, got '%s' with browser '%s', db '%s' and error '%v'",
+			data.Profile, actual.Browser, actual.DBPath, err)
+	}
+
+	data.Profile = "home"
+	if _, err := resolveProfile(&data, cfg); err == nil || data.Profile != "" {
+		t.Errorf("expected error for unknown profile, got '%v' and profile '%s'", err, data.Profile)
+	}
+}
+
+func Test_openCommandDB_profile(t *testing.T) {
+	t.Setenv(bukuDbEnvVar, testutil.NewFixtureDB(t, 3))
+	cfg := config.Default()
+	cfg.Profiles = []config.Profile{{Name: "work", DBPath: testutil.NewFixtureDB(t, 5)}}
+
+	for profile, expected := range map[string]int{"": 3, "work": 5} {
+		cfg.Profile = profile
+		db, err := openCommandDB(cfg)
+		if err != nil {
+			t.Fatalf("expected no error opening the db of profile '%s', got '%v'", profile, err)
+		}
+		if db.Len() != expected {
+			t.Errorf("expected %d bookmarks with profile '%s', got %d", expected, profile, db.Len())
+		}
+		db.Close()
+	}
+
+	cfg.Profile = "home"
+	if _, err := openCommandDB(cfg); err == nil {
+		t.Errorf("expected error for unknown profile")
+	}
+}
+
+func Test_lockWrites(t *testing.T) {
+	var db bukudb.DBInterface = &bukudb.BukuDB{}
+	if actual := lockWrites(db, config.Config{}); actual != db {
+		t.Errorf("expected db to be unwrapped without a lock file, got %T", actual)
+	}
+	cfg := config.Config{LockFile: filepath.Join(t.TempDir(), "robuku.lock")}
+	if actual, ok := lockWrites(db, cfg).(*bukudb.LockedDB); !ok {
+		t.Errorf("expected *bukudb.LockedDB, got %T", actual)
+	}
+}
@@ -0,0 +1,44 @@
+package maintenance
+
+import (
+	"regexp"
+	"strings"
+)
+
+// TitleCleaner normalizes the titles of bookmarks, the zero value only
+// cleans up whitespace.
+type TitleCleaner struct {
+	strip []*regexp.Regexp
+}
+
+// NewTitleCleaner returns a TitleCleaner that also removes the matches of
+// strip, like `\s+[-|]\s+YouTube$` for the site name YouTube appends.
+func NewTitleCleaner(strip []*regexp.Regexp) TitleCleaner {
+	return TitleCleaner{strip: strip}
+}
+
+// CleanTitle returns title trimmed, with runs of whitespace, newlines
+// included, collapsed to one space and the strip patterns removed in order.
+// title is returned unchanged if nothing would be left of it.
+func (c TitleCleaner) CleanTitle(title string) string {
+	cleaned := collapseSpace(title)
+	for _, re := range c.strip {
+		cleaned = collapseSpace(re.ReplaceAllString(cleaned, ""))
+	}
+	if cleaned == "" {
+		return title
+	}
+	return cleaned
+}
+
+// CleanTitle returns title trimmed and with its whitespace collapsed, see
+// TitleCleaner.
+func CleanTitle(title string) string {
+	return TitleCleaner{}.CleanTitle(title)
+}
+
+// collapseSpace returns s trimmed with its runs of whitespace replaced by a
+// space.
+func collapseSpace(s string) string {
+	return strings.Join(strings.Fields(s), " ")
+}
@@ -0,0 +1,50 @@
+package maintenance
+
+import (
+	"regexp"
+	"testing"
+)
+
+func Test_CleanTitle(t *testing.T) {
+	tests := []struct {
+		title    string
+		expected string
+	}{
+		{"Go", "Go"},
+		{"  The Go\nProgramming \t Language  ", "The Go Programming Language"},
+		{"a\r\n\r\nb", "a b"},
+		{"", ""},
+		{" \n ", " \n "},
+	}
+	for _, tt := range tests {
+		if actual := CleanTitle(tt.title); actual != tt.expected {
+			t.Errorf("expected %q for %q, got %q", tt.expected, tt.title, actual)
+		}
+	}
+}
+
+func Test_TitleCleaner_CleanTitle(t *testing.T) {
+	c := NewTitleCleaner([]*regexp.Regexp{
+		regexp.MustCompile(`\s+[-|–]\s+YouTube$`),
+		regexp.MustCompile(`(?i)^\(\d+\)\s*`),
+		regexp.MustCompile(`\s+-\s+Wikipedia$`),
+	})
+	tests := []struct {
+		title    string
+		expected string
+	}{
+		{"Some  video\n - YouTube", "Some video"},
+		{"Some video | YouTube", "Some video"},
+		{"(3) Some video – YouTube", "Some video"},
+		{"YouTube - the site", "YouTube - the site"},
+		{"Go (programming language) - Wikipedia", "Go (programming language)"},
+		{"Go - Wikipedia - Wikipedia", "Go - Wikipedia"},
+		{"  - YouTube", "- YouTube"},
+		{"(1) ", "(1) "},
+	}
+	for _, tt := range tests {
+		if actual := c.CleanTitle(tt.title); actual != tt.expected {
+			t.Errorf("expected %q for %q, got %q", tt.expected, tt.title, actual)
+		}
+	}
+}
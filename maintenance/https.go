@@ -0,0 +1,162 @@
+// maintenance, operations on the bookmark database that reach out to the
+// network, run from the command line or from rofi.
+package maintenance
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/VannRR/robuku/bukudb"
+)
+
+const (
+	// DefaultHTTPSTimeout is how long a single https check may take.
+	DefaultHTTPSTimeout = 5 * time.Second
+	// DefaultHTTPSConcurrency is the number of https checks run at once.
+	DefaultHTTPSConcurrency = 8
+)
+
+// HTTPSSummary counts the outcome of an https upgrade run.
+type HTTPSSummary struct {
+	Checked  int
+	Upgraded int
+	Failed   int
+}
+
+// HTTPSUpgrader moves http bookmarks to https where the https variant of
+// their URL answers a HEAD request.
+type HTTPSUpgrader struct {
+	Client      *http.Client
+	Timeout     time.Duration
+	Concurrency int
+	// Out receives one progress line per checked bookmark.
+	Out io.Writer
+}
+
+// NewHTTPSUpgrader returns an HTTPSUpgrader with the default timeout and
+// concurrency writing progress to out.
+func NewHTTPSUpgrader(out io.Writer) *HTTPSUpgrader {
+	return &HTTPSUpgrader{
+		Client:      http.DefaultClient,
+		Timeout:     DefaultHTTPSTimeout,
+		Concurrency: DefaultHTTPSConcurrency,
+		Out:         out,
+	}
+}
+
+// httpsCheck is the result of checking the https variant of one bookmark.
+type httpsCheck struct {
+	bookmark bukudb.Bookmark
+	httpsURL string
+	err      error
+}
+
+// Run checks every http bookmark of db and updates the URL of those whose
+// https variant is reachable.
+func (u *HTTPSUpgrader) Run(ctx context.Context, db bukudb.DBInterface) (HTTPSSummary, error) {
+	var summary HTTPSSummary
+
+	bookmarks, err := db.GetAllContext(ctx)
+	if err != nil {
+		return summary, fmt.Errorf("failed to load bookmarks: %w", err)
+	}
+
+	var candidates []httpsCheck
+	for _, b := range bookmarks {
+		if httpsURL, ok := HTTPSVariant(b.URL); ok {
+			candidates = append(candidates, httpsCheck{bookmark: b, httpsURL: httpsURL})
+		}
+	}
+
+	for check := range u.checkAll(ctx, candidates) {
+		summary.Checked++
+		prefix := fmt.Sprintf("[%d/%d]", summary.Checked, len(candidates))
+
+		if check.err == nil {
+			check.err = db.UpdateURL(check.bookmark.ID, check.httpsURL)
+		}
+		if check.err != nil {
+			summary.Failed++
+			fmt.Fprintf(u.Out, "%s kept %s: %v\n", prefix, check.bookmark.URL, check.err)
+			continue
+		}
+		summary.Upgraded++
+		fmt.Fprintf(u.Out, "%s %s -> %s\n", prefix, check.bookmark.URL, check.httpsURL)
+	}
+
+	return summary, ctx.Err()
+}
+
+// checkAll probes the https URL of each candidate with at most
+// u.Concurrency requests in flight, results arrive in completion order.
+func (u *HTTPSUpgrader) checkAll(ctx context.Context, candidates []httpsCheck) <-chan httpsCheck {
+	results := make(chan httpsCheck)
+	sem := make(chan struct{}, max(u.Concurrency, 1))
+
+	var wg sync.WaitGroup
+	for _, c := range candidates {
+		wg.Add(1)
+		go func(c httpsCheck) {
+			defer wg.Done()
+			sem <- struct{}{}
+			c.err = u.probe(ctx, c.httpsURL)
+			<-sem
+			results <- c
+		}(c)
+	}
+
+	go func() {
+		wg.Wait()
+		close(results)
+	}()
+	return results
+}
+
+// probe sends a HEAD request to httpsURL and returns an error unless it
+// ends in a non error status without being redirected back to http.
+func (u *HTTPSUpgrader) probe(ctx context.Context, httpsURL string) error {
+	if u.Timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, u.Timeout)
+		defer cancel()
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodHead, httpsURL, nil)
+	if err != nil {
+		return err
+	}
+	resp, err := u.Client.Do(req)
+	if err != nil {
+		return err
+	}
+	resp.Body.Close()
+
+	if resp.StatusCode >= http.StatusBadRequest {
+		return fmt.Errorf("https answered %s", resp.Status)
+	}
+	if resp.Request.URL.Scheme != "https" {
+		return fmt.Errorf("https redirects back to %s", resp.Request.URL.Scheme)
+	}
+	return nil
+}
+
+// HTTPSVariant returns rawURL with its http scheme replaced by https, the
+// default port 80 is dropped. It returns false if rawURL is not an http URL
+// with a host.
+func HTTPSVariant(rawURL string) (string, bool) {
+	parsedURL, err := url.Parse(strings.TrimSpace(rawURL))
+	if err != nil || !strings.EqualFold(parsedURL.Scheme, "http") || parsedURL.Host == "" {
+		return "", false
+	}
+	parsedURL.Scheme = "https"
+	if parsedURL.Port() == "80" {
+		parsedURL.Host = strings.TrimSuffix(parsedURL.Host, ":80")
+	}
+	return parsedURL.String(), true
+}
@@ -0,0 +1,153 @@
+package maintenance
+
+import (
+	"bytes"
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/VannRR/robuku/bukudb"
+)
+
+func Test_HTTPSVariant(t *testing.T) {
+	tests := []struct {
+		in   string
+		want string
+		ok   bool
+	}{
+		{"http://example.com", "https://example.com", true},
+		{"http://example.com/a/b?q=1#frag", "https://example.com/a/b?q=1#frag", true},
+		{"HTTP://example.com/", "https://example.com/", true},
+		{"http://example.com:80/x", "https://example.com/x", true},
+		{"http://example.com:8080/x", "https://example.com:8080/x", true},
+		{" http://example.com ", "https://example.com", true},
+		{"https://example.com", "", false},
+		{"ftp://example.com", "", false},
+		{"example.com", "", false},
+		{"http:///path", "", false},
+		{"mailto:a@example.com", "", false},
+	}
+
+	for _, tt := range tests {
+		got, ok := HTTPSVariant(tt.in)
+		if got != tt.want || ok != tt.ok {
+			t.Errorf("expected HTTPSVariant(%q) to be (%q, %v), got (%q, %v)",
+				tt.in, tt.want, tt.ok, got, ok)
+		}
+	}
+}
+
+func Test_probe(t *testing.T) {
+	handler := http.NewServeMux()
+	handler.HandleFunc("/ok", func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodHead {
+			t.Errorf("expected HEAD request, got %s", r.Method)
+		}
+	})
+	handler.HandleFunc("/missing", http.NotFound)
+	handler.HandleFunc("/downgrade", func(w http.ResponseWriter, r *http.Request) {
+		http.Redirect(w, r, "http://"+r.Host+"/ok", http.StatusMovedPermanently)
+	})
+	handler.HandleFunc("/slow", func(w http.ResponseWriter, r *http.Request) {
+		<-r.Context().Done()
+	})
+	server := httptest.NewTLSServer(handler)
+	defer server.Close()
+
+	u := &HTTPSUpgrader{Client: server.Client(), Timeout: 100 * time.Millisecond}
+	ctx := context.Background()
+
+	if err := u.probe(ctx, server.URL+"/ok"); err != nil {
+		t.Errorf("expected no error for reachable URL, got %v", err)
+	}
+	if err := u.probe(ctx, server.URL+"/missing"); err == nil || !strings.Contains(err.Error(), "404") {
+		t.Errorf("expected 404 error, got %v", err)
+	}
+	if err := u.probe(ctx, server.URL+"/downgrade"); err == nil {
+		t.Errorf("expected error for redirect back to http, got nil")
+	}
+	if err := u.probe(ctx, server.URL+"/slow"); err == nil {
+		t.Errorf("expected timeout error, got nil")
+	}
+}
+
+// upgradeDB records URL updates of a fixed set of bookmarks.
+type upgradeDB struct {
+	bukudb.DBInterface
+	bookmarks []bukudb.Bookmark
+	updated   map[uint16]string
+}
+
+func (db *upgradeDB) GetAllContext(ctx context.Context) ([]bukudb.Bookmark, error) {
+	return db.bookmarks, nil
+}
+
+func (db *upgradeDB) UpdateURL(id uint16, url string) error {
+	db.updated[id] = url
+	return nil
+}
+
+func Test_HTTPSUpgrader_Run(t *testing.T) {
+	var inFlight, maxInFlight atomic.Int32
+	server := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		n := inFlight.Add(1)
+		defer inFlight.Add(-1)
+		for {
+			m := maxInFlight.Load()
+			if n <= m || maxInFlight.CompareAndSwap(m, n) {
+				break
+			}
+		}
+		time.Sleep(10 * time.Millisecond)
+		if strings.HasPrefix(r.URL.Path, "/gone") {
+			http.NotFound(w, r)
+		}
+	}))
+	defer server.Close()
+	host := strings.TrimPrefix(server.URL, "https://")
+
+	db := &upgradeDB{
+		bookmarks: []bukudb.Bookmark{
+			{ID: 1, URL: "http://" + host + "/a"},
+			{ID: 2, URL: "http://" + host + "/gone"},
+			{ID: 3, URL: "https://" + host + "/already"},
+			{ID: 4, URL: "http://" + host + "/b"},
+			{ID: 5, URL: "http://" + host + "/c"},
+			{ID: 6, URL: "mailto:a@example.com"},
+		},
+		updated: make(map[uint16]string),
+	}
+
+	var out bytes.Buffer
+	u := &HTTPSUpgrader{Client: server.Client(), Timeout: time.Second, Concurrency: 2, Out: &out}
+	summary, err := u.Run(context.Background(), db)
+	if err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+
+	want := HTTPSSummary{Checked: 4, Upgraded: 3, Failed: 1}
+	if summary != want {
+		t.Errorf("expected summary %+v, got %+v", want, summary)
+	}
+	for _, id := range []uint16{1, 4, 5} {
+		if got := db.updated[id]; !strings.HasPrefix(got, "https://") {
+			t.Errorf("expected bookmark %d to be upgraded, got '%s'", id, got)
+		}
+	}
+	if got, ok := db.updated[2]; ok {
+		t.Errorf("expected bookmark 2 to be kept, got '%s'", got)
+	}
+	if m := maxInFlight.Load(); m > 2 {
+		t.Errorf("expected at most 2 requests in flight, got %d", m)
+	}
+	if lines := strings.Count(out.String(), "\n"); lines != 4 {
+		t.Errorf("expected 4 progress lines, got %d: %s", lines, out.String())
+	}
+	if !strings.Contains(out.String(), "kept http://"+host+"/gone") {
+		t.Errorf("expected progress to report the kept bookmark, got '%s'", out.String())
+	}
+}
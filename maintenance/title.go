@@ -0,0 +1,60 @@
+package maintenance
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"html"
+	"io"
+	"net/http"
+	"regexp"
+	"strings"
+	"time"
+)
+
+// DefaultTitleTimeout is how long fetching a page title may take.
+const DefaultTitleTimeout = 5 * time.Second
+
+// titleReadLimit is how much of a page is searched for its title.
+const titleReadLimit = 64 << 10
+
+var titleRegexp = regexp.MustCompile(`(?is)<title[^>]*>(.*?)</title>`)
+
+// errNoTitle is returned by FetchTitle for pages without a title.
+var errNoTitle = errors.New("page has no title")
+
+// FetchTitle returns the title of the HTML page at rawURL, with entities
+// decoded and whitespace collapsed.
+func FetchTitle(ctx context.Context, client *http.Client, rawURL string) (string, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, rawURL, nil)
+	if err != nil {
+		return "", fmt.Errorf("failed to create request: %w", err)
+	}
+	resp, err := client.Do(req)
+	if err != nil {
+		return "", err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode >= http.StatusBadRequest {
+		return "", fmt.Errorf("status %s", resp.Status)
+	}
+	page, err := io.ReadAll(io.LimitReader(resp.Body, titleReadLimit))
+	if err != nil {
+		return "", fmt.Errorf("failed to read page: %w", err)
+	}
+	return parseTitle(page)
+}
+
+// parseTitle returns the content of the first title element of page.
+func parseTitle(page []byte) (string, error) {
+	m := titleRegexp.FindSubmatch(page)
+	if m == nil {
+		return "", errNoTitle
+	}
+	title := CleanTitle(html.UnescapeString(string(m[1])))
+	if strings.TrimSpace(title) == "" {
+		return "", errNoTitle
+	}
+	return title, nil
+}
@@ -0,0 +1,50 @@
+package maintenance
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func Test_parseTitle(t *testing.T) {
+	tests := []struct {
+		page     string
+		expected string
+		err      bool
+	}{
+		{"<html><head><title>Go</title></head></html>", "Go", false},
+		{"<TITLE lang=\"en\">\n  Tom &amp; Jerry\n  wiki </TITLE>", "Tom & Jerry wiki", false},
+		{"<title>first</title><title>second</title>", "first", false},
+		{"<title>  </title>", "", true},
+		{"<html><body>no title</body></html>", "", true},
+	}
+	for _, tt := range tests {
+		got, err := parseTitle([]byte(tt.page))
+		if (err != nil) != tt.err {
+			t.Errorf("page %q: expected error %v, got %v", tt.page, tt.err, err)
+		}
+		if got != tt.expected {
+			t.Errorf("expected title '%s', got '%s'", tt.expected, got)
+		}
+	}
+}
+
+func Test_FetchTitle(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/missing" {
+			http.NotFound(w, r)
+			return
+		}
+		w.Write([]byte("<html><head><title>Example Domain</title></head></html>"))
+	}))
+	defer srv.Close()
+
+	title, err := FetchTitle(context.Background(), srv.Client(), srv.URL)
+	if err != nil || title != "Example Domain" {
+		t.Errorf("expected 'Example Domain', got '%s' and error %v", title, err)
+	}
+	if _, err := FetchTitle(context.Background(), srv.Client(), srv.URL+"/missing"); err == nil {
+		t.Errorf("expected error for missing page, got nil")
+	}
+}
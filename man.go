@@ -0,0 +1,110 @@
+package main
+
+import (
+	"fmt"
+	"io"
+	"strings"
+)
+
+// roffEscaper escapes the characters roff would interpret in text.
+var roffEscaper = strings.NewReplacer(`\`, `\e`, `-`, `\-`)
+
+// roffLine escapes s and guards a leading "." or "'" which start a request.
+func roffLine(s string) string {
+	s = roffEscaper.Replace(s)
+	if strings.HasPrefix(s, ".") || strings.HasPrefix(s, "'") {
+		s = `\&` + s
+	}
+	return s
+}
+
+// roffUsage returns usage with flags in bold and placeholders in italics.
+func roffUsage(usage string) string {
+	words := strings.Fields(usage)
+	for i, w := range words {
+		trimmed := strings.Trim(w, "[]")
+		start := strings.Index(w, trimmed)
+		prefix, suffix := w[:start], w[start+len(trimmed):]
+		flag, value, joined := strings.Cut(trimmed, "=")
+		switch {
+		case joined:
+			trimmed = `\fB` + roffEscaper.Replace(flag) + `\fR=\fI` + roffEscaper.Replace(value) + `\fR`
+		case strings.HasPrefix(trimmed, "-"):
+			trimmed = `\fB` + roffEscaper.Replace(trimmed) + `\fR`
+		default:
+			trimmed = `\fI` + roffEscaper.Replace(trimmed) + `\fR`
+		}
+		words[i] = prefix + trimmed + suffix
+	}
+	return strings.Join(words, " ")
+}
+
+// runMan prints the man page of robuku to out. It returns the exit code.
+func runMan(out io.Writer) int {
+	writeMan(out, cliCommands())
+	return 0
+}
+
+// writeMan writes the roff man page of robuku with commands to out.
+func writeMan(out io.Writer, commands []cliCommand) {
+	fmt.Fprintln(out, `.TH ROBUKU 1 "" "robuku" "User Commands"
+.SH NAME
+robuku \- a rofi front end for buku bookmarks
+.SH SYNOPSIS
+.B rofi \-show robuku \-modi robuku:robuku`)
+	for _, c := range commands {
+		fmt.Fprintln(out, ".br")
+		line := `\fBrobuku\fR \fB` + roffEscaper.Replace(c.flag) + `\fR`
+		if usage := c.usage(); usage != "" {
+			line += " " + roffUsage(usage)
+		}
+		fmt.Fprintln(out, line)
+	}
+
+	fmt.Fprintln(out, `.SH DESCRIPTION
+robuku lists, opens, adds, and edits the bookmarks of a buku database as a
+rofi script. Started from a terminal with one of the commands below, it runs
+that command without rofi instead.
+.SH COMMANDS`)
+	for _, c := range commands {
+		fmt.Fprintln(out, ".TP")
+		line := `\fB` + roffEscaper.Replace(c.flag) + `\fR`
+		if c.arg != "" {
+			line += " " + roffUsage(c.arg)
+		}
+		fmt.Fprintln(out, line)
+		fmt.Fprintln(out, roffLine(sentence(c.help)))
+		if len(c.options) == 0 {
+			continue
+		}
+		fmt.Fprintln(out, ".RS")
+		for _, o := range c.options {
+			fmt.Fprintln(out, ".TP")
+			fmt.Fprintln(out, roffUsage(o.usage()))
+			fmt.Fprintln(out, roffLine(sentence(o.help)))
+		}
+		fmt.Fprintln(out, ".RE")
+	}
+
+	fmt.Fprintf(out, `.SH ENVIRONMENT
+.TP
+.B %s
+The buku database, by default the one buku uses.
+.TP
+.B %s
+Set to 1 to offer creating the buku schema in a database without it.
+.PP
+The other settings are described in the README.
+.SH SEE ALSO
+.BR buku (1),
+.BR rofi (1)
+`, roffEscaper.Replace(bukuDbEnvVar), roffEscaper.Replace(createSchemaEnvVar))
+}
+
+// sentence returns s with its first letter upper case and a full stop.
+func sentence(s string) string {
+	if s == "" {
+		return s
+	}
+	return strings.ToUpper(s[:1]) + s[1:] + "."
+}
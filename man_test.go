@@ -0,0 +1,48 @@
+package main
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/VannRR/robuku/internal/golden"
+)
+
+func Test_runMan(t *testing.T) {
+	var out bytes.Buffer
+	if code := runMan(&out); code != 0 {
+		t.Fatalf("expected exit code 0, got %d", code)
+	}
+	golden.Check(t, "man", out.String())
+}
+
+func Test_roffUsage(t *testing.T) {
+	tests := []struct {
+		usage, expected string
+	}{
+		{"FILE", `\fIFILE\fR`},
+		{"TRACE [DB]", `\fITRACE\fR [\fIDB\fR]`},
+		{"[--report REPORT]", `[\fB\-\-report\fR \fIREPORT\fR]`},
+		{"[--on-conflict=POLICY]", `[\fB\-\-on\-conflict\fR=\fIPOLICY\fR]`},
+	}
+	for _, tt := range tests {
+		if got := roffUsage(tt.usage); got != tt.expected {
+			t.Errorf("usage '%s': expected '%s', got '%s'", tt.usage, tt.expected, got)
+		}
+	}
+}
+
+func Test_roffLine(t *testing.T) {
+	tests := []struct {
+		s, expected string
+	}{
+		{"merge-tags", `merge\-tags`},
+		{`a\b`, `a\eb`},
+		{".hidden", `\&.hidden`},
+		{"'quoted'", `\&'quoted'`},
+	}
+	for _, tt := range tests {
+		if got := roffLine(tt.s); got != tt.expected {
+			t.Errorf("line '%s': expected '%s', got '%s'", tt.s, tt.expected, got)
+		}
+	}
+}
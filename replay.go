@@ -0,0 +1,85 @@
+package main
+
+import (
+	"fmt"
+	"io"
+	"log"
+	"os"
+	"path/filepath"
+
+	"github.com/VannRR/robuku/bukudb"
+	"github.com/VannRR/robuku/config"
+	"github.com/VannRR/robuku/inputhandler"
+)
+
+// runReplay replays the runs of the trace file in args against a copy of
+// the database, the one in args or robuku's, printing the state transitions
+// to out. It returns the exit code, 1 if a run ends in another state than
+// traced.
+func runReplay(args []string, out io.Writer) int {
+	if len(args) != 1 && len(args) != 2 {
+		log.Println("ERROR", fmt.Errorf("usage: robuku %s TRACE [DB]", replayFlag))
+		return 1
+	}
+
+	records, err := readTraceFile(args[0])
+	if err != nil {
+		log.Println("ERROR", err)
+		return 1
+	}
+
+	var dbPath string
+	if len(args) == 2 {
+		dbPath = args[1]
+	} else if dbPath, err = getBukuDbPath(); err != nil {
+		log.Println("ERROR", err)
+		return 1
+	}
+
+	dir, err := os.MkdirTemp("", "robuku-replay")
+	if err != nil {
+		log.Println("ERROR", err)
+		return 1
+	}
+	defer os.RemoveAll(dir)
+
+	copyPath := filepath.Join(dir, "bookmarks.db")
+	if err := bukudb.CopyDB(dbPath, copyPath); err != nil {
+		log.Println("ERROR", err)
+		return 1
+	}
+	db, err := bukudb.NewBukuDB(copyPath, dbOptions(config.Load())...)
+	if err != nil {
+		log.Println("ERROR", err)
+		return 1
+	}
+	defer closeDB(db)
+
+	diverged := 0
+	for i, r := range records {
+		api := newEmptyApi()
+		inputhandler.NewInputHandler(db, api).Replay(r)
+
+		fmt.Fprintf(out, "%d: state %s -> %s, selected %q", i+1, r.Before.State, api.Data.State, r.Selected)
+		if api.Data.State != r.After.State {
+			fmt.Fprintf(out, ", traced %s", r.After.State)
+			diverged++
+		}
+		fmt.Fprintln(out)
+	}
+	if diverged > 0 {
+		fmt.Fprintf(out, "%d of %d runs ended in another state than traced\n", diverged, len(records))
+		return 1
+	}
+	return 0
+}
+
+// readTraceFile returns the records of the trace file at path.
+func readTraceFile(path string) ([]inputhandler.TraceRecord, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open trace file: %w", err)
+	}
+	defer f.Close()
+	return inputhandler.ReadTrace(f)
+}
@@ -0,0 +1,92 @@
+package main
+
+import (
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/VannRR/robuku/bukudb"
+	"github.com/VannRR/robuku/inputhandler"
+)
+
+func Test_runReplay(t *testing.T) {
+	dir := t.TempDir()
+	dbPath := filepath.Join(dir, "bookmarks.db")
+	if err := bukudb.InitSchema(dbPath); err != nil {
+		t.Fatal(err)
+	}
+
+	tracePath := filepath.Join(dir, "trace")
+	records := []inputhandler.TraceRecord{
+		{After: inputhandler.Data{State: inputhandler.StateBookmarksSelect}},
+		{
+			Selected:  "https://example.com",
+			Selection: true,
+			Before:    inputhandler.Data{State: inputhandler.StateAddUrlSelect},
+			After:     inputhandler.Data{State: inputhandler.StateAddSelect},
+		},
+		{
+			Selected:  "--> Confirm",
+			Selection: true,
+			Before: inputhandler.Data{
+				State:    inputhandler.StateAddSelect,
+				Bookmark: bukudb.Bookmark{URL: "https://example.com"},
+			},
+			After: inputhandler.Data{State: inputhandler.StateBookmarksSelect},
+		},
+	}
+	for _, r := range records {
+		if err := inputhandler.AppendTrace(tracePath, r); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	var out strings.Builder
+	if code := runReplay([]string{tracePath, dbPath}, &out); code != 0 {
+		t.Fatalf("expected exit code 0, got %d, output:\n%s", code, out.String())
+	}
+	expected := "1: state Null -> BookmarksSelect, selected \"\"\n" +
+		"2: state AddUrlSelect -> AddSelect, selected \"https://example.com\"\n" +
+		"3: state AddSelect -> BookmarksSelect, selected \"--> Confirm\"\n"
+	if out.String() != expected {
+		t.Errorf("expected output %q, got %q", expected, out.String())
+	}
+
+	db, err := bukudb.NewBukuDB(dbPath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer closeDB(db)
+	if db.Len() != 0 {
+		t.Errorf("expected the database to be unchanged, got %d bookmarks", db.Len())
+	}
+}
+
+func Test_runReplay_Diverged(t *testing.T) {
+	dir := t.TempDir()
+	dbPath := filepath.Join(dir, "bookmarks.db")
+	if err := bukudb.InitSchema(dbPath); err != nil {
+		t.Fatal(err)
+	}
+	tracePath := filepath.Join(dir, "trace")
+	err := inputhandler.AppendTrace(tracePath, inputhandler.TraceRecord{
+		After: inputhandler.Data{State: inputhandler.StateHelpSelect},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var out strings.Builder
+	if code := runReplay([]string{tracePath, dbPath}, &out); code != 1 {
+		t.Errorf("expected exit code 1, got %d", code)
+	}
+	if !strings.Contains(out.String(), ", traced HelpSelect\n1 of 1 runs ended in another state than traced") {
+		t.Errorf("expected the divergence to be reported, got '%s'", out.String())
+	}
+
+	for _, args := range [][]string{{}, {filepath.Join(dir, "missing"), dbPath}, {tracePath, filepath.Join(dir, "missing.db")}} {
+		if code := runReplay(args, &out); code != 1 {
+			t.Errorf("expected exit code 1 for %q, got %d", args, code)
+		}
+	}
+}
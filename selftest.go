@@ -0,0 +1,199 @@
+package main
+
+import (
+	"fmt"
+	"io"
+	"log"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/VannRR/robuku/bukudb"
+	"github.com/VannRR/robuku/inputhandler"
+)
+
+// selfTestTimeout is how long --self-test may take in total.
+const selfTestTimeout = time.Second
+
+const (
+	selfTestURL      = "https://robuku.example.com/self-test"
+	selfTestAddedURL = "https://robuku.example.com/added"
+)
+
+// selfTestStep is one check of --self-test against a scratch database.
+type selfTestStep struct {
+	name string
+	run  func(db *bukudb.BukuDB) error
+}
+
+// selfTestSteps run in order, each building on the database the previous
+// ones left behind.
+var selfTestSteps = []selfTestStep{
+	{"db add", func(db *bukudb.BukuDB) error {
+		err := db.Add(bukudb.Bookmark{URL: selfTestURL, Title: "self-test", Tags: []string{"robuku"}})
+		if err != nil {
+			return err
+		}
+		return expectLen(db, 1)
+	}},
+	{"db get", func(db *bukudb.BukuDB) error {
+		b, err := db.Get(1)
+		if err != nil {
+			return err
+		}
+		if b.URL != selfTestURL {
+			return fmt.Errorf("expected url '%s', got '%s'", selfTestURL, b.URL)
+		}
+		return nil
+	}},
+	{"db update", func(db *bukudb.BukuDB) error {
+		if err := db.UpdateTitle(1, "updated"); err != nil {
+			return err
+		}
+		b, err := db.Get(1)
+		if err != nil {
+			return err
+		}
+		if b.Title != "updated" {
+			return fmt.Errorf("expected title 'updated', got '%s'", b.Title)
+		}
+		return nil
+	}},
+	{"db tag", func(db *bukudb.BukuDB) error {
+		if err := db.AddTags(1, []string{"checked"}); err != nil {
+			return err
+		}
+		if err := db.RemoveTags(1, []string{"robuku"}); err != nil {
+			return err
+		}
+		b, err := db.Get(1)
+		if err != nil {
+			return err
+		}
+		if !slices.Equal(b.Tags, []string{"checked"}) {
+			return fmt.Errorf("expected tags [checked], got %v", b.Tags)
+		}
+		return nil
+	}},
+	{"rofi bookmarks", func(db *bukudb.BukuDB) error {
+		api := newEmptyApi()
+		inputhandler.NewInputHandler(db, api).HandleBookmarksShow()
+		if err := expectState(api.Data.State, inputhandler.StateBookmarksSelect); err != nil {
+			return err
+		}
+		for _, e := range api.Entries {
+			if strings.HasPrefix(e.Text, "1. ") {
+				return nil
+			}
+		}
+		return fmt.Errorf("expected an entry of bookmark 1, got %d entries", len(api.Entries))
+	}},
+	{"rofi add", func(db *bukudb.BukuDB) error {
+		api := newEmptyApi()
+		in := inputhandler.NewInputHandler(db, api)
+		api.Data.State = inputhandler.StateAddUrlSelect
+		in.HandleInput(selfTestAddedURL)
+		if err := expectState(api.Data.State, inputhandler.StateAddSelect); err != nil {
+			return err
+		}
+		in.HandleInput("--> Confirm")
+		if err := expectState(api.Data.State, inputhandler.StateBookmarksSelect); err != nil {
+			return err
+		}
+		return expectLen(db, 2)
+	}},
+	{"rofi unknown state", func(db *bukudb.BukuDB) error {
+		api := newEmptyApi()
+		api.Data.State = 255
+		inputhandler.NewInputHandler(db, api).HandleInput("")
+		return expectState(api.Data.State, inputhandler.StateBookmarksSelect)
+	}},
+	{"rofi delete", func(db *bukudb.BukuDB) error {
+		api := newEmptyApi()
+		in := inputhandler.NewInputHandler(db, api)
+		b, err := db.Get(2)
+		if err != nil {
+			return err
+		}
+		api.Data.Bookmark = b
+		api.Data.State = inputhandler.StateDeleteConfirmSelect
+		in.HandleInput("yes")
+		if err := expectState(api.Data.State, inputhandler.StateBookmarksSelect); err != nil {
+			return err
+		}
+		return expectLen(db, 1)
+	}},
+	{"db remove", func(db *bukudb.BukuDB) error {
+		if err := db.Remove(1); err != nil {
+			return err
+		}
+		return expectLen(db, 0)
+	}},
+}
+
+func expectLen(db *bukudb.BukuDB, n int) error {
+	if db.Len() != n {
+		return fmt.Errorf("expected %d bookmarks, got %d", n, db.Len())
+	}
+	return nil
+}
+
+func expectState(got, expected inputhandler.State) error {
+	if got != expected {
+		return fmt.Errorf("expected state %s, got %s", expected, got)
+	}
+	return nil
+}
+
+// runSelfTest runs selfTestSteps against a scratch database without rofi,
+// printing PASS or FAIL for each to out. It returns the exit code.
+func runSelfTest(out io.Writer) int {
+	start := time.Now()
+
+	dir, err := os.MkdirTemp("", "robuku-self-test")
+	if err != nil {
+		log.Println("ERROR", err)
+		return 1
+	}
+	defer os.RemoveAll(dir)
+
+	path := filepath.Join(dir, "bookmarks.db")
+	if err := bukudb.InitSchema(path); err != nil {
+		log.Println("ERROR", err)
+		return 1
+	}
+	db, err := bukudb.NewBukuDB(path)
+	if err != nil {
+		log.Println("ERROR", err)
+		return 1
+	}
+	defer closeDB(db)
+
+	failed := runSelfTestSteps(out, db, selfTestSteps)
+	if elapsed := time.Since(start); elapsed > selfTestTimeout {
+		fmt.Fprintf(out, "FAIL duration: took %s, expected under %s\n",
+			elapsed.Round(time.Millisecond), selfTestTimeout)
+		failed++
+	}
+	if failed > 0 {
+		fmt.Fprintf(out, "%d of the self-test steps failed\n", failed)
+		return 1
+	}
+	return 0
+}
+
+// runSelfTestSteps runs steps in order against db and returns how many failed.
+func runSelfTestSteps(out io.Writer, db *bukudb.BukuDB, steps []selfTestStep) int {
+	failed := 0
+	for _, s := range steps {
+		if err := s.run(db); err != nil {
+			fmt.Fprintf(out, "FAIL %s: %v\n", s.name, err)
+			failed++
+			continue
+		}
+		fmt.Fprintf(out, "PASS %s\n", s.name)
+	}
+	return failed
+}
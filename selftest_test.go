@@ -0,0 +1,52 @@
+package main
+
+import (
+	"errors"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/VannRR/robuku/bukudb"
+)
+
+func Test_runSelfTest(t *testing.T) {
+	var out strings.Builder
+	if code := runSelfTest(&out); code != 0 {
+		t.Fatalf("expected exit code 0, got %d, output:\n%s", code, out.String())
+	}
+
+	lines := strings.Split(strings.TrimSpace(out.String()), "\n")
+	if len(lines) != len(selfTestSteps) {
+		t.Fatalf("expected %d lines, got %d", len(selfTestSteps), len(lines))
+	}
+	for i, l := range lines {
+		if expected := "PASS " + selfTestSteps[i].name; l != expected {
+			t.Errorf("expected '%s', got '%s'", expected, l)
+		}
+	}
+}
+
+func Test_runSelfTestSteps_Fail(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "bookmarks.db")
+	if err := bukudb.InitSchema(path); err != nil {
+		t.Fatal(err)
+	}
+	db, err := bukudb.NewBukuDB(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer closeDB(db)
+
+	steps := []selfTestStep{
+		{"broken", func(*bukudb.BukuDB) error { return errors.New("broken") }},
+		{"fine", func(*bukudb.BukuDB) error { return nil }},
+	}
+	var out strings.Builder
+	if failed := runSelfTestSteps(&out, db, steps); failed != 1 {
+		t.Errorf("expected 1 failed step, got %d", failed)
+	}
+	expected := "FAIL broken: broken\nPASS fine\n"
+	if out.String() != expected {
+		t.Errorf("expected output %q, got %q", expected, out.String())
+	}
+}